@@ -16,7 +16,6 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -35,11 +34,15 @@ import (
 	"github.com/nexora/nexora/internal/aiops"
 	"github.com/nexora/nexora/internal/config"
 	"github.com/nexora/nexora/internal/csync"
+	"github.com/nexora/nexora/internal/history"
 	"github.com/nexora/nexora/internal/message"
+	"github.com/nexora/nexora/internal/modelstats"
 	"github.com/nexora/nexora/internal/permission"
 	"github.com/nexora/nexora/internal/resources"
 	"github.com/nexora/nexora/internal/session"
+	"github.com/nexora/nexora/internal/sessionlog"
 	"github.com/nexora/nexora/internal/stringext"
+	"github.com/nexora/nexora/internal/telemetry"
 )
 
 //go:embed templates/title.md
@@ -75,6 +78,9 @@ type SessionAgent interface {
 	ClearQueue(sessionID string)
 	Summarize(context.Context, string, fantasy.ProviderOptions) error
 	Model() Model
+	// ResumeSession resumes a paused session and returns whether the agent
+	// has its state loaded.
+	ResumeSession(ctx context.Context, sessionID string) (bool, error)
 }
 
 type Model struct {
@@ -107,22 +113,34 @@ type sessionAgent struct {
 	// Background compaction
 	backgroundCompactor *BackgroundCompactor
 
-	// State for loop and drift detection
-	recentCalls       []aiops.ToolCall
-	callCount         int
-	recentActions     []aiops.Action
-	actionCount       int
-	recentCallsLock   sync.Mutex
-	recentActionsLock sync.Mutex
+	// Measured model speeds
+	modelSpeeds modelstats.Service
+
+	// Session log of tool calls, model requests and state transitions
+	sessionLog *sessionlog.Manager
+
+	// Batches similar tool calls of a step into one operation
+	scriptor aiops.Scriptor
+
+	// Judges whether sessions are stuck in a loop or drifting from their
+	// task, and the warnings given to their model
+	loopPolicy   state.LoopPolicy
+	loopWarnings *csync.Map[string, loopWarnings]
 
 	// Session state tracking
 	sessionStates *csync.Map[string, string] // Track session states like "awaiting_continuation"
 
 	// State machines per session
 	stateMachines *csync.Map[string, *state.StateMachine]
+	// Saved state machines, to resume sessions after a restart
+	agentStates session.AgentStateService
+	stateSaveMu sync.Mutex
 
 	// Error recovery system
 	recoveryRegistry *recovery.RecoveryRegistry
+	// Model types recovery rules switched sessions to
+	modelOverrides *csync.Map[string, config.SelectedModelType]
+	workingDir     string
 
 	// Retry tracking for automatic recovery
 	retryQueue *csync.Map[string, *RetryRequest] // sessionID -> retry request
@@ -148,9 +166,17 @@ type SessionAgentOptions struct {
 	Sessions             session.Service
 	Messages             message.Service
 	Tools                []fantasy.AgentTool
-	AIOPS                aiops.Ops            // AIOPS client
-	ResourceMonitor      *resources.Monitor   // Resource monitor for pause/resume
-	BackgroundCompactor  *BackgroundCompactor // Background compactor for idle-time optimization
+	AIOPS                aiops.Ops                 // AIOPS client
+	ResourceMonitor      *resources.Monitor        // Resource monitor for pause/resume
+	BackgroundCompactor  *BackgroundCompactor      // Background compactor for idle-time optimization
+	ModelSpeeds          modelstats.Service        // Records measured model latency and speed
+	SessionLog           *sessionlog.Manager       // Records tool calls, model requests and state transitions
+	AgentStates          session.AgentStateService // Saves state machines to resume sessions after a restart
+	Scriptor             aiops.Scriptor            // Batches similar tool calls of a step
+	LoopDetection        config.AIOPSLoopDetectionConfig
+	Recovery             config.RecoveryConfig
+	RecoveryStats        recovery.StatsService // Persists recovery attempts for statistics
+	WorkingDir           string                // Where recovery rules run their commands
 }
 
 func NewSessionAgent(
@@ -162,7 +188,7 @@ func NewSessionAgent(
 		compactor = NewCompactor(DefaultCompactionConfig(int64(opts.LargeModel.CatwalkCfg.ContextWindow)))
 	}
 
-	return &sessionAgent{
+	a := &sessionAgent{
 		convoMgr:             NewConversationManager(),
 		largeModel:           opts.LargeModel,
 		smallModel:           opts.SmallModel,
@@ -179,12 +205,25 @@ func NewSessionAgent(
 		resourceMonitor:      opts.ResourceMonitor,
 		compactor:            compactor,
 		backgroundCompactor:  opts.BackgroundCompactor,
+		modelSpeeds:          opts.ModelSpeeds,
+		sessionLog:           opts.SessionLog,
+		scriptor:             opts.Scriptor,
 		sessionStates:        csync.NewMap[string, string](),
 		stateMachines:        csync.NewMap[string, *state.StateMachine](),
+		agentStates:          opts.AgentStates,
 		recoveryRegistry:     recovery.NewRecoveryRegistry(),
 		retryQueue:           csync.NewMap[string, *RetryRequest](),
 		toolTimeout:          defaultToolTimeout,
-	}
+		loopWarnings:         csync.NewMap[string, loopWarnings](),
+		modelOverrides:       csync.NewMap[string, config.SelectedModelType](),
+		workingDir:           opts.WorkingDir,
+	}
+	a.loopPolicy = a.newLoopPolicy(opts.LoopDetection)
+	loopStrategy := &recovery.LoopDetectedStrategy{MaxWarnings: opts.LoopDetection.Warnings()}
+	a.recoveryRegistry.RemoveStrategy(loopStrategy.Name())
+	a.recoveryRegistry.AddStrategy(loopStrategy)
+	a.configureRecovery(opts.Recovery, opts.RecoveryStats)
+	return a
 }
 
 // wrapToolsWithTimeout cannot directly wrap fantasy.AgentTool since it's from external package
@@ -242,7 +281,7 @@ func (a *sessionAgent) getToolTimeout(toolName string) time.Duration {
 	case "view", "grep", "ls", "glob":
 		// Read-only operations are fast
 		return defaultToolTimeout
-	case "edit", "multiedit", "write":
+	case "edit", "multiedit", "write", "notebook_edit":
 		// File operations can take some time for large files
 		return 2 * time.Minute
 	default:
@@ -324,16 +363,12 @@ func (a *sessionAgent) getOrCreateStateMachine(sessionID string, ctx context.Con
 		return sm
 	}
 
-	// Create new state machine
-	sm := state.NewStateMachine(state.Config{
-		SessionID: sessionID,
-		Context:   ctx,
-		OnStuck: func(reason string) {
-			slog.Warn("session stuck - loop detected",
-				"session_id", sessionID,
-				"reason", reason,
-			)
-		},
+	// Create new state machine, or restore the saved one
+	sm := a.newStateMachine(state.Config{
+		SessionID:     sessionID,
+		Context:       ctx,
+		LoopPolicy:    a.loopPolicy,
+		OnStateChange: a.onStateChange(sessionID),
 		OnProgress: func(stats state.ProgressStats) {
 			slog.Debug("session progress",
 				"session_id", sessionID,
@@ -356,23 +391,19 @@ func (a *sessionAgent) getOrCreateStateMachine(sessionID string, ctx context.Con
 					"session_id", sessionID,
 					"cpu_percent", usage,
 				)
-				if sm.CanTransitionTo(state.StateResourcePaused) {
-					sm.TransitionTo(state.StateResourcePaused)
-					sm.SetPauseReason(fmt.Sprintf("CPU usage %.1f%% exceeds threshold", usage))
-				}
+				a.pause(context.Background(), sm, state.StateResourcePaused, fmt.Sprintf("CPU usage %.1f%% exceeds threshold", usage))
 			},
 			func(usage uint64) {
 				if sm == nil {
 					return
 				}
+				// usage is the percentage of the memory available to
+				// nexora, which is its cgroup's limit in a container.
 				slog.Warn("Memory usage high, pausing session",
 					"session_id", sessionID,
-					"mem_usage_mb", usage/(1024*1024),
+					"mem_percent", usage,
 				)
-				if sm.CanTransitionTo(state.StateResourcePaused) {
-					sm.TransitionTo(state.StateResourcePaused)
-					sm.SetPauseReason(fmt.Sprintf("Memory usage %.1f%% exceeds threshold", float64(usage)/float64(1024*1024*1024)*100))
-				}
+				a.pause(context.Background(), sm, state.StateResourcePaused, fmt.Sprintf("Memory usage %d%% exceeds threshold", usage))
 			},
 			func(free uint64) {
 				if sm == nil {
@@ -382,10 +413,7 @@ func (a *sessionAgent) getOrCreateStateMachine(sessionID string, ctx context.Con
 					"session_id", sessionID,
 					"disk_free_gb", float64(free)/(1024*1024*1024),
 				)
-				if sm.CanTransitionTo(state.StateResourcePaused) {
-					sm.TransitionTo(state.StateResourcePaused)
-					sm.SetPauseReason(fmt.Sprintf("Disk space low: %.1fGB free", float64(free)/(1024*1024*1024)))
-				}
+				a.pause(context.Background(), sm, state.StateResourcePaused, fmt.Sprintf("Disk space low: %.1fGB free", float64(free)/(1024*1024*1024)))
 			},
 			func(v resources.Violation) {
 				if sm == nil {
@@ -396,11 +424,17 @@ func (a *sessionAgent) getOrCreateStateMachine(sessionID string, ctx context.Con
 					"type", v.Type,
 					"message", v.Message,
 				)
+				// File descriptors and processes have no callback of
+				// their own.
+				if v.Type == resources.ViolationTypeFDs || v.Type == resources.ViolationTypeProc {
+					a.pause(context.Background(), sm, state.StateResourcePaused, v.Message)
+				}
 			},
 		)
 	}
 
 	a.stateMachines.Set(sessionID, sm)
+	a.sessionLog.StartSession(ctx, sessionID, nil)
 	return sm
 }
 
@@ -507,6 +541,25 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	// Get or create state machine for this session
 	sm := a.getOrCreateStateMachine(call.SessionID, ctx)
 
+	// Drift is judged against the latest prompt, which starts the warnings
+	// over
+	sm.SetTask(call.Prompt)
+	a.loopWarnings.Del(call.SessionID)
+	if !isContinuation {
+		// Recovery rules run again for each prompt, which goes back to the
+		// large model if a rule switched the session away from it
+		a.recoveryRegistry.ResetSession(call.SessionID)
+		a.modelOverrides.Del(call.SessionID)
+	}
+
+	// A new prompt resumes a paused session
+	if sm.GetState().IsPaused() {
+		slog.Info("resuming paused session",
+			"session_id", call.SessionID,
+			"pause_reason", sm.GetPauseReason(),
+		)
+	}
+
 	// Transition to processing state
 	if err := sm.TransitionTo(state.StateProcessingPrompt); err != nil {
 		return nil, fmt.Errorf("failed to transition to processing state for session %s: %w", call.SessionID, err)
@@ -528,15 +581,23 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		return nil, fmt.Errorf("session %s: message queued (position %d in queue)", call.SessionID, len(existing))
 	}
 
+	// A recovery rule may have switched the session to another model
+	model := a.sessionModel(call.SessionID)
+	cacheStrategy := a.promptCacheStrategy(model)
 	if len(a.tools) > 0 {
 		// Add Anthropic caching to the last tool.
-		a.tools[len(a.tools)-1].SetProviderOptions(a.getCacheControlOptions())
+		toolCacheOptions := fantasy.ProviderOptions{}
+		if cacheStrategy.tools {
+			toolCacheOptions = a.getCacheControlOptions()
+		}
+		a.tools[len(a.tools)-1].SetProviderOptions(toolCacheOptions)
 	}
 
+	batcher := newToolBatcher(a.scriptor)
 	agent := fantasy.NewAgent(
-		a.largeModel.Model,
+		model.Model,
 		fantasy.WithSystemPrompt(a.systemPrompt),
-		fantasy.WithTools(a.tools...),
+		fantasy.WithTools(batcher.wrap(a.tools)...),
 	)
 
 	currentSession, err := a.sessions.Get(ctx, call.SessionID)
@@ -583,6 +644,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 
 	genCtx, cancel := context.WithCancel(ctx)
 	a.activeRequests.Set(call.SessionID, cancel)
+	genCtx, turn := telemetry.StartTurn(genCtx, call.SessionID, model.ModelCfg.Provider, model.ModelCfg.Model)
 
 	defer cancel()
 	defer a.activeRequests.Del(call.SessionID)
@@ -595,7 +657,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		}
 	}
 
-	history, files := a.preparePrompt(msgs, call.Attachments...)
+	promptHistory, files := a.preparePrompt(msgs, call.Attachments...)
 
 	startTime := time.Now()
 	a.eventPromptSent(call.SessionID)
@@ -613,10 +675,24 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 
 	var currentAssistant *message.Message
 	var shouldSummarize bool
+	// Files attached to tool results, by tool call ID.
+	toolAttachments := csync.NewMap[string, []message.BinaryContent]()
+	// What recovery did after tool calls of this turn, by tool call ID.
+	recoveryNotes := csync.NewMap[string, string]()
+	for _, msg := range msgs {
+		for _, toolResult := range msg.ToolResults() {
+			if len(toolResult.Attachments) > 0 {
+				toolAttachments.Set(toolResult.ToolCallID, toolResult.Attachments)
+			}
+		}
+	}
+	// The running tools, by tool call ID.
+	toolStarts := csync.NewMap[string, startedTool]()
+	stepTimer := newStepTimer()
 	result, err := agent.Stream(genCtx, fantasy.AgentStreamCall{
 		Prompt:           call.Prompt,
 		Files:            files,
-		Messages:         history,
+		Messages:         promptHistory,
 		ProviderOptions:  call.ProviderOptions,
 		MaxOutputTokens:  &maxOutputTokens,
 		TopP:             call.TopP,
@@ -629,6 +705,8 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			for i := range prepared.Messages {
 				prepared.Messages[i].ProviderOptions = nil
 			}
+			prepared.Messages = attachToolResultFiles(prepared.Messages, toolAttachments)
+			prepared.Messages = attachRecoveryNotes(prepared.Messages, recoveryNotes)
 
 			// Only process queued messages if this is NOT an auto-continuation
 			// Auto-continuation has the special prompt "CONTINUE_AFTER_TOOL_EXECUTION"
@@ -645,23 +723,10 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 
 				}
 			}
-			lastSystemRoleInx := 0
-			systemMessageUpdated := false
-			for i, msg := range prepared.Messages {
-				// Only add cache control to the last message.
-				if msg.Role == fantasy.MessageRoleSystem {
-					lastSystemRoleInx = i
-				} else if !systemMessageUpdated {
-					prepared.Messages[lastSystemRoleInx].ProviderOptions = a.getCacheControlOptions()
-					systemMessageUpdated = true
-				}
-				// Than add cache control to the last 2 messages.
-				if i > len(prepared.Messages)-3 {
-					prepared.Messages[i].ProviderOptions = a.getCacheControlOptions()
-				}
-			}
+			prepared.Messages = a.workaroundProviderMediaLimitations(prepared.Messages, model)
+			applyCacheBreakpoints(prepared.Messages, cacheStrategy, a.getCacheControlOptions())
 
-			if promptPrefix := a.promptPrefix(); promptPrefix != "" {
+			if promptPrefix := a.promptPrefix(model); promptPrefix != "" {
 				prepared.Messages = append([]fantasy.Message{fantasy.NewSystemMessage(promptPrefix)}, prepared.Messages...)
 			}
 
@@ -669,33 +734,38 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			assistantMsg, err = a.messages.Create(callContext, call.SessionID, message.CreateMessageParams{
 				Role:     message.Assistant,
 				Parts:    []message.ContentPart{},
-				Model:    a.largeModel.ModelCfg.Model,
-				Provider: a.largeModel.ModelCfg.Provider,
+				Model:    model.ModelCfg.Model,
+				Provider: model.ModelCfg.Provider,
 			})
 			if err != nil {
 				return callContext, prepared, err
 			}
 			callContext = context.WithValue(callContext, tools.MessageIDContextKey, assistantMsg.ID)
-			callContext = context.WithValue(callContext, tools.SupportsImagesContextKey, a.largeModel.CatwalkCfg.SupportsImages)
-			callContext = context.WithValue(callContext, tools.ModelNameContextKey, a.largeModel.CatwalkCfg.Name)
+			callContext = history.WithMessageID(callContext, assistantMsg.ID)
+			callContext = context.WithValue(callContext, tools.SupportsImagesContextKey, model.CatwalkCfg.SupportsImages)
+			callContext = context.WithValue(callContext, tools.ModelNameContextKey, model.CatwalkCfg.Name)
 			currentAssistant = &assistantMsg
 
 			// Set tool_choice for Cerebras/ZAI when tools are available
 			// This ensures tool calling works properly with GPT OSS models
-			if (a.largeModel.ModelCfg.Provider == "cerebras" || a.largeModel.ModelCfg.Provider == string(catwalk.InferenceProviderZAI)) && len(prepared.Tools) > 0 {
+			if (model.ModelCfg.Provider == "cerebras" || model.ModelCfg.Provider == string(catwalk.InferenceProviderZAI)) && len(prepared.Tools) > 0 {
 				toolChoice := fantasy.ToolChoiceAuto
 				prepared.ToolChoice = &toolChoice
-			} else if (a.largeModel.ModelCfg.Provider == "cerebras" || a.largeModel.ModelCfg.Provider == string(catwalk.InferenceProviderZAI)) && len(prepared.Tools) == 0 {
+			} else if (model.ModelCfg.Provider == "cerebras" || model.ModelCfg.Provider == string(catwalk.InferenceProviderZAI)) && len(prepared.Tools) == 0 {
 				prepared.ToolChoice = nil // Explicitly set to nil
 			}
 
+			batcher.startStep()
+			stepTimer.Start()
 			return callContext, prepared, err
 		},
 		OnReasoningStart: func(id string, reasoning fantasy.ReasoningContent) error {
+			stepTimer.FirstToken()
 			currentAssistant.AppendReasoningContent(reasoning.Text)
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
 		OnReasoningDelta: func(id string, text string) error {
+			stepTimer.FirstToken()
 			currentAssistant.AppendReasoningContent(text)
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
@@ -720,6 +790,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
 		OnTextDelta: func(id string, text string) error {
+			stepTimer.FirstToken()
 			// Strip leading newline from initial text content. This is is
 			// particularly important in non-interactive mode where leading
 			// newlines are very visible.
@@ -731,6 +802,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
 		OnToolInputStart: func(id string, toolName string) error {
+			stepTimer.FirstToken()
 			toolCall := message.ToolCall{
 				ID:               id,
 				Name:             toolName,
@@ -741,6 +813,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
 		OnRetry: func(err *fantasy.ProviderError, delay time.Duration) {
+			stepTimer.Retry(delay)
 			// Log the retry attempt
 			slog.Warn("Provider request failed, retrying",
 				"error", err.Error(),
@@ -764,7 +837,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		OnToolCall: func(tc fantasy.ToolCallContent) error {
 			// Fix Mistral tool call ID format (9 alphanumeric chars)
 			sanitizedID := tc.ToolCallID
-			if a.largeModel.ModelCfg.Provider == "mistral" || a.largeModel.ModelCfg.Provider == "mistral-native" {
+			if model.ModelCfg.Provider == "mistral" || model.ModelCfg.Provider == "mistral-native" {
 				sanitizedID = utils.SanitizeToolCallID(tc.ToolCallID, "mistral")
 			}
 
@@ -775,6 +848,8 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				ProviderExecuted: false,
 				Finished:         true,
 			}
+			toolStarts.Set(tc.ToolCallID, startedTool{start: time.Now(), input: tc.Input})
+			batcher.addCall(tc)
 			currentAssistant.AddToolCall(toolCall)
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
@@ -783,7 +858,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			sm := a.getOrCreateStateMachine(currentAssistant.SessionID, genCtx)
 
 			// Extract tool details for tracking
-			var errorMsg string
+			var errorMsg, output, input string
 			var toolError error
 
 			switch result.Result.GetType() {
@@ -792,23 +867,39 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 					errorMsg = r.Error.Error()
 					toolError = r.Error
 				}
+			case fantasy.ToolResultContentTypeText:
+				if r, ok := fantasy.AsToolResultOutputType[fantasy.ToolResultOutputContentText](result.Result); ok {
+					output = r.Text
+				}
+			}
+			if started, ok := toolStarts.Take(result.ToolCallID); ok {
+				input = started.input
+				telemetry.RecordToolCall(genCtx, telemetry.ToolCall{
+					ID:       result.ToolCallID,
+					Name:     result.ToolName,
+					Start:    started.start,
+					Duration: time.Since(started.start),
+					Err:      toolError,
+				})
+				a.logToolCall(genCtx, currentAssistant.SessionID, result, started, toolError)
 			}
 
-			// Attempt error recovery if there's an error
-			if toolError != nil {
+			// Attempt error recovery if there's an error, or a command that
+			// a recovery rule handles failed
+			var recoveryNote string
+			if failure := a.failureForRecovery(toolError, result.ToolName, input, output); failure != nil {
 				execCtx := &state.AgentExecutionContext{
 					SessionID: currentAssistant.SessionID,
 				}
-				recoverableErr := a.wrapErrorForRecovery(toolError, result.ToolName, "", "", "")
-				recoveryErr := a.recoveryRegistry.AttemptRecovery(genCtx, recoverableErr, execCtx)
+				recoveryErr := a.recoveryRegistry.AttemptRecovery(genCtx, failure, execCtx)
 				if recoveryErr != nil {
 					slog.Error("Error recovery failed",
 						"session_id", currentAssistant.SessionID,
 						"tool", result.ToolName,
-						"original_error", toolError.Error(),
+						"original_error", failure.Error(),
 						"recovery_error", recoveryErr.Error(),
 					)
-				} else {
+				} else if toolError != nil {
 					slog.Info("Error recovery succeeded - scheduling retry",
 						"session_id", currentAssistant.SessionID,
 						"tool", result.ToolName,
@@ -823,206 +914,43 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 					a.retryQueue.Set(currentAssistant.SessionID, retryReq)
 					errorMsg = "" // Clear error to allow continuation
 				}
+				recoveryNote = execCtx.RecoveryNote
 			}
 
-			// Record tool call in state machine
-			// Note: We don't have file_path/command from ToolResultContent, but tool name is tracked
-			success := errorMsg == ""
-			sm.RecordToolCall(result.ToolName, "", "", errorMsg, success)
-
-			// Check for stuck condition after each tool call
-			if stuck, reason := sm.IsStuck(); stuck {
-				slog.Warn("session stuck - halting",
-					"session_id", currentAssistant.SessionID,
-					"reason", reason,
-					"tool_calls", sm.GetToolCallCount(),
-				)
-
-				// Differentiate between hard errors and "no progress" warnings
-				isErrorLoop := strings.Contains(strings.ToLower(reason), "error") || strings.Contains(strings.ToLower(reason), "oscillating")
-				isNoProgress := strings.Contains(strings.ToLower(reason), "no meaningful progress")
-
-				// Create system message about being stuck
-				if isErrorLoop {
-					// Hard error loop - halt execution
-					_, _ = a.messages.Create(genCtx, currentAssistant.SessionID, message.CreateMessageParams{
-						Role: message.System,
-						Parts: []message.ContentPart{
-							message.TextContent{
-								Text: fmt.Sprintf("🛑 Loop detected: %s\n\nStopping execution to prevent infinite loop. Please review the error and try a different approach.", reason),
-							},
-						},
-					})
-					return fmt.Errorf("loop detected: %s", reason)
-				} else if isNoProgress {
-					// Soft warning for no progress - don't halt, just warn
-					_, _ = a.messages.Create(genCtx, currentAssistant.SessionID, message.CreateMessageParams{
-						Role: message.System,
-						Parts: []message.ContentPart{
-							message.TextContent{
-								Text: fmt.Sprintf("⚠️ Progress warning: %s\n\nIf you're exploring or gathering information, you can continue. If you're stuck, try a different approach.", reason),
-							},
-						},
-					})
-					// Don't return error - let agent continue
-				}
-			}
-
-			// Track tool call for loop detection (existing AIOPS code)
-			if a.aiops != nil {
-				var content string
-				var aiopsErrorMsg string
-
-				switch result.Result.GetType() {
-				case fantasy.ToolResultContentTypeText:
-					if r, ok := fantasy.AsToolResultOutputType[fantasy.ToolResultOutputContentText](result.Result); ok {
-						content = r.Text
-					}
-				case fantasy.ToolResultContentTypeError:
-					if r, ok := fantasy.AsToolResultOutputType[fantasy.ToolResultOutputContentError](result.Result); ok {
-						aiopsErrorMsg = r.Error.Error()
-					}
-				case fantasy.ToolResultContentTypeMedia:
-					if r, ok := fantasy.AsToolResultOutputType[fantasy.ToolResultOutputContentMedia](result.Result); ok {
-						content = fmt.Sprintf("Loaded %s content", r.MediaType)
-					}
-				}
-
-				if aiopsErrorMsg != "" {
-					content = aiopsErrorMsg // Track error messages for loop detection
-				}
-
-				// Convert to AIOPS ToolCall for tracking
-				aiopsCall := aiops.ToolCall{
-					ID:        result.ToolCallID,
-					Name:      result.ToolName,
-					Result:    content,
-					Error:     aiopsErrorMsg,
-					Timestamp: time.Now(),
-				}
-				if len(aiopsCall.Result) > 10000 {
-					aiopsCall.Result = aiopsCall.Result[:10000] + "..."
-				}
-
-				// Add to recent calls
-				a.recentCallsLock.Lock()
-				if len(a.recentCalls) >= 10 {
-					// Keep only last 10 calls
-					copy(a.recentCalls, a.recentCalls[1:])
-					a.recentCalls[len(a.recentCalls)-1] = aiopsCall
-				} else {
-					a.recentCalls = append(a.recentCalls, aiopsCall)
-				}
-
-				// Increment call count
-				a.callCount++
-
-				// Check for loops every 5 tool calls
-				if a.callCount%5 == 0 && len(a.recentCalls) >= 5 {
-					go func(calls []aiops.ToolCall, sessionID string, agentCtx context.Context) {
-						detectCtx, cancel := context.WithTimeout(agentCtx, 2*time.Second)
-						defer cancel()
-						detection, err := a.aiops.DetectLoop(detectCtx, calls)
-						if err != nil {
-							slog.Debug("Loop detection failed", "error", err, "session_id", sessionID)
-							return
-						}
-						if detection.IsLooping {
-							// Create a system message about the loop
-							_, createErr := a.messages.Create(genCtx, currentAssistant.SessionID, message.CreateMessageParams{
-								Role: message.System,
-								Parts: []message.ContentPart{
-									message.TextContent{
-										Text: fmt.Sprintf("🔁 Loop detected: %s. Suggestion: %s", detection.Reason, detection.Suggestion),
-									},
-								},
-							})
-							if createErr != nil {
-								slog.Error("Failed to create loop detection message", "error", createErr, "session_id", sessionID)
-							}
-						}
-					}(a.recentCalls, currentAssistant.SessionID, genCtx)
+			// Record tool call in state machine, and check whether the
+			// session is stuck
+			a.recordToolCall(genCtx, sm, result.ToolName, input, output, errorMsg)
+			a.saveState(genCtx, sm)
+			if verdict, ok := sm.TakeVerdict(); ok && verdict.Stuck() {
+				if err := a.handleLoopVerdict(genCtx, sm, currentAssistant.SessionID, verdict); err != nil {
+					return err
 				}
-
-				// Track actions for drift detection
-				a.recentActions = append(a.recentActions, aiops.Action{
-					Description: fmt.Sprintf("Tool call: %s", result.ToolName),
-					ToolCalls: []aiops.ToolCall{
-						{
-							ID:        result.ToolCallID,
-							Name:      result.ToolName,
-							Params:    map[string]any{}, // We don't have access to the original params in ToolResultContent
-							Result:    content,
-							Error:     aiopsErrorMsg,
-							Timestamp: time.Now(),
-						},
-					},
-					Timestamp: time.Now(),
-				})
-				// Keep only the last 20 actions
-				if len(a.recentActions) > 20 {
-					a.recentActions = a.recentActions[len(a.recentActions)-20:]
-				}
-				a.actionCount++
-				// Check for drift every 10 tool calls
-				if a.actionCount%10 == 0 && a.aiops != nil {
-					go func(actions []aiops.Action, sessionID string, agentCtx context.Context) {
-						detectCtx, cancel := context.WithTimeout(agentCtx, 2*time.Second)
-						defer cancel()
-
-						// Get the original task from the first message in the session
-						originalTask := ""
-						if msgs, err := a.messages.List(detectCtx, sessionID); err == nil && len(msgs) > 0 {
-							// Extract task from the first user message
-							for _, msg := range msgs {
-								if msg.Role == message.User {
-									for _, part := range msg.Parts {
-										if tc, ok := part.(message.TextContent); ok {
-											originalTask = tc.Text
-											break
-										}
-									}
-									if originalTask != "" {
-										break
-									}
-								}
-							}
-						}
-
-						if originalTask != "" {
-							drift, err := a.aiops.DetectDrift(detectCtx, originalTask, actions)
-							if err != nil {
-								slog.Debug("Drift detection failed", "error", err, "session_id", sessionID)
-								return
-							}
-							if drift.IsDrifting {
-								// Create a warning message about task drift
-								_, createErr := a.messages.Create(agentCtx, sessionID, message.CreateMessageParams{
-									Role: message.System,
-									Parts: []message.ContentPart{
-										message.TextContent{
-											Text: fmt.Sprintf("⚠️ Task drift detected: %s. Suggestion: %s", drift.Reason, drift.Suggestion),
-										},
-									},
-								})
-								if createErr != nil {
-									slog.Error("Failed to create drift detection message", "error", createErr, "session_id", sessionID)
-								}
-							}
-						}
-					}(a.recentActions, currentAssistant.SessionID, genCtx)
-				}
-				a.recentCallsLock.Unlock()
 			}
 
 			toolResult := a.convertToToolResult(result)
+			if recoveryNote != "" {
+				// The model sees what recovery did in the next step, and
+				// with the result in later turns
+				recoveryNotes.Set(result.ToolCallID, recoveryNote)
+				toolResult.Content += "\n\n" + formatRecoveryNote(recoveryNote)
+			}
+			if len(toolResult.Attachments) > 0 {
+				toolAttachments.Set(result.ToolCallID, toolResult.Attachments)
+			}
 			_, createMsgErr := a.messages.Create(genCtx, currentAssistant.SessionID, message.CreateMessageParams{
 				Role: message.Tool,
 				Parts: []message.ContentPart{
 					toolResult,
 				},
 			})
-			return createMsgErr
+			if createMsgErr != nil {
+				return createMsgErr
+			}
+			if sm.GetState() == state.StateRecoveryPaused {
+				// A recovery rule paused the session
+				return fmt.Errorf("session paused: %s", sm.GetPauseReason())
+			}
+			return nil
 		},
 		OnStepFinish: func(stepResult fantasy.StepResult) error {
 			finishReason := message.FinishReasonUnknown
@@ -1035,7 +963,11 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				finishReason = message.FinishReasonToolUse
 			}
 			currentAssistant.AddFinish(finishReason, "", "")
-			a.updateSessionUsage(a.largeModel, &currentSession, stepResult.Usage, a.openrouterCost(stepResult.ProviderMetadata))
+			usage := normalizeCacheUsage(model.Model.Provider(), stepResult.Usage, stepResult.ProviderMetadata)
+			currentAssistant.SetUsage(messageTokenUsage(usage))
+			a.recordModelSpeed(genCtx, stepTimer, model, usage.OutputTokens)
+			cost := a.updateSessionUsage(model, &currentSession, usage, a.openrouterCost(stepResult.ProviderMetadata))
+			a.recordLLMCall(genCtx, currentSession.ID, stepTimer, model, usage, cost, string(stepResult.FinishReason))
 			_, sessionErr := a.sessions.Save(genCtx, currentSession)
 			if sessionErr != nil {
 				return sessionErr
@@ -1044,7 +976,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		},
 		StopWhen: []fantasy.StopCondition{
 			func(steps []fantasy.StepResult) bool {
-				cw := int64(a.largeModel.CatwalkCfg.ContextWindow)
+				cw := int64(model.CatwalkCfg.ContextWindow)
 				tokens := currentSession.CompletionTokens + currentSession.PromptTokens
 				remaining := cw - tokens
 				var threshold int64
@@ -1079,6 +1011,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			},
 		},
 	})
+	turn.End(err)
 
 	a.eventPromptResponded(call.SessionID, time.Since(startTime).Truncate(time.Second))
 
@@ -1323,13 +1256,14 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	// Provider-specific summarization model selection:
 	// Cerebras GLM-4.6 struggles with summarization at 180K tokens (90% threshold),
 	// so we use the smaller, more reliable llama3.1-8b model for summarization.
-	// Other providers continue using their large models as before.
+	// Other providers use the model of the session.
 
 	// Use smaller model for Cerebras summarization (more reliable at 180K)
 	var agent fantasy.Agent
 	var summarizationModel Model
 	var summarizationOpts fantasy.ProviderOptions
-	if strings.Contains(a.largeModel.Model.Model(), "glm-4.6") || strings.Contains(a.largeModel.Model.Provider(), "cerebras") {
+	sessionModel := a.sessionModel(sessionID)
+	if strings.Contains(sessionModel.Model.Model(), "glm-4.6") || strings.Contains(sessionModel.Model.Provider(), "cerebras") {
 		agent = fantasy.NewAgent(a.smallModel.Model,
 			fantasy.WithSystemPrompt(string(summaryPrompt)),
 		)
@@ -1337,10 +1271,10 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		// Clear provider options when switching models - they may not be compatible
 		summarizationOpts = fantasy.ProviderOptions{}
 	} else {
-		agent = fantasy.NewAgent(a.largeModel.Model,
+		agent = fantasy.NewAgent(sessionModel.Model,
 			fantasy.WithSystemPrompt(string(summaryPrompt)),
 		)
-		summarizationModel = a.largeModel
+		summarizationModel = sessionModel
 		summarizationOpts = opts
 	}
 	summaryMessage, err := a.messages.Create(ctx, sessionID, message.CreateMessageParams{
@@ -1421,7 +1355,7 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		}
 	}
 
-	a.updateSessionUsage(a.largeModel, &currentSession, resp.TotalUsage, openrouterCost)
+	a.updateSessionUsage(summarizationModel, &currentSession, normalizeStepsUsage(summarizationModel.Model.Provider(), resp.Steps, resp.TotalUsage), openrouterCost)
 
 	// Just in case, get just the last usage info.
 	usage := resp.Response.Usage
@@ -1433,9 +1367,6 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 }
 
 func (a *sessionAgent) getCacheControlOptions() fantasy.ProviderOptions {
-	if t, _ := strconv.ParseBool(os.Getenv("NEXORA_DISABLE_ANTHROPIC_CACHE")); t {
-		return fantasy.ProviderOptions{}
-	}
 	return fantasy.ProviderOptions{
 		anthropic.Name: &anthropic.ProviderCacheControlOptions{
 			CacheControl: anthropic.CacheControl{Type: "ephemeral"},
@@ -1550,6 +1481,7 @@ func (a *sessionAgent) generateTitle(ctx context.Context, session *session.Sessi
 		fantasy.WithSystemPrompt(string(titlePrompt)),
 	)
 
+	stepTimer := newStepTimer()
 	resp, err := agent.Stream(ctx, fantasy.AgentStreamCall{
 		Prompt:          fmt.Sprintf("Generate a short title (max 50 chars) for this message:\n\n%s", prompt),
 		MaxOutputTokens: &maxOutput,
@@ -1558,8 +1490,20 @@ func (a *sessionAgent) generateTitle(ctx context.Context, session *session.Sessi
 			if a.systemPromptPrefix != "" {
 				prepared.Messages = append([]fantasy.Message{fantasy.NewSystemMessage(a.systemPromptPrefix)}, prepared.Messages...)
 			}
+			stepTimer.Start()
 			return callContext, prepared, nil
 		},
+		OnReasoningDelta: func(id, text string) error {
+			stepTimer.FirstToken()
+			return nil
+		},
+		OnTextDelta: func(id, text string) error {
+			stepTimer.FirstToken()
+			return nil
+		},
+		OnRetry: func(err *fantasy.ProviderError, delay time.Duration) {
+			stepTimer.Retry(delay)
+		},
 	})
 	if err != nil {
 		slog.Error("error generating title, using prompt fallback",
@@ -1664,7 +1608,8 @@ func (a *sessionAgent) generateTitle(ctx context.Context, session *session.Sessi
 		}
 	}
 
-	a.updateSessionUsage(a.smallModel, session, resp.TotalUsage, openrouterCost)
+	a.recordModelSpeed(ctx, stepTimer, a.smallModel, resp.TotalUsage.OutputTokens)
+	a.updateSessionUsage(a.smallModel, session, normalizeStepsUsage(a.smallModel.Model.Provider(), resp.Steps, resp.TotalUsage), openrouterCost)
 	_, saveErr := a.sessions.Save(ctx, *session)
 	if saveErr != nil {
 		slog.Error("failed to save session title & usage",
@@ -1690,27 +1635,29 @@ func (a *sessionAgent) openrouterCost(metadata fantasy.ProviderMetadata) *float6
 	return &opts.Usage.Cost
 }
 
-func (a *sessionAgent) updateSessionUsage(model Model, session *session.Session, usage fantasy.Usage, overrideCost *float64) {
+// updateSessionUsage adds usage to session and returns its cost.
+func (a *sessionAgent) updateSessionUsage(model Model, session *session.Session, usage fantasy.Usage, overrideCost *float64) float64 {
 	modelConfig := model.CatwalkCfg
 	cost := modelConfig.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
 		modelConfig.CostPer1MOutCached/1e6*float64(usage.CacheReadTokens) +
 		modelConfig.CostPer1MIn/1e6*float64(usage.InputTokens) +
 		modelConfig.CostPer1MOut/1e6*float64(usage.OutputTokens)
 
-	if a.isClaudeCode() {
+	if a.isClaudeCode(model) {
 		cost = 0
 	}
 
 	a.eventTokensUsed(session.ID, model, usage, cost)
 
 	if overrideCost != nil {
-		session.Cost += *overrideCost
-	} else {
-		session.Cost += cost
+		cost = *overrideCost
 	}
+	session.Cost += cost
 
 	session.CompletionTokens = usage.OutputTokens + usage.CacheReadTokens
 	session.PromptTokens = usage.InputTokens + usage.CacheCreationTokens
+	addCacheUsage(modelConfig, session, usage)
+	return cost
 }
 
 func (a *sessionAgent) Cancel(sessionID string) {
@@ -1795,25 +1742,33 @@ func (a *sessionAgent) Model() Model {
 	return a.largeModel
 }
 
-func (a *sessionAgent) promptPrefix() string {
-	if a.isClaudeCode() {
+func (a *sessionAgent) promptPrefix(model Model) string {
+	if a.isClaudeCode(model) {
 		return "You are Nexora, an AI-native terminal application."
 	}
 	return a.systemPromptPrefix
 }
 
-func (a *sessionAgent) isClaudeCode() bool {
+func (a *sessionAgent) isClaudeCode(model Model) bool {
 	cfg := config.Get()
-	pc, ok := cfg.Providers.Get(a.largeModel.ModelCfg.Provider)
+	pc, ok := cfg.Providers.Get(model.ModelCfg.Provider)
 	return ok && pc.ID == string(catwalk.InferenceProviderAnthropic) && pc.OAuthToken != nil
 }
 
 // convertToToolResult converts a fantasy tool result to a message tool result.
 func (a *sessionAgent) convertToToolResult(result fantasy.ToolResultContent) message.ToolResult {
+	metadata, attachments := tools.SplitResponseAttachments(result.ClientMetadata)
 	baseResult := message.ToolResult{
 		ToolCallID: result.ToolCallID,
 		Name:       result.ToolName,
-		Metadata:   result.ClientMetadata,
+		Metadata:   metadata,
+	}
+	for _, attachment := range attachments {
+		baseResult.Attachments = append(baseResult.Attachments, message.BinaryContent{
+			Path:     fmt.Sprintf("tool-result-%s", result.ToolCallID),
+			MIMEType: attachment.MIMEType,
+			Data:     attachment.Data,
+		})
 	}
 
 	switch result.Result.GetType() {
@@ -1841,6 +1796,37 @@ func (a *sessionAgent) convertToToolResult(result fantasy.ToolResultContent) mes
 	return baseResult
 }
 
+// attachToolResultFiles inserts a user message carrying the files attached to
+// tool results after each run of tool messages. Providers expect the results
+// of all tool calls of a step right after the assistant message, so the files
+// only follow once the run is complete.
+func attachToolResultFiles(messages []fantasy.Message, attachments *csync.Map[string, []message.BinaryContent]) []fantasy.Message {
+	if attachments.Len() == 0 {
+		return messages
+	}
+
+	result := make([]fantasy.Message, 0, len(messages)+1)
+	var files []message.BinaryContent
+	for i, msg := range messages {
+		result = append(result, msg)
+		if msg.Role != fantasy.MessageRoleTool {
+			continue
+		}
+		for _, part := range msg.Content {
+			if toolResult, ok := fantasy.AsMessagePart[fantasy.ToolResultPart](part); ok {
+				if attached, ok := attachments.Get(toolResult.ToolCallID); ok {
+					files = append(files, attached...)
+				}
+			}
+		}
+		if len(files) > 0 && (i+1 == len(messages) || messages[i+1].Role != fantasy.MessageRoleTool) {
+			result = append(result, message.ToolAttachmentsMessage(files))
+			files = nil
+		}
+	}
+	return result
+}
+
 // workaroundProviderMediaLimitations converts media content in tool results to
 // user messages for providers that don't natively support images in tool results.
 //
@@ -1858,16 +1844,21 @@ func (a *sessionAgent) convertToToolResult(result fantasy.ToolResultContent) mes
 // Anthropic and Bedrock support images natively in tool results, so we skip
 // this workaround for them.
 //
+// Files attached to user messages, including the ones moved out of tool
+// results, are then checked against what the provider accepts: text is
+// inlined, and images or PDFs the provider can't take or that are over its
+// size limits are replaced by a note (see limitPromptFiles).
+//
 // Example transformation:
 //
 //	BEFORE: [tool result: image data]
 //	AFTER:  [tool result: "Image loaded - see attached"], [user: image attachment]
-func (a *sessionAgent) workaroundProviderMediaLimitations(messages []fantasy.Message) []fantasy.Message {
-	providerSupportsMedia := a.largeModel.ModelCfg.Provider == string(catwalk.InferenceProviderAnthropic) ||
-		a.largeModel.ModelCfg.Provider == string(catwalk.InferenceProviderBedrock)
+func (a *sessionAgent) workaroundProviderMediaLimitations(messages []fantasy.Message, model Model) []fantasy.Message {
+	providerSupportsMedia := model.ModelCfg.Provider == string(catwalk.InferenceProviderAnthropic) ||
+		model.ModelCfg.Provider == string(catwalk.InferenceProviderBedrock)
 
 	if providerSupportsMedia {
-		return messages
+		return limitPromptFiles(messages, model.ModelCfg.Provider, model.CatwalkCfg.SupportsImages)
 	}
 
 	convertedMessages := make([]fantasy.Message, 0, len(messages))
@@ -1927,7 +1918,7 @@ func (a *sessionAgent) workaroundProviderMediaLimitations(messages []fantasy.Mes
 		}
 	}
 
-	return convertedMessages
+	return limitPromptFiles(convertedMessages, model.ModelCfg.Provider, model.CatwalkCfg.SupportsImages)
 }
 
 // shouldContinueAfterTool checks if the conversation should continue based on state
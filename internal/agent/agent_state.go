@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"github.com/nexora/nexora/internal/agent/state"
+)
+
+// newStateMachine creates the state machine of a session, resuming from its
+// saved state when there is one.
+func (a *sessionAgent) newStateMachine(cfg state.Config) *state.StateMachine {
+	if a.agentStates == nil {
+		return state.NewStateMachine(cfg)
+	}
+	saved, err := a.agentStates.Get(cfg.Context, cfg.SessionID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Warn("failed to load agent state, starting fresh",
+				"session_id", cfg.SessionID,
+				"error", err,
+			)
+		}
+		return state.NewStateMachine(cfg)
+	}
+	sm, err := state.RestoreStateMachine(cfg, saved.Snapshot)
+	if err != nil {
+		slog.Warn("failed to restore agent state, starting fresh",
+			"session_id", cfg.SessionID,
+			"error", err,
+		)
+		return state.NewStateMachine(cfg)
+	}
+	slog.Info("restored agent state",
+		"session_id", cfg.SessionID,
+		"state", sm.GetState().String(),
+		"pause_reason", sm.GetPauseReason(),
+		"tool_calls", sm.GetToolCallCount(),
+	)
+	return sm
+}
+
+// onStateChange returns the state change callback of a session's state
+// machine, which logs the transition and saves the state.
+func (a *sessionAgent) onStateChange(sessionID string) func(from, to state.AgentState) {
+	logTransition := a.logStateTransitions(sessionID)
+	return func(from, to state.AgentState) {
+		if logTransition != nil {
+			logTransition(from, to)
+		}
+		if sm, ok := a.stateMachines.Get(sessionID); ok {
+			a.saveState(context.Background(), sm)
+		}
+	}
+}
+
+// saveState saves the state machine of a session so that it survives a
+// restart.
+func (a *sessionAgent) saveState(ctx context.Context, sm *state.StateMachine) {
+	if a.agentStates == nil {
+		return
+	}
+	// State changes are saved from their own goroutines; serializing them
+	// keeps an older snapshot from overwriting a newer one.
+	a.stateSaveMu.Lock()
+	defer a.stateSaveMu.Unlock()
+	snapshot := sm.Snapshot()
+	if _, err := a.agentStates.Save(ctx, snapshot); err != nil {
+		slog.Warn("failed to save agent state",
+			"session_id", snapshot.SessionID,
+			"error", err,
+		)
+	}
+}
+
+// pause pauses the session of sm for reason and saves it.
+func (a *sessionAgent) pause(ctx context.Context, sm *state.StateMachine, paused state.AgentState, reason string) {
+	if !sm.CanTransitionTo(paused) {
+		return
+	}
+	if err := sm.Pause(paused, reason); err != nil {
+		slog.Warn("failed to pause session", "state", paused.String(), "error", err)
+		return
+	}
+	a.saveState(ctx, sm)
+}
+
+// ResumeSession resumes the paused session if its state machine is loaded,
+// and returns whether it is.
+func (a *sessionAgent) ResumeSession(ctx context.Context, sessionID string) (bool, error) {
+	sm, ok := a.stateMachines.Get(sessionID)
+	if !ok {
+		return false, nil
+	}
+	if err := sm.Resume(); err != nil {
+		return true, err
+	}
+	a.loopWarnings.Del(sessionID)
+	slog.Info("resumed paused session", "session_id", sessionID)
+	a.saveState(ctx, sm)
+	return true, nil
+}
@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/nexora/nexora/internal/agent/state"
+	"github.com/nexora/nexora/internal/csync"
+	"github.com/nexora/nexora/internal/pubsub"
+	"github.com/nexora/nexora/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAgentStates keeps saved agent states in memory.
+type fakeAgentStates struct {
+	*pubsub.Broker[session.AgentState]
+	saved map[string]state.Snapshot
+}
+
+func newFakeAgentStates() *fakeAgentStates {
+	return &fakeAgentStates{
+		Broker: pubsub.NewBroker[session.AgentState](),
+		saved:  make(map[string]state.Snapshot),
+	}
+}
+
+func (f *fakeAgentStates) Save(_ context.Context, snapshot state.Snapshot) (session.AgentState, error) {
+	f.saved[snapshot.SessionID] = snapshot
+	return f.toAgentState(snapshot), nil
+}
+
+func (f *fakeAgentStates) Get(_ context.Context, sessionID string) (session.AgentState, error) {
+	snapshot, ok := f.saved[sessionID]
+	if !ok {
+		return session.AgentState{}, sql.ErrNoRows
+	}
+	return f.toAgentState(snapshot), nil
+}
+
+func (f *fakeAgentStates) List(context.Context) ([]session.AgentState, error) {
+	var states []session.AgentState
+	for _, snapshot := range f.saved {
+		states = append(states, f.toAgentState(snapshot))
+	}
+	return states, nil
+}
+
+func (f *fakeAgentStates) Resume(ctx context.Context, sessionID string) (session.AgentState, error) {
+	saved, err := f.Get(ctx, sessionID)
+	if err != nil {
+		return session.AgentState{}, err
+	}
+	sm, err := state.RestoreStateMachine(state.Config{SessionID: sessionID}, saved.Snapshot)
+	if err != nil {
+		return session.AgentState{}, err
+	}
+	if err := sm.Resume(); err != nil {
+		return session.AgentState{}, err
+	}
+	return f.Save(ctx, sm.Snapshot())
+}
+
+func (f *fakeAgentStates) Delete(_ context.Context, sessionID string) error {
+	delete(f.saved, sessionID)
+	return nil
+}
+
+func (f *fakeAgentStates) toAgentState(snapshot state.Snapshot) session.AgentState {
+	return session.AgentState{
+		SessionID:   snapshot.SessionID,
+		State:       snapshot.AgentState(),
+		PauseReason: snapshot.PauseReason,
+		Snapshot:    snapshot,
+	}
+}
+
+func newStateTestAgent(states session.AgentStateService) *sessionAgent {
+	return &sessionAgent{
+		stateMachines: csync.NewMap[string, *state.StateMachine](),
+		agentStates:   states,
+		loopWarnings:  csync.NewMap[string, loopWarnings](),
+	}
+}
+
+func TestSessionAgentRestoresPausedState(t *testing.T) {
+	ctx := context.Background()
+	states := newFakeAgentStates()
+
+	a := newStateTestAgent(states)
+	sm := a.newStateMachine(state.Config{SessionID: "s1", Context: ctx})
+	require.NoError(t, sm.TransitionTo(state.StateProcessingPrompt))
+	sm.RecordToolCall(context.Background(), "bash", "", "go test", "", true)
+	a.pause(ctx, sm, state.StateLoopPaused, "repeated error")
+
+	saved, err := states.Get(ctx, "s1")
+	require.NoError(t, err)
+	require.True(t, saved.IsPaused())
+	require.Equal(t, "repeated error", saved.PauseReason)
+
+	// After a restart the session comes back paused, with its tool calls.
+	restarted := newStateTestAgent(states)
+	restored := restarted.newStateMachine(state.Config{SessionID: "s1", Context: ctx})
+	require.Equal(t, state.StateLoopPaused, restored.GetState())
+	require.Equal(t, "repeated error", restored.GetPauseReason())
+	require.Equal(t, 1, restored.GetToolCallCount())
+}
+
+func TestSessionAgentResumeSession(t *testing.T) {
+	ctx := context.Background()
+	states := newFakeAgentStates()
+	a := newStateTestAgent(states)
+
+	loaded, err := a.ResumeSession(ctx, "s1")
+	require.NoError(t, err)
+	require.False(t, loaded)
+
+	sm := a.newStateMachine(state.Config{SessionID: "s1", Context: ctx})
+	a.stateMachines.Set("s1", sm)
+	a.pause(ctx, sm, state.StateResourcePaused, "Memory usage 95% exceeds threshold")
+
+	loaded, err = a.ResumeSession(ctx, "s1")
+	require.NoError(t, err)
+	require.True(t, loaded)
+	require.Equal(t, state.StateIdle, sm.GetState())
+
+	saved, err := states.Get(ctx, "s1")
+	require.NoError(t, err)
+	require.False(t, saved.IsPaused())
+
+	_, err = a.ResumeSession(ctx, "s1")
+	require.Error(t, err, "a session that isn't paused can't be resumed")
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 
 	"charm.land/fantasy"
 
@@ -19,6 +20,7 @@ var agentToolDescription []byte
 
 type AgentParams struct {
 	Prompt string `json:"prompt" description:"The task for the agent to perform"`
+	Agent  string `json:"agent,omitempty" description:"The name of a user-defined agent to perform the task instead of the default search agent"`
 }
 
 const (
@@ -41,11 +43,24 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 	}
 	return fantasy.NewParallelAgentTool(
 		AgentToolName,
-		string(agentToolDescription),
+		string(agentToolDescription)+customAgentsDescription(c.cfg.CustomAgents()),
 		func(ctx context.Context, params AgentParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 			if params.Prompt == "" {
 				return fantasy.NewTextErrorResponse("prompt is required"), nil
 			}
+			subAgent := agent
+			title := "New Agent Session"
+			if params.Agent != "" {
+				if !c.isCustomAgent(params.Agent) {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("unknown agent %q", params.Agent)), nil
+				}
+				named, err := c.namedAgent(ctx, params.Agent)
+				if err != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("error starting agent %s: %s", params.Agent, err)), nil
+				}
+				subAgent = named
+				title = "@" + params.Agent
+			}
 
 			sessionID := tools.GetSessionFromContext(ctx)
 			if sessionID == "" {
@@ -58,11 +73,11 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 			}
 
 			agentToolSessionID := c.sessions.CreateAgentToolSessionID(agentMessageID, call.ID)
-			session, err := c.sessions.CreateTaskSession(ctx, agentToolSessionID, sessionID, "New Agent Session")
+			session, err := c.sessions.CreateTaskSession(ctx, agentToolSessionID, sessionID, title)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error creating session: %s", err)
 			}
-			model := agent.Model()
+			model := subAgent.Model()
 			maxTokens := model.CatwalkCfg.DefaultMaxTokens
 			if model.ModelCfg.MaxTokens != 0 {
 				maxTokens = model.ModelCfg.MaxTokens
@@ -77,7 +92,7 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 			if !ok {
 				return fantasy.ToolResponse{}, errors.New("model provider not configured")
 			}
-			result, err := agent.Run(ctx, SessionAgentCall{
+			result, err := subAgent.Run(ctx, SessionAgentCall{
 				SessionID:        session.ID,
 				Prompt:           params.Prompt,
 				MaxOutputTokens:  maxTokens,
@@ -109,3 +124,22 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 			return fantasy.NewTextResponse(result.Response.Content.Text()), nil
 		}), nil
 }
+
+// customAgentsDescription lists the user-defined agents a tool can hand a
+// task to, for the tool description.
+func customAgentsDescription(agents []config.Agent) string {
+	if len(agents) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\n<agents>\nThese user-defined agents can be chosen with the agent parameter:\n")
+	for _, agent := range agents {
+		fmt.Fprintf(&b, "- %s: %s", agent.ID, agent.Description)
+		if len(agent.Triggers) > 0 {
+			fmt.Fprintf(&b, " (use for: %s)", strings.Join(agent.Triggers, ", "))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("</agents>")
+	return b.String()
+}
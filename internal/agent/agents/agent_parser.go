@@ -18,6 +18,7 @@ type AgentConfig struct {
 	Tools        []string `yaml:"tools"`    // Explicit tool access
 	Triggers     []string `yaml:"triggers"` // Natural language triggers
 	SystemPrompt string   // Markdown content after frontmatter
+	Path         string   `yaml:"-"` // File the agent was loaded from
 }
 
 // AgentRegistry holds all loaded agents
@@ -53,6 +54,10 @@ func (r *AgentRegistry) LoadAgentFromFile(filePath string) error {
 
 	// Set system prompt from markdown content
 	config.SystemPrompt = strings.TrimSpace(parts[2])
+	config.Path = filePath
+	if config.Name == "" {
+		config.Name = strings.TrimSuffix(filepath.Base(filePath), ".md")
+	}
 
 	// Store in registry
 	r.agents[config.Name] = &config
@@ -0,0 +1,114 @@
+package agents
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/nexora/nexora/internal/config"
+)
+
+// toolAliases maps the tool names used by agent files written for other
+// assistants to the names of nexora's tools.
+var toolAliases = map[string]string{
+	"read":         "view",
+	"webfetch":     "fetch",
+	"notebookedit": "notebook_edit",
+	"notebookread": "view",
+	"task":         "agent",
+}
+
+// ToolName returns the nexora tool a tool name in an agent file refers to.
+func ToolName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := toolAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// LoadCustomAgents loads the markdown agent files in dirs. Agents in later
+// directories replace those of the same name in earlier ones. Missing
+// directories are skipped, and files that can't be loaded are reported in the
+// returned error without keeping the other agents from loading.
+func LoadCustomAgents(dirs ...string) ([]config.Agent, error) {
+	registry := NewAgentRegistry()
+	var errs []error
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read agents directory: %w", err))
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if err := registry.LoadAgentFromFile(path); err != nil {
+				errs = append(errs, fmt.Errorf("failed to load agent %s: %w", path, err))
+			}
+		}
+	}
+
+	names := registry.ListAgents()
+	slices.Sort(names)
+	agents := make([]config.Agent, 0, len(names))
+	for _, name := range names {
+		agent, _ := registry.GetAgent(name)
+		converted, err := ToConfigAgent(agent)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to load agent %s: %w", agent.Path, err))
+			continue
+		}
+		agents = append(agents, converted)
+	}
+	return agents, errors.Join(errs...)
+}
+
+// ToConfigAgent converts an agent file to a user-defined agent. Agents without
+// a tools list get every tool. The model is large or small; agents that leave
+// it out or inherit it use the large one.
+func ToConfigAgent(agent *AgentConfig) (config.Agent, error) {
+	model, err := agentModel(agent.Model)
+	if err != nil {
+		return config.Agent{}, err
+	}
+	var tools []string
+	if agent.Tools != nil {
+		tools = make([]string, 0, len(agent.Tools))
+		for _, tool := range agent.Tools {
+			if name := ToolName(tool); !slices.Contains(tools, name) {
+				tools = append(tools, name)
+			}
+		}
+	}
+	return config.Agent{
+		ID:           agent.Name,
+		Name:         agent.Name,
+		Description:  agent.Description,
+		Model:        model,
+		AllowedTools: tools,
+		SystemPrompt: agent.SystemPrompt,
+		Color:        agent.Color,
+		Triggers:     agent.Triggers,
+		Path:         agent.Path,
+	}, nil
+}
+
+// agentModel returns the model type the model of an agent file names.
+func agentModel(model string) (config.SelectedModelType, error) {
+	switch strings.ToLower(strings.TrimSpace(model)) {
+	case "", "inherit", string(config.SelectedModelTypeLarge):
+		return config.SelectedModelTypeLarge, nil
+	case string(config.SelectedModelTypeSmall):
+		return config.SelectedModelTypeSmall, nil
+	}
+	return "", fmt.Errorf("unsupported model %q, use large, small or inherit", model)
+}
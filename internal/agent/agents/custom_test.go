@@ -0,0 +1,76 @@
+package agents
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nexora/nexora/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAgentFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoadCustomAgents(t *testing.T) {
+	t.Parallel()
+
+	userDir := filepath.Join(t.TempDir(), "user")
+	projectDir := filepath.Join(t.TempDir(), "project")
+
+	writeAgentFile(t, userDir, "reviewer.md", "---\nname: reviewer\ndescription: User reviewer\n---\nReview from the user config.\n")
+	writeAgentFile(t, userDir, "docs.md", "---\ndescription: Writes docs\ncolor: green\ntools: [Read, Grep, Edit, WebFetch, Grep]\n---\nWrite documentation.\n")
+	projectReviewer := writeAgentFile(t, projectDir, "reviewer.md", "---\nname: reviewer\ndescription: Project reviewer\nmodel: small\ntriggers: [review, audit]\n---\nReview from the project.\n")
+	writeAgentFile(t, projectDir, "notes.txt", "not an agent")
+
+	agents, err := LoadCustomAgents(userDir, projectDir, filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	require.Len(t, agents, 2)
+
+	docs := agents[0]
+	require.Equal(t, "docs", docs.ID)
+	require.Equal(t, "Writes docs", docs.Description)
+	require.Equal(t, "green", docs.Color)
+	require.Equal(t, []string{"view", "grep", "edit", "fetch"}, docs.AllowedTools)
+	require.Equal(t, "Write documentation.", docs.SystemPrompt)
+	require.Equal(t, config.SelectedModelTypeLarge, docs.Model)
+
+	reviewer := agents[1]
+	require.Equal(t, "reviewer", reviewer.ID)
+	require.Equal(t, "Project reviewer", reviewer.Description)
+	require.Equal(t, projectReviewer, reviewer.Path)
+	require.Nil(t, reviewer.AllowedTools)
+	require.Equal(t, config.SelectedModelTypeSmall, reviewer.Model)
+	require.Equal(t, []string{"review", "audit"}, reviewer.Triggers)
+}
+
+func TestLoadCustomAgentsReportsBadFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeAgentFile(t, dir, "good.md", "---\nname: good\n---\nBe good.\n")
+	writeAgentFile(t, dir, "bad.md", "no frontmatter here")
+	writeAgentFile(t, dir, "opus.md", "---\nname: opus\nmodel: opus\n---\nThink hard.\n")
+
+	agents, err := LoadCustomAgents(dir)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "bad.md")
+	require.Contains(t, err.Error(), `unsupported model "opus"`)
+	require.Len(t, agents, 1)
+	require.Equal(t, "good", agents[0].ID)
+}
+
+func TestToolName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "view", ToolName("Read"))
+	require.Equal(t, "view", ToolName("View"))
+	require.Equal(t, "ls", ToolName("LS"))
+	require.Equal(t, "notebook_edit", ToolName("NotebookEdit"))
+	require.Equal(t, "bash", ToolName(" bash "))
+}
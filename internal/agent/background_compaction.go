@@ -303,6 +303,7 @@ func compactMessage(msg message.Message) message.Message {
 				p.Content = p.Content[:500] + "\n... [truncated]"
 			}
 			p.Data = "" // Clear binary data
+			p.Attachments = nil
 			newParts = append(newParts, p)
 		case message.ReasoningContent:
 			// Keep reasoning but truncate if very long
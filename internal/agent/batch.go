@@ -0,0 +1,162 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"charm.land/fantasy"
+	"github.com/nexora/nexora/internal/agent/tools"
+	"github.com/nexora/nexora/internal/aiops"
+)
+
+// toolBatcher batches the similar tool calls a model makes in one step, such
+// as several greps, views or edits, into a single operation of the scriptor.
+// The first call of the batch to run returns the consolidated result of the
+// whole batch, and the others point to it.
+type toolBatcher struct {
+	scriptor aiops.Scriptor
+
+	mu sync.Mutex
+	// Calls of the current step, and the ones that ran on their own
+	pending []aiops.ToolCall
+	ran     map[string]bool
+	batch   *toolBatch
+}
+
+// toolBatch is a batch of tool calls of a step.
+type toolBatch struct {
+	plan   *aiops.ScriptPlan
+	calls  map[string]bool
+	lead   string // The call that returned the result
+	failed bool   // Run each call on its own
+}
+
+func newToolBatcher(scriptor aiops.Scriptor) *toolBatcher {
+	if scriptor == nil {
+		return nil
+	}
+	return &toolBatcher{scriptor: scriptor, ran: make(map[string]bool)}
+}
+
+// startStep forgets the calls of the previous step.
+func (b *toolBatcher) startStep() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending = nil
+	b.ran = make(map[string]bool)
+	b.batch = nil
+}
+
+// addCall records a tool call of the step before it runs.
+func (b *toolBatcher) addCall(tc fantasy.ToolCallContent) {
+	if b == nil {
+		return
+	}
+	var params map[string]any
+	if err := json.Unmarshal([]byte(tc.Input), &params); err != nil {
+		return // Runs on its own, which reports the bad input
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending = append(b.pending, aiops.ToolCall{
+		ID:     tc.ToolCallID,
+		Name:   tools.ResolveToolName(tc.ToolName),
+		Params: params,
+	})
+}
+
+// run returns the result of call when it is part of a batch, running the
+// batch the first time.
+func (b *toolBatcher) run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.batch == nil {
+		b.detect(ctx)
+	}
+	batch := b.batch
+	if batch == nil || batch.failed || !batch.calls[call.ID] {
+		b.ran[call.ID] = true
+		return fantasy.ToolResponse{}, false
+	}
+	if batch.lead == "" {
+		batch.lead = call.ID
+		report, err := b.execute(ctx, batch.plan)
+		if err != nil {
+			slog.Warn("batched tool calls failed, running them one by one", "error", err)
+			batch.failed = true
+			b.ran[call.ID] = true
+			return fantasy.ToolResponse{}, false
+		}
+		return fantasy.NewTextResponse(report), true
+	}
+	return fantasy.NewTextResponse(fmt.Sprintf("This call was batched with others; its result is in the result of tool call %s.", batch.lead)), true
+}
+
+// detect looks for a batch among the calls of the step that haven't run.
+func (b *toolBatcher) detect(ctx context.Context) {
+	var calls []aiops.ToolCall
+	for _, c := range b.pending {
+		if !b.ran[c.ID] {
+			calls = append(calls, c)
+		}
+	}
+	plan, err := b.scriptor.DetectPattern(ctx, calls)
+	if err != nil || plan == nil {
+		return
+	}
+	batch := &toolBatch{plan: plan, calls: make(map[string]bool, len(plan.ToolCalls))}
+	for _, c := range plan.ToolCalls {
+		batch.calls[c.ID] = true
+	}
+	b.batch = batch
+}
+
+func (b *toolBatcher) execute(ctx context.Context, plan *aiops.ScriptPlan) (string, error) {
+	script, err := b.scriptor.Compile(ctx, plan)
+	if err != nil {
+		return "", err
+	}
+	result, err := b.scriptor.Execute(ctx, script)
+	if err != nil {
+		return "", err
+	}
+	slog.Info("batched tool calls",
+		"pattern", plan.PatternType,
+		"calls", len(plan.ToolCalls),
+		"duration_ms", result.Duration,
+		"success", result.Success,
+	)
+	return b.scriptor.Report(result), nil
+}
+
+// wrap returns tools whose calls go through the batcher.
+func (b *toolBatcher) wrap(agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	if b == nil {
+		return agentTools
+	}
+	wrapped := make([]fantasy.AgentTool, len(agentTools))
+	for i, tool := range agentTools {
+		wrapped[i] = &batchedTool{AgentTool: tool, batcher: b}
+	}
+	return wrapped
+}
+
+// batchedTool is a tool whose calls can be batched.
+type batchedTool struct {
+	fantasy.AgentTool
+	batcher *toolBatcher
+}
+
+func (t *batchedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	if resp, ok := t.batcher.run(ctx, call); ok {
+		return resp, nil
+	}
+	return t.AgentTool.Run(ctx, call)
+}
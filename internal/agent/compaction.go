@@ -90,6 +90,9 @@ func (c *Compactor) EstimateTokens(msg message.Message) int {
 			tokens += 20 // Overhead for tool result structure
 			tokens += estimateTextTokens(p.Content)
 			tokens += estimateTextTokens(p.Data)
+			for _, attachment := range p.Attachments {
+				tokens += len(attachment.Data) / 3
+			}
 		case message.BinaryContent:
 			// Binary content is typically base64 encoded
 			tokens += len(p.Data) / 3 // Base64 expansion factor
@@ -242,6 +245,7 @@ func (c *Compactor) dropToolResults(msgs []message.Message) []message.Message {
 				if tr, ok := part.(message.ToolResult); ok {
 					tr.Content = "[tool output removed for context management]"
 					tr.Data = ""
+					tr.Attachments = nil
 					newParts = append(newParts, tr)
 				} else {
 					newParts = append(newParts, part)
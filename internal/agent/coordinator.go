@@ -4,36 +4,45 @@ import (
 	"bytes"
 	"cmp"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"maps"
+	"net/http"
 	"os"
+	"path/filepath"
 	"regexp"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/nexora/nexora/internal/agent/delegation"
 	"github.com/nexora/nexora/internal/agent/prompt"
+	"github.com/nexora/nexora/internal/agent/recovery"
 	"github.com/nexora/nexora/internal/agent/tools"
 	"github.com/nexora/nexora/internal/aiops"
 	"github.com/nexora/nexora/internal/config"
-	"github.com/nexora/nexora/internal/config/providers"
 	"github.com/nexora/nexora/internal/csync"
 	"github.com/nexora/nexora/internal/history"
+	"github.com/nexora/nexora/internal/home"
 	"github.com/nexora/nexora/internal/log"
 	"github.com/nexora/nexora/internal/lsp"
 	"github.com/nexora/nexora/internal/message"
+	"github.com/nexora/nexora/internal/modelstats"
 	"github.com/nexora/nexora/internal/permission"
 	"github.com/nexora/nexora/internal/resources"
+	"github.com/nexora/nexora/internal/sandbox"
 	"github.com/nexora/nexora/internal/session"
 	"github.com/nexora/nexora/internal/sessionlog"
+	"github.com/nexora/nexora/internal/task"
 	"golang.org/x/sync/errgroup"
 
 	"charm.land/fantasy/providers/anthropic"
@@ -58,6 +67,9 @@ type Coordinator interface {
 	// INFO: (kujtim) this is not used yet we will use this when we have multiple agents
 	// SetMainAgent(string)
 	Run(ctx context.Context, sessionID, prompt string, attachments ...message.Attachment) (*fantasy.AgentResult, error)
+	// RunAgent runs prompt with the configured agent agentID instead of the
+	// coder, building it on first use.
+	RunAgent(ctx context.Context, agentID, sessionID, prompt string, attachments ...message.Attachment) (*fantasy.AgentResult, error)
 	Cancel(sessionID string)
 	CancelAll()
 	IsSessionBusy(sessionID string) bool
@@ -65,6 +77,9 @@ type Coordinator interface {
 	QueuedPrompts(sessionID string) int
 	ClearQueue(sessionID string)
 	Summarize(context.Context, string) error
+	// ResumeSession resumes a session paused for its resource usage or a
+	// loop, whether or not its agent is running.
+	ResumeSession(ctx context.Context, sessionID string) error
 	Model() Model
 	UpdateModels(ctx context.Context) error
 }
@@ -184,6 +199,25 @@ func (t *timeoutWrappedTool) SetProviderOptions(opts fantasy.ProviderOptions) {
 	t.original.SetProviderOptions(opts)
 }
 
+// servedTool is a tool whose calls are answered from a source of results,
+// such as a recording, instead of being run.
+type servedTool struct {
+	fantasy.AgentTool
+	source config.ToolResultSource
+}
+
+func (t *servedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	output, isError, ok := t.source(ctx, call.Name, call.Input)
+	switch {
+	case !ok:
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("No recorded result for this %s call; it was not run.", call.Name)), nil
+	case isError:
+		return fantasy.NewTextErrorResponse(output), nil
+	default:
+		return fantasy.NewTextResponse(output), nil
+	}
+}
+
 type coordinator struct {
 	cfg                 *config.Config
 	sessions            session.Service
@@ -195,9 +229,15 @@ type coordinator struct {
 	sessionLog          *sessionlog.Manager
 	resourceMonitor     *resources.Monitor
 	delegatePool        *delegation.Pool
+	headlessDelegates   *delegation.HeadlessExecutor // Nil when delegates run inline
 	backgroundCompactor *BackgroundCompactor
+	modelSpeeds         modelstats.Service
+	plans               task.PlanService
+	agentStates         session.AgentStateService
+	recoveryStats       recovery.StatsService
 
 	currentAgent SessionAgent
+	agentsMu     sync.Mutex
 	agents       map[string]SessionAgent
 
 	readyWg errgroup.Group
@@ -215,6 +255,10 @@ func NewCoordinator(
 	sessionLog *sessionlog.Manager,
 	resourceMonitor *resources.Monitor,
 	backgroundCompactor *BackgroundCompactor,
+	modelSpeeds modelstats.Service,
+	plans task.PlanService,
+	agentStates session.AgentStateService,
+	recoveryStats recovery.StatsService,
 ) (Coordinator, error) {
 	c := &coordinator{
 		cfg:                 cfg,
@@ -227,6 +271,10 @@ func NewCoordinator(
 		sessionLog:          sessionLog,
 		resourceMonitor:     resourceMonitor,
 		backgroundCompactor: backgroundCompactor,
+		modelSpeeds:         modelSpeeds,
+		plans:               plans,
+		agentStates:         agentStates,
+		recoveryStats:       recoveryStats,
 		agents:              make(map[string]SessionAgent),
 	}
 
@@ -255,8 +303,102 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 	if err := c.readyWg.Wait(); err != nil {
 		return nil, err
 	}
+	if agentID, rest, ok := parseAgentMention(prompt); ok && c.isCustomAgent(agentID) {
+		agent, err := c.namedAgent(ctx, agentID)
+		if err != nil {
+			return nil, err
+		}
+		return c.run(ctx, agent, sessionID, rest, attachments...)
+	}
+	return c.run(ctx, c.currentAgent, sessionID, prompt, attachments...)
+}
+
+// parseAgentMention splits a prompt that starts with an @name mention into
+// the mentioned name and the rest of the prompt.
+func parseAgentMention(prompt string) (agentID, rest string, ok bool) {
+	trimmed := strings.TrimLeftFunc(prompt, unicode.IsSpace)
+	name, found := strings.CutPrefix(trimmed, "@")
+	if !found {
+		return "", prompt, false
+	}
+	if i := strings.IndexFunc(name, unicode.IsSpace); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+	if !config.IsValidAgentID(name) {
+		return "", prompt, false
+	}
+	return name, strings.TrimSpace(rest), true
+}
+
+// isCustomAgent reports whether agentID names an enabled user-defined agent.
+func (c *coordinator) isCustomAgent(agentID string) bool {
+	agent, ok := c.cfg.Agents[agentID]
+	return ok && !agent.Disabled && agent.IsCustom()
+}
 
-	model := c.currentAgent.Model()
+// RunAgent implements Coordinator.
+func (c *coordinator) RunAgent(ctx context.Context, agentID, sessionID, prompt string, attachments ...message.Attachment) (*fantasy.AgentResult, error) {
+	if agentID == config.AgentCoder {
+		return c.Run(ctx, sessionID, prompt, attachments...)
+	}
+	if err := c.readyWg.Wait(); err != nil {
+		return nil, err
+	}
+	agent, err := c.namedAgent(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	return c.run(ctx, agent, sessionID, prompt, attachments...)
+}
+
+// namedAgent returns the agent configured as agentID. Unlike the coder, its
+// tools are built synchronously the first time it is requested.
+func (c *coordinator) namedAgent(ctx context.Context, agentID string) (SessionAgent, error) {
+	c.agentsMu.Lock()
+	defer c.agentsMu.Unlock()
+
+	if agent, ok := c.agents[agentID]; ok {
+		return agent, nil
+	}
+	agentCfg, ok := c.cfg.Agents[agentID]
+	if !ok || agentCfg.Disabled {
+		return nil, fmt.Errorf("%s agent not configured", agentID)
+	}
+
+	var systemPrompt *prompt.Prompt
+	var err error
+	switch agentID {
+	case config.AgentTask:
+		systemPrompt, err = taskPrompt(prompt.WithWorkingDir(c.cfg.WorkingDir()))
+	case config.AgentReviewer:
+		systemPrompt, err = reviewerPrompt(prompt.WithWorkingDir(c.cfg.WorkingDir()))
+	case config.AgentPlanner:
+		systemPrompt, err = plannerPrompt(prompt.WithWorkingDir(c.cfg.WorkingDir()))
+	default:
+		if !agentCfg.IsCustom() {
+			return nil, fmt.Errorf("no system prompt for %s agent", agentID)
+		}
+		systemPrompt, err = customAgentPrompt(agentCfg, prompt.WithWorkingDir(c.cfg.WorkingDir()))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	agent, err := c.newSessionAgent(ctx, systemPrompt, agentCfg.Model)
+	if err != nil {
+		return nil, err
+	}
+	tools, err := c.buildTools(ctx, agentCfg)
+	if err != nil {
+		return nil, err
+	}
+	agent.SetTools(tools)
+	c.agents[agentID] = agent
+	return agent, nil
+}
+
+func (c *coordinator) run(ctx context.Context, agent SessionAgent, sessionID string, prompt string, attachments ...message.Attachment) (*fantasy.AgentResult, error) {
+	model := agent.Model()
 	maxTokens := model.CatwalkCfg.DefaultMaxTokens
 	if model.ModelCfg.MaxTokens != 0 {
 		maxTokens = model.ModelCfg.MaxTokens
@@ -300,7 +442,7 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 			return nil, updateErr
 		}
 	}
-	result, err := c.currentAgent.Run(ctx, SessionAgentCall{
+	result, err := agent.Run(ctx, SessionAgentCall{
 		SessionID:        sessionID,
 		Prompt:           prompt,
 		Attachments:      attachments,
@@ -446,10 +588,38 @@ func mergeCallOptions(model Model, cfg config.ProviderConfig) (fantasy.ProviderO
 }
 
 func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, agent config.Agent) (SessionAgent, error) {
+	result, err := c.newSessionAgent(ctx, prompt, agent.Model)
+	if err != nil {
+		return nil, err
+	}
+	c.readyWg.Go(func() error {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("Panic caught while building tools", "error", r)
+			}
+		}()
+
+		tools, err := c.buildTools(ctx, agent)
+		if err != nil {
+			return err
+		}
+		result.SetTools(tools)
+		return nil
+	})
+
+	return result, nil
+}
+
+// newSessionAgent creates a session agent for prompt without any tools.
+// newSessionAgent creates an agent running on the model of modelType.
+func (c *coordinator) newSessionAgent(ctx context.Context, prompt *prompt.Prompt, modelType config.SelectedModelType) (SessionAgent, error) {
 	large, small, err := c.buildAgentModels(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if modelType == config.SelectedModelTypeSmall {
+		large = small
+	}
 
 	systemPrompt, err := prompt.Build(ctx, large.Model.Provider(), large.Model.Model(), *c.cfg)
 	if err != nil {
@@ -468,23 +638,58 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 		AIOPS:               c.aiops,
 		ResourceMonitor:     c.resourceMonitor,
 		BackgroundCompactor: c.backgroundCompactor,
+		ModelSpeeds:         c.modelSpeeds,
+		SessionLog:          c.sessionLog,
+		AgentStates:         c.agentStates,
+		Scriptor:            c.scriptor(),
+		LoopDetection:       c.cfg.AIOPS.LoopDetection,
+		Recovery:            c.cfg.Recovery,
+		RecoveryStats:       c.recoveryStats,
+		WorkingDir:          c.cfg.WorkingDir(),
 	})
-	c.readyWg.Go(func() error {
-		defer func() {
-			if r := recover(); r != nil {
-				slog.Error("Panic caught while building tools", "error", r)
-			}
-		}()
+	return result, nil
+}
 
-		tools, err := c.buildTools(ctx, agent)
-		if err != nil {
-			return err
-		}
-		result.SetTools(tools)
+// scriptor returns the scriptor that batches similar tool calls of a step,
+// or nil when batching is disabled.
+func (c *coordinator) scriptor() aiops.Scriptor {
+	cfg := c.cfg.AIOPS.Scriptor
+	if cfg.Disabled || c.cfg.ToolResults() != nil {
+		// Batched calls would run instead of being answered from the results
 		return nil
-	})
+	}
+	return tools.NewBatchScriptor(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir(), cfg.MinBatchSize)
+}
 
-	return result, nil
+// bashTool returns the bash tool for workingDir, sandboxed when the
+// configuration asks for it. If the sandbox cannot be set up the tool is left
+// out instead of running commands unrestricted.
+func (c *coordinator) bashTool(workingDir, modelName string) fantasy.AgentTool {
+	opts := c.cfg.Options.Sandbox
+	if opts == nil || !opts.Enabled {
+		return tools.NewBashTool(c.permissions, workingDir, c.cfg.Options.Attribution, modelName)
+	}
+
+	writable := make([]string, 0, len(opts.WritablePaths))
+	for _, p := range opts.WritablePaths {
+		p = home.Long(p)
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(workingDir, p)
+		}
+		writable = append(writable, p)
+	}
+	sb, err := sandbox.New(sandbox.Options{
+		Backend:       sandbox.Backend(opts.Backend),
+		ProjectDir:    workingDir,
+		WritablePaths: writable,
+		Network:       opts.Network,
+	})
+	if err != nil {
+		slog.Error("Sandbox is enabled but unavailable, disabling the bash tool", "error", err)
+		return nil
+	}
+	slog.Debug("Running bash commands in sandbox", "backend", sb.Backend(), "network", sb.Network(), "writable", sb.WritablePaths())
+	return tools.NewSandboxedBashTool(c.permissions, workingDir, c.cfg.Options.Attribution, modelName, sb)
 }
 
 // safeCreateTool safely creates a tool and catches any panics
@@ -550,9 +755,14 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 		}
 	}
 
+	var permissionRules []string
+	if c.cfg.Permissions != nil {
+		permissionRules = c.cfg.Permissions.AllowedTools
+	}
+
 	allTools = append(allTools,
 		c.safeCreateTool(func() fantasy.AgentTool {
-			return tools.NewBashTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Options.Attribution, modelName)
+			return c.bashTool(c.cfg.WorkingDir(), modelName)
 		}),
 		c.safeCreateTool(func() fantasy.AgentTool {
 			return tools.NewJobOutputTool()
@@ -590,9 +800,28 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 		c.safeCreateTool(func() fantasy.AgentTool {
 			return tools.NewWriteTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir())
 		}),
+		c.safeCreateTool(func() fantasy.AgentTool {
+			return tools.NewNotebookEditTool(c.permissions, c.history, c.cfg.WorkingDir())
+		}),
+		c.safeCreateTool(func() fantasy.AgentTool {
+			return tools.NewGitStatusTool(c.cfg.WorkingDir())
+		}),
+		c.safeCreateTool(func() fantasy.AgentTool {
+			return tools.NewGitDiffTool(c.cfg.WorkingDir())
+		}),
+		c.safeCreateTool(func() fantasy.AgentTool {
+			return tools.NewGitLogTool(c.cfg.WorkingDir())
+		}),
+		c.safeCreateTool(func() fantasy.AgentTool {
+			return tools.NewGitCommitTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Options.Attribution, modelName, permissionRules)
+		}),
+		c.safeCreateTool(func() fantasy.AgentTool {
+			return tools.NewGitBranchTool(c.permissions, c.cfg.WorkingDir(), permissionRules)
+		}),
 	)
 
-	if len(c.cfg.LSP) > 0 {
+	// Auto-LSP starts servers without an lsp configuration
+	if len(c.cfg.LSP) > 0 || c.cfg.Options.AutoLSP || c.lspClients.Len() > 0 {
 		allTools = append(allTools,
 			c.safeCreateTool(func() fantasy.AgentTool {
 				return tools.NewDiagnosticsTool(c.lspClients)
@@ -603,6 +832,10 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 		)
 	}
 
+	if slices.Contains(agent.AllowedTools, tools.SubmitPlanToolName) && c.plans != nil {
+		allTools = append(allTools, tools.NewSubmitPlanTool(c.plans))
+	}
+
 	// Add delegate tool for sub-agent spawning
 	delegateTool, err := c.delegateTool(ctx)
 	if err != nil {
@@ -684,6 +917,11 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 			slog.Debug("MCP not allowed", "tool", tool.Name(), "agent", agent.Name)
 		}
 	}
+	if source := c.cfg.ToolResults(); source != nil {
+		for i, tool := range filteredTools {
+			filteredTools[i] = &servedTool{AgentTool: tool, source: source}
+		}
+	}
 	slices.SortFunc(filteredTools, func(a, b fantasy.AgentTool) int {
 		if a == nil || b == nil {
 			return 0
@@ -832,7 +1070,7 @@ func (c *coordinator) getToolTimeout(toolName string) time.Duration {
 	case "view", "grep", "ls", "glob":
 		// Read-only operations are fast
 		return defaultToolTimeout
-	case "edit", "multiedit", "write":
+	case "edit", "multiedit", "write", "notebook_edit":
 		// File operations can take some time for large files
 		return 2 * time.Minute
 	default:
@@ -921,13 +1159,25 @@ func (c *coordinator) buildAgentModels(ctx context.Context) (Model, Model, error
 	return largeModelStruct, fastestModel, nil
 }
 
-// selectFastestModel finds and builds the fastest available model across all providers
+// selectFastestModel finds and builds the model expected to answer a short
+// request the fastest across all providers. Measured models rank first;
+// models without enough samples are ranked after them by the static speed
+// table.
 func (c *coordinator) selectFastestModel(ctx context.Context, knownProviders []catwalk.Provider) (Model, error) {
+	var measured map[string]modelstats.Stats
+	if c.modelSpeeds != nil {
+		var err error
+		if measured, err = c.modelSpeeds.All(ctx); err != nil {
+			slog.Warn("Failed to load measured model speeds", "error", err)
+		}
+	}
+
 	var fastestModelID string
 	var fastestProviderID string
 	var fastestProviderCfg config.ProviderConfig
 	var fastestCatwalkModel *catwalk.Model
-	maxSpeed := 0.0
+	var fastestMeasured bool
+	var minLatency time.Duration
 
 	// Iterate through all enabled providers
 	for providerID, providerCfg := range c.cfg.Providers.Seq2() {
@@ -935,23 +1185,25 @@ func (c *coordinator) selectFastestModel(ctx context.Context, knownProviders []c
 			continue // Skip disabled providers
 		}
 
-		// Check models in this provider
+		// Custom providers, such as local servers, list their own models.
+		models := providerCfg.Models
 		for _, provider := range knownProviders {
-			if string(provider.ID) != providerID {
-				continue
+			if string(provider.ID) == providerID {
+				models = provider.Models
+				break
 			}
+		}
 
-			for i, model := range provider.Models {
-				speed := providers.GetModelSpeed(model.ID)
-				if speed.TokensPerSecond > maxSpeed {
-					maxSpeed = speed.TokensPerSecond
-					fastestModelID = model.ID
-					fastestProviderID = providerID
-					fastestProviderCfg = providerCfg
-					fastestCatwalkModel = &provider.Models[i]
-				}
+		for i, model := range models {
+			latency, isMeasured := expectedLatency(measured, providerID, model.ID)
+			if fastestModelID == "" || rankedFaster(latency, isMeasured, minLatency, fastestMeasured) {
+				minLatency = latency
+				fastestModelID = model.ID
+				fastestProviderID = providerID
+				fastestProviderCfg = providerCfg
+				fastestCatwalkModel = &models[i]
+				fastestMeasured = isMeasured
 			}
-			break
 		}
 	}
 
@@ -962,8 +1214,8 @@ func (c *coordinator) selectFastestModel(ctx context.Context, knownProviders []c
 	slog.Info("Selected fastest model for title generation",
 		"model", fastestModelID,
 		"provider", fastestProviderID,
-		"speed_tps", maxSpeed,
-		"tier", providers.GetModelSpeed(fastestModelID).SpeedTier)
+		"expected_latency", minLatency,
+		"measured", fastestMeasured)
 
 	// Build the model config for the fastest model
 	fastestModelCfg := config.SelectedModel{
@@ -990,6 +1242,23 @@ func (c *coordinator) selectFastestModel(ctx context.Context, knownProviders []c
 	}, nil
 }
 
+// providerHTTPClient returns the HTTP client providers should use, or nil
+// for their default. Requests go through the configured transport, if any,
+// and are logged in debug mode.
+func (c *coordinator) providerHTTPClient() *http.Client {
+	transport := c.cfg.HTTPTransport()
+	if !c.cfg.Options.Debug {
+		if transport == nil {
+			return nil
+		}
+		return &http.Client{Transport: transport}
+	}
+	if transport == nil {
+		return log.NewHTTPClient()
+	}
+	return &http.Client{Transport: &log.HTTPRoundTripLogger{Transport: transport}}
+}
+
 func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
 	var opts []anthropic.Option
 
@@ -1019,8 +1288,7 @@ func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map
 		opts = append(opts, anthropic.WithBaseURL(baseURL))
 	}
 
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.providerHTTPClient(); httpClient != nil {
 		opts = append(opts, anthropic.WithHTTPClient(httpClient))
 	}
 
@@ -1032,8 +1300,7 @@ func (c *coordinator) buildOpenaiProvider(baseURL, apiKey string, headers map[st
 		openai.WithAPIKey(apiKey),
 		openai.WithUseResponsesAPI(),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.providerHTTPClient(); httpClient != nil {
 		opts = append(opts, openai.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -1049,8 +1316,7 @@ func (c *coordinator) buildOpenrouterProvider(_, apiKey string, headers map[stri
 	opts := []openrouter.Option{
 		openrouter.WithAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.providerHTTPClient(); httpClient != nil {
 		opts = append(opts, openrouter.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -1064,8 +1330,7 @@ func (c *coordinator) buildMinimaxProvider(baseURL, apiKey string) (fantasy.Prov
 	opts := []anthropic.Option{
 		anthropic.WithAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.providerHTTPClient(); httpClient != nil {
 		opts = append(opts, anthropic.WithHTTPClient(httpClient))
 	}
 	opts = append(opts, anthropic.WithBaseURL(baseURL))
@@ -1078,8 +1343,7 @@ func (c *coordinator) buildOpenaiCompatProvider(baseURL, apiKey string, headers
 		openaicompat.WithBaseURL(baseURL),
 		openaicompat.WithAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.providerHTTPClient(); httpClient != nil {
 		opts = append(opts, openaicompat.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -1099,8 +1363,7 @@ func (c *coordinator) buildAzureProvider(baseURL, apiKey string, headers map[str
 		azure.WithAPIKey(apiKey),
 		azure.WithUseResponsesAPI(),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.providerHTTPClient(); httpClient != nil {
 		opts = append(opts, azure.WithHTTPClient(httpClient))
 	}
 	if options == nil {
@@ -1118,8 +1381,7 @@ func (c *coordinator) buildAzureProvider(baseURL, apiKey string, headers map[str
 
 func (c *coordinator) buildBedrockProvider(headers map[string]string) (fantasy.Provider, error) {
 	var opts []bedrock.Option
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.providerHTTPClient(); httpClient != nil {
 		opts = append(opts, bedrock.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -1137,8 +1399,7 @@ func (c *coordinator) buildGoogleProvider(baseURL, apiKey string, headers map[st
 		google.WithBaseURL(baseURL),
 		google.WithGeminiAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.providerHTTPClient(); httpClient != nil {
 		opts = append(opts, google.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -1149,8 +1410,7 @@ func (c *coordinator) buildGoogleProvider(baseURL, apiKey string, headers map[st
 
 func (c *coordinator) buildGoogleVertexProvider(headers map[string]string, options map[string]string) (fantasy.Provider, error) {
 	opts := []google.Option{}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.providerHTTPClient(); httpClient != nil {
 		opts = append(opts, google.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -1247,10 +1507,24 @@ func isExactoSupported(modelID string) bool {
 
 func (c *coordinator) Cancel(sessionID string) {
 	c.currentAgent.Cancel(sessionID)
+	c.agentsMu.Lock()
+	defer c.agentsMu.Unlock()
+	for id, agent := range c.agents {
+		if id != config.AgentCoder {
+			agent.Cancel(sessionID)
+		}
+	}
 }
 
 func (c *coordinator) CancelAll() {
 	c.currentAgent.CancelAll()
+	c.agentsMu.Lock()
+	defer c.agentsMu.Unlock()
+	for id, agent := range c.agents {
+		if id != config.AgentCoder {
+			agent.CancelAll()
+		}
+	}
 }
 
 func (c *coordinator) ClearQueue(sessionID string) {
@@ -1258,11 +1532,61 @@ func (c *coordinator) ClearQueue(sessionID string) {
 }
 
 func (c *coordinator) IsBusy() bool {
-	return c.currentAgent.IsBusy()
+	if c.currentAgent.IsBusy() {
+		return true
+	}
+	c.agentsMu.Lock()
+	defer c.agentsMu.Unlock()
+	for id, agent := range c.agents {
+		if id != config.AgentCoder && agent.IsBusy() {
+			return true
+		}
+	}
+	return false
 }
 
 func (c *coordinator) IsSessionBusy(sessionID string) bool {
-	return c.currentAgent.IsSessionBusy(sessionID)
+	if c.currentAgent.IsSessionBusy(sessionID) {
+		return true
+	}
+	c.agentsMu.Lock()
+	defer c.agentsMu.Unlock()
+	for id, agent := range c.agents {
+		if id != config.AgentCoder && agent.IsSessionBusy(sessionID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *coordinator) ResumeSession(ctx context.Context, sessionID string) error {
+	loaded, err := c.currentAgent.ResumeSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	c.agentsMu.Lock()
+	for id, agent := range c.agents {
+		if id == config.AgentCoder {
+			continue
+		}
+		ok, err := agent.ResumeSession(ctx, sessionID)
+		if err != nil {
+			c.agentsMu.Unlock()
+			return err
+		}
+		loaded = loaded || ok
+	}
+	c.agentsMu.Unlock()
+	if loaded || c.agentStates == nil {
+		return nil
+	}
+	// No agent has run the session since the restart: resume its saved state.
+	if _, err := c.agentStates.Resume(ctx, sessionID); errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("session %s is not paused", sessionID)
+	} else if err != nil {
+		return err
+	}
+	return nil
 }
 
 func (c *coordinator) Model() Model {
@@ -1287,6 +1611,13 @@ func (c *coordinator) UpdateModels(ctx context.Context) error {
 		return err
 	}
 	c.currentAgent.SetTools(tools)
+
+	// Rebuild other agents with the new models the next time they are used.
+	c.agentsMu.Lock()
+	maps.DeleteFunc(c.agents, func(id string, _ SessionAgent) bool {
+		return id != config.AgentCoder
+	})
+	c.agentsMu.Unlock()
 	return nil
 }
 
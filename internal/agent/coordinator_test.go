@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/nexora/nexora/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAgentMention(t *testing.T) {
+	tests := []struct {
+		prompt  string
+		agentID string
+		rest    string
+		ok      bool
+	}{
+		{prompt: "@docs write the README", agentID: "docs", rest: "write the README", ok: true},
+		{prompt: "  @code-reviewer\nlook at main.go", agentID: "code-reviewer", rest: "look at main.go", ok: true},
+		{prompt: "@docs", agentID: "docs", rest: "", ok: true},
+		{prompt: "email me@example.com", rest: "email me@example.com"},
+		{prompt: "@ docs", rest: "@ docs"},
+		{prompt: "@main.go is broken", rest: "@main.go is broken"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prompt, func(t *testing.T) {
+			agentID, rest, ok := parseAgentMention(tt.prompt)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.agentID, agentID)
+			assert.Equal(t, tt.rest, rest)
+		})
+	}
+}
+
+func TestCustomAgentsDescription(t *testing.T) {
+	assert.Empty(t, customAgentsDescription(nil))
+
+	description := customAgentsDescription([]config.Agent{
+		{ID: "docs", Description: "Writes documentation"},
+		{ID: "reviewer", Description: "Reviews changes", Triggers: []string{"review", "audit"}},
+	})
+	require.Contains(t, description, "<agents>")
+	assert.Contains(t, description, "- docs: Writes documentation\n")
+	assert.Contains(t, description, "- reviewer: Reviews changes (use for: review, audit)\n")
+}
@@ -1,10 +1,14 @@
 package agent
 
 import (
+	"cmp"
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -13,8 +17,10 @@ import (
 	"github.com/nexora/nexora/internal/agent/delegation"
 	"github.com/nexora/nexora/internal/agent/prompt"
 	"github.com/nexora/nexora/internal/agent/tools"
+	"github.com/nexora/nexora/internal/config"
 	"github.com/nexora/nexora/internal/message"
 	"github.com/nexora/nexora/internal/permission"
+	"github.com/nexora/nexora/internal/shell"
 )
 
 //go:embed templates/delegate.md
@@ -31,6 +37,7 @@ type DelegateParams struct {
 	Context    string `json:"context,omitempty" description:"Additional context or background information for the sub-agent."`
 	WorkingDir string `json:"working_dir,omitempty" description:"Working directory for the sub-agent (defaults to current project root)."`
 	MaxTokens  int    `json:"max_tokens,omitempty" description:"Maximum tokens for the sub-agent response (default: 4096)."`
+	Agent      string `json:"agent,omitempty" description:"The name of a user-defined agent to perform the task instead of the default sub-agent."`
 }
 
 // DelegatePermissionsParams is the permission-safe version of DelegateParams
@@ -38,6 +45,7 @@ type DelegatePermissionsParams struct {
 	Task       string `json:"task"`
 	Context    string `json:"context"`
 	WorkingDir string `json:"working_dir"`
+	Agent      string `json:"agent,omitempty"`
 }
 
 // delegateValidationResult holds validated parameters from tool call context
@@ -73,6 +81,7 @@ func validateDelegateParams(ctx context.Context, params DelegateParams) (delegat
 func (c *coordinator) delegateTool(ctx context.Context) (fantasy.AgentTool, error) {
 	// Initialize pool if not already done
 	if c.delegatePool == nil {
+		c.headlessDelegates = c.newHeadlessExecutor()
 		c.delegatePool = delegation.NewPool(delegation.DefaultPoolConfig(), c.resourceMonitor)
 		c.delegatePool.SetExecutor(c.executeDelegatedTask)
 		c.delegatePool.Start(ctx)
@@ -80,12 +89,15 @@ func (c *coordinator) delegateTool(ctx context.Context) (fantasy.AgentTool, erro
 
 	return fantasy.NewParallelAgentTool(
 		DelegateToolName,
-		string(delegateToolDescription),
+		string(delegateToolDescription)+customAgentsDescription(c.cfg.CustomAgents()),
 		func(ctx context.Context, params DelegateParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 			validationResult, err := validateDelegateParams(ctx, params)
 			if err != nil {
 				return fantasy.NewTextErrorResponse(err.Error()), nil
 			}
+			if params.Agent != "" && !c.isCustomAgent(params.Agent) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("unknown agent %q", params.Agent)), nil
+			}
 
 			// Request permission for delegation
 			description := fmt.Sprintf("Delegate task: %s", params.Task)
@@ -105,6 +117,7 @@ func (c *coordinator) delegateTool(ctx context.Context) (fantasy.AgentTool, erro
 						Task:       params.Task,
 						Context:    params.Context,
 						WorkingDir: params.WorkingDir,
+						Agent:      params.Agent,
 					},
 				},
 			)
@@ -126,7 +139,8 @@ func (c *coordinator) delegateTool(ctx context.Context) (fantasy.AgentTool, erro
 			}
 
 			// Submit to pool - always runs asynchronously
-			taskID, _, err := c.delegatePool.Submit(
+			taskID, _, err := c.delegatePool.SubmitAs(
+				params.Agent,
 				params.Task,
 				params.Context,
 				workingDir,
@@ -148,14 +162,18 @@ func (c *coordinator) delegateTool(ctx context.Context) (fantasy.AgentTool, erro
 		}), nil
 }
 
-// executeDelegatedTask runs a delegated task with a sub-agent.
-// It implements a continuation loop to ensure the agent actually completes work
-// rather than just outputting plans without executing them.
-func (c *coordinator) executeDelegatedTask(ctx context.Context, task *delegation.Task) (string, error) {
-	// Build the full prompt with context
-	fullPrompt := task.Description
-	if task.Context != "" {
-		fullPrompt = fmt.Sprintf("Context:\n%s\n\nTask:\n%s", task.Context, task.Description)
+// delegateAgent returns the agent that works on task: the user-defined agent
+// the task names, or otherwise a sub-agent with the core read and bash tools.
+func (c *coordinator) delegateAgent(ctx context.Context, task *delegation.Task, model Model, providerCfg config.ProviderConfig) (SessionAgent, error) {
+	if task.Agent != "" {
+		if !c.isCustomAgent(task.Agent) {
+			return nil, fmt.Errorf("unknown agent %q", task.Agent)
+		}
+		agent, err := c.namedAgent(ctx, task.Agent)
+		if err != nil {
+			return nil, fmt.Errorf("error starting agent %s: %w", task.Agent, err)
+		}
+		return agent, nil
 	}
 
 	// Create prompt template for the delegate
@@ -165,23 +183,12 @@ func (c *coordinator) executeDelegatedTask(ctx context.Context, task *delegation
 
 	promptTemplate, err := prompt.NewPrompt("delegate", string(delegatePromptTmpl), promptOpts...)
 	if err != nil {
-		return "", fmt.Errorf("error creating prompt: %w", err)
-	}
-
-	// Use large model for delegated tasks (small model support removed)
-	_, model, err := c.buildAgentModels(ctx)
-	if err != nil {
-		return "", fmt.Errorf("error building models: %w", err)
+		return nil, fmt.Errorf("error creating prompt: %w", err)
 	}
 
 	systemPrompt, err := promptTemplate.Build(ctx, model.Model.Provider(), model.Model.Model(), *c.cfg)
 	if err != nil {
-		return "", fmt.Errorf("error building system prompt: %w", err)
-	}
-
-	providerCfg, ok := c.cfg.Providers.Get(model.ModelCfg.Provider)
-	if !ok {
-		return "", fmt.Errorf("model provider not configured")
+		return nil, fmt.Errorf("error building system prompt: %w", err)
 	}
 
 	// Build tools for the sub-agent - give it access to core tools
@@ -189,11 +196,12 @@ func (c *coordinator) executeDelegatedTask(ctx context.Context, task *delegation
 		tools.NewGlobTool(task.WorkingDir),
 		tools.NewGrepTool(task.WorkingDir),
 		tools.NewViewTool(c.lspClients, c.permissions, task.WorkingDir),
-		tools.NewBashTool(c.permissions, task.WorkingDir, c.cfg.Options.Attribution, model.Model.Model()),
+	}
+	if bashTool := c.bashTool(task.WorkingDir, model.Model.Model()); bashTool != nil {
+		delegateTools = append(delegateTools, bashTool)
 	}
 
-	// Create the sub-agent
-	agent := NewSessionAgent(SessionAgentOptions{
+	return NewSessionAgent(SessionAgentOptions{
 		LargeModel:           model,
 		SmallModel:           model,
 		SystemPromptPrefix:   providerCfg.SystemPromptPrefix,
@@ -203,7 +211,101 @@ func (c *coordinator) executeDelegatedTask(ctx context.Context, task *delegation
 		Sessions:             c.sessions,
 		Messages:             c.messages,
 		Tools:                delegateTools,
-	})
+		ModelSpeeds:          c.modelSpeeds,
+	}), nil
+}
+
+// newHeadlessExecutor returns the executor that runs delegates as separate
+// nexora processes in TMUX sessions, or nil if delegates run inline: when the
+// config asks for it, TMUX is not available, or this process is a delegate
+// itself.
+func (c *coordinator) newHeadlessExecutor() *delegation.HeadlessExecutor {
+	opts := cmp.Or(c.cfg.Options.Delegates, &config.Delegates{})
+	if opts.Inline || os.Getenv(delegation.EnvTaskID) != "" {
+		return nil
+	}
+	if !shell.IsTmuxAvailable() {
+		slog.Info("tmux is not available, running delegates inline")
+		return nil
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		slog.Warn("failed to find the nexora executable, running delegates inline", "error", err)
+		return nil
+	}
+	dataDir, err := filepath.Abs(c.cfg.Options.DataDirectory)
+	if err != nil {
+		slog.Warn("failed to resolve the data directory, running delegates inline", "error", err)
+		return nil
+	}
+
+	return delegation.NewHeadlessExecutor(delegation.HeadlessConfig{
+		Dir:         filepath.Join(dataDir, "delegates"),
+		Timeout:     time.Duration(opts.Timeout) * time.Minute,
+		IdleTimeout: time.Duration(opts.IdleTimeout) * time.Minute,
+	}, delegation.NewTmuxSpawner(shell.GetTmuxManager(), executable, dataDir))
+}
+
+// executeDelegatedTask runs a delegated task in a headless delegate if
+// possible, or otherwise with a sub-agent in this process, and reports the
+// result to the parent session.
+func (c *coordinator) executeDelegatedTask(ctx context.Context, task *delegation.Task) (string, error) {
+	var result string
+	var err error
+	if c.headlessDelegates != nil {
+		result, err = c.executeHeadlessTask(ctx, task)
+	} else {
+		result, err = c.executeInlineTask(ctx, task)
+	}
+	c.reportDelegateResult(task, result, err)
+	return result, err
+}
+
+// executeHeadlessTask runs a delegated task in a separate nexora process and
+// adds its cost to the parent session.
+func (c *coordinator) executeHeadlessTask(ctx context.Context, task *delegation.Task) (string, error) {
+	result, status, err := c.headlessDelegates.Execute(ctx, task)
+	if status.Cost > 0 {
+		if costErr := c.addSessionCost(context.WithoutCancel(ctx), task.ParentSession, status.Cost); costErr != nil {
+			slog.Warn("failed to add delegate cost to parent session", "task_id", task.ID, "error", costErr)
+		}
+	}
+	return result, err
+}
+
+func (c *coordinator) addSessionCost(ctx context.Context, sessionID string, cost float64) error {
+	session, err := c.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get parent session: %w", err)
+	}
+	session.Cost += cost
+	if _, err := c.sessions.Save(ctx, session); err != nil {
+		return fmt.Errorf("failed to save parent session cost: %w", err)
+	}
+	return nil
+}
+
+// executeInlineTask runs a delegated task with a sub-agent.
+// It implements a continuation loop to ensure the agent actually completes work
+// rather than just outputting plans without executing them.
+func (c *coordinator) executeInlineTask(ctx context.Context, task *delegation.Task) (string, error) {
+	fullPrompt := task.Prompt()
+
+	// Use large model for delegated tasks (small model support removed)
+	_, model, err := c.buildAgentModels(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error building models: %w", err)
+	}
+
+	providerCfg, ok := c.cfg.Providers.Get(model.ModelCfg.Provider)
+	if !ok {
+		return "", fmt.Errorf("model provider not configured")
+	}
+
+	agent, err := c.delegateAgent(ctx, task, model, providerCfg)
+	if err != nil {
+		return "", err
+	}
 
 	// Create a task session for the delegated work
 	agentToolSessionID := c.sessions.CreateAgentToolSessionID(task.ParentSession, task.ID)
@@ -380,15 +482,8 @@ func (c *coordinator) executeDelegatedTask(ctx context.Context, task *delegation
 		return "", fmt.Errorf("failed to get updated session: %w", err)
 	}
 
-	parentSession, err := c.sessions.Get(ctx, task.ParentSession)
-	if err != nil {
-		return "", fmt.Errorf("failed to get parent session: %w", err)
-	}
-
-	parentSession.Cost += updatedSession.Cost
-	_, err = c.sessions.Save(ctx, parentSession)
-	if err != nil {
-		return "", fmt.Errorf("failed to save parent session cost: %w", err)
+	if err := c.addSessionCost(ctx, task.ParentSession, updatedSession.Cost); err != nil {
+		return "", err
 	}
 
 	// If no text content found, try reasoning text as fallback
@@ -410,20 +505,39 @@ func (c *coordinator) executeDelegatedTask(ctx context.Context, task *delegation
 		}
 	}
 
-	result := strings.Join(allTextParts, "\n\n")
+	return strings.Join(allTextParts, "\n\n"), nil
+}
 
-	// Trigger the main AI with the delegate's report
-	// This prompts the parent session to continue with the delegate's findings
-	go func() {
-		reportPrompt := fmt.Sprintf(
+// reportDelegateResult prompts the parent session of a task to continue with
+// the result of its delegate, or to deal with its failure.
+func (c *coordinator) reportDelegateResult(task *delegation.Task, result string, err error) {
+	if errors.Is(err, context.Canceled) {
+		// The parent is shutting down or the task was cancelled
+		return
+	}
+
+	var reportPrompt string
+	if err != nil {
+		reportPrompt = fmt.Sprintf(
+			"[DELEGATE FAILED - Task ID: %s]\n\nThe delegated sub-agent could not complete its task:\n\n%s\n\n---\nPlease decide whether to retry the task, do it yourself or continue without it.",
+			task.ID,
+			err,
+		)
+	} else {
+		reportPrompt = fmt.Sprintf(
 			"[DELEGATE REPORT - Task ID: %s]\n\nThe delegated sub-agent has completed its task.\n\n## Delegate's Findings:\n\n%s\n\n---\nPlease review the delegate's report and continue accordingly.",
 			task.ID,
 			result,
 		)
+	}
 
+	// Trigger the main AI with the delegate's report
+	// This prompts the parent session to continue with the delegate's findings
+	go func() {
 		slog.Info("delegate reporting to parent session",
 			"task_id", task.ID,
 			"parent_session", task.ParentSession,
+			"failed", err != nil,
 		)
 
 		// Use a fresh context with timeout since the original might be cancelled
@@ -437,6 +551,4 @@ func (c *coordinator) executeDelegatedTask(ctx context.Context, task *delegation
 			)
 		}
 	}()
-
-	return result, nil
 }
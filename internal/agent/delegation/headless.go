@@ -0,0 +1,370 @@
+package delegation
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nexora/nexora/internal/csync"
+	"github.com/nexora/nexora/internal/pubsub"
+	"github.com/nexora/nexora/internal/shell"
+)
+
+// TaskStatusStarting is the status of a headless delegate whose process is
+// starting.
+const TaskStatusStarting TaskStatus = "starting"
+
+// EnvTaskID is set in the environment of headless delegates to the ID of
+// their task, so that they run their own delegates inline.
+const EnvTaskID = shell.EnvDelegateTask
+
+// HeartbeatInterval is how often a headless delegate writes its status to
+// show that it is alive, whether or not it made progress.
+const HeartbeatInterval = 5 * time.Second
+
+var (
+	broker   = pubsub.NewBroker[Status]()
+	statuses = csync.NewMap[string, Status]()
+)
+
+// Prompt returns the prompt of the task, with its context.
+func (t *Task) Prompt() string {
+	if t.Context == "" {
+		return t.Description
+	}
+	return fmt.Sprintf("Context:\n%s\n\nTask:\n%s", t.Context, t.Description)
+}
+
+// Status is the live status of a headless delegate. The delegate writes it to
+// its status file as it works; the parent adds what it knows of the task.
+type Status struct {
+	TaskID        string     `json:"task_id,omitempty"`
+	ParentSession string     `json:"parent_session,omitempty"`
+	Description   string     `json:"description,omitempty"`
+	Process       string     `json:"process,omitempty"` // TMUX session of the delegate
+	State         TaskStatus `json:"state"`
+	SessionID     string     `json:"session_id,omitempty"` // Session of the delegate
+	ToolCalls     int        `json:"tool_calls"`
+	LastTool      string     `json:"last_tool,omitempty"`
+	StartedAt     time.Time  `json:"started_at,omitzero"`
+	LastActivity  time.Time  `json:"last_activity,omitzero"` // Last tool result or streamed response
+	Heartbeat     time.Time  `json:"heartbeat,omitzero"`     // Last time the delegate was alive
+	Cost          float64    `json:"cost,omitempty"`
+	Error         string     `json:"error,omitempty"`
+}
+
+// Finished returns true if the delegate is done, successfully or not.
+func (s Status) Finished() bool {
+	switch s.State {
+	case TaskStatusCompleted, TaskStatusFailed, TaskStatusCancelled, TaskStatusTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// update returns s with the progress a delegate wrote to its status file.
+func (s Status) update(written Status) Status {
+	s.State = written.State
+	s.SessionID = cmp.Or(written.SessionID, s.SessionID)
+	s.ToolCalls = written.ToolCalls
+	s.LastTool = written.LastTool
+	if written.LastActivity.After(s.LastActivity) {
+		s.LastActivity = written.LastActivity
+	}
+	if written.Heartbeat.After(s.Heartbeat) {
+		s.Heartbeat = written.Heartbeat
+	}
+	s.Cost = written.Cost
+	s.Error = written.Error
+	return s
+}
+
+// SubscribeEvents returns a channel of the status changes of headless
+// delegates.
+func SubscribeEvents(ctx context.Context) <-chan pubsub.Event[Status] {
+	return broker.Subscribe(ctx)
+}
+
+// Statuses returns the statuses of the running headless delegates of a
+// session, the latest started first.
+func Statuses(parentSession string) []Status {
+	var result []Status
+	for status := range statuses.Seq() {
+		if status.ParentSession == parentSession {
+			result = append(result, status)
+		}
+	}
+	slices.SortFunc(result, func(a, b Status) int {
+		return b.StartedAt.Compare(a.StartedAt)
+	})
+	return result
+}
+
+func publish(status Status) {
+	statuses.Set(status.TaskID, status)
+	broker.Publish(pubsub.UpdatedEvent, status)
+}
+
+// Files are the files a headless delegate exchanges with the session that
+// delegated its task.
+type Files struct {
+	Prompt string // Prompt of the task, written by the parent
+	Output string // Final response, written by the delegate
+	Status string // Status, kept up to date by the delegate
+	Log    string // Standard error of the delegate
+}
+
+// TaskFiles returns the files of a task in dir.
+func TaskFiles(dir, taskID string) Files {
+	base := filepath.Join(dir, taskID)
+	return Files{
+		Prompt: base + ".prompt",
+		Output: base + ".output",
+		Status: base + ".status",
+		Log:    base + ".log",
+	}
+}
+
+// WriteStatus writes a status file. The file is replaced atomically, so that
+// it is never read half-written.
+func WriteStatus(path string, status Status) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadStatus reads a status file.
+func ReadStatus(path string) (Status, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Status{}, err
+	}
+	var status Status
+	if err := json.Unmarshal(data, &status); err != nil {
+		return Status{}, fmt.Errorf("invalid status file %s: %w", path, err)
+	}
+	return status, nil
+}
+
+// Process is the running process of a headless delegate.
+type Process interface {
+	// Name identifies the process for the user, such as its TMUX session.
+	Name() string
+	Running() bool
+	Kill() error
+}
+
+// Spawner starts the processes of headless delegates.
+type Spawner interface {
+	Spawn(task *Task, files Files) (Process, error)
+}
+
+// HeadlessConfig configures how headless delegates are run and monitored.
+type HeadlessConfig struct {
+	// Dir is the directory of the files of the delegates.
+	Dir string
+
+	// Timeout is how long a delegate may run before it is killed.
+	// Default: 30 minutes.
+	Timeout time.Duration
+
+	// IdleTimeout is how long a delegate may go without a tool call or a
+	// streamed response before it is killed. Default: 10 minutes.
+	IdleTimeout time.Duration
+
+	// HeartbeatTimeout is how long a delegate that started writing
+	// heartbeats may miss them before it is considered hung and killed.
+	// Default: 1 minute.
+	HeartbeatTimeout time.Duration
+
+	// PollInterval is how often the status file of a delegate is read.
+	// Default: 2 seconds.
+	PollInterval time.Duration
+}
+
+// HeadlessExecutor runs delegated tasks in separate nexora processes, so that
+// a crashing delegate cannot take down the session that delegated its task.
+// Prompts, statuses and results are exchanged through files.
+type HeadlessExecutor struct {
+	config  HeadlessConfig
+	spawner Spawner
+}
+
+// NewHeadlessExecutor creates an executor that starts delegates with spawner.
+func NewHeadlessExecutor(config HeadlessConfig, spawner Spawner) *HeadlessExecutor {
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Minute
+	}
+	if config.IdleTimeout == 0 {
+		config.IdleTimeout = 10 * time.Minute
+	}
+	if config.HeartbeatTimeout == 0 {
+		config.HeartbeatTimeout = time.Minute
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = 2 * time.Second
+	}
+	return &HeadlessExecutor{config: config, spawner: spawner}
+}
+
+// Execute runs a task in a headless delegate and waits for its result,
+// killing the delegate when it times out, stalls or ctx is done. The final
+// status is returned along with the result, also on failure.
+func (h *HeadlessExecutor) Execute(ctx context.Context, task *Task) (string, Status, error) {
+	now := time.Now()
+	status := Status{
+		TaskID:        task.ID,
+		ParentSession: task.ParentSession,
+		Description:   task.Description,
+		State:         TaskStatusStarting,
+		StartedAt:     now,
+		LastActivity:  now,
+	}
+	files := TaskFiles(h.config.Dir, task.ID)
+	defer cleanup(task.ID, files)
+	if err := os.MkdirAll(h.config.Dir, 0o700); err != nil {
+		return "", h.fail(status, err), err
+	}
+	if err := os.WriteFile(files.Prompt, []byte(task.Prompt()), 0o600); err != nil {
+		return "", h.fail(status, err), err
+	}
+	if err := WriteStatus(files.Status, status); err != nil {
+		return "", h.fail(status, err), err
+	}
+
+	proc, err := h.spawner.Spawn(task, files)
+	if err != nil {
+		err = fmt.Errorf("failed to start delegate: %w", err)
+		return "", h.fail(status, err), err
+	}
+	status.Process = proc.Name()
+	publish(status)
+	slog.Info("headless delegate started", "task_id", task.ID, "process", status.Process)
+
+	return h.monitor(ctx, proc, files, status)
+}
+
+// monitor follows the status file of a running delegate until it finishes.
+func (h *HeadlessExecutor) monitor(ctx context.Context, proc Process, files Files, status Status) (string, Status, error) {
+	ticker := time.NewTicker(h.config.PollInterval)
+	defer ticker.Stop()
+	deadline := time.NewTimer(h.config.Timeout)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return h.kill(proc, status, TaskStatusCancelled, ctx.Err())
+		case <-deadline.C:
+			return h.kill(proc, status, TaskStatusTimeout, fmt.Errorf("delegate timed out after %v", h.config.Timeout))
+		case <-ticker.C:
+		}
+
+		// Check the process before reading the status, as the delegate
+		// exits right after it writes its final status
+		running := proc.Running()
+		status = h.refresh(files, status)
+		switch {
+		case status.Finished():
+			return h.finish(proc, files, status)
+		case !running:
+			err := errors.New("delegate exited without a result")
+			if tail := logTail(files.Log); tail != "" {
+				err = fmt.Errorf("%w: %s", err, tail)
+			}
+			return h.kill(proc, status, TaskStatusFailed, err)
+		case !status.Heartbeat.IsZero() && time.Since(status.Heartbeat) > h.config.HeartbeatTimeout:
+			return h.kill(proc, status, TaskStatusFailed, fmt.Errorf("delegate stopped responding for %v", h.config.HeartbeatTimeout))
+		case time.Since(status.LastActivity) > h.config.IdleTimeout:
+			return h.kill(proc, status, TaskStatusTimeout, fmt.Errorf("delegate made no progress for %v", h.config.IdleTimeout))
+		}
+	}
+}
+
+// refresh returns status updated from the status file of a delegate, and
+// publishes it if it changed.
+func (h *HeadlessExecutor) refresh(files Files, status Status) Status {
+	written, err := ReadStatus(files.Status)
+	if err != nil {
+		slog.Debug("failed to read delegate status", "task_id", status.TaskID, "error", err)
+		return status
+	}
+	updated := status.update(written)
+	if updated != status {
+		publish(updated)
+	}
+	return updated
+}
+
+// finish collects the result of a delegate that finished.
+func (h *HeadlessExecutor) finish(proc Process, files Files, status Status) (string, Status, error) {
+	// The delegate exits when it is done; this only cleans up its session
+	_ = proc.Kill()
+
+	if status.State != TaskStatusCompleted {
+		return "", status, fmt.Errorf("delegate %s: %s", status.State, cmp.Or(status.Error, "no error reported"))
+	}
+	output, err := os.ReadFile(files.Output)
+	if err != nil {
+		err = fmt.Errorf("failed to read delegate result: %w", err)
+		return "", h.fail(status, err), err
+	}
+	slog.Info("headless delegate completed", "task_id", status.TaskID, "tool_calls", status.ToolCalls)
+	return string(output), status, nil
+}
+
+// kill kills a delegate that did not finish.
+func (h *HeadlessExecutor) kill(proc Process, status Status, state TaskStatus, err error) (string, Status, error) {
+	if killErr := proc.Kill(); killErr != nil && proc.Running() {
+		slog.Warn("failed to kill delegate", "task_id", status.TaskID, "process", proc.Name(), "error", killErr)
+	}
+	status.State = state
+	status.Error = err.Error()
+	publish(status)
+	slog.Warn("headless delegate stopped", "task_id", status.TaskID, "state", state, "error", err)
+	return "", status, err
+}
+
+// fail records that a delegate failed before or after it ran.
+func (h *HeadlessExecutor) fail(status Status, err error) Status {
+	status.State = TaskStatusFailed
+	status.Error = err.Error()
+	publish(status)
+	return status
+}
+
+// cleanup forgets a task once its final status is published, and removes the
+// files it exchanged with its delegate.
+func cleanup(taskID string, files Files) {
+	statuses.Del(taskID)
+	for _, path := range []string{files.Prompt, files.Output, files.Status, files.Status + ".tmp", files.Log} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Debug("failed to remove delegate file", "task_id", taskID, "path", path, "error", err)
+		}
+	}
+}
+
+// logTail returns the last line of a delegate's log.
+func logTail(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,254 @@
+package delegation
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeProcess is a delegate that runs a function in a goroutine.
+type fakeProcess struct {
+	running atomic.Bool
+	killed  atomic.Bool
+	done    chan struct{}
+}
+
+func (p *fakeProcess) Name() string  { return "fake" }
+func (p *fakeProcess) Running() bool { return p.running.Load() }
+func (p *fakeProcess) Kill() error {
+	p.killed.Store(true)
+	p.running.Store(false)
+	// Like a killed process, the delegate writes nothing after this
+	<-p.done
+	return nil
+}
+
+// fakeSpawner starts fake delegates that run delegate.
+type fakeSpawner struct {
+	delegate func(task *Task, files Files)
+	mu       sync.Mutex
+	procs    []*fakeProcess
+}
+
+func (s *fakeSpawner) Spawn(task *Task, files Files) (Process, error) {
+	if s.delegate == nil {
+		return nil, errors.New("no tmux")
+	}
+	proc := &fakeProcess{done: make(chan struct{})}
+	proc.running.Store(true)
+	s.mu.Lock()
+	s.procs = append(s.procs, proc)
+	s.mu.Unlock()
+	go func() {
+		s.delegate(task, files)
+		proc.running.Store(false)
+		close(proc.done)
+	}()
+	return proc, nil
+}
+
+func testExecutor(t *testing.T, spawner Spawner) *HeadlessExecutor {
+	return NewHeadlessExecutor(HeadlessConfig{
+		Dir:          t.TempDir(),
+		Timeout:      5 * time.Second,
+		PollInterval: 10 * time.Millisecond,
+	}, spawner)
+}
+
+// assertCleanedUp checks that the files of a finished task were removed.
+func assertCleanedUp(t *testing.T, dir, taskID string) {
+	t.Helper()
+	files := TaskFiles(dir, taskID)
+	for _, path := range []string{files.Prompt, files.Output, files.Status, files.Log} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", filepath.Base(path))
+		}
+	}
+}
+
+func TestHeadlessExecutorCompletes(t *testing.T) {
+	prompts := make(chan string, 1)
+	spawner := &fakeSpawner{delegate: func(task *Task, files Files) {
+		data, _ := os.ReadFile(files.Prompt)
+		prompts <- string(data)
+		now := time.Now()
+		_ = WriteStatus(files.Status, Status{State: TaskStatusRunning, SessionID: "child", ToolCalls: 2, LastTool: "edit", LastActivity: now})
+		time.Sleep(30 * time.Millisecond)
+		_ = os.WriteFile(files.Output, []byte("Fixed the parser."), 0o600)
+		_ = WriteStatus(files.Status, Status{State: TaskStatusCompleted, SessionID: "child", ToolCalls: 3, LastTool: "bash", LastActivity: now, Cost: 0.25})
+	}}
+	executor := testExecutor(t, spawner)
+
+	// The subscription is kept, as the broker may still be publishing to it
+	// from the next test when it is closed
+	events := SubscribeEvents(context.Background())
+
+	task := &Task{ID: "task-complete", Description: "Fix the parser", Context: "It fails on tabs", ParentSession: "parent-complete"}
+	result, status, err := executor.Execute(context.Background(), task)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if result != "Fixed the parser." {
+		t.Errorf("unexpected result %q", result)
+	}
+	if prompt := <-prompts; prompt != "Context:\nIt fails on tabs\n\nTask:\nFix the parser" {
+		t.Errorf("unexpected prompt %q", prompt)
+	}
+	if status.State != TaskStatusCompleted || status.ToolCalls != 3 || status.Cost != 0.25 || status.SessionID != "child" {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if status.TaskID != task.ID || status.Description != task.Description || status.Process != "fake" {
+		t.Errorf("expected the status to keep the task, got %+v", status)
+	}
+	assertCleanedUp(t, executor.config.Dir, task.ID)
+
+	var published Status
+	for len(events) > 0 {
+		if event := <-events; event.Payload.TaskID == task.ID {
+			published = event.Payload
+		}
+	}
+	if published.State != TaskStatusCompleted {
+		t.Errorf("expected the final status of the delegate to be published, got %+v", published)
+	}
+	if statuses := Statuses("parent-complete"); len(statuses) != 0 {
+		t.Errorf("expected the finished delegate to be forgotten, got %+v", statuses)
+	}
+}
+
+func TestHeadlessExecutorFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   func(*HeadlessConfig)
+		delegate func(task *Task, files Files)
+		state    TaskStatus
+		err      string
+		killed   bool
+	}{
+		{
+			name: "crash",
+			delegate: func(_ *Task, files Files) {
+				_ = os.WriteFile(files.Log, []byte("starting\npanic: nil map\n"), 0o600)
+			},
+			state: TaskStatusFailed,
+			err:   "delegate exited without a result: panic: nil map",
+		},
+		{
+			name: "reported failure",
+			delegate: func(_ *Task, files Files) {
+				_ = WriteStatus(files.Status, Status{State: TaskStatusFailed, Error: "no providers configured"})
+			},
+			state: TaskStatusFailed,
+			err:   "no providers configured",
+		},
+		{
+			name:     "timeout",
+			config:   func(c *HeadlessConfig) { c.Timeout = 50 * time.Millisecond },
+			delegate: func(*Task, Files) { time.Sleep(200 * time.Millisecond) },
+			state:    TaskStatusTimeout,
+			err:      "timed out",
+			killed:   true,
+		},
+		{
+			name:     "stalled",
+			config:   func(c *HeadlessConfig) { c.IdleTimeout = 50 * time.Millisecond },
+			delegate: func(*Task, Files) { time.Sleep(200 * time.Millisecond) },
+			state:    TaskStatusTimeout,
+			err:      "no progress",
+			killed:   true,
+		},
+		{
+			name:   "alive but stalled",
+			config: func(c *HeadlessConfig) { c.IdleTimeout = 50 * time.Millisecond },
+			delegate: func(_ *Task, files Files) {
+				// Heartbeats are no progress
+				started := time.Now()
+				for range 20 {
+					_ = WriteStatus(files.Status, Status{State: TaskStatusRunning, LastActivity: started, Heartbeat: time.Now()})
+					time.Sleep(10 * time.Millisecond)
+				}
+			},
+			state:  TaskStatusTimeout,
+			err:    "no progress",
+			killed: true,
+		},
+		{
+			name:   "hung",
+			config: func(c *HeadlessConfig) { c.HeartbeatTimeout = 50 * time.Millisecond },
+			delegate: func(_ *Task, files Files) {
+				_ = WriteStatus(files.Status, Status{State: TaskStatusRunning, LastActivity: time.Now(), Heartbeat: time.Now()})
+				time.Sleep(200 * time.Millisecond)
+			},
+			state:  TaskStatusFailed,
+			err:    "stopped responding",
+			killed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spawner := &fakeSpawner{delegate: tt.delegate}
+			config := HeadlessConfig{Dir: t.TempDir(), Timeout: 5 * time.Second, PollInterval: 10 * time.Millisecond}
+			if tt.config != nil {
+				tt.config(&config)
+			}
+			executor := NewHeadlessExecutor(config, spawner)
+
+			_, status, err := executor.Execute(context.Background(), &Task{ID: "task-" + tt.name, ParentSession: "parent-failures"})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+			}
+			if status.State != tt.state {
+				t.Errorf("expected state %s, got %s", tt.state, status.State)
+			}
+			if tt.killed && !spawner.procs[0].killed.Load() {
+				t.Error("expected the delegate to be killed")
+			}
+			assertCleanedUp(t, config.Dir, "task-"+tt.name)
+		})
+	}
+}
+
+func TestHeadlessExecutorCancel(t *testing.T) {
+	spawner := &fakeSpawner{delegate: func(*Task, Files) { time.Sleep(200 * time.Millisecond) }}
+	executor := testExecutor(t, spawner)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, status, err := executor.Execute(ctx, &Task{ID: "task-cancel"})
+	if !errors.Is(err, context.DeadlineExceeded) || status.State != TaskStatusCancelled {
+		t.Errorf("expected the delegate to be cancelled, got %s (%v)", status.State, err)
+	}
+	if !spawner.procs[0].killed.Load() {
+		t.Error("expected the delegate to be killed")
+	}
+}
+
+func TestHeadlessExecutorSpawnFailure(t *testing.T) {
+	executor := testExecutor(t, &fakeSpawner{})
+	_, status, err := executor.Execute(context.Background(), &Task{ID: "task-spawn"})
+	if err == nil || status.State != TaskStatusFailed {
+		t.Errorf("expected the task to fail, got %s (%v)", status.State, err)
+	}
+}
+
+func TestTmuxSpawnerCommand(t *testing.T) {
+	spawner := NewTmuxSpawner(nil, "/usr/local/bin/nexora", "/home/me/.nexora")
+	task := &Task{ID: "0123456789", WorkingDir: "/home/me/my project", Agent: "reviewer", ParentSession: "parent"}
+	command := spawner.Command(task, TaskFiles("/tmp/delegates", task.ID))
+
+	want := "exec env NEXORA_DELEGATE_TASK=0123456789 /usr/local/bin/nexora run --quiet --cwd '/home/me/my project' --data-dir /home/me/.nexora" +
+		" --prompt-file /tmp/delegates/0123456789.prompt --output-file /tmp/delegates/0123456789.output" +
+		" --status-file /tmp/delegates/0123456789.status --agent reviewer --parent-session parent 2>/tmp/delegates/0123456789.log"
+	if command != want {
+		t.Errorf("unexpected command:\n%s\nwant:\n%s", command, want)
+	}
+	if got := shellQuote("it's"); got != `'it'\''s'` {
+		t.Errorf("unexpected quoting %s", got)
+	}
+}
@@ -22,7 +22,8 @@ type PoolConfig struct {
 	// Default: 30 minutes.
 	QueueTimeout time.Duration
 
-	// CPUPerAgent is the expected CPU percentage per agent.
+	// CPUPerAgent is the expected CPU percentage per agent, of the CPUs
+	// available to nexora (its cgroup's limit in a container).
 	// Used for dynamic sizing. Default: 15%.
 	CPUPerAgent float64
 
@@ -37,6 +38,11 @@ type PoolConfig struct {
 	// MinFreeCPU is the minimum free CPU percentage to maintain.
 	// Default: 20%.
 	MinFreeCPU float64
+
+	// ProcsPerAgent is the expected number of processes per agent, counted
+	// against the cgroup's process limit when there is one.
+	// Default: 16.
+	ProcsPerAgent uint64
 }
 
 // DefaultPoolConfig returns sensible defaults for the pool.
@@ -48,6 +54,7 @@ func DefaultPoolConfig() PoolConfig {
 		MemPerAgent:   256 * 1024 * 1024, // Reduced from 512MB - delegates share model
 		MinFreeMemory: 512 * 1024 * 1024, // Reduced from 1GB - more permissive
 		MinFreeCPU:    10.0,              // Reduced from 20% - more permissive
+		ProcsPerAgent: 16,
 	}
 }
 
@@ -70,6 +77,7 @@ type Task struct {
 	Context       string
 	WorkingDir    string
 	MaxTokens     int64
+	Agent         string // user-defined agent to run the task, if any
 	Status        TaskStatus
 	Result        string
 	Error         error
@@ -87,6 +95,9 @@ type Pool struct {
 	config  PoolConfig
 	monitor *resources.Monitor
 
+	// snapshot returns the monitor's latest resource usage.
+	snapshot func() resources.ResourceSnapshot
+
 	// Active tasks
 	running   map[string]*Task
 	queued    []*Task
@@ -124,14 +135,21 @@ func NewPool(config PoolConfig, monitor *resources.Monitor) *Pool {
 	if config.MinFreeCPU == 0 {
 		config.MinFreeCPU = 20.0
 	}
+	if config.ProcsPerAgent == 0 {
+		config.ProcsPerAgent = 16
+	}
 
-	return &Pool{
+	p := &Pool{
 		config:    config,
 		monitor:   monitor,
 		running:   make(map[string]*Task),
 		queued:    make([]*Task, 0),
 		completed: make(map[string]*Task),
 	}
+	if monitor != nil {
+		p.snapshot = monitor.CurrentSnapshot
+	}
+	return p
 }
 
 // SetExecutor sets the task execution function.
@@ -175,6 +193,11 @@ func (p *Pool) Stop() {
 // Submit adds a task to the pool.
 // Returns the task ID and a channel that closes when the task completes.
 func (p *Pool) Submit(description, taskContext, workingDir string, maxTokens int64, parentSession string) (string, <-chan struct{}, error) {
+	return p.SubmitAs("", description, taskContext, workingDir, maxTokens, parentSession)
+}
+
+// SubmitAs is like Submit, but the task is run by the named user-defined agent.
+func (p *Pool) SubmitAs(agent, description, taskContext, workingDir string, maxTokens int64, parentSession string) (string, <-chan struct{}, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -188,6 +211,7 @@ func (p *Pool) Submit(description, taskContext, workingDir string, maxTokens int
 		Context:       taskContext,
 		WorkingDir:    workingDir,
 		MaxTokens:     maxTokens,
+		Agent:         agent,
 		Status:        TaskStatusQueued,
 		CreatedAt:     time.Now(),
 		ParentSession: parentSession,
@@ -302,18 +326,20 @@ func (p *Pool) maxConcurrent() int {
 	}
 
 	// Dynamic calculation based on resources
-	if p.monitor == nil {
+	if p.snapshot == nil {
 		return 3 // Fallback
 	}
 
-	snapshot := p.monitor.CurrentSnapshot()
+	// The snapshot is of the cgroup's budget when nexora runs in a
+	// container, so this sizes the pool for the container.
+	snapshot := p.snapshot()
 
 	// Calculate based on CPU
 	availableCPU := 100.0 - snapshot.CPUPercent - p.config.MinFreeCPU
 	cpuAgents := int(availableCPU / p.config.CPUPerAgent)
 
-	// Calculate based on memory (available = total - used)
-	memAvailable := snapshot.MemTotal - snapshot.MemUsed
+	// Calculate based on memory
+	memAvailable := snapshot.MemAvailable()
 	if memAvailable < p.config.MinFreeMemory {
 		memAvailable = 0
 	} else {
@@ -323,6 +349,11 @@ func (p *Pool) maxConcurrent() int {
 
 	// Use minimum of both
 	max := min(cpuAgents, memAgents)
+
+	// And of the processes the cgroup allows
+	if pidsAvailable, ok := pidsAvailable(snapshot); ok {
+		max = min(max, int(pidsAvailable/p.config.ProcsPerAgent))
+	}
 	if max < 1 {
 		max = 1 // Always allow at least 1
 	}
@@ -339,11 +370,11 @@ func (p *Pool) canSpawn() bool {
 		return false
 	}
 
-	if p.monitor == nil {
+	if p.snapshot == nil {
 		return true
 	}
 
-	snapshot := p.monitor.CurrentSnapshot()
+	snapshot := p.snapshot()
 
 	// Check CPU headroom
 	if snapshot.CPUPercent > (100.0 - p.config.MinFreeCPU - p.config.CPUPerAgent) {
@@ -354,8 +385,8 @@ func (p *Pool) canSpawn() bool {
 		return false
 	}
 
-	// Check memory headroom (available = total - used)
-	memAvailable := snapshot.MemTotal - snapshot.MemUsed
+	// Check memory headroom
+	memAvailable := snapshot.MemAvailable()
 	if memAvailable < p.config.MinFreeMemory+p.config.MemPerAgent {
 		slog.Debug("cannot spawn: memory constraint",
 			"available", memAvailable,
@@ -364,9 +395,30 @@ func (p *Pool) canSpawn() bool {
 		return false
 	}
 
+	// Check process headroom
+	if pidsAvailable, ok := pidsAvailable(snapshot); ok && pidsAvailable < p.config.ProcsPerAgent {
+		slog.Debug("cannot spawn: process constraint",
+			"available", pidsAvailable,
+			"required", p.config.ProcsPerAgent,
+		)
+		return false
+	}
+
 	return true
 }
 
+// pidsAvailable returns how many more processes the cgroup allows, if it
+// limits them.
+func pidsAvailable(snapshot resources.ResourceSnapshot) (uint64, bool) {
+	if snapshot.PidsMax == 0 {
+		return 0, false
+	}
+	if snapshot.PidsCurrent >= snapshot.PidsMax {
+		return 0, true
+	}
+	return snapshot.PidsMax - snapshot.PidsCurrent, true
+}
+
 // processQueue is the main queue processing loop.
 func (p *Pool) processQueue() {
 	defer p.wg.Done()
@@ -165,6 +165,25 @@ func TestPool_Submit(t *testing.T) {
 	}
 }
 
+func TestPool_SubmitAs(t *testing.T) {
+	pool := NewPool(DefaultPoolConfig(), nil)
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	id, _, err := pool.SubmitAs("reviewer", "review", "", "/tmp", 1000, "session-1")
+	if err != nil {
+		t.Fatalf("SubmitAs failed: %v", err)
+	}
+
+	task, ok := pool.GetTask(id)
+	if !ok {
+		t.Fatal("task not found after submission")
+	}
+	if task.Agent != "reviewer" {
+		t.Errorf("expected agent='reviewer', got %q", task.Agent)
+	}
+}
+
 func TestPool_GetTask(t *testing.T) {
 	pool := NewPool(DefaultPoolConfig(), nil)
 	ctx := context.Background()
@@ -675,3 +694,52 @@ func TestPool_ParallelExecution(t *testing.T) {
 		}
 	}
 }
+
+func TestPool_MaxConcurrent_CgroupBudget(t *testing.T) {
+	config := DefaultPoolConfig()
+	pool := NewPool(config, nil)
+
+	// A container limited to 2GB and 100 processes on a much larger host.
+	snapshot := resources.ResourceSnapshot{
+		Cgroup:      true,
+		CPUPercent:  10,
+		CPULimit:    2,
+		MemUsed:     512 * 1024 * 1024,
+		MemTotal:    2 * 1024 * 1024 * 1024,
+		PidsCurrent: 20,
+		PidsMax:     100,
+	}
+	pool.snapshot = func() resources.ResourceSnapshot { return snapshot }
+
+	// (2GB - 512MB used - 512MB free) / 256MB per agent
+	if max := pool.maxConcurrent(); max != 4 {
+		t.Errorf("expected maxConcurrent=4 from memory, got %d", max)
+	}
+	if !pool.canSpawn() {
+		t.Error("expected canSpawn with memory and processes left")
+	}
+
+	// 80 processes left at 16 per agent
+	snapshot.MemUsed = 0
+	if max := pool.maxConcurrent(); max != 5 {
+		t.Errorf("expected maxConcurrent=5 from processes, got %d", max)
+	}
+
+	snapshot.PidsCurrent = 90
+	if max := pool.maxConcurrent(); max != 1 {
+		t.Errorf("expected maxConcurrent=1 at the process limit, got %d", max)
+	}
+	if pool.canSpawn() {
+		t.Error("expected no spawn with 10 processes left")
+	}
+
+	// Used memory above the limit doesn't wrap around
+	snapshot.PidsCurrent = 20
+	snapshot.MemUsed = 3 * 1024 * 1024 * 1024
+	if max := pool.maxConcurrent(); max != 1 {
+		t.Errorf("expected maxConcurrent=1 over the memory limit, got %d", max)
+	}
+	if pool.canSpawn() {
+		t.Error("expected no spawn over the memory limit")
+	}
+}
@@ -0,0 +1,104 @@
+package delegation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nexora/nexora/internal/shell"
+	"github.com/nexora/nexora/internal/stringext"
+)
+
+// TmuxSpawner starts headless delegates as nexora processes in TMUX sessions,
+// where the user can attach to watch them work.
+type TmuxSpawner struct {
+	tmux       *shell.TmuxManager
+	executable string
+	dataDir    string
+}
+
+// NewTmuxSpawner creates a spawner that runs the nexora executable with the
+// data directory of the parent, so that delegates share its database.
+func NewTmuxSpawner(tmux *shell.TmuxManager, executable, dataDir string) *TmuxSpawner {
+	return &TmuxSpawner{
+		tmux:       tmux,
+		executable: executable,
+		dataDir:    dataDir,
+	}
+}
+
+func (s *TmuxSpawner) Spawn(task *Task, files Files) (Process, error) {
+	id := "delegate-" + shortID(task.ID)
+	description := stringext.Truncate("Delegate: "+task.Description, 80)
+	session, err := s.tmux.NewTmuxSession(id, task.WorkingDir, s.Command(task, files), description)
+	if err != nil {
+		return nil, err
+	}
+	return &tmuxProcess{tmux: s.tmux, id: id, name: session.SessionName}, nil
+}
+
+// Command returns the shell command that runs the delegate of a task. The
+// shell is replaced by nexora, so the TMUX session ends when it exits.
+func (s *TmuxSpawner) Command(task *Task, files Files) string {
+	args := []string{
+		"exec", "env", EnvTaskID + "=" + task.ID,
+		s.executable, "run", "--quiet",
+		"--cwd", task.WorkingDir,
+		"--data-dir", s.dataDir,
+		"--prompt-file", files.Prompt,
+		"--output-file", files.Output,
+		"--status-file", files.Status,
+	}
+	if task.Agent != "" {
+		args = append(args, "--agent", task.Agent)
+	}
+	if task.ParentSession != "" {
+		args = append(args, "--parent-session", task.ParentSession)
+	}
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return fmt.Sprintf("%s 2>%s", strings.Join(quoted, " "), shellQuote(files.Log))
+}
+
+// tmuxProcess is a delegate running in a TMUX session.
+type tmuxProcess struct {
+	tmux *shell.TmuxManager
+	id   string
+	name string
+}
+
+func (p *tmuxProcess) Name() string {
+	return p.name
+}
+
+func (p *tmuxProcess) Running() bool {
+	return p.tmux.IsSessionRunning(p.id)
+}
+
+func (p *tmuxProcess) Kill() error {
+	if !p.tmux.IsSessionRunning(p.id) {
+		// The delegate exited, and its session with it
+		p.tmux.RemoveSession(p.id)
+		return nil
+	}
+	return p.tmux.KillSession(p.id)
+}
+
+// shortID returns the first 8 characters of a task ID.
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// shellQuote quotes s for a POSIX shell, unless it only has safe characters.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:@%+,", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,402 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/nexora/nexora/internal/agent/recovery"
+	"github.com/nexora/nexora/internal/agent/state"
+	"github.com/nexora/nexora/internal/agent/tools"
+	"github.com/nexora/nexora/internal/aiops"
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/message"
+)
+
+//go:embed templates/loop_check.md
+var loopCheckPrompt []byte
+
+const (
+	defaultLoopCheckInterval = 5
+	defaultLoopCheckTimeout  = 10 * time.Second
+)
+
+// newLoopPolicy returns the loop policy of cfg.
+func (a *sessionAgent) newLoopPolicy(cfg config.AIOPSLoopDetectionConfig) state.LoopPolicy {
+	interval := cmp.Or(cfg.CheckInterval, defaultLoopCheckInterval)
+	timeout := cmp.Or(cfg.Timeout, defaultLoopCheckTimeout)
+	switch cfg.Policy {
+	case "", config.LoopPolicyHeuristic:
+		return state.HeuristicPolicy{}
+	case config.LoopPolicyModel:
+		return &modelLoopPolicy{
+			// The small model can change while the agent runs
+			model:    func() Model { return a.smallModel },
+			interval: interval,
+			timeout:  timeout,
+		}
+	case config.LoopPolicyAIOPS:
+		if a.aiops == nil {
+			return state.HeuristicPolicy{}
+		}
+		return &aiopsLoopPolicy{ops: a.aiops, interval: interval, timeout: timeout}
+	default:
+		slog.Warn("unknown loop policy, using heuristics", "policy", cfg.Policy)
+		return state.HeuristicPolicy{}
+	}
+}
+
+// checkDue returns true if the tool call just recorded in obs is one every
+// interval at which a slower check runs.
+func checkDue(obs state.Observation, interval int) bool {
+	return obs.TotalActions > 0 && obs.TotalActions%interval == 0 && len(obs.Actions) >= interval
+}
+
+// modelLoopPolicy adds the judgment of the small model to the heuristics
+// every interval tool calls. The heuristics decide alone when to halt.
+type modelLoopPolicy struct {
+	heuristic state.HeuristicPolicy
+	model     func() Model
+	interval  int
+	timeout   time.Duration
+}
+
+func (p *modelLoopPolicy) Name() string { return config.LoopPolicyModel }
+
+func (p *modelLoopPolicy) Evaluate(ctx context.Context, obs state.Observation) (state.Verdict, error) {
+	verdict, err := p.heuristic.Evaluate(ctx, obs)
+	if err != nil || verdict.Stuck() || !checkDue(obs, p.interval) {
+		return verdict, err
+	}
+	model := p.model()
+	if model.Model == nil {
+		return verdict, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	judged, err := p.ask(ctx, model, obs)
+	if err != nil {
+		slog.Debug("loop check of the small model failed", "error", err)
+		return verdict, nil
+	}
+	return judged, nil
+}
+
+// ask has model judge obs.
+func (p *modelLoopPolicy) ask(ctx context.Context, model Model, obs state.Observation) (state.Verdict, error) {
+	var maxOutput int64 = 300
+	if model.CatwalkCfg.CanReason && model.CatwalkCfg.DefaultMaxTokens > maxOutput {
+		maxOutput = model.CatwalkCfg.DefaultMaxTokens
+	}
+	agent := fantasy.NewAgent(model.Model,
+		fantasy.WithSystemPrompt(string(loopCheckPrompt)),
+	)
+	resp, err := agent.Stream(ctx, fantasy.AgentStreamCall{
+		Prompt:          describeObservation(obs),
+		MaxOutputTokens: &maxOutput,
+	})
+	if err != nil {
+		return state.Verdict{}, err
+	}
+	return parseModelVerdict(resp.Response.Content.Text(), p.Name())
+}
+
+// parseModelVerdict parses the JSON answer of the small model. Answers with
+// little confidence don't count.
+func parseModelVerdict(text, policy string) (state.Verdict, error) {
+	// Reasoning models may think out loud before answering
+	if idx := strings.LastIndex(text, "</think>"); idx >= 0 {
+		text = text[idx+len("</think>"):]
+	}
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return state.Verdict{}, errors.New("no JSON object in the answer")
+	}
+	var answer struct {
+		Verdict    string  `json:"verdict"`
+		Reason     string  `json:"reason"`
+		Suggestion string  `json:"suggestion"`
+		Confidence float64 `json:"confidence"`
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &answer); err != nil {
+		return state.Verdict{}, fmt.Errorf("invalid answer: %w", err)
+	}
+
+	verdict := state.Verdict{Policy: policy}
+	if answer.Confidence < 0.5 {
+		return verdict, nil
+	}
+	switch answer.Verdict {
+	case "loop":
+		verdict.Pattern = state.PatternNoProgress
+	case "drift":
+		verdict.Pattern = state.PatternDrift
+	default:
+		return verdict, nil
+	}
+	verdict.Severity = state.SeverityWarn
+	verdict.Reason = answer.Reason
+	verdict.Suggestion = answer.Suggestion
+	verdict.Score = answer.Confidence
+	if verdict.Score > 1 {
+		verdict.Score = 1
+	}
+	return verdict, nil
+}
+
+// describeObservation describes obs to the small model.
+func describeObservation(obs state.Observation) string {
+	var b strings.Builder
+	if obs.Task != "" {
+		fmt.Fprintf(&b, "Task:\n%s\n\n", truncateText(obs.Task, 2000))
+	}
+	b.WriteString("Recent tool calls, oldest first:\n")
+	for i, action := range obs.Actions {
+		fmt.Fprintf(&b, "%d. %s", i+1, action.ToolName)
+		if action.TargetFile != "" {
+			fmt.Fprintf(&b, " %s", action.TargetFile)
+		}
+		if action.Command != "" {
+			fmt.Fprintf(&b, " `%s`", truncateText(action.Command, 200))
+		}
+		if action.Success {
+			b.WriteString(": ok\n")
+		} else {
+			fmt.Fprintf(&b, ": failed (error %s)\n", action.ErrorHash)
+		}
+	}
+	fmt.Fprintf(&b, "\nFiles changed in the session: %d\n", len(obs.FilesModified))
+	if n := len(obs.Tests); n > 0 {
+		b.WriteString("Recent test runs, oldest first:\n")
+		for _, test := range obs.Tests[max(0, n-5):] {
+			outcome := "passed"
+			if !test.Passed {
+				outcome = "failed"
+			}
+			fmt.Fprintf(&b, "- `%s`: %s\n", truncateText(test.Command, 200), outcome)
+		}
+	}
+	return b.String()
+}
+
+// aiopsLoopPolicy adds the loop detection of the AIOPS service to the
+// heuristics every interval tool calls, and its drift detection every other
+// check. The heuristics decide alone when to halt.
+type aiopsLoopPolicy struct {
+	heuristic state.HeuristicPolicy
+	ops       aiops.Ops
+	interval  int
+	timeout   time.Duration
+}
+
+func (p *aiopsLoopPolicy) Name() string { return config.LoopPolicyAIOPS }
+
+func (p *aiopsLoopPolicy) Evaluate(ctx context.Context, obs state.Observation) (state.Verdict, error) {
+	verdict, err := p.heuristic.Evaluate(ctx, obs)
+	if err != nil || verdict.Stuck() || !checkDue(obs, p.interval) {
+		return verdict, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	calls := make([]aiops.ToolCall, len(obs.Actions))
+	for i, action := range obs.Actions {
+		calls[i] = aiops.ToolCall{
+			Name:      action.ToolName,
+			Params:    map[string]any{"file_path": action.TargetFile, "command": action.Command},
+			Timestamp: action.Timestamp,
+		}
+		if !action.Success {
+			calls[i].Error = action.ErrorHash
+		}
+	}
+	detection, err := p.ops.DetectLoop(ctx, calls)
+	if err != nil {
+		slog.Debug("loop detection of the AIOPS service failed", "error", err)
+		return verdict, nil
+	}
+	if detection.IsLooping {
+		pattern := detection.PatternType
+		if pattern == "" || pattern == "repetition" {
+			pattern = state.PatternRepeatedError
+		}
+		return state.Verdict{
+			Severity:   state.SeverityWarn,
+			Pattern:    pattern,
+			Reason:     detection.Reason,
+			Suggestion: detection.Suggestion,
+			Score:      1,
+			Policy:     p.Name(),
+		}, nil
+	}
+
+	if obs.Task == "" || !checkDue(obs, 2*p.interval) {
+		return verdict, nil
+	}
+	actions := make([]aiops.Action, len(calls))
+	for i, call := range calls {
+		actions[i] = aiops.Action{
+			Description: fmt.Sprintf("Tool call: %s", call.Name),
+			ToolCalls:   []aiops.ToolCall{call},
+			Timestamp:   call.Timestamp,
+		}
+	}
+	drift, err := p.ops.DetectDrift(ctx, obs.Task, actions)
+	if err != nil {
+		slog.Debug("drift detection of the AIOPS service failed", "error", err)
+		return verdict, nil
+	}
+	if drift.IsDrifting {
+		return state.Verdict{
+			Severity:   state.SeverityWarn,
+			Pattern:    state.PatternDrift,
+			Reason:     drift.Reason,
+			Suggestion: drift.Suggestion,
+			Score:      drift.DriftScore,
+			Policy:     p.Name(),
+		}, nil
+	}
+	return verdict, nil
+}
+
+// fileModifyingTools are the tools whose target file changes when they
+// succeed.
+var fileModifyingTools = map[string]bool{
+	tools.EditToolName:         true,
+	tools.MultiEditToolName:    true,
+	tools.WriteToolName:        true,
+	tools.SmartEditToolName:    true,
+	tools.NotebookEditToolName: true,
+}
+
+var (
+	testCommandPattern = regexp.MustCompile(`\b(go test|(npm|pnpm|yarn|bun)( run)? test|pytest|cargo (nextest|test)|make (check|test)|jest|vitest|mvn test|gradle test|rspec|phpunit|dotnet test)\b`)
+	exitCodePattern    = regexp.MustCompile(`Exit code [1-9][0-9]*`)
+)
+
+// recordToolCall records a tool call in the state machine of a session with
+// the evidence its loop policy works from: the target of the call, the
+// content of the file it changed and the outcome of the tests it ran.
+func (a *sessionAgent) recordToolCall(ctx context.Context, sm *state.StateMachine, toolName, input, output, errorMsg string) {
+	var params map[string]any
+	_ = json.Unmarshal([]byte(input), &params)
+	name := tools.ResolveToolName(toolName)
+	success := errorMsg == ""
+
+	var targetFile, command string
+	for _, key := range []string{"file_path", "notebook_path", "path"} {
+		if v, ok := params[key].(string); ok && v != "" {
+			targetFile = v
+			break
+		}
+	}
+	if name == tools.BashToolName {
+		command, _ = params["command"].(string)
+	}
+
+	if success && targetFile != "" && fileModifyingTools[name] {
+		if content, err := os.ReadFile(targetFile); err == nil {
+			sm.RecordFileModification(targetFile, fmt.Sprintf("%x", sha256.Sum256(content))[:16])
+		}
+	}
+	if command != "" && testCommandPattern.MatchString(command) {
+		passed := success && !exitCodePattern.MatchString(output)
+		sm.RecordTest(command, passed, lastLines(output, 20))
+	}
+	sm.RecordToolCall(ctx, name, targetFile, command, errorMsg, success)
+}
+
+// loopWarnings are the warnings given to the model of a session that it may
+// be stuck.
+type loopWarnings struct {
+	count  int
+	reason string
+	at     int // Tool call count of the last warning
+}
+
+// handleLoopVerdict acts on a verdict that a session may be stuck through
+// the loop recovery strategy: a warning is passed on to the model until the
+// strategy runs out of warnings, and any other verdict pauses the session.
+// It returns an error when the session is paused.
+func (a *sessionAgent) handleLoopVerdict(ctx context.Context, sm *state.StateMachine, sessionID string, verdict state.Verdict) error {
+	warnings, _ := a.loopWarnings.Get(sessionID)
+	toolCalls := sm.GetToolCallCount()
+	if verdict.Severity == state.SeverityWarn && verdict.Reason == warnings.reason &&
+		toolCalls-warnings.at < defaultLoopCheckInterval {
+		return nil // Give the model time to act on the last warning
+	}
+
+	execCtx := state.NewAgentExecutionContext(sessionID)
+	execCtx.RetryCount = warnings.count
+	execCtx.StuckCount = warnings.count
+	recoveryErr := a.recoveryRegistry.AttemptRecovery(ctx, recovery.NewLoopVerdictError(verdict), execCtx)
+	if recoveryErr == nil {
+		slog.Warn("session may be stuck - warning the model",
+			"session_id", sessionID,
+			"pattern", verdict.Pattern,
+			"reason", verdict.Reason,
+			"policy", verdict.Policy,
+			"warning", execCtx.RetryCount,
+		)
+		a.loopWarnings.Set(sessionID, loopWarnings{count: execCtx.RetryCount, reason: verdict.Reason, at: toolCalls})
+		title := "Progress warning"
+		if verdict.Pattern == state.PatternDrift {
+			title = "Task drift warning"
+		}
+		_, _ = a.messages.Create(ctx, sessionID, message.CreateMessageParams{
+			Role: message.System,
+			Parts: []message.ContentPart{
+				message.TextContent{
+					Text: fmt.Sprintf("⚠️ %s: %s\n\n%s", title, verdict.Reason, verdict.Suggestion),
+				},
+			},
+		})
+		return nil
+	}
+
+	slog.Warn("session stuck - pausing",
+		"session_id", sessionID,
+		"pattern", verdict.Pattern,
+		"reason", verdict.Reason,
+		"policy", verdict.Policy,
+		"tool_calls", toolCalls,
+		"recovery_error", recoveryErr,
+	)
+	a.loopWarnings.Del(sessionID)
+	a.pause(ctx, sm, state.StateLoopPaused, verdict.Reason)
+	_, _ = a.messages.Create(ctx, sessionID, message.CreateMessageParams{
+		Role: message.System,
+		Parts: []message.ContentPart{
+			message.TextContent{
+				Text: fmt.Sprintf("🛑 Loop detected: %s\n\nThe session is paused to prevent an infinite loop. Please review the error, then resume the session or send a prompt with a different approach.", verdict.Reason),
+			},
+		},
+	})
+	return fmt.Errorf("loop detected: %s", verdict.Reason)
+}
+
+// truncateText truncates s to n bytes.
+func truncateText(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// lastLines returns the last n lines of s.
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	return strings.Join(lines[max(0, len(lines)-n):], "\n")
+}
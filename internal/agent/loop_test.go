@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nexora/nexora/internal/agent/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseModelVerdict(t *testing.T) {
+	v, err := parseModelVerdict(`<think>The agent views the same file.</think>
+{"verdict": "loop", "reason": "Views main.go over and over", "suggestion": "Edit it", "confidence": 0.9}`, "model")
+	require.NoError(t, err)
+	require.Equal(t, state.SeverityWarn, v.Severity)
+	require.Equal(t, state.PatternNoProgress, v.Pattern)
+	require.Equal(t, "Views main.go over and over", v.Reason)
+	require.Equal(t, "model", v.Policy)
+
+	v, err = parseModelVerdict(`{"verdict": "drift", "reason": "r", "suggestion": "s", "confidence": 0.3}`, "model")
+	require.NoError(t, err)
+	require.False(t, v.Stuck(), "answers with little confidence don't count")
+
+	v, err = parseModelVerdict(`{"verdict": "ok", "confidence": 1}`, "model")
+	require.NoError(t, err)
+	require.False(t, v.Stuck())
+
+	_, err = parseModelVerdict("The agent is fine.", "model")
+	require.Error(t, err)
+}
+
+func TestRecordToolCallEvidence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o644))
+
+	a := &sessionAgent{}
+	sm := state.NewStateMachine(state.Config{SessionID: "s1"})
+	a.recordToolCall(context.Background(), sm, "edit", `{"file_path": "`+path+`", "old_string": "a", "new_string": "b"}`, "", "")
+	a.recordToolCall(context.Background(), sm, "bash", `{"command": "go test ./..."}`, "--- FAIL: TestMain\nExit code 1", "")
+	a.recordToolCall(context.Background(), sm, "view", `{"file_path": "`+path+`"}`, "", "")
+
+	progress := sm.Snapshot().Progress
+	require.Len(t, progress.FileHashes[path], 1, "a successful edit records the content of the file")
+	require.Len(t, progress.TestsRun, 1)
+	require.False(t, progress.TestsRun[0].Passed, "a non-zero exit code fails the tests")
+	require.Equal(t, path, progress.RecentActions[0].TargetFile)
+	require.Equal(t, "go test ./...", progress.RecentActions[1].Command)
+	require.Equal(t, 3, sm.GetToolCallCount())
+}
+
+func TestCheckDue(t *testing.T) {
+	actions := make([]state.ActionFingerprint, 5)
+	require.True(t, checkDue(state.Observation{TotalActions: 10, Actions: actions}, 5))
+	require.False(t, checkDue(state.Observation{TotalActions: 11, Actions: actions}, 5))
+	require.False(t, checkDue(state.Observation{TotalActions: 5, Actions: actions[:3]}, 5))
+}
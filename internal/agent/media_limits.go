@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/nexora/nexora/internal/message"
+)
+
+// mediaLimits are the largest images and PDFs a provider accepts in a
+// prompt, in bytes before encoding. A zero pdf limit means the provider
+// doesn't accept PDFs.
+type mediaLimits struct {
+	image int
+	pdf   int
+}
+
+// defaultMediaLimits applies to OpenAI-compatible providers, which mostly
+// accept small images and no documents.
+var defaultMediaLimits = mediaLimits{image: 5 << 20}
+
+var providerMediaLimits = map[string]mediaLimits{
+	// Anthropic limits images to 5MB once base64 encoded.
+	string(catwalk.InferenceProviderAnthropic):  {image: 3_750_000, pdf: 32 << 20},
+	string(catwalk.InferenceProviderBedrock):    {image: 3_750_000, pdf: 4_500_000},
+	string(catwalk.InferenceProviderOpenAI):     {image: 20 << 20, pdf: 32 << 20},
+	string(catwalk.InferenceProviderAzure):      {image: 20 << 20, pdf: 32 << 20},
+	string(catwalk.InferenceProviderOpenRouter): {image: 20 << 20, pdf: 32 << 20},
+	string(catwalk.InferenceProviderVertexAI):   {image: 20 << 20, pdf: 20 << 20},
+	"gemini": {image: 20 << 20, pdf: 20 << 20},
+}
+
+func mediaLimitsFor(provider string) mediaLimits {
+	if limits, ok := providerMediaLimits[provider]; ok {
+		return limits
+	}
+	return defaultMediaLimits
+}
+
+// limitPromptFiles makes the files attached to user messages acceptable to
+// provider: text files are inlined as text, and images or PDFs the provider
+// can't take, or that are over its size limits, are replaced by a note
+// saying why they were left out.
+func limitPromptFiles(messages []fantasy.Message, provider string, supportsImages bool) []fantasy.Message {
+	limits := mediaLimitsFor(provider)
+	result := make([]fantasy.Message, len(messages))
+	for i, msg := range messages {
+		result[i] = msg
+		if msg.Role != fantasy.MessageRoleUser {
+			continue
+		}
+		var content []fantasy.MessagePart
+		for j, part := range msg.Content {
+			file, ok := fantasy.AsMessagePart[fantasy.FilePart](part)
+			if !ok {
+				if content != nil {
+					content = append(content, part)
+				}
+				continue
+			}
+			replacement, changed := limitPromptFile(file, provider, limits, supportsImages)
+			if !changed {
+				if content != nil {
+					content = append(content, part)
+				}
+				continue
+			}
+			if content == nil {
+				content = append(make([]fantasy.MessagePart, 0, len(msg.Content)), msg.Content[:j]...)
+			}
+			content = append(content, replacement)
+		}
+		if content != nil {
+			result[i].Content = content
+		}
+	}
+	return result
+}
+
+func limitPromptFile(file fantasy.FilePart, provider string, limits mediaLimits, supportsImages bool) (fantasy.MessagePart, bool) {
+	omitted := func(reason string) fantasy.TextPart {
+		return fantasy.TextPart{Text: fmt.Sprintf("[Attachment %s was not sent: %s]", file.Filename, reason)}
+	}
+	switch {
+	case message.IsTextMimeType(file.MediaType):
+		return fantasy.TextPart{Text: fmt.Sprintf("<file name=%q>\n%s\n</file>", file.Filename, file.Data)}, true
+	case message.IsImageMimeType(file.MediaType):
+		if !supportsImages {
+			return omitted("the model does not accept images"), true
+		}
+		if len(file.Data) > limits.image {
+			return omitted(fmt.Sprintf("%s is over the %s image limit of %s", formatSize(len(file.Data)), formatSize(limits.image), provider)), true
+		}
+	case file.MediaType == "application/pdf":
+		if limits.pdf == 0 {
+			return omitted(provider + " does not accept PDFs"), true
+		}
+		if len(file.Data) > limits.pdf {
+			return omitted(fmt.Sprintf("%s is over the %s PDF limit of %s", formatSize(len(file.Data)), formatSize(limits.pdf), provider)), true
+		}
+	}
+	return file, false
+}
+
+func formatSize(n int) string {
+	if n >= 1<<20 {
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	}
+	return fmt.Sprintf("%dKB", (n+1023)/1024)
+}
@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"bytes"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitPromptFiles(t *testing.T) {
+	image := fantasy.FilePart{Filename: "shot.png", MediaType: "image/png", Data: []byte("png")}
+	bigImage := fantasy.FilePart{Filename: "big.png", MediaType: "image/png", Data: bytes.Repeat([]byte{1}, 6<<20)}
+	pdf := fantasy.FilePart{Filename: "spec.pdf", MediaType: "application/pdf", Data: []byte("%PDF-")}
+	text := fantasy.FilePart{Filename: "notes.md", MediaType: "text/markdown", Data: []byte("# Notes")}
+	messages := []fantasy.Message{
+		fantasy.NewSystemMessage("system"),
+		{
+			Role: fantasy.MessageRoleUser,
+			Content: []fantasy.MessagePart{
+				fantasy.TextPart{Text: "look at these"},
+				image, bigImage, pdf, text,
+			},
+		},
+	}
+
+	texts := func(msgs []fantasy.Message) []string {
+		var out []string
+		for _, part := range msgs[1].Content {
+			if p, ok := fantasy.AsMessagePart[fantasy.TextPart](part); ok {
+				out = append(out, p.Text)
+			}
+		}
+		return out
+	}
+
+	t.Run("anthropic", func(t *testing.T) {
+		got := limitPromptFiles(messages, "anthropic", true)
+		require.Len(t, got[1].Content, 5)
+		assert.Equal(t, image, got[1].Content[1])
+		assert.Equal(t, pdf, got[1].Content[3])
+		assert.Equal(t, []string{
+			"look at these",
+			"[Attachment big.png was not sent: 6.0MB is over the 3.6MB image limit of anthropic]",
+			"<file name=\"notes.md\">\n# Notes\n</file>",
+		}, texts(got))
+	})
+
+	t.Run("openai compatible", func(t *testing.T) {
+		got := limitPromptFiles(messages, "groq", true)
+		assert.Equal(t, image, got[1].Content[1])
+		assert.Contains(t, texts(got), "[Attachment spec.pdf was not sent: groq does not accept PDFs]")
+	})
+
+	t.Run("no image support", func(t *testing.T) {
+		got := limitPromptFiles(messages, "openai", false)
+		assert.Contains(t, texts(got), "[Attachment shot.png was not sent: the model does not accept images]")
+		assert.Equal(t, pdf, got[1].Content[3])
+	})
+
+	// The input is not modified.
+	assert.Equal(t, image, messages[1].Content[1])
+	assert.Equal(t, text, messages[1].Content[4])
+}
+
+func TestLimitPromptFilesUnchanged(t *testing.T) {
+	messages := []fantasy.Message{fantasy.NewUserMessage("hello")}
+	got := limitPromptFiles(messages, "anthropic", true)
+	assert.Equal(t, messages, got)
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "1KB", formatSize(10))
+	assert.Equal(t, "512KB", formatSize(512<<10))
+	assert.Equal(t, "1.5MB", formatSize(3<<19))
+}
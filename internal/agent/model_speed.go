@@ -0,0 +1,218 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/config/providers"
+	"github.com/nexora/nexora/internal/modelstats"
+	"github.com/nexora/nexora/internal/telemetry"
+)
+
+const (
+	// titleOutputTokens is the size of the short completions the fastest
+	// model is picked for.
+	titleOutputTokens = 40
+	// unmeasuredTTFT is the time to first token assumed for models that have
+	// not been measured yet.
+	unmeasuredTTFT = time.Second
+
+	// benchPrompt asks for a predictable answer long enough to measure the
+	// output speed.
+	benchPrompt          = "Count from 1 to 100 in words, separated by commas. Reply with the list only."
+	benchMaxOutputTokens = 1024
+)
+
+// stepTimer measures the time to first token and the duration of a single
+// streamed step.
+type stepTimer struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	start time.Time
+	first time.Time
+}
+
+func newStepTimer() *stepTimer {
+	return &stepTimer{now: time.Now}
+}
+
+// Start marks the request of a step as sent.
+func (t *stepTimer) Start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.start = t.now()
+	t.first = time.Time{}
+}
+
+// FirstToken marks the first streamed token of the step. Later calls are
+// ignored.
+func (t *stepTimer) FirstToken() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.first.IsZero() && !t.start.IsZero() {
+		t.first = t.now()
+	}
+}
+
+// Retry restarts the step once the retry delay is over so backoff is not
+// counted as latency.
+func (t *stepTimer) Retry(delay time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.start = t.now().Add(delay)
+	t.first = time.Time{}
+}
+
+// Sample returns the measurement of the step. It reports false when no token
+// was streamed.
+func (t *stepTimer) Sample(model Model, outputTokens int64) (modelstats.Sample, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.start.IsZero() || t.first.IsZero() {
+		return modelstats.Sample{}, false
+	}
+	return modelstats.Sample{
+		Provider:     model.ModelCfg.Provider,
+		Model:        model.ModelCfg.Model,
+		TTFT:         t.first.Sub(t.start),
+		Duration:     t.now().Sub(t.start),
+		OutputTokens: outputTokens,
+	}, true
+}
+
+// recordModelSpeed stores the measurement of a step of the given model.
+func (a *sessionAgent) recordModelSpeed(ctx context.Context, timer *stepTimer, model Model, outputTokens int64) {
+	if a.modelSpeeds == nil {
+		return
+	}
+	sample, ok := timer.Sample(model, outputTokens)
+	if !ok {
+		return
+	}
+	if err := a.modelSpeeds.Record(context.WithoutCancel(ctx), sample); err != nil {
+		slog.Debug("Failed to record model speed", "error", err, "provider", sample.Provider, "model", sample.Model)
+	}
+}
+
+// llmCall summarizes the timing, usage and cost of a step of the given
+// model. It reports false when the step wasn't timed.
+func llmCall(timer *stepTimer, model Model, usage fantasy.Usage, cost float64, finishReason string) (telemetry.LLMCall, bool) {
+	timer.mu.Lock()
+	start, first, end := timer.start, timer.first, timer.now()
+	timer.mu.Unlock()
+	if start.IsZero() {
+		return telemetry.LLMCall{}, false
+	}
+	call := telemetry.LLMCall{
+		Provider:            model.ModelCfg.Provider,
+		Model:               model.ModelCfg.Model,
+		Start:               start,
+		Duration:            end.Sub(start),
+		InputTokens:         usage.InputTokens,
+		OutputTokens:        usage.OutputTokens,
+		CacheReadTokens:     usage.CacheReadTokens,
+		CacheCreationTokens: usage.CacheCreationTokens,
+		Cost:                cost,
+		FinishReason:        finishReason,
+	}
+	if !first.IsZero() {
+		call.TTFT = first.Sub(start)
+	}
+	return call, true
+}
+
+// recordLLMCall exports a step of the given model to telemetry and writes it
+// to the session log.
+func (a *sessionAgent) recordLLMCall(ctx context.Context, sessionID string, timer *stepTimer, model Model, usage fantasy.Usage, cost float64, finishReason string) {
+	if !telemetry.Enabled() && a.sessionLog == nil {
+		return
+	}
+	call, ok := llmCall(timer, model, usage, cost, finishReason)
+	if !ok {
+		return
+	}
+	telemetry.RecordLLMCall(ctx, call)
+	a.logLLMRequest(ctx, sessionID, call)
+}
+
+// expectedLatency estimates how long the model takes to answer a short
+// request. It reports whether the estimate comes from measured samples.
+func expectedLatency(measured map[string]modelstats.Stats, providerID, modelID string) (time.Duration, bool) {
+	if stats, ok := measured[modelstats.Key(providerID, modelID)]; ok && stats.Reliable() && stats.TokensPerSecond > 0 {
+		return stats.Latency(titleOutputTokens), true
+	}
+	static := modelstats.Stats{
+		TTFT:            unmeasuredTTFT,
+		TokensPerSecond: providers.GetModelSpeed(modelID).TokensPerSecond,
+	}
+	return static.Latency(titleOutputTokens), false
+}
+
+// rankedFaster reports whether a model with the given expected latency
+// should be picked over the current best. Measured models always rank before
+// unmeasured ones, so the static table only decides between models that have
+// not been measured.
+func rankedFaster(latency time.Duration, measured bool, best time.Duration, bestMeasured bool) bool {
+	if measured != bestMeasured {
+		return measured
+	}
+	return latency < best
+}
+
+// BenchModel sends a fixed prompt to a configured model the given number of
+// times and returns the measurement of each run.
+func BenchModel(ctx context.Context, cfg *config.Config, selected config.SelectedModel, runs int) ([]modelstats.Sample, error) {
+	providerCfg, ok := cfg.Providers.Get(selected.Provider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s not configured", selected.Provider)
+	}
+	c := &coordinator{cfg: cfg}
+	provider, err := c.buildProvider(providerCfg, selected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build provider %s: %w", selected.Provider, err)
+	}
+	languageModel, err := provider.LanguageModel(ctx, selected.Model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load model %s: %w", selected.Model, err)
+	}
+	model := Model{Model: languageModel, ModelCfg: selected}
+	agent := fantasy.NewAgent(languageModel)
+
+	samples := make([]modelstats.Sample, 0, runs)
+	for range runs {
+		timer := newStepTimer()
+		maxOutput := int64(benchMaxOutputTokens)
+		resp, err := agent.Stream(ctx, fantasy.AgentStreamCall{
+			Prompt:          benchPrompt,
+			MaxOutputTokens: &maxOutput,
+			PrepareStep: func(callContext context.Context, options fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
+				prepared.Messages = options.Messages
+				timer.Start()
+				return callContext, prepared, nil
+			},
+			OnReasoningDelta: func(id, text string) error {
+				timer.FirstToken()
+				return nil
+			},
+			OnTextDelta: func(id, text string) error {
+				timer.FirstToken()
+				return nil
+			},
+			OnRetry: func(err *fantasy.ProviderError, delay time.Duration) {
+				timer.Retry(delay)
+			},
+		})
+		if err != nil {
+			return samples, fmt.Errorf("benchmark request failed: %w", err)
+		}
+		if sample, ok := timer.Sample(model, resp.TotalUsage.OutputTokens); ok {
+			samples = append(samples, sample)
+		}
+	}
+	return samples, nil
+}
@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/modelstats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepTimer(t *testing.T) {
+	now := time.Unix(1000, 0)
+	timer := newStepTimer()
+	timer.now = func() time.Time { return now }
+	model := Model{ModelCfg: config.SelectedModel{Provider: "local", Model: "qwen"}}
+
+	// Nothing streamed yet.
+	timer.FirstToken()
+	_, ok := timer.Sample(model, 10)
+	assert.False(t, ok)
+
+	timer.Start()
+	// Backoff before a retry is not latency.
+	now = now.Add(time.Second)
+	timer.Retry(2 * time.Second)
+	now = now.Add(2500 * time.Millisecond)
+	timer.FirstToken()
+	now = now.Add(time.Second)
+	timer.FirstToken()
+	now = now.Add(time.Second)
+
+	sample, ok := timer.Sample(model, 100)
+	require.True(t, ok)
+	assert.Equal(t, "local", sample.Provider)
+	assert.Equal(t, "qwen", sample.Model)
+	assert.Equal(t, 500*time.Millisecond, sample.TTFT)
+	assert.Equal(t, 2500*time.Millisecond, sample.Duration)
+	assert.InDelta(t, 50, sample.TokensPerSecond(), 1e-9)
+
+	// A new step starts over.
+	timer.Start()
+	_, ok = timer.Sample(model, 100)
+	assert.False(t, ok)
+}
+
+func TestExpectedLatency(t *testing.T) {
+	measured := map[string]modelstats.Stats{
+		modelstats.Key("local", "qwen"):   {Samples: 5, TTFT: 100 * time.Millisecond, TokensPerSecond: 80},
+		modelstats.Key("openai", "gpt-x"): {Samples: 1, TTFT: 10 * time.Millisecond, TokensPerSecond: 1000},
+	}
+
+	latency, ok := expectedLatency(measured, "local", "qwen")
+	assert.True(t, ok)
+	assert.Equal(t, 600*time.Millisecond, latency)
+
+	// Too few samples falls back to the static table.
+	latency, ok = expectedLatency(measured, "openai", "gpt-x")
+	assert.False(t, ok)
+	assert.Equal(t, unmeasuredTTFT+titleOutputTokens*time.Second/30, latency)
+
+	// A measured local model beats an unmeasured fast one.
+	cerebras, _ := expectedLatency(measured, "cerebras", "llama-3.3-70b")
+	local, _ := expectedLatency(measured, "local", "qwen")
+	assert.Greater(t, cerebras, local)
+}
+
+func TestRankedFaster(t *testing.T) {
+	// A measured model beats an unmeasured one whatever the estimate.
+	assert.True(t, rankedFaster(5*time.Second, true, 100*time.Millisecond, false))
+	assert.False(t, rankedFaster(100*time.Millisecond, false, 5*time.Second, true))
+
+	// Models of the same kind are ranked by latency.
+	assert.True(t, rankedFaster(time.Second, true, 2*time.Second, true))
+	assert.True(t, rankedFaster(time.Second, false, 2*time.Second, false))
+	assert.False(t, rankedFaster(2*time.Second, false, time.Second, false))
+}
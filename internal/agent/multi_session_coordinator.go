@@ -1,190 +1,289 @@
-//go:build ignore
-// +build ignore
-
-// TODO: This file is incomplete and needs to be finished
-// See internal/agent/coordinator.go for reference implementation
-// Required fixes:
-// 1. Import fantasy package
-// 2. Implement buildAgent method
-// 3. Fix message.NewAssistantMessage usage
-// 4. Fix AgentConfig references
-
 package agent
 
 import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 
-	"charm.land/fantasy"
-	"github.com/google/uuid"
-	"github.com/nexora/nexora/internal/agent/prompt"
-	"github.com/nexora/nexora/internal/config"
 	"github.com/nexora/nexora/internal/message"
 	"github.com/nexora/nexora/internal/session"
 )
 
+// ErrWindowBusy is returned when closing a window whose agent is working.
+var ErrWindowBusy = errors.New("window is busy")
+
+// MultiSessionCoordinator keeps the windows the TUI has open. Every window
+// runs its session through the shared Coordinator, so a window keeps
+// streaming while another one is shown.
 type MultiSessionCoordinator struct {
-	cfg          *config.Config
-	sessions     session.Service
-	messages     message.Service
-	windows      map[string]*SessionWindow
-	activeID     string
-	windowMu     sync.RWMutex
-	conversation *ConversationLoopManager
-}
-
-func NewMultiSessionCoordinator(
-	cfg *config.Config,
-	sessions session.Service,
-	messages message.Service,
-) *MultiSessionCoordinator {
+	sessions session.Service
+	messages message.Service
+
+	mu          sync.RWMutex
+	coordinator Coordinator
+	windows     []*SessionWindow
+	activeID    string
+}
+
+// NewMultiSessionCoordinator creates a coordinator without windows.
+func NewMultiSessionCoordinator(sessions session.Service, messages message.Service) *MultiSessionCoordinator {
 	return &MultiSessionCoordinator{
-		cfg:          cfg,
-		sessions:     sessions,
-		messages:     messages,
-		windows:      make(map[string]*SessionWindow),
-		activeID:     "",
-		conversation: NewConversationLoopManager(),
+		sessions: sessions,
+		messages: messages,
 	}
 }
 
-func (m *MultiSessionCoordinator) CreateWindow(ctx context.Context, title, prompt string) (string, error) {
-	id := uuid.New().String()
-
-	// Build agent for this window (Claude-style isolation)
-	agentCfg, ok := m.cfg.Agents[config.AgentCoder]
-	if !ok {
-		return "", errors.New("coder agent not configured")
-	}
+// SetCoordinator sets the coordinator that runs the windows' sessions.
+func (m *MultiSessionCoordinator) SetCoordinator(coordinator Coordinator) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.coordinator = coordinator
+}
 
-	agent, err := m.buildWindowAgent(ctx, agentCfg)
+// CreateWindow creates a session titled title in a new window and makes it
+// the active window.
+func (m *MultiSessionCoordinator) CreateWindow(ctx context.Context, title string) (string, error) {
+	s, err := m.sessions.Create(ctx, title)
 	if err != nil {
 		return "", err
 	}
-
-	window := NewSessionWindow(id, title, agent)
-
-	m.windowMu.Lock()
-	m.windows[id] = window
-	if m.activeID == "" {
-		m.activeID = id
-		window.IsActive = true
-	}
-	m.windowMu.Unlock()
-
-	return id, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.windows = append(m.windows, &SessionWindow{ID: s.ID, Title: s.Title})
+	m.activate(s.ID)
+	return s.ID, nil
 }
 
-func (m *MultiSessionCoordinator) SwitchWindow(sessionID string) error {
-	m.windowMu.Lock()
-	defer m.windowMu.Unlock()
-
-	oldWindow, oldExists := m.windows[m.activeID]
-	newWindow, newExists := m.windows[sessionID]
-
-	if !newExists {
-		return fmt.Errorf("window %s not found", sessionID)
+// OpenWindow shows s in a window and makes it the active window. A session
+// that already has a window is switched to. Otherwise the session takes the
+// place of the active window when that window is idle, and gets a window of
+// its own when it isn't.
+func (m *MultiSessionCoordinator) OpenWindow(s session.Session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.index(s.ID) < 0 {
+		window := &SessionWindow{ID: s.ID, Title: s.Title}
+		if i := m.index(m.activeID); i >= 0 && !m.isBusy(m.activeID) && !m.windows[i].PendingPermission {
+			m.windows[i] = window
+		} else {
+			m.windows = append(m.windows, window)
+		}
 	}
+	m.activate(s.ID)
+}
 
-	// Deactivate old
-	if oldExists {
-		oldWindow.IsActive = false
+// SwitchWindow makes the window of session id the active window.
+func (m *MultiSessionCoordinator) SwitchWindow(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.index(id) < 0 {
+		return fmt.Errorf("window %s not found", id)
 	}
-
-	// Activate new
-	newWindow.IsActive = true
-	m.activeID = sessionID
-
+	m.activate(id)
 	return nil
 }
 
-func (m *MultiSessionCoordinator) ListWindows() []SessionWindow {
-	m.windowMu.RLock()
-	defer m.windowMu.RUnlock()
+// ClearActive leaves every window open but none active, for a new chat that
+// doesn't have a session yet.
+func (m *MultiSessionCoordinator) ClearActive() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.activate("")
+}
 
-	var windows []SessionWindow
-	for _, w := range m.windows {
-		// Copy without agent to avoid serialization issues
-		copy := *w
-		copy.Agent = nil
-		windows = append(windows, copy)
+// CloseWindow closes the window of session id. When it was the active
+// window, its neighbor becomes active and is returned; an empty ID means no
+// window is left. Windows whose agent is working can't be closed.
+func (m *MultiSessionCoordinator) CloseWindow(id string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.index(id)
+	if i < 0 {
+		return "", fmt.Errorf("window %s not found", id)
 	}
-	return windows
+	if m.isBusy(id) {
+		return "", ErrWindowBusy
+	}
+	m.windows = slices.Delete(m.windows, i, i+1)
+	if m.activeID != id {
+		return m.activeID, nil
+	}
+	next := ""
+	if len(m.windows) > 0 {
+		next = m.windows[min(i, len(m.windows)-1)].ID
+	}
+	m.activate(next)
+	return next, nil
 }
 
-func (m *MultiSessionCoordinator) ForkWindow(parentID, prompt string) (string, error) {
-	parent, exists := m.windows[parentID]
-	if !exists {
+// ForkWindow copies the session of window parentID into a new session, opens
+// it in a window next to its parent and makes it the active window.
+func (m *MultiSessionCoordinator) ForkWindow(ctx context.Context, parentID string) (string, error) {
+	m.mu.RLock()
+	i := m.index(parentID)
+	m.mu.RUnlock()
+	if i < 0 {
 		return "", fmt.Errorf("parent window %s not found", parentID)
 	}
-
-	// Create fork with parent history as context
-	newID, err := m.CreateWindow(context.Background(), "Fork: "+parent.Title, prompt)
+	parent, err := m.sessions.Get(ctx, parentID)
+	if err != nil {
+		return "", err
+	}
+	msgs, err := m.messages.List(ctx, parentID)
 	if err != nil {
 		return "", err
 	}
 
-	newWindow := m.windows[newID]
-	newWindow.ForkParent = parentID
-	// Copy recent history for context
-	newWindow.History = append([]message.Message(nil), parent.History[len(parent.History)-10:]...)
+	fork, err := m.sessions.Create(ctx, "Fork: "+parent.Title)
+	if err != nil {
+		return "", err
+	}
+	for _, msg := range msgs {
+		parts := msg.Parts
+		if msg.Role != message.Assistant {
+			// Create finishes every message but the assistant's itself.
+			parts = slices.DeleteFunc(slices.Clone(parts), func(part message.ContentPart) bool {
+				_, ok := part.(message.Finish)
+				return ok
+			})
+		}
+		copied, err := m.messages.Create(ctx, fork.ID, message.CreateMessageParams{
+			Role:             msg.Role,
+			Parts:            parts,
+			Model:            msg.Model,
+			Provider:         msg.Provider,
+			IsSummaryMessage: msg.IsSummaryMessage,
+		})
+		if err != nil {
+			return "", fmt.Errorf("copying messages: %w", err)
+		}
+		if msg.ID == parent.SummaryMessageID {
+			fork.SummaryMessageID = copied.ID
+		}
+	}
+	fork.PromptTokens = parent.PromptTokens
+	fork.CompletionTokens = parent.CompletionTokens
+	if fork, err = m.sessions.Save(ctx, fork); err != nil {
+		return "", err
+	}
 
-	return newID, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	window := &SessionWindow{ID: fork.ID, Title: fork.Title, ForkParent: parentID}
+	at := len(m.windows)
+	if i := m.index(parentID); i >= 0 {
+		at = i + 1
+	}
+	m.windows = slices.Insert(m.windows, at, window)
+	m.activate(fork.ID)
+	return fork.ID, nil
 }
 
-func (m *MultiSessionCoordinator) Run(ctx context.Context, windowID, prompt string, attachments ...message.Attachment) (*fantasy.AgentResult, error) {
-	m.windowMu.RLock()
-	window, exists := m.windows[windowID]
-	m.windowMu.RUnlock()
-
-	if !exists {
-		return nil, fmt.Errorf("window %s not found", windowID)
+// ListWindows returns the open windows in order.
+func (m *MultiSessionCoordinator) ListWindows() []SessionWindow {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	windows := make([]SessionWindow, len(m.windows))
+	for i, w := range m.windows {
+		windows[i] = *w
+		windows[i].Busy = m.isBusy(w.ID)
 	}
+	return windows
+}
 
-	// Record tool use for auto-continue
-	m.conversation.RecordToolUse(windowID, "user_prompt")
+// ActiveWindow returns the ID of the active window, or an empty string when
+// no window is active.
+func (m *MultiSessionCoordinator) ActiveWindow() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.activeID
+}
 
-	result, err := window.Agent.Run(ctx, SessionAgentCall{
-		SessionID:   windowID,
-		Prompt:      prompt,
-		Attachments: attachments,
-	})
+// Neighbor returns the window offset positions away from the active one,
+// wrapping around at either end. Without an active window, offset counts
+// from before the first window.
+func (m *MultiSessionCoordinator) Neighbor(offset int) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n := len(m.windows)
+	if n == 0 {
+		return "", false
+	}
+	i := m.index(m.activeID)
+	if i < 0 && offset < 0 {
+		i = n
+	}
+	return m.windows[((i+offset)%n+n)%n].ID, true
+}
 
-	if err == nil {
-		// Add result to window history
-		window.AddMessage(message.NewAssistantMessage(result.Content))
-		m.conversation.RecordToolResult(windowID, "agent_response", result.Content)
+// WindowAt returns the window at index i.
+func (m *MultiSessionCoordinator) WindowAt(i int) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if i < 0 || i >= len(m.windows) {
+		return "", false
 	}
+	return m.windows[i].ID, true
+}
 
-	return result, err
+// WindowFor returns the index and window that sessionID belongs to, which is
+// the window of the session itself or of the session of the agent that
+// started it.
+func (m *MultiSessionCoordinator) WindowFor(ctx context.Context, sessionID string) (int, SessionWindow, bool) {
+	for range 10 {
+		m.mu.RLock()
+		if i := m.index(sessionID); i >= 0 {
+			window := *m.windows[i]
+			m.mu.RUnlock()
+			return i, window, true
+		}
+		m.mu.RUnlock()
+		s, err := m.sessions.Get(ctx, sessionID)
+		if err != nil || s.ParentSessionID == "" {
+			break
+		}
+		sessionID = s.ParentSessionID
+	}
+	return -1, SessionWindow{}, false
 }
 
-func (m *MultiSessionCoordinator) ActiveWindow() string {
-	m.windowMu.RLock()
-	defer m.windowMu.RUnlock()
-	return m.activeID
+// SetPermissionPending records whether window id waits for the answer to a
+// permission request.
+func (m *MultiSessionCoordinator) SetPermissionPending(id string, pending bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i := m.index(id); i >= 0 {
+		m.windows[i].PendingPermission = pending
+	}
 }
 
-func (m *MultiSessionCoordinator) buildWindowAgent(ctx context.Context, agentCfg config.AgentConfig) (SessionAgent, error) {
-	// Delegate to existing coordinator logic for agent creation
-	prompt, err := coderPrompt(prompt.WithWorkingDir(m.cfg.WorkingDir()))
-	if err != nil {
-		return nil, err
+// UpdateSession updates the title of the window showing s.
+func (m *MultiSessionCoordinator) UpdateSession(s session.Session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i := m.index(s.ID); i >= 0 {
+		m.windows[i].Title = s.Title
 	}
+}
 
-	// Use existing agent building pattern from coordinator.go
-	agent, err := m.buildAgent(ctx, prompt, agentCfg)
-	if err != nil {
-		return nil, err
+func (m *MultiSessionCoordinator) index(id string) int {
+	if id == "" {
+		return -1
 	}
+	return slices.IndexFunc(m.windows, func(w *SessionWindow) bool {
+		return w.ID == id
+	})
+}
 
-	return agent, nil
+func (m *MultiSessionCoordinator) activate(id string) {
+	m.activeID = id
+	for _, w := range m.windows {
+		w.IsActive = w.ID == id
+	}
 }
 
-func (m *MultiSessionCoordinator) buildAgent(ctx context.Context, prompt string, agentCfg config.AgentConfig) (SessionAgent, error) {
-	// Copy from existing coordinator.buildAgent logic
-	// This needs to be implemented based on coordinator.go patterns
-	return nil, fmt.Errorf("buildAgent implementation required - copy from coordinator")
+func (m *MultiSessionCoordinator) isBusy(id string) bool {
+	return m.coordinator != nil && m.coordinator.IsSessionBusy(id)
 }
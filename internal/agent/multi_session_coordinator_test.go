@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/nexora/nexora/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// busyCoordinator reports the sessions in busy as busy.
+type busyCoordinator struct {
+	Coordinator
+	busy map[string]bool
+}
+
+func (c busyCoordinator) IsSessionBusy(sessionID string) bool {
+	return c.busy[sessionID]
+}
+
+func windowIDs(windows []SessionWindow) []string {
+	ids := make([]string, len(windows))
+	for i, w := range windows {
+		ids[i] = w.ID
+	}
+	return ids
+}
+
+func TestMultiSessionCoordinator_Windows(t *testing.T) {
+	env := testEnv(t)
+	busy := map[string]bool{}
+	windows := NewMultiSessionCoordinator(env.sessions, env.messages)
+	windows.SetCoordinator(busyCoordinator{busy: busy})
+
+	first, err := windows.CreateWindow(t.Context(), "first")
+	require.NoError(t, err)
+	second, err := windows.CreateWindow(t.Context(), "second")
+	require.NoError(t, err)
+	assert.Equal(t, second, windows.ActiveWindow())
+
+	// An idle active window is replaced by the session opened in it.
+	third, err := env.sessions.Create(t.Context(), "third")
+	require.NoError(t, err)
+	windows.OpenWindow(third)
+	assert.Equal(t, []string{first, third.ID}, windowIDs(windows.ListWindows()))
+
+	// A busy one keeps running in its own window.
+	busy[third.ID] = true
+	fourth, err := env.sessions.Create(t.Context(), "fourth")
+	require.NoError(t, err)
+	windows.OpenWindow(fourth)
+	list := windows.ListWindows()
+	assert.Equal(t, []string{first, third.ID, fourth.ID}, windowIDs(list))
+	assert.True(t, list[1].Busy)
+	assert.True(t, list[2].IsActive)
+
+	next, ok := windows.Neighbor(1)
+	require.True(t, ok)
+	assert.Equal(t, first, next)
+	previous, ok := windows.Neighbor(-1)
+	require.True(t, ok)
+	assert.Equal(t, third.ID, previous)
+
+	require.NoError(t, windows.SwitchWindow(first))
+	assert.Error(t, windows.SwitchWindow("missing"))
+
+	_, err = windows.CloseWindow(third.ID)
+	assert.ErrorIs(t, err, ErrWindowBusy)
+	busy[third.ID] = false
+	active, err := windows.CloseWindow(first)
+	require.NoError(t, err)
+	assert.Equal(t, third.ID, active)
+
+	windows.ClearActive()
+	assert.Empty(t, windows.ActiveWindow())
+	next, ok = windows.Neighbor(1)
+	require.True(t, ok)
+	assert.Equal(t, third.ID, next)
+}
+
+func TestMultiSessionCoordinator_WindowFor(t *testing.T) {
+	env := testEnv(t)
+	windows := NewMultiSessionCoordinator(env.sessions, env.messages)
+
+	id, err := windows.CreateWindow(t.Context(), "main")
+	require.NoError(t, err)
+	child, err := env.sessions.CreateTaskSession(t.Context(), "call-1", id, "sub-agent")
+	require.NoError(t, err)
+
+	i, window, ok := windows.WindowFor(t.Context(), child.ID)
+	require.True(t, ok)
+	assert.Equal(t, 0, i)
+	assert.Equal(t, id, window.ID)
+
+	windows.SetPermissionPending(id, true)
+	assert.True(t, windows.ListWindows()[0].PendingPermission)
+
+	other, err := env.sessions.Create(t.Context(), "other")
+	require.NoError(t, err)
+	_, _, ok = windows.WindowFor(t.Context(), other.ID)
+	assert.False(t, ok)
+}
+
+func TestMultiSessionCoordinator_ForkWindow(t *testing.T) {
+	env := testEnv(t)
+	windows := NewMultiSessionCoordinator(env.sessions, env.messages)
+
+	parent, err := windows.CreateWindow(t.Context(), "main")
+	require.NoError(t, err)
+	_, err = env.messages.Create(t.Context(), parent, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "hello"}},
+	})
+	require.NoError(t, err)
+	_, err = windows.CreateWindow(t.Context(), "other")
+	require.NoError(t, err)
+
+	fork, err := windows.ForkWindow(t.Context(), parent)
+	require.NoError(t, err)
+	assert.Equal(t, fork, windows.ActiveWindow())
+
+	list := windows.ListWindows()
+	require.Len(t, list, 3)
+	assert.Equal(t, fork, list[1].ID)
+	assert.Equal(t, parent, list[1].ForkParent)
+	assert.Equal(t, "Fork: main", list[1].Title)
+
+	msgs, err := env.messages.List(t.Context(), fork)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "hello", msgs[0].Content().Text)
+	assert.Len(t, msgs[0].Parts, 2, "the copy is finished once")
+}
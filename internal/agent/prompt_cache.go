@@ -0,0 +1,223 @@
+package agent
+
+import (
+	"cmp"
+	"encoding/json"
+	"os"
+	"strconv"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/anthropic"
+	"charm.land/fantasy/providers/bedrock"
+	"charm.land/fantasy/providers/google"
+	"charm.land/fantasy/providers/openai"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/message"
+	"github.com/nexora/nexora/internal/session"
+)
+
+const (
+	defaultCacheTailMessages = 2
+	// Anthropic accepts at most four cache breakpoints per request.
+	maxCacheBreakpoints = 4
+)
+
+// promptCacheStrategy says which parts of a request get a cache breakpoint.
+type promptCacheStrategy struct {
+	system bool
+	tools  bool
+	tail   int
+}
+
+func newPromptCacheStrategy(cfg *config.PromptCacheConfig) promptCacheStrategy {
+	if cfg == nil {
+		return promptCacheStrategy{system: true, tools: true, tail: defaultCacheTailMessages}
+	}
+	if cfg.Disable {
+		return promptCacheStrategy{}
+	}
+
+	breakpoints := cfg.Breakpoints
+	if len(breakpoints) == 0 {
+		breakpoints = []config.CacheBreakpoint{config.CacheBreakpointSystem, config.CacheBreakpointTools, config.CacheBreakpointTail}
+	}
+	var s promptCacheStrategy
+	for _, b := range breakpoints {
+		switch b {
+		case config.CacheBreakpointSystem:
+			s.system = true
+		case config.CacheBreakpointTools:
+			s.tools = true
+		case config.CacheBreakpointTail:
+			s.tail = cmp.Or(cfg.TailMessages, defaultCacheTailMessages)
+		}
+	}
+
+	budget := maxCacheBreakpoints
+	if s.system {
+		budget--
+	}
+	if s.tools {
+		budget--
+	}
+	s.tail = min(max(s.tail, 0), budget)
+	return s
+}
+
+// promptCacheStrategy returns the cache breakpoints configured for the
+// provider of model.
+func (a *sessionAgent) promptCacheStrategy(model Model) promptCacheStrategy {
+	if t, _ := strconv.ParseBool(os.Getenv("NEXORA_DISABLE_ANTHROPIC_CACHE")); t {
+		return promptCacheStrategy{}
+	}
+	var cacheCfg *config.PromptCacheConfig
+	if cfg := config.Get(); cfg != nil {
+		if pc, ok := cfg.Providers.Get(model.ModelCfg.Provider); ok {
+			cacheCfg = pc.PromptCache
+		}
+	}
+	return newPromptCacheStrategy(cacheCfg)
+}
+
+// applyCacheBreakpoints marks the last leading system message and the last
+// messages of the conversation as cache breakpoints.
+func applyCacheBreakpoints(messages []fantasy.Message, s promptCacheStrategy, opts fantasy.ProviderOptions) {
+	if s.system {
+		lastSystem := -1
+		for i, msg := range messages {
+			if msg.Role != fantasy.MessageRoleSystem {
+				break
+			}
+			lastSystem = i
+		}
+		if lastSystem >= 0 {
+			messages[lastSystem].ProviderOptions = opts
+		}
+	}
+	for i := max(len(messages)-s.tail, 0); i < len(messages); i++ {
+		messages[i].ProviderOptions = opts
+	}
+}
+
+// cachedTokenKeys are the names OpenAI and Gemini use for the prompt tokens
+// served from their automatic caches.
+var cachedTokenKeys = []string{
+	"cached_tokens",
+	"cached_input_tokens",
+	"cachedTokens",
+	"cached_content_token_count",
+	"cachedContentTokenCount",
+}
+
+// cachedTokensFromMetadata finds the cached prompt tokens in the OpenAI or
+// Google provider metadata of a step. The metadata is searched by key so it
+// does not depend on how each provider nests its usage details.
+func cachedTokensFromMetadata(metadata fantasy.ProviderMetadata) int64 {
+	for _, name := range []string{openai.Name, google.Name} {
+		data, ok := metadata[name]
+		if !ok || data == nil {
+			continue
+		}
+		raw, err := json.Marshal(data)
+		if err != nil {
+			continue
+		}
+		var decoded any
+		if err := json.Unmarshal(raw, &decoded); err != nil {
+			continue
+		}
+		if n := findCachedTokens(decoded); n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+func findCachedTokens(v any) int64 {
+	switch v := v.(type) {
+	case map[string]any:
+		for _, key := range cachedTokenKeys {
+			if n, ok := v[key].(float64); ok && n > 0 {
+				return int64(n)
+			}
+		}
+		for _, child := range v {
+			if n := findCachedTokens(child); n > 0 {
+				return n
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if n := findCachedTokens(child); n > 0 {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// normalizeCacheUsage makes InputTokens exclude cached tokens for every
+// provider. Anthropic and Bedrock already report cache reads and writes
+// apart from the input tokens; OpenAI and Gemini count cached tokens as
+// part of the prompt and may only report them in the provider metadata.
+func normalizeCacheUsage(provider string, usage fantasy.Usage, metadata fantasy.ProviderMetadata) fantasy.Usage {
+	if provider == anthropic.Name || provider == bedrock.Name {
+		return usage
+	}
+	// When the input and output tokens add up to the total, the cached
+	// tokens are still counted in the input.
+	included := usage.TotalTokens == 0 || usage.TotalTokens == usage.InputTokens+usage.OutputTokens
+	if usage.CacheReadTokens == 0 {
+		usage.CacheReadTokens = cachedTokensFromMetadata(metadata)
+		included = true
+	}
+	if included && usage.CacheReadTokens > 0 && usage.InputTokens >= usage.CacheReadTokens {
+		usage.InputTokens -= usage.CacheReadTokens
+	}
+	return usage
+}
+
+// normalizeStepsUsage sums the normalized usage of every step of a response.
+func normalizeStepsUsage(provider string, steps []fantasy.StepResult, total fantasy.Usage) fantasy.Usage {
+	if len(steps) == 0 {
+		return normalizeCacheUsage(provider, total, nil)
+	}
+	var sum fantasy.Usage
+	for _, step := range steps {
+		usage := normalizeCacheUsage(provider, step.Usage, step.ProviderMetadata)
+		sum.InputTokens += usage.InputTokens
+		sum.OutputTokens += usage.OutputTokens
+		sum.TotalTokens += usage.TotalTokens
+		sum.ReasoningTokens += usage.ReasoningTokens
+		sum.CacheCreationTokens += usage.CacheCreationTokens
+		sum.CacheReadTokens += usage.CacheReadTokens
+	}
+	return sum
+}
+
+// cacheSavings returns what the cache saved on a request compared to
+// sending every cached token as regular input, net of the cache write
+// premium.
+func cacheSavings(model catwalk.Model, usage fantasy.Usage) float64 {
+	saved := (model.CostPer1MIn - model.CostPer1MOutCached) / 1e6 * float64(usage.CacheReadTokens)
+	premium := (model.CostPer1MInCached - model.CostPer1MIn) / 1e6 * float64(usage.CacheCreationTokens)
+	return saved - premium
+}
+
+// addCacheUsage adds the prompt cache accounting of a request to the session.
+func addCacheUsage(model catwalk.Model, sess *session.Session, usage fantasy.Usage) {
+	sess.TotalInputTokens += usage.InputTokens + usage.CacheReadTokens + usage.CacheCreationTokens
+	sess.CacheReadTokens += usage.CacheReadTokens
+	sess.CacheCreationTokens += usage.CacheCreationTokens
+	sess.CacheSavings += cacheSavings(model, usage)
+}
+
+func messageTokenUsage(usage fantasy.Usage) message.TokenUsage {
+	return message.TokenUsage{
+		InputTokens:         usage.InputTokens,
+		OutputTokens:        usage.OutputTokens,
+		CacheReadTokens:     usage.CacheReadTokens,
+		CacheCreationTokens: usage.CacheCreationTokens,
+	}
+}
@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"encoding/json"
+	"testing"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/anthropic"
+	"charm.land/fantasy/providers/openai"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPromptCacheStrategy(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *config.PromptCacheConfig
+		want promptCacheStrategy
+	}{
+		{
+			name: "defaults",
+			want: promptCacheStrategy{system: true, tools: true, tail: 2},
+		},
+		{
+			name: "disabled",
+			cfg:  &config.PromptCacheConfig{Disable: true},
+			want: promptCacheStrategy{},
+		},
+		{
+			name: "empty config uses every breakpoint",
+			cfg:  &config.PromptCacheConfig{TailMessages: 1},
+			want: promptCacheStrategy{system: true, tools: true, tail: 1},
+		},
+		{
+			name: "tail only gets the whole budget",
+			cfg:  &config.PromptCacheConfig{Breakpoints: []config.CacheBreakpoint{config.CacheBreakpointTail}, TailMessages: 9},
+			want: promptCacheStrategy{tail: 4},
+		},
+		{
+			name: "tail is capped by the other breakpoints",
+			cfg: &config.PromptCacheConfig{
+				Breakpoints:  []config.CacheBreakpoint{config.CacheBreakpointSystem, config.CacheBreakpointTools, config.CacheBreakpointTail},
+				TailMessages: 3,
+			},
+			want: promptCacheStrategy{system: true, tools: true, tail: 2},
+		},
+		{
+			name: "system only",
+			cfg:  &config.PromptCacheConfig{Breakpoints: []config.CacheBreakpoint{config.CacheBreakpointSystem, "bogus"}},
+			want: promptCacheStrategy{system: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, newPromptCacheStrategy(tt.cfg))
+		})
+	}
+}
+
+func TestApplyCacheBreakpoints(t *testing.T) {
+	opts := fantasy.ProviderOptions{
+		anthropic.Name: &anthropic.ProviderCacheControlOptions{CacheControl: anthropic.CacheControl{Type: "ephemeral"}},
+	}
+	newMessages := func() []fantasy.Message {
+		return []fantasy.Message{
+			{Role: fantasy.MessageRoleSystem},
+			{Role: fantasy.MessageRoleSystem},
+			{Role: fantasy.MessageRoleUser},
+			{Role: fantasy.MessageRoleAssistant},
+			{Role: fantasy.MessageRoleUser},
+		}
+	}
+	marked := func(messages []fantasy.Message) []int {
+		var idx []int
+		for i, msg := range messages {
+			if msg.ProviderOptions != nil {
+				idx = append(idx, i)
+			}
+		}
+		return idx
+	}
+
+	messages := newMessages()
+	applyCacheBreakpoints(messages, promptCacheStrategy{system: true, tail: 2}, opts)
+	assert.Equal(t, []int{1, 3, 4}, marked(messages))
+
+	messages = newMessages()
+	applyCacheBreakpoints(messages, promptCacheStrategy{tail: 1}, opts)
+	assert.Equal(t, []int{4}, marked(messages))
+
+	messages = newMessages()
+	applyCacheBreakpoints(messages, promptCacheStrategy{}, opts)
+	assert.Empty(t, marked(messages))
+
+	messages = newMessages()[:1]
+	applyCacheBreakpoints(messages, promptCacheStrategy{system: true, tail: 4}, opts)
+	assert.Equal(t, []int{0}, marked(messages))
+}
+
+func TestFindCachedTokens(t *testing.T) {
+	for raw, want := range map[string]int64{
+		`{"usage":{"prompt_tokens_details":{"cached_tokens":1024}}}`: 1024,
+		`{"usageMetadata":{"cachedContentTokenCount":2048}}`:         2048,
+		`[{"cached_content_token_count":7}]`:                         7,
+		`{"usage":{"cached_tokens":0}}`:                              0,
+		`{"logprobs":null}`:                                          0,
+	} {
+		var decoded any
+		require.NoError(t, json.Unmarshal([]byte(raw), &decoded))
+		assert.Equal(t, want, findCachedTokens(decoded), raw)
+	}
+}
+
+func TestNormalizeCacheUsage(t *testing.T) {
+	// Anthropic reports cache tokens apart from the input tokens.
+	usage := fantasy.Usage{InputTokens: 100, OutputTokens: 50, CacheReadTokens: 900, CacheCreationTokens: 200}
+	assert.Equal(t, usage, normalizeCacheUsage(anthropic.Name, usage, nil))
+
+	// OpenAI counts cached tokens in the prompt tokens.
+	usage = fantasy.Usage{InputTokens: 1000, OutputTokens: 50, TotalTokens: 1050, CacheReadTokens: 800}
+	got := normalizeCacheUsage(openai.Name, usage, nil)
+	assert.Equal(t, int64(200), got.InputTokens)
+	assert.Equal(t, int64(800), got.CacheReadTokens)
+
+	// Already excluded from the input.
+	usage = fantasy.Usage{InputTokens: 200, OutputTokens: 50, TotalTokens: 1050, CacheReadTokens: 800}
+	assert.Equal(t, usage, normalizeCacheUsage(openai.Name, usage, nil))
+
+	// Nothing cached.
+	usage = fantasy.Usage{InputTokens: 1000, OutputTokens: 50, TotalTokens: 1050}
+	assert.Equal(t, usage, normalizeCacheUsage(openai.Name, usage, nil))
+}
+
+func TestAddCacheUsage(t *testing.T) {
+	model := catwalk.Model{
+		CostPer1MIn:        3,
+		CostPer1MOut:       15,
+		CostPer1MInCached:  3.75,
+		CostPer1MOutCached: 0.3,
+	}
+	var sess session.Session
+	addCacheUsage(model, &sess, fantasy.Usage{InputTokens: 1_000, CacheCreationTokens: 1_000_000})
+	addCacheUsage(model, &sess, fantasy.Usage{InputTokens: 1_000, CacheReadTokens: 2_000_000})
+
+	assert.Equal(t, int64(3_002_000), sess.TotalInputTokens)
+	assert.Equal(t, int64(2_000_000), sess.CacheReadTokens)
+	assert.Equal(t, int64(1_000_000), sess.CacheCreationTokens)
+	// 2M reads save 2*(3-0.3) = 5.4, the 1M write costs 0.75 extra.
+	assert.InDelta(t, 4.65, sess.CacheSavings, 1e-9)
+	assert.InDelta(t, 2.0/3.002, sess.CacheHitRate(), 1e-9)
+}
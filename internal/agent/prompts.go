@@ -3,6 +3,7 @@ package agent
 import (
 	"context"
 	_ "embed"
+	"strings"
 
 	"github.com/nexora/nexora/internal/agent/prompt"
 	"github.com/nexora/nexora/internal/config"
@@ -14,6 +15,15 @@ var coderPromptTmpl []byte
 //go:embed templates/task.md.tpl
 var taskPromptTmpl []byte
 
+//go:embed templates/reviewer.md.tpl
+var reviewerPromptTmpl []byte
+
+//go:embed templates/planner.md.tpl
+var plannerPromptTmpl []byte
+
+//go:embed templates/custom_agent.md.tpl
+var customAgentPromptTmpl []byte
+
 //go:embed templates/initialize.md.tpl
 var initializePromptTmpl []byte
 
@@ -33,6 +43,30 @@ func taskPrompt(opts ...prompt.Option) (*prompt.Prompt, error) {
 	return systemPrompt, nil
 }
 
+func reviewerPrompt(opts ...prompt.Option) (*prompt.Prompt, error) {
+	systemPrompt, err := prompt.NewPrompt("reviewer", string(reviewerPromptTmpl), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return systemPrompt, nil
+}
+
+func plannerPrompt(opts ...prompt.Option) (*prompt.Prompt, error) {
+	systemPrompt, err := prompt.NewPrompt("planner", string(plannerPromptTmpl), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return systemPrompt, nil
+}
+
+// customAgentPrompt builds the prompt of a user-defined agent from its own
+// instructions followed by the environment. The instructions are taken
+// literally, not as a template.
+func customAgentPrompt(agent config.Agent, opts ...prompt.Option) (*prompt.Prompt, error) {
+	instructions := strings.ReplaceAll(agent.SystemPrompt, "{{", `{{"{{"}}`)
+	return prompt.NewPrompt(agent.ID, instructions+string(customAgentPromptTmpl), opts...)
+}
+
 func InitializePrompt(cfg config.Config) (string, error) {
 	systemPrompt, err := prompt.NewPrompt("initialize", string(initializePromptTmpl))
 	if err != nil {
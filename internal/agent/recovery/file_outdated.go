@@ -122,8 +122,12 @@ func (s *EditFailedStrategy) Name() string {
 	return "Edit Failed Recovery"
 }
 
-// LoopDetectedStrategy handles infinite loop detection
-type LoopDetectedStrategy struct{}
+// LoopDetectedStrategy handles infinite loop detection. Loops found by a
+// loop policy that only warrant a warning are recovered from by warning the
+// model, MaxWarnings times; any other loop halts execution.
+type LoopDetectedStrategy struct {
+	MaxWarnings int
+}
 
 func (s *LoopDetectedStrategy) CanRecover(err error) bool {
 	if re, ok := err.(*RecoverableError); ok {
@@ -135,19 +139,26 @@ func (s *LoopDetectedStrategy) CanRecover(err error) bool {
 }
 
 func (s *LoopDetectedStrategy) Recover(ctx context.Context, err error, execCtx *state.AgentExecutionContext) error {
+	if re, ok := err.(*RecoverableError); ok && re.Context != nil {
+		if severity, _ := re.Context["severity"].(state.Severity); severity == state.SeverityWarn {
+			// Recovery means warning the model so that it changes its approach
+			suggestion, _ := re.Context["suggestion"].(string)
+			execCtx.LastError = fmt.Errorf("%w: %s", re.Err, suggestion)
+			execCtx.StuckCount++
+			return nil
+		}
+	}
+
 	// For loop detection, recovery means stopping the loop and returning control
 	execCtx.LastError = fmt.Errorf("loop detected, halting execution and returning to user")
 
-	// In a complete implementation, this would:
-	// 1. Transition state machine to StateHalted
-	// 2. Provide a summary of what was being done
-	// 3. Suggest next steps to the user
-
+	// The caller pauses the session with the reason of the loop until the
+	// user resumes it
 	return fmt.Errorf("loop detected - execution halted to prevent infinite loop")
 }
 
 func (s *LoopDetectedStrategy) MaxRetries() int {
-	return 0 // No retries for loops - always halt
+	return s.MaxWarnings // No retries for loops by default - always halt
 }
 
 func (s *LoopDetectedStrategy) Name() string {
@@ -305,6 +316,19 @@ func NewLoopDetectedError(operation string, iterations int) error {
 		})
 }
 
+// NewLoopVerdictError creates a loop detected error from the verdict of a
+// loop policy.
+func NewLoopVerdictError(verdict state.Verdict) error {
+	return NewRecoverableError(fmt.Errorf("loop detected (%s): %s", verdict.Pattern, verdict.Reason),
+		ErrorTypeLoopDetected, map[string]interface{}{
+			"pattern":    verdict.Pattern,
+			"severity":   verdict.Severity,
+			"suggestion": verdict.Suggestion,
+			"policy":     verdict.Policy,
+			"timestamp":  verdict.Time,
+		})
+}
+
 func NewTimeoutError(operation string, timeout time.Duration) error {
 	return NewRecoverableError(fmt.Errorf("%s timed out after %v", operation, timeout),
 		ErrorTypeTimeout, map[string]interface{}{
@@ -272,3 +272,43 @@ func BenchmarkAttemptRecovery(b *testing.B) {
 		registry.AttemptRecovery(ctx, err, execCtx)
 	}
 }
+
+func TestLoopVerdictRecovery(t *testing.T) {
+	ctx := context.Background()
+	registry := NewRecoveryRegistry()
+	registry.RemoveStrategy("Loop Detected Recovery")
+	registry.AddStrategy(&LoopDetectedStrategy{MaxWarnings: 2})
+
+	warning := NewLoopVerdictError(state.Verdict{
+		Severity:   state.SeverityWarn,
+		Pattern:    state.PatternNoProgress,
+		Reason:     "No meaningful progress",
+		Suggestion: "Try a different approach.",
+	})
+	execCtx := state.NewAgentExecutionContext("s1")
+
+	// Warnings are recovered from until the strategy runs out of them
+	for i := range 2 {
+		if err := registry.AttemptRecovery(ctx, warning, execCtx); err != nil {
+			t.Fatalf("warning %d: expected recovery, got %v", i, err)
+		}
+		if execCtx.LastError == nil || !strings.Contains(execCtx.LastError.Error(), "Try a different approach.") {
+			t.Errorf("expected the suggestion in the last error, got %v", execCtx.LastError)
+		}
+	}
+	if execCtx.StuckCount != 2 {
+		t.Errorf("expected 2 warnings, got %d", execCtx.StuckCount)
+	}
+	if err := registry.AttemptRecovery(ctx, warning, execCtx); err == nil {
+		t.Error("expected a third warning to halt")
+	}
+
+	halt := NewLoopVerdictError(state.Verdict{
+		Severity: state.SeverityHalt,
+		Pattern:  state.PatternRepeatedError,
+		Reason:   "Same error on 'a.go' repeated 3 times",
+	})
+	if err := registry.AttemptRecovery(ctx, halt, state.NewAgentExecutionContext("s2")); err == nil {
+		t.Error("expected a halting verdict to halt")
+	}
+}
@@ -3,6 +3,10 @@ package recovery
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
 
 	"github.com/nexora/nexora/internal/agent/state"
 )
@@ -11,6 +15,10 @@ import (
 type RecoveryRegistry struct {
 	strategies  []RecoveryStrategy
 	maxAttempts int // Global limit (default: 3)
+
+	statsMu    sync.Mutex
+	statistics *RecoveryStatistics // Attempts since the registry was created
+	stats      StatsService        // Persists attempts, if set
 }
 
 // NewRecoveryRegistry creates a new recovery registry with default strategies
@@ -25,10 +33,16 @@ func NewRecoveryRegistry() *RecoveryRegistry {
 			&ResourceLimitStrategy{},
 			&PanicStrategy{},
 		},
+		statistics: NewRecoveryStatistics(),
 	}
 	return registry
 }
 
+// SetStatsService makes the registry persist its recovery attempts.
+func (rr *RecoveryRegistry) SetStatsService(stats StatsService) {
+	rr.stats = stats
+}
+
 // SetMaxAttempts sets the global maximum retry attempts
 func (rr *RecoveryRegistry) SetMaxAttempts(max int) {
 	if max < 1 {
@@ -71,10 +85,12 @@ func (rr *RecoveryRegistry) AttemptRecovery(ctx context.Context, err error, exec
 
 	// Check both global and strategy-specific retry limits
 	if execCtx.RetryCount >= rr.maxAttempts {
+		rr.recordAttempt(ctx, strategy, err, false, execCtx)
 		return fmt.Errorf("global retry limit (%d) exceeded: %w", rr.maxAttempts, err)
 	}
 
 	if execCtx.RetryCount >= strategy.MaxRetries() {
+		rr.recordAttempt(ctx, strategy, err, false, execCtx)
 		return fmt.Errorf("strategy retry limit (%d) exceeded for %s: %w",
 			strategy.MaxRetries(), strategy.Name(), err)
 	}
@@ -82,14 +98,52 @@ func (rr *RecoveryRegistry) AttemptRecovery(ctx context.Context, err error, exec
 	// Attempt recovery
 	recoveryErr := strategy.Recover(ctx, err, execCtx)
 	if recoveryErr != nil {
+		rr.recordAttempt(ctx, strategy, err, false, execCtx)
 		return fmt.Errorf("recovery failed using %s: %w", strategy.Name(), recoveryErr)
 	}
 
 	// Increment retry count
 	execCtx.RetryCount++
+	rr.recordAttempt(ctx, strategy, err, true, execCtx)
 	return nil
 }
 
+// recordAttempt records the outcome of a recovery attempt in the statistics
+// of the registry, and persists it.
+func (rr *RecoveryRegistry) recordAttempt(ctx context.Context, strategy RecoveryStrategy, err error, success bool, execCtx *state.AgentExecutionContext) {
+	attempt := Attempt{
+		SessionID:  execCtx.SessionID,
+		Strategy:   strategy.Name(),
+		ErrorType:  errorType(err),
+		Success:    success,
+		RetryCount: execCtx.RetryCount,
+	}
+
+	rr.statsMu.Lock()
+	rr.statistics.RecordAttempt(attempt.Strategy, attempt.ErrorType, attempt.Success, attempt.RetryCount)
+	rr.statsMu.Unlock()
+
+	if rr.stats == nil {
+		return
+	}
+	if recordErr := rr.stats.Record(context.WithoutCancel(ctx), attempt); recordErr != nil {
+		slog.Warn("failed to record recovery attempt", "strategy", attempt.Strategy, "error", recordErr)
+	}
+}
+
+// GetStatistics returns the statistics of the recovery attempts since the
+// registry was created.
+func (rr *RecoveryRegistry) GetStatistics() RecoveryStatistics {
+	rr.statsMu.Lock()
+	defer rr.statsMu.Unlock()
+
+	stats := *rr.statistics
+	stats.StrategyCounts = maps.Clone(stats.StrategyCounts)
+	stats.StrategySuccesses = maps.Clone(stats.StrategySuccesses)
+	stats.ErrorTypeCounts = maps.Clone(stats.ErrorTypeCounts)
+	return stats
+}
+
 // GetAllStrategies returns all registered strategies (for testing/diagnostics)
 func (rr *RecoveryRegistry) GetAllStrategies() []RecoveryStrategy {
 	return append([]RecoveryStrategy{}, rr.strategies...)
@@ -144,22 +198,38 @@ func (rr *RecoveryRegistry) GetDiagnostics() RecoveryDiagnostics {
 	}
 }
 
-// CreateRecoveryStatistics tracks recovery attempts and outcomes
+// RecoveryStatistics tracks recovery attempts and outcomes
 type RecoveryStatistics struct {
-	TotalAttempts   int            `json:"total_attempts"`
-	SuccessCount    int            `json:"success_count"`
-	FailureCount    int            `json:"failure_count"`
-	StrategyCounts  map[string]int `json:"strategy_counts"`
-	ErrorTypeCounts map[string]int `json:"error_type_counts"`
-	AverageRetries  float64        `json:"average_retries"`
+	TotalAttempts     int            `json:"total_attempts"`
+	SuccessCount      int            `json:"success_count"`
+	FailureCount      int            `json:"failure_count"`
+	StrategyCounts    map[string]int `json:"strategy_counts"`
+	StrategySuccesses map[string]int `json:"strategy_successes"`
+	ErrorTypeCounts   map[string]int `json:"error_type_counts"`
+	AverageRetries    float64        `json:"average_retries"`
+	LastAttempt       time.Time      `json:"last_attempt,omitzero"`
 }
 
 // NewRecoveryStatistics creates empty statistics
 func NewRecoveryStatistics() *RecoveryStatistics {
 	return &RecoveryStatistics{
-		StrategyCounts:  make(map[string]int),
-		ErrorTypeCounts: make(map[string]int),
+		StrategyCounts:    make(map[string]int),
+		StrategySuccesses: make(map[string]int),
+		ErrorTypeCounts:   make(map[string]int),
+	}
+}
+
+// SuccessRate returns the share of successful attempts of a strategy, or of
+// all strategies if strategy is empty.
+func (rs *RecoveryStatistics) SuccessRate(strategy string) float64 {
+	attempts, successes := rs.TotalAttempts, rs.SuccessCount
+	if strategy != "" {
+		attempts, successes = rs.StrategyCounts[strategy], rs.StrategySuccesses[strategy]
+	}
+	if attempts == 0 {
+		return 0
 	}
+	return float64(successes) / float64(attempts)
 }
 
 // RecordAttempt records a recovery attempt
@@ -167,6 +237,7 @@ func (rs *RecoveryStatistics) RecordAttempt(strategy string, errorType string, s
 	rs.TotalAttempts++
 	if success {
 		rs.SuccessCount++
+		rs.StrategySuccesses[strategy]++
 	} else {
 		rs.FailureCount++
 	}
@@ -175,5 +246,5 @@ func (rs *RecoveryStatistics) RecordAttempt(strategy string, errorType string, s
 	rs.ErrorTypeCounts[errorType]++
 
 	// Update average retries
-	rs.AverageRetries = float64(retryCount)
+	rs.AverageRetries += (float64(retryCount) - rs.AverageRetries) / float64(rs.TotalAttempts)
 }
@@ -0,0 +1,264 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nexora/nexora/internal/agent/state"
+)
+
+// Actions of recovery rules.
+const (
+	ActionRunCommand  = "run_command"  // Run a shell command, then let the model retry
+	ActionPrompt      = "prompt"       // Tell the model how to recover
+	ActionSwitchModel = "switch_model" // Switch the session to another model
+	ActionPause       = "pause"        // Pause the session until the user resumes it
+)
+
+var ruleActions = []string{ActionRunCommand, ActionPrompt, ActionSwitchModel, ActionPause}
+
+// Rule is a recovery strategy declared in config rather than in code, such
+// as "when go build fails, run go mod tidy once". A failed tool call matches
+// a rule when it matches all of the rule's conditions.
+type Rule struct {
+	Name string
+
+	// Conditions
+	ErrorType string // Error type of the failure, such as edit_failed or command_failed
+	Tool      string // Name of the tool that failed
+	Match     string // Regular expression on the error and the output of the tool
+
+	Action  string
+	Command string // Command of ActionRunCommand
+	Prompt  string // Prompt of ActionPrompt, or a prompt added to any other action
+	Model   string // Model type ActionSwitchModel switches to: large or small
+
+	// MaxRetries is how many times the rule runs for a session before its
+	// next prompt (default 1).
+	MaxRetries int
+}
+
+// RuleActions carries out the actions of recovery rules in a session.
+type RuleActions interface {
+	// RunCommand runs command in the working directory of the session and
+	// returns its output.
+	RunCommand(ctx context.Context, sessionID, command string) (string, error)
+
+	// SwitchModel switches the session to the model of the given type.
+	SwitchModel(ctx context.Context, sessionID, model string) error
+
+	// Pause pauses the session until the user resumes it.
+	Pause(ctx context.Context, sessionID, reason string) error
+}
+
+// RuleStrategy is the recovery strategy of a Rule.
+type RuleStrategy struct {
+	rule    Rule
+	match   *regexp.Regexp
+	actions RuleActions
+
+	mu       sync.Mutex
+	attempts map[string]int // Session ID -> runs since its last prompt
+}
+
+// NewRuleStrategy creates the recovery strategy of rule, whose actions are
+// carried out by actions.
+func NewRuleStrategy(rule Rule, actions RuleActions) (*RuleStrategy, error) {
+	if rule.Name == "" {
+		return nil, errors.New("recovery rule has no name")
+	}
+	if rule.ErrorType == "" && rule.Tool == "" && rule.Match == "" {
+		return nil, fmt.Errorf("recovery rule %q has no error_type, tool or match", rule.Name)
+	}
+	switch rule.Action {
+	case ActionRunCommand:
+		if rule.Command == "" {
+			return nil, fmt.Errorf("recovery rule %q runs no command", rule.Name)
+		}
+	case ActionPrompt:
+		if rule.Prompt == "" {
+			return nil, fmt.Errorf("recovery rule %q has no prompt", rule.Name)
+		}
+	case ActionSwitchModel:
+		if rule.Model != "large" && rule.Model != "small" {
+			return nil, fmt.Errorf("recovery rule %q switches to unknown model type %q", rule.Name, rule.Model)
+		}
+	case ActionPause:
+	default:
+		return nil, fmt.Errorf("recovery rule %q has unknown action %q, expected one of %v", rule.Name, rule.Action, ruleActions)
+	}
+	if rule.MaxRetries < 0 {
+		return nil, fmt.Errorf("recovery rule %q has negative max_retries", rule.Name)
+	}
+	if rule.MaxRetries == 0 {
+		rule.MaxRetries = 1
+	}
+
+	s := &RuleStrategy{
+		rule:     rule,
+		actions:  actions,
+		attempts: make(map[string]int),
+	}
+	if rule.Match != "" {
+		match, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, fmt.Errorf("recovery rule %q: invalid match: %w", rule.Name, err)
+		}
+		s.match = match
+	}
+	return s, nil
+}
+
+func (s *RuleStrategy) CanRecover(err error) bool {
+	var re *RecoverableError
+	isRecoverable := errors.As(err, &re)
+	if s.rule.ErrorType != "" && (!isRecoverable || re.ErrorType != s.rule.ErrorType) {
+		return false
+	}
+	if s.rule.Tool != "" {
+		if !isRecoverable {
+			return false
+		}
+		if tool, _ := re.Context["tool"].(string); tool != s.rule.Tool {
+			return false
+		}
+	}
+	if s.match != nil {
+		text := err.Error()
+		if isRecoverable {
+			if re.Err != nil {
+				text = re.Err.Error()
+			}
+			if output, _ := re.Context["output"].(string); output != "" {
+				text += "\n" + output
+			}
+		}
+		if !s.match.MatchString(text) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *RuleStrategy) Recover(ctx context.Context, err error, execCtx *state.AgentExecutionContext) error {
+	if !s.take(execCtx.SessionID) {
+		return fmt.Errorf("recovery rule %q already ran %d times since the last prompt", s.rule.Name, s.rule.MaxRetries)
+	}
+
+	var note string
+	switch s.rule.Action {
+	case ActionRunCommand:
+		output, runErr := s.actions.RunCommand(ctx, execCtx.SessionID, s.rule.Command)
+		if runErr != nil {
+			return fmt.Errorf("recovery command %q failed: %w", s.rule.Command, runErr)
+		}
+		note = fmt.Sprintf("Ran `%s` to recover from the failure.", s.rule.Command)
+		if output != "" {
+			note += "\n" + output
+		}
+	case ActionSwitchModel:
+		if switchErr := s.actions.SwitchModel(ctx, execCtx.SessionID, s.rule.Model); switchErr != nil {
+			return fmt.Errorf("switching to the %s model failed: %w", s.rule.Model, switchErr)
+		}
+		note = fmt.Sprintf("Switched the session to the %s model.", s.rule.Model)
+	case ActionPause:
+		reason := fmt.Sprintf("Recovery rule %q: %v", s.rule.Name, err)
+		if pauseErr := s.actions.Pause(ctx, execCtx.SessionID, reason); pauseErr != nil {
+			return fmt.Errorf("pausing the session failed: %w", pauseErr)
+		}
+		note = reason
+	}
+	if s.rule.Prompt != "" {
+		if note != "" {
+			note += "\n\n"
+		}
+		note += s.rule.Prompt
+	}
+
+	execCtx.RecoveryNote = note
+	execCtx.LastError = err
+	return nil
+}
+
+// take counts a run of the rule for a session, and returns false if the
+// rule already ran MaxRetries times since the session's last prompt.
+func (s *RuleStrategy) take(sessionID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.attempts[sessionID] >= s.rule.MaxRetries {
+		return false
+	}
+	s.attempts[sessionID]++
+	return true
+}
+
+// ResetSession lets the rule run again for a session, when it gets a new
+// prompt.
+func (s *RuleStrategy) ResetSession(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, sessionID)
+}
+
+func (s *RuleStrategy) MaxRetries() int {
+	return s.rule.MaxRetries
+}
+
+func (s *RuleStrategy) Name() string {
+	return s.rule.Name
+}
+
+// Rule returns the rule of the strategy.
+func (s *RuleStrategy) Rule() Rule {
+	return s.rule
+}
+
+// AddRule adds the strategy of rule to the registry. Rules are tried in the
+// order they are added, before the built-in strategies.
+func (rr *RecoveryRegistry) AddRule(rule Rule, actions RuleActions) error {
+	strategy, err := NewRuleStrategy(rule, actions)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(rr.GetStrategyNames(), rule.Name) {
+		return fmt.Errorf("recovery strategy %q already exists", rule.Name)
+	}
+
+	i := slices.IndexFunc(rr.strategies, func(s RecoveryStrategy) bool {
+		_, ok := s.(*RuleStrategy)
+		return !ok
+	})
+	if i < 0 {
+		i = len(rr.strategies)
+	}
+	rr.strategies = slices.Insert(rr.strategies, i, RecoveryStrategy(strategy))
+	return nil
+}
+
+// ResetSession lets the rules run again for a session, when it gets a new
+// prompt.
+func (rr *RecoveryRegistry) ResetSession(sessionID string) {
+	for _, strategy := range rr.strategies {
+		if rs, ok := strategy.(*RuleStrategy); ok {
+			rs.ResetSession(sessionID)
+		}
+	}
+}
+
+// NewCommandFailedError creates an error for a shell command that exited
+// with a non-zero code, which rules can recover from.
+func NewCommandFailedError(command, output string) error {
+	return NewRecoverableError(fmt.Errorf("command failed: %s", command),
+		ErrorTypeCommandFailed, map[string]interface{}{
+			"tool":      "bash",
+			"command":   command,
+			"output":    output,
+			"timestamp": time.Now(),
+		})
+}
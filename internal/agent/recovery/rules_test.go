@@ -0,0 +1,223 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nexora/nexora/internal/agent/state"
+	"github.com/nexora/nexora/internal/db"
+)
+
+// fakeActions records the actions of recovery rules.
+type fakeActions struct {
+	commands []string
+	models   []string
+	paused   string
+	err      error
+}
+
+func (a *fakeActions) RunCommand(_ context.Context, _, command string) (string, error) {
+	a.commands = append(a.commands, command)
+	return "all modules verified", a.err
+}
+
+func (a *fakeActions) SwitchModel(_ context.Context, _, model string) error {
+	a.models = append(a.models, model)
+	return a.err
+}
+
+func (a *fakeActions) Pause(_ context.Context, _, reason string) error {
+	a.paused = reason
+	return a.err
+}
+
+func TestRuleStrategyMatching(t *testing.T) {
+	strategy, err := NewRuleStrategy(Rule{
+		Name:    "go mod tidy",
+		Tool:    "bash",
+		Match:   `^command failed: go build|missing go.sum entry`,
+		Action:  ActionRunCommand,
+		Command: "go mod tidy",
+	}, &fakeActions{})
+	if err != nil {
+		t.Fatalf("NewRuleStrategy: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"failing build", NewCommandFailedError("go build ./...", "undefined: foo"), true},
+		{"match in the output", NewCommandFailedError("make", "missing go.sum entry for module"), true},
+		{"other command", NewCommandFailedError("go test ./...", "FAIL"), false},
+		{"other tool", NewRecoverableError(errors.New("command failed: go build"), "generic", map[string]interface{}{"tool": "edit"}), false},
+		{"plain error", errors.New("command failed: go build"), false},
+	}
+	for _, tt := range tests {
+		if got := strategy.CanRecover(tt.err); got != tt.want {
+			t.Errorf("%s: CanRecover = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	byType, err := NewRuleStrategy(Rule{Name: "edits", ErrorType: ErrorTypeEditFailed, Action: ActionPrompt, Prompt: "View the file first."}, &fakeActions{})
+	if err != nil {
+		t.Fatalf("NewRuleStrategy: %v", err)
+	}
+	if !byType.CanRecover(NewEditFailedError(errors.New("text not found"), "a.go", "", "")) {
+		t.Error("expected the rule to match its error type")
+	}
+	if byType.CanRecover(NewTimeoutError("bash", time.Minute)) {
+		t.Error("expected the rule not to match other error types")
+	}
+}
+
+func TestRuleStrategyValidation(t *testing.T) {
+	invalid := []Rule{
+		{Action: ActionPause, Tool: "bash"},
+		{Name: "no condition", Action: ActionPause},
+		{Name: "no command", Tool: "bash", Action: ActionRunCommand},
+		{Name: "no prompt", Tool: "bash", Action: ActionPrompt},
+		{Name: "bad model", Tool: "bash", Action: ActionSwitchModel, Model: "medium"},
+		{Name: "bad action", Tool: "bash", Action: "retry"},
+		{Name: "bad match", Match: "(", Action: ActionPause},
+		{Name: "negative retries", Tool: "bash", Action: ActionPause, MaxRetries: -1},
+	}
+	for _, rule := range invalid {
+		if _, err := NewRuleStrategy(rule, &fakeActions{}); err == nil {
+			t.Errorf("expected rule %+v to be invalid", rule)
+		}
+	}
+}
+
+func TestRuleActions(t *testing.T) {
+	ctx := context.Background()
+	actions := &fakeActions{}
+	registry := NewRecoveryRegistry()
+	rules := []Rule{
+		{Name: "tidy", Match: "go build", Action: ActionRunCommand, Command: "go mod tidy", Prompt: "Build again."},
+		{Name: "bigger model", ErrorType: ErrorTypeEditFailed, Action: ActionSwitchModel, Model: "large", MaxRetries: 2},
+		{Name: "stop on timeouts", ErrorType: ErrorTypeTimeout, Action: ActionPause},
+	}
+	for _, rule := range rules {
+		if err := registry.AddRule(rule, actions); err != nil {
+			t.Fatalf("AddRule: %v", err)
+		}
+	}
+	if err := registry.AddRule(rules[0], actions); err == nil {
+		t.Error("expected a duplicate rule name to fail")
+	}
+	names := registry.GetStrategyNames()
+	if names[0] != "tidy" || names[2] != "stop on timeouts" || names[3] != "File Outdated Recovery" {
+		t.Errorf("expected the rules in order before the built-in strategies, got %v", names)
+	}
+
+	// Rules run once per prompt unless told otherwise
+	buildErr := NewCommandFailedError("go build ./...", "missing go.sum entry")
+	execCtx := state.NewAgentExecutionContext("s1")
+	if err := registry.AttemptRecovery(ctx, buildErr, execCtx); err != nil {
+		t.Fatalf("AttemptRecovery: %v", err)
+	}
+	if len(actions.commands) != 1 || actions.commands[0] != "go mod tidy" {
+		t.Errorf("expected go mod tidy to run, got %v", actions.commands)
+	}
+	if !strings.Contains(execCtx.RecoveryNote, "Ran `go mod tidy`") || !strings.HasSuffix(execCtx.RecoveryNote, "Build again.") {
+		t.Errorf("expected a note of the command and the prompt, got %q", execCtx.RecoveryNote)
+	}
+	if err := registry.AttemptRecovery(ctx, buildErr, state.NewAgentExecutionContext("s1")); err == nil {
+		t.Error("expected the rule not to run twice for a prompt")
+	}
+	if err := registry.AttemptRecovery(ctx, buildErr, state.NewAgentExecutionContext("s2")); err != nil {
+		t.Errorf("expected the rule to run for another session: %v", err)
+	}
+	registry.ResetSession("s1")
+	if err := registry.AttemptRecovery(ctx, buildErr, state.NewAgentExecutionContext("s1")); err != nil {
+		t.Errorf("expected the rule to run again after a new prompt: %v", err)
+	}
+
+	// A rule wins over the built-in strategy of its error type
+	editErr := NewEditFailedError(errors.New("text not found"), "/nonexistent/a.go", "", "")
+	for range 2 {
+		if err := registry.AttemptRecovery(ctx, editErr, state.NewAgentExecutionContext("s1")); err != nil {
+			t.Fatalf("AttemptRecovery: %v", err)
+		}
+	}
+	if len(actions.models) != 2 || actions.models[0] != "large" {
+		t.Errorf("expected two switches to the large model, got %v", actions.models)
+	}
+
+	if err := registry.AttemptRecovery(ctx, NewTimeoutError("bash", time.Minute), state.NewAgentExecutionContext("s1")); err != nil {
+		t.Fatalf("AttemptRecovery: %v", err)
+	}
+	if !strings.Contains(actions.paused, `Recovery rule "stop on timeouts"`) {
+		t.Errorf("expected the session to be paused with the rule, got %q", actions.paused)
+	}
+
+	// A failing action fails the recovery
+	actions.err = errors.New("no shell")
+	if err := registry.AttemptRecovery(ctx, buildErr, state.NewAgentExecutionContext("s3")); err == nil {
+		t.Error("expected a failing command to fail the recovery")
+	}
+
+	stats := registry.GetStatistics()
+	if stats.StrategyCounts["tidy"] != 5 || stats.StrategySuccesses["tidy"] != 3 {
+		t.Errorf("expected 5 attempts and 3 successes of the rule, got %+v", stats)
+	}
+	if stats.ErrorTypeCounts[ErrorTypeCommandFailed] != 5 {
+		t.Errorf("expected 5 command failures, got %v", stats.ErrorTypeCounts)
+	}
+}
+
+func TestStatsService(t *testing.T) {
+	ctx := context.Background()
+	conn, err := db.Connect(ctx, t.TempDir())
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	service := NewStatsService(db.New(conn))
+	registry := NewRecoveryRegistry()
+	registry.SetStatsService(service)
+	if err := registry.AddRule(Rule{Name: "tidy", Match: "go build", Action: ActionRunCommand, Command: "go mod tidy"}, &fakeActions{}); err != nil {
+		t.Fatalf("AddRule: %v", err)
+	}
+
+	buildErr := NewCommandFailedError("go build ./...", "")
+	_ = registry.AttemptRecovery(ctx, buildErr, state.NewAgentExecutionContext("s1"))
+	_ = registry.AttemptRecovery(ctx, buildErr, state.NewAgentExecutionContext("s1"))
+	_ = registry.AttemptRecovery(ctx, NewTimeoutError("bash", time.Minute), state.NewAgentExecutionContext("s2"))
+
+	stats, err := service.Stats(ctx, time.Time{})
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.TotalAttempts != 3 || stats.SuccessCount != 1 || stats.FailureCount != 2 {
+		t.Errorf("expected 3 attempts, 1 of them successful, got %+v", stats)
+	}
+	if stats.SuccessRate("tidy") != 0.5 || stats.StrategyCounts["Timeout Recovery"] != 1 {
+		t.Errorf("unexpected statistics per strategy: %+v", stats)
+	}
+	if stats.LastAttempt.IsZero() {
+		t.Error("expected the time of the last attempt")
+	}
+
+	stats, err = service.SessionStats(ctx, "s2")
+	if err != nil {
+		t.Fatalf("SessionStats: %v", err)
+	}
+	if stats.TotalAttempts != 1 || stats.ErrorTypeCounts[ErrorTypeTimeout] != 1 {
+		t.Errorf("expected the timeout of the session, got %+v", stats)
+	}
+
+	stats, err = service.Stats(ctx, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.TotalAttempts != 0 {
+		t.Errorf("expected no attempts in the future, got %d", stats.TotalAttempts)
+	}
+}
@@ -0,0 +1,90 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nexora/nexora/internal/db"
+)
+
+// Attempt is a recovery attempt, as persisted for statistics.
+type Attempt struct {
+	SessionID  string
+	Strategy   string
+	ErrorType  string
+	Success    bool
+	RetryCount int
+}
+
+// StatsService persists recovery attempts and computes their statistics.
+type StatsService interface {
+	// Record persists an attempt.
+	Record(ctx context.Context, attempt Attempt) error
+
+	// Stats returns the statistics of the attempts since the given time.
+	Stats(ctx context.Context, since time.Time) (*RecoveryStatistics, error)
+
+	// SessionStats returns the statistics of the attempts of a session.
+	SessionStats(ctx context.Context, sessionID string) (*RecoveryStatistics, error)
+}
+
+type statsService struct {
+	q db.Querier
+}
+
+// NewStatsService creates a StatsService that persists attempts in the
+// project database.
+func NewStatsService(q db.Querier) StatsService {
+	return &statsService{q: q}
+}
+
+func (s *statsService) Record(ctx context.Context, attempt Attempt) error {
+	return s.q.CreateRecoveryAttempt(ctx, db.CreateRecoveryAttemptParams{
+		SessionID:  attempt.SessionID,
+		Strategy:   attempt.Strategy,
+		ErrorType:  attempt.ErrorType,
+		Success:    attempt.Success,
+		RetryCount: int64(max(attempt.RetryCount, 0)),
+	})
+}
+
+func (s *statsService) Stats(ctx context.Context, since time.Time) (*RecoveryStatistics, error) {
+	var createdAt int64
+	if !since.IsZero() {
+		createdAt = since.Unix()
+	}
+	rows, err := s.q.ListRecoveryAttempts(ctx, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	return computeStatistics(rows), nil
+}
+
+func (s *statsService) SessionStats(ctx context.Context, sessionID string) (*RecoveryStatistics, error) {
+	rows, err := s.q.ListRecoveryAttemptsBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return computeStatistics(rows), nil
+}
+
+func computeStatistics(rows []db.RecoveryAttempt) *RecoveryStatistics {
+	stats := NewRecoveryStatistics()
+	for _, row := range rows {
+		stats.RecordAttempt(row.Strategy, row.ErrorType, row.Success, int(row.RetryCount))
+		if created := time.Unix(row.CreatedAt, 0); created.After(stats.LastAttempt) {
+			stats.LastAttempt = created
+		}
+	}
+	return stats
+}
+
+// errorType returns the error type of a recoverable error.
+func errorType(err error) string {
+	var re *RecoverableError
+	if errors.As(err, &re) && re.ErrorType != "" {
+		return re.ErrorType
+	}
+	return "unknown"
+}
@@ -30,6 +30,7 @@ const (
 	ErrorTypeTimeout       = "timeout"
 	ErrorTypeResourceLimit = "resource_limit"
 	ErrorTypePanic         = "panic"
+	ErrorTypeCommandFailed = "command_failed"
 )
 
 // RecoverableError wraps errors to indicate they are recoverable
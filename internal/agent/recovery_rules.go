@@ -0,0 +1,209 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/google/uuid"
+	"github.com/nexora/nexora/internal/agent/recovery"
+	"github.com/nexora/nexora/internal/agent/state"
+	"github.com/nexora/nexora/internal/agent/tools"
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/csync"
+	"github.com/nexora/nexora/internal/message"
+)
+
+const (
+	// recoveryCommandTimeout is how long the command of a recovery rule may run.
+	recoveryCommandTimeout = 2 * time.Minute
+)
+
+// configureRecovery sets up the recovery registry of the agent from cfg: its
+// global retry limit, and the rules tried before the built-in strategies.
+// Attempts are persisted in stats, if set.
+func (a *sessionAgent) configureRecovery(cfg config.RecoveryConfig, stats recovery.StatsService) {
+	if stats != nil {
+		a.recoveryRegistry.SetStatsService(stats)
+	}
+	if cfg.MaxAttempts > 0 {
+		a.recoveryRegistry.SetMaxAttempts(cfg.MaxAttempts)
+	}
+	actions := recoveryActions{a: a}
+	for _, rule := range cfg.Rules {
+		if err := a.recoveryRegistry.AddRule(recoveryRule(rule), actions); err != nil {
+			slog.Warn("Ignoring invalid recovery rule", "error", err)
+		}
+	}
+}
+
+// recoveryRule converts a rule of the config.
+func recoveryRule(rule config.RecoveryRule) recovery.Rule {
+	return recovery.Rule{
+		Name:       rule.Name,
+		ErrorType:  rule.ErrorType,
+		Tool:       rule.Tool,
+		Match:      rule.Match,
+		Action:     rule.Action,
+		Command:    rule.Command,
+		Prompt:     rule.Prompt,
+		Model:      string(rule.Model),
+		MaxRetries: rule.MaxRetries,
+	}
+}
+
+// failureForRecovery returns the error to recover from after a tool call, or
+// nil if it succeeded: the tool error, or the failure of a bash command that
+// exited with a non-zero code and that a recovery rule handles.
+func (a *sessionAgent) failureForRecovery(toolError error, toolName, input, output string) error {
+	name := tools.ResolveToolName(toolName)
+	if toolError != nil {
+		err := a.wrapErrorForRecovery(toolError, toolName, "", "", "")
+		var re *recovery.RecoverableError
+		if errors.As(err, &re) {
+			if re.Context == nil {
+				re.Context = make(map[string]interface{})
+			}
+			re.Context["tool"] = name
+		}
+		return err
+	}
+
+	if name != tools.BashToolName || !exitCodePattern.MatchString(output) {
+		return nil
+	}
+	var params struct {
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal([]byte(input), &params); err != nil || params.Command == "" {
+		return nil
+	}
+	failure := recovery.NewCommandFailedError(params.Command, lastLines(output, 50))
+	// Only recovery rules handle failed commands
+	if !a.recoveryRegistry.CanRecover(failure) {
+		return nil
+	}
+	return failure
+}
+
+// sessionModel returns the model of a session, which a recovery rule may
+// have switched from the large model.
+func (a *sessionAgent) sessionModel(sessionID string) Model {
+	if modelType, ok := a.modelOverrides.Get(sessionID); ok && modelType == config.SelectedModelTypeSmall && a.smallModel.Model != nil {
+		return a.smallModel
+	}
+	return a.largeModel
+}
+
+// recoveryActions carries out the actions of recovery rules for the agent.
+type recoveryActions struct {
+	a *sessionAgent
+}
+
+// RunCommand runs command through the bash tool of the agent, so that the
+// command goes through its block list, sandbox and permission request like
+// the commands of the model.
+func (r recoveryActions) RunCommand(ctx context.Context, sessionID, command string) (string, error) {
+	idx := slices.IndexFunc(r.a.tools, func(tool fantasy.AgentTool) bool {
+		return tool.Info().Name == tools.BashToolName
+	})
+	if idx < 0 {
+		return "", errors.New("the bash tool is not available")
+	}
+	input, err := json.Marshal(tools.BashParams{Command: command, Description: "Recovery rule command"})
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, recoveryCommandTimeout)
+	defer cancel()
+	ctx = context.WithValue(ctx, tools.SessionIDContextKey, sessionID)
+	resp, err := r.a.tools[idx].Run(ctx, fantasy.ToolCall{
+		ID:    uuid.NewString(),
+		Name:  tools.BashToolName,
+		Input: string(input),
+	})
+	slog.Info("Ran recovery command", "session_id", sessionID, "command", command, "error", err)
+	if err != nil {
+		return "", err
+	}
+	output := lastLines(strings.TrimSpace(resp.Content), 20)
+	if resp.IsError || exitCodePattern.MatchString(resp.Content) {
+		return output, errors.New(output)
+	}
+	return output, nil
+}
+
+func (r recoveryActions) SwitchModel(_ context.Context, sessionID, model string) error {
+	switch config.SelectedModelType(model) {
+	case config.SelectedModelTypeLarge:
+		r.a.modelOverrides.Del(sessionID)
+	case config.SelectedModelTypeSmall:
+		if r.a.smallModel.Model == nil {
+			return errors.New("no small model is configured")
+		}
+		r.a.modelOverrides.Set(sessionID, config.SelectedModelTypeSmall)
+	default:
+		return fmt.Errorf("unknown model type %q", model)
+	}
+	slog.Info("Recovery rule switched the model of the session", "session_id", sessionID, "model", model)
+	return nil
+}
+
+func (r recoveryActions) Pause(ctx context.Context, sessionID, reason string) error {
+	sm := r.a.getOrCreateStateMachine(sessionID, ctx)
+	r.a.pause(ctx, sm, state.StateRecoveryPaused, reason)
+	if sm.GetState() != state.StateRecoveryPaused {
+		return fmt.Errorf("cannot pause the session from state %s", sm.GetState())
+	}
+	_, _ = r.a.messages.Create(ctx, sessionID, message.CreateMessageParams{
+		Role: message.System,
+		Parts: []message.ContentPart{
+			message.TextContent{
+				Text: fmt.Sprintf("⏸ %s\n\nThe session is paused. Resume it, or send a prompt to continue.", reason),
+			},
+		},
+	})
+	return nil
+}
+
+// formatRecoveryNote formats what recovery did after a tool call for the
+// model.
+func formatRecoveryNote(note string) string {
+	return "<recovery>\n" + note + "\n</recovery>"
+}
+
+// attachRecoveryNotes adds what recovery did after tool calls to messages,
+// in a user message after their results.
+func attachRecoveryNotes(messages []fantasy.Message, notes *csync.Map[string, string]) []fantasy.Message {
+	if notes.Len() == 0 {
+		return messages
+	}
+
+	result := make([]fantasy.Message, 0, len(messages)+1)
+	var pending []string
+	for i, msg := range messages {
+		result = append(result, msg)
+		if msg.Role != fantasy.MessageRoleTool {
+			continue
+		}
+		for _, part := range msg.Content {
+			if toolResult, ok := fantasy.AsMessagePart[fantasy.ToolResultPart](part); ok {
+				if note, ok := notes.Get(toolResult.ToolCallID); ok {
+					pending = append(pending, formatRecoveryNote(note))
+				}
+			}
+		}
+		if len(pending) > 0 && (i+1 == len(messages) || messages[i+1].Role != fantasy.MessageRoleTool) {
+			result = append(result, fantasy.NewUserMessage(strings.Join(pending, "\n\n")))
+			pending = nil
+		}
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/nexora/nexora/internal/agent/recovery"
+	"github.com/nexora/nexora/internal/agent/tools"
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/csync"
+	"github.com/nexora/nexora/internal/permission"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailureForRecovery(t *testing.T) {
+	a := &sessionAgent{recoveryRegistry: recovery.NewRecoveryRegistry()}
+	command := `{"command": "go build ./..."}`
+	output := "missing go.sum entry\nExit code 1"
+
+	err := a.failureForRecovery(errors.New("edit failed"), "edit", "", "")
+	var re *recovery.RecoverableError
+	require.ErrorAs(t, err, &re)
+	require.Equal(t, recovery.ErrorTypeEditFailed, re.ErrorType)
+	require.Equal(t, "edit", re.Context["tool"])
+
+	require.NoError(t, a.failureForRecovery(nil, "bash", command, output), "only rules recover from failed commands")
+
+	a.configureRecovery(config.RecoveryConfig{
+		MaxAttempts: 2,
+		Rules: []config.RecoveryRule{
+			{Name: "tidy", Tool: "bash", Match: "go.sum", Action: recovery.ActionRunCommand, Command: "go mod tidy"},
+			{Name: "invalid", Action: recovery.ActionPause},
+		},
+	}, nil)
+	require.Equal(t, "tidy", a.recoveryRegistry.GetStrategyNames()[0])
+	require.NotContains(t, a.recoveryRegistry.GetStrategyNames(), "invalid")
+
+	err = a.failureForRecovery(nil, "bash", command, output)
+	require.ErrorAs(t, err, &re)
+	require.Equal(t, recovery.ErrorTypeCommandFailed, re.ErrorType)
+	require.Equal(t, "go build ./...", re.Context["command"])
+	require.NoError(t, a.failureForRecovery(nil, "bash", command, "ok"))
+	require.NoError(t, a.failureForRecovery(nil, "view", command, output))
+}
+
+func TestSwitchModelAction(t *testing.T) {
+	large, small := Model{ModelCfg: config.SelectedModel{Model: "large"}}, Model{ModelCfg: config.SelectedModel{Model: "small"}}
+	a := &sessionAgent{largeModel: large, smallModel: small, modelOverrides: csync.NewMap[string, config.SelectedModelType]()}
+	actions := recoveryActions{a: a}
+
+	require.Error(t, actions.SwitchModel(t.Context(), "s1", "small"), "no small model is built")
+	require.Error(t, actions.SwitchModel(t.Context(), "s1", "medium"))
+	require.Equal(t, "large", a.sessionModel("s1").ModelCfg.Model)
+
+	require.NoError(t, actions.SwitchModel(t.Context(), "s1", "large"))
+	require.Equal(t, "large", a.sessionModel("s1").ModelCfg.Model)
+}
+
+func TestRunCommandActionUsesBashTool(t *testing.T) {
+	a := &sessionAgent{}
+	actions := recoveryActions{a: a}
+
+	_, err := actions.RunCommand(t.Context(), "s1", "go mod tidy")
+	require.Error(t, err, "no bash tool")
+
+	workingDir := t.TempDir()
+	permissions := permission.NewPermissionService(workingDir, true, []string{})
+	a.tools = []fantasy.AgentTool{tools.NewBashTool(permissions, workingDir, nil, "")}
+	_, err = actions.RunCommand(t.Context(), "s1", "rm -rf "+workingDir)
+	require.ErrorContains(t, err, "not allowed")
+	require.DirExists(t, workingDir)
+}
@@ -0,0 +1,172 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/nexora/nexora/internal/agent/state"
+	"github.com/nexora/nexora/internal/agent/tools"
+	"github.com/nexora/nexora/internal/sessionlog"
+	"github.com/nexora/nexora/internal/telemetry"
+)
+
+// startedTool is a tool call that is running.
+type startedTool struct {
+	start time.Time
+	input string
+}
+
+// logToolCall writes a finished tool call to the session log, and the edit
+// or view it made when it is one.
+func (a *sessionAgent) logToolCall(ctx context.Context, sessionID string, result fantasy.ToolResultContent, started startedTool, toolErr error) {
+	if a.sessionLog == nil {
+		return
+	}
+	duration := float64(time.Since(started.start).Microseconds()) / 1000
+	call := sessionlog.ToolCallLog{
+		SessionID:  sessionID,
+		ToolCallID: result.ToolCallID,
+		ToolName:   result.ToolName,
+		Status:     "success",
+		InputBytes: len(started.input),
+		DurationMS: duration,
+	}
+	if toolErr != nil {
+		call.Status = "error"
+		call.Error = toolErr.Error()
+	}
+	a.sessionLog.LogToolCall(ctx, call)
+
+	if result.ToolName == tools.EditToolName {
+		if edit, ok := editOperationLog(sessionID, started.input, toolErr); ok {
+			edit.DurationMS = duration
+			a.sessionLog.LogEditOperation(ctx, edit)
+		}
+	}
+	if result.ToolName == tools.ViewToolName {
+		if view, ok := viewOperationLog(sessionID, started.input, toolErr); ok {
+			view.DurationMS = duration
+			a.sessionLog.LogViewOperation(ctx, view)
+		}
+	}
+}
+
+// viewOperationLog describes the view tool call with the given input.
+func viewOperationLog(sessionID, input string, toolErr error) (sessionlog.ViewOperationLog, bool) {
+	var params tools.ViewParams
+	if err := json.Unmarshal([]byte(input), &params); err != nil || params.FilePath == "" {
+		return sessionlog.ViewOperationLog{}, false
+	}
+	view := sessionlog.ViewOperationLog{
+		SessionID:  sessionID,
+		FilePath:   params.FilePath,
+		OffsetLine: params.Offset,
+		LimitLines: params.Limit,
+		Status:     "success",
+	}
+	if info, err := os.Stat(params.FilePath); err == nil {
+		view.FileSizeBytes = info.Size()
+	}
+	if toolErr != nil {
+		view.Status = "error"
+		view.ErrorReason = toolErr.Error()
+	}
+	return view, true
+}
+
+// editOperationLog describes the edit tool call with the given input.
+func editOperationLog(sessionID, input string, toolErr error) (sessionlog.EditOperationLog, bool) {
+	var params tools.EditParams
+	if err := json.Unmarshal([]byte(input), &params); err != nil || params.FilePath == "" {
+		return sessionlog.EditOperationLog{}, false
+	}
+	analysis := tools.AnalyzeWhitespace(params.OldString)
+	edit := sessionlog.EditOperationLog{
+		SessionID:       sessionID,
+		FilePath:        params.FilePath,
+		Status:          "success",
+		OldStringLength: len(params.OldString),
+		NewStringLength: len(params.NewString),
+		AttemptCount:    1,
+		HasTabs:         analysis.ContainsTab,
+		HasMixedIndent:  analysis.HasMixedIndent,
+		FileLineEndings: analysis.LineEndings,
+	}
+	if toolErr != nil {
+		edit.Status = "failure"
+		edit.FailureReason = editFailureReason(toolErr.Error())
+	}
+	return edit, true
+}
+
+// errorCode matches the upper-case code the edit tool starts some errors
+// with, like "TAB_MISMATCH: ...".
+var errorCode = regexp.MustCompile(`^([A-Z]+(?:_[A-Z]+)+):`)
+
+// editFailureReason classifies an error of the edit tool for the
+// failure_reason column.
+func editFailureReason(msg string) string {
+	if m := errorCode.FindStringSubmatch(msg); m != nil {
+		return strings.ToLower(m[1])
+	}
+	switch {
+	case strings.Contains(msg, "old_string not found"):
+		return "not_found"
+	case strings.Contains(msg, "appears multiple times"):
+		return "multiple_matches"
+	case strings.Contains(msg, "must read the file"):
+		return "not_read"
+	case strings.Contains(msg, "modified since it was last read"):
+		return "stale_read"
+	case strings.HasPrefix(msg, "file not found"):
+		return "file_not_found"
+	case strings.Contains(msg, "same as old content"):
+		return "no_change"
+	default:
+		return "other"
+	}
+}
+
+// logLLMRequest writes the summary of a model request to the session log.
+func (a *sessionAgent) logLLMRequest(ctx context.Context, sessionID string, call telemetry.LLMCall) {
+	if a.sessionLog == nil {
+		return
+	}
+	a.sessionLog.LogLLMRequest(ctx, sessionlog.LLMRequestLog{
+		SessionID:           sessionID,
+		Provider:            call.Provider,
+		Model:               call.Model,
+		InputTokens:         call.InputTokens,
+		OutputTokens:        call.OutputTokens,
+		CacheReadTokens:     call.CacheReadTokens,
+		CacheCreationTokens: call.CacheCreationTokens,
+		Cost:                call.Cost,
+		TTFTMS:              float64(call.TTFT.Microseconds()) / 1000,
+		DurationMS:          float64(call.Duration.Microseconds()) / 1000,
+		FinishReason:        call.FinishReason,
+	})
+}
+
+// logStateTransitions returns the state change callback that writes the
+// transitions of the state machine of sessionID to the session log.
+func (a *sessionAgent) logStateTransitions(sessionID string) func(from, to state.AgentState) {
+	if a.sessionLog == nil {
+		return nil
+	}
+	return func(from, to state.AgentState) {
+		transition := sessionlog.StateTransitionLog{
+			SessionID: sessionID,
+			FromState: from.String(),
+			ToState:   to.String(),
+		}
+		if sm, ok := a.stateMachines.Get(sessionID); ok {
+			transition.ToolCalls = sm.GetToolCallCount()
+		}
+		a.sessionLog.LogStateTransition(context.Background(), transition)
+	}
+}
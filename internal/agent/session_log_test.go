@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditFailureReason(t *testing.T) {
+	tests := map[string]string{
+		"TAB_MISMATCH: The VIEW tool shows tabs as '→\t' but EDIT needs raw tabs.": "tab_mismatch",
+		"MULTIPLE_MATCHES: The pattern appears multiple times in the file.":        "multiple_matches",
+		"old_string not found in file. Make sure it matches exactly":               "not_found",
+		"old_string appears multiple times in the file. Please provide more":       "multiple_matches",
+		"you must read the file before editing it. Use the View tool first":        "not_read",
+		"file /a.go has been modified since it was last read (mod time: x)":        "stale_read",
+		"file not found: /a.go": "file_not_found",
+		"permission denied":     "other",
+	}
+	for msg, want := range tests {
+		require.Equal(t, want, editFailureReason(msg), msg)
+	}
+}
+
+func TestEditOperationLog(t *testing.T) {
+	input := `{"file_path":"/src/main.go","old_string":"\tfoo()\r\n","new_string":"\tbar()\r\n"}`
+
+	edit, ok := editOperationLog("s1", input, nil)
+	require.True(t, ok)
+	require.Equal(t, "/src/main.go", edit.FilePath)
+	require.Equal(t, "success", edit.Status)
+	require.Equal(t, 8, edit.OldStringLength)
+	require.True(t, edit.HasTabs)
+	require.Equal(t, "CRLF", edit.FileLineEndings)
+
+	edit, ok = editOperationLog("s1", input, errors.New("old_string not found in file"))
+	require.True(t, ok)
+	require.Equal(t, "failure", edit.Status)
+	require.Equal(t, "not_found", edit.FailureReason)
+
+	_, ok = editOperationLog("s1", `{"path":"x"}`, nil)
+	require.False(t, ok)
+}
+
+func TestViewOperationLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello\n"), 0o644))
+	input := `{"file_path":"` + path + `","offset":10,"limit":50}`
+
+	view, ok := viewOperationLog("s1", input, nil)
+	require.True(t, ok)
+	require.Equal(t, path, view.FilePath)
+	require.Equal(t, "success", view.Status)
+	require.Equal(t, 10, view.OffsetLine)
+	require.Equal(t, 50, view.LimitLines)
+	require.Equal(t, int64(6), view.FileSizeBytes)
+
+	view, ok = viewOperationLog("s1", input, errors.New("file not found"))
+	require.True(t, ok)
+	require.Equal(t, "error", view.Status)
+	require.Equal(t, "file not found", view.ErrorReason)
+
+	_, ok = viewOperationLog("s1", `{"path":"x"}`, nil)
+	require.False(t, ok)
+}
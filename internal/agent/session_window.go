@@ -1,40 +1,16 @@
 package agent
 
-import (
-	"sync"
-
-	"github.com/nexora/nexora/internal/message"
-)
-
+// SessionWindow is a chat window of the TUI. Each window shows one session,
+// which keeps running while another window is shown.
 type SessionWindow struct {
-	ID         string             `json:"id"`
-	Title      string             `json:"title"`
-	Agent      SessionAgent       `json:"-"`
-	History    []message.Message  `json:"history,omitempty"`
-	State      *ConversationState `json:"state,omitempty"`
-	ForkParent string             `json:"fork_parent,omitempty"`
-	IsActive   bool               `json:"is_active"`
-
-	mu sync.RWMutex
-}
-
-func (w *SessionWindow) Messages() []message.Message {
-	w.mu.RLock()
-	defer w.mu.RUnlock()
-	return w.History
-}
-
-func (w *SessionWindow) AddMessage(msg message.Message) {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-	w.History = append(w.History, msg)
-}
-
-func NewSessionWindow(id, title string, agent SessionAgent) *SessionWindow {
-	return &SessionWindow{
-		ID:      id,
-		Title:   title,
-		Agent:   agent,
-		History: []message.Message{},
-	}
+	// ID is the ID of the session the window shows.
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	ForkParent string `json:"fork_parent,omitempty"`
+	IsActive   bool   `json:"is_active"`
+	// Busy reports whether an agent is working in the window's session.
+	Busy bool `json:"busy"`
+	// PendingPermission reports whether the window waits for the user to
+	// answer a permission request.
+	PendingPermission bool `json:"pending_permission"`
 }
@@ -32,6 +32,7 @@ type AgentExecutionContext struct {
 	// Recovery context
 	InRecovery      bool
 	RecoveryAttempt int
+	RecoveryNote    string // What recovery did, for the model to act on
 }
 
 // ResetCounters resets the operational counters
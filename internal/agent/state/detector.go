@@ -0,0 +1,351 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Severity is how serious a loop verdict is.
+type Severity int
+
+const (
+	// SeverityNone means the session is making progress.
+	SeverityNone Severity = iota
+	// SeverityWarn means the session may be stuck: the model is warned.
+	SeverityWarn
+	// SeverityHalt means the session is stuck: it is paused.
+	SeverityHalt
+)
+
+var severityNames = map[Severity]string{
+	SeverityNone: "none",
+	SeverityWarn: "warn",
+	SeverityHalt: "halt",
+}
+
+// String returns the string representation of a severity.
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *Severity) UnmarshalText(text []byte) error {
+	for severity, name := range severityNames {
+		if name == string(text) {
+			*s = severity
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown severity %q", text)
+}
+
+// Patterns of loop verdicts.
+const (
+	PatternRepeatedError = "repeated_error" // Same error on the same target
+	PatternOscillation   = "oscillation"    // Failing back and forth between two targets
+	PatternFileChurn     = "file_churn"     // Edits reverting a file to earlier versions
+	PatternFailingTests  = "failing_tests"  // Same tests failing the same way
+	PatternNoProgress    = "no_progress"    // Actions without results
+	PatternDrift         = "drift"          // Work away from the task
+)
+
+// Verdict is the judgment of a loop policy on a session's recent work.
+type Verdict struct {
+	Severity   Severity  `json:"severity"`
+	Pattern    string    `json:"pattern,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	Suggestion string    `json:"suggestion,omitempty"`
+	Score      float64   `json:"score,omitempty"` // Confidence, or how far the work drifted, from 0 to 1
+	Policy     string    `json:"policy,omitempty"`
+	Time       time.Time `json:"time,omitzero"`
+}
+
+// Stuck returns true if the verdict is that the session may be stuck.
+func (v Verdict) Stuck() bool {
+	return v.Severity != SeverityNone
+}
+
+// Observation is what a loop policy judges: a session's task and recent
+// work, oldest first.
+type Observation struct {
+	Task              string
+	TotalActions      int
+	Actions           []ActionFingerprint
+	Errors            []ErrorFingerprint
+	ConsecutiveErrors int
+	FilesModified     map[string]string   // File path -> content hash
+	FileHashes        map[string][]string // File path -> recent content hashes
+	Tests             []TestResult
+}
+
+// LoopPolicy decides whether a session is stuck in a loop or drifting away
+// from its task.
+type LoopPolicy interface {
+	// Name returns the name of the policy.
+	Name() string
+
+	// Evaluate judges the recent work of a session.
+	Evaluate(ctx context.Context, obs Observation) (Verdict, error)
+}
+
+// HeuristicPolicy is the built-in loop policy, which works offline from
+// action fingerprints, file hash churn and test outcomes.
+type HeuristicPolicy struct{}
+
+// Name implements LoopPolicy.
+func (HeuristicPolicy) Name() string { return "heuristic" }
+
+// Evaluate implements LoopPolicy. Checks that halt the session come first.
+func (p HeuristicPolicy) Evaluate(_ context.Context, obs Observation) (Verdict, error) {
+	checks := []func(Observation) Verdict{
+		checkRepeatedError,
+		checkFileChurn,
+		checkOscillation,
+		checkFailingTests,
+		checkNoProgress,
+		checkDrift,
+	}
+	for _, check := range checks {
+		if v := check(obs); v.Stuck() {
+			v.Policy = p.Name()
+			return v, nil
+		}
+	}
+	return Verdict{Policy: p.Name()}, nil
+}
+
+// checkRepeatedError checks for the same error on the same target 3 times
+// among 5 or more consecutive errors.
+func checkRepeatedError(obs Observation) Verdict {
+	if obs.ConsecutiveErrors < 5 || len(obs.Errors) < 3 {
+		return Verdict{}
+	}
+	recent := obs.Errors[len(obs.Errors)-3:]
+	first := recent[0]
+	for _, e := range recent[1:] {
+		if e.Target != first.Target || e.ErrorHash != first.ErrorHash {
+			return Verdict{}
+		}
+	}
+	return Verdict{
+		Severity:   SeverityHalt,
+		Pattern:    PatternRepeatedError,
+		Reason:     fmt.Sprintf("Same error on '%s' repeated 3 times", first.Target),
+		Suggestion: "Read the error and the current content again, then try a different approach.",
+		Score:      1,
+	}
+}
+
+// checkFileChurn checks for a file whose edits bring it back to an earlier
+// version twice, as when two changes keep undoing each other.
+func checkFileChurn(obs Observation) Verdict {
+	paths := make([]string, 0, len(obs.FileHashes))
+	for path := range obs.FileHashes {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	for _, path := range paths {
+		hashes := obs.FileHashes[path]
+		reverts := 0
+		for i := 2; i < len(hashes); i++ {
+			if hashes[i] == hashes[i-2] {
+				reverts++
+			}
+		}
+		if reverts >= 2 {
+			return Verdict{
+				Severity:   SeverityHalt,
+				Pattern:    PatternFileChurn,
+				Reason:     fmt.Sprintf("Edits keep reverting '%s' to an earlier version (%d times)", path, reverts),
+				Suggestion: "Decide which version of the file is right; the recent edits undo each other.",
+				Score:      1,
+			}
+		}
+	}
+	return Verdict{}
+}
+
+// checkOscillation checks for failing actions alternating between two
+// targets (A->B->A->B).
+func checkOscillation(obs Observation) Verdict {
+	if len(obs.Actions) < 4 {
+		return Verdict{}
+	}
+	recent := obs.Actions[len(obs.Actions)-4:]
+	if recent[0].TargetFile == "" || recent[1].TargetFile == "" ||
+		recent[0].TargetFile == recent[1].TargetFile ||
+		recent[0].TargetFile != recent[2].TargetFile ||
+		recent[1].TargetFile != recent[3].TargetFile {
+		return Verdict{}
+	}
+	// Working on two files in turn is fine as long as it succeeds
+	if slices.ContainsFunc(recent, func(a ActionFingerprint) bool { return a.Success }) {
+		return Verdict{}
+	}
+	return Verdict{
+		Severity:   SeverityHalt,
+		Pattern:    PatternOscillation,
+		Reason:     fmt.Sprintf("Oscillating between '%s' and '%s'", recent[0].TargetFile, recent[1].TargetFile),
+		Suggestion: "Stop alternating between the two files and fix the failure on one of them first.",
+		Score:      1,
+	}
+}
+
+// durationPattern matches the durations in test output, which change from run
+// to run.
+var durationPattern = regexp.MustCompile(`\b\d+(\.\d+)?(ns|µs|ms|s|m)\b`)
+
+// checkFailingTests checks for the latest test command failing 3 times in a
+// row with the same output, which means the changes between runs don't
+// affect the failure.
+func checkFailingTests(obs Observation) Verdict {
+	if len(obs.Tests) < 3 {
+		return Verdict{}
+	}
+	last := obs.Tests[len(obs.Tests)-1]
+	output := durationPattern.ReplaceAllString(last.Output, "")
+	runs := 0
+	for i := len(obs.Tests) - 1; i >= 0; i-- {
+		t := obs.Tests[i]
+		if t.Command != last.Command || t.Passed || durationPattern.ReplaceAllString(t.Output, "") != output {
+			break
+		}
+		runs++
+	}
+	if runs < 3 {
+		return Verdict{}
+	}
+	return Verdict{
+		Severity:   SeverityWarn,
+		Pattern:    PatternFailingTests,
+		Reason:     fmt.Sprintf("'%s' failed %d times in a row with the same output", getTarget("", last.Command), runs),
+		Suggestion: "The changes aren't affecting the failure: read the test output closely and check the right code is being changed.",
+		Score:      0.8,
+	}
+}
+
+// checkNoProgress checks for 15 actions with few meaningful successes, little
+// variety and no file changes.
+func checkNoProgress(obs Observation) Verdict {
+	const window = 15
+	if len(obs.Actions) < window {
+		return Verdict{}
+	}
+	recent := obs.Actions[len(obs.Actions)-window:]
+
+	uniqueTargets := make(map[string]bool)
+	uniqueTools := make(map[string]bool)
+	meaningfulSuccessCount := 0 // Only counts edits, writes, etc. (not just views)
+	for _, action := range recent {
+		if !action.Success {
+			continue
+		}
+		if target := getTarget(action.TargetFile, action.Command); target != "" {
+			uniqueTargets[target] = true
+		}
+		uniqueTools[action.ToolName] = true
+		if action.ToolName != "view" && action.ToolName != "ls" && action.ToolName != "grep" {
+			meaningfulSuccessCount++
+		}
+	}
+
+	// File modifications are a strong progress indicator
+	if meaningfulSuccessCount < 2 && len(uniqueTargets) < 3 && len(uniqueTools) < 2 && len(obs.FilesModified) == 0 {
+		return Verdict{
+			Severity:   SeverityWarn,
+			Pattern:    PatternNoProgress,
+			Reason:     fmt.Sprintf("No meaningful progress in last %d actions (%d meaningful successes, %d unique targets)", window, meaningfulSuccessCount, len(uniqueTargets)),
+			Suggestion: "If you're exploring or gathering information, you can continue. If you're stuck, try a different approach.",
+			Score:      0.6,
+		}
+	}
+	return Verdict{}
+}
+
+// checkDrift checks for work that started on the files the task names and
+// has since moved away from them for 10 actions.
+func checkDrift(obs Observation) Verdict {
+	const window = 10
+	anchors := taskAnchors(obs.Task)
+	if len(anchors) == 0 {
+		return Verdict{}
+	}
+
+	var targets []string
+	for _, action := range obs.Actions {
+		if target := getTarget(action.TargetFile, action.Command); target != "" {
+			targets = append(targets, strings.ToLower(target))
+		}
+	}
+	if len(targets) <= window {
+		return Verdict{}
+	}
+	onTask := func(target string) bool {
+		return slices.ContainsFunc(anchors, func(anchor string) bool {
+			return strings.Contains(target, anchor)
+		})
+	}
+	recent, earlier := targets[len(targets)-window:], targets[:len(targets)-window]
+	if !slices.ContainsFunc(earlier, onTask) || slices.ContainsFunc(recent, onTask) {
+		return Verdict{}
+	}
+	return Verdict{
+		Severity:   SeverityWarn,
+		Pattern:    PatternDrift,
+		Reason:     fmt.Sprintf("None of the last %d actions touched what the task names (%s)", window, strings.Join(anchors[:min(len(anchors), 3)], ", ")),
+		Suggestion: "Check that the current work is needed for the task, and get back to it.",
+		Score:      1,
+	}
+}
+
+// taskAnchors returns the file names and paths a task names, lower-cased.
+func taskAnchors(task string) []string {
+	var anchors []string
+	fields := strings.FieldsFunc(task, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("`'\"(),;<>[]{}", r)
+	})
+	for _, f := range fields {
+		f = strings.TrimRight(f, ".!?:")
+		if strings.Contains(f, "://") {
+			continue
+		}
+		f = strings.ToLower(strings.TrimPrefix(f, "./"))
+		if !strings.Contains(f, "/") && !isFileName(f) {
+			continue
+		}
+		if f != "" && f != "/" && !slices.Contains(anchors, f) {
+			anchors = append(anchors, f)
+		}
+	}
+	return anchors
+}
+
+// isFileName returns true if s looks like a file name, such as main.go.
+func isFileName(s string) bool {
+	ext := filepath.Ext(s)
+	stem := strings.TrimSuffix(s, ext)
+	if len(stem) < 2 || len(ext) < 2 || len(ext) > 6 {
+		return false
+	}
+	for _, r := range ext[1:] {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
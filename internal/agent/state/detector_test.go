@@ -0,0 +1,311 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+func evaluate(t *testing.T, pt *ProgressTracker) Verdict {
+	t.Helper()
+	return pt.Evaluate(context.Background())
+}
+
+func TestHeuristicFileChurn(t *testing.T) {
+	pt := NewProgressTracker()
+
+	// Two edits undoing each other: A -> B -> A -> B
+	for i, hash := range []string{"a", "b", "a", "b"} {
+		pt.RecordFileModification("main.go", hash)
+		pt.RecordAction("edit", "main.go", "", "", true)
+		if v := evaluate(t, pt); i < 3 && v.Stuck() {
+			t.Fatalf("edit %d: unexpected verdict %+v", i, v)
+		}
+	}
+
+	v := pt.LastVerdict()
+	if v.Severity != SeverityHalt || v.Pattern != PatternFileChurn {
+		t.Fatalf("expected file churn to halt, got %+v", v)
+	}
+	if v.Policy != "heuristic" || v.Time.IsZero() {
+		t.Errorf("expected the policy and time of the verdict, got %+v", v)
+	}
+
+	// Recording the same content twice isn't churn
+	pt = NewProgressTracker()
+	for _, hash := range []string{"a", "a", "b", "b", "c"} {
+		pt.RecordFileModification("main.go", hash)
+	}
+	if v := evaluate(t, pt); v.Stuck() {
+		t.Errorf("expected no churn for a file moving forward, got %+v", v)
+	}
+}
+
+func TestHeuristicOscillationNeedsFailures(t *testing.T) {
+	pt := NewProgressTracker()
+	for i := range 4 {
+		pt.RecordAction("edit", []string{"a.go", "b.go"}[i%2], "", "", true)
+	}
+	if v := evaluate(t, pt); v.Stuck() {
+		t.Errorf("expected editing two files in turn to be fine, got %+v", v)
+	}
+
+	for i := range 4 {
+		pt.RecordAction("edit", []string{"a.go", "b.go"}[i%2], "", "old_string not found", false)
+	}
+	if v := evaluate(t, pt); v.Severity != SeverityHalt || v.Pattern != PatternOscillation {
+		t.Errorf("expected failing edits in turn to halt, got %+v", v)
+	}
+}
+
+func TestHeuristicFailingTests(t *testing.T) {
+	pt := NewProgressTracker()
+	for i := range 3 {
+		pt.RecordTest("go test ./...", false, fmt.Sprintf("--- FAIL: TestParse (0.0%ds)\nFAIL\tpkg\t0.%d12s", i, i))
+		if v := evaluate(t, pt); i < 2 && v.Stuck() {
+			t.Fatalf("run %d: unexpected verdict %+v", i, v)
+		}
+	}
+	v := pt.LastVerdict()
+	if v.Severity != SeverityWarn || v.Pattern != PatternFailingTests {
+		t.Fatalf("expected the same failure 3 times to warn, ignoring durations, got %+v", v)
+	}
+
+	pt.RecordTest("go test ./...", false, "--- FAIL: TestFormat")
+	if v := evaluate(t, pt); v.Stuck() {
+		t.Errorf("expected a different failure to count as progress, got %+v", v)
+	}
+}
+
+func TestHeuristicDrift(t *testing.T) {
+	pt := NewProgressTracker()
+	pt.SetTask("Fix the parsing of durations in internal/config/load.go.")
+
+	pt.RecordAction("view", "/src/internal/config/load.go", "", "", true)
+	for i := range 10 {
+		pt.RecordAction("edit", fmt.Sprintf("/src/internal/tui/view%d.go", i), "", "", true)
+		pt.RecordFileModification(fmt.Sprintf("/src/internal/tui/view%d.go", i), "hash")
+	}
+	v := evaluate(t, pt)
+	if v.Severity != SeverityWarn || v.Pattern != PatternDrift {
+		t.Fatalf("expected 10 actions away from the task to warn, got %+v", v)
+	}
+
+	pt.RecordAction("edit", "/src/internal/config/load.go", "", "", true)
+	if v := evaluate(t, pt); v.Stuck() {
+		t.Errorf("expected getting back to the task to clear the drift, got %+v", v)
+	}
+
+	// A task that names no files can't drift
+	pt = NewProgressTracker()
+	pt.SetTask("Make the tests pass")
+	for i := range 15 {
+		pt.RecordAction("edit", fmt.Sprintf("file%d.go", i), "", "", true)
+	}
+	if v := evaluate(t, pt); v.Stuck() {
+		t.Errorf("unexpected verdict %+v", v)
+	}
+}
+
+func TestTaskAnchors(t *testing.T) {
+	anchors := taskAnchors("Rename `Foo` in ./internal/app/app.go and main.go, e.g. like https://example.com/x.html. Thanks!")
+	want := []string{"internal/app/app.go", "main.go"}
+	if !slices.Equal(anchors, want) {
+		t.Errorf("expected anchors %v, got %v", want, anchors)
+	}
+}
+
+// stubPolicy is a loop policy with a fixed verdict.
+type stubPolicy struct {
+	verdict Verdict
+	err     error
+	calls   int
+}
+
+func (p *stubPolicy) Name() string { return "stub" }
+
+func (p *stubPolicy) Evaluate(context.Context, Observation) (Verdict, error) {
+	p.calls++
+	return p.verdict, p.err
+}
+
+func TestStateMachineLoopPolicy(t *testing.T) {
+	policy := &stubPolicy{verdict: Verdict{Severity: SeverityWarn, Pattern: PatternDrift, Reason: "off task", Policy: "stub"}}
+	stuck := make(chan string, 1)
+	sm := NewStateMachine(Config{
+		SessionID:  "s1",
+		LoopPolicy: policy,
+		OnStuck:    func(reason string) { stuck <- reason },
+	})
+
+	sm.RecordToolCall(context.Background(), "view", "a.go", "", "", true)
+	sm.evaluations.Wait()
+	if policy.calls != 1 {
+		t.Errorf("expected the policy to judge the tool call once, got %d", policy.calls)
+	}
+	if v := sm.LastVerdict(); v.Reason != "off task" {
+		t.Errorf("expected the verdict of the policy, got %+v", v)
+	}
+	if reason := <-stuck; reason != "off task" {
+		t.Errorf("expected OnStuck with the reason of the verdict, got %q", reason)
+	}
+
+	sm.TakeVerdict()
+
+	// A failing policy falls back to the heuristics
+	policy.err = errors.New("model unavailable")
+	sm.RecordToolCall(context.Background(), "view", "a.go", "", "", true)
+	sm.evaluations.Wait()
+	if v := sm.LastVerdict(); v.Stuck() || v.Policy != "heuristic" {
+		t.Errorf("expected the heuristics' verdict, got %+v", v)
+	}
+}
+
+func TestVerdictSurvivesSnapshot(t *testing.T) {
+	sm := NewStateMachine(Config{SessionID: "s1"})
+	sm.SetTask("Fix main.go")
+	for i := range 4 {
+		sm.RecordFileModification("main.go", []string{"a", "b"}[i%2])
+	}
+	sm.RecordToolCall(context.Background(), "edit", "main.go", "", "", true)
+	if v := sm.LastVerdict(); v.Pattern != PatternFileChurn {
+		t.Fatalf("expected file churn, got %+v", v)
+	}
+
+	data, err := json.Marshal(sm.Snapshot())
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if v := snapshot.Progress.Verdict; v == nil || v.Severity != SeverityHalt || v.Pattern != PatternFileChurn {
+		t.Fatalf("expected the verdict in the snapshot, got %+v", v)
+	}
+
+	restored, err := RestoreStateMachine(Config{SessionID: "s1"}, snapshot)
+	if err != nil {
+		t.Fatalf("RestoreStateMachine: %v", err)
+	}
+	if v := restored.LastVerdict(); v.Pattern != PatternFileChurn {
+		t.Errorf("expected the restored verdict, got %+v", v)
+	}
+	if obs := restored.progressTracker.Observe(); obs.Task != "Fix main.go" || len(obs.FileHashes["main.go"]) != 4 {
+		t.Errorf("expected the restored task and file hashes, got %+v", obs)
+	}
+}
+
+func TestResumeClearsLoopEvidence(t *testing.T) {
+	sm := NewStateMachine(Config{SessionID: "s1"})
+	if err := sm.TransitionTo(StateProcessingPrompt); err != nil {
+		t.Fatalf("TransitionTo: %v", err)
+	}
+	for range 5 {
+		sm.RecordToolCall(context.Background(), "edit", "a.go", "", "old_string not found", false)
+	}
+	if !sm.LastVerdict().Stuck() {
+		t.Fatal("expected a stuck verdict")
+	}
+	if err := sm.Pause(StateLoopPaused, sm.LastVerdict().Reason); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	if err := sm.Resume(); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+
+	sm.RecordToolCall(context.Background(), "edit", "a.go", "", "old_string not found", false)
+	if v := sm.LastVerdict(); v.Stuck() {
+		t.Errorf("expected the errors from before the pause to be forgotten, got %+v", v)
+	}
+}
+
+// blockingPolicy is a loop policy that judges once release is closed.
+type blockingPolicy struct {
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (p *blockingPolicy) Name() string { return "blocking" }
+
+func (p *blockingPolicy) Evaluate(ctx context.Context, _ Observation) (Verdict, error) {
+	p.calls.Add(1)
+	select {
+	case <-p.release:
+		return Verdict{Severity: SeverityWarn, Pattern: PatternDrift, Reason: "off task", Policy: "blocking"}, nil
+	case <-ctx.Done():
+		return Verdict{}, ctx.Err()
+	}
+}
+
+func TestSlowLoopPolicyRunsInBackground(t *testing.T) {
+	policy := &blockingPolicy{release: make(chan struct{})}
+	sm := NewStateMachine(Config{SessionID: "s1", LoopPolicy: policy})
+
+	// Recording doesn't wait for the policy, and only one check runs at a time
+	sm.RecordToolCall(context.Background(), "view", "a.go", "", "", true)
+	sm.RecordToolCall(context.Background(), "view", "b.go", "", "", true)
+	if v, _ := sm.TakeVerdict(); v.Stuck() || v.Policy != "heuristic" {
+		t.Errorf("expected the heuristics' verdict while the policy judges, got %+v", v)
+	}
+
+	close(policy.release)
+	sm.evaluations.Wait()
+	if n := policy.calls.Load(); n != 1 {
+		t.Errorf("expected one check, got %d", n)
+	}
+	v, ok := sm.TakeVerdict()
+	if !ok || v.Reason != "off task" {
+		t.Errorf("expected the verdict of the policy, got %+v (new: %v)", v, ok)
+	}
+	if _, ok := sm.TakeVerdict(); ok {
+		t.Error("expected the verdict to be taken once")
+	}
+}
+
+func TestHeuristicsJudgeWhileSlowPolicyRuns(t *testing.T) {
+	policy := &blockingPolicy{release: make(chan struct{})}
+	stuck := make(chan string, 1)
+	sm := NewStateMachine(Config{
+		SessionID:  "s1",
+		LoopPolicy: policy,
+		OnStuck:    func(reason string) { stuck <- reason },
+	})
+
+	// The heuristics halt the session without waiting for the policy
+	for range 5 {
+		sm.RecordToolCall(context.Background(), "edit", "a.go", "", "old_string not found", false)
+	}
+	v, ok := sm.TakeVerdict()
+	if !ok || v.Severity != SeverityHalt || v.Pattern != PatternRepeatedError {
+		t.Errorf("expected the heuristics to halt, got %+v (new: %v)", v, ok)
+	}
+	if reason := <-stuck; reason != v.Reason {
+		t.Errorf("expected OnStuck with the reason of the verdict, got %q", reason)
+	}
+
+	close(policy.release)
+	sm.evaluations.Wait()
+	if n := policy.calls.Load(); n != 1 {
+		t.Errorf("expected one check, got %d", n)
+	}
+}
+
+func TestSlowLoopPolicyIsCancelled(t *testing.T) {
+	policy := &blockingPolicy{release: make(chan struct{})}
+	sm := NewStateMachine(Config{SessionID: "s1", LoopPolicy: policy})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sm.RecordToolCall(ctx, "view", "a.go", "", "", true)
+	cancel()
+	sm.evaluations.Wait()
+
+	// The failed check falls back to the heuristics
+	if v, ok := sm.TakeVerdict(); !ok || v.Policy != "heuristic" {
+		t.Errorf("expected the heuristics' verdict, got %+v (new: %v)", v, ok)
+	}
+}
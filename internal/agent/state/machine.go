@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -26,6 +27,10 @@ type StateMachine struct {
 	ctx        context.Context
 	cancelFunc context.CancelFunc
 
+	// Loop checks running in the background
+	evaluating  atomic.Bool
+	evaluations sync.WaitGroup
+
 	// Metrics
 	toolCallCount int
 	stateHistory  []StateMetrics
@@ -43,6 +48,7 @@ type Config struct {
 	Context       context.Context
 	TotalPhases   int
 	MaxHistory    int
+	LoopPolicy    LoopPolicy // Judges progress; the heuristics when nil
 	OnStateChange func(from, to AgentState)
 	OnStuck       func(reason string)
 	OnProgress    func(stats ProgressStats)
@@ -67,11 +73,16 @@ func NewStateMachine(cfg Config) *StateMachine {
 		phaseContext = NewPhaseContext(cfg.TotalPhases)
 	}
 
+	progressTracker := NewProgressTracker()
+	if cfg.LoopPolicy != nil {
+		progressTracker.SetPolicy(cfg.LoopPolicy)
+	}
+
 	return &StateMachine{
 		currentState:    StateIdle,
 		sessionID:       cfg.SessionID,
 		startTime:       time.Now(),
-		progressTracker: NewProgressTracker(),
+		progressTracker: progressTracker,
 		phaseContext:    phaseContext,
 		ctx:             ctx,
 		cancelFunc:      cancel,
@@ -86,6 +97,20 @@ func NewStateMachine(cfg Config) *StateMachine {
 
 // TransitionTo attempts to transition to a new state.
 func (sm *StateMachine) TransitionTo(newState AgentState) error {
+	return sm.transition(newState, "")
+}
+
+// Pause attempts to transition to the paused state for reason.
+func (sm *StateMachine) Pause(paused AgentState, reason string) error {
+	if !paused.IsPaused() {
+		return fmt.Errorf("%s is not a paused state", paused)
+	}
+	return sm.transition(paused, reason)
+}
+
+// transition moves to newState, recording reason when it is a paused state,
+// before calling back so that the callback sees the reason.
+func (sm *StateMachine) transition(newState AgentState, reason string) error {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
@@ -110,8 +135,17 @@ func (sm *StateMachine) TransitionTo(newState AgentState) error {
 
 	// Transition
 	sm.currentState = newState
-	if newState != StateResourcePaused {
+	if newState.IsPaused() {
+		if reason != "" {
+			sm.pauseReason = reason
+		}
+	} else {
 		sm.pauseReason = "" // Clear pause reason when leaving paused state
+		if oldState.IsPaused() {
+			// Whoever resumed the session changed something: the loop
+			// evidence from before would pause it again right away
+			sm.progressTracker.Reset()
+		}
 	}
 
 	// Record state entry
@@ -170,8 +204,9 @@ func (sm *StateMachine) GetState() AgentState {
 	return sm.currentState
 }
 
-// RecordToolCall records a tool execution.
-func (sm *StateMachine) RecordToolCall(toolName, targetFile, command, errorMsg string, success bool) {
+// RecordToolCall records a tool execution, and has the loop policy judge
+// the progress within ctx.
+func (sm *StateMachine) RecordToolCall(ctx context.Context, toolName, targetFile, command, errorMsg string, success bool) {
 	sm.mu.Lock()
 	sm.toolCallCount++
 	sm.mu.Unlock()
@@ -184,17 +219,19 @@ func (sm *StateMachine) RecordToolCall(toolName, targetFile, command, errorMsg s
 		sm.phaseContext.RecordFileChange(targetFile)
 	}
 
-	// Check for stuck condition
-	if stuck, reason := sm.progressTracker.IsStuck(); stuck {
-		slog.Warn("stuck condition detected",
-			"session_id", sm.sessionID,
-			"reason", reason,
-			"tool_calls", sm.toolCallCount,
-		)
-
-		if sm.onStuck != nil {
-			go sm.onStuck(reason)
-		}
+	// Check for stuck condition. The heuristics judge every call right away;
+	// policies that also ask a model or a service run that in the
+	// background, one check at a time, and their verdict is taken at a later
+	// tool call.
+	verdict := sm.progressTracker.evaluate(ctx, HeuristicPolicy{})
+	sm.reportVerdict(verdict)
+	if !verdict.Stuck() && !sm.progressTracker.quickPolicy() && sm.evaluating.CompareAndSwap(false, true) {
+		sm.evaluations.Add(1)
+		go func() {
+			defer sm.evaluations.Done()
+			defer sm.evaluating.Store(false)
+			sm.reportVerdict(sm.progressTracker.Evaluate(ctx))
+		}()
 	}
 
 	// Progress callback
@@ -204,6 +241,25 @@ func (sm *StateMachine) RecordToolCall(toolName, targetFile, command, errorMsg s
 	}
 }
 
+// reportVerdict logs a verdict that the session is stuck and calls OnStuck.
+func (sm *StateMachine) reportVerdict(verdict Verdict) {
+	if !verdict.Stuck() {
+		return
+	}
+	slog.Warn("stuck condition detected",
+		"session_id", sm.sessionID,
+		"reason", verdict.Reason,
+		"pattern", verdict.Pattern,
+		"severity", verdict.Severity,
+		"policy", verdict.Policy,
+		"tool_calls", sm.GetToolCallCount(),
+	)
+
+	if sm.onStuck != nil {
+		go sm.onStuck(verdict.Reason)
+	}
+}
+
 // RecordMessage records a message for deduplication.
 func (sm *StateMachine) RecordMessage(message string) bool {
 	return sm.progressTracker.RecordMessage(message)
@@ -292,6 +348,22 @@ func (sm *StateMachine) IsStuck() (bool, string) {
 	return sm.progressTracker.IsStuck()
 }
 
+// LastVerdict returns the verdict of the last loop check.
+func (sm *StateMachine) LastVerdict() Verdict {
+	return sm.progressTracker.LastVerdict()
+}
+
+// TakeVerdict returns the verdict of the last loop check, and whether it was
+// not taken before. Checks running in the background are not waited for.
+func (sm *StateMachine) TakeVerdict() (Verdict, bool) {
+	return sm.progressTracker.TakeVerdict()
+}
+
+// SetTask sets the task that drift from it is judged against.
+func (sm *StateMachine) SetTask(task string) {
+	sm.progressTracker.SetTask(task)
+}
+
 // GetProgress returns current progress statistics.
 func (sm *StateMachine) GetProgress() ProgressStats {
 	return sm.progressTracker.GetStats()
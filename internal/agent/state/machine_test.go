@@ -22,11 +22,20 @@ func TestStateTransitions(t *testing.T) {
 		{"Streaming to ProgressCheck", StateStreamingResponse, StateProgressCheck, true},
 		{"ProgressCheck to PhaseTransition", StateProgressCheck, StatePhaseTransition, true},
 		{"PhaseTransition to Processing", StatePhaseTransition, StateProcessingPrompt, true},
+		{"Executing to ResourcePaused", StateExecutingTool, StateResourcePaused, true},
+		{"Streaming to LoopPaused", StateStreamingResponse, StateLoopPaused, true},
+		{"ResourcePaused to Idle", StateResourcePaused, StateIdle, true},
+		{"LoopPaused to Processing", StateLoopPaused, StateProcessingPrompt, true},
+		{"Executing to RecoveryPaused", StateExecutingTool, StateRecoveryPaused, true},
+		{"RecoveryPaused to Processing", StateRecoveryPaused, StateProcessingPrompt, true},
 
 		// Invalid transitions
 		{"Idle to Executing", StateIdle, StateExecutingTool, false},
 		{"Halted to anything", StateHalted, StateProcessingPrompt, false},
 		{"Processing to PhaseTransition", StateProcessingPrompt, StatePhaseTransition, false},
+		{"ResourcePaused to Executing", StateResourcePaused, StateExecutingTool, false},
+		{"Halted to LoopPaused", StateHalted, StateLoopPaused, false},
+		{"RecoveryPaused to Executing", StateRecoveryPaused, StateExecutingTool, false},
 	}
 
 	for _, tt := range tests {
@@ -218,8 +227,8 @@ func TestStateMachineWithPhases(t *testing.T) {
 	}
 
 	// Record some work
-	sm.RecordToolCall("edit", "test.go", "", "", true)
-	sm.RecordToolCall("bash", "", "go test", "", true)
+	sm.RecordToolCall(context.Background(), "edit", "test.go", "", "", true)
+	sm.RecordToolCall(context.Background(), "bash", "", "go test", "", true)
 	sm.RecordTest("go test", true, "PASS")
 
 	// Don't manually complete - StartPhase for phase 2 will auto-complete phase 1
@@ -291,7 +300,7 @@ func TestStateMachineCallbacks(t *testing.T) {
 
 	// Trigger stuck condition (5 errors needed now)
 	for i := 0; i < 5; i++ {
-		sm.RecordToolCall("edit", "test.go", "", "same error", false)
+		sm.RecordToolCall(context.Background(), "edit", "test.go", "", "same error", false)
 	}
 
 	// Give callback time to execute
@@ -307,7 +316,7 @@ func TestStateMachineCallbacks(t *testing.T) {
 
 	// Trigger progress callback (every 10 calls)
 	for i := 0; i < 10; i++ {
-		sm.RecordToolCall("edit", "file.go", "", "", true)
+		sm.RecordToolCall(context.Background(), "edit", "file.go", "", "", true)
 	}
 
 	// Give callback time to execute
@@ -339,7 +348,7 @@ func TestStateMachineLongRunningTask(t *testing.T) {
 		// Simulate 100 tool calls per phase (1000 total)
 		for call := 0; call < 100; call++ {
 			fileName := fmt.Sprintf("file_%d_%d.go", phase, call)
-			sm.RecordToolCall("edit", fileName, "", "", true)
+			sm.RecordToolCall(context.Background(), "edit", fileName, "", "", true)
 		}
 
 		// Mark tests passed
@@ -387,7 +396,7 @@ func TestStateMachineStuckDetection(t *testing.T) {
 
 	// Simulate stuck loop: same error 5 times (new threshold)
 	for i := 0; i < 5; i++ {
-		sm.RecordToolCall("edit", "stuck.go", "", "old_string not found", false)
+		sm.RecordToolCall(context.Background(), "edit", "stuck.go", "", "old_string not found", false)
 	}
 
 	stuck, reason := sm.IsStuck()
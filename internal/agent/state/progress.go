@@ -1,8 +1,12 @@
 package state
 
 import (
+	"context"
 	"crypto/sha256"
 	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
 	"sync"
 	"time"
 )
@@ -12,14 +16,21 @@ type ProgressTracker struct {
 	mu sync.RWMutex
 
 	// Semantic progress markers
-	filesModified    map[string]string // file path -> content hash
-	commandsExecuted map[string]int    // command -> execution count
+	filesModified    map[string]string   // file path -> content hash
+	fileHashes       map[string][]string // file path -> recent content hashes
+	maxFileHashes    int
+	commandsExecuted map[string]int // command -> execution count
 	testsRun         []TestResult
 	milestones       []Milestone
 
 	// Loop detection
 	recentActions    []ActionFingerprint
 	maxRecentActions int // Keep last 10-20 actions
+	totalActions     int
+	task             string
+	policy           LoopPolicy
+	verdict          Verdict
+	verdictNew       bool // The verdict was not taken by TakeVerdict yet
 
 	// Error tracking
 	recentErrors      []ErrorFingerprint
@@ -70,11 +81,14 @@ type Milestone struct {
 func NewProgressTracker() *ProgressTracker {
 	return &ProgressTracker{
 		filesModified:       make(map[string]string),
+		fileHashes:          make(map[string][]string),
+		maxFileHashes:       6,
 		commandsExecuted:    make(map[string]int),
 		testsRun:            make([]TestResult, 0),
 		milestones:          make([]Milestone, 0),
 		recentActions:       make([]ActionFingerprint, 0),
 		maxRecentActions:    20,
+		policy:              HeuristicPolicy{},
 		recentErrors:        make([]ErrorFingerprint, 0),
 		maxRecentErrors:     10,
 		recentMessageHashes: make([]string, 0),
@@ -88,6 +102,11 @@ func (pt *ProgressTracker) RecordFileModification(filePath, contentHash string)
 	defer pt.mu.Unlock()
 
 	pt.filesModified[filePath] = contentHash
+
+	hashes := pt.fileHashes[filePath]
+	if len(hashes) == 0 || hashes[len(hashes)-1] != contentHash {
+		pt.fileHashes[filePath] = lastN(append(hashes, contentHash), pt.maxFileHashes)
+	}
 }
 
 // RecordCommand records a command execution.
@@ -143,6 +162,7 @@ func (pt *ProgressTracker) RecordAction(toolName, targetFile, command, errorMsg
 		Success:    success,
 	}
 
+	pt.totalActions++
 	pt.recentActions = append(pt.recentActions, action)
 	if len(pt.recentActions) > pt.maxRecentActions {
 		pt.recentActions = pt.recentActions[1:]
@@ -188,136 +208,106 @@ func (pt *ProgressTracker) RecordMessage(message string) bool {
 	return false // Not a duplicate
 }
 
-// IsStuck determines if the agent is stuck in a loop.
-func (pt *ProgressTracker) IsStuck() (bool, string) {
-	pt.mu.RLock()
-	defer pt.mu.RUnlock()
-
-	// Check 1: Consecutive identical errors (same file, same error, 5+ times)
-	// Increased threshold to reduce false positives
-	if pt.consecutiveErrors >= 5 {
-		if stuck, reason := pt.hasSameFileError(); stuck {
-			return true, reason
-		}
-	}
+// SetPolicy sets the loop policy that judges the progress.
+func (pt *ProgressTracker) SetPolicy(policy LoopPolicy) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
 
-	// Check 2: Oscillating actions (edit A->B, edit B->A repeatedly)
-	if stuck, reason := pt.hasOscillatingActions(); stuck {
-		return true, reason
-	}
+	pt.policy = policy
+}
 
-	// Check 3: No progress (same actions, no file changes)
-	// Only check if we have enough actions and no recent success
-	if len(pt.recentActions) >= 15 { // Increased from 10
-		if stuck, reason := pt.hasNoProgress(); stuck {
-			return true, reason
-		}
-	}
+// SetTask sets the task that drift is judged against.
+func (pt *ProgressTracker) SetTask(task string) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
 
-	return false, ""
+	pt.task = task
 }
 
-// hasSameFileError checks if the same file has the same error 3+ times.
-func (pt *ProgressTracker) hasSameFileError() (bool, string) {
-	if len(pt.recentErrors) < 3 {
-		return false, ""
-	}
+// Observe returns what the loop policy judges.
+func (pt *ProgressTracker) Observe() Observation {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
 
-	// Get last 3 errors
-	recent := pt.recentErrors[max(0, len(pt.recentErrors)-3):]
-
-	// Check if same target and error
-	if len(recent) >= 3 {
-		first := recent[0]
-		matchCount := 1
-		for i := 1; i < len(recent); i++ {
-			if recent[i].Target == first.Target && recent[i].ErrorHash == first.ErrorHash {
-				matchCount++
-			}
-		}
-		if matchCount >= 3 {
-			return true, fmt.Sprintf("Same error on '%s' repeated 3 times", first.Target)
-		}
+	fileHashes := make(map[string][]string, len(pt.fileHashes))
+	for path, hashes := range pt.fileHashes {
+		fileHashes[path] = slices.Clone(hashes)
 	}
-
-	return false, ""
-}
-
-// hasOscillatingActions detects alternating actions (A->B->A->B pattern).
-func (pt *ProgressTracker) hasOscillatingActions() (bool, string) {
-	if len(pt.recentActions) < 4 {
-		return false, ""
+	return Observation{
+		Task:              pt.task,
+		TotalActions:      pt.totalActions,
+		Actions:           slices.Clone(pt.recentActions),
+		Errors:            slices.Clone(pt.recentErrors),
+		ConsecutiveErrors: pt.consecutiveErrors,
+		FilesModified:     maps.Clone(pt.filesModified),
+		FileHashes:        fileHashes,
+		Tests:             slices.Clone(pt.testsRun),
 	}
+}
 
-	// Get last 4 actions
-	recent := pt.recentActions[max(0, len(pt.recentActions)-4):]
-
-	// Check for A-B-A-B pattern (same target, alternating operations)
-	if len(recent) == 4 {
-		if recent[0].TargetFile == recent[2].TargetFile &&
-			recent[1].TargetFile == recent[3].TargetFile &&
-			recent[0].TargetFile != "" &&
-			recent[1].TargetFile != "" &&
-			recent[0].TargetFile != recent[1].TargetFile {
-			return true, fmt.Sprintf("Oscillating between '%s' and '%s'", recent[0].TargetFile, recent[1].TargetFile)
-		}
-	}
+// Evaluate has the loop policy judge the progress, falling back to the
+// heuristics when the policy fails, and keeps the verdict.
+func (pt *ProgressTracker) Evaluate(ctx context.Context) Verdict {
+	pt.mu.RLock()
+	policy := pt.policy
+	pt.mu.RUnlock()
 
-	return false, ""
+	return pt.evaluate(ctx, policy)
 }
 
-// hasNoProgress checks if there's been no meaningful progress.
-func (pt *ProgressTracker) hasNoProgress() (bool, string) {
-	// Only check if we have enough actions
-	if len(pt.recentActions) < 15 {
-		return false, ""
+// evaluate has policy judge the progress. A verdict that the session is not
+// stuck doesn't replace a stuck one that was not taken yet.
+func (pt *ProgressTracker) evaluate(ctx context.Context, policy LoopPolicy) Verdict {
+	obs := pt.Observe()
+	verdict, err := policy.Evaluate(ctx, obs)
+	if err != nil {
+		slog.Warn("loop policy failed, using heuristics", "policy", policy.Name(), "error", err)
+		verdict, _ = HeuristicPolicy{}.Evaluate(ctx, obs)
 	}
+	verdict.Time = time.Now()
 
-	// Get last 15 actions to match the error message
-	recent := pt.recentActions[max(0, len(pt.recentActions)-15):]
-
-	// Count successful operations and analyze progress patterns
-	uniqueTargets := make(map[string]bool)
-	successCount := 0
-	meaningfulSuccessCount := 0 // Only counts edits, writes, etc. (not just views)
-	successfulOps := make([]ActionFingerprint, 0)
-
-	for _, action := range recent {
-		if action.Success {
-			target := getTarget(action.TargetFile, action.Command)
-			if target != "" {
-				uniqueTargets[target] = true
-			}
-			successCount++
+	pt.mu.Lock()
+	if verdict.Stuck() || !pt.verdictNew || !pt.verdict.Stuck() {
+		pt.verdict = verdict
+		pt.verdictNew = true
+	}
+	pt.mu.Unlock()
+	return verdict
+}
 
-			// Count only meaningful operations as progress (not just viewing)
-			if action.ToolName != "view" && action.ToolName != "ls" && action.ToolName != "grep" {
-				meaningfulSuccessCount++
-			}
+// TakeVerdict returns the verdict of the last evaluation, and whether it was
+// not taken before.
+func (pt *ProgressTracker) TakeVerdict() (Verdict, bool) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
 
-			successfulOps = append(successfulOps, action)
-		}
-	}
+	isNew := pt.verdictNew
+	pt.verdictNew = false
+	return pt.verdict, isNew
+}
 
-	// More sophisticated progress detection:
-	// Only declare stuck if we have very few meaningful successes AND very little variety
-	// AND no meaningful file modifications in recent actions
+// quickPolicy reports whether the loop policy judges without calling out to
+// a model or a service.
+func (pt *ProgressTracker) quickPolicy() bool {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
 
-	// Check if we've made any actual file modifications (strong progress indicator)
-	hasFileMods := len(pt.filesModified) > 0
+	_, ok := pt.policy.(HeuristicPolicy)
+	return ok
+}
 
-	// Check for variety in successful operations (different tools, targets, etc.)
-	uniqueTools := make(map[string]bool)
-	for _, op := range successfulOps {
-		uniqueTools[op.ToolName] = true
-	}
+// LastVerdict returns the verdict of the last evaluation.
+func (pt *ProgressTracker) LastVerdict() Verdict {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
 
-	// More lenient conditions: need both low meaningful success rate AND low variety
-	if meaningfulSuccessCount < 2 && len(uniqueTargets) < 3 && len(uniqueTools) < 2 && !hasFileMods {
-		return true, fmt.Sprintf("No meaningful progress in last 15 actions (%d meaningful successes, %d unique targets)", meaningfulSuccessCount, len(uniqueTargets))
-	}
+	return pt.verdict
+}
 
-	return false, ""
+// IsStuck determines if the agent is stuck in a loop.
+func (pt *ProgressTracker) IsStuck() (bool, string) {
+	verdict := pt.Evaluate(context.Background())
+	return verdict.Stuck(), verdict.Reason
 }
 
 // Reset clears all progress tracking (called when phase transitions).
@@ -331,6 +321,9 @@ func (pt *ProgressTracker) Reset() {
 	pt.recentErrors = make([]ErrorFingerprint, 0)
 	pt.consecutiveErrors = 0
 	pt.recentMessageHashes = make([]string, 0)
+	pt.fileHashes = make(map[string][]string)
+	pt.verdict = Verdict{}
+	pt.verdictNew = false
 }
 
 // GetStats returns current progress statistics.
@@ -391,10 +384,3 @@ func getTarget(file, command string) string {
 	}
 	return ""
 }
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
@@ -0,0 +1,253 @@
+package state
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Snapshot is the persistent state of a StateMachine: what is needed to
+// resume a session after a restart, including its progress and phases.
+type Snapshot struct {
+	SessionID     string             `json:"session_id"`
+	State         string             `json:"state"`
+	PauseReason   string             `json:"pause_reason,omitempty"`
+	StartTime     time.Time          `json:"start_time"`
+	ToolCallCount int                `json:"tool_call_count"`
+	History       []TransitionRecord `json:"history,omitempty"`
+	Progress      ProgressSnapshot   `json:"progress"`
+	Phase         *PhaseSnapshot     `json:"phase,omitempty"`
+}
+
+// TransitionRecord is a StateMetrics entry of a snapshot.
+type TransitionRecord struct {
+	State        string    `json:"state"`
+	EnterTime    time.Time `json:"enter_time"`
+	ExitTime     time.Time `json:"exit_time,omitzero"`
+	TransitionTo string    `json:"transition_to,omitempty"`
+	ErrorCount   int       `json:"error_count,omitempty"`
+}
+
+// ProgressSnapshot is the persistent state of a ProgressTracker, including
+// the recent actions and errors stuck detection works from.
+type ProgressSnapshot struct {
+	FilesModified       map[string]string   `json:"files_modified,omitempty"`
+	CommandsExecuted    map[string]int      `json:"commands_executed,omitempty"`
+	TestsRun            []TestResult        `json:"tests_run,omitempty"`
+	Milestones          []Milestone         `json:"milestones,omitempty"`
+	RecentActions       []ActionFingerprint `json:"recent_actions,omitempty"`
+	RecentErrors        []ErrorFingerprint  `json:"recent_errors,omitempty"`
+	ConsecutiveErrors   int                 `json:"consecutive_errors,omitempty"`
+	RecentMessageHashes []string            `json:"recent_message_hashes,omitempty"`
+	FileHashes          map[string][]string `json:"file_hashes,omitempty"`
+	TotalActions        int                 `json:"total_actions,omitempty"`
+	Task                string              `json:"task,omitempty"`
+	Verdict             *Verdict            `json:"verdict,omitempty"`
+}
+
+// PhaseSnapshot is the persistent state of a PhaseContext.
+type PhaseSnapshot struct {
+	CurrentPhase     int              `json:"current_phase"`
+	TotalPhases      int              `json:"total_phases"`
+	PhaseDescription string           `json:"phase_description,omitempty"`
+	PhaseStartTime   time.Time        `json:"phase_start_time,omitzero"`
+	ExpectedDuration time.Duration    `json:"expected_duration,omitempty"`
+	TotalStartTime   time.Time        `json:"total_start_time"`
+	FilesChanged     []string         `json:"files_changed,omitempty"`
+	TestsPassed      bool             `json:"tests_passed,omitempty"`
+	Blockers         []string         `json:"blockers,omitempty"`
+	CompletedPhases  []CompletedPhase `json:"completed_phases,omitempty"`
+}
+
+// AgentState returns the state of the snapshot.
+func (s Snapshot) AgentState() AgentState {
+	state, _ := ParseAgentState(s.State)
+	return state
+}
+
+// Snapshot returns the persistent state of the state machine.
+func (sm *StateMachine) Snapshot() Snapshot {
+	sm.mu.RLock()
+	snapshot := Snapshot{
+		SessionID:     sm.sessionID,
+		State:         sm.currentState.String(),
+		PauseReason:   sm.pauseReason,
+		StartTime:     sm.startTime,
+		ToolCallCount: sm.toolCallCount,
+		History:       make([]TransitionRecord, 0, len(sm.stateHistory)),
+	}
+	for _, m := range sm.stateHistory {
+		record := TransitionRecord{
+			State:      m.State.String(),
+			EnterTime:  m.EnterTime,
+			ExitTime:   m.ExitTime,
+			ErrorCount: m.ErrorCount,
+		}
+		if !m.ExitTime.IsZero() {
+			record.TransitionTo = m.TransitionTo.String()
+		}
+		snapshot.History = append(snapshot.History, record)
+	}
+	sm.mu.RUnlock()
+
+	snapshot.Progress = sm.progressTracker.snapshot()
+	if sm.phaseContext != nil {
+		phase := sm.phaseContext.snapshot()
+		snapshot.Phase = &phase
+	}
+	return snapshot
+}
+
+// RestoreStateMachine creates a state machine from cfg that resumes from
+// snapshot. A paused session stays paused; any other session, including one
+// interrupted while working by a crash or a restart, resumes idle since its
+// turn is gone.
+func RestoreStateMachine(cfg Config, snapshot Snapshot) (*StateMachine, error) {
+	current, ok := ParseAgentState(snapshot.State)
+	if !ok {
+		return nil, fmt.Errorf("unknown agent state %q", snapshot.State)
+	}
+	if !current.IsPaused() {
+		current = StateIdle
+	}
+
+	cfg.TotalPhases = 0 // The phases come from the snapshot
+	sm := NewStateMachine(cfg)
+	sm.currentState = current
+	if current.IsPaused() {
+		sm.pauseReason = snapshot.PauseReason
+	}
+	if !snapshot.StartTime.IsZero() {
+		sm.startTime = snapshot.StartTime
+	}
+	sm.toolCallCount = snapshot.ToolCallCount
+
+	for _, r := range snapshot.History {
+		state, _ := ParseAgentState(r.State)
+		to, _ := ParseAgentState(r.TransitionTo)
+		sm.stateHistory = append(sm.stateHistory, StateMetrics{
+			State:        state,
+			EnterTime:    r.EnterTime,
+			ExitTime:     r.ExitTime,
+			Duration:     r.ExitTime.Sub(r.EnterTime),
+			TransitionTo: to,
+			ErrorCount:   r.ErrorCount,
+		})
+	}
+	if n := len(sm.stateHistory); n > sm.maxHistory {
+		sm.stateHistory = sm.stateHistory[n-sm.maxHistory:]
+	}
+
+	sm.progressTracker.restore(snapshot.Progress)
+	if snapshot.Phase != nil {
+		sm.phaseContext = restorePhaseContext(*snapshot.Phase)
+	}
+	return sm, nil
+}
+
+// Resume leaves a paused state so that the agent can take prompts again.
+func (sm *StateMachine) Resume() error {
+	current := sm.GetState()
+	if !current.IsPaused() {
+		return fmt.Errorf("session is not paused: %s", current)
+	}
+	return sm.TransitionTo(StateIdle)
+}
+
+func (pt *ProgressTracker) snapshot() ProgressSnapshot {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+
+	var verdict *Verdict
+	if pt.verdict.Stuck() {
+		v := pt.verdict
+		verdict = &v
+	}
+	fileHashes := make(map[string][]string, len(pt.fileHashes))
+	for path, hashes := range pt.fileHashes {
+		fileHashes[path] = slices.Clone(hashes)
+	}
+	return ProgressSnapshot{
+		FilesModified:       maps.Clone(pt.filesModified),
+		CommandsExecuted:    maps.Clone(pt.commandsExecuted),
+		TestsRun:            slices.Clone(pt.testsRun),
+		Milestones:          slices.Clone(pt.milestones),
+		RecentActions:       slices.Clone(pt.recentActions),
+		RecentErrors:        slices.Clone(pt.recentErrors),
+		ConsecutiveErrors:   pt.consecutiveErrors,
+		RecentMessageHashes: slices.Clone(pt.recentMessageHashes),
+		FileHashes:          fileHashes,
+		TotalActions:        pt.totalActions,
+		Task:                pt.task,
+		Verdict:             verdict,
+	}
+}
+
+func (pt *ProgressTracker) restore(s ProgressSnapshot) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	if s.FilesModified != nil {
+		pt.filesModified = maps.Clone(s.FilesModified)
+	}
+	if s.CommandsExecuted != nil {
+		pt.commandsExecuted = maps.Clone(s.CommandsExecuted)
+	}
+	pt.testsRun = append(pt.testsRun[:0], s.TestsRun...)
+	pt.milestones = append(pt.milestones[:0], s.Milestones...)
+	pt.recentActions = append(pt.recentActions[:0], lastN(s.RecentActions, pt.maxRecentActions)...)
+	pt.recentErrors = append(pt.recentErrors[:0], lastN(s.RecentErrors, pt.maxRecentErrors)...)
+	pt.consecutiveErrors = s.ConsecutiveErrors
+	pt.recentMessageHashes = append(pt.recentMessageHashes[:0], lastN(s.RecentMessageHashes, pt.maxMessageHistory)...)
+	for path, hashes := range s.FileHashes {
+		pt.fileHashes[path] = slices.Clone(lastN(hashes, pt.maxFileHashes))
+	}
+	pt.totalActions = s.TotalActions
+	pt.task = s.Task
+	if s.Verdict != nil {
+		pt.verdict = *s.Verdict
+	}
+}
+
+func (pc *PhaseContext) snapshot() PhaseSnapshot {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+
+	return PhaseSnapshot{
+		CurrentPhase:     pc.CurrentPhase,
+		TotalPhases:      pc.TotalPhases,
+		PhaseDescription: pc.PhaseDescription,
+		PhaseStartTime:   pc.PhaseStartTime,
+		ExpectedDuration: pc.ExpectedDuration,
+		TotalStartTime:   pc.TotalStartTime,
+		FilesChanged:     slices.Clone(pc.FilesChanged),
+		TestsPassed:      pc.TestsPassed,
+		Blockers:         slices.Clone(pc.Blockers),
+		CompletedPhases:  slices.Clone(pc.CompletedPhases),
+	}
+}
+
+func restorePhaseContext(s PhaseSnapshot) *PhaseContext {
+	pc := NewPhaseContext(s.TotalPhases)
+	pc.CurrentPhase = s.CurrentPhase
+	pc.PhaseDescription = s.PhaseDescription
+	pc.PhaseStartTime = s.PhaseStartTime
+	pc.ExpectedDuration = s.ExpectedDuration
+	if !s.TotalStartTime.IsZero() {
+		pc.TotalStartTime = s.TotalStartTime
+	}
+	pc.FilesChanged = append(pc.FilesChanged, s.FilesChanged...)
+	pc.TestsPassed = s.TestsPassed
+	pc.Blockers = append(pc.Blockers, s.Blockers...)
+	pc.CompletedPhases = append(pc.CompletedPhases, s.CompletedPhases...)
+	return pc
+}
+
+// lastN returns the last n elements of s.
+func lastN[T any](s []T, n int) []T {
+	if len(s) > n {
+		return s[len(s)-n:]
+	}
+	return s
+}
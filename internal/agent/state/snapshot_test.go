@@ -0,0 +1,156 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	sm := NewStateMachine(Config{
+		SessionID:   "session-1",
+		Context:     context.Background(),
+		TotalPhases: 3,
+	})
+	if err := sm.StartPhase(1, "Extract interfaces", 10*time.Minute); err != nil {
+		t.Fatalf("StartPhase: %v", err)
+	}
+	sm.RecordToolCall(context.Background(), "edit", "a.go", "", "", true)
+	sm.RecordToolCall(context.Background(), "edit", "b.go", "", "old_string not found", false)
+	sm.RecordFileModification("a.go", "hash-a")
+	sm.RecordTest("go test ./...", true, "PASS")
+	sm.RecordMilestone("interfaces extracted", nil)
+	sm.RecordMessage("hello")
+	if err := sm.TransitionTo(StateResourcePaused); err != nil {
+		t.Fatalf("TransitionTo: %v", err)
+	}
+	sm.SetPauseReason("Memory usage 91% exceeds threshold")
+
+	// The snapshot is stored as JSON.
+	data, err := json.Marshal(sm.Snapshot())
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	restored, err := RestoreStateMachine(Config{SessionID: "session-1"}, snapshot)
+	if err != nil {
+		t.Fatalf("RestoreStateMachine: %v", err)
+	}
+
+	if got := restored.GetState(); got != StateResourcePaused {
+		t.Errorf("expected state ResourcePaused, got %s", got)
+	}
+	if got := restored.GetPauseReason(); got != "Memory usage 91% exceeds threshold" {
+		t.Errorf("expected pause reason to be restored, got %q", got)
+	}
+	if got := restored.GetToolCallCount(); got != 2 {
+		t.Errorf("expected 2 tool calls, got %d", got)
+	}
+	if got, want := restored.GetProgress(), sm.GetProgress(); got != want {
+		t.Errorf("expected progress %+v, got %+v", want, got)
+	}
+	if !restored.RecordMessage("hello") {
+		t.Error("expected message history to be restored")
+	}
+	if got, want := len(restored.GetStateHistory(0)), len(sm.GetStateHistory(0)); got != want {
+		t.Errorf("expected %d history entries, got %d", want, got)
+	}
+
+	phase := restored.GetPhaseInfo()
+	if phase == nil {
+		t.Fatal("expected phase context to be restored")
+	}
+	if phase.PhaseNumber != 1 || phase.TotalPhases != 3 || phase.Description != "Extract interfaces" {
+		t.Errorf("unexpected phase %+v", *phase)
+	}
+	if phase.FilesChanged != 1 || !phase.TestsPassed {
+		t.Errorf("expected phase progress to be restored, got %+v", *phase)
+	}
+}
+
+func TestRestoreInterruptedSession(t *testing.T) {
+	snapshot := Snapshot{
+		SessionID: "session-1",
+		State:     StateExecutingTool.String(),
+		Progress: ProgressSnapshot{
+			ConsecutiveErrors: 2,
+		},
+	}
+
+	sm, err := RestoreStateMachine(Config{SessionID: "session-1"}, snapshot)
+	if err != nil {
+		t.Fatalf("RestoreStateMachine: %v", err)
+	}
+	if got := sm.GetState(); got != StateIdle {
+		t.Errorf("expected an interrupted session to resume idle, got %s", got)
+	}
+	if got := sm.GetProgress().ConsecutiveErrors; got != 2 {
+		t.Errorf("expected stuck detection history to be restored, got %d consecutive errors", got)
+	}
+	if err := sm.TransitionTo(StateProcessingPrompt); err != nil {
+		t.Errorf("expected the restored session to take prompts: %v", err)
+	}
+
+	if _, err := RestoreStateMachine(Config{}, Snapshot{State: "Sleeping"}); err == nil {
+		t.Error("expected an error for an unknown state")
+	}
+}
+
+func TestResume(t *testing.T) {
+	sm := NewStateMachine(Config{SessionID: "session-1"})
+	if err := sm.Resume(); err == nil {
+		t.Error("expected an error resuming a session that isn't paused")
+	}
+
+	if err := sm.TransitionTo(StateLoopPaused); err != nil {
+		t.Fatalf("TransitionTo: %v", err)
+	}
+	sm.SetPauseReason("Same error on 'a.go' repeated 3 times")
+	if err := sm.Resume(); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if got := sm.GetState(); got != StateIdle {
+		t.Errorf("expected Idle after resuming, got %s", got)
+	}
+	if got := sm.GetPauseReason(); got != "" {
+		t.Errorf("expected pause reason to be cleared, got %q", got)
+	}
+}
+
+func TestParseAgentState(t *testing.T) {
+	for s := StateIdle; s <= StateRecoveryPaused; s++ {
+		parsed, ok := ParseAgentState(s.String())
+		if !ok || parsed != s {
+			t.Errorf("ParseAgentState(%q) = %v, %v", s.String(), parsed, ok)
+		}
+	}
+	if _, ok := ParseAgentState("Unknown(42)"); ok {
+		t.Error("expected unknown state not to parse")
+	}
+}
+
+func TestPauseRecordsReasonBeforeCallback(t *testing.T) {
+	reasons := make(chan string, 1)
+	var sm *StateMachine
+	sm = NewStateMachine(Config{
+		SessionID: "session-1",
+		OnStateChange: func(from, to AgentState) {
+			reasons <- sm.GetPauseReason()
+		},
+	})
+
+	if err := sm.Pause(StateIdle, "not a pause"); err == nil {
+		t.Error("expected an error pausing into a state that isn't paused")
+	}
+	if err := sm.Pause(StateResourcePaused, "CPU usage 95.0% exceeds threshold"); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	if got := <-reasons; got != "CPU usage 95.0% exceeds threshold" {
+		t.Errorf("expected the callback to see the pause reason, got %q", got)
+	}
+}
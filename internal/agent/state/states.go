@@ -42,6 +42,12 @@ const (
 
 	// StateHalted indicates the agent has stopped (loop detected or user request)
 	StateHalted
+
+	// StateLoopPaused indicates agent paused because it was stuck in a loop
+	StateLoopPaused
+
+	// StateRecoveryPaused indicates agent paused by a recovery rule
+	StateRecoveryPaused
 )
 
 // String returns a human-readable state name.
@@ -67,16 +73,36 @@ func (s AgentState) String() string {
 		return "ResourcePaused"
 	case StateHalted:
 		return "Halted"
+	case StateLoopPaused:
+		return "LoopPaused"
+	case StateRecoveryPaused:
+		return "RecoveryPaused"
 	default:
 		return fmt.Sprintf("Unknown(%d)", s)
 	}
 }
 
+// ParseAgentState returns the state named name, as returned by String.
+func ParseAgentState(name string) (AgentState, bool) {
+	for s := StateIdle; s <= StateRecoveryPaused; s++ {
+		if s.String() == name {
+			return s, true
+		}
+	}
+	return StateIdle, false
+}
+
 // IsTerminal returns true if this state is terminal (execution should stop).
 func (s AgentState) IsTerminal() bool {
 	return s == StateHalted
 }
 
+// IsPaused returns true if the agent is paused until it is resumed, by the
+// user or by a new prompt.
+func (s AgentState) IsPaused() bool {
+	return s == StateResourcePaused || s == StateLoopPaused || s == StateRecoveryPaused
+}
+
 // CanTransitionTo returns true if transitioning from this state to target is valid.
 func (s AgentState) CanTransitionTo(target AgentState) bool {
 	transitions, ok := validTransitions[s]
@@ -98,12 +124,18 @@ var validTransitions = map[AgentState][]AgentState{
 		StateProcessingPrompt,
 		StatePhaseTransition,
 		StateHalted,
+		StateResourcePaused,
+		StateLoopPaused,
+		StateRecoveryPaused,
 	},
 	StateProcessingPrompt: {
 		StateStreamingResponse,
 		StateErrorRecovery,
 		StateHalted,
 		StateProcessingPrompt, // Allow re-prompting for better recovery
+		StateResourcePaused,
+		StateLoopPaused,
+		StateRecoveryPaused,
 	},
 	StateStreamingResponse: {
 		StateExecutingTool,
@@ -112,18 +144,27 @@ var validTransitions = map[AgentState][]AgentState{
 		StateIdle,
 		StateErrorRecovery,
 		StateHalted,
+		StateResourcePaused,
+		StateLoopPaused,
+		StateRecoveryPaused,
 	},
 	StateExecutingTool: {
 		StateStreamingResponse,
 		StateProgressCheck,
 		StateErrorRecovery,
 		StateHalted,
+		StateResourcePaused,
+		StateLoopPaused,
+		StateRecoveryPaused,
 	},
 	StateAwaitingPermission: {
 		StateStreamingResponse,
 		StateExecutingTool,
 		StateIdle,
 		StateHalted,
+		StateResourcePaused,
+		StateLoopPaused,
+		StateRecoveryPaused,
 	},
 	StateErrorRecovery: {
 		StateStreamingResponse,
@@ -131,6 +172,9 @@ var validTransitions = map[AgentState][]AgentState{
 		StateProgressCheck,
 		StateIdle,
 		StateHalted,
+		StateResourcePaused,
+		StateLoopPaused,
+		StateRecoveryPaused,
 	},
 	StatePhaseTransition: {
 		StateProcessingPrompt,
@@ -138,6 +182,9 @@ var validTransitions = map[AgentState][]AgentState{
 		StatePhaseTransition,
 		StateIdle,
 		StateHalted,
+		StateResourcePaused,
+		StateLoopPaused,
+		StateRecoveryPaused,
 	},
 	StateProgressCheck: {
 		StateStreamingResponse,
@@ -146,6 +193,30 @@ var validTransitions = map[AgentState][]AgentState{
 		StateErrorRecovery,
 		StateIdle,
 		StateHalted,
+		StateResourcePaused,
+		StateLoopPaused,
+		StateRecoveryPaused,
+	},
+	StateResourcePaused: {
+		StateIdle,
+		StateProcessingPrompt, // A new prompt resumes the session
+		StateLoopPaused,
+		StateRecoveryPaused,
+		StateHalted,
+	},
+	StateLoopPaused: {
+		StateIdle,
+		StateProcessingPrompt, // A new prompt resumes the session
+		StateResourcePaused,
+		StateRecoveryPaused,
+		StateHalted,
+	},
+	StateRecoveryPaused: {
+		StateIdle,
+		StateProcessingPrompt, // A new prompt resumes the session
+		StateResourcePaused,
+		StateLoopPaused,
+		StateHalted,
 	},
 	StateHalted: {}, // Terminal state
 }
@@ -0,0 +1,17 @@
+
+
+<env>
+Working directory: {{.WorkingDir}}
+Is directory a git repo: {{if .IsGitRepo}} yes {{else}} no {{end}}
+Platform: {{.Platform}}
+Today's date: {{.Date}}
+</env>
+{{if .ContextFiles}}
+<memory>
+{{range .ContextFiles}}
+<file path="{{.Path}}">
+{{.Content}}
+</file>
+{{end}}
+</memory>
+{{end}}
@@ -17,6 +17,7 @@ import (
 // - job_kill: Kill background jobs
 // - job_output: Get background job output
 // - multiedit: Edit multiple files
+// - notebook_edit: Edit Jupyter notebook cells
 // - smart_edit: Smart editing with AI
 // - agentic_fetch: Fetch with agentic retry logic
 // - lsp_diagnostics: Language server protocol diagnostics
@@ -58,6 +59,10 @@ var toolAliases = map[string]string{
 	"make":   "write",
 	"new":    "write",
 
+	// Notebook tools
+	"notebookedit":  "notebook_edit",
+	"edit_notebook": "notebook_edit",
+
 	// Search tools
 	"search": "grep",
 	"rg":     "grep",
@@ -6,7 +6,6 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
-	"html/template"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -14,11 +13,13 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"charm.land/fantasy"
 	"github.com/nexora/nexora/internal/config"
 	"github.com/nexora/nexora/internal/permission"
+	"github.com/nexora/nexora/internal/sandbox"
 	"github.com/nexora/nexora/internal/shell"
 )
 
@@ -53,6 +54,10 @@ type BashResponseMetadata struct {
 	TmuxSessionID string `json:"tmux_session_id,omitempty"`
 	TmuxPaneID    string `json:"tmux_pane_id,omitempty"`
 	TmuxAvailable bool   `json:"tmux_available,omitempty"`
+
+	// Sandbox fields
+	Sandboxed  bool                `json:"sandboxed,omitempty"`
+	Violations []sandbox.Violation `json:"violations,omitempty"`
 }
 
 const (
@@ -76,8 +81,14 @@ type bashDescriptionData struct {
 	MaxOutputLength int
 	ScrollbackLines int
 	TerminalInfo    string
-	Attribution     config.Attribution
-	ModelName       string
+	CommitFooter    string
+	CommitTrailers  []string
+	Sandbox         *bashSandboxData
+}
+
+type bashSandboxData struct {
+	Network       bool
+	WritablePaths []string
 }
 
 const defaultScrollbackLines = 10000 // typical terminal scrollback
@@ -141,17 +152,25 @@ func detectSleepDuration(command string) time.Duration {
 	return 0
 }
 
-func bashDescription(attribution *config.Attribution, modelName string) string {
+func bashDescription(attribution *config.Attribution, modelName string, sb *sandbox.Sandbox) string {
 	bannedCommandsStr := strings.Join(bannedCommands, ", ")
-	var out bytes.Buffer
-	if err := bashDescriptionTpl.Execute(&out, bashDescriptionData{
+	footer, trailers := gitAttribution(attribution, modelName)
+	data := bashDescriptionData{
 		BannedCommands:  bannedCommandsStr,
 		MaxOutputLength: MaxOutputLength,
 		ScrollbackLines: defaultScrollbackLines,
 		TerminalInfo:    getTerminalInfo(),
-		Attribution:     *attribution,
-		ModelName:       modelName,
-	}); err != nil {
+		CommitFooter:    footer,
+		CommitTrailers:  trailers,
+	}
+	if sb != nil {
+		data.Sandbox = &bashSandboxData{
+			Network:       sb.Network(),
+			WritablePaths: sb.WritablePaths(),
+		}
+	}
+	var out bytes.Buffer
+	if err := bashDescriptionTpl.Execute(&out, data); err != nil {
 		// this should never happen.
 		panic("failed to execute bash description template: " + err.Error())
 	}
@@ -231,6 +250,7 @@ func blockFuncs() []shell.BlockFunc {
 		// Block dangerous git operations
 		shell.ArgumentsBlocker("git", []string{"push"}, []string{"--force"}),
 		shell.ArgumentsBlocker("git", []string{"push"}, []string{"-f"}),
+		shell.ArgumentsBlocker("git", []string{"push"}, []string{"--force-with-lease"}),
 
 		// Block dangerous chmod operations
 		func(args []string) bool {
@@ -280,9 +300,15 @@ func sliceContains(slice []string, item string) bool {
 }
 
 func NewBashTool(permissions permission.Service, workingDir string, attribution *config.Attribution, modelName string) fantasy.AgentTool {
+	return NewSandboxedBashTool(permissions, workingDir, attribution, modelName, nil)
+}
+
+// NewSandboxedBashTool returns a bash tool that runs every command inside sb.
+// A nil sandbox runs commands unrestricted, like NewBashTool.
+func NewSandboxedBashTool(permissions permission.Service, workingDir string, attribution *config.Attribution, modelName string, sb *sandbox.Sandbox) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		BashToolName,
-		string(bashDescription(attribution, modelName)),
+		string(bashDescription(attribution, modelName, sb)),
 		func(ctx context.Context, params BashParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 			if params.Command == "" {
 				return fantasy.NewTextErrorResponse("missing command"), nil
@@ -291,6 +317,12 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			// Determine working directory
 			execWorkingDir := cmp.Or(params.WorkingDir, workingDir)
 
+			// The sandbox and TMUX run commands outside the shell that
+			// enforces the block list, so check it before anything runs
+			if blocked, ok := shell.BlockedCommand(params.Command, blockFuncs()); ok {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("command is not allowed for security reasons: %s", blocked)), nil
+			}
+
 			isSafeReadOnly := false
 			cmdLower := strings.ToLower(params.Command)
 
@@ -324,6 +356,10 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 				}
 			}
 
+			if sb != nil {
+				return executeSandboxedCommand(ctx, sb, params, execWorkingDir)
+			}
+
 			// === TMUX Integration ===
 			// If TMUX is available, use it for command execution
 			if shell.IsTmuxAvailable() {
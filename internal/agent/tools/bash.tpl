@@ -5,7 +5,15 @@ Uses mvdan/sh interpreter (Bash-compatible on all platforms including Windows).
 Use forward slashes for paths: "ls C:/foo/bar" not "ls C:\foo\bar".
 </cross_platform>
 
-<execution_steps>
+{{ if .Sandbox }}<sandbox>
+Commands run in a sandbox:
+- Writable: {{ range $i, $p := .Sandbox.WritablePaths }}{{ if $i }}, {{ end }}{{ $p }}{{ end }} and a private /tmp; everything else is read-only
+- Network: {{ if .Sandbox.Network }}allowed{{ else }}disabled - do not try to download dependencies or reach remote services{{ end }}
+- Commands run in the foreground only; run_in_background, shell_id and reset are unavailable
+- Blocked operations are reported in <sandbox_violations>; do not work around them, ask the user instead
+</sandbox>
+
+{{ end }}<execution_steps>
 1. Directory Verification: Use LS tool to verify parent exists before creating files
 2. Security Check: No restrictions - explain to user. Safe read-only commands execute without prompts
 3. Command Execution: Execute with proper quoting, capture output
@@ -38,7 +46,9 @@ Use forward slashes for paths: "ls C:/foo/bar" not "ls C:\foo\bar".
 </background_execution>
 
 <git_commits>
-When user asks to create git commit:
+Prefer the git_status, git_diff, git_log and git_commit tools over running git through bash; git_commit adds attribution automatically.
+
+When user asks to create git commit with bash:
 
 1. Run: git status, git diff, git log (single message, three tool_use blocks)
 2. Add relevant untracked files to staging
@@ -46,17 +56,15 @@ When user asks to create git commit:
    - List changed files, summarize nature (feature/bug fix/refactor/docs)
    - Draft concise (1-2 sentences) focusing on "why" not "what"
    - Use accurate verbs: "add"=new feature, "update"=enhancement, "fix"=bug
-4. Create commit{{ if or (eq .Attribution.TrailerStyle "assisted-by") (eq .Attribution.TrailerStyle "co-authored-by")}} with attribution{{ end }}:
+4. Create commit{{ if .CommitTrailers }} with attribution{{ end }}:
    ```bash
    git commit -m "$(cat <<'EOF'
    Commit message here.
-{{ if .Attribution.GeneratedWith }}
-   💘 Generated with Crush
+{{ if .CommitFooter }}
+   {{ .CommitFooter }}
 {{ end}}
-{{if eq .Attribution.TrailerStyle "assisted-by" }}
-   Assisted-by: {{ .ModelName }} via Crush <crush@charm.land>
-{{ else if eq .Attribution.TrailerStyle "co-authored-by" }}
-   Co-Authored-By: Crush <crush@charm.land>
+{{ range .CommitTrailers }}
+   {{ . }}
 {{ end }}
    EOF
    )"
@@ -84,8 +92,8 @@ When user asks to create PR:
    
    ## Test plan
    [Checklist...]
-{{ if .Attribution.GeneratedWith}}
-   💘 Generated with Crush
+{{ if .CommitFooter }}
+   {{ .CommitFooter }}
 {{ end }}
    EOF
    )"
@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/nexora/nexora/internal/sandbox"
+	"github.com/nexora/nexora/internal/shell"
+)
+
+// executeSandboxedCommand runs a command inside the sandbox and waits for it.
+// Background jobs and persistent shells are not available in the sandbox.
+func executeSandboxedCommand(ctx context.Context, sb *sandbox.Sandbox, params BashParams, execWorkingDir string) (fantasy.ToolResponse, error) {
+	if params.RunInBackground || params.ShellID != "" || params.Reset {
+		return fantasy.NewTextErrorResponse("background jobs, shell_id and reset are not available while commands run in the sandbox; run the command in the foreground"), nil
+	}
+
+	startTime := time.Now()
+	var stdout, stderr bytes.Buffer
+	cmd := sb.Command(ctx, execWorkingDir, params.Command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	execErr := cmd.Run()
+	if ctx.Err() != nil {
+		execErr = ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if execErr != nil && !errors.As(execErr, &exitErr) && !shell.IsInterrupt(execErr) {
+		return fantasy.ToolResponse{}, fmt.Errorf("error starting sandboxed command: %w", execErr)
+	}
+
+	violations := sandbox.DetectViolations(stdout.String()+"\n"+stderr.String(), shell.ExitCode(execErr), sb.Network())
+	output := formatOutput(stdout.String(), stderr.String(), execErr)
+
+	metadata := BashResponseMetadata{
+		StartTime:        startTime.UnixMilli(),
+		EndTime:          time.Now().UnixMilli(),
+		Output:           output,
+		Description:      params.Description,
+		WorkingDirectory: execWorkingDir,
+		Sandboxed:        true,
+		Violations:       violations,
+	}
+	if len(violations) > 0 {
+		return fantasy.WithResponseMetadata(fantasy.NewTextErrorResponse(output+"\n\n"+formatViolations(violations)), metadata), nil
+	}
+	if output == "" {
+		return fantasy.WithResponseMetadata(fantasy.NewTextResponse(BashNoOutput), metadata), nil
+	}
+	output += fmt.Sprintf("\n\n<cwd>%s</cwd>", normalizeWorkingDir(execWorkingDir))
+	return fantasy.WithResponseMetadata(fantasy.NewTextResponse(output), metadata), nil
+}
+
+// formatViolations describes blocked operations so the model can tell a
+// sandbox restriction apart from an ordinary failure.
+func formatViolations(violations []sandbox.Violation) string {
+	data, err := json.MarshalIndent(violations, "", "  ")
+	if err != nil {
+		data = []byte(fmt.Sprint(violations))
+	}
+	return fmt.Sprintf("<sandbox_violations>\n%s\n</sandbox_violations>\nThe sandbox blocked these operations. Only the writable directories can be modified and network access may be disabled; ask the user before working around it.", data)
+}
@@ -3,10 +3,14 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"charm.land/fantasy"
 	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/sandbox"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -230,3 +234,53 @@ func TestBashTool_CommandWithOutput(t *testing.T) {
 	assert.Contains(t, resp.Content, "line")
 	assert.Contains(t, resp.Content, "output")
 }
+
+func TestSandboxedBashTool_ReportsViolations(t *testing.T) {
+	workingDir := t.TempDir()
+	sb, err := sandbox.New(sandbox.Options{ProjectDir: workingDir})
+	if err != nil {
+		t.Skipf("sandbox unavailable: %v", err)
+	}
+
+	tool := NewSandboxedBashTool(&mockPermissionService{}, workingDir, &config.Attribution{}, "test-model", sb)
+	assert.Contains(t, tool.Info().Description, "<sandbox>")
+
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session-123")
+	paramsJSON, _ := json.Marshal(BashParams{Command: "touch ok.txt && touch /usr/nexora-sandbox-test"})
+	resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "test-sandbox", Name: BashToolName, Input: string(paramsJSON)})
+	require.NoError(t, err)
+	if strings.Contains(resp.Content, "nexora sandbox:") {
+		t.Skipf("namespaces not permitted here: %s", resp.Content)
+	}
+
+	assert.True(t, resp.IsError)
+	assert.Contains(t, resp.Content, "<sandbox_violations>")
+	assert.Contains(t, resp.Content, "/usr/nexora-sandbox-test")
+	assert.FileExists(t, filepath.Join(workingDir, "ok.txt"))
+
+	var metadata BashResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &metadata))
+	assert.True(t, metadata.Sandboxed)
+	require.Len(t, metadata.Violations, 1)
+	assert.Equal(t, sandbox.ViolationFilesystem, metadata.Violations[0].Kind)
+}
+
+func TestSandboxedBashTool_BlocksBannedCommands(t *testing.T) {
+	workingDir := t.TempDir()
+	sb, err := sandbox.New(sandbox.Options{ProjectDir: workingDir})
+	if err != nil {
+		t.Skipf("sandbox unavailable: %v", err)
+	}
+	keep := filepath.Join(workingDir, "keep")
+	require.NoError(t, os.MkdirAll(keep, 0o755))
+
+	tool := NewSandboxedBashTool(&mockPermissionService{}, workingDir, &config.Attribution{}, "test-model", sb)
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session-123")
+	paramsJSON, _ := json.Marshal(BashParams{Command: "echo cleaning && rm -rf keep"})
+	resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "test-blocked", Name: BashToolName, Input: string(paramsJSON)})
+	require.NoError(t, err)
+
+	assert.True(t, resp.IsError)
+	assert.Contains(t, resp.Content, "not allowed for security reasons: rm -rf keep")
+	assert.DirExists(t, keep)
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nexora/nexora/internal/aiops"
+	"github.com/nexora/nexora/internal/csync"
+	"github.com/nexora/nexora/internal/fsext"
+	"github.com/nexora/nexora/internal/history"
+	"github.com/nexora/nexora/internal/lsp"
+	"github.com/nexora/nexora/internal/permission"
+)
+
+// NewBatchScriptor creates the scriptor that batches grep, view and edit
+// calls in-process. Files its views read count as read for the edit tool,
+// and files its edits change are written the way the edit tool writes them:
+// after the same checks and with permission, in the file history, and
+// notifying LSPs.
+func NewBatchScriptor(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string, minBatchSize int) *aiops.LocalScriptor {
+	return aiops.NewLocalScriptor(aiops.LocalScriptorOptions{
+		WorkingDir:   workingDir,
+		MinBatchSize: minBatchSize,
+		OnRead:       recordFileRead,
+		Write: func(ctx context.Context, callID, path, oldContent, newContent string) error {
+			return writeBatchedEdit(ctx, lspClients, permissions, files, workingDir, callID, path, oldContent, newContent)
+		},
+	})
+}
+
+func writeBatchedEdit(ctx context.Context, lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir, callID, path, oldContent, newContent string) error {
+	if generated, source := isSQLCGeneratedFile(path); generated {
+		return fmt.Errorf("%s is generated by sqlc; edit %s and regenerate it instead", path, source)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	lastRead := getLastReadTime(path)
+	if lastRead.IsZero() {
+		return errors.New("you must read the file before editing it. Use the View tool first")
+	}
+	if info.ModTime().After(lastRead) {
+		return fmt.Errorf("file %s has been modified since it was last read (mod time: %s, last read: %s)",
+			path, info.ModTime().Format(time.RFC3339), lastRead.Format(time.RFC3339))
+	}
+
+	sessionID := GetSessionFromContext(ctx)
+	if sessionID == "" {
+		return errors.New("session ID is required for editing a file")
+	}
+	if !permissions.Request(permission.CreatePermissionRequest{
+		SessionID:   sessionID,
+		Path:        fsext.PathOrPrefix(path, workingDir),
+		ToolCallID:  callID,
+		ToolName:    EditToolName,
+		Action:      "write",
+		Description: fmt.Sprintf("Replace content in file %s", path),
+		Params: EditPermissionsParams{
+			FilePath:   path,
+			OldContent: oldContent,
+			NewContent: newContent,
+		},
+	}) {
+		return permission.ErrorPermissionDenied
+	}
+
+	if err := os.WriteFile(path, []byte(newContent), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	file, err := files.GetByPathAndSession(ctx, path, sessionID)
+	if err != nil {
+		if _, err := files.Create(ctx, sessionID, path, oldContent); err != nil {
+			return fmt.Errorf("error creating file history: %w", err)
+		}
+	} else if file.Content != oldContent {
+		// Changed outside the agent: keep that version too
+		_, _ = files.CreateVersion(ctx, sessionID, path, oldContent)
+	}
+	if _, err := files.CreateVersion(ctx, sessionID, path, newContent); err != nil {
+		return fmt.Errorf("error creating file history version: %w", err)
+	}
+
+	recordFileWrite(path)
+	recordFileRead(path)
+	notifyLSPs(ctx, lspClients, path)
+	return nil
+}
@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"charm.land/fantasy"
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/git"
+)
+
+// The attribution added to commits by the git tools and suggested in the
+// description of the bash tool. Trailers carry an email so that forges such
+// as GitHub recognize the co-author.
+const (
+	gitAttributionName      = "Nexora"
+	gitAttributionIdentity  = gitAttributionName + " <nexora@users.noreply.github.com>"
+	gitGeneratedWithFooter  = "💘 Generated with " + gitAttributionName
+	gitCoAuthoredByTrailer  = "Co-Authored-By: " + gitAttributionIdentity
+	gitAssistedByTrailerFmt = "Assisted-by: %s via " + gitAttributionIdentity
+)
+
+// openGitRepo opens the repository containing dir, returning a tool error
+// response when there is none.
+func openGitRepo(ctx context.Context, dir string) (*git.Repo, *fantasy.ToolResponse) {
+	repo, err := git.Open(ctx, dir)
+	if err != nil {
+		resp := fantasy.NewTextErrorResponse(err.Error())
+		return nil, &resp
+	}
+	return repo, nil
+}
+
+// gitErrorResponse turns an error from the git package into a tool error
+// response so the model can react to it.
+func gitErrorResponse(err error) fantasy.ToolResponse {
+	switch {
+	case errors.Is(err, git.ErrNothingToCommit):
+		return fantasy.NewTextErrorResponse("nothing to commit: stage files with the paths parameter or set all=true")
+	default:
+		return fantasy.NewTextErrorResponse(err.Error())
+	}
+}
+
+// gitJSONResponse renders v as indented JSON for the model and attaches it as
+// response metadata for the UI.
+func gitJSONResponse(v any) (fantasy.ToolResponse, error) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fantasy.ToolResponse{}, fmt.Errorf("failed to encode git result: %w", err)
+	}
+	return fantasy.WithResponseMetadata(fantasy.NewTextResponse(string(out)), v), nil
+}
+
+// gitRuleAllows reports whether a permission rule explicitly allows an
+// action that rewrites history. YOLO mode and session grants do not imply
+// it; only a "<tool>:<action>" entry in permissions.allowed_tools does.
+func gitRuleAllows(rules []string, toolName, action string) bool {
+	return slices.Contains(rules, toolName+":"+action)
+}
+
+func gitRuleDenied(toolName, action, what string) fantasy.ToolResponse {
+	return fantasy.NewTextErrorResponse(fmt.Sprintf(
+		"%s rewrites history and is disabled. Add %q to permissions.allowed_tools to allow it.",
+		what, toolName+":"+action,
+	))
+}
+
+// gitAttribution returns the footer and trailers to add to commit messages
+// for the configured attribution style.
+func gitAttribution(attribution *config.Attribution, modelName string) (string, []string) {
+	if attribution == nil {
+		return "", nil
+	}
+
+	var footer string
+	if attribution.GeneratedWith {
+		footer = gitGeneratedWithFooter
+	}
+
+	var trailers []string
+	switch attribution.TrailerStyle {
+	case config.TrailerStyleCoAuthoredBy:
+		trailers = append(trailers, gitCoAuthoredByTrailer)
+	case config.TrailerStyleAssistedBy:
+		if modelName != "" {
+			trailers = append(trailers, fmt.Sprintf(gitAssistedByTrailerFmt, modelName))
+		} else {
+			trailers = append(trailers, gitCoAuthoredByTrailer)
+		}
+	}
+	return footer, trailers
+}
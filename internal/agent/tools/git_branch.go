@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/nexora/nexora/internal/permission"
+)
+
+const GitBranchToolName = "git_branch"
+
+//go:embed git_branch.md
+var gitBranchDescription []byte
+
+type GitBranchParams struct {
+	Action string `json:"action,omitempty" description:"One of: list (default), create, switch, delete"`
+	Name   string `json:"name,omitempty" description:"Branch name for create, switch and delete"`
+	Start  string `json:"start,omitempty" description:"Commit or branch to create the new branch from (defaults to HEAD)"`
+	Switch bool   `json:"switch,omitempty" description:"For create: also switch to the new branch"`
+	Force  bool   `json:"force,omitempty" description:"For delete: delete even if not fully merged. Discards commits and is disabled unless allowed by a permission rule."`
+}
+
+type GitBranchPermissionsParams struct {
+	Action string `json:"action"`
+	Name   string `json:"name"`
+	Start  string `json:"start,omitempty"`
+	Switch bool   `json:"switch,omitempty"`
+	Force  bool   `json:"force,omitempty"`
+}
+
+func NewGitBranchTool(permissions permission.Service, workingDir string, rules []string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		GitBranchToolName,
+		string(gitBranchDescription),
+		func(ctx context.Context, params GitBranchParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			repo, errResp := openGitRepo(ctx, workingDir)
+			if errResp != nil {
+				return *errResp, nil
+			}
+
+			action := params.Action
+			switch action {
+			case "", "list":
+				branches, err := repo.Branches(ctx)
+				if err != nil {
+					return gitErrorResponse(err), nil
+				}
+				return gitJSONResponse(branches)
+			case "create", "switch", "delete":
+			default:
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("unknown action %q: use list, create, switch or delete", action)), nil
+			}
+
+			if params.Name == "" {
+				return fantasy.NewTextErrorResponse("name is required"), nil
+			}
+			if action == "delete" && params.Force {
+				if !gitRuleAllows(rules, GitBranchToolName, "force_delete") {
+					return gitRuleDenied(GitBranchToolName, "force_delete", "Force-deleting a branch"), nil
+				}
+				action = "force_delete"
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for changing branches")
+			}
+			p := permissions.Request(
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        repo.Root,
+					ToolCallID:  call.ID,
+					ToolName:    GitBranchToolName,
+					Action:      action,
+					Description: fmt.Sprintf("Git branch %s: %s", params.Action, params.Name),
+					Params:      GitBranchPermissionsParams(params),
+				},
+			)
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			var err error
+			var result string
+			switch params.Action {
+			case "create":
+				err = repo.CreateBranch(ctx, params.Name, params.Start, params.Switch)
+				result = fmt.Sprintf("Created branch %s", params.Name)
+				if params.Switch {
+					result += " and switched to it"
+				}
+			case "switch":
+				err = repo.Switch(ctx, params.Name)
+				result = fmt.Sprintf("Switched to branch %s", params.Name)
+			case "delete":
+				err = repo.DeleteBranch(ctx, params.Name, params.Force)
+				result = fmt.Sprintf("Deleted branch %s", params.Name)
+			}
+			if err != nil {
+				return gitErrorResponse(err), nil
+			}
+			return fantasy.NewTextResponse(result), nil
+		})
+}
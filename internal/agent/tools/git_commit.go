@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/git"
+	"github.com/nexora/nexora/internal/permission"
+)
+
+const GitCommitToolName = "git_commit"
+
+//go:embed git_commit.md
+var gitCommitDescription []byte
+
+type GitCommitParams struct {
+	Message string   `json:"message" description:"The commit message: a short subject line, optionally followed by a blank line and a body. Attribution is added automatically."`
+	Paths   []string `json:"paths,omitempty" description:"Files to stage before committing"`
+	All     bool     `json:"all,omitempty" description:"Stage all modifications to tracked files before committing"`
+	Amend   bool     `json:"amend,omitempty" description:"Replace the last commit instead of creating a new one. Rewrites history and is disabled unless allowed by a permission rule."`
+}
+
+type GitCommitPermissionsParams struct {
+	Message string   `json:"message"`
+	Paths   []string `json:"paths,omitempty"`
+	All     bool     `json:"all,omitempty"`
+	Amend   bool     `json:"amend,omitempty"`
+}
+
+type GitCommitResponseMetadata struct {
+	Commit git.Commit `json:"commit"`
+	Amend  bool       `json:"amend,omitempty"`
+}
+
+func NewGitCommitTool(permissions permission.Service, workingDir string, attribution *config.Attribution, modelName string, rules []string) fantasy.AgentTool {
+	footer, trailers := gitAttribution(attribution, modelName)
+	return fantasy.NewAgentTool(
+		GitCommitToolName,
+		string(gitCommitDescription),
+		func(ctx context.Context, params GitCommitParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.Message == "" {
+				return fantasy.NewTextErrorResponse("message is required"), nil
+			}
+			if params.Amend && !gitRuleAllows(rules, GitCommitToolName, "amend") {
+				return gitRuleDenied(GitCommitToolName, "amend", "Amending a commit"), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for creating a commit")
+			}
+
+			repo, errResp := openGitRepo(ctx, workingDir)
+			if errResp != nil {
+				return *errResp, nil
+			}
+
+			action := "commit"
+			if params.Amend {
+				action = "amend"
+			}
+			p := permissions.Request(
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        repo.Root,
+					ToolCallID:  call.ID,
+					ToolName:    GitCommitToolName,
+					Action:      action,
+					Description: fmt.Sprintf("Create git commit: %s", strings.SplitN(params.Message, "\n", 2)[0]),
+					Params:      GitCommitPermissionsParams(params),
+				},
+			)
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			commit, err := repo.Commit(ctx, git.CommitOptions{
+				Message:  params.Message,
+				Footer:   footer,
+				Trailers: trailers,
+				Paths:    params.Paths,
+				All:      params.All,
+				Amend:    params.Amend,
+			})
+			if err != nil {
+				return gitErrorResponse(err), nil
+			}
+
+			result := fmt.Sprintf("Created commit %s: %s", commit.ShortHash(), commit.Subject)
+			if params.Amend {
+				result = fmt.Sprintf("Amended commit %s: %s", commit.ShortHash(), commit.Subject)
+			}
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(result),
+				GitCommitResponseMetadata{Commit: commit, Amend: params.Amend},
+			), nil
+		})
+}
@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/nexora/nexora/internal/git"
+)
+
+const GitDiffToolName = "git_diff"
+
+//go:embed git_diff.md
+var gitDiffDescription []byte
+
+type GitDiffParams struct {
+	Staged  bool     `json:"staged,omitempty" description:"Show staged changes (index against HEAD) instead of unstaged changes"`
+	Base    string   `json:"base,omitempty" description:"Commit, branch or range to diff against, e.g. 'main' or 'main...HEAD'"`
+	Paths   []string `json:"paths,omitempty" description:"Limit the diff to these paths"`
+	Context int      `json:"context,omitempty" description:"Number of context lines around each change (default 3)"`
+}
+
+type GitDiffResponseMetadata struct {
+	Files     []git.FileDiff `json:"files"`
+	Additions int            `json:"additions"`
+	Deletions int            `json:"deletions"`
+	Truncated bool           `json:"truncated,omitempty"`
+}
+
+func NewGitDiffTool(workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		GitDiffToolName,
+		string(gitDiffDescription),
+		func(ctx context.Context, params GitDiffParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			repo, errResp := openGitRepo(ctx, workingDir)
+			if errResp != nil {
+				return *errResp, nil
+			}
+
+			diff, err := repo.Diff(ctx, git.DiffOptions{
+				Staged:  params.Staged,
+				Base:    params.Base,
+				Paths:   params.Paths,
+				Context: params.Context,
+			})
+			if err != nil {
+				return gitErrorResponse(err), nil
+			}
+
+			metadata := GitDiffResponseMetadata{
+				Files:     diff.Files,
+				Additions: diff.Additions(),
+				Deletions: diff.Deletions(),
+			}
+			if len(diff.Files) == 0 {
+				return fantasy.WithResponseMetadata(fantasy.NewTextResponse("No changes"), metadata), nil
+			}
+
+			var b strings.Builder
+			fmt.Fprintf(&b, "%d file(s) changed, +%d -%d\n", len(diff.Files), metadata.Additions, metadata.Deletions)
+			for _, f := range diff.Files {
+				if f.Binary {
+					fmt.Fprintf(&b, "  %s (binary)\n", f.Path)
+				} else {
+					fmt.Fprintf(&b, "  %s +%d -%d\n", f.Path, f.Additions, f.Deletions)
+				}
+			}
+			b.WriteString("\n")
+
+			patch := diff.Patch
+			if len(patch) > MaxOutputLength {
+				patch = patch[:MaxOutputLength]
+				metadata.Truncated = true
+			}
+			b.WriteString(patch)
+			if metadata.Truncated {
+				b.WriteString("\n\n(Diff truncated. Use the paths parameter to diff fewer files.)")
+			}
+
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(b.String()), metadata), nil
+		})
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+
+	"charm.land/fantasy"
+	"github.com/nexora/nexora/internal/git"
+)
+
+const (
+	GitLogToolName = "git_log"
+
+	defaultGitLogLimit = 20
+	maxGitLogLimit     = 200
+)
+
+//go:embed git_log.md
+var gitLogDescription []byte
+
+type GitLogParams struct {
+	Ref   string   `json:"ref,omitempty" description:"Revision or range to list, e.g. 'main' or 'main..HEAD' (defaults to HEAD)"`
+	Limit int      `json:"limit,omitempty" description:"Maximum number of commits to return (default 20, max 200)"`
+	Paths []string `json:"paths,omitempty" description:"Only list commits that touch these paths"`
+}
+
+func NewGitLogTool(workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		GitLogToolName,
+		string(gitLogDescription),
+		func(ctx context.Context, params GitLogParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			repo, errResp := openGitRepo(ctx, workingDir)
+			if errResp != nil {
+				return *errResp, nil
+			}
+
+			commits, err := repo.Log(ctx, git.LogOptions{
+				Ref:   params.Ref,
+				Limit: min(cmp.Or(params.Limit, defaultGitLogLimit), maxGitLogLimit),
+				Paths: params.Paths,
+			})
+			if err != nil {
+				return gitErrorResponse(err), nil
+			}
+			if len(commits) == 0 {
+				return fantasy.NewTextResponse("No commits found"), nil
+			}
+			return gitJSONResponse(commits)
+		})
+}
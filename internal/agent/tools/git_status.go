@@ -0,0 +1,33 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+
+	"charm.land/fantasy"
+)
+
+const GitStatusToolName = "git_status"
+
+//go:embed git_status.md
+var gitStatusDescription []byte
+
+type GitStatusParams struct{}
+
+func NewGitStatusTool(workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		GitStatusToolName,
+		string(gitStatusDescription),
+		func(ctx context.Context, params GitStatusParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			repo, errResp := openGitRepo(ctx, workingDir)
+			if errResp != nil {
+				return *errResp, nil
+			}
+
+			status, err := repo.Status(ctx)
+			if err != nil {
+				return gitErrorResponse(err), nil
+			}
+			return gitJSONResponse(status)
+		})
+}
@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/nexora/nexora/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func newGitToolTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--initial-branch=main"},
+		{"config", "user.name", "Test"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		require.NoError(t, cmd.Run())
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644))
+	return dir
+}
+
+func runGitTool(t *testing.T, tool fantasy.AgentTool, params any) fantasy.ToolResponse {
+	t.Helper()
+	input, err := json.Marshal(params)
+	require.NoError(t, err)
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+	resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: tool.Info().Name, Input: string(input)})
+	require.NoError(t, err)
+	return resp
+}
+
+func TestGitAttribution(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		attribution  *config.Attribution
+		wantFooter   string
+		wantTrailers []string
+	}{
+		{name: "nil", attribution: nil},
+		{
+			name:         "assisted-by with generated with",
+			attribution:  &config.Attribution{TrailerStyle: config.TrailerStyleAssistedBy, GeneratedWith: true},
+			wantFooter:   gitGeneratedWithFooter,
+			wantTrailers: []string{"Assisted-by: test-model via Nexora <nexora@users.noreply.github.com>"},
+		},
+		{
+			name:         "co-authored-by",
+			attribution:  &config.Attribution{TrailerStyle: config.TrailerStyleCoAuthoredBy},
+			wantTrailers: []string{gitCoAuthoredByTrailer},
+		},
+		{
+			name:        "none",
+			attribution: &config.Attribution{TrailerStyle: config.TrailerStyleNone},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			footer, trailers := gitAttribution(tt.attribution, "test-model")
+			require.Equal(t, tt.wantFooter, footer)
+			require.Equal(t, tt.wantTrailers, trailers)
+		})
+	}
+}
+
+func TestGitTools_CommitFlow(t *testing.T) {
+	dir := newGitToolTestRepo(t)
+	perms := &mockPermissionService{}
+	attribution := &config.Attribution{TrailerStyle: config.TrailerStyleCoAuthoredBy}
+
+	resp := runGitTool(t, NewGitStatusTool(dir), GitStatusParams{})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, `"path": "main.go"`)
+
+	commitTool := NewGitCommitTool(perms, dir, attribution, "test-model", nil)
+	resp = runGitTool(t, commitTool, GitCommitParams{Message: "Add main", Paths: []string{"main.go"}})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "Add main")
+
+	resp = runGitTool(t, NewGitLogTool(dir), GitLogParams{})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, gitCoAuthoredByTrailer)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644))
+	resp = runGitTool(t, NewGitDiffTool(dir), GitDiffParams{})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "+func main() {}")
+
+	resp = runGitTool(t, commitTool, GitCommitParams{Message: "Amend", All: true, Amend: true})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "git_commit:amend")
+
+	allowed := NewGitCommitTool(perms, dir, attribution, "test-model", []string{"git_commit:amend"})
+	resp = runGitTool(t, allowed, GitCommitParams{Message: "Add main func", All: true, Amend: true})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "Amended")
+}
+
+func TestGitBranchTool(t *testing.T) {
+	dir := newGitToolTestRepo(t)
+	perms := &mockPermissionService{}
+
+	resp := runGitTool(t, NewGitCommitTool(perms, dir, nil, "", nil), GitCommitParams{Message: "init", All: true, Paths: []string{"main.go"}})
+	require.False(t, resp.IsError, resp.Content)
+
+	tool := NewGitBranchTool(perms, dir, nil)
+	resp = runGitTool(t, tool, GitBranchParams{Action: "create", Name: "feature", Switch: true})
+	require.False(t, resp.IsError, resp.Content)
+
+	resp = runGitTool(t, tool, GitBranchParams{})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, `"name": "feature"`)
+
+	resp = runGitTool(t, tool, GitBranchParams{Action: "switch", Name: "main"})
+	require.False(t, resp.IsError, resp.Content)
+
+	resp = runGitTool(t, tool, GitBranchParams{Action: "delete", Name: "feature", Force: true})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "git_branch:force_delete")
+
+	resp = runGitTool(t, tool, GitBranchParams{Action: "rebase", Name: "main"})
+	require.True(t, resp.IsError)
+}
@@ -5,15 +5,19 @@ import (
 	"bytes"
 	"context"
 	_ "embed"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -76,27 +80,87 @@ type GrepParams struct {
 	Pattern     string `json:"pattern" description:"The regex pattern to search for in file contents"`
 	Path        string `json:"path,omitempty" description:"The directory to search in. Defaults to the current working directory."`
 	Include     string `json:"include,omitempty" description:"File pattern to include in the search (e.g. \"*.js\", \"*.{ts,tsx}\")"`
+	Type        string `json:"type,omitempty" description:"File type to search (e.g. \"go\", \"js\", \"py\"). Combined with include when both are set."`
 	LiteralText bool   `json:"literal_text,omitempty" description:"If true, the pattern will be treated as literal text with special regex characters escaped. Default is false."`
+	IgnoreCase  bool   `json:"ignore_case,omitempty" description:"If true, the search is case-insensitive. Default is false."`
+	Multiline   bool   `json:"multiline,omitempty" description:"If true, the pattern can match across lines and . matches newlines. Default is false."`
+	OutputMode  string `json:"output_mode,omitempty" description:"\"content\" shows matching lines (default), \"files_with_matches\" lists matching files, \"count\" shows the number of matching lines per file"`
+	Before      int    `json:"before,omitempty" description:"Number of lines to show before each match in content mode"`
+	After       int    `json:"after,omitempty" description:"Number of lines to show after each match in content mode"`
+	Context     int    `json:"context,omitempty" description:"Number of lines to show before and after each match in content mode, unless before or after are set"`
+	Offset      int    `json:"offset,omitempty" description:"Number of results to skip, for paging through results (0-based)"`
+	Limit       int    `json:"limit,omitempty" description:"Maximum number of results to return (defaults to 100)"`
 }
 
+// grepMatch is a line reported by a search: a line matching the pattern or,
+// when context lines were requested, a line around one.
 type grepMatch struct {
 	path     string
 	modTime  time.Time
 	lineNum  int
-	charNum  int
+	charNum  int // 1-based column of the first match starting on the line, 0 if none does
 	lineText string
+	// isContext is set for lines shown only as context around a match.
+	isContext bool
+}
+
+// grepOptions holds the search settings shared by the ripgrep and Go
+// implementations.
+type grepOptions struct {
+	pattern    string
+	include    string
+	fileType   string
+	ignoreCase bool
+	multiline  bool
+	before     int
+	after      int
 }
 
 type GrepResponseMetadata struct {
 	NumberOfMatches int  `json:"number_of_matches"`
+	NumberOfFiles   int  `json:"number_of_files"`
 	Truncated       bool `json:"truncated"`
 }
 
 const (
 	GrepToolName        = "grep"
 	maxGrepContentWidth = 500
+	defaultGrepLimit    = 100
+
+	grepOutputContent = "content"
+	grepOutputFiles   = "files_with_matches"
+	grepOutputCount   = "count"
 )
 
+// grepFileTypes maps the file types accepted by the type parameter to their
+// globs. Names follow ripgrep's types.
+var grepFileTypes = map[string][]string{
+	"c":        {"*.c", "*.h"},
+	"cpp":      {"*.cpp", "*.cc", "*.cxx", "*.hpp", "*.hh", "*.hxx", "*.h"},
+	"cs":       {"*.cs"},
+	"css":      {"*.css", "*.scss", "*.sass", "*.less"},
+	"go":       {"*.go"},
+	"html":     {"*.html", "*.htm"},
+	"java":     {"*.java"},
+	"js":       {"*.js", "*.jsx", "*.mjs", "*.cjs"},
+	"json":     {"*.json"},
+	"kotlin":   {"*.kt", "*.kts"},
+	"lua":      {"*.lua"},
+	"md":       {"*.md", "*.markdown"},
+	"php":      {"*.php"},
+	"protobuf": {"*.proto"},
+	"py":       {"*.py", "*.pyi"},
+	"ruby":     {"*.rb"},
+	"rust":     {"*.rs"},
+	"sh":       {"*.sh", "*.bash", "*.zsh"},
+	"sql":      {"*.sql"},
+	"swift":    {"*.swift"},
+	"toml":     {"*.toml"},
+	"ts":       {"*.ts", "*.tsx", "*.mts", "*.cts"},
+	"txt":      {"*.txt"},
+	"yaml":     {"*.yaml", "*.yml"},
+}
+
 //go:embed grep.md
 var grepDescription []byte
 
@@ -120,6 +184,22 @@ func NewGrepTool(workingDir string) fantasy.AgentTool {
 			if params.Pattern == "" {
 				return fantasy.NewTextErrorResponse("pattern is required"), nil
 			}
+			outputMode := params.OutputMode
+			if outputMode == "" {
+				outputMode = grepOutputContent
+			}
+			if !slices.Contains([]string{grepOutputContent, grepOutputFiles, grepOutputCount}, outputMode) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid output_mode %q: use content, files_with_matches or count", params.OutputMode)), nil
+			}
+			if params.Type != "" {
+				if _, ok := grepFileTypes[params.Type]; !ok {
+					types := slices.Sorted(maps.Keys(grepFileTypes))
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("unknown type %q, supported types: %s", params.Type, strings.Join(types, ", "))), nil
+				}
+			}
+			if params.Before < 0 || params.After < 0 || params.Context < 0 || params.Offset < 0 || params.Limit < 0 {
+				return fantasy.NewTextErrorResponse("before, after, context, offset and limit must not be negative"), nil
+			}
 
 			// If literal_text is true, escape the pattern
 			searchPattern := params.Pattern
@@ -132,11 +212,28 @@ func NewGrepTool(workingDir string) fantasy.AgentTool {
 				searchPath = workingDir
 			}
 
+			opts := grepOptions{
+				pattern:    searchPattern,
+				include:    params.Include,
+				fileType:   params.Type,
+				ignoreCase: params.IgnoreCase,
+				multiline:  params.Multiline,
+			}
+			if outputMode == grepOutputContent {
+				opts.before, opts.after = params.Context, params.Context
+				if params.Before > 0 {
+					opts.before = params.Before
+				}
+				if params.After > 0 {
+					opts.after = params.After
+				}
+			}
+
 			// Create a child context with timeout for the entire search operation
 			searchCtx, cancel := context.WithTimeout(ctx, 90*time.Second)
 			defer cancel()
 
-			matches, truncated, err := searchFiles(searchCtx, searchPattern, searchPath, params.Include, 100)
+			matches, err := grep(searchCtx, searchPath, opts)
 			if err != nil {
 				if searchCtx.Err() == context.DeadlineExceeded {
 					return fantasy.NewTextErrorResponse("search timed out after 90 seconds - the search area may be too large or contain problematic files"), nil
@@ -147,64 +244,146 @@ func NewGrepTool(workingDir string) fantasy.AgentTool {
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("error searching files: %v", err)), nil
 			}
 
-			var output strings.Builder
-			if len(matches) == 0 {
-				output.WriteString("No files found")
-			} else {
-				fmt.Fprintf(&output, "Found %d matches\n", len(matches))
-
-				currentFile := ""
-				for _, match := range matches {
-					if currentFile != match.path {
-						if currentFile != "" {
-							output.WriteString("\n")
-						}
-						currentFile = match.path
-						fmt.Fprintf(&output, "%s:\n", filepath.ToSlash(match.path))
-					}
-					if match.lineNum > 0 {
-						lineText := match.lineText
-						if len(lineText) > maxGrepContentWidth {
-							lineText = lineText[:maxGrepContentWidth] + "..."
-						}
-						if match.charNum > 0 {
-							fmt.Fprintf(&output, "  Line %d, Char %d: %s\n", match.lineNum, match.charNum, lineText)
-						} else {
-							fmt.Fprintf(&output, "  Line %d: %s\n", match.lineNum, lineText)
-						}
-					} else {
-						fmt.Fprintf(&output, "  %s\n", match.path)
-					}
-				}
+			limit := params.Limit
+			if limit == 0 {
+				limit = defaultGrepLimit
+			}
+			output, metadata := formatGrepResults(matches, outputMode, params.Offset, limit, opts)
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(output), metadata), nil
+		})
+}
 
-				if truncated {
-					output.WriteString("\n(Results are truncated. Consider using a more specific path or pattern.)")
-				}
+// formatGrepResults renders a page of results for the output mode. Pages
+// hold matching lines in content mode and files otherwise.
+func formatGrepResults(matches []grepMatch, outputMode string, offset, limit int, opts grepOptions) (string, GrepResponseMetadata) {
+	var files []string
+	counts := map[string]int{}
+	numMatches := 0
+	for _, m := range matches {
+		if m.isContext {
+			continue
+		}
+		if counts[m.path] == 0 {
+			files = append(files, m.path)
+		}
+		counts[m.path]++
+		numMatches++
+	}
+	metadata := GrepResponseMetadata{NumberOfMatches: numMatches, NumberOfFiles: len(files)}
+	if numMatches == 0 {
+		return "No files found", metadata
+	}
+
+	total := numMatches
+	if outputMode != grepOutputContent {
+		total = len(files)
+	}
+	start, end := min(offset, total), min(offset+limit, total)
+	metadata.Truncated = start > 0 || end < total
+
+	var output strings.Builder
+	switch outputMode {
+	case grepOutputFiles:
+		fmt.Fprintf(&output, "Found %d files\n", len(files))
+		for _, path := range files[start:end] {
+			fmt.Fprintf(&output, "%s\n", filepath.ToSlash(path))
+		}
+	case grepOutputCount:
+		fmt.Fprintf(&output, "Found %d matches in %d files\n", numMatches, len(files))
+		for _, path := range files[start:end] {
+			fmt.Fprintf(&output, "%s: %d\n", filepath.ToSlash(path), counts[path])
+		}
+	default:
+		fmt.Fprintf(&output, "Found %d matches\n", numMatches)
+		writeGrepContent(&output, grepPage(matches, start, end, opts), opts.before > 0 || opts.after > 0)
+	}
+
+	switch {
+	case start == end:
+		fmt.Fprintf(&output, "\n(No results at offset %d, there are %d results.)", offset, total)
+	case end < total:
+		fmt.Fprintf(&output, "\n(Showing results %d-%d of %d. Use offset=%d for more, or a more specific path or pattern.)", start+1, end, total, end)
+	case start > 0:
+		fmt.Fprintf(&output, "\n(Showing results %d-%d of %d.)", start+1, end, total)
+	}
+	return output.String(), metadata
+}
+
+// grepPage returns the matching lines numbered start to end, in the order
+// of matches, with the context lines around them.
+func grepPage(matches []grepMatch, start, end int, opts grepOptions) []grepMatch {
+	selected := map[string][]int{}
+	n := 0
+	for _, m := range matches {
+		if m.isContext {
+			continue
+		}
+		if n >= start && n < end {
+			selected[m.path] = append(selected[m.path], m.lineNum)
+		}
+		n++
+	}
+
+	var page []grepMatch
+	for _, m := range matches {
+		lines, ok := selected[m.path]
+		if !ok {
+			continue
+		}
+		if !m.isContext {
+			if slices.Contains(lines, m.lineNum) {
+				page = append(page, m)
 			}
+			continue
+		}
+		if slices.ContainsFunc(lines, func(l int) bool {
+			return m.lineNum >= l-opts.before && m.lineNum <= l+opts.after
+		}) {
+			page = append(page, m)
+		}
+	}
+	return page
+}
 
-			return fantasy.WithResponseMetadata(
-				fantasy.NewTextResponse(output.String()),
-				GrepResponseMetadata{
-					NumberOfMatches: len(matches),
-					Truncated:       truncated,
-				},
-			), nil
-		})
+// writeGrepContent writes lines grouped by file. With context lines, "--"
+// separates groups of lines that are not adjacent.
+func writeGrepContent(output *strings.Builder, matches []grepMatch, withContext bool) {
+	currentFile := ""
+	lastLine := 0
+	for _, match := range matches {
+		if currentFile != match.path {
+			if currentFile != "" {
+				output.WriteString("\n")
+			}
+			currentFile = match.path
+			fmt.Fprintf(output, "%s:\n", filepath.ToSlash(match.path))
+		} else if withContext && match.lineNum > lastLine+1 {
+			output.WriteString("  --\n")
+		}
+		lastLine = match.lineNum
+
+		lineText := match.lineText
+		if len(lineText) > maxGrepContentWidth {
+			lineText = lineText[:maxGrepContentWidth] + "..."
+		}
+		switch {
+		case match.isContext:
+			fmt.Fprintf(output, "  Line %d- %s\n", match.lineNum, lineText)
+		case match.charNum > 0:
+			fmt.Fprintf(output, "  Line %d, Char %d: %s\n", match.lineNum, match.charNum, lineText)
+		default:
+			fmt.Fprintf(output, "  Line %d: %s\n", match.lineNum, lineText)
+		}
+	}
 }
 
+// searchFiles returns the lines matching pattern, newest files first.
 func searchFiles(ctx context.Context, pattern, rootPath, include string, limit int) ([]grepMatch, bool, error) {
-	matches, err := searchWithRipgrep(ctx, pattern, rootPath, include)
+	matches, err := grep(ctx, rootPath, grepOptions{pattern: pattern, include: include})
 	if err != nil {
-		matches, err = searchFilesWithRegex(pattern, rootPath, include)
-		if err != nil {
-			return nil, false, err
-		}
+		return nil, false, err
 	}
 
-	sort.Slice(matches, func(i, j int) bool {
-		return matches[i].modTime.After(matches[j].modTime)
-	})
-
 	truncated := len(matches) > limit
 	if truncated {
 		matches = matches[:limit]
@@ -213,23 +392,71 @@ func searchFiles(ctx context.Context, pattern, rootPath, include string, limit i
 	return matches, truncated, nil
 }
 
+// grep searches rootPath with ripgrep, falling back to the Go
+// implementation, and sorts the results by file modification time (newest
+// first), path and line.
+func grep(ctx context.Context, rootPath string, opts grepOptions) ([]grepMatch, error) {
+	matches, err := searchWithRipgrepOptions(ctx, rootPath, opts)
+	if err != nil {
+		matches, err = searchFilesWithRegexOptions(ctx, rootPath, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if !a.modTime.Equal(b.modTime) {
+			return a.modTime.After(b.modTime)
+		}
+		if a.path != b.path {
+			return a.path < b.path
+		}
+		return a.lineNum < b.lineNum
+	})
+	return matches, nil
+}
+
 func searchWithRipgrep(ctx context.Context, pattern, path, include string) ([]grepMatch, error) {
+	return searchWithRipgrepOptions(ctx, path, grepOptions{pattern: pattern, include: include})
+}
+
+func searchWithRipgrepOptions(ctx context.Context, root string, opts grepOptions) ([]grepMatch, error) {
 	// Create timeout context specifically for ripgrep command
 	ripgrepCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	cmd := getRgSearchCmd(ripgrepCtx, pattern, path, include)
+	// Type globs are only passed to ripgrep when they can't widen the
+	// include glob; the type is checked on the results either way.
+	include := opts.include
+	cmd := getRgSearchCmd(ripgrepCtx, opts.pattern, root, include)
 	if cmd == nil {
 		return nil, fmt.Errorf("ripgrep not found in $PATH")
 	}
+	if include == "" {
+		for _, glob := range grepFileTypes[opts.fileType] {
+			cmd.Args = append(cmd.Args, "--glob", glob)
+		}
+	}
+	if opts.ignoreCase {
+		cmd.Args = append(cmd.Args, "--ignore-case")
+	}
+	if opts.multiline {
+		cmd.Args = append(cmd.Args, "--multiline", "--multiline-dotall")
+	}
+	if opts.before > 0 {
+		cmd.Args = append(cmd.Args, "--before-context", strconv.Itoa(opts.before))
+	}
+	if opts.after > 0 {
+		cmd.Args = append(cmd.Args, "--after-context", strconv.Itoa(opts.after))
+	}
 
 	// Set safer parameters
 	cmd.Args = append(cmd.Args, "--max-filesize", "50M")
-	cmd.Args = append(cmd.Args, "--max-columns", "500")
 
 	// Only add ignore files if they exist
 	for _, ignoreFile := range []string{".gitignore", ".nexoraignore"} {
-		ignorePath := filepath.Join(path, ignoreFile)
+		ignorePath := filepath.Join(root, ignoreFile)
 		if _, err := os.Stat(ignorePath); err == nil {
 			cmd.Args = append(cmd.Args, "--ignore-file", ignorePath)
 		}
@@ -266,33 +493,70 @@ func searchWithRipgrep(ctx context.Context, pattern, path, include string) ([]gr
 		return nil, err
 	}
 
-	var matches []grepMatch
+	files := map[string]*grepFile{}
+	var order []string
 	for line := range bytes.SplitSeq(bytes.TrimSpace(output), []byte{'\n'}) {
 		if len(line) == 0 {
 			continue
 		}
-		var match ripgrepMatch
-		if err := json.Unmarshal(line, &match); err != nil {
+		var event ripgrepMatch
+		if err := json.Unmarshal(line, &event); err != nil {
+			continue
+		}
+		if event.Type != "match" && event.Type != "context" {
 			continue
 		}
-		if match.Type != "match" {
+		path := filepath.Clean(event.Data.Path.Text)
+		relPath, err := filepath.Rel(filepath.Clean(root), path)
+		if err != nil {
+			relPath = path
+		}
+		if !matchesGrepFilters(path, relPath, opts) {
 			continue
 		}
-		for _, m := range match.Data.Submatches {
-			fi, err := os.Stat(match.Data.Path.Text)
+		f, ok := files[path]
+		if !ok {
+			fi, err := os.Stat(event.Data.Path.Text)
 			if err != nil {
 				continue // Skip files we can't access
 			}
-			matches = append(matches, grepMatch{
-				path:     match.Data.Path.Text,
-				modTime:  fi.ModTime(),
-				lineNum:  match.Data.LineNumber,
-				charNum:  m.Start + 1, // ensure 1-based
-				lineText: strings.TrimSpace(match.Data.Lines.Text),
-			})
-			// only get the first match of each line
-			break
+			f = newGrepFile(path, fi.ModTime())
+			files[path] = f
+			order = append(order, path)
+		}
+
+		text := event.Data.Lines.Text
+		if text == "" && event.Data.Lines.Bytes != "" {
+			decoded, err := base64.StdEncoding.DecodeString(event.Data.Lines.Bytes)
+			if err != nil {
+				continue
+			}
+			text = string(decoded)
+		}
+		lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+		for i, l := range lines {
+			f.text[event.Data.LineNumber+i] = strings.TrimSuffix(l, "\r")
+		}
+		if event.Type == "context" {
+			for i := range lines {
+				f.context[event.Data.LineNumber+i] = true
+			}
+			continue
+		}
+		if len(event.Data.Submatches) == 0 {
+			f.addMatch(event.Data.LineNumber, 0, event.Data.LineNumber)
 		}
+		for _, m := range event.Data.Submatches {
+			startLine := event.Data.LineNumber + strings.Count(text[:m.Start], "\n")
+			endLine := event.Data.LineNumber + strings.Count(text[:max(m.Start, m.End-1)], "\n")
+			col := m.Start - (strings.LastIndex(text[:m.Start], "\n") + 1)
+			f.addMatch(startLine, col, endLine)
+		}
+	}
+
+	var matches []grepMatch
+	for _, path := range order {
+		matches = append(matches, files[path].results()...)
 	}
 	return matches, nil
 }
@@ -304,15 +568,151 @@ type ripgrepMatch struct {
 			Text string `json:"text"`
 		} `json:"path"`
 		Lines struct {
-			Text string `json:"text"`
+			Text  string `json:"text"`
+			Bytes string `json:"bytes"`
 		} `json:"lines"`
 		LineNumber int `json:"line_number"`
 		Submatches []struct {
 			Start int `json:"start"`
+			End   int `json:"end"`
 		} `json:"submatches"`
 	} `json:"data"`
 }
 
+// grepFile collects the matching and context lines of a file.
+type grepFile struct {
+	path    string
+	modTime time.Time
+	text    map[int]string
+	// columns maps matching lines to the 1-based column of the first match
+	// starting on them, 0 for lines only covered by a multi-line match.
+	columns map[int]int
+	context map[int]bool
+}
+
+func newGrepFile(path string, modTime time.Time) *grepFile {
+	return &grepFile{
+		path:    path,
+		modTime: modTime,
+		text:    map[int]string{},
+		columns: map[int]int{},
+		context: map[int]bool{},
+	}
+}
+
+// addMatch records a match starting at the 0-based byte column col of
+// startLine and ending on endLine.
+func (f *grepFile) addMatch(startLine, col, endLine int) {
+	if c, ok := f.columns[startLine]; !ok || c == 0 || col+1 < c {
+		f.columns[startLine] = col + 1
+	}
+	for l := startLine + 1; l <= endLine; l++ {
+		if _, ok := f.columns[l]; !ok {
+			f.columns[l] = 0
+		}
+	}
+}
+
+// results returns the file's lines ordered by line number.
+func (f *grepFile) results() []grepMatch {
+	var lines []int
+	for l := range f.columns {
+		lines = append(lines, l)
+	}
+	for l := range f.context {
+		if _, ok := f.columns[l]; !ok {
+			lines = append(lines, l)
+		}
+	}
+	slices.Sort(lines)
+
+	matches := make([]grepMatch, 0, len(lines))
+	for _, l := range lines {
+		col, isMatch := f.columns[l]
+		matches = append(matches, grepMatch{
+			path:      f.path,
+			modTime:   f.modTime,
+			lineNum:   l,
+			charNum:   col,
+			lineText:  f.text[l],
+			isContext: !isMatch,
+		})
+	}
+	return matches
+}
+
+// matchesGrepFilters reports whether a file passes the include glob and type
+// filter. The include glob matches the base name unless it has a slash, in
+// which case it matches relPath.
+func matchesGrepFilters(path, relPath string, opts grepOptions) bool {
+	matchGlob := func(glob, name string) bool {
+		regex, err := globRegexCache.get(grepGlobRegex(glob))
+		return err == nil && regex.MatchString(filepath.ToSlash(name))
+	}
+	if opts.include != "" {
+		name := filepath.Base(path)
+		if strings.Contains(opts.include, "/") {
+			name = relPath
+		}
+		if !matchGlob(opts.include, name) {
+			return false
+		}
+	}
+	if opts.fileType != "" {
+		return slices.ContainsFunc(grepFileTypes[opts.fileType], func(glob string) bool {
+			return matchGlob(glob, filepath.Base(path))
+		})
+	}
+	return true
+}
+
+// grepGlobRegex converts a ripgrep glob to an anchored regex: * and ?
+// don't match slashes, **/ matches any number of directories and {a,b}
+// matches alternatives.
+func grepGlobRegex(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	inBraces := false
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '{':
+			b.WriteString("(?:")
+			inBraces = true
+		case c == '}' && inBraces:
+			b.WriteString(")")
+			inBraces = false
+		case c == ',' && inBraces:
+			b.WriteString("|")
+		case c == '[':
+			if end := strings.IndexByte(glob[i+1:], ']'); end >= 0 {
+				class := glob[i+1 : i+1+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				b.WriteString("[" + class + "]")
+				i += end + 1
+				continue
+			}
+			b.WriteString(`\[`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
 func searchFilesWithRegex(pattern, rootPath, include string) ([]grepMatch, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
@@ -322,23 +722,35 @@ func searchFilesWithRegex(pattern, rootPath, include string) ([]grepMatch, error
 
 // Separate function that accepts context
 func searchFilesWithRegexContext(ctx context.Context, pattern, rootPath, include string) ([]grepMatch, error) {
+	return searchFilesWithRegexOptions(ctx, rootPath, grepOptions{pattern: pattern, include: include})
+}
+
+// grepRegex compiles the pattern with the flags matching ripgrep's
+// behavior for opts.
+func grepRegex(opts grepOptions) (*regexp.Regexp, error) {
+	flags := ""
+	if opts.ignoreCase {
+		flags += "i"
+	}
+	if opts.multiline {
+		flags += "ms"
+	}
+	pattern := opts.pattern
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+	return searchRegexCache.get(pattern)
+}
+
+func searchFilesWithRegexOptions(ctx context.Context, rootPath string, opts grepOptions) ([]grepMatch, error) {
 	matches := []grepMatch{}
 
 	// Use cached regex compilation
-	regex, err := searchRegexCache.get(pattern)
+	regex, err := grepRegex(opts)
 	if err != nil {
 		return nil, fmt.Errorf("invalid regex pattern: %w", err)
 	}
 
-	var includePattern *regexp.Regexp
-	if include != "" {
-		regexPattern := globToRegex(include)
-		includePattern, err = globRegexCache.get(regexPattern)
-		if err != nil {
-			return nil, fmt.Errorf("invalid include pattern: %w", err)
-		}
-	}
-
 	// Create walker with gitignore and nexoraignore support
 	walker := fsext.NewFastGlobWalker(rootPath)
 
@@ -378,31 +790,20 @@ func searchFilesWithRegexContext(ctx context.Context, pattern, rootPath, include
 			return nil
 		}
 
-		if includePattern != nil && !includePattern.MatchString(path) {
+		relPath, err := filepath.Rel(rootPath, path)
+		if err != nil {
+			relPath = path
+		}
+		if !matchesGrepFilters(path, relPath, opts) {
 			return nil
 		}
 
-		// Check file with timeout
-		fileCtx, fileCancel := context.WithTimeout(ctx, 5*time.Second)
-		match, lineNum, charNum, lineText, err := fileContainsPatternWithContext(fileCtx, path, regex)
-		fileCancel()
-
+		f, err := grepFileContent(ctx, path, info.ModTime(), regex, opts)
 		if err != nil {
 			return nil // Skip files we can't read
 		}
-
-		if match {
-			matches = append(matches, grepMatch{
-				path:     path,
-				modTime:  info.ModTime(),
-				lineNum:  lineNum,
-				charNum:  charNum,
-				lineText: lineText,
-			})
-
-			if len(matches) >= 200 {
-				return filepath.SkipAll
-			}
+		if f != nil {
+			matches = append(matches, f.results()...)
 		}
 
 		return nil
@@ -417,8 +818,75 @@ func searchFilesWithRegexContext(ctx context.Context, pattern, rootPath, include
 	return matches, nil
 }
 
-func fileContainsPattern(filePath string, pattern *regexp.Regexp) (bool, int, int, string, error) {
-	return fileContainsPatternWithContext(context.Background(), filePath, pattern)
+// grepFileContent searches a file the way ripgrep does: line by line, or
+// across lines in multiline mode. It returns nil for binary files, detected
+// by NUL bytes like ripgrep does, and files without matches.
+func grepFileContent(ctx context.Context, path string, modTime time.Time, regex *regexp.Regexp, opts grepOptions) (*grepFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.IndexByte(content, 0) >= 0 {
+		return nil, nil
+	}
+
+	text := string(content)
+	if text == "" {
+		return nil, nil
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	f := newGrepFile(path, modTime)
+
+	if opts.multiline {
+		// lineStarts[i] is the byte offset of line i+1.
+		lineStarts := make([]int, len(lines))
+		offset := 0
+		for i, l := range lines {
+			lineStarts[i] = offset
+			offset += len(l) + 1
+		}
+		lineAt := func(pos int) int {
+			i, found := slices.BinarySearch(lineStarts, pos)
+			if !found {
+				i--
+			}
+			return i + 1
+		}
+		for _, loc := range regex.FindAllStringIndex(text, -1) {
+			if loc[0] == len(text) && strings.HasSuffix(text, "\n") {
+				continue // empty match after the final newline
+			}
+			startLine := lineAt(loc[0])
+			f.addMatch(startLine, loc[0]-lineStarts[startLine-1], lineAt(max(loc[0], loc[1]-1)))
+		}
+	} else {
+		for i, line := range lines {
+			if i%1000 == 0 && ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if loc := regex.FindStringIndex(line); loc != nil {
+				f.addMatch(i+1, loc[0], i+1)
+			}
+		}
+	}
+	if len(f.columns) == 0 {
+		return nil, nil
+	}
+
+	for l := range f.columns {
+		for c := max(1, l-opts.before); c <= min(len(lines), l+opts.after); c++ {
+			if _, ok := f.columns[c]; !ok {
+				f.context[c] = true
+			}
+		}
+	}
+	for l := range f.columns {
+		f.text[l] = strings.TrimSuffix(lines[l-1], "\r")
+	}
+	for l := range f.context {
+		f.text[l] = strings.TrimSuffix(lines[l-1], "\r")
+	}
+	return f, nil
 }
 
 // fileContainsMultiLinePattern searches for a pattern that may span multiple lines
@@ -539,43 +1007,6 @@ func fileContainsPatternFromLine(filePath string, pattern *regexp.Regexp, startL
 	return false, 0, 0, "", nil
 }
 
-func fileContainsPatternWithContext(ctx context.Context, filePath string, pattern *regexp.Regexp) (bool, int, int, string, error) {
-	// Only search text files.
-	if !isTextFile(filePath) {
-		return false, 0, 0, "", nil
-	}
-
-	file, err := os.Open(filePath)
-	if err != nil {
-		return false, 0, 0, "", err
-	}
-	defer file.Close()
-
-	// Use scanner with buffer size limits to handle very long lines
-	scanner := bufio.NewScanner(file)
-	buffer := make([]byte, 0, 64*1024) // 64KB initial buffer
-	scanner.Buffer(buffer, 1024*1024)  // Max 1MB per line
-
-	lineNum := 0
-	for scanner.Scan() {
-		// Check context cancellation frequently
-		select {
-		case <-ctx.Done():
-			return false, 0, 0, "", ctx.Err()
-		default:
-		}
-
-		lineNum++
-		line := scanner.Text()
-		if loc := pattern.FindStringIndex(line); loc != nil {
-			charNum := loc[0] + 1
-			return true, lineNum, charNum, line, nil
-		}
-	}
-
-	return false, 0, 0, "", scanner.Err()
-}
-
 // isTextFile checks if a file is a text file by examining its MIME type.
 func isTextFile(filePath string) bool {
 	file, err := os.Open(filePath)
@@ -1,10 +1,14 @@
 package tools
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -425,3 +429,159 @@ func TestColumnMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestGrepOptions(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+
+	for path, content := range map[string]string{
+		"a.go":       "package a\n\nfunc Foo() {\n\treturn\n}\n\nfunc Bar() {\n\tFoo()\n}\n",
+		"b.txt":      "one\nTwo foo\nthree\nfour foo\n",
+		"sub/c.js":   "let foo = 1;\n",
+		"sub/c.json": "{\"foo\": 1}\n",
+		"bin.dat":    "foo\x00bar",
+	} {
+		fullPath := filepath.Join(tempDir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0o755))
+		require.NoError(t, os.WriteFile(fullPath, []byte(content), 0o644))
+	}
+
+	format := func(matches []grepMatch) []string {
+		var lines []string
+		for _, m := range matches {
+			rel, err := filepath.Rel(tempDir, m.path)
+			require.NoError(t, err)
+			kind := ":"
+			if m.isContext {
+				kind = "-"
+			}
+			lines = append(lines, fmt.Sprintf("%s:%d:%d%s%s", filepath.ToSlash(rel), m.lineNum, m.charNum, kind, m.lineText))
+		}
+		sort.Strings(lines)
+		return lines
+	}
+
+	tests := []struct {
+		name string
+		opts grepOptions
+		want []string
+	}{
+		{
+			name: "all matching lines",
+			opts: grepOptions{pattern: "foo"},
+			want: []string{"b.txt:2:5:Two foo", "b.txt:4:6:four foo", "sub/c.js:1:5:let foo = 1;", "sub/c.json:1:3:{\"foo\": 1}"},
+		},
+		{
+			name: "include matches base names",
+			opts: grepOptions{pattern: "foo", include: "*.js"},
+			want: []string{"sub/c.js:1:5:let foo = 1;"},
+		},
+		{
+			name: "type",
+			opts: grepOptions{pattern: "foo", fileType: "js"},
+			want: []string{"sub/c.js:1:5:let foo = 1;"},
+		},
+		{
+			name: "ignore case with context",
+			opts: grepOptions{pattern: "foo", fileType: "go", ignoreCase: true, before: 1, after: 2},
+			want: []string{
+				"a.go:2:0-", "a.go:3:6:func Foo() {", "a.go:4:0-\treturn", "a.go:5:0-}",
+				"a.go:7:0-func Bar() {", "a.go:8:2:\tFoo()", "a.go:9:0-}",
+			},
+		},
+		{
+			name: "multiline",
+			opts: grepOptions{pattern: `Foo\(\) \{\n.return`, multiline: true},
+			want: []string{"a.go:3:6:func Foo() {", "a.go:4:0:\treturn"},
+		},
+		{
+			name: "multiline dot matches newlines",
+			opts: grepOptions{pattern: `Bar.*?\}`, multiline: true},
+			want: []string{"a.go:7:6:func Bar() {", "a.go:8:0:\tFoo()", "a.go:9:0:}"},
+		},
+	}
+
+	for name, fn := range map[string]func(ctx context.Context, path string, opts grepOptions) ([]grepMatch, error){
+		"regex": searchFilesWithRegexOptions,
+		"rg":    searchWithRipgrepOptions,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if name == "rg" && getRg() == "" {
+				t.Skip("rg is not in $PATH")
+			}
+
+			for _, tt := range tests {
+				matches, err := fn(t.Context(), tempDir, tt.opts)
+				require.NoError(t, err, tt.name)
+				require.Equal(t, tt.want, format(matches), tt.name)
+			}
+		})
+	}
+}
+
+func TestFormatGrepResults(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	matches := []grepMatch{
+		{path: "b.go", modTime: now, lineNum: 1, lineText: "package b", isContext: true},
+		{path: "b.go", modTime: now, lineNum: 2, charNum: 3, lineText: "x foo"},
+		{path: "b.go", modTime: now, lineNum: 3, lineText: "y", isContext: true},
+		{path: "b.go", modTime: now, lineNum: 9, lineText: "z", isContext: true},
+		{path: "b.go", modTime: now, lineNum: 10, charNum: 1, lineText: "foo"},
+		{path: "a.go", modTime: now.Add(-time.Hour), lineNum: 4, charNum: 1, lineText: "foo()"},
+	}
+	opts := grepOptions{before: 1, after: 1}
+
+	out, metadata := formatGrepResults(matches, grepOutputContent, 0, 100, opts)
+	require.Equal(t, GrepResponseMetadata{NumberOfMatches: 3, NumberOfFiles: 2}, metadata)
+	require.Equal(t, `Found 3 matches
+b.go:
+  Line 1- package b
+  Line 2, Char 3: x foo
+  Line 3- y
+  --
+  Line 9- z
+  Line 10, Char 1: foo
+
+a.go:
+  Line 4, Char 1: foo()
+`, out)
+
+	out, metadata = formatGrepResults(matches, grepOutputContent, 1, 1, opts)
+	require.True(t, metadata.Truncated)
+	require.Equal(t, `Found 3 matches
+b.go:
+  Line 9- z
+  Line 10, Char 1: foo
+
+(Showing results 2-2 of 3. Use offset=2 for more, or a more specific path or pattern.)`, out)
+
+	out, _ = formatGrepResults(matches, grepOutputFiles, 1, 10, opts)
+	require.Equal(t, "Found 2 files\na.go\n\n(Showing results 2-2 of 2.)", out)
+
+	out, _ = formatGrepResults(matches, grepOutputCount, 0, 10, opts)
+	require.Equal(t, "Found 3 matches in 2 files\nb.go: 2\na.go: 1\n", out)
+
+	out, _ = formatGrepResults(nil, grepOutputCount, 0, 10, opts)
+	require.Equal(t, "No files found", out)
+}
+
+func TestGrepGlobRegex(t *testing.T) {
+	t.Parallel()
+
+	for glob, paths := range map[string]map[string]bool{
+		"*.js":             {"a.js": true, "a.json": false},
+		"*.{ts,tsx}":       {"a.ts": true, "a.tsx": true, "a.js": false},
+		"src/**/*.go":      {"src/a.go": true, "src/x/y/a.go": true, "lib/a.go": false, "src/a.go.txt": false},
+		"[!_]?.go":         {"ab.go": true, "_b.go": false},
+		"test.(foo)+$.txt": {"test.(foo)+$.txt": true, "test.foo.txt": false},
+	} {
+		regex := regexp.MustCompile(grepGlobRegex(glob))
+		for path, want := range paths {
+			require.Equal(t, want, regex.MatchString(path), "%s ~ %s", glob, path)
+		}
+	}
+}
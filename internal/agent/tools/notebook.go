@@ -0,0 +1,358 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Jupyter notebook support shared by the view and notebook_edit tools.
+
+const (
+	maxNotebookOutputChars = 4000
+	maxNotebookImages      = 10
+)
+
+// notebookText is a multiline string, which nbformat stores either as a
+// string or as a list of lines.
+type notebookText string
+
+func (t *notebookText) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = notebookText(s)
+		return nil
+	}
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return err
+	}
+	*t = notebookText(strings.Join(lines, ""))
+	return nil
+}
+
+type notebookFile struct {
+	Cells    []notebookCell `json:"cells"`
+	Metadata struct {
+		KernelSpec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+type notebookCell struct {
+	ID             string           `json:"id"`
+	CellType       string           `json:"cell_type"`
+	Source         notebookText     `json:"source"`
+	ExecutionCount *int             `json:"execution_count"`
+	Outputs        []notebookOutput `json:"outputs"`
+}
+
+type notebookOutput struct {
+	OutputType string                     `json:"output_type"`
+	Name       string                     `json:"name"`
+	Text       notebookText               `json:"text"`
+	Data       map[string]json.RawMessage `json:"data"`
+	EName      string                     `json:"ename"`
+	EValue     string                     `json:"evalue"`
+	Traceback  []string                   `json:"traceback"`
+}
+
+func parseNotebook(data []byte) (notebookFile, error) {
+	var nb notebookFile
+	if err := json.Unmarshal(data, &nb); err != nil {
+		return nb, fmt.Errorf("invalid notebook: %w", err)
+	}
+	return nb, nil
+}
+
+func (nb notebookFile) language() string {
+	if nb.Metadata.LanguageInfo.Name != "" {
+		return nb.Metadata.LanguageInfo.Name
+	}
+	if nb.Metadata.KernelSpec.Language != "" {
+		return nb.Metadata.KernelSpec.Language
+	}
+	return "python"
+}
+
+// renderNotebook formats the cells from offset on with their outputs, until
+// limit cells are rendered or the token budget is spent. It returns the text,
+// the index of the first cell not rendered and the image outputs, which are
+// only collected when withImages is set.
+func renderNotebook(nb notebookFile, offset, limit, maxTokens int, withImages bool) (string, int, []ResponseAttachment) {
+	var (
+		b           strings.Builder
+		attachments []ResponseAttachment
+		tokens      int
+	)
+	next := offset
+	for i := offset; i < len(nb.Cells) && i < offset+limit; i++ {
+		cell := renderNotebookCell(nb.Cells[i], i, withImages, &attachments)
+		cellTokens := countTokens(cell)
+		if i > offset && tokens+cellTokens > maxTokens {
+			break
+		}
+		tokens += cellTokens
+		b.WriteString(cell)
+		next = i + 1
+	}
+	return b.String(), next, attachments
+}
+
+func renderNotebookCell(cell notebookCell, index int, withImages bool, attachments *[]ResponseAttachment) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<cell index=\"%d\" type=\"%s\"", index, cell.CellType)
+	if cell.ID != "" {
+		fmt.Fprintf(&b, " id=%q", cell.ID)
+	}
+	if cell.ExecutionCount != nil {
+		fmt.Fprintf(&b, " execution_count=\"%d\"", *cell.ExecutionCount)
+	}
+	b.WriteString(">\n")
+	if source := strings.TrimRight(string(cell.Source), "\n"); source != "" {
+		b.WriteString(source)
+		b.WriteString("\n")
+	}
+	for _, output := range cell.Outputs {
+		b.WriteString(renderNotebookOutput(output, withImages, attachments))
+	}
+	b.WriteString("</cell>\n")
+	return b.String()
+}
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+func renderNotebookOutput(output notebookOutput, withImages bool, attachments *[]ResponseAttachment) string {
+	var text string
+	attrs := fmt.Sprintf(" type=%q", output.OutputType)
+	switch output.OutputType {
+	case "stream":
+		attrs += fmt.Sprintf(" name=%q", output.Name)
+		text = string(output.Text)
+	case "error":
+		text = fmt.Sprintf("%s: %s", output.EName, output.EValue)
+		if len(output.Traceback) > 0 {
+			text = strings.Join(output.Traceback, "\n")
+		}
+	case "execute_result", "display_data":
+		var parts []string
+		for _, mime := range []string{"image/png", "image/jpeg", "image/gif", "image/webp"} {
+			raw, ok := output.Data[mime]
+			if !ok {
+				continue
+			}
+			parts = append(parts, notebookImage(mime, raw, withImages, attachments))
+		}
+		for _, mime := range []string{"text/plain", "text/markdown", "application/json"} {
+			raw, ok := output.Data[mime]
+			if !ok {
+				continue
+			}
+			var value notebookText
+			if err := json.Unmarshal(raw, &value); err != nil {
+				value = notebookText(raw)
+			}
+			parts = append(parts, string(value))
+			break
+		}
+		if len(parts) == 0 {
+			var mimes []string
+			for mime := range output.Data {
+				mimes = append(mimes, mime)
+			}
+			slices.Sort(mimes)
+			parts = append(parts, fmt.Sprintf("[%s output omitted]", strings.Join(mimes, ", ")))
+		}
+		text = strings.Join(parts, "\n")
+	}
+
+	text = strings.TrimRight(ansiEscape.ReplaceAllString(text, ""), "\n")
+	if len(text) > maxNotebookOutputChars {
+		text = text[:maxNotebookOutputChars] + "\n... (output truncated)"
+	}
+	return fmt.Sprintf("<output%s>\n%s\n</output>\n", attrs, text)
+}
+
+func notebookImage(mime string, raw json.RawMessage, withImages bool, attachments *[]ResponseAttachment) string {
+	if !withImages {
+		return fmt.Sprintf("[%s output omitted: this model does not support images]", mime)
+	}
+	if len(*attachments) >= maxNotebookImages {
+		return fmt.Sprintf("[%s output omitted: more than %d images]", mime, maxNotebookImages)
+	}
+	var encoded notebookText
+	if err := json.Unmarshal(raw, &encoded); err != nil {
+		return fmt.Sprintf("[%s output omitted: invalid data]", mime)
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(encoded)), ""))
+	if err != nil {
+		return fmt.Sprintf("[%s output omitted: invalid data]", mime)
+	}
+	*attachments = append(*attachments, ResponseAttachment{MIMEType: mime, Data: data})
+	return fmt.Sprintf("[%s output attached as image %d]", mime, len(*attachments))
+}
+
+// Notebook edit modes.
+const (
+	NotebookEditReplace = "replace"
+	NotebookEditInsert  = "insert"
+	NotebookEditDelete  = "delete"
+)
+
+// editNotebook applies a cell edit to the raw notebook and returns the new
+// file content along with the previous source of the cell. Fields the tool
+// does not know about are kept as they are.
+func editNotebook(data []byte, mode string, index int, cellType, source string) ([]byte, string, error) {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(data, &top); err != nil {
+		return nil, "", fmt.Errorf("invalid notebook: %w", err)
+	}
+	var cells []map[string]any
+	dec := json.NewDecoder(bytes.NewReader(top["cells"]))
+	dec.UseNumber()
+	if err := dec.Decode(&cells); err != nil {
+		return nil, "", fmt.Errorf("invalid notebook cells: %w", err)
+	}
+
+	if cellType != "" && cellType != "code" && cellType != "markdown" && cellType != "raw" {
+		return nil, "", fmt.Errorf("invalid cell_type %q: use code, markdown or raw", cellType)
+	}
+
+	var oldSource string
+	switch mode {
+	case NotebookEditReplace:
+		if index < 0 || index >= len(cells) {
+			return nil, "", fmt.Errorf("cell_index %d is out of range: the notebook has %d cells", index, len(cells))
+		}
+		cell := cells[index]
+		oldSource = notebookSource(cell["source"])
+		cell["source"] = notebookLines(source)
+		if cellType != "" {
+			cell["cell_type"] = cellType
+		}
+		// The outputs no longer match the source.
+		if cell["cell_type"] == "code" {
+			cell["execution_count"] = nil
+			cell["outputs"] = []any{}
+		} else {
+			delete(cell, "execution_count")
+			delete(cell, "outputs")
+		}
+	case NotebookEditInsert:
+		if index < 0 || index > len(cells) {
+			return nil, "", fmt.Errorf("cell_index %d is out of range: new cells go at 0-%d", index, len(cells))
+		}
+		if cellType == "" {
+			cellType = "code"
+		}
+		cell := map[string]any{
+			"cell_type": cellType,
+			"metadata":  map[string]any{},
+			"source":    notebookLines(source),
+		}
+		if cellType == "code" {
+			cell["execution_count"] = nil
+			cell["outputs"] = []any{}
+		}
+		if notebookHasCellIDs(top, cells) {
+			cell["id"] = newNotebookCellID()
+		}
+		cells = slices.Insert(cells, index, cell)
+	case NotebookEditDelete:
+		if index < 0 || index >= len(cells) {
+			return nil, "", fmt.Errorf("cell_index %d is out of range: the notebook has %d cells", index, len(cells))
+		}
+		oldSource = notebookSource(cells[index]["source"])
+		cells = slices.Delete(cells, index, index+1)
+	default:
+		return nil, "", fmt.Errorf("invalid edit_mode %q: use replace, insert or delete", mode)
+	}
+
+	var encodedCells bytes.Buffer
+	cellsEnc := json.NewEncoder(&encodedCells)
+	cellsEnc.SetEscapeHTML(false)
+	if err := cellsEnc.Encode(cells); err != nil {
+		return nil, "", err
+	}
+	top["cells"] = encodedCells.Bytes()
+
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", notebookIndent(data))
+	if err := enc.Encode(top); err != nil {
+		return nil, "", err
+	}
+	return out.Bytes(), oldSource, nil
+}
+
+func notebookSource(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []any:
+		var b strings.Builder
+		for _, line := range s {
+			if line, ok := line.(string); ok {
+				b.WriteString(line)
+			}
+		}
+		return b.String()
+	}
+	return ""
+}
+
+// notebookLines splits source into lines that keep their newline, the way
+// Jupyter writes them.
+func notebookLines(source string) []any {
+	lines := []any{}
+	for line := range strings.SplitAfterSeq(source, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// notebookHasCellIDs reports whether the notebook format uses cell IDs, which
+// nbformat requires from version 4.5 on.
+func notebookHasCellIDs(top map[string]json.RawMessage, cells []map[string]any) bool {
+	for _, cell := range cells {
+		if _, ok := cell["id"]; ok {
+			return true
+		}
+	}
+	var major, minor int
+	_ = json.Unmarshal(top["nbformat"], &major)
+	_ = json.Unmarshal(top["nbformat_minor"], &minor)
+	return major > 4 || major == 4 && minor >= 5
+}
+
+func newNotebookCellID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// notebookIndent returns the indentation of the notebook file, which is a
+// single space for files written by Jupyter.
+func notebookIndent(data []byte) string {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		line := data[i+1:]
+		n := len(line) - len(bytes.TrimLeft(line, " \t"))
+		if n > 0 {
+			return string(line[:n])
+		}
+	}
+	return " "
+}
@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/nexora/nexora/internal/filepathext"
+	"github.com/nexora/nexora/internal/fsext"
+	"github.com/nexora/nexora/internal/history"
+	"github.com/nexora/nexora/internal/permission"
+)
+
+//go:embed notebook_edit.md
+var notebookEditDescription []byte
+
+type NotebookEditParams struct {
+	NotebookPath string `json:"notebook_path" description:"The path to the Jupyter notebook (.ipynb) to edit"`
+	CellIndex    int    `json:"cell_index" description:"The 0-based index of the cell to edit; for insert, the position of the new cell"`
+	NewSource    string `json:"new_source,omitempty" description:"The new source of the cell (not used for delete)"`
+	CellType     string `json:"cell_type,omitempty" description:"The cell type: code, markdown or raw. Defaults to code for insert and to the current type for replace"`
+	EditMode     string `json:"edit_mode,omitempty" description:"replace (default), insert or delete"`
+}
+
+type NotebookEditPermissionsParams struct {
+	FilePath  string `json:"file_path"`
+	CellIndex int    `json:"cell_index"`
+	EditMode  string `json:"edit_mode"`
+	OldSource string `json:"old_source,omitempty"`
+	NewSource string `json:"new_source,omitempty"`
+}
+
+type NotebookEditResponseMetadata struct {
+	FilePath  string `json:"file_path"`
+	CellIndex int    `json:"cell_index"`
+	EditMode  string `json:"edit_mode"`
+	OldSource string `json:"old_source,omitempty"`
+	NewSource string `json:"new_source,omitempty"`
+}
+
+const NotebookEditToolName = "notebook_edit"
+
+func NewNotebookEditTool(permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		NotebookEditToolName,
+		string(notebookEditDescription),
+		func(ctx context.Context, params NotebookEditParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.NotebookPath == "" {
+				return fantasy.NewTextErrorResponse("notebook_path is required"), nil
+			}
+			if !strings.EqualFold(filepath.Ext(params.NotebookPath), ".ipynb") {
+				return fantasy.NewTextErrorResponse("notebook_path must be a Jupyter notebook (.ipynb); use the edit tool for other files"), nil
+			}
+			if params.EditMode == "" {
+				params.EditMode = NotebookEditReplace
+			}
+
+			filePath := filepathext.SmartJoin(workingDir, params.NotebookPath)
+			fileInfo, err := os.Stat(filePath)
+			if err != nil {
+				if os.IsNotExist(err) {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("Notebook not found: %s", filePath)), nil
+				}
+				return fantasy.ToolResponse{}, fmt.Errorf("error checking notebook: %w", err)
+			}
+			if fileInfo.IsDir() {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("Path is a directory, not a notebook: %s", filePath)), nil
+			}
+
+			modTime := fileInfo.ModTime()
+			lastRead := getLastReadTime(filePath)
+			if modTime.After(lastRead) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("Notebook %s has been modified since it was last read.\nLast modification: %s\nLast read: %s\n\nPlease read the notebook again before modifying it.",
+					filePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339))), nil
+			}
+
+			oldContent, err := os.ReadFile(filePath)
+			if err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("error reading notebook: %w", err)
+			}
+			newContent, oldSource, err := editNotebook(oldContent, params.EditMode, params.CellIndex, params.CellType, params.NewSource)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session_id is required")
+			}
+
+			newSource := params.NewSource
+			if params.EditMode == NotebookEditDelete {
+				newSource = ""
+			}
+			p := permissions.Request(
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        fsext.PathOrPrefix(filePath, workingDir),
+					ToolCallID:  call.ID,
+					ToolName:    NotebookEditToolName,
+					Action:      "write",
+					Description: fmt.Sprintf("%s cell %d of notebook %s", notebookEditVerb(params.EditMode), params.CellIndex, filePath),
+					Params: NotebookEditPermissionsParams{
+						FilePath:  filePath,
+						CellIndex: params.CellIndex,
+						EditMode:  params.EditMode,
+						OldSource: oldSource,
+						NewSource: newSource,
+					},
+				},
+			)
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			if err := os.WriteFile(filePath, newContent, fileInfo.Mode().Perm()); err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("error writing notebook: %w", err)
+			}
+
+			// Check if file exists in history
+			file, err := files.GetByPathAndSession(ctx, filePath, sessionID)
+			if err != nil {
+				_, err = files.Create(ctx, sessionID, filePath, string(oldContent))
+				if err != nil {
+					return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
+				}
+			}
+			if file.Content != string(oldContent) {
+				// User manually changed the content, store an intermediate version
+				_, err = files.CreateVersion(ctx, sessionID, filePath, string(oldContent))
+				if err != nil {
+					return fantasy.ToolResponse{}, fmt.Errorf("error creating intermediate file history version: %w", err)
+				}
+			}
+			_, err = files.CreateVersion(ctx, sessionID, filePath, string(newContent))
+			if err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("error creating file history version: %w", err)
+			}
+
+			recordFileWrite(filePath)
+			recordFileRead(filePath)
+
+			result := fmt.Sprintf("<result>\nCell %d %s in notebook %s\n</result>",
+				params.CellIndex, notebookEditPastTense(params.EditMode), filePath)
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(result),
+				NotebookEditResponseMetadata{
+					FilePath:  filePath,
+					CellIndex: params.CellIndex,
+					EditMode:  params.EditMode,
+					OldSource: oldSource,
+					NewSource: newSource,
+				},
+			), nil
+		})
+}
+
+func notebookEditVerb(mode string) string {
+	switch mode {
+	case NotebookEditInsert:
+		return "Insert"
+	case NotebookEditDelete:
+		return "Delete"
+	default:
+		return "Replace"
+	}
+}
+
+func notebookEditPastTense(mode string) string {
+	switch mode {
+	case NotebookEditInsert:
+		return "inserted"
+	case NotebookEditDelete:
+		return "deleted"
+	default:
+		return "replaced"
+	}
+}
@@ -0,0 +1,186 @@
+package tools
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testPNG = []byte("\x89PNG\r\n\x1a\nfake")
+
+func testNotebook() string {
+	return `{
+ "cells": [
+  {
+   "cell_type": "markdown",
+   "id": "intro",
+   "metadata": {},
+   "source": ["# Title\n", "Some <b>text</b>"]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 2,
+   "id": "plot",
+   "metadata": {"tags": ["keep"]},
+   "outputs": [
+    {"name": "stdout", "output_type": "stream", "text": ["hello\n"]},
+    {"data": {"image/png": "` + base64.StdEncoding.EncodeToString(testPNG) + `", "text/plain": ["<Figure>"]}, "metadata": {}, "output_type": "display_data"},
+    {"data": {"text/plain": "42"}, "execution_count": 2, "metadata": {}, "output_type": "execute_result"}
+   ],
+   "source": "print('hello')\nplot()"
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 3,
+   "id": "boom",
+   "metadata": {},
+   "outputs": [
+    {"ename": "ZeroDivisionError", "evalue": "division by zero", "output_type": "error", "traceback": ["\u001b[0;31mZeroDivisionError\u001b[0m: division by zero"]}
+   ],
+   "source": "1/0"
+  }
+ ],
+ "metadata": {"kernelspec": {"language": "python", "name": "python3"}},
+ "nbformat": 4,
+ "nbformat_minor": 5
+}
+`
+}
+
+func TestRenderNotebook(t *testing.T) {
+	t.Parallel()
+
+	nb, err := parseNotebook([]byte(testNotebook()))
+	require.NoError(t, err)
+	require.Equal(t, "python", nb.language())
+
+	text, next, attachments := renderNotebook(nb, 0, 10, MaxViewTokens, true)
+	require.Equal(t, 3, next)
+	require.Equal(t, `<cell index="0" type="markdown" id="intro">
+# Title
+Some <b>text</b>
+</cell>
+<cell index="1" type="code" id="plot" execution_count="2">
+print('hello')
+plot()
+<output type="stream" name="stdout">
+hello
+</output>
+<output type="display_data">
+[image/png output attached as image 1]
+<Figure>
+</output>
+<output type="execute_result">
+42
+</output>
+</cell>
+<cell index="2" type="code" id="boom" execution_count="3">
+1/0
+<output type="error">
+ZeroDivisionError: division by zero
+</output>
+</cell>
+`, text)
+	require.Equal(t, []ResponseAttachment{{MIMEType: "image/png", Data: testPNG}}, attachments)
+
+	text, next, attachments = renderNotebook(nb, 1, 1, MaxViewTokens, false)
+	require.Equal(t, 2, next)
+	require.Contains(t, text, "[image/png output omitted: this model does not support images]")
+	require.NotContains(t, text, `index="0"`)
+	require.Empty(t, attachments)
+}
+
+func TestEditNotebook(t *testing.T) {
+	t.Parallel()
+
+	cells := func(t *testing.T, data []byte) []map[string]any {
+		t.Helper()
+		var nb struct {
+			Cells []map[string]any `json:"cells"`
+		}
+		require.NoError(t, json.Unmarshal(data, &nb))
+		return nb.Cells
+	}
+
+	t.Run("replace", func(t *testing.T) {
+		t.Parallel()
+		data, old, err := editNotebook([]byte(testNotebook()), NotebookEditReplace, 1, "", "x = 1\ny = 2\n")
+		require.NoError(t, err)
+		require.Equal(t, "print('hello')\nplot()", old)
+		cell := cells(t, data)[1]
+		require.Equal(t, []any{"x = 1\n", "y = 2\n"}, cell["source"])
+		require.Nil(t, cell["execution_count"])
+		require.Empty(t, cell["outputs"])
+		require.Equal(t, map[string]any{"tags": []any{"keep"}}, cell["metadata"])
+		// Jupyter's formatting is kept.
+		require.True(t, strings.HasPrefix(string(data), "{\n \"cells\": [\n  {\n"))
+		require.Contains(t, string(data), "Some <b>text</b>")
+	})
+
+	t.Run("replace changes type", func(t *testing.T) {
+		t.Parallel()
+		data, _, err := editNotebook([]byte(testNotebook()), NotebookEditReplace, 2, "markdown", "Done")
+		require.NoError(t, err)
+		cell := cells(t, data)[2]
+		require.Equal(t, "markdown", cell["cell_type"])
+		require.NotContains(t, cell, "outputs")
+		require.NotContains(t, cell, "execution_count")
+	})
+
+	t.Run("insert", func(t *testing.T) {
+		t.Parallel()
+		data, _, err := editNotebook([]byte(testNotebook()), NotebookEditInsert, 3, "", "import os")
+		require.NoError(t, err)
+		got := cells(t, data)
+		require.Len(t, got, 4)
+		require.Equal(t, "code", got[3]["cell_type"])
+		require.Equal(t, []any{"import os"}, got[3]["source"])
+		require.Len(t, got[3]["id"], 8)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		t.Parallel()
+		data, old, err := editNotebook([]byte(testNotebook()), NotebookEditDelete, 0, "", "")
+		require.NoError(t, err)
+		require.Equal(t, "# Title\nSome <b>text</b>", old)
+		got := cells(t, data)
+		require.Len(t, got, 2)
+		require.Equal(t, "plot", got[0]["id"])
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, _, err := editNotebook([]byte(testNotebook()), NotebookEditReplace, 3, "", "x")
+		require.ErrorContains(t, err, "out of range")
+		_, _, err = editNotebook([]byte(testNotebook()), NotebookEditInsert, 4, "", "x")
+		require.ErrorContains(t, err, "out of range")
+		_, _, err = editNotebook([]byte(testNotebook()), "append", 0, "", "x")
+		require.ErrorContains(t, err, "invalid edit_mode")
+		_, _, err = editNotebook([]byte(testNotebook()), NotebookEditInsert, 0, "sql", "x")
+		require.ErrorContains(t, err, "invalid cell_type")
+		_, _, err = editNotebook([]byte("not json"), NotebookEditDelete, 0, "", "")
+		require.ErrorContains(t, err, "invalid notebook")
+	})
+}
+
+func TestSplitResponseAttachments(t *testing.T) {
+	t.Parallel()
+
+	metadata, err := json.Marshal(ViewResponseMetadata{
+		FilePath:    "plot.ipynb",
+		Content:     "cells",
+		Attachments: []ResponseAttachment{{MIMEType: "image/png", Data: testPNG}},
+	})
+	require.NoError(t, err)
+
+	stripped, attachments := SplitResponseAttachments(string(metadata))
+	require.JSONEq(t, `{"file_path":"plot.ipynb","content":"cells"}`, stripped)
+	require.Equal(t, []ResponseAttachment{{MIMEType: "image/png", Data: testPNG}}, attachments)
+
+	stripped, attachments = SplitResponseAttachments(`{"file_path":"main.go"}`)
+	require.Equal(t, `{"file_path":"main.go"}`, stripped)
+	require.Nil(t, attachments)
+}
@@ -0,0 +1,1055 @@
+package tools
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// PDF text extraction for the view tool. This is not a full PDF reader: it
+// understands enough of the object model to walk the page tree, decode content
+// streams and turn text-showing operators into plain text. Layout is
+// approximated with a line break whenever the text position moves vertically.
+
+const (
+	MaxPDFSize  = 32 * 1024 * 1024 // 32MB
+	MaxPDFPages = 20               // Max pages returned by a single view
+
+	maxPDFStreamSize  = 64 * 1024 * 1024
+	maxPDFDecodedSize = 256 * 1024 * 1024 // All streams of a document together
+	maxPDFDepth       = 32
+)
+
+type (
+	pdfName    string
+	pdfString  string
+	pdfKeyword string
+	pdfArray   []any
+	pdfDict    map[pdfName]any
+)
+
+type pdfRef struct {
+	num, gen int
+}
+
+type pdfStream struct {
+	dict pdfDict
+	raw  []byte
+}
+
+var errNotPDF = errors.New("not a PDF file")
+
+var errPDFTooLarge = fmt.Errorf("PDF streams decode to more than %dMB", maxPDFDecodedSize/(1024*1024))
+
+// pdfDocument holds every object of a PDF file, indexed by object number.
+type pdfDocument struct {
+	data    []byte
+	offsets map[int]int
+	objects map[int]any
+	loading map[int]bool
+	pages   []pdfPage
+	fonts   map[pdfRef]*pdfFont
+	// budget is how many more bytes streams may decode to, so that a
+	// crafted file cannot exhaust memory with many compressed streams.
+	budget int
+}
+
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+var pdfObjectHeader = regexp.MustCompile(`(?:^|[\s>])(\d+)\s+(\d+)\s+obj\b`)
+
+func readPDFFile(path string) (*pdfDocument, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parsePDF(data)
+}
+
+func parsePDF(data []byte) (*pdfDocument, error) {
+	if !bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF-")) {
+		return nil, errNotPDF
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return nil, errors.New("encrypted PDF files are not supported")
+	}
+
+	d := &pdfDocument{
+		data:    data,
+		offsets: make(map[int]int),
+		objects: make(map[int]any),
+		loading: make(map[int]bool),
+		fonts:   make(map[pdfRef]*pdfFont),
+		budget:  maxPDFDecodedSize,
+	}
+	// Scanning for object headers instead of trusting the xref table copes
+	// with damaged files; later definitions win, as with incremental updates.
+	for _, m := range pdfObjectHeader.FindAllSubmatchIndex(data, -1) {
+		num, err := strconv.Atoi(string(data[m[2]:m[3]]))
+		if err != nil {
+			continue
+		}
+		d.offsets[num] = m[1]
+	}
+
+	nums := make([]int, 0, len(d.offsets))
+	for num := range d.offsets {
+		nums = append(nums, num)
+	}
+	slices.Sort(nums)
+	for _, num := range nums {
+		if s, ok := d.object(num).(*pdfStream); ok && s.dict["Type"] == pdfName("ObjStm") {
+			d.loadObjectStream(s)
+		}
+	}
+	if d.budget == 0 {
+		return nil, errPDFTooLarge
+	}
+
+	nums = nums[:0]
+	for num := range d.objects {
+		nums = append(nums, num)
+	}
+	slices.Sort(nums)
+	d.pages = d.collectPages(nums)
+	if len(d.pages) == 0 {
+		return nil, errors.New("no pages found in PDF file")
+	}
+	return d, nil
+}
+
+// NumPages returns the number of pages in the document.
+func (d *pdfDocument) NumPages() int {
+	return len(d.pages)
+}
+
+// PageText returns the text of the page at the 0-based index.
+func (d *pdfDocument) PageText(i int) string {
+	if i < 0 || i >= len(d.pages) {
+		return ""
+	}
+	page := d.pages[i]
+	var content []byte
+	switch c := d.resolve(page.dict["Contents"]).(type) {
+	case *pdfStream:
+		content, _ = d.decodeStream(c)
+	case pdfArray:
+		for _, part := range c {
+			if s, ok := d.resolve(part).(*pdfStream); ok {
+				data, _ := d.decodeStream(s)
+				content = append(content, data...)
+				content = append(content, '\n')
+			}
+		}
+	}
+
+	var w pdfTextWriter
+	d.runContent(content, page.resources, &w, 0)
+	return w.String()
+}
+
+// parsePageRange parses a 1-based, inclusive page range such as "3", "1-5"
+// or "10-" against the number of pages in the document.
+func parsePageRange(spec string, total int) (int, int, error) {
+	spec = strings.TrimSpace(spec)
+	from, to, isRange := strings.Cut(spec, "-")
+	first, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil || first < 1 {
+		return 0, 0, fmt.Errorf("invalid page range %q: use a page number or a range like 1-5", spec)
+	}
+	last := first
+	if isRange {
+		last = total
+		if to = strings.TrimSpace(to); to != "" {
+			if last, err = strconv.Atoi(to); err != nil || last < first {
+				return 0, 0, fmt.Errorf("invalid page range %q: use a page number or a range like 1-5", spec)
+			}
+		}
+	}
+	if first > total {
+		return 0, 0, fmt.Errorf("page %d is out of range: the document has %d pages", first, total)
+	}
+	return first, min(last, total), nil
+}
+
+func (d *pdfDocument) object(num int) any {
+	if obj, ok := d.objects[num]; ok {
+		return obj
+	}
+	offset, ok := d.offsets[num]
+	if !ok || d.loading[num] {
+		return nil
+	}
+	d.loading[num] = true
+	defer delete(d.loading, num)
+
+	l := &pdfLexer{data: d.data, pos: offset}
+	obj, err := l.object()
+	if err != nil {
+		return nil
+	}
+	if dict, ok := obj.(pdfDict); ok {
+		save := l.pos
+		if tok, err := l.token(); err == nil && tok == pdfKeyword("stream") {
+			obj = d.readStream(dict, l.pos)
+		} else {
+			l.pos = save
+		}
+	}
+	d.objects[num] = obj
+	return obj
+}
+
+func (d *pdfDocument) readStream(dict pdfDict, pos int) *pdfStream {
+	if pos < len(d.data) && d.data[pos] == '\r' {
+		pos++
+	}
+	if pos < len(d.data) && d.data[pos] == '\n' {
+		pos++
+	}
+	if length, ok := d.resolve(dict["Length"]).(float64); ok {
+		end := pos + int(length)
+		if length >= 0 && end <= len(d.data) {
+			rest := bytes.TrimLeft(d.data[end:min(len(d.data), end+32)], "\r\n\t ")
+			if bytes.HasPrefix(rest, []byte("endstream")) {
+				return &pdfStream{dict: dict, raw: d.data[pos:end]}
+			}
+		}
+	}
+	// Missing or wrong length: fall back to the endstream keyword.
+	end := bytes.Index(d.data[pos:], []byte("endstream"))
+	if end < 0 {
+		return &pdfStream{dict: dict, raw: d.data[pos:]}
+	}
+	raw := d.data[pos : pos+end]
+	raw = bytes.TrimSuffix(raw, []byte("\n"))
+	raw = bytes.TrimSuffix(raw, []byte("\r"))
+	return &pdfStream{dict: dict, raw: raw}
+}
+
+// loadObjectStream registers the objects compressed into an object stream.
+// Objects defined directly in the file take precedence.
+func (d *pdfDocument) loadObjectStream(s *pdfStream) {
+	data, err := d.decodeStream(s)
+	if err != nil {
+		return
+	}
+	n, _ := d.resolve(s.dict["N"]).(float64)
+	first, _ := d.resolve(s.dict["First"]).(float64)
+	if int(first) > len(data) {
+		return
+	}
+
+	l := &pdfLexer{data: data}
+	type entry struct{ num, offset int }
+	var entries []entry
+	for range int(n) {
+		num, err1 := l.token()
+		offset, err2 := l.token()
+		numF, ok1 := num.(float64)
+		offsetF, ok2 := offset.(float64)
+		if err1 != nil || err2 != nil || !ok1 || !ok2 {
+			break
+		}
+		entries = append(entries, entry{int(numF), int(offsetF)})
+	}
+	for _, e := range entries {
+		if _, ok := d.offsets[e.num]; ok {
+			continue
+		}
+		if _, ok := d.objects[e.num]; ok {
+			continue
+		}
+		pos := int(first) + e.offset
+		if pos < 0 || pos >= len(data) {
+			continue
+		}
+		l := &pdfLexer{data: data, pos: pos}
+		if obj, err := l.object(); err == nil {
+			d.objects[e.num] = obj
+		}
+	}
+}
+
+func (d *pdfDocument) resolve(obj any) any {
+	for range maxPDFDepth {
+		ref, ok := obj.(pdfRef)
+		if !ok {
+			return obj
+		}
+		obj = d.object(ref.num)
+	}
+	return nil
+}
+
+func (d *pdfDocument) dict(obj any) pdfDict {
+	switch v := d.resolve(obj).(type) {
+	case pdfDict:
+		return v
+	case *pdfStream:
+		return v.dict
+	}
+	return nil
+}
+
+func (d *pdfDocument) collectPages(nums []int) []pdfPage {
+	// The catalog with the highest object number is the most recent one.
+	var root pdfDict
+	for _, num := range nums {
+		if dict := d.dict(d.object(num)); dict["Type"] == pdfName("Catalog") {
+			root = dict
+		}
+	}
+
+	var pages []pdfPage
+	visited := make(map[pdfRef]bool)
+	var walk func(node any, resources pdfDict, depth int)
+	walk = func(node any, resources pdfDict, depth int) {
+		if depth > maxPDFDepth {
+			return
+		}
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref] {
+				return
+			}
+			visited[ref] = true
+		}
+		dict := d.dict(node)
+		if dict == nil {
+			return
+		}
+		if r := d.dict(dict["Resources"]); r != nil {
+			resources = r
+		}
+		kids, ok := d.resolve(dict["Kids"]).(pdfArray)
+		if dict["Type"] == pdfName("Page") || !ok {
+			pages = append(pages, pdfPage{dict: dict, resources: resources})
+			return
+		}
+		for _, kid := range kids {
+			walk(kid, resources, depth+1)
+		}
+	}
+	if root != nil {
+		walk(root["Pages"], nil, 0)
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+
+	// No usable page tree: take the page objects in file order.
+	for _, num := range nums {
+		if dict := d.dict(d.object(num)); dict["Type"] == pdfName("Page") {
+			pages = append(pages, pdfPage{dict: dict, resources: d.dict(dict["Resources"])})
+		}
+	}
+	return pages
+}
+
+func (d *pdfDocument) decodeStream(s *pdfStream) ([]byte, error) {
+	var filters []any
+	switch f := d.resolve(s.dict["Filter"]).(type) {
+	case pdfName:
+		filters = []any{f}
+	case pdfArray:
+		filters = f
+	}
+
+	data := s.raw
+	for _, f := range filters {
+		name, _ := d.resolve(f).(pdfName)
+		var err error
+		switch name {
+		case "FlateDecode", "Fl":
+			data, err = pdfInflate(data, min(maxPDFStreamSize, d.budget))
+		case "ASCIIHexDecode", "AHx":
+			data, err = pdfASCIIHexDecode(data)
+		case "ASCII85Decode", "A85":
+			data, err = pdfASCII85Decode(data)
+		default:
+			err = fmt.Errorf("unsupported stream filter %q", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(data) >= d.budget {
+			d.budget = 0
+			return nil, errPDFTooLarge
+		}
+		d.budget -= len(data)
+	}
+	return data, nil
+}
+
+func pdfInflate(data []byte, limit int) ([]byte, error) {
+	var r io.Reader
+	if zr, err := zlib.NewReader(bytes.NewReader(data)); err == nil {
+		r = zr
+	} else {
+		r = flate.NewReader(bytes.NewReader(data))
+	}
+	out, err := io.ReadAll(io.LimitReader(r, int64(limit)))
+	// Truncated streams are common; keep whatever could be inflated.
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+func pdfASCIIHexDecode(data []byte) ([]byte, error) {
+	if i := bytes.IndexByte(data, '>'); i >= 0 {
+		data = data[:i]
+	}
+	digits := bytes.Map(func(r rune) rune {
+		if isPDFSpace(byte(r)) {
+			return -1
+		}
+		return r
+	}, data)
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	return hex.DecodeString(string(digits))
+}
+
+func pdfASCII85Decode(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	data = bytes.TrimPrefix(data, []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	out := make([]byte, 4*len(data))
+	n, _, err := ascii85.Decode(out, data, true)
+	if err != nil {
+		return nil, err
+	}
+	return out[:n], nil
+}
+
+// runContent interprets the text operators of a content stream.
+func (d *pdfDocument) runContent(content []byte, resources pdfDict, w *pdfTextWriter, depth int) {
+	if depth > 8 {
+		return
+	}
+	var (
+		font     *pdfFont
+		operands []any
+		lastY    float64
+	)
+	fonts := d.dict(resources["Font"])
+	l := &pdfLexer{data: content}
+	for {
+		obj, err := l.object()
+		if err != nil {
+			return
+		}
+		op, ok := obj.(pdfKeyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+
+		num := func(i int) float64 {
+			if i < len(operands) {
+				f, _ := operands[i].(float64)
+				return f
+			}
+			return 0
+		}
+		str := func(i int) pdfString {
+			if i < len(operands) {
+				s, _ := operands[i].(pdfString)
+				return s
+			}
+			return ""
+		}
+
+		switch op {
+		case "BT":
+			lastY = 0
+		case "Tf":
+			if len(operands) > 0 {
+				name, _ := operands[0].(pdfName)
+				font = d.font(fonts[name])
+			}
+		case "Td", "TD":
+			if num(1) != 0 {
+				w.newline()
+			} else if num(0) > 0 {
+				w.space()
+			}
+		case "Tm":
+			if y := num(5); len(operands) == 6 && y != lastY {
+				w.newline()
+				lastY = y
+			} else {
+				w.space()
+			}
+		case "T*":
+			w.newline()
+		case "Tj":
+			w.write(font.decode(str(0)))
+		case "'":
+			w.newline()
+			w.write(font.decode(str(0)))
+		case "\"":
+			w.newline()
+			w.write(font.decode(str(2)))
+		case "TJ":
+			if len(operands) > 0 {
+				arr, _ := operands[0].(pdfArray)
+				for _, item := range arr {
+					switch v := item.(type) {
+					case pdfString:
+						w.write(font.decode(v))
+					case float64:
+						// Large negative adjustments separate words.
+						if v < -180 {
+							w.space()
+						}
+					}
+				}
+			}
+		case "Do":
+			if len(operands) > 0 {
+				name, _ := operands[0].(pdfName)
+				xobjects := d.dict(resources["XObject"])
+				if form, ok := d.resolve(xobjects[name]).(*pdfStream); ok && form.dict["Subtype"] == pdfName("Form") {
+					formResources := d.dict(form.dict["Resources"])
+					if formResources == nil {
+						formResources = resources
+					}
+					if data, err := d.decodeStream(form); err == nil {
+						d.runContent(data, formResources, w, depth+1)
+					}
+				}
+			}
+		case "ID":
+			l.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+}
+
+type pdfTextWriter struct {
+	b strings.Builder
+}
+
+func (w *pdfTextWriter) last() byte {
+	s := w.b.String()
+	if s == "" {
+		return '\n'
+	}
+	return s[len(s)-1]
+}
+
+func (w *pdfTextWriter) write(s string) {
+	w.b.WriteString(s)
+}
+
+func (w *pdfTextWriter) space() {
+	if c := w.last(); c != ' ' && c != '\n' {
+		w.b.WriteByte(' ')
+	}
+}
+
+func (w *pdfTextWriter) newline() {
+	if w.last() != '\n' {
+		w.b.WriteByte('\n')
+	}
+}
+
+func (w *pdfTextWriter) String() string {
+	lines := strings.Split(w.b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// pdfFont maps the character codes of a font to Unicode text.
+type pdfFont struct {
+	codeBytes int
+	toUnicode map[uint32]string
+	encoding  map[byte]rune
+}
+
+func (d *pdfDocument) font(obj any) *pdfFont {
+	ref, isRef := obj.(pdfRef)
+	if font, ok := d.fonts[ref]; isRef && ok {
+		return font
+	}
+	dict := d.dict(obj)
+	if dict == nil {
+		return nil
+	}
+
+	font := &pdfFont{codeBytes: 1}
+	if dict["Subtype"] == pdfName("Type0") {
+		font.codeBytes = 2
+	}
+	if cmap, ok := d.resolve(dict["ToUnicode"]).(*pdfStream); ok {
+		if data, err := d.decodeStream(cmap); err == nil {
+			font.toUnicode, font.codeBytes = parsePDFCMap(data, font.codeBytes)
+		}
+	}
+	if enc := d.dict(dict["Encoding"]); enc != nil {
+		if diffs, ok := d.resolve(enc["Differences"]).(pdfArray); ok {
+			font.encoding = make(map[byte]rune)
+			code := 0
+			for _, item := range diffs {
+				switch v := item.(type) {
+				case float64:
+					code = int(v)
+				case pdfName:
+					if r, ok := pdfGlyphRune(string(v)); ok && code >= 0 && code < 256 {
+						font.encoding[byte(code)] = r
+					}
+					code++
+				}
+			}
+		}
+	}
+	if isRef {
+		d.fonts[ref] = font
+	}
+	return font
+}
+
+func (f *pdfFont) decode(s pdfString) string {
+	if f == nil {
+		return pdfLatin1(s)
+	}
+	var b strings.Builder
+	if f.codeBytes == 2 {
+		for i := 0; i+1 < len(s); i += 2 {
+			code := uint32(s[i])<<8 | uint32(s[i+1])
+			if text, ok := f.toUnicode[code]; ok {
+				b.WriteString(text)
+			}
+		}
+		return b.String()
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if text, ok := f.toUnicode[uint32(c)]; ok {
+			b.WriteString(text)
+		} else if r, ok := f.encoding[c]; ok {
+			b.WriteRune(r)
+		} else {
+			b.WriteString(pdfLatin1(s[i : i+1]))
+		}
+	}
+	return b.String()
+}
+
+// pdfWinAnsi covers the WinAnsiEncoding codes that differ from Latin-1.
+var pdfWinAnsi = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+	0x88: 'ˆ', 0x89: '‰', 0x8a: 'Š', 0x8b: '‹', 0x8c: 'Œ', 0x8e: 'Ž', 0x91: '‘',
+	0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—', 0x98: '˜',
+	0x99: '™', 0x9a: 'š', 0x9b: '›', 0x9c: 'œ', 0x9e: 'ž', 0x9f: 'Ÿ',
+}
+
+func pdfLatin1(s pdfString) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case pdfWinAnsi[c] != 0:
+			b.WriteRune(pdfWinAnsi[c])
+		case c >= 0x20 || c == '\t' || c == '\n':
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
+
+var pdfGlyphNames = map[string]rune{
+	"space": ' ', "exclam": '!', "quotedbl": '"', "numbersign": '#', "dollar": '$',
+	"percent": '%', "ampersand": '&', "quotesingle": '\'', "quoteright": '’',
+	"quoteleft": '‘', "parenleft": '(', "parenright": ')', "asterisk": '*',
+	"plus": '+', "comma": ',', "hyphen": '-', "minus": '−', "period": '.',
+	"slash": '/', "colon": ':', "semicolon": ';', "less": '<', "equal": '=',
+	"greater": '>', "question": '?', "at": '@', "bracketleft": '[',
+	"backslash": '\\', "bracketright": ']', "underscore": '_', "braceleft": '{',
+	"bar": '|', "braceright": '}', "asciitilde": '~', "bullet": '•',
+	"endash": '–', "emdash": '—', "quotedblleft": '“', "quotedblright": '”',
+	"ellipsis": '…', "fi": 'ﬁ', "fl": 'ﬂ', "ff": 'ﬀ', "ffi": 'ﬃ', "ffl": 'ﬄ',
+	"zero": '0', "one": '1', "two": '2', "three": '3', "four": '4', "five": '5',
+	"six": '6', "seven": '7', "eight": '8', "nine": '9',
+}
+
+func pdfGlyphRune(name string) (rune, bool) {
+	if r, ok := pdfGlyphNames[name]; ok {
+		return r, true
+	}
+	if len(name) == 1 && (name[0] >= 'a' && name[0] <= 'z' || name[0] >= 'A' && name[0] <= 'Z') {
+		return rune(name[0]), true
+	}
+	if hexCode, ok := strings.CutPrefix(name, "uni"); ok && len(hexCode) == 4 {
+		if v, err := strconv.ParseUint(hexCode, 16, 16); err == nil {
+			return rune(v), true
+		}
+	}
+	return 0, false
+}
+
+// parsePDFCMap reads the bfchar and bfrange mappings of a ToUnicode CMap. It
+// also returns the code length declared by the codespace ranges.
+func parsePDFCMap(data []byte, codeBytes int) (map[uint32]string, int) {
+	m := make(map[uint32]string)
+	l := &pdfLexer{data: data}
+	var operands []any
+	section := ""
+	for {
+		obj, err := l.object()
+		if err != nil {
+			break
+		}
+		kw, ok := obj.(pdfKeyword)
+		if !ok {
+			if section != "" {
+				operands = append(operands, obj)
+			}
+			continue
+		}
+		switch kw {
+		case "begincodespacerange", "beginbfchar", "beginbfrange":
+			section = string(kw)
+			operands = operands[:0]
+		case "endcodespacerange":
+			if len(operands) > 0 {
+				if low, ok := operands[0].(pdfString); ok && len(low) > 0 {
+					codeBytes = len(low)
+				}
+			}
+			section = ""
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				dst, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					m[pdfCode(src)] = pdfUTF16(dst)
+				}
+			}
+			section = ""
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 || pdfCode(hi) < pdfCode(lo) || pdfCode(hi)-pdfCode(lo) > 0xffff {
+					continue
+				}
+				start, end := pdfCode(lo), pdfCode(hi)
+				switch dst := operands[i+2].(type) {
+				case pdfString:
+					units := utf16Units(dst)
+					if len(units) == 0 {
+						continue
+					}
+					for code := start; code <= end; code++ {
+						u := slices.Clone(units)
+						u[len(u)-1] += uint16(code - start)
+						m[code] = string(utf16.Decode(u))
+					}
+				case pdfArray:
+					for j, item := range dst {
+						if s, ok := item.(pdfString); ok && start+uint32(j) <= end {
+							m[start+uint32(j)] = pdfUTF16(s)
+						}
+					}
+				}
+			}
+			section = ""
+		}
+	}
+	return m, codeBytes
+}
+
+func pdfCode(s pdfString) uint32 {
+	var code uint32
+	for i := 0; i < len(s) && i < 4; i++ {
+		code = code<<8 | uint32(s[i])
+	}
+	return code
+}
+
+func utf16Units(s pdfString) []uint16 {
+	units := make([]uint16, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	return units
+}
+
+func pdfUTF16(s pdfString) string {
+	return string(utf16.Decode(utf16Units(s)))
+}
+
+// pdfLexer tokenizes PDF object syntax and content streams.
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(c byte) bool {
+	return c == 0 || c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+func isPDFDelim(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isPDFSpace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// token returns the next number, name, string or keyword. Array and
+// dictionary delimiters come back as keywords.
+func (l *pdfLexer) token() (any, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		return pdfName(l.name()), nil
+	case c == '(':
+		l.pos++
+		return l.literalString(), nil
+	case c == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return pdfKeyword("<<"), nil
+		}
+		l.pos++
+		return l.hexString(), nil
+	case c == '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return pdfKeyword(">>"), nil
+		}
+		l.pos++
+		return l.token()
+	case c == '[' || c == ']' || c == '{' || c == '}':
+		l.pos++
+		return pdfKeyword(c), nil
+	case c == ')':
+		l.pos++
+		return l.token()
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+		l.pos++
+	}
+	word := string(l.data[start:l.pos])
+	if f, err := strconv.ParseFloat(word, 64); err == nil && strings.IndexFunc(word, func(r rune) bool {
+		return r != '+' && r != '-' && r != '.' && (r < '0' || r > '9')
+	}) < 0 {
+		return f, nil
+	}
+	return pdfKeyword(word), nil
+}
+
+func (l *pdfLexer) name() string {
+	var b strings.Builder
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFSpace(c) || isPDFDelim(c) {
+			break
+		}
+		if c == '#' && l.pos+2 < len(l.data) {
+			if v, err := strconv.ParseUint(string(l.data[l.pos+1:l.pos+3]), 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				l.pos += 3
+				continue
+			}
+		}
+		b.WriteByte(c)
+		l.pos++
+	}
+	return b.String()
+}
+
+func (l *pdfLexer) literalString() pdfString {
+	var b strings.Builder
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return pdfString(b.String())
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				continue
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				v := int(e - '0')
+				for range 2 {
+					if l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7' {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+				}
+				b.WriteByte(byte(v))
+			default:
+				b.WriteByte(e)
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return pdfString(b.String())
+}
+
+func (l *pdfLexer) hexString() pdfString {
+	end := bytes.IndexByte(l.data[l.pos:], '>')
+	if end < 0 {
+		end = len(l.data) - l.pos
+	}
+	raw := l.data[l.pos : l.pos+end]
+	l.pos = min(len(l.data), l.pos+end+1)
+	decoded, _ := pdfASCIIHexDecode(raw)
+	return pdfString(decoded)
+}
+
+// skipInlineImage moves past the data of an inline image, which starts after
+// the ID operator and ends with EI.
+func (l *pdfLexer) skipInlineImage() {
+	for i := l.pos; i+2 <= len(l.data); i++ {
+		if l.data[i] == 'E' && l.data[i+1] == 'I' && i > 0 && isPDFSpace(l.data[i-1]) &&
+			(i+2 == len(l.data) || isPDFSpace(l.data[i+2])) {
+			l.pos = i + 2
+			return
+		}
+	}
+	l.pos = len(l.data)
+}
+
+// object reads a complete object: arrays and dictionaries are read
+// recursively and "num gen R" becomes a reference.
+func (l *pdfLexer) object() (any, error) {
+	return l.objectDepth(0)
+}
+
+func (l *pdfLexer) objectDepth(depth int) (any, error) {
+	if depth > maxPDFDepth {
+		return nil, errors.New("PDF object nested too deeply")
+	}
+	tok, err := l.token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case pdfKeyword:
+		switch t {
+		case "[":
+			arr := pdfArray{}
+			for {
+				l.skipSpace()
+				if l.pos < len(l.data) && l.data[l.pos] == ']' {
+					l.pos++
+					return arr, nil
+				}
+				item, err := l.objectDepth(depth + 1)
+				if err != nil {
+					return arr, err
+				}
+				arr = append(arr, item)
+			}
+		case "<<":
+			dict := pdfDict{}
+			for {
+				key, err := l.objectDepth(depth + 1)
+				if err != nil {
+					return dict, err
+				}
+				if key == pdfKeyword(">>") {
+					return dict, nil
+				}
+				name, ok := key.(pdfName)
+				if !ok {
+					continue
+				}
+				value, err := l.objectDepth(depth + 1)
+				if err != nil {
+					return dict, err
+				}
+				if value != pdfKeyword(">>") {
+					dict[name] = value
+					continue
+				}
+				return dict, nil
+			}
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	case float64:
+		save := l.pos
+		gen, err1 := l.token()
+		r, err2 := l.token()
+		if g, ok := gen.(float64); ok && err1 == nil && err2 == nil && r == pdfKeyword("R") {
+			return pdfRef{num: int(t), gen: int(g)}, nil
+		}
+		l.pos = save
+	}
+	return tok, nil
+}
@@ -0,0 +1,161 @@
+package tools
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// buildTestPDF assembles a PDF from object bodies; object i+1 is objects[i].
+func buildTestPDF(objects ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.5\n")
+	for i, obj := range objects {
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func testPDFStream(dict string, data []byte) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func deflate(t *testing.T, data string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	_, err := w.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return b.Bytes()
+}
+
+func TestPDFPageText(t *testing.T) {
+	t.Parallel()
+
+	font := "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>"
+	data := buildTestPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 5 0 R] /Count 2 /Resources << /Font << /F1 7 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		testPDFStream("", []byte("BT /F1 12 Tf 72 700 Td (Hello) Tj [(W) 20 (orld) -300 (again)] TJ 0 -14 Td (Second \\(line\\)) Tj ET")),
+		"<< /Type /Page /Parent 2 0 R /Contents [6 0 R] >>",
+		testPDFStream("/Filter /FlateDecode", deflate(t, "BT /F1 12 Tf 1 0 0 1 72 700 Tm (Page) Tj 1 0 0 1 72 680 Tm (two) Tj T* <43616665> Tj ET")),
+		font,
+	)
+
+	doc, err := parsePDF(data)
+	require.NoError(t, err)
+	require.Equal(t, 2, doc.NumPages())
+	require.Equal(t, "HelloWorld again\nSecond (line)", doc.PageText(0))
+	require.Equal(t, "Page\ntwo\nCafe", doc.PageText(1))
+	require.Empty(t, doc.PageText(2))
+}
+
+func TestPDFToUnicode(t *testing.T) {
+	t.Parallel()
+
+	cmap := `/CIDInit /ProcSet findresource begin
+begincmap
+1 begincodespacerange <0000> <FFFF> endcodespacerange
+2 beginbfchar
+<0001> <0048>
+<0002> <00E9>
+endbfchar
+1 beginbfrange
+<0010> <0012> <0061>
+endbfrange
+endcmap`
+	data := buildTestPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		testPDFStream("", []byte("BT /F1 10 Tf <000100020010> Tj [<0011> -500 <0012>] TJ ET")),
+		"<< /Type /Font /Subtype /Type0 /Encoding /Identity-H /ToUnicode 6 0 R >>",
+		testPDFStream("", []byte(cmap)),
+	)
+
+	doc, err := parsePDF(data)
+	require.NoError(t, err)
+	require.Equal(t, "Héab c", doc.PageText(0))
+}
+
+func TestPDFObjectStream(t *testing.T) {
+	t.Parallel()
+
+	// The catalog, page tree and page live in a compressed object stream.
+	var header, objs string
+	for i, obj := range []string{
+		"<< /Type /Catalog /Pages 3 0 R >>",
+		"<< /Type /Pages /Kids [4 0 R] /Count 1 >>",
+		"<< /Type /Page /Contents 1 0 R >>",
+	} {
+		header += fmt.Sprintf("%d %d ", i+2, len(objs))
+		objs += obj + " "
+	}
+	data := buildTestPDF(
+		testPDFStream("", []byte("BT (compressed) Tj ET")),
+		"null", "null", "null",
+		testPDFStream(fmt.Sprintf("/Type /ObjStm /N 3 /First %d /Filter /FlateDecode", len(header)), deflate(t, header+objs)),
+	)
+	// Drop the placeholder definitions so the object stream provides them.
+	data = bytes.Replace(data, []byte("2 0 obj\nnull\nendobj\n3 0 obj\nnull\nendobj\n4 0 obj\nnull\nendobj\n"), nil, 1)
+
+	doc, err := parsePDF(data)
+	require.NoError(t, err)
+	require.Equal(t, 1, doc.NumPages())
+	require.Equal(t, "compressed", doc.PageText(0))
+}
+
+func TestPDFDecodeBudget(t *testing.T) {
+	t.Parallel()
+
+	d := &pdfDocument{budget: 1500}
+	s := &pdfStream{
+		dict: pdfDict{"Filter": pdfName("FlateDecode")},
+		raw:  deflate(t, strings.Repeat(" ", 1000)),
+	}
+	data, err := d.decodeStream(s)
+	require.NoError(t, err)
+	require.Len(t, data, 1000)
+
+	_, err = d.decodeStream(s)
+	require.ErrorIs(t, err, errPDFTooLarge)
+	_, err = d.decodeStream(s)
+	require.ErrorIs(t, err, errPDFTooLarge, "the budget is spent for the whole document")
+}
+
+func TestPDFInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := parsePDF([]byte("just some text"))
+	require.ErrorIs(t, err, errNotPDF)
+
+	_, err = parsePDF([]byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n"))
+	require.ErrorContains(t, err, "no pages")
+}
+
+func TestParsePageRange(t *testing.T) {
+	t.Parallel()
+
+	for spec, want := range map[string][2]int{
+		"3":     {3, 3},
+		"1-5":   {1, 5},
+		" 2 - ": {2, 12},
+		"10-40": {10, 12},
+	} {
+		first, last, err := parsePageRange(spec, 12)
+		require.NoError(t, err, spec)
+		require.Equal(t, want, [2]int{first, last}, spec)
+	}
+
+	for _, spec := range []string{"", "0", "a-b", "5-2", "-3", "13"} {
+		_, _, err := parsePageRange(spec, 12)
+		require.Error(t, err, spec)
+	}
+}
@@ -40,7 +40,7 @@ func getRgSearchCmd(ctx context.Context, pattern, path, include string) *exec.Cm
 		return nil
 	}
 	// Use -n to show line numbers, -0 for null separation to handle Windows paths
-	args := []string{"--json", "-H", "-n", "-0", pattern}
+	args := []string{"--json", "-H", "-n", "-0", "-e", pattern}
 	if include != "" {
 		args = append(args, "--glob", include)
 	}
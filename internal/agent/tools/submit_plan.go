@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/nexora/nexora/internal/task"
+)
+
+const SubmitPlanToolName = "submit_plan"
+
+//go:embed submit_plan.md
+var submitPlanDescription []byte
+
+type SubmitPlanStep struct {
+	Title   string   `json:"title" description:"Short imperative description of the step"`
+	Details string   `json:"details,omitempty" description:"What changes and why"`
+	Files   []string `json:"files,omitempty" description:"Files the step touches, relative to the working directory"`
+}
+
+type SubmitPlanParams struct {
+	Summary string           `json:"summary" description:"What the change does and the approach taken"`
+	Steps   []SubmitPlanStep `json:"steps" description:"Ordered checklist of steps"`
+}
+
+type SubmitPlanResponseMetadata struct {
+	TaskID string `json:"task_id"`
+	Steps  int    `json:"steps"`
+}
+
+func NewSubmitPlanTool(plans task.PlanService) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		SubmitPlanToolName,
+		string(submitPlanDescription),
+		func(ctx context.Context, params SubmitPlanParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for submitting a plan")
+			}
+
+			steps := make([]task.PlanStep, 0, len(params.Steps))
+			for _, step := range params.Steps {
+				steps = append(steps, task.PlanStep(step))
+			}
+			plan, err := plans.Submit(ctx, task.Plan{
+				SessionID: sessionID,
+				Summary:   params.Summary,
+				Steps:     steps,
+			})
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			metadata := SubmitPlanResponseMetadata{TaskID: plan.TaskID, Steps: len(plan.Steps)}
+			result := fmt.Sprintf("Plan with %d steps submitted for approval. Stop here; the user will approve or reject it.", len(plan.Steps))
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(result), metadata), nil
+		})
+}
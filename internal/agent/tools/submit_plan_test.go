@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/nexora/nexora/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmitPlanTool(t *testing.T) {
+	t.Parallel()
+
+	plans := task.NewPlanService(task.NewService())
+	tool := NewSubmitPlanTool(plans)
+
+	resp := runGitTool(t, tool, SubmitPlanParams{
+		Summary: "Add plan mode",
+		Steps: []SubmitPlanStep{
+			{Title: "Add the planner agent", Files: []string{"internal/config/config.go"}},
+			{Title: "Add tests"},
+		},
+	})
+	require.False(t, resp.IsError, resp.Content)
+	assert.Contains(t, resp.Content, "2 steps")
+
+	plan, ok := plans.Get("test-session")
+	require.True(t, ok)
+	assert.Equal(t, task.PlanPending, plan.Status)
+	assert.Equal(t, []string{"internal/config/config.go"}, plan.Steps[0].Files)
+
+	resp = runGitTool(t, tool, SubmitPlanParams{Summary: "Empty"})
+	assert.True(t, resp.IsError)
+}
@@ -2,6 +2,8 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 )
 
 type (
@@ -72,3 +74,33 @@ func GetModelNameFromContext(ctx context.Context) string {
 	}
 	return s
 }
+
+// ResponseAttachment is a file a tool hands to the model next to its text
+// result, such as an image output of a notebook cell.
+type ResponseAttachment struct {
+	MIMEType string `json:"mime_type"`
+	Data     []byte `json:"data"`
+}
+
+// SplitResponseAttachments removes the "attachments" field from a tool's
+// response metadata and returns it separately, so the files are stored once
+// as attachments of the tool result.
+func SplitResponseAttachments(metadata string) (string, []ResponseAttachment) {
+	if !strings.Contains(metadata, `"attachments"`) {
+		return metadata, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(metadata), &fields); err != nil {
+		return metadata, nil
+	}
+	var attachments []ResponseAttachment
+	if err := json.Unmarshal(fields["attachments"], &attachments); err != nil {
+		return metadata, nil
+	}
+	delete(fields, "attachments")
+	stripped, err := json.Marshal(fields)
+	if err != nil {
+		return metadata, nil
+	}
+	return string(stripped), attachments
+}
@@ -29,12 +29,14 @@ type ViewParams struct {
 	FilePath string `json:"file_path" description:"The path to the file to read"`
 	Offset   int    `json:"offset,omitempty" description:"The line number to start reading from (0-based)"`
 	Limit    int    `json:"limit,omitempty" description:"The number of lines to read (defaults to 2000)"`
+	Pages    string `json:"pages,omitempty" description:"Page range for PDF files, e.g. \"3\", \"1-5\" or \"10-\" (max 20 pages per request)"`
 }
 
 type ViewPermissionsParams struct {
 	FilePath string `json:"file_path"`
 	Offset   int    `json:"offset"`
 	Limit    int    `json:"limit"`
+	Pages    string `json:"pages,omitempty"`
 }
 
 type viewTool struct {
@@ -44,8 +46,9 @@ type viewTool struct {
 }
 
 type ViewResponseMetadata struct {
-	FilePath string `json:"file_path"`
-	Content  string `json:"content"`
+	FilePath    string               `json:"file_path"`
+	Content     string               `json:"content"`
+	Attachments []ResponseAttachment `json:"attachments,omitempty"`
 }
 
 const (
@@ -172,9 +175,14 @@ func NewViewTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 			}
 
 			// Check file size
-			if fileInfo.Size() > MaxReadSize {
+			ext := strings.ToLower(filepath.Ext(filePath))
+			maxSize := int64(MaxReadSize)
+			if ext == ".pdf" {
+				maxSize = MaxPDFSize
+			}
+			if fileInfo.Size() > maxSize {
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("File is too large (%d bytes). Maximum size is %d bytes",
-					fileInfo.Size(), MaxReadSize)), nil
+					fileInfo.Size(), maxSize)), nil
 			}
 
 			// Validate offset to prevent negative slice bounds panic
@@ -182,6 +190,13 @@ func NewViewTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 				params.Offset = 0
 			}
 
+			switch ext {
+			case ".pdf":
+				return viewPDF(filePath, params)
+			case ".ipynb":
+				return viewNotebook(ctx, filePath, params)
+			}
+
 			// Smart limit determination:
 			// If no explicit limit provided and no offset, try to read entire file if it fits in context
 			// Otherwise use chunking
@@ -315,6 +330,105 @@ func NewViewTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 		})
 }
 
+// viewPDF returns the text of a range of pages of a PDF file. Without an
+// explicit range it reads from the first page until the token budget is spent.
+func viewPDF(filePath string, params ViewParams) (fantasy.ToolResponse, error) {
+	doc, err := readPDFFile(filePath)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("Cannot read PDF file %s: %v", filePath, err)), nil
+	}
+
+	total := doc.NumPages()
+	first, last := 1, min(total, MaxPDFPages)
+	if params.Pages != "" {
+		first, last, err = parsePageRange(params.Pages, total)
+		if err != nil {
+			return fantasy.NewTextErrorResponse(err.Error()), nil
+		}
+		if last-first+1 > MaxPDFPages {
+			return fantasy.NewTextErrorResponse(fmt.Sprintf("Page range %q spans %d pages. At most %d pages can be read at once",
+				params.Pages, last-first+1, MaxPDFPages)), nil
+		}
+	}
+
+	var content strings.Builder
+	tokens := 0
+	shown := first
+	for page := first; page <= last; page++ {
+		text := doc.PageText(page - 1)
+		if text == "" {
+			text = "(no extractable text; the page may be scanned or contain only images)"
+		}
+		section := fmt.Sprintf("--- Page %d ---\n%s\n", page, text)
+		if params.Pages == "" && page > first && tokens+countTokens(section) > MaxViewTokens {
+			break
+		}
+		tokens += countTokens(section)
+		content.WriteString(section)
+		shown = page
+	}
+
+	output := "<file>\n" + content.String() + "</file>\n"
+	output += fmt.Sprintf("\n\n📄 PDF: %d pages — showing pages %d-%d", total, first, shown)
+	if shown < total {
+		output += fmt.Sprintf("\n💡 Use pages=\"%d-%d\" to read the next pages", shown+1, min(total, shown+MaxPDFPages))
+	}
+	recordFileRead(filePath)
+	return fantasy.WithResponseMetadata(
+		fantasy.NewTextResponse(output),
+		ViewResponseMetadata{
+			FilePath: filePath,
+			Content:  content.String(),
+		},
+	), nil
+}
+
+// viewNotebook renders the cells of a Jupyter notebook with their outputs.
+// Offset and limit select cells; image outputs are attached for models that
+// support images.
+func viewNotebook(ctx context.Context, filePath string, params ViewParams) (fantasy.ToolResponse, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fantasy.ToolResponse{}, fmt.Errorf("error reading notebook: %w", err)
+	}
+	nb, err := parseNotebook(data)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("Cannot read notebook %s: %v", filePath, err)), nil
+	}
+	if params.Offset > 0 && params.Offset >= len(nb.Cells) {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("Cell offset %d is out of range: the notebook has %d cells",
+			params.Offset, len(nb.Cells))), nil
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = len(nb.Cells)
+	}
+	cells, next, attachments := renderNotebook(nb, params.Offset, limit, MaxViewTokens, GetSupportsImagesFromContext(ctx))
+
+	output := fmt.Sprintf("<notebook language=%q cells=\"%d\">\n%s</notebook>\n", nb.language(), len(nb.Cells), cells)
+	if len(nb.Cells) == 0 {
+		output += "\n\n📓 Notebook has no cells"
+	} else {
+		output += fmt.Sprintf("\n\n📓 Notebook: %d cells — showing cells %d-%d", len(nb.Cells), params.Offset, next-1)
+	}
+	if next < len(nb.Cells) {
+		output += fmt.Sprintf("\n💡 Use offset=%d to read the next cells", next)
+	}
+	if len(attachments) > 0 {
+		output += fmt.Sprintf("\n🖼️ %d image output(s) attached", len(attachments))
+	}
+	recordFileRead(filePath)
+	return fantasy.WithResponseMetadata(
+		fantasy.NewTextResponse(output),
+		ViewResponseMetadata{
+			FilePath:    filePath,
+			Content:     cells,
+			Attachments: attachments,
+		},
+	), nil
+}
+
 func addLineNumbers(content string, startLine int) string {
 	if content == "" {
 		return ""
@@ -0,0 +1,595 @@
+package aiops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nexora/nexora/internal/filepathext"
+	"github.com/nexora/nexora/internal/fsext"
+)
+
+// ScriptLanguageBatch is the language of the scripts LocalScriptor compiles:
+// a JSON program of operations it runs in-process instead of in a shell.
+const ScriptLanguageBatch = "batch"
+
+// Names of the tools LocalScriptor batches.
+const (
+	grepToolName = "grep"
+	viewToolName = "view"
+	editToolName = "edit"
+)
+
+const (
+	defaultMinBatchSize = 3
+	defaultMaxOutput    = 256 * 1024
+	defaultViewLimit    = 2000
+	maxGrepMatches      = 100
+	maxBatchFileSize    = 5 * 1024 * 1024
+	maxLineLength       = 2000
+)
+
+// LocalScriptorOptions configures a LocalScriptor.
+type LocalScriptorOptions struct {
+	// WorkingDir resolves relative paths. Only calls on paths inside it
+	// are batched; others run on their own, through their permissions.
+	WorkingDir string
+	// MinBatchSize is the number of similar calls that are batched.
+	MinBatchSize int
+	// MaxOutput caps the consolidated result, in bytes.
+	MaxOutput int
+
+	// OnRead is called with each file a batched view reads, so that it
+	// can be edited afterwards.
+	OnRead func(path string)
+	// Write writes the content of a file that batched edits changed, for
+	// the first of their tool calls, after checking that it may. Edits are
+	// only batched when it is set.
+	Write func(ctx context.Context, callID, path, oldContent, newContent string) error
+}
+
+// LocalScriptor is a Scriptor that runs in-process, without the AIOPS
+// service. It batches grep calls into a single walk of the tree, view calls
+// into a single read of each file, and edit calls into a single write of
+// each file, and reports the results of the batch as one.
+type LocalScriptor struct {
+	opts LocalScriptorOptions
+}
+
+// NewLocalScriptor creates a new local scriptor.
+func NewLocalScriptor(opts LocalScriptorOptions) *LocalScriptor {
+	if opts.MinBatchSize < 2 {
+		opts.MinBatchSize = defaultMinBatchSize
+	}
+	if opts.MaxOutput <= 0 {
+		opts.MaxOutput = defaultMaxOutput
+	}
+	return &LocalScriptor{opts: opts}
+}
+
+// batchProgram is the code of a batch script.
+type batchProgram struct {
+	Greps []grepQuery `json:"greps,omitempty"`
+	Views []viewRange `json:"views,omitempty"`
+	Edits []fileEdits `json:"edits,omitempty"`
+}
+
+type grepQuery struct {
+	CallID    string `json:"call_id"`
+	Root      string `json:"root"`
+	Pattern   string `json:"pattern"`
+	Regex     string `json:"regex"`
+	Include   string `json:"include,omitempty"`
+	FilesOnly bool   `json:"files_only,omitempty"`
+}
+
+type viewRange struct {
+	CallID string `json:"call_id"`
+	Path   string `json:"path"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+}
+
+type fileEdits struct {
+	Path  string     `json:"path"`
+	Edits []fileEdit `json:"edits"`
+}
+
+type fileEdit struct {
+	CallID     string `json:"call_id"`
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+}
+
+// DetectPattern finds the calls that can be batched: at least MinBatchSize
+// edits, views or greps. It returns a nil plan when there are none.
+func (s *LocalScriptor) DetectPattern(ctx context.Context, calls []ToolCall) (*ScriptPlan, error) {
+	groups := make(map[string][]ToolCall)
+	for _, call := range calls {
+		if pattern := s.batchable(call); pattern != "" {
+			groups[pattern] = append(groups[pattern], call)
+		}
+	}
+	for _, pattern := range []string{PatternMultiEdit, PatternMultiView, PatternMultiGrep} {
+		batch := groups[pattern]
+		if len(batch) < s.opts.MinBatchSize {
+			continue
+		}
+		plan := &ScriptPlan{
+			PatternType: pattern,
+			ToolCalls:   batch,
+			Description: describeBatch(pattern, batch),
+		}
+		plan.Estimated.Speedup = float64(len(batch))
+		plan.Estimated.ToolsSaved = len(batch) - 1
+		return plan, nil
+	}
+	return nil, nil
+}
+
+// batchable returns the pattern call can be batched in, if any.
+func (s *LocalScriptor) batchable(call ToolCall) string {
+	p := call.Params
+	switch call.Name {
+	case grepToolName:
+		if stringParam(p, "pattern") == "" || !s.inWorkingDir(stringParam(p, "path")) {
+			return ""
+		}
+		// Options the single walk doesn't implement run on their own.
+		if stringParam(p, "type") != "" || boolParam(p, "multiline") ||
+			intParam(p, "before") > 0 || intParam(p, "after") > 0 ||
+			intParam(p, "context") > 0 || intParam(p, "offset") > 0 ||
+			strings.ContainsAny(stringParam(p, "include"), "{}") {
+			return ""
+		}
+		switch stringParam(p, "output_mode") {
+		case "", "content", "files_with_matches":
+			return PatternMultiGrep
+		}
+	case viewToolName:
+		path := stringParam(p, "file_path")
+		if path == "" || stringParam(p, "pages") != "" || !s.inWorkingDir(path) {
+			return ""
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".png", ".jpg", ".jpeg", ".gif", ".webp", ".bmp", ".pdf", ".ipynb":
+			return ""
+		}
+		return PatternMultiView
+	case editToolName:
+		path := stringParam(p, "file_path")
+		old := stringParam(p, "old_string")
+		if s.opts.Write == nil || path == "" || old == "" || old == stringParam(p, "new_string") || !s.inWorkingDir(path) {
+			return ""
+		}
+		// Tabs copied from the view tool's output need the edit tool's
+		// own matching.
+		if strings.Contains(old, "→") {
+			return ""
+		}
+		return PatternMultiEdit
+	}
+	return ""
+}
+
+// Compile compiles a plan into a batch script.
+func (s *LocalScriptor) Compile(ctx context.Context, plan *ScriptPlan) (*Script, error) {
+	if plan == nil || len(plan.ToolCalls) == 0 {
+		return nil, errors.New("empty script plan")
+	}
+	var program batchProgram
+	for _, call := range plan.ToolCalls {
+		if s.batchable(call) != plan.PatternType {
+			return nil, fmt.Errorf("tool call %s (%s) can't be batched as %s", call.ID, call.Name, plan.PatternType)
+		}
+		p := call.Params
+		switch plan.PatternType {
+		case PatternMultiGrep:
+			regex, err := grepRegex(p)
+			if err != nil {
+				return nil, fmt.Errorf("tool call %s: invalid regex pattern: %w", call.ID, err)
+			}
+			program.Greps = append(program.Greps, grepQuery{
+				CallID:    call.ID,
+				Root:      s.resolve(stringParam(p, "path")),
+				Pattern:   stringParam(p, "pattern"),
+				Regex:     regex,
+				Include:   stringParam(p, "include"),
+				FilesOnly: stringParam(p, "output_mode") == "files_with_matches",
+			})
+		case PatternMultiView:
+			limit := intParam(p, "limit")
+			if limit <= 0 {
+				limit = defaultViewLimit
+			}
+			program.Views = append(program.Views, viewRange{
+				CallID: call.ID,
+				Path:   s.resolve(stringParam(p, "file_path")),
+				Offset: max(intParam(p, "offset"), 0),
+				Limit:  limit,
+			})
+		case PatternMultiEdit:
+			path := s.resolve(stringParam(p, "file_path"))
+			edit := fileEdit{
+				CallID:     call.ID,
+				OldString:  stringParam(p, "old_string"),
+				NewString:  stringParam(p, "new_string"),
+				ReplaceAll: boolParam(p, "replace_all"),
+			}
+			i := slices.IndexFunc(program.Edits, func(f fileEdits) bool { return f.Path == path })
+			if i < 0 {
+				program.Edits = append(program.Edits, fileEdits{Path: path})
+				i = len(program.Edits) - 1
+			}
+			program.Edits[i].Edits = append(program.Edits[i].Edits, edit)
+		default:
+			return nil, fmt.Errorf("unsupported pattern %q", plan.PatternType)
+		}
+	}
+	code, err := json.Marshal(program)
+	if err != nil {
+		return nil, fmt.Errorf("marshal batch: %w", err)
+	}
+	return &Script{Language: ScriptLanguageBatch, Code: string(code), Plan: plan}, nil
+}
+
+// Execute runs a batch script. Edits are applied per file: a file is only
+// written when all of its edits apply.
+func (s *LocalScriptor) Execute(ctx context.Context, script *Script) (*ScriptResult, error) {
+	if script == nil || script.Language != ScriptLanguageBatch {
+		return nil, fmt.Errorf("local scriptor only runs %s scripts", ScriptLanguageBatch)
+	}
+	var program batchProgram
+	if err := json.Unmarshal([]byte(script.Code), &program); err != nil {
+		return nil, fmt.Errorf("parse batch: %w", err)
+	}
+
+	start := time.Now()
+	out := &outputBuffer{max: s.opts.MaxOutput}
+	var failures []string
+	if len(program.Greps) > 0 {
+		if err := s.runGreps(ctx, program.Greps, out); err != nil {
+			return nil, err
+		}
+	}
+	for _, v := range program.Views {
+		if err := s.runView(v, out); err != nil {
+			failures = append(failures, fmt.Sprintf("view %s: %v", v.Path, err))
+		}
+	}
+	for _, f := range program.Edits {
+		if err := s.runEdits(ctx, f, out); err != nil {
+			failures = append(failures, fmt.Sprintf("edit %s: %v", f.Path, err))
+		}
+	}
+
+	result := &ScriptResult{
+		Success:  len(failures) == 0,
+		Output:   out.String(),
+		Duration: time.Since(start).Milliseconds(),
+		Script:   script,
+	}
+	if !result.Success {
+		result.ExitCode = 1
+		result.Error = strings.Join(failures, "\n")
+	}
+	return result, nil
+}
+
+// Report formats a batch result as one tool result for the model.
+func (s *LocalScriptor) Report(result *ScriptResult) string {
+	if result == nil {
+		return ""
+	}
+	description := "Batched operation"
+	if result.Script != nil && result.Script.Plan != nil {
+		description = result.Script.Plan.Description
+	}
+	if result.Success {
+		return fmt.Sprintf("%s (%dms):\n\n%s", description, result.Duration, result.Output)
+	}
+	return fmt.Sprintf("%s, with errors (%dms):\n\n%s\nErrors:\n%s", description, result.Duration, result.Output, result.Error)
+}
+
+// runGreps searches each root once for all the queries on it.
+func (s *LocalScriptor) runGreps(ctx context.Context, queries []grepQuery, out *outputBuffer) error {
+	regexes := make([]*regexp.Regexp, len(queries))
+	for i, q := range queries {
+		re, err := regexp.Compile(q.Regex)
+		if err != nil {
+			return fmt.Errorf("tool call %s: invalid regex pattern: %w", q.CallID, err)
+		}
+		regexes[i] = re
+	}
+	matches := make([][]string, len(queries))
+	files := make([]map[string]bool, len(queries))
+	for i := range files {
+		files[i] = make(map[string]bool)
+	}
+
+	var roots []string
+	for _, q := range queries {
+		if !slices.Contains(roots, q.Root) {
+			roots = append(roots, q.Root)
+		}
+	}
+	for _, root := range roots {
+		walker := fsext.NewFastGlobWalker(root)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err != nil {
+				return nil // Skip unreadable entries
+			}
+			// Like ripgrep, skip ignored and hidden files and directories
+			if path != root && (walker.ShouldSkip(path) || strings.HasPrefix(d.Name(), ".")) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			content, ok := readTextFile(path)
+			if !ok {
+				return nil
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				rel = path
+			}
+			var lines []string
+			for i, q := range queries {
+				if q.Root != root || !includes(q.Include, path, rel) || len(matches[i]) >= maxGrepMatches {
+					continue
+				}
+				if lines == nil {
+					lines = strings.Split(content, "\n")
+				}
+				for n, line := range lines {
+					if !regexes[i].MatchString(line) {
+						continue
+					}
+					files[i][path] = true
+					if q.FilesOnly {
+						break
+					}
+					matches[i] = append(matches[i], fmt.Sprintf("%s:%d: %s", path, n+1, truncateLine(strings.TrimSuffix(line, "\r"))))
+					if len(matches[i]) >= maxGrepMatches {
+						break
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("search %s: %w", root, err)
+		}
+	}
+
+	for i, q := range queries {
+		fmt.Fprintf(out, "## grep %q in %s (tool call %s)\n", q.Pattern, q.Root, q.CallID)
+		switch {
+		case len(files[i]) == 0:
+			out.WriteString("No matches found\n")
+		case q.FilesOnly:
+			paths := make([]string, 0, len(files[i]))
+			for path := range files[i] {
+				paths = append(paths, path)
+			}
+			slices.Sort(paths)
+			out.WriteString(strings.Join(paths, "\n") + "\n")
+		default:
+			out.WriteString(strings.Join(matches[i], "\n") + "\n")
+			if len(matches[i]) >= maxGrepMatches {
+				fmt.Fprintf(out, "(results limited to %d matches)\n", maxGrepMatches)
+			}
+		}
+		out.WriteString("\n")
+	}
+	return nil
+}
+
+// runView reads a range of lines of a file, numbered like the view tool.
+func (s *LocalScriptor) runView(v viewRange, out *outputBuffer) error {
+	content, ok := readTextFile(v.Path)
+	if !ok {
+		fmt.Fprintf(out, "## %s (tool call %s)\nCan't be read in a batch; view it on its own.\n\n", v.Path, v.CallID)
+		return errors.New("not a readable text file")
+	}
+	if s.opts.OnRead != nil {
+		s.opts.OnRead(v.Path)
+	}
+	lines := strings.Split(content, "\n")
+	first := min(v.Offset, len(lines))
+	last := min(first+v.Limit, len(lines))
+	fmt.Fprintf(out, "## %s, lines %d-%d of %d (tool call %s)\n", v.Path, first+1, last, len(lines), v.CallID)
+	for i := first; i < last; i++ {
+		line := strings.ReplaceAll(truncateLine(strings.TrimSuffix(lines[i], "\r")), "\t", "→\t")
+		fmt.Fprintf(out, "%6d|%s\n", i+1, line)
+	}
+	out.WriteString("\n")
+	return nil
+}
+
+// runEdits applies the edits of a file in order and writes it once.
+func (s *LocalScriptor) runEdits(ctx context.Context, f fileEdits, out *outputBuffer) error {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		fmt.Fprintf(out, "## %s: not changed\n%v\n\n", f.Path, err)
+		return err
+	}
+	oldContent, crlf := fsext.ToUnixLineEndings(string(data))
+	newContent := oldContent
+	for i, e := range f.Edits {
+		count := strings.Count(newContent, e.OldString)
+		switch {
+		case count == 0:
+			err = fmt.Errorf("edit %d (tool call %s): old_string not found", i+1, e.CallID)
+		case count > 1 && !e.ReplaceAll:
+			err = fmt.Errorf("edit %d (tool call %s): old_string appears %d times; make it unique or use replace_all", i+1, e.CallID, count)
+		case e.ReplaceAll:
+			newContent = strings.ReplaceAll(newContent, e.OldString, e.NewString)
+		default:
+			newContent = strings.Replace(newContent, e.OldString, e.NewString, 1)
+		}
+		if err != nil {
+			fmt.Fprintf(out, "## %s: not changed, no edit was applied\n%v\n\n", f.Path, err)
+			return err
+		}
+	}
+	if crlf {
+		oldContent, _ = fsext.ToWindowsLineEndings(oldContent)
+		newContent, _ = fsext.ToWindowsLineEndings(newContent)
+	}
+	if err := s.opts.Write(ctx, f.Edits[0].CallID, f.Path, oldContent, newContent); err != nil {
+		fmt.Fprintf(out, "## %s: not changed\n%v\n\n", f.Path, err)
+		return err
+	}
+	fmt.Fprintf(out, "## %s: applied %d edits\n\n", f.Path, len(f.Edits))
+	return nil
+}
+
+// resolve returns the absolute path of a tool call's path parameter.
+func (s *LocalScriptor) resolve(path string) string {
+	if path == "" {
+		return filepath.Clean(s.opts.WorkingDir)
+	}
+	return filepath.Clean(filepathext.SmartJoin(s.opts.WorkingDir, path))
+}
+
+// inWorkingDir reports whether path is inside the working directory.
+func (s *LocalScriptor) inWorkingDir(path string) bool {
+	if s.opts.WorkingDir == "" {
+		return false
+	}
+	rel, err := filepath.Rel(filepath.Clean(s.opts.WorkingDir), s.resolve(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// describeBatch describes a batch for the model.
+func describeBatch(pattern string, calls []ToolCall) string {
+	ids := make([]string, len(calls))
+	for i, call := range calls {
+		ids[i] = call.ID
+	}
+	var what string
+	switch pattern {
+	case PatternMultiGrep:
+		what = fmt.Sprintf("%d grep calls searched in one pass", len(calls))
+	case PatternMultiView:
+		what = fmt.Sprintf("%d view calls read in one pass", len(calls))
+	case PatternMultiEdit:
+		what = fmt.Sprintf("%d edit calls applied in one pass", len(calls))
+	default:
+		what = fmt.Sprintf("%d tool calls batched", len(calls))
+	}
+	return fmt.Sprintf("Batched result of tool calls %s: %s", strings.Join(ids, ", "), what)
+}
+
+// grepRegex returns the regular expression of a grep call.
+func grepRegex(p map[string]any) (string, error) {
+	pattern := stringParam(p, "pattern")
+	if boolParam(p, "literal_text") {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if boolParam(p, "ignore_case") {
+		pattern = "(?i)" + pattern
+	}
+	_, err := regexp.Compile(pattern)
+	return pattern, err
+}
+
+// includes reports whether a file matches a grep include glob.
+func includes(include, path, rel string) bool {
+	if include == "" {
+		return true
+	}
+	if ok, _ := filepath.Match(include, filepath.Base(path)); ok {
+		return true
+	}
+	ok, _ := filepath.Match(include, filepath.ToSlash(rel))
+	return ok
+}
+
+// readTextFile reads a file that is small enough and isn't binary.
+func readTextFile(path string) (string, bool) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxBatchFileSize {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(data, 0) >= 0 {
+		return "", false
+	}
+	return string(data), true
+}
+
+func truncateLine(line string) string {
+	if len(line) > maxLineLength {
+		return line[:maxLineLength] + "..."
+	}
+	return line
+}
+
+func stringParam(p map[string]any, key string) string {
+	s, _ := p[key].(string)
+	return s
+}
+
+func boolParam(p map[string]any, key string) bool {
+	b, _ := p[key].(bool)
+	return b
+}
+
+// intParam returns an integer parameter, which JSON decodes as a float.
+func intParam(p map[string]any, key string) int {
+	switch n := p[key].(type) {
+	case float64:
+		return int(n)
+	case int:
+		return n
+	}
+	return 0
+}
+
+// outputBuffer collects output up to a maximum size.
+type outputBuffer struct {
+	buf       strings.Builder
+	max       int
+	truncated bool
+}
+
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.WriteString(string(p))
+	return len(p), nil
+}
+
+func (b *outputBuffer) WriteString(s string) {
+	if b.truncated {
+		return
+	}
+	if room := b.max - b.buf.Len(); len(s) > room {
+		b.buf.WriteString(s[:max(room, 0)])
+		b.buf.WriteString("\n... (output truncated)\n")
+		b.truncated = true
+		return
+	}
+	b.buf.WriteString(s)
+}
+
+func (b *outputBuffer) String() string {
+	return b.buf.String()
+}
@@ -0,0 +1,200 @@
+package aiops
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func call(id, name string, params map[string]any) ToolCall {
+	return ToolCall{ID: id, Name: name, Params: params}
+}
+
+func runPlan(t *testing.T, s *LocalScriptor, plan *ScriptPlan) *ScriptResult {
+	t.Helper()
+	script, err := s.Compile(context.Background(), plan)
+	require.NoError(t, err)
+	require.Equal(t, ScriptLanguageBatch, script.Language)
+	result, err := s.Execute(context.Background(), script)
+	require.NoError(t, err)
+	return result
+}
+
+func TestLocalScriptorDetectPattern(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocalScriptor(LocalScriptorOptions{WorkingDir: dir})
+	ctx := context.Background()
+
+	plan, err := s.DetectPattern(ctx, []ToolCall{
+		call("1", "grep", map[string]any{"pattern": "foo"}),
+		call("2", "bash", map[string]any{"command": "ls"}),
+		call("3", "grep", map[string]any{"pattern": "bar"}),
+	})
+	require.NoError(t, err)
+	require.Nil(t, plan, "two greps are below the minimum batch size")
+
+	plan, err = s.DetectPattern(ctx, []ToolCall{
+		call("1", "grep", map[string]any{"pattern": "foo"}),
+		call("2", "grep", map[string]any{"pattern": "bar", "multiline": true}),
+		call("3", "grep", map[string]any{"pattern": "baz", "path": "sub"}),
+		call("4", "grep", map[string]any{"pattern": "qux", "include": "*.go"}),
+		call("5", "grep", map[string]any{"pattern": "out", "path": "/etc"}),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, plan)
+	require.Equal(t, PatternMultiGrep, plan.PatternType)
+	ids := make([]string, len(plan.ToolCalls))
+	for i, c := range plan.ToolCalls {
+		ids[i] = c.ID
+	}
+	require.Equal(t, []string{"1", "3", "4"}, ids, "multiline and paths outside the working directory run on their own")
+	require.Equal(t, 2, plan.Estimated.ToolsSaved)
+
+	plan, err = s.DetectPattern(ctx, []ToolCall{
+		call("1", "edit", map[string]any{"file_path": "a.go", "old_string": "a", "new_string": "b"}),
+		call("2", "edit", map[string]any{"file_path": "a.go", "old_string": "c", "new_string": "d"}),
+		call("3", "edit", map[string]any{"file_path": "b.go", "old_string": "e", "new_string": "f"}),
+	})
+	require.NoError(t, err)
+	require.Nil(t, plan, "edits aren't batched without a write hook")
+}
+
+func TestLocalScriptorMultiGrep(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":         "package main\n\nfunc main() {\n\tRun()\n}\n",
+		"run.go":          "package main\n\nfunc Run() {}\n",
+		"docs/readme.md":  "Run the program.\n",
+		".hidden/skip.go": "func Run() {}\n",
+	})
+	s := NewLocalScriptor(LocalScriptorOptions{WorkingDir: dir})
+
+	plan, err := s.DetectPattern(context.Background(), []ToolCall{
+		call("a", "grep", map[string]any{"pattern": "func Run"}),
+		call("b", "grep", map[string]any{"pattern": "run", "ignore_case": true, "include": "*.md"}),
+		call("c", "grep", map[string]any{"pattern": "Run(", "literal_text": true, "output_mode": "files_with_matches"}),
+		call("d", "grep", map[string]any{"pattern": "nothing here"}),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, plan)
+
+	result := runPlan(t, s, plan)
+	require.True(t, result.Success)
+	out := result.Output
+	require.Contains(t, out, filepath.Join(dir, "run.go")+":3: func Run() {}")
+	require.Contains(t, out, filepath.Join(dir, "docs", "readme.md")+":1: Run the program.")
+	require.NotContains(t, out, "skip.go", "hidden directories are skipped")
+	require.Contains(t, out, "No matches found")
+
+	report := s.Report(result)
+	require.True(t, strings.HasPrefix(report, "Batched result of tool calls a, b, c, d: 4 grep calls searched in one pass"))
+}
+
+func TestLocalScriptorMultiView(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.go": "package a\n\nfunc A() {\n\treturn\n}\n",
+		"b.go": "line 1\nline 2\nline 3\nline 4\n",
+		"c.go": "package c\n",
+	})
+	var read []string
+	s := NewLocalScriptor(LocalScriptorOptions{
+		WorkingDir: dir,
+		OnRead:     func(path string) { read = append(read, path) },
+	})
+
+	plan, err := s.DetectPattern(context.Background(), []ToolCall{
+		call("1", "view", map[string]any{"file_path": "a.go"}),
+		call("2", "view", map[string]any{"file_path": filepath.Join(dir, "b.go"), "offset": float64(1), "limit": float64(2)}),
+		call("3", "view", map[string]any{"file_path": "c.go"}),
+		call("4", "view", map[string]any{"file_path": "image.png"}),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, plan)
+	require.Len(t, plan.ToolCalls, 3, "images are viewed on their own")
+
+	result := runPlan(t, s, plan)
+	require.True(t, result.Success)
+	require.Contains(t, result.Output, "     4|→\treturn")
+	require.Contains(t, result.Output, "lines 2-3 of 5")
+	require.Contains(t, result.Output, "     2|line 2\n     3|line 3\n")
+	require.NotContains(t, result.Output, "line 4")
+	require.Len(t, read, 3)
+}
+
+func TestLocalScriptorMultiEdit(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.go": "one\r\ntwo\r\nthree\r\n",
+		"b.go": "alpha beta alpha\n",
+	})
+	written := make(map[string]string)
+	s := NewLocalScriptor(LocalScriptorOptions{
+		WorkingDir: dir,
+		Write: func(ctx context.Context, callID, path, oldContent, newContent string) error {
+			written[path] = newContent
+			return os.WriteFile(path, []byte(newContent), 0o644)
+		},
+	})
+
+	plan, err := s.DetectPattern(context.Background(), []ToolCall{
+		call("1", "edit", map[string]any{"file_path": "a.go", "old_string": "one\ntwo", "new_string": "1\n2"}),
+		call("2", "edit", map[string]any{"file_path": "a.go", "old_string": "three", "new_string": "3"}),
+		call("3", "edit", map[string]any{"file_path": "b.go", "old_string": "alpha", "new_string": "gamma"}),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, plan)
+	require.Equal(t, PatternMultiEdit, plan.PatternType)
+
+	result := runPlan(t, s, plan)
+	require.False(t, result.Success, "an ambiguous edit fails its file")
+	require.Contains(t, result.Error, "appears 2 times")
+
+	data, err := os.ReadFile(filepath.Join(dir, "a.go"))
+	require.NoError(t, err)
+	require.Equal(t, "1\r\n2\r\n3\r\n", string(data), "line endings are kept")
+	data, err = os.ReadFile(filepath.Join(dir, "b.go"))
+	require.NoError(t, err)
+	require.Equal(t, "alpha beta alpha\n", string(data), "a file with a failed edit isn't written")
+	require.Len(t, written, 1)
+}
+
+func TestLocalScriptorEditWriteRefused(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.go": "a b c\n"})
+	s := NewLocalScriptor(LocalScriptorOptions{
+		WorkingDir:   dir,
+		MinBatchSize: 2,
+		Write: func(ctx context.Context, callID, path, oldContent, newContent string) error {
+			return errors.New("permission denied")
+		},
+	})
+
+	plan, err := s.DetectPattern(context.Background(), []ToolCall{
+		call("1", "edit", map[string]any{"file_path": "a.go", "old_string": "a", "new_string": "x"}),
+		call("2", "edit", map[string]any{"file_path": "a.go", "old_string": "b", "new_string": "y"}),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, plan)
+
+	result := runPlan(t, s, plan)
+	require.False(t, result.Success)
+	require.Contains(t, s.Report(result), "permission denied")
+	data, err := os.ReadFile(filepath.Join(dir, "a.go"))
+	require.NoError(t, err)
+	require.Equal(t, "a b c\n", string(data))
+}
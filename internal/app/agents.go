@@ -0,0 +1,22 @@
+package app
+
+import (
+	"log/slog"
+
+	"github.com/nexora/nexora/internal/agent/agents"
+)
+
+// loadCustomAgents registers the markdown agents in the user's and the
+// project's agents directories. Agents that fail to load are skipped.
+func (app *App) loadCustomAgents() {
+	custom, err := agents.LoadCustomAgents(app.config.CustomAgentsDirs()...)
+	if err != nil {
+		slog.Warn("Failed to load some agents", "error", err)
+	}
+	if err := app.config.AddCustomAgents(custom...); err != nil {
+		slog.Warn("Ignoring invalid agents", "error", err)
+	}
+	if len(custom) > 0 {
+		slog.Info("Loaded agents", "count", len(app.config.CustomAgents()))
+	}
+}
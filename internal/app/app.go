@@ -17,6 +17,8 @@ import (
 	"charm.land/fantasy"
 	"github.com/charmbracelet/x/ansi"
 	"github.com/nexora/nexora/internal/agent"
+	"github.com/nexora/nexora/internal/agent/delegation"
+	"github.com/nexora/nexora/internal/agent/recovery"
 	"github.com/nexora/nexora/internal/agent/tools/mcp"
 	"github.com/nexora/nexora/internal/aiops"
 	"github.com/nexora/nexora/internal/config"
@@ -27,12 +29,13 @@ import (
 	"github.com/nexora/nexora/internal/log"
 	"github.com/nexora/nexora/internal/lsp"
 	"github.com/nexora/nexora/internal/message"
+	"github.com/nexora/nexora/internal/modelstats"
 	"github.com/nexora/nexora/internal/permission"
 	"github.com/nexora/nexora/internal/pubsub"
 	"github.com/nexora/nexora/internal/resources"
 	"github.com/nexora/nexora/internal/session"
-	"github.com/nexora/nexora/internal/sessionlog"
 	"github.com/nexora/nexora/internal/shell"
+	"github.com/nexora/nexora/internal/task"
 	"github.com/nexora/nexora/internal/term"
 	"github.com/nexora/nexora/internal/tui/styles"
 	"github.com/nexora/nexora/internal/update"
@@ -47,10 +50,18 @@ type App struct {
 
 	AgentCoordinator    agent.Coordinator
 	BackgroundCompactor *agent.BackgroundCompactor
+	// Windows are the sessions open side by side in the TUI.
+	Windows *agent.MultiSessionCoordinator
 
 	LSPClients      *csync.Map[string, *lsp.Client]
+	autoLSP         autoLSPState
 	AIOPS           aiops.Ops
 	ResourceMonitor *resources.Monitor
+	ModelSpeeds     modelstats.Service
+	Tasks           task.Service
+	Plans           task.PlanService
+	AgentStates     session.AgentStateService
+	RecoveryStats   recovery.StatsService
 
 	config *config.Config
 
@@ -70,6 +81,7 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 	sessions := session.NewService(q)
 	messages := message.NewService(q)
 	files := history.NewService(q, conn)
+	tasks := task.NewService()
 	skipPermissionsRequests := cfg.Permissions != nil && cfg.Permissions.SkipRequests
 	allowedTools := []string{}
 	if cfg.Permissions != nil && cfg.Permissions.AllowedTools != nil {
@@ -77,11 +89,17 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 	}
 
 	app := &App{
-		Sessions:    sessions,
-		Messages:    messages,
-		History:     files,
-		Permissions: permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools),
-		LSPClients:  csync.NewMap[string, *lsp.Client](),
+		Sessions:      sessions,
+		Messages:      messages,
+		History:       files,
+		Permissions:   permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools),
+		LSPClients:    csync.NewMap[string, *lsp.Client](),
+		ModelSpeeds:   modelstats.NewService(q),
+		Tasks:         tasks,
+		Plans:         task.NewPlanService(tasks),
+		AgentStates:   session.NewAgentStateService(q),
+		RecoveryStats: recovery.NewStatsService(q),
+		Windows:       agent.NewMultiSessionCoordinator(sessions, messages),
 		AIOPS: aiops.NewClient(aiops.Config{
 			Enabled:  cfg.AIOPS.Enabled,
 			Endpoint: cfg.AIOPS.Endpoint,
@@ -99,6 +117,7 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 	}
 
 	app.setupEvents()
+	app.setupTelemetry(ctx)
 
 	// Initialize background compactor
 	app.BackgroundCompactor = agent.NewBackgroundCompactor(
@@ -120,6 +139,8 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 
 	// Initialize resource monitor
 	app.ResourceMonitor = resources.NewMonitor(resources.DefaultConfig())
+	// The shells of TMUX panes are children of the TMUX server, not nexora.
+	app.ResourceMonitor.TrackProcesses(shell.TmuxPanePIDs)
 	app.cleanupFuncs = append(app.cleanupFuncs, func() error {
 		app.ResourceMonitor.Stop()
 		return nil
@@ -162,10 +183,55 @@ func (app *App) Config() *config.Config {
 }
 
 // RunNonInteractive runs the application in non-interactive mode with the
-// given prompt, printing to stdout.
-func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt string, quiet bool) error {
+// given prompt and attachments, printing to stdout.
+func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt string, quiet bool, attachments ...message.Attachment) error {
+	_, err := app.runNonInteractive(ctx, output, prompt, quiet, attachments...)
+	return err
+}
+
+// runNonInteractive is RunNonInteractive, also returning the ID of the
+// session it created.
+func (app *App) runNonInteractive(ctx context.Context, output io.Writer, prompt string, quiet bool, attachments ...message.Attachment) (string, error) {
 	slog.Info("Running in non-interactive mode")
 
+	sessionID, err := app.createNonInteractiveSession(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	return sessionID, app.streamNonInteractive(ctx, output, sessionID, quiet, func(ctx context.Context) (*fantasy.AgentResult, error) {
+		return app.AgentCoordinator.Run(ctx, sessionID, prompt, attachments...)
+	})
+}
+
+// createNonInteractiveSession creates the session of a non-interactive run
+// and approves its permission requests.
+func (app *App) createNonInteractiveSession(ctx context.Context, prompt string) (string, error) {
+	const maxPromptLengthForTitle = 100
+	const titlePrefix = "Non-interactive: "
+	var titleSuffix string
+
+	if len(prompt) > maxPromptLengthForTitle {
+		titleSuffix = prompt[:maxPromptLengthForTitle] + "..."
+	} else {
+		titleSuffix = prompt
+	}
+	title := titlePrefix + titleSuffix
+
+	sess, err := app.Sessions.Create(ctx, title)
+	if err != nil {
+		return "", fmt.Errorf("failed to create session for non-interactive mode: %w", err)
+	}
+	slog.Info("Created session for non-interactive run", "session_id", sess.ID)
+
+	// Automatically approve all permission requests for this non-interactive
+	// session.
+	app.Permissions.AutoApproveSession(sess.ID)
+	return sess.ID, nil
+}
+
+// streamNonInteractive calls run and prints the assistant messages of the
+// session to output as they stream in.
+func (app *App) streamNonInteractive(ctx context.Context, output io.Writer, sessionID string, quiet bool, run func(context.Context) (*fantasy.AgentResult, error)) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -196,35 +262,14 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt
 	}
 	defer stopSpinner()
 
-	const maxPromptLengthForTitle = 100
-	const titlePrefix = "Non-interactive: "
-	var titleSuffix string
-
-	if len(prompt) > maxPromptLengthForTitle {
-		titleSuffix = prompt[:maxPromptLengthForTitle] + "..."
-	} else {
-		titleSuffix = prompt
-	}
-	title := titlePrefix + titleSuffix
-
-	sess, err := app.Sessions.Create(ctx, title)
-	if err != nil {
-		return fmt.Errorf("failed to create session for non-interactive mode: %w", err)
-	}
-	slog.Info("Created session for non-interactive run", "session_id", sess.ID)
-
-	// Automatically approve all permission requests for this non-interactive
-	// session.
-	app.Permissions.AutoApproveSession(sess.ID)
-
 	type response struct {
 		result *fantasy.AgentResult
 		err    error
 	}
 	done := make(chan response, 1)
 
-	go func(ctx context.Context, sessionID, prompt string) {
-		result, err := app.AgentCoordinator.Run(ctx, sess.ID, prompt)
+	go func(ctx context.Context) {
+		result, err := run(ctx)
 		if err != nil {
 			done <- response{
 				err: fmt.Errorf("failed to start agent processing stream: %w", err),
@@ -233,7 +278,7 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt
 		done <- response{
 			result: result,
 		}
-	}(ctx, sess.ID, prompt)
+	}(ctx)
 
 	messageEvents := app.Messages.Subscribe(ctx)
 	messageReadBytes := make(map[string]int)
@@ -261,7 +306,7 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt
 			stopSpinner()
 			if result.err != nil {
 				if errors.Is(result.err, context.Canceled) || errors.Is(result.err, agent.ErrRequestCancelled) {
-					slog.Info("Non-interactive: agent processing cancelled", "session_id", sess.ID)
+					slog.Info("Non-interactive: agent processing cancelled", "session_id", sessionID)
 					return nil
 				}
 				return fmt.Errorf("agent processing failed: %w", result.err)
@@ -270,7 +315,7 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt
 
 		case event := <-messageEvents:
 			msg := event.Payload
-			if msg.SessionID == sess.ID && msg.Role == message.Assistant && len(msg.Parts) > 0 {
+			if msg.SessionID == sessionID && msg.Role == message.Assistant && len(msg.Parts) > 0 {
 				stopSpinner()
 
 				content := msg.Content().String()
@@ -308,7 +353,10 @@ func (app *App) setupEvents() {
 	setupSubscriber(ctx, app.serviceEventsWG, "permissions", app.Permissions.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "permissions-notifications", app.Permissions.SubscribeNotifications, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "history", app.History.Subscribe, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "plans", app.Plans.Subscribe, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "agent-states", app.AgentStates.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "mcp", mcp.SubscribeEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "delegates", delegation.SubscribeEvents, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "lsp", SubscribeLSPEvents, app.events)
 	cleanupFunc := func() error {
 		cancel()
@@ -356,21 +404,9 @@ func (app *App) InitCoderAgent(ctx context.Context) error {
 	if coderAgentCfg.ID == "" {
 		return fmt.Errorf("coder agent configuration is missing")
 	}
+	app.loadCustomAgents()
 	var err error
-	// Initialize session logging manager
-	sessionLogMgr, err := sessionlog.NewManager(sessionlog.Config{
-		PostgresConnStr: os.Getenv("NEXORA_SESSION_LOG_DSN"),
-		InstanceID:      os.Getenv("NEXORA_INSTANCE_ID"),
-		Enabled:         os.Getenv("NEXORA_SESSION_LOG_ENABLED") != "false",
-		BatchSize:       50,
-		BatchTimeout:    100 * time.Millisecond,
-	})
-	if err != nil {
-		slog.Warn("Failed to initialize session logging, continuing without it", "error", err)
-		sessionLogMgr = nil
-	} else {
-		sessionlog.SetGlobalManager(sessionLogMgr)
-	}
+	sessionLogMgr := app.setupSessionLog()
 
 	app.AgentCoordinator, err = agent.NewCoordinator(
 		ctx,
@@ -384,11 +420,16 @@ func (app *App) InitCoderAgent(ctx context.Context) error {
 		sessionLogMgr,
 		app.ResourceMonitor,
 		app.BackgroundCompactor,
+		app.ModelSpeeds,
+		app.Plans,
+		app.AgentStates,
+		app.RecoveryStats,
 	)
 	if err != nil {
 		slog.Error("Failed to create coder agent", "err", err)
 		return err
 	}
+	app.Windows.SetCoordinator(app.AgentCoordinator)
 	return nil
 }
 
@@ -510,13 +551,14 @@ func (app *App) GetStreaming() bool {
 }
 
 func (app *App) GetVimMode() bool {
-	// VimMode is not yet implemented, return false for now
-	return false
+	if app.config == nil || app.config.Options == nil || app.config.Options.TUI == nil {
+		return false
+	}
+	return app.config.Options.TUI.VimMode
 }
 
 func (app *App) GetAutoLSP() bool {
-	// AutoLSP detection is not yet implemented, return false for now
-	return false
+	return autoLSPEnabled(app.config)
 }
 
 func (app *App) SetAutoApprove(enabled bool) {
@@ -546,9 +588,34 @@ func (app *App) SetStreaming(enabled bool) {
 }
 
 func (app *App) SetVimMode(enabled bool) {
-	// VimMode toggle not implemented yet - no-op
+	if app.config == nil {
+		return
+	}
+	if err := app.config.SetVimMode(enabled); err != nil {
+		slog.Error("Failed to save vim mode setting", "error", err)
+	}
 }
 
 func (app *App) SetAutoLSP(enabled bool) {
-	// AutoLSP toggle not implemented yet - no-op
+	if app.config == nil {
+		return
+	}
+	if app.config.Options == nil {
+		app.config.Options = &config.Options{}
+	}
+	app.config.Options.AutoLSP = enabled
+	if err := app.config.SetConfigField("options.auto_lsp", enabled); err != nil {
+		slog.Error("Failed to save Auto-LSP setting", "error", err)
+	}
+	if enabled {
+		app.startAutoLSP(app.globalCtx)
+		// Give the agent the LSP tools
+		if app.AgentCoordinator != nil {
+			if err := app.UpdateAgentModel(app.globalCtx); err != nil {
+				slog.Error("Failed to update the agent tools", "error", err)
+			}
+		}
+	} else {
+		app.stopAutoLSP()
+	}
 }
@@ -0,0 +1,195 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/nexora/nexora/internal/agent/delegation"
+	"github.com/nexora/nexora/internal/message"
+	"github.com/nexora/nexora/internal/pubsub"
+	"github.com/nexora/nexora/internal/stringext"
+)
+
+// HeadlessOptions are the files of a headless run, through which a delegate
+// exchanges its prompt, status and result with the session that started it.
+type HeadlessOptions struct {
+	PromptFile string
+	OutputFile string
+	StatusFile string // Optional
+	Agent      string // User-defined agent to run the prompt, if any
+
+	// ParentSession and TaskID identify the delegated task of the run. When
+	// set, the run gets a task session under the session that delegated it.
+	ParentSession string
+	TaskID        string
+}
+
+// RunHeadless runs the prompt of PromptFile in a new session, printing the
+// response to output as it streams in. The status of the run is kept in
+// StatusFile as tools are called, and the final response is written to
+// OutputFile.
+func (app *App) RunHeadless(ctx context.Context, output io.Writer, opts HeadlessOptions) error {
+	now := time.Now()
+	status := delegation.Status{
+		State:        delegation.TaskStatusRunning,
+		StartedAt:    now,
+		LastActivity: now,
+		Heartbeat:    now,
+	}
+	fail := func(err error) error {
+		status.State = delegation.TaskStatusFailed
+		status.Error = err.Error()
+		app.writeHeadlessStatus(opts, status)
+		return err
+	}
+
+	data, err := os.ReadFile(opts.PromptFile)
+	if err != nil {
+		return fail(fmt.Errorf("failed to read prompt: %w", err))
+	}
+	prompt := strings.TrimSpace(string(data))
+	if prompt == "" {
+		return fail(errors.New("the prompt file is empty"))
+	}
+	if app.AgentCoordinator == nil {
+		return fail(errors.New("agent configuration is missing"))
+	}
+
+	sessionID, err := app.createHeadlessSession(ctx, opts, prompt)
+	if err != nil {
+		return fail(err)
+	}
+	status.SessionID = sessionID
+	app.writeHeadlessStatus(opts, status)
+
+	// Track tool calls in the status until the run is done
+	trackCtx, stopTracking := context.WithCancel(ctx)
+	tracked := make(chan delegation.Status, 1)
+	go func() {
+		tracked <- app.trackHeadlessStatus(trackCtx, opts, status)
+	}()
+	runErr := app.streamNonInteractive(ctx, output, sessionID, true, func(ctx context.Context) (*fantasy.AgentResult, error) {
+		if opts.Agent != "" {
+			return app.AgentCoordinator.RunAgent(ctx, opts.Agent, sessionID, prompt)
+		}
+		return app.AgentCoordinator.Run(ctx, sessionID, prompt)
+	})
+	stopTracking()
+	status = <-tracked
+	status.LastActivity = time.Now()
+
+	if sess, err := app.Sessions.Get(ctx, sessionID); err == nil {
+		status.Cost = sess.Cost
+	}
+	if runErr != nil {
+		return fail(runErr)
+	}
+	response, err := app.finalResponse(ctx, sessionID)
+	if err != nil {
+		return fail(err)
+	}
+	if err := os.WriteFile(opts.OutputFile, []byte(response), 0o600); err != nil {
+		return fail(fmt.Errorf("failed to write result: %w", err))
+	}
+	status.State = delegation.TaskStatusCompleted
+	app.writeHeadlessStatus(opts, status)
+	return nil
+}
+
+// createHeadlessSession creates the session of a headless run: a task session
+// under the delegating session for delegated tasks, or else a non-interactive
+// session.
+func (app *App) createHeadlessSession(ctx context.Context, opts HeadlessOptions, prompt string) (string, error) {
+	if opts.ParentSession == "" || opts.TaskID == "" {
+		return app.createNonInteractiveSession(ctx, prompt)
+	}
+
+	// The delegating session wrote the task description in the status file
+	description := prompt
+	if written, err := delegation.ReadStatus(opts.StatusFile); err == nil && written.Description != "" {
+		description = written.Description
+	}
+	title := stringext.Truncate("Delegated: "+description, 50)
+	sessionID := app.Sessions.CreateAgentToolSessionID(opts.ParentSession, opts.TaskID)
+	sess, err := app.Sessions.CreateTaskSession(ctx, sessionID, opts.ParentSession, title)
+	if err != nil {
+		return "", fmt.Errorf("failed to create delegate session: %w", err)
+	}
+
+	// Automatically approve all permission requests for the delegated task
+	app.Permissions.AutoApproveSession(sess.ID)
+	return sess.ID, nil
+}
+
+// trackHeadlessStatus records the tool calls of a headless run in its status
+// until ctx is done, and returns the last status. Tool results and streamed
+// responses are progress; the heartbeat only shows the run is alive.
+func (app *App) trackHeadlessStatus(ctx context.Context, opts HeadlessOptions, status delegation.Status) delegation.Status {
+	events := app.Messages.Subscribe(ctx)
+	ticker := time.NewTicker(delegation.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status
+		case <-ticker.C:
+			status.Heartbeat = time.Now()
+		case event, ok := <-events:
+			if !ok {
+				return status
+			}
+			msg := event.Payload
+			if msg.SessionID != status.SessionID {
+				continue
+			}
+			switch {
+			case event.Type == pubsub.CreatedEvent && msg.Role == message.Tool:
+				for _, result := range msg.ToolResults() {
+					status.ToolCalls++
+					status.LastTool = result.Name
+				}
+				status.LastActivity = time.Now()
+			case msg.Role == message.Assistant:
+				// Streamed content is written with the next heartbeat
+				status.LastActivity = time.Now()
+				continue
+			default:
+				continue
+			}
+		}
+		app.writeHeadlessStatus(opts, status)
+	}
+}
+
+// finalResponse returns the text of the last response of a session.
+func (app *App) finalResponse(ctx context.Context, sessionID string) (string, error) {
+	msgs, err := app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to list messages: %w", err)
+	}
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role != message.Assistant {
+			continue
+		}
+		if text := strings.TrimSpace(msgs[i].Content().Text); text != "" {
+			return text, nil
+		}
+	}
+	return "", errors.New("the agent produced no response")
+}
+
+func (app *App) writeHeadlessStatus(opts HeadlessOptions, status delegation.Status) {
+	if opts.StatusFile == "" {
+		return
+	}
+	if err := delegation.WriteStatus(opts.StatusFile, status); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write status: %v\n", err)
+	}
+}
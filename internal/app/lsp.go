@@ -18,6 +18,9 @@ func (app *App) initLSPClients(ctx context.Context) {
 		}
 		go app.createAndStartLSPClient(ctx, name, clientConfig)
 	}
+	if autoLSPEnabled(app.config) {
+		app.startAutoLSP(ctx)
+	}
 	slog.Info("LSP clients initialization started in background")
 }
 
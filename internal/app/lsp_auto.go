@@ -0,0 +1,227 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/lsp"
+	"github.com/nexora/nexora/internal/permission"
+	"github.com/nexora/nexora/internal/pubsub"
+)
+
+// lspInstallToolName identifies LSP server installs in permission requests.
+const lspInstallToolName = "lsp_install"
+
+// autoLSPState tracks the servers started by Auto-LSP.
+type autoLSPState struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	// handled holds the servers already started, declined or failed, so
+	// each is only tried once while Auto-LSP is on.
+	handled map[string]bool
+	clients []string
+}
+
+// startAutoLSP starts servers for the languages detected in the working
+// directory and keeps picking up new languages until stopAutoLSP is called.
+func (app *App) startAutoLSP(ctx context.Context) {
+	app.autoLSP.mu.Lock()
+	defer app.autoLSP.mu.Unlock()
+	if app.autoLSP.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	app.autoLSP.cancel = cancel
+	app.autoLSP.handled = make(map[string]bool)
+	go app.watchAutoLSP(ctx)
+	slog.Info("Auto-LSP started")
+}
+
+// stopAutoLSP stops watching for new languages and shuts down the servers
+// Auto-LSP started.
+func (app *App) stopAutoLSP() {
+	app.autoLSP.mu.Lock()
+	if app.autoLSP.cancel == nil {
+		app.autoLSP.mu.Unlock()
+		return
+	}
+	app.autoLSP.cancel()
+	clients := app.autoLSP.clients
+	app.autoLSP.cancel, app.autoLSP.clients = nil, nil
+	app.autoLSP.mu.Unlock()
+
+	app.stopAutoLSPClients(clients)
+	slog.Info("Auto-LSP stopped")
+}
+
+func (app *App) stopAutoLSPClients(clients []string) {
+	for _, name := range clients {
+		client, ok := app.LSPClients.Take(name)
+		if !ok {
+			continue
+		}
+		shutdownCtx, cancel := context.WithTimeout(app.globalCtx, 5*time.Second)
+		err := client.Close(shutdownCtx)
+		cancel()
+		if err != nil {
+			slog.Error("Failed to shutdown LSP client", "name", name, "error", err)
+		}
+		updateLSPState(name, lsp.StateDisabled, nil, nil, 0)
+	}
+}
+
+func (app *App) watchAutoLSP(ctx context.Context) {
+	workingDir := app.config.WorkingDir()
+	for _, lang := range lsp.DetectLanguagesFromDirectory(workingDir) {
+		app.ensureAutoLSP(ctx, "", lang, true)
+	}
+
+	// Root markers appear in the working directory, while files the agent
+	// creates may be anywhere below it.
+	var fsEvents <-chan fsnotify.Event
+	var fsErrors <-chan error
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		defer watcher.Close()
+		if err = watcher.Add(workingDir); err == nil {
+			fsEvents, fsErrors = watcher.Events, watcher.Errors
+		}
+	}
+	if err != nil {
+		slog.Warn("Auto-LSP cannot watch the working directory", "error", err)
+	}
+	files := app.History.Subscribe(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-fsEvents:
+			if !ok {
+				fsEvents = nil
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				app.detectAutoLSP(ctx, "", event.Name)
+			}
+		case err, ok := <-fsErrors:
+			if !ok {
+				fsErrors = nil
+				continue
+			}
+			slog.Debug("Auto-LSP watcher error", "error", err)
+		case event, ok := <-files:
+			if !ok {
+				files = nil
+				continue
+			}
+			if event.Type == pubsub.CreatedEvent {
+				app.detectAutoLSP(ctx, event.Payload.SessionID, event.Payload.Path)
+			}
+		}
+	}
+}
+
+// detectAutoLSP starts the server for the language of a new file, created
+// by the agent of sessionID or, without a session, outside of nexora.
+func (app *App) detectAutoLSP(ctx context.Context, sessionID, path string) {
+	if lsp.IsProjectMarker(filepath.Base(path)) {
+		for _, lang := range lsp.DetectLanguagesFromDirectory(app.config.WorkingDir()) {
+			app.ensureAutoLSP(ctx, sessionID, lang, true)
+		}
+		return
+	}
+	if lang := lsp.DetectLanguage(path); lang != "" {
+		app.ensureAutoLSP(ctx, sessionID, lang, false)
+	}
+}
+
+// ensureAutoLSP starts the server for lang unless it is configured
+// explicitly, offering to install it first when it is missing. The install
+// is asked for in sessionID, so a missing server waits for a session to
+// work on a file of its language.
+func (app *App) ensureAutoLSP(ctx context.Context, sessionID, lang string, withRootMarkers bool) {
+	cfg, ok := lsp.AutoConfig(lang, withRootMarkers)
+	if !ok {
+		return
+	}
+	name := cfg.Command
+
+	app.autoLSP.mu.Lock()
+	if app.autoLSP.handled == nil || app.autoLSP.handled[name] {
+		app.autoLSP.mu.Unlock()
+		return
+	}
+	app.autoLSP.handled[name] = true
+	app.autoLSP.mu.Unlock()
+
+	if app.hasLSPConfig(name) {
+		return
+	}
+
+	if _, found := lsp.FindLSPServer(lang); !found {
+		if sessionID == "" {
+			app.autoLSP.mu.Lock()
+			delete(app.autoLSP.handled, name)
+			app.autoLSP.mu.Unlock()
+			slog.Debug("LSP server missing, waiting for a session to offer its install", "language", lang, "server", name)
+			return
+		}
+		if err := lsp.InstallLSPServer(ctx, lang, app.confirmLSPInstall(sessionID, lang)); err != nil {
+			slog.Warn("LSP server not available", "language", lang, "server", name, "error", err)
+			updateLSPState(name, lsp.StateError, err, nil, 0)
+			return
+		}
+		slog.Info("Installed LSP server", "language", lang, "server", name)
+	}
+
+	app.createAndStartLSPClient(ctx, name, cfg)
+	if _, ok := app.LSPClients.Get(name); ok {
+		// Auto-LSP may have been turned off while the server was starting.
+		app.autoLSP.mu.Lock()
+		stopped := ctx.Err() != nil
+		if !stopped {
+			app.autoLSP.clients = append(app.autoLSP.clients, name)
+		}
+		app.autoLSP.mu.Unlock()
+		if stopped {
+			app.stopAutoLSPClients([]string{name})
+		}
+	}
+}
+
+// hasLSPConfig reports whether the user configured a server named name or
+// running the same command, including disabled ones.
+func (app *App) hasLSPConfig(name string) bool {
+	for configured, cfg := range app.config.LSP {
+		if configured == name || cfg.Command == name {
+			return true
+		}
+	}
+	return false
+}
+
+// confirmLSPInstall asks for permission to install a server through the
+// permission service, so the TUI shows its usual dialog in sessionID.
+func (app *App) confirmLSPInstall(sessionID, lang string) lsp.ConfirmFunc {
+	return func(prompt string) bool {
+		return app.Permissions.Request(permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			ToolCallID:  "lsp-install-" + lang,
+			ToolName:    lspInstallToolName,
+			Action:      "install",
+			Path:        app.config.WorkingDir(),
+			Description: prompt,
+			Params:      lsp.GetInstallCommand(lang),
+		})
+	}
+}
+
+func autoLSPEnabled(cfg *config.Config) bool {
+	return cfg != nil && cfg.Options != nil && cfg.Options.AutoLSP
+}
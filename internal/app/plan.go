@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"charm.land/fantasy"
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/message"
+	"github.com/nexora/nexora/internal/task"
+)
+
+// ErrNoPlanSubmitted is returned when a planning turn ends without the
+// planner submitting a plan.
+var ErrNoPlanSubmitted = errors.New("the planner did not submit a plan")
+
+// Plan runs prompt with the read-only planner agent. The plan it submits is
+// published by Plans for the user to approve.
+func (app *App) Plan(ctx context.Context, sessionID, prompt string, attachments ...message.Attachment) (*fantasy.AgentResult, error) {
+	if app.AgentCoordinator == nil {
+		return nil, fmt.Errorf("agent configuration is missing")
+	}
+	return app.AgentCoordinator.RunAgent(ctx, config.AgentPlanner, sessionID, prompt, attachments...)
+}
+
+// ExecutePlan approves the pending plan of the session and hands it to the
+// coder, with its full set of tools, to carry out.
+func (app *App) ExecutePlan(ctx context.Context, sessionID string) (*fantasy.AgentResult, error) {
+	if app.AgentCoordinator == nil {
+		return nil, fmt.Errorf("agent configuration is missing")
+	}
+	plan, err := app.Plans.Approve(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return app.AgentCoordinator.Run(ctx, sessionID, plan.Prompt())
+}
+
+// RunPlanNonInteractive runs prompt in plan mode and prints the resulting
+// plan. The plan is executed when approve accepts it; a nil approve only
+// prints it. The attachments are sent to the planner with the prompt.
+func (app *App) RunPlanNonInteractive(ctx context.Context, output io.Writer, prompt string, quiet bool, approve func(task.Plan) bool, attachments ...message.Attachment) error {
+	sessionID, err := app.createNonInteractiveSession(ctx, prompt)
+	if err != nil {
+		return err
+	}
+	err = app.streamNonInteractive(ctx, output, sessionID, quiet, func(ctx context.Context) (*fantasy.AgentResult, error) {
+		return app.Plan(ctx, sessionID, prompt, attachments...)
+	})
+	if err != nil {
+		return err
+	}
+
+	plan, ok := app.Plans.Get(sessionID)
+	if !ok || plan.Status != task.PlanPending {
+		return ErrNoPlanSubmitted
+	}
+	fmt.Fprintf(output, "\nPlan:\n\n%s\n\n", plan.Checklist())
+
+	if approve == nil || !approve(plan) {
+		if _, err := app.Plans.Reject(ctx, sessionID); err != nil {
+			return err
+		}
+		fmt.Fprintln(output, "Plan not approved; nothing was changed.")
+		return nil
+	}
+	return app.streamNonInteractive(ctx, output, sessionID, quiet, func(ctx context.Context) (*fantasy.AgentResult, error) {
+		return app.ExecutePlan(ctx, sessionID)
+	})
+}
@@ -0,0 +1,23 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/nexora/nexora/internal/replay"
+)
+
+// RunRecorded runs prompt like RunNonInteractive and returns the tool calls
+// the agent made, in order, with their results.
+func (app *App) RunRecorded(ctx context.Context, output io.Writer, prompt string, quiet bool) ([]replay.ToolCall, error) {
+	sessionID, runErr := app.runNonInteractive(ctx, output, prompt, quiet)
+	if sessionID == "" {
+		return nil, runErr
+	}
+	msgs, err := app.Messages.List(context.WithoutCancel(ctx), sessionID)
+	if err != nil {
+		return nil, errors.Join(runErr, err)
+	}
+	return replay.ToolCallsFromMessages(msgs), runErr
+}
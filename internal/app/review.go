@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/git"
+	"github.com/nexora/nexora/internal/review"
+)
+
+// ErrNothingToReview is returned when the branch has no changes against its
+// base.
+var ErrNothingToReview = errors.New("no changes to review")
+
+// Review runs the reviewer agent over the changes between the merge base of
+// base and HEAD and the working tree, and returns its findings.
+func (app *App) Review(ctx context.Context, base string) (review.Report, error) {
+	if app.AgentCoordinator == nil {
+		return review.Report{}, fmt.Errorf("agent configuration is missing")
+	}
+
+	repo, err := git.Open(ctx, app.config.WorkingDir())
+	if err != nil {
+		return review.Report{}, err
+	}
+	head, err := repo.CurrentBranch(ctx)
+	if err != nil || head == "" {
+		head = "HEAD"
+	}
+	mergeBase, err := repo.MergeBase(ctx, base, "HEAD")
+	if err != nil {
+		return review.Report{}, fmt.Errorf("failed to find merge base with %s: %w", base, err)
+	}
+	diff, err := repo.Diff(ctx, git.DiffOptions{Base: mergeBase})
+	if err != nil {
+		return review.Report{}, err
+	}
+
+	report := review.Report{Base: base, MergeBase: mergeBase, Head: head}
+	if len(diff.Files) == 0 {
+		return report, ErrNothingToReview
+	}
+
+	sess, err := app.Sessions.Create(ctx, fmt.Sprintf("Review: %s against %s", head, base))
+	if err != nil {
+		return report, fmt.Errorf("failed to create review session: %w", err)
+	}
+	// The reviewer only has read-only tools; approving its requests keeps
+	// view working for files outside the working directory.
+	app.Permissions.AutoApproveSession(sess.ID)
+	slog.Info("Reviewing changes", "session_id", sess.ID, "base", base, "files", len(diff.Files))
+
+	result, err := app.AgentCoordinator.RunAgent(ctx, config.AgentReviewer, sess.ID, review.Prompt(base, head, diff))
+	if err != nil {
+		return report, fmt.Errorf("review failed: %w", err)
+	}
+
+	report.Summary, report.Findings, err = review.ParseResponse(result.Response.Content.Text())
+	if err != nil {
+		return report, err
+	}
+	return report, nil
+}
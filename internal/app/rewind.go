@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nexora/nexora/internal/history"
+	"github.com/nexora/nexora/internal/message"
+)
+
+// PlanRewind computes the file changes needed to roll the working tree back
+// to the state before the last turns user turns of a session.
+func (app *App) PlanRewind(ctx context.Context, sessionID string, turns int) (history.RewindPlan, error) {
+	if turns < 1 {
+		return history.RewindPlan{}, fmt.Errorf("number of turns must be at least 1, got %d", turns)
+	}
+
+	msgs, err := app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return history.RewindPlan{}, fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	var userMsgs []int
+	for i, msg := range msgs {
+		if msg.Role == message.User {
+			userMsgs = append(userMsgs, i)
+		}
+	}
+	if len(userMsgs) < turns {
+		return history.RewindPlan{}, fmt.Errorf("session only has %d turns", len(userMsgs))
+	}
+
+	return history.PlanRewind(ctx, app.History, sessionID, RewindPoint(msgs, userMsgs[len(userMsgs)-turns]))
+}
+
+// RewindPoint returns the rewind point at msgs[i], where msgs are the
+// messages of a session in the order they were created.
+func RewindPoint(msgs []message.Message, i int) history.RewindPoint {
+	point := history.RewindPoint{Since: msgs[i].CreatedAt}
+	for _, msg := range msgs[i:] {
+		point.MessageIDs = append(point.MessageIDs, msg.ID)
+	}
+	return point
+}
+
+// ApplyRewind restores the files in plan. See [history.ApplyRewind].
+func (app *App) ApplyRewind(ctx context.Context, plan history.RewindPlan, force bool) error {
+	if app.AgentCoordinator != nil && app.AgentCoordinator.IsSessionBusy(plan.SessionID) {
+		return fmt.Errorf("cannot rewind while the agent is working on this session")
+	}
+	return history.ApplyRewind(ctx, app.History, plan, force)
+}
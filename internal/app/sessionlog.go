@@ -0,0 +1,80 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/sessionlog"
+)
+
+// setupSessionLog creates the session log manager from the session_log
+// options, or from the environment when they are missing, and closes it on
+// shutdown. It returns nil when session logging is off.
+func (app *App) setupSessionLog() *sessionlog.Manager {
+	cfg := sessionlog.ConfigFromEnv()
+	if opts := app.config.Options.SessionLog; opts != nil {
+		cfg = app.sessionLogConfig(*opts)
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+
+	mgr, err := sessionlog.NewManager(cfg)
+	if err != nil {
+		slog.Warn("Failed to initialize session logging, continuing without it", "error", err)
+		return nil
+	}
+	sessionlog.SetGlobalManager(mgr)
+	app.cleanupFuncs = append(app.cleanupFuncs, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return mgr.Close(ctx)
+	})
+	return mgr
+}
+
+func (app *App) sessionLogConfig(opts config.SessionLog) sessionlog.Config {
+	dataDir := app.config.Options.DataDirectory
+	sinks := opts.Sinks
+	if len(sinks) == 0 {
+		sinks = []config.SessionLogSink{{Type: sessionlog.SinkSQLite}}
+	}
+
+	cfg := sessionlog.Config{
+		InstanceID: cmp.Or(opts.InstanceID, os.Getenv("NEXORA_INSTANCE_ID")),
+		Enabled:    opts.Enabled,
+	}
+	for _, s := range sinks {
+		sc := sessionlog.SinkConfig{
+			Type:       s.Type,
+			Path:       s.Path,
+			MaxSizeMB:  s.MaxSizeMB,
+			MaxBackups: s.MaxBackups,
+			MaxAgeDays: s.MaxAgeDays,
+			Compress:   s.Compress,
+		}
+		switch s.Type {
+		case sessionlog.SinkPostgres:
+			dsn, err := s.ResolvedDSN()
+			if err != nil {
+				slog.Error("error resolving session log DSN variable", "error", err)
+				continue
+			}
+			sc.DSN = dsn
+		case sessionlog.SinkSQLite:
+			sc.Path = cmp.Or(sc.Path, "session_log.db")
+		case sessionlog.SinkJSONL:
+			sc.Path = cmp.Or(sc.Path, filepath.Join("session_log", "session.jsonl"))
+		}
+		if sc.Path != "" && !filepath.IsAbs(sc.Path) {
+			sc.Path = filepath.Join(dataDir, sc.Path)
+		}
+		cfg.Sinks = append(cfg.Sinks, sc)
+	}
+	return cfg
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"os"
+	"time"
+
+	"github.com/nexora/nexora/internal/telemetry"
+	"github.com/nexora/nexora/internal/version"
+)
+
+// setupTelemetry starts exporting traces and metrics when the config enables
+// it, and flushes them on shutdown.
+func (app *App) setupTelemetry(ctx context.Context) {
+	opts := app.config.Options.Telemetry
+	if opts == nil || !opts.Enabled {
+		return
+	}
+	attrs := make(map[string]string, len(opts.ResourceAttributes)+1)
+	if instanceID := os.Getenv("NEXORA_INSTANCE_ID"); instanceID != "" {
+		attrs["service.instance.id"] = instanceID
+	}
+	maps.Copy(attrs, opts.ResourceAttributes)
+	shutdown, err := telemetry.Setup(ctx, telemetry.Config{
+		Endpoint:           opts.Endpoint,
+		Protocol:           opts.Protocol,
+		Insecure:           opts.Insecure,
+		Headers:            opts.ResolvedHeaders(),
+		ServiceName:        opts.ServiceName,
+		ServiceVersion:     version.Version,
+		ResourceAttributes: attrs,
+		SampleRatio:        opts.SampleRatio,
+		MetricInterval:     time.Duration(opts.MetricInterval) * time.Second,
+	})
+	if err != nil {
+		slog.Warn("Failed to set up telemetry, continuing without it", "error", err)
+		return
+	}
+	slog.Info("Exporting telemetry", "endpoint", opts.Endpoint, "protocol", opts.Protocol)
+	app.cleanupFuncs = append(app.cleanupFuncs, func() error {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return shutdown(shutdownCtx)
+	})
+}
@@ -0,0 +1,97 @@
+// Package clipboard reads images from the system clipboard.
+//
+// Terminals only paste text, so images are read with the platform's
+// clipboard tools: wl-paste or xclip on Linux, pngpaste or osascript on
+// macOS and PowerShell on Windows.
+package clipboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+var (
+	// ErrNoImage is returned when the clipboard holds no image.
+	ErrNoImage = errors.New("no image in clipboard")
+	// ErrUnsupported is returned when no clipboard tool is installed.
+	ErrUnsupported = errors.New("no clipboard tool found to read images")
+)
+
+// command is a clipboard tool invocation printing the clipboard image.
+type command struct {
+	name   string
+	args   []string
+	decode func([]byte) ([]byte, error)
+}
+
+// ReadImage returns the image in the system clipboard as PNG data.
+func ReadImage(ctx context.Context) ([]byte, error) {
+	return readImage(ctx, commands(runtime.GOOS, os.Getenv("WAYLAND_DISPLAY") != ""))
+}
+
+func readImage(ctx context.Context, cmds []command) ([]byte, error) {
+	found := false
+	for _, c := range cmds {
+		if _, err := exec.LookPath(c.name); err != nil {
+			continue
+		}
+		found = true
+		out, err := exec.CommandContext(ctx, c.name, c.args...).Output()
+		if err != nil || len(out) == 0 {
+			continue
+		}
+		if c.decode != nil {
+			if out, err = c.decode(out); err != nil {
+				continue
+			}
+		}
+		return out, nil
+	}
+	if !found {
+		return nil, ErrUnsupported
+	}
+	return nil, ErrNoImage
+}
+
+func commands(goos string, wayland bool) []command {
+	switch goos {
+	case "darwin":
+		return []command{
+			{name: "pngpaste", args: []string{"-"}},
+			{name: "osascript", args: []string{"-e", "get the clipboard as «class PNGf»"}, decode: decodeAppleScriptData},
+		}
+	case "windows":
+		return []command{{
+			name: "powershell",
+			args: []string{"-NoProfile", "-NonInteractive", "-Command", strings.Join([]string{
+				"Add-Type -AssemblyName System.Windows.Forms",
+				"$img = [System.Windows.Forms.Clipboard]::GetImage()",
+				"if ($img) { $ms = New-Object System.IO.MemoryStream; $img.Save($ms, [System.Drawing.Imaging.ImageFormat]::Png); $out = [Console]::OpenStandardOutput(); $out.Write($ms.ToArray(), 0, $ms.Length) }",
+			}, "; ")},
+		}}
+	default:
+		wl := command{name: "wl-paste", args: []string{"--no-newline", "--type", "image/png"}}
+		x := command{name: "xclip", args: []string{"-selection", "clipboard", "-target", "image/png", "-out"}}
+		if wayland {
+			return []command{wl, x}
+		}
+		return []command{x, wl}
+	}
+}
+
+// decodeAppleScriptData decodes the «data PNGf89504E47…» literal osascript
+// prints for binary clipboard data.
+func decodeAppleScriptData(out []byte) ([]byte, error) {
+	out = bytes.TrimSpace(out)
+	const prefix, suffix = "«data PNGf", "»"
+	if !bytes.HasPrefix(out, []byte(prefix)) || !bytes.HasSuffix(out, []byte(suffix)) {
+		return nil, ErrNoImage
+	}
+	return hex.DecodeString(string(out[len(prefix) : len(out)-len(suffix)]))
+}
@@ -0,0 +1,65 @@
+package clipboard
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadImage(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	ctx := context.Background()
+
+	out, err := readImage(ctx, []command{
+		{name: "nexora-missing-clipboard-tool"},
+		{name: "sh", args: []string{"-c", "exit 1"}},
+		{name: "sh", args: []string{"-c", "printf png"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "png", string(out))
+
+	_, err = readImage(ctx, []command{{name: "sh", args: []string{"-c", "true"}}})
+	require.True(t, errors.Is(err, ErrNoImage))
+
+	_, err = readImage(ctx, []command{{name: "nexora-missing-clipboard-tool"}})
+	require.True(t, errors.Is(err, ErrUnsupported))
+}
+
+func TestReadImageDecode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	out, err := readImage(context.Background(), []command{{
+		name:   "sh",
+		args:   []string{"-c", "echo '«data PNGf89504E47»'"},
+		decode: decodeAppleScriptData,
+	}})
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x89, 'P', 'N', 'G'}, out)
+}
+
+func TestDecodeAppleScriptData(t *testing.T) {
+	_, err := decodeAppleScriptData([]byte("missing value"))
+	require.True(t, errors.Is(err, ErrNoImage))
+	_, err = decodeAppleScriptData([]byte("«data PNGfZZ»"))
+	require.Error(t, err)
+}
+
+func TestCommands(t *testing.T) {
+	names := func(cmds []command) []string {
+		var out []string
+		for _, c := range cmds {
+			out = append(out, c.name)
+		}
+		return out
+	}
+	require.Equal(t, []string{"wl-paste", "xclip"}, names(commands("linux", true)))
+	require.Equal(t, []string{"xclip", "wl-paste"}, names(commands("linux", false)))
+	require.Equal(t, []string{"pngpaste", "osascript"}, names(commands("darwin", false)))
+	require.Equal(t, []string{"powershell"}, names(commands("windows", false)))
+}
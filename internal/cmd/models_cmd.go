@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nexora/nexora/internal/agent"
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/db"
+	"github.com/nexora/nexora/internal/modelstats"
+	"github.com/spf13/cobra"
+)
+
+var modelsCmd = &cobra.Command{
+	Use:   "models",
+	Short: "Show measured model speeds",
+	Long: `Show the time to first token and output speed nexora measured for each
+model, computed from the most recent completions of the last 30 days.
+
+These measurements pick the model used for session titles and are shown in
+the models dialog. Use "nexora models bench" to measure a model explicitly.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := connectProjectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		all, err := modelstats.NewService(db.New(conn)).All(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to load model speeds: %w", err)
+		}
+		if len(all) == 0 {
+			cmd.Println("No measurements yet. Run nexora models bench to measure a model.")
+			return nil
+		}
+		printModelSpeeds(cmd.OutOrStdout(), slices.Collect(maps.Values(all)))
+		return nil
+	},
+}
+
+var modelsBenchCmd = &cobra.Command{
+	Use:   "bench [provider/model...]",
+	Short: "Measure the latency and speed of models",
+	Long: `Send a fixed prompt to each model and record its time to first token
+and output speed. Without arguments the selected model and the recently used
+models are measured.`,
+	Example: `
+# Measure the configured models
+nexora models bench
+
+# Measure specific models five times each
+nexora models bench openai/gpt-4o local/qwen2.5-coder --runs 5
+  `,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		runs, _ := cmd.Flags().GetInt("runs")
+		if runs < 1 {
+			return fmt.Errorf("--runs must be at least 1")
+		}
+
+		debug, _ := cmd.Flags().GetBool("debug")
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		cfg, err := config.Init(cwd, dataDir, debug)
+		if err != nil {
+			return err
+		}
+
+		targets, err := benchTargets(cfg, args)
+		if err != nil {
+			return err
+		}
+		if len(targets) == 0 {
+			return fmt.Errorf("no models to measure")
+		}
+
+		conn, err := db.Connect(cmd.Context(), cfg.Options.DataDirectory)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer conn.Close()
+		speeds := modelstats.NewService(db.New(conn))
+
+		var failed int
+		for _, target := range targets {
+			cmd.Printf("Measuring %s/%s...\n", target.Provider, target.Model)
+			samples, err := agent.BenchModel(cmd.Context(), cfg, target, runs)
+			for _, sample := range samples {
+				if recordErr := speeds.Record(cmd.Context(), sample); recordErr != nil {
+					return fmt.Errorf("failed to record measurement: %w", recordErr)
+				}
+			}
+			if err != nil {
+				failed++
+				cmd.PrintErrf("  %v\n", err)
+			}
+		}
+
+		all, err := speeds.All(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to load model speeds: %w", err)
+		}
+		var measured []modelstats.Stats
+		for _, target := range targets {
+			if stats, ok := all[modelstats.Key(target.Provider, target.Model)]; ok {
+				measured = append(measured, stats)
+			}
+		}
+		if len(measured) > 0 {
+			cmd.Println()
+			printModelSpeeds(cmd.OutOrStdout(), measured)
+		}
+		if failed == len(targets) {
+			return fmt.Errorf("all benchmarks failed")
+		}
+		return nil
+	},
+}
+
+func init() {
+	modelsBenchCmd.Flags().Int("runs", 3, "Number of requests sent to each model")
+	modelsCmd.AddCommand(modelsBenchCmd)
+}
+
+// benchTargets returns the models named in args, or the selected model and
+// the recently used models when there are none.
+func benchTargets(cfg *config.Config, args []string) ([]config.SelectedModel, error) {
+	var targets []config.SelectedModel
+	add := func(model config.SelectedModel) {
+		if model.Provider == "" || model.Model == "" {
+			return
+		}
+		if slices.ContainsFunc(targets, func(m config.SelectedModel) bool {
+			return m.Provider == model.Provider && m.Model == model.Model
+		}) {
+			return
+		}
+		targets = append(targets, model)
+	}
+
+	if len(args) > 0 {
+		for _, arg := range args {
+			// Model IDs may contain slashes, provider IDs do not.
+			provider, model, ok := strings.Cut(arg, "/")
+			if !ok || provider == "" || model == "" {
+				return nil, fmt.Errorf("invalid model %q, expected provider/model", arg)
+			}
+			if _, ok := cfg.Providers.Get(provider); !ok {
+				return nil, fmt.Errorf("provider %s not configured", provider)
+			}
+			add(config.SelectedModel{Provider: provider, Model: model})
+		}
+		return targets, nil
+	}
+
+	add(cfg.Models[config.SelectedModelTypeLarge])
+	for _, recent := range cfg.RecentModels[config.SelectedModelTypeLarge] {
+		add(recent)
+	}
+	return targets, nil
+}
+
+func printModelSpeeds(w io.Writer, stats []modelstats.Stats) {
+	slices.SortFunc(stats, func(a, b modelstats.Stats) int {
+		return strings.Compare(modelstats.Key(a.Provider, a.Model), modelstats.Key(b.Provider, b.Model))
+	})
+	row := "%-40s  %7s  %8s  %8s  %s\n"
+	fmt.Fprintf(w, row, "Model", "Samples", "TTFT", "Tok/s", "Last measured")
+	for _, s := range stats {
+		tps := "-"
+		if s.TokensPerSecond > 0 {
+			tps = fmt.Sprintf("%.1f", s.TokensPerSecond)
+		}
+		fmt.Fprintf(w, row, s.Provider+"/"+s.Model, fmt.Sprint(s.Samples), s.TTFT.Round(time.Millisecond),
+			tps, s.LastMeasured.Local().Format("2006-01-02 15:04"))
+	}
+}
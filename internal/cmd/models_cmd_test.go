@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/csync"
+	"github.com/nexora/nexora/internal/modelstats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBenchTargets(t *testing.T) {
+	cfg := &config.Config{
+		Providers: csync.NewMapFrom(map[string]config.ProviderConfig{
+			"openai":     {ID: "openai"},
+			"openrouter": {ID: "openrouter"},
+		}),
+		Models: map[config.SelectedModelType]config.SelectedModel{
+			config.SelectedModelTypeLarge: {Provider: "openai", Model: "gpt-4o"},
+		},
+		RecentModels: map[config.SelectedModelType][]config.SelectedModel{
+			config.SelectedModelTypeLarge: {
+				{Provider: "openai", Model: "gpt-4o"},
+				{Provider: "openrouter", Model: "moonshotai/kimi-k2"},
+			},
+		},
+	}
+
+	targets, err := benchTargets(cfg, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []config.SelectedModel{
+		{Provider: "openai", Model: "gpt-4o"},
+		{Provider: "openrouter", Model: "moonshotai/kimi-k2"},
+	}, targets)
+
+	targets, err = benchTargets(cfg, []string{"openrouter/moonshotai/kimi-k2"})
+	require.NoError(t, err)
+	assert.Equal(t, []config.SelectedModel{{Provider: "openrouter", Model: "moonshotai/kimi-k2"}}, targets)
+
+	_, err = benchTargets(cfg, []string{"gpt-4o"})
+	assert.Error(t, err)
+	_, err = benchTargets(cfg, []string{"missing/model"})
+	assert.Error(t, err)
+}
+
+func TestPrintModelSpeeds(t *testing.T) {
+	measured := time.Date(2026, 10, 18, 9, 30, 0, 0, time.Local)
+	var b bytes.Buffer
+	printModelSpeeds(&b, []modelstats.Stats{
+		{Provider: "openai", Model: "gpt-4o", Samples: 4, TTFT: 412 * time.Millisecond, TokensPerSecond: 61.25, LastMeasured: measured},
+		{Provider: "local", Model: "qwen", Samples: 1, TTFT: 90 * time.Millisecond, LastMeasured: measured},
+	})
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"Model", "Samples", "TTFT", "Tok/s", "Last", "measured"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"local/qwen", "1", "90ms", "-", "2026-10-18", "09:30"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"openai/gpt-4o", "4", "412ms", "61.2", "2026-10-18", "09:30"}, strings.Fields(lines[2]))
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/nexora/nexora/internal/agent/recovery"
+	"github.com/nexora/nexora/internal/db"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	recoveryStatsCmd.Flags().String("session", "", "Only show the attempts of a session")
+	recoveryStatsCmd.Flags().Duration("since", 0, "Only show the attempts of the given period, such as 24h")
+	recoveryStatsCmd.Flags().Bool("json", false, "Print the statistics as JSON")
+
+	recoveryCmd.AddCommand(recoveryStatsCmd)
+}
+
+var recoveryCmd = &cobra.Command{
+	Use:   "recovery",
+	Short: "Inspect error recovery",
+	Long: `Inspect how the agent recovers from failed tool calls, with the built-in
+recovery strategies and the recovery rules of the config.`,
+}
+
+var recoveryStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show recovery statistics",
+	Long: `Show how often each recovery strategy ran in the current project and how
+often it succeeded, along with the types of errors it recovered from.`,
+	Example: `
+# Statistics of every recovery attempt in the project
+nexora recovery stats
+
+# Statistics of the last day, as JSON
+nexora recovery stats --since 24h --json
+
+# Statistics of a single session
+nexora recovery stats --session <session-id>
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sessionID, _ := cmd.Flags().GetString("session")
+		since, _ := cmd.Flags().GetDuration("since")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		if sessionID != "" && since != 0 {
+			return fmt.Errorf("--session and --since cannot be combined")
+		}
+
+		conn, err := connectProjectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		service := recovery.NewStatsService(db.New(conn))
+		var stats *recovery.RecoveryStatistics
+		if sessionID != "" {
+			stats, err = service.SessionStats(cmd.Context(), sessionID)
+		} else {
+			var from time.Time
+			if since > 0 {
+				from = time.Now().Add(-since)
+			}
+			stats, err = service.Stats(cmd.Context(), from)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get recovery statistics: %w", err)
+		}
+
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(stats)
+		}
+		if stats.TotalAttempts == 0 {
+			cmd.Println("No recovery attempts found.")
+			return nil
+		}
+		printRecoveryStats(cmd.OutOrStdout(), stats)
+		return nil
+	},
+}
+
+func printRecoveryStats(w io.Writer, stats *recovery.RecoveryStatistics) {
+	fmt.Fprintf(w, "Attempts:        %d (%d succeeded, %d failed)\n", stats.TotalAttempts, stats.SuccessCount, stats.FailureCount)
+	fmt.Fprintf(w, "Success rate:    %.0f%%\n", stats.SuccessRate("")*100)
+	fmt.Fprintf(w, "Average retries: %.1f\n", stats.AverageRetries)
+	if !stats.LastAttempt.IsZero() {
+		fmt.Fprintf(w, "Last attempt:    %s\n", stats.LastAttempt.Local().Format(time.DateTime))
+	}
+
+	row := "%-32s  %8s  %9s  %5s\n"
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, row, "Strategy", "Attempts", "Succeeded", "Rate")
+	for _, name := range byCount(stats.StrategyCounts) {
+		fmt.Fprintf(w, row, truncate(name, 32), fmt.Sprint(stats.StrategyCounts[name]),
+			fmt.Sprint(stats.StrategySuccesses[name]), fmt.Sprintf("%.0f%%", stats.SuccessRate(name)*100))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%-32s  %8s\n", "Error type", "Attempts")
+	for _, errorType := range byCount(stats.ErrorTypeCounts) {
+		fmt.Fprintf(w, "%-32s  %8d\n", truncate(errorType, 32), stats.ErrorTypeCounts[errorType])
+	}
+}
+
+// byCount returns the keys of counts, most counted first.
+func byCount(counts map[string]int) []string {
+	return slices.SortedFunc(maps.Keys(counts), func(a, b string) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), cmp.Compare(a, b))
+	})
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nexora/nexora/internal/agent/recovery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoveryCmd(t *testing.T) {
+	assert.Equal(t, "stats", recoveryStatsCmd.Use)
+	assert.Error(t, recoveryStatsCmd.Args(recoveryStatsCmd, []string{"a"}))
+	require.NotNil(t, recoveryStatsCmd.Flags().Lookup("session"))
+	require.NotNil(t, recoveryStatsCmd.Flags().Lookup("json"))
+}
+
+func TestPrintRecoveryStats(t *testing.T) {
+	stats := recovery.NewRecoveryStatistics()
+	stats.RecordAttempt("go mod tidy", recovery.ErrorTypeCommandFailed, true, 1)
+	stats.RecordAttempt("go mod tidy", recovery.ErrorTypeCommandFailed, false, 2)
+	stats.RecordAttempt("Timeout Recovery", recovery.ErrorTypeTimeout, true, 1)
+
+	var b bytes.Buffer
+	printRecoveryStats(&b, stats)
+	out := b.String()
+
+	assert.Contains(t, out, "Attempts:        3 (2 succeeded, 1 failed)")
+	assert.Contains(t, out, "Success rate:    67%")
+	lines := strings.Split(out, "\n")
+	i := slices.IndexFunc(lines, func(line string) bool { return strings.HasPrefix(line, "Strategy") })
+	require.GreaterOrEqual(t, i, 0)
+	assert.Equal(t, []string{"go", "mod", "tidy", "2", "1", "50%"}, strings.Fields(lines[i+1]))
+	assert.Equal(t, []string{"Timeout", "Recovery", "1", "1", "100%"}, strings.Fields(lines[i+2]))
+	assert.Contains(t, out, recovery.ErrorTypeCommandFailed)
+}
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/git"
+	"github.com/nexora/nexora/internal/replay"
+	"github.com/nexora/nexora/internal/version"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	recordCmd.Flags().String("cassette", "", "Directory to write the cassette to (default: <data-dir>/cassettes/<timestamp>)")
+	recordCmd.Flags().BoolP("quiet", "q", false, "Hide spinner")
+
+	replayCmd.Flags().Bool("compare-output", false, "Also compare tool output text, not only tool names, inputs and errors")
+	replayCmd.Flags().Bool("strict", false, "Fail when the requests sent to the provider differ from the recording")
+	replayCmd.Flags().Bool("json", false, "Print the result as JSON")
+	replayCmd.Flags().Bool("show-output", false, "Print the agent's response while replaying")
+	replayCmd.Flags().BoolP("quiet", "q", false, "Hide spinner")
+	replayCmd.Flags().Bool("execute-tools", false, "Run the tool calls against the working tree instead of serving the recorded results")
+}
+
+var recordCmd = &cobra.Command{
+	Use:   "record [prompt...]",
+	Short: "Run a prompt and record it into a cassette",
+	Long: `Run a single prompt non-interactively, like 'nexora run', while recording
+every provider request and response together with the tool calls and their
+results into a cassette directory.
+
+API keys and other credentials are stripped from the recorded requests.
+Replay the cassette later with 'nexora replay' to detect behavior changes.`,
+	Example: `
+# Record a run into the default cassette directory
+nexora record "Add a test for the parser"
+
+# Record into a specific directory
+nexora record --cassette testdata/cassettes/parser "Add a test for the parser"
+  `,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("cassette")
+		quiet, _ := cmd.Flags().GetBool("quiet")
+
+		prompt, err := MaybePrependStdin(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		if prompt == "" {
+			return fmt.Errorf("no prompt provided")
+		}
+
+		var recorder *replay.Recorder
+		var recorderErr error
+		nexora, err := setupApp(cmd, func(cfg *config.Config) {
+			if dir == "" {
+				dir = filepath.Join(cfg.Options.DataDirectory, "cassettes", time.Now().Format("20060102-150405"))
+			}
+			recorder, recorderErr = replay.NewRecorder(dir, nil)
+			if recorderErr == nil {
+				cfg.SetHTTPTransport(recorder)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		defer nexora.Shutdown()
+		if recorderErr != nil {
+			return fmt.Errorf("failed to create recorder: %w", recorderErr)
+		}
+
+		if !nexora.Config().IsConfigured() {
+			return fmt.Errorf("no providers configured - please run 'nexora' to set up a provider interactively")
+		}
+
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer cancel()
+
+		calls, runErr := nexora.RunRecorded(ctx, os.Stdout, prompt, quiet)
+		if err := recorder.Stop(); err != nil {
+			return fmt.Errorf("failed to save cassette: %w", err)
+		}
+
+		model := nexora.Config().Models[config.SelectedModelTypeLarge]
+		session := replay.Session{
+			Version:    version.Version,
+			Prompt:     prompt,
+			Provider:   model.Provider,
+			Model:      model.Model,
+			RecordedAt: time.Now().UTC(),
+			ToolCalls:  calls,
+		}
+		if repo, err := git.Open(ctx, nexora.Config().WorkingDir()); err == nil {
+			if head, err := repo.Head(ctx); err == nil {
+				session.GitHead = head.Hash
+			}
+		}
+		if err := replay.Save(dir, session); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Recorded %d tool call(s) to %s\n", len(calls), dir)
+		return runErr
+	},
+}
+
+type replayResult struct {
+	Cassette       string                `json:"cassette"`
+	Recorded       int                   `json:"recorded_tool_calls"`
+	Replayed       int                   `json:"replayed_tool_calls"`
+	Differences    []replay.Difference   `json:"differences"`
+	RequestDrift   []replay.RequestDrift `json:"request_drift"`
+	UnusedRequests int                   `json:"unused_requests"`
+	Unanswered     int                   `json:"unanswered_tool_calls,omitempty"`
+	Error          string                `json:"error,omitempty"`
+}
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <cassette-dir>",
+	Short: "Replay a recorded run and diff its tool calls",
+	Long: `Replay a cassette made with 'nexora record' against this build.
+
+Provider responses and tool results are served from the cassette, so no
+network access or API credits are needed and nothing in the working tree
+changes. Tool calls that were not recorded are answered with an error. The
+resulting tool-call sequence is compared with the recorded one, and requests
+whose bodies changed (for example because a prompt or tool description
+changed) are reported as drift.
+
+With --execute-tools the tools run for real instead: commands are executed
+and files are edited in the working tree. Only use it in a disposable
+checkout of the commit the cassette was recorded at.
+
+The command fails when the tool calls differ, or with --strict when any
+request drifted.`,
+	Example: `
+# Replay a cassette and report differences
+nexora replay .nexora/cassettes/20250101-120000
+
+# Fail on any prompt or tool schema change too
+nexora replay --strict --json testdata/cassettes/parser
+
+# Run the tools for real, in a disposable checkout
+nexora replay --execute-tools testdata/cassettes/parser
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := args[0]
+		compareOutput, _ := cmd.Flags().GetBool("compare-output")
+		strict, _ := cmd.Flags().GetBool("strict")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		showOutput, _ := cmd.Flags().GetBool("show-output")
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		executeTools, _ := cmd.Flags().GetBool("execute-tools")
+
+		session, err := replay.Load(dir)
+		if err != nil {
+			return err
+		}
+		player, err := replay.NewPlayer(dir)
+		if err != nil {
+			return err
+		}
+
+		var toolResults *replay.ToolResults
+		if !executeTools {
+			toolResults = replay.NewToolResults(session.ToolCalls)
+		}
+
+		nexora, err := setupApp(cmd, func(cfg *config.Config) {
+			cfg.SetHTTPTransport(player)
+			if toolResults != nil {
+				cfg.SetToolResults(toolResults.Result)
+			}
+			// Use the recorded model so requests go to the recorded URLs.
+			if cfg.Models == nil {
+				cfg.Models = make(map[config.SelectedModelType]config.SelectedModel)
+			}
+			large := cfg.Models[config.SelectedModelTypeLarge]
+			large.Provider = session.Provider
+			large.Model = session.Model
+			cfg.Models[config.SelectedModelTypeLarge] = large
+		})
+		if err != nil {
+			return err
+		}
+		defer nexora.Shutdown()
+
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer cancel()
+
+		if executeTools {
+			fmt.Fprintf(os.Stderr, "Warning: tools run for real; recorded commands and edits are applied to %s\n", nexora.Config().WorkingDir())
+			if repo, err := git.Open(ctx, nexora.Config().WorkingDir()); err == nil && session.GitHead != "" {
+				if head, err := repo.Head(ctx); err == nil && head.Hash != session.GitHead {
+					fmt.Fprintf(os.Stderr, "Warning: recorded at %s but HEAD is %s; tool results may differ\n", session.GitHead[:min(12, len(session.GitHead))], head.ShortHash())
+				}
+			}
+		}
+
+		var output io.Writer = io.Discard
+		if showOutput {
+			output = os.Stdout
+		}
+		calls, runErr := nexora.RunRecorded(ctx, output, session.Prompt, quiet)
+
+		result := replayResult{
+			Cassette:       dir,
+			Recorded:       len(session.ToolCalls),
+			Replayed:       len(calls),
+			Differences:    replay.DiffToolCalls(session.ToolCalls, calls, replay.DiffOptions{CompareOutput: compareOutput}),
+			RequestDrift:   player.Drift(),
+			UnusedRequests: player.Unused(),
+		}
+		if toolResults != nil {
+			result.Unanswered = toolResults.Missed()
+		}
+		if runErr != nil {
+			result.Error = runErr.Error()
+		}
+
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(result); err != nil {
+				return err
+			}
+		} else {
+			printReplayResult(cmd.OutOrStdout(), result)
+		}
+
+		switch {
+		case runErr != nil:
+			return fmt.Errorf("replay failed: %w", runErr)
+		case len(result.Differences) > 0:
+			return errors.New("tool calls differ from the recording")
+		case strict && (len(result.RequestDrift) > 0 || result.UnusedRequests > 0):
+			return errors.New("provider requests differ from the recording")
+		}
+		return nil
+	},
+}
+
+func printReplayResult(w io.Writer, result replayResult) {
+	fmt.Fprintf(w, "Replayed %d tool call(s), %d recorded\n", result.Replayed, result.Recorded)
+	if result.Error != "" {
+		fmt.Fprintf(w, "Replay stopped: %s\n", result.Error)
+	}
+	if len(result.RequestDrift) > 0 {
+		fmt.Fprintln(w, "\nRequest drift:")
+		for _, d := range result.RequestDrift {
+			fmt.Fprintf(w, "  request #%d %s %s: %s\n", d.Index+1, d.Method, d.URL, strings.Join(d.Fields, ", "))
+		}
+	}
+	if result.UnusedRequests > 0 {
+		fmt.Fprintf(w, "\n%d recorded request(s) were not made\n", result.UnusedRequests)
+	}
+	if result.Unanswered > 0 {
+		fmt.Fprintf(w, "\n%d tool call(s) had no recorded result and were not run\n", result.Unanswered)
+	}
+	if len(result.Differences) == 0 {
+		fmt.Fprintln(w, "\nTool calls match the recording.")
+		return
+	}
+	fmt.Fprintln(w, "\nTool call differences:")
+	replay.WriteDiff(w, result.Differences)
+}
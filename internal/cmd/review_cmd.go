@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+
+	"charm.land/lipgloss/v2"
+	"github.com/nexora/nexora/internal/app"
+	"github.com/nexora/nexora/internal/format"
+	"github.com/nexora/nexora/internal/git"
+	"github.com/nexora/nexora/internal/review"
+	"github.com/nexora/nexora/internal/tui/components/core"
+	"github.com/nexora/nexora/internal/tui/exp/diffview"
+	"github.com/nexora/nexora/internal/tui/styles"
+	"github.com/nexora/nexora/internal/version"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	reviewCmd.Flags().String("base", "main", "Branch to review the current branch against")
+	reviewCmd.Flags().String("format", "text", "Output format: text, diff, json or sarif")
+	reviewCmd.Flags().StringP("output", "o", "", "Write the findings to a file instead of stdout")
+	reviewCmd.Flags().String("fail-on", "", "Exit with an error when a finding of this severity or worse is reported (error, warning or info)")
+	reviewCmd.Flags().BoolP("quiet", "q", false, "Hide spinner")
+}
+
+var reviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Review the current branch like a pull request",
+	Long: `Review the changes on the current branch, including uncommitted
+changes, against a base branch.
+
+The reviewer agent only has read-only tools (view, grep, glob, ls, the git
+read tools and LSP) and reports structured findings with a file, line range,
+severity and suggestion.`,
+	Example: `
+# Review the current branch against main
+nexora review
+
+# Review against another base and show findings inline in the diff
+nexora review --base develop --format diff
+
+# Produce SARIF for code scanning and fail the build on errors
+nexora review --format sarif -o review.sarif --fail-on error
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		base, _ := cmd.Flags().GetString("base")
+		outputFormat, _ := cmd.Flags().GetString("format")
+		outputPath, _ := cmd.Flags().GetString("output")
+		failOn, _ := cmd.Flags().GetString("fail-on")
+		quiet, _ := cmd.Flags().GetBool("quiet")
+
+		if !slices.Contains([]string{"text", "diff", "json", "sarif"}, outputFormat) {
+			return fmt.Errorf("unknown format %q: use text, diff, json or sarif", outputFormat)
+		}
+		if failOn != "" && !slices.Contains([]string{"error", "warning", "info"}, failOn) {
+			return fmt.Errorf("unknown severity %q for --fail-on: use error, warning or info", failOn)
+		}
+
+		nexora, err := setupApp(cmd)
+		if err != nil {
+			return err
+		}
+		defer nexora.Shutdown()
+
+		if !nexora.Config().IsConfigured() {
+			return fmt.Errorf("no providers configured - please run 'nexora' to set up a provider interactively")
+		}
+
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer cancel()
+
+		var spinner format.SpinnerInterface
+		if !quiet {
+			t := styles.CurrentTheme()
+			spinner = format.NewSpinnerInterface(ctx, cancel, format.Settings{
+				Size:       10,
+				Label:      "Reviewing",
+				LabelColor: styles.ColorToHex(t.Primary),
+				ColorStart: styles.ColorToHex(t.Primary),
+				ColorEnd:   styles.ColorToHex(t.Secondary),
+			})
+			spinner.Start()
+		}
+		report, err := nexora.Review(ctx, base)
+		if spinner != nil {
+			spinner.Stop()
+		}
+		if errors.Is(err, app.ErrNothingToReview) {
+			cmd.Printf("No changes against %s.\n", base)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var out io.Writer = cmd.OutOrStdout()
+		if outputPath != "" {
+			f, err := os.Create(outputPath)
+			if err != nil {
+				return fmt.Errorf("failed to create output file: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		switch outputFormat {
+		case "json":
+			err = review.WriteJSON(out, report)
+		case "sarif":
+			err = review.WriteSARIF(out, report, version.Version)
+		case "diff":
+			err = printReviewDiff(ctx, out, nexora.Config().WorkingDir(), report)
+			if err == nil {
+				printReviewFindings(out, report)
+			}
+		default:
+			printReviewFindings(out, report)
+		}
+		if err != nil {
+			return err
+		}
+
+		if failOn != "" {
+			threshold := review.ParseSeverity(failOn)
+			for _, f := range report.Findings {
+				if review.SeverityAtLeast(f.Severity, threshold) {
+					return fmt.Errorf("review reported %s findings", f.Severity)
+				}
+			}
+		}
+		return nil
+	},
+}
+
+func severityStyle(s review.Severity) lipgloss.Style {
+	t := styles.CurrentTheme()
+	switch s {
+	case review.SeverityError:
+		return t.S().Error
+	case review.SeverityWarning:
+		return t.S().Warning
+	default:
+		return t.S().Info
+	}
+}
+
+func printReviewFindings(w io.Writer, report review.Report) {
+	if report.Summary != "" {
+		fmt.Fprintf(w, "%s\n\n", report.Summary)
+	}
+	if len(report.Findings) == 0 {
+		fmt.Fprintf(w, "No findings for %s against %s.\n", report.Head, report.Base)
+		return
+	}
+	for _, f := range report.Findings {
+		location := fmt.Sprintf("%s:%d", f.File, f.StartLine)
+		if f.EndLine > f.StartLine {
+			location = fmt.Sprintf("%s-%d", location, f.EndLine)
+		}
+		fmt.Fprintf(w, "%s %s %s\n", severityStyle(f.Severity).Render(fmt.Sprintf("%-7s", f.Severity)), location, f.Title)
+		if f.Suggestion != "" {
+			fmt.Fprintf(w, "        %s\n", f.Suggestion)
+		}
+	}
+	fmt.Fprintf(w, "\n%d error(s), %d warning(s), %d info\n",
+		report.Count(review.SeverityError),
+		report.Count(review.SeverityWarning),
+		report.Count(review.SeverityInfo),
+	)
+}
+
+// printReviewDiff renders the diff of every file with findings, with the
+// findings shown inline below the lines they refer to.
+func printReviewDiff(ctx context.Context, w io.Writer, workingDir string, report review.Report) error {
+	repo, err := git.Open(ctx, workingDir)
+	if err != nil {
+		return err
+	}
+
+	var files []string
+	for _, f := range report.Findings {
+		if !slices.Contains(files, f.File) {
+			files = append(files, f.File)
+		}
+	}
+	slices.Sort(files)
+
+	for _, path := range files {
+		before, err := repo.Show(ctx, report.MergeBase, path)
+		if err != nil {
+			return err
+		}
+		after, err := os.ReadFile(filepath.Join(repo.Root, path))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		var annotations []diffview.Annotation
+		for _, f := range report.FindingsFor(path) {
+			text := fmt.Sprintf("%s: %s", f.Severity, f.Title)
+			if f.Suggestion != "" {
+				text += " — " + f.Suggestion
+			}
+			annotations = append(annotations, diffview.Annotation{
+				Line:  f.StartLine,
+				Text:  text,
+				Style: severityStyle(f.Severity),
+			})
+		}
+
+		fmt.Fprintf(w, "%s\n", styles.CurrentTheme().S().Title.Render(path))
+		dv := core.DiffFormatter().
+			Before(path, before).
+			After(path, string(after)).
+			Annotations(annotations...)
+		fmt.Fprintf(w, "%s\n\n", dv.String())
+	}
+	return nil
+}
@@ -59,6 +59,13 @@ For more information, visit our GitHub at https://github.com/jeffersonwarrior/ne
 		resetCmd,
 		tasksCmd,
 		checkpointCmd,
+		sessionsCmd,
+		usageCmd,
+		recoveryCmd,
+		modelsCmd,
+		reviewCmd,
+		recordCmd,
+		replayCmd,
 		installCmd,
 	)
 }
@@ -180,7 +187,9 @@ func setupAppWithProgressBar(cmd *cobra.Command) (*app.App, error) {
 
 // setupApp handles the common setup logic for both interactive and non-interactive modes.
 // It returns the app instance, config, cleanup function, and any error.
-func setupApp(cmd *cobra.Command) (*app.App, error) {
+// The configure functions can adjust the loaded config before the app is
+// created.
+func setupApp(cmd *cobra.Command, configure ...func(*config.Config)) (*app.App, error) {
 	debug, _ := cmd.Flags().GetBool("debug")
 	yolo, _ := cmd.Flags().GetBool("yolo")
 	dataDir, _ := cmd.Flags().GetString("data-dir")
@@ -201,6 +210,10 @@ func setupApp(cmd *cobra.Command) (*app.App, error) {
 	}
 	cfg.Permissions.SkipRequests = yolo
 
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
 	if err := createDotNexoraDir(cfg.Options.DataDirectory); err != nil {
 		return nil, err
 	}
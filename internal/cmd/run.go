@@ -1,12 +1,19 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
 	"strings"
 
+	"github.com/charmbracelet/x/term"
+	"github.com/nexora/nexora/internal/agent/delegation"
+	"github.com/nexora/nexora/internal/app"
+	"github.com/nexora/nexora/internal/message"
+	"github.com/nexora/nexora/internal/task"
 	"github.com/spf13/cobra"
 )
 
@@ -25,11 +32,34 @@ curl https://charm.land | nexora run "Summarize this website"
 # Read from a file
 nexora run "What is this code doing?" <<< prrr.go
 
+# Attach images, PDFs or text files
+nexora run --attach screenshot.png --attach spec.pdf "Why does the layout break?"
+
 # Run in quiet mode (hide the spinner)
 nexora run --quiet "Generate a README for this project"
+
+# Plan with read-only tools first and confirm before anything changes
+nexora run --plan "Migrate the config loader to the new schema"
+
+# Plan and execute without asking
+nexora run --plan --yes "Add a --json flag to the logs command"
+
+# Run a prompt from a file, writing the response and the status to files
+nexora run --quiet --prompt-file task.md --output-file result.md --status-file status.json
   `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		quiet, _ := cmd.Flags().GetBool("quiet")
+		plan, _ := cmd.Flags().GetBool("plan")
+		yes, _ := cmd.Flags().GetBool("yes")
+		attachPaths, _ := cmd.Flags().GetStringArray("attach")
+		headless, err := headlessOptions(cmd, args)
+		if err != nil {
+			return err
+		}
+		attachments, err := readAttachments(attachPaths)
+		if err != nil {
+			return err
+		}
 
 		app, err := setupApp(cmd)
 		if err != nil {
@@ -41,6 +71,12 @@ nexora run --quiet "Generate a README for this project"
 			return fmt.Errorf("no providers configured - please run 'nexora' to set up a provider interactively")
 		}
 
+		if headless != nil {
+			ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer cancel()
+			return app.RunHeadless(ctx, os.Stdout, *headless)
+		}
+
 		prompt := strings.Join(args, " ")
 
 		prompt, err = MaybePrependStdin(prompt)
@@ -59,10 +95,91 @@ nexora run --quiet "Generate a README for this project"
 		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
 		defer cancel()
 
-		return app.RunNonInteractive(ctx, os.Stdout, prompt, quiet)
+		if plan {
+			return app.RunPlanNonInteractive(ctx, os.Stdout, prompt, quiet, planApprover(yes), attachments...)
+		}
+		return app.RunNonInteractive(ctx, os.Stdout, prompt, quiet, attachments...)
 	},
 }
 
 func init() {
 	runCmd.Flags().BoolP("quiet", "q", false, "Hide spinner")
+	runCmd.Flags().Bool("plan", false, "Plan with read-only tools and ask before executing the plan")
+	runCmd.Flags().BoolP("yes", "y", false, "Execute the plan without asking (with --plan)")
+	runCmd.Flags().StringArrayP("attach", "a", nil, "Attach an image, PDF or text file (repeatable)")
+	runCmd.Flags().String("prompt-file", "", "Read the prompt from a file")
+	runCmd.Flags().String("output-file", "", "Write the final response to a file (with --prompt-file)")
+	runCmd.Flags().String("status-file", "", "Keep the status of the run in a JSON file (with --prompt-file)")
+	runCmd.Flags().String("agent", "", "Run the prompt with a user-defined agent (with --prompt-file)")
+	runCmd.Flags().String("parent-session", "", "Run the prompt in a task session of this session (with --prompt-file)")
+}
+
+// headlessOptions returns the options of a run with --prompt-file, or nil if
+// the prompt is given otherwise.
+func headlessOptions(cmd *cobra.Command, args []string) (*app.HeadlessOptions, error) {
+	promptFile, _ := cmd.Flags().GetString("prompt-file")
+	outputFile, _ := cmd.Flags().GetString("output-file")
+	statusFile, _ := cmd.Flags().GetString("status-file")
+	agent, _ := cmd.Flags().GetString("agent")
+	parentSession, _ := cmd.Flags().GetString("parent-session")
+	if promptFile == "" {
+		if outputFile != "" || statusFile != "" || agent != "" || parentSession != "" {
+			return nil, fmt.Errorf("--output-file, --status-file, --agent and --parent-session require --prompt-file")
+		}
+		return nil, nil
+	}
+
+	plan, _ := cmd.Flags().GetBool("plan")
+	attachPaths, _ := cmd.Flags().GetStringArray("attach")
+	switch {
+	case len(args) > 0:
+		return nil, fmt.Errorf("--prompt-file can't be used with a prompt argument")
+	case plan || len(attachPaths) > 0:
+		return nil, fmt.Errorf("--prompt-file can't be used with --plan or --attach")
+	case outputFile == "":
+		return nil, fmt.Errorf("--prompt-file requires --output-file")
+	}
+	return &app.HeadlessOptions{
+		PromptFile:    promptFile,
+		OutputFile:    outputFile,
+		StatusFile:    statusFile,
+		Agent:         agent,
+		ParentSession: parentSession,
+		TaskID:        os.Getenv(delegation.EnvTaskID),
+	}, nil
+}
+
+func readAttachments(paths []string) ([]message.Attachment, error) {
+	var attachments []message.Attachment
+	for _, path := range paths {
+		attachment, err := message.ReadAttachment(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to attach file: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
+
+// planApprover decides whether a plan produced by run --plan is executed.
+// Without a terminal to ask on, the plan is only printed unless yes is set.
+func planApprover(yes bool) func(task.Plan) bool {
+	switch {
+	case yes:
+		return func(task.Plan) bool { return true }
+	case term.IsTerminal(os.Stdin.Fd()):
+		return func(task.Plan) bool { return confirmPlan(os.Stdin, os.Stdout) }
+	default:
+		return nil
+	}
+}
+
+func confirmPlan(in io.Reader, out io.Writer) bool {
+	fmt.Fprint(out, "Execute this plan? [y/N]: ")
+	response, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && response == "" {
+		return false
+	}
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes"
 }
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nexora/nexora/internal/agent/delegation"
+	"github.com/nexora/nexora/internal/app"
+	"github.com/nexora/nexora/internal/task"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmPlan(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{" yes ", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+		{"sure\n", false},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		require.Equal(t, tt.want, confirmPlan(strings.NewReader(tt.input), &out), "input %q", tt.input)
+		require.Equal(t, "Execute this plan? [y/N]: ", out.String())
+	}
+}
+
+func TestPlanApproverYes(t *testing.T) {
+	approve := planApprover(true)
+	require.NotNil(t, approve)
+	require.True(t, approve(task.Plan{}))
+}
+
+func TestRunFlags(t *testing.T) {
+	require.NotNil(t, runCmd.Flags().Lookup("plan"))
+	require.NotNil(t, runCmd.Flags().Lookup("yes"))
+	require.NotNil(t, runCmd.Flags().Lookup("attach"))
+	require.NotNil(t, runCmd.Flags().Lookup("prompt-file"))
+	require.NotNil(t, runCmd.Flags().Lookup("output-file"))
+	require.NotNil(t, runCmd.Flags().Lookup("status-file"))
+	require.NotNil(t, runCmd.Flags().Lookup("agent"))
+}
+
+func TestHeadlessOptions(t *testing.T) {
+	parse := func(args ...string) (*cobra.Command, []string) {
+		cmd := &cobra.Command{}
+		cmd.Flags().Bool("plan", false, "")
+		cmd.Flags().StringArray("attach", nil, "")
+		cmd.Flags().String("prompt-file", "", "")
+		cmd.Flags().String("output-file", "", "")
+		cmd.Flags().String("status-file", "", "")
+		cmd.Flags().String("agent", "", "")
+		cmd.Flags().String("parent-session", "", "")
+		require.NoError(t, cmd.Flags().Parse(args))
+		return cmd, cmd.Flags().Args()
+	}
+
+	opts, err := headlessOptions(parse("some", "prompt"))
+	require.NoError(t, err)
+	require.Nil(t, opts)
+
+	t.Setenv(delegation.EnvTaskID, "task")
+	opts, err = headlessOptions(parse("--prompt-file", "task.prompt", "--output-file", "task.output", "--status-file", "task.status", "--agent", "reviewer", "--parent-session", "parent"))
+	require.NoError(t, err)
+	require.Equal(t, &app.HeadlessOptions{
+		PromptFile:    "task.prompt",
+		OutputFile:    "task.output",
+		StatusFile:    "task.status",
+		Agent:         "reviewer",
+		ParentSession: "parent",
+		TaskID:        "task",
+	}, opts)
+
+	_, err = headlessOptions(parse("--output-file", "task.output"))
+	require.ErrorContains(t, err, "require --prompt-file")
+	_, err = headlessOptions(parse("--prompt-file", "task.prompt"))
+	require.ErrorContains(t, err, "requires --output-file")
+	_, err = headlessOptions(parse("--prompt-file", "task.prompt", "--output-file", "task.output", "extra"))
+	require.ErrorContains(t, err, "prompt argument")
+	_, err = headlessOptions(parse("--prompt-file", "task.prompt", "--output-file", "task.output", "--plan"))
+	require.ErrorContains(t, err, "--plan")
+}
+
+func TestReadAttachments(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "shot.png")
+	require.NoError(t, os.WriteFile(image, []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), 0o644))
+	notes := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(notes, []byte("remember"), 0o644))
+
+	attachments, err := readAttachments([]string{image, notes})
+	require.NoError(t, err)
+	require.Len(t, attachments, 2)
+	require.Equal(t, "image/png", attachments[0].MimeType)
+	require.Equal(t, "notes.txt", attachments[1].FileName)
+	require.Equal(t, "text/plain", attachments[1].MimeType)
+
+	_, err = readAttachments([]string{filepath.Join(dir, "missing.png")})
+	require.ErrorContains(t, err, "failed to attach file")
+
+	attachments, err = readAttachments(nil)
+	require.NoError(t, err)
+	require.Empty(t, attachments)
+}
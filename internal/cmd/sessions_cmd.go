@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nexora/nexora/internal/app"
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/db"
+	"github.com/nexora/nexora/internal/history"
+	"github.com/nexora/nexora/internal/message"
+	"github.com/nexora/nexora/internal/session"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	sessionsRewindCmd.Flags().Bool("dry-run", false, "Show the changes without restoring any files")
+	sessionsRewindCmd.Flags().BoolP("force", "f", false, "Skip confirmation and overwrite files edited since the agent changed them")
+
+	sessionsCmd.AddCommand(sessionsListCmd)
+	sessionsCmd.AddCommand(sessionsRewindCmd)
+	sessionsCmd.AddCommand(sessionsStateCmd)
+	sessionsCmd.AddCommand(sessionsResumeCmd)
+}
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Manage sessions",
+	Long: `List sessions, roll back file changes made by the agent, and inspect
+or resume the agent state of paused sessions.`,
+}
+
+var sessionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List sessions in the current project",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := connectProjectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		sessions, err := session.NewService(db.New(conn)).List(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to list sessions: %w", err)
+		}
+		if len(sessions) == 0 {
+			cmd.Println("No sessions found.")
+			return nil
+		}
+
+		cmd.Printf("%-36s  %8s  %-40s\n", "ID", "Messages", "Title")
+		for _, s := range sessions {
+			cmd.Printf("%-36s  %8d  %-40s\n", s.ID, s.MessageCount, truncate(s.Title, 40))
+		}
+		return nil
+	},
+}
+
+var sessionsRewindCmd = &cobra.Command{
+	Use:   "rewind <session-id> <message-id>",
+	Short: "Restore files to their state before a message",
+	Long: `Restore every file the agent changed in a session since the given
+message to the content it had before that message was sent.
+
+Files that were edited outside the agent after its last change are
+reported as conflicts and are only overwritten with --force.`,
+	Example: `
+# Preview what would be restored
+nexora sessions rewind <session-id> <message-id> --dry-run
+
+# Restore without a confirmation prompt
+nexora sessions rewind <session-id> <message-id> --force
+  `,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		force, _ := cmd.Flags().GetBool("force")
+		ctx := cmd.Context()
+
+		conn, err := connectProjectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		q := db.New(conn)
+		msgs, err := message.NewService(q).List(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to list messages: %w", err)
+		}
+		i := slices.IndexFunc(msgs, func(msg message.Message) bool { return msg.ID == args[1] })
+		if i < 0 {
+			return fmt.Errorf("message %s not found in session %s", args[1], args[0])
+		}
+
+		files := history.NewService(q, conn)
+		plan, err := history.PlanRewind(ctx, files, args[0], app.RewindPoint(msgs, i))
+		if err != nil {
+			return err
+		}
+		if len(plan.Changes) == 0 {
+			cmd.Println("No files were changed since that message.")
+			return nil
+		}
+
+		printRewindPlan(cmd, plan)
+		if dryRun {
+			return nil
+		}
+
+		conflicts := plan.Conflicts()
+		if len(conflicts) > 0 && !force {
+			return fmt.Errorf("%d file(s) were modified since the agent changed them; use --force to overwrite", len(conflicts))
+		}
+
+		if !force {
+			cmd.Print("Restore these files? [y/N]: ")
+			response, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil {
+				return fmt.Errorf("failed to read response: %w", err)
+			}
+			response = strings.TrimSpace(strings.ToLower(response))
+			if response != "y" && response != "yes" {
+				cmd.Println("Rewind cancelled.")
+				return nil
+			}
+		}
+
+		if err := history.ApplyRewind(ctx, files, plan, force); err != nil {
+			return err
+		}
+		cmd.Printf("✓ Restored %d file(s).\n", len(plan.Changes))
+		return nil
+	},
+}
+
+var sessionsStateCmd = &cobra.Command{
+	Use:   "state [session-id]",
+	Short: "Show the saved agent state of sessions",
+	Long: `Show the agent state saved for each session, or the details of one
+session's state: why it is paused, its progress, phases and recent
+transitions.
+
+Sessions are paused when nexora runs short of resources or the agent
+loops on the same errors. A paused session resumes when it is given a new
+prompt, or with "nexora sessions resume".`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := connectProjectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		states := session.NewAgentStateService(db.New(conn))
+		if len(args) == 1 {
+			saved, err := states.Get(cmd.Context(), args[0])
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("no agent state saved for session %s", args[0])
+			} else if err != nil {
+				return fmt.Errorf("failed to get agent state: %w", err)
+			}
+			printAgentState(cmd, saved)
+			return nil
+		}
+
+		saved, err := states.List(cmd.Context())
+		if err != nil {
+			return err
+		}
+		if len(saved) == 0 {
+			cmd.Println("No agent states saved.")
+			return nil
+		}
+		cmd.Printf("%-36s  %-18s  %-16s  %s\n", "Session", "State", "Updated", "Pause Reason")
+		for _, s := range saved {
+			state := s.State.String()
+			if s.IsPaused() {
+				state = "⏸ " + state
+			}
+			updated := time.Unix(s.UpdatedAt, 0).Format("2006-01-02 15:04")
+			cmd.Printf("%-36s  %-18s  %-16s  %s\n", s.SessionID, state, updated, truncate(s.PauseReason, 60))
+		}
+		return nil
+	},
+}
+
+var sessionsResumeCmd = &cobra.Command{
+	Use:   "resume <session-id>",
+	Short: "Resume a paused session",
+	Long: `Resume a session that was paused because nexora ran short of resources
+or the agent looped, so that it takes prompts again. Its progress and
+phases are kept.
+
+This resumes the saved state; a session that is open in a running nexora
+is resumed from there instead.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := connectProjectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		saved, err := session.NewAgentStateService(db.New(conn)).Resume(cmd.Context(), args[0])
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no agent state saved for session %s", args[0])
+		} else if err != nil {
+			return fmt.Errorf("failed to resume session: %w", err)
+		}
+		cmd.Printf("✓ Session %s resumed (%s).\n", saved.SessionID, saved.State)
+		return nil
+	},
+}
+
+func printAgentState(cmd *cobra.Command, s session.AgentState) {
+	snap := s.Snapshot
+	cmd.Printf("Session:     %s\n", s.SessionID)
+	cmd.Printf("State:       %s\n", s.State)
+	if s.PauseReason != "" {
+		cmd.Printf("Paused:      %s\n", s.PauseReason)
+	}
+	cmd.Printf("Updated:     %s\n", time.Unix(s.UpdatedAt, 0).Format(time.DateTime))
+	cmd.Printf("Tool calls:  %d\n", snap.ToolCallCount)
+	cmd.Printf("Files:       %d modified\n", len(snap.Progress.FilesModified))
+	if snap.Progress.ConsecutiveErrors > 0 {
+		cmd.Printf("Errors:      %d in a row\n", snap.Progress.ConsecutiveErrors)
+	}
+	if phase := snap.Phase; phase != nil && phase.TotalPhases > 0 {
+		cmd.Printf("Phase:       %d/%d %s\n", phase.CurrentPhase, phase.TotalPhases, phase.PhaseDescription)
+	}
+	if v := snap.Progress.Verdict; v != nil && v.Stuck() {
+		cmd.Printf("Loop check:  %s %s (%s policy): %s\n", v.Severity, v.Pattern, v.Policy, v.Reason)
+	}
+
+	if len(snap.Progress.Milestones) > 0 {
+		cmd.Println("\nMilestones:")
+		for _, m := range lastItems(snap.Progress.Milestones, 5) {
+			cmd.Printf("  %s  %s\n", m.Timestamp.Format(time.TimeOnly), m.Description)
+		}
+	}
+	if len(snap.History) > 0 {
+		cmd.Println("\nRecent transitions:")
+		for _, r := range lastItems(snap.History, 10) {
+			to := r.TransitionTo
+			if to == "" {
+				to = "(current)"
+			}
+			cmd.Printf("  %s  %-18s → %s\n", r.EnterTime.Format(time.TimeOnly), r.State, to)
+		}
+	}
+}
+
+// lastItems returns the last n items of s.
+func lastItems[T any](s []T, n int) []T {
+	return s[max(len(s)-n, 0):]
+}
+
+func printRewindPlan(cmd *cobra.Command, plan history.RewindPlan) {
+	for _, c := range plan.Changes {
+		status := "restore"
+		if c.Remove {
+			status = "remove"
+		}
+		if c.Conflict {
+			status += ", CONFLICT"
+		}
+		diff, additions, removals := c.Diff()
+		cmd.Printf("%s (%s, +%d -%d)\n", c.Path, status, additions, removals)
+		if diff != "" {
+			cmd.Println(diff)
+		}
+	}
+}
+
+// connectProjectDB opens the database of the project in the working
+// directory.
+func connectProjectDB(cmd *cobra.Command) (*sql.DB, error) {
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return nil, err
+	}
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	cfg, err := config.Load(cwd, dataDir, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	conn, err := db.Connect(context.Background(), cfg.Options.DataDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return conn, nil
+}
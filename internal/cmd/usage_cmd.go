@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/nexora/nexora/internal/db"
+	"github.com/nexora/nexora/internal/format"
+	"github.com/nexora/nexora/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage [session-id]",
+	Short: "Show token usage, cost and prompt cache savings",
+	Long: `Show the cost of the sessions in the current project along with how much
+of their input was served from the provider's prompt cache.
+
+The hit rate is the share of input tokens read from the cache. Savings are
+what those reads saved compared to regular input tokens, less the extra
+cost of writing to the cache.`,
+	Example: `
+# Usage of every session in the project
+nexora usage
+
+# Usage of a single session
+nexora usage <session-id>
+  `,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := connectProjectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		sessions := session.NewService(db.New(conn))
+		var list []session.Session
+		if len(args) == 1 {
+			sess, err := sessions.Get(cmd.Context(), args[0])
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("session %s not found", args[0])
+			} else if err != nil {
+				return fmt.Errorf("failed to get session: %w", err)
+			}
+			list = append(list, sess)
+		} else {
+			list, err = sessions.List(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to list sessions: %w", err)
+			}
+		}
+		if len(list) == 0 {
+			cmd.Println("No sessions found.")
+			return nil
+		}
+
+		printUsage(cmd.OutOrStdout(), list)
+		return nil
+	},
+}
+
+func printUsage(w io.Writer, sessions []session.Session) {
+	row := "%-36s  %8s  %8s  %8s  %6s  %9s  %s\n"
+	fmt.Fprintf(w, row, "ID", "Input", "Cached", "Written", "Hit", "Savings", "Cost")
+
+	var total session.Session
+	for _, s := range sessions {
+		fmt.Fprintf(w, row, s.ID, format.Tokens(s.TotalInputTokens), format.Tokens(s.CacheReadTokens),
+			format.Tokens(s.CacheCreationTokens), hitRate(s), fmt.Sprintf("$%.2f", s.CacheSavings), fmt.Sprintf("$%.2f", s.Cost))
+		total.TotalInputTokens += s.TotalInputTokens
+		total.CacheReadTokens += s.CacheReadTokens
+		total.CacheCreationTokens += s.CacheCreationTokens
+		total.CacheSavings += s.CacheSavings
+		total.Cost += s.Cost
+	}
+	if len(sessions) > 1 {
+		fmt.Fprintf(w, row, "Total", format.Tokens(total.TotalInputTokens), format.Tokens(total.CacheReadTokens),
+			format.Tokens(total.CacheCreationTokens), hitRate(total), fmt.Sprintf("$%.2f", total.CacheSavings), fmt.Sprintf("$%.2f", total.Cost))
+	}
+}
+
+func hitRate(s session.Session) string {
+	if s.TotalInputTokens == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", s.CacheHitRate()*100)
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nexora/nexora/internal/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageCmd(t *testing.T) {
+	assert.Equal(t, "usage [session-id]", usageCmd.Use)
+	assert.Error(t, usageCmd.Args(usageCmd, []string{"a", "b"}))
+}
+
+func TestPrintUsage(t *testing.T) {
+	var b bytes.Buffer
+	printUsage(&b, []session.Session{
+		{ID: "s1", TotalInputTokens: 100_000, CacheReadTokens: 80_000, CacheCreationTokens: 10_000, CacheSavings: 0.2, Cost: 0.5},
+		{ID: "s2", TotalInputTokens: 0, Cost: 0},
+	})
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, []string{"ID", "Input", "Cached", "Written", "Hit", "Savings", "Cost"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"s1", "100K", "80K", "10K", "80%", "$0.20", "$0.50"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"s2", "0", "0", "0", "-", "$0.00", "$0.00"}, strings.Fields(lines[2]))
+	assert.Equal(t, []string{"Total", "100K", "80K", "10K", "80%", "$0.20", "$0.50"}, strings.Fields(lines[3]))
+
+	b.Reset()
+	printUsage(&b, []session.Session{{ID: "only"}})
+	assert.NotContains(t, b.String(), "Total")
+}
@@ -3,12 +3,16 @@ package config
 import (
 	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
@@ -51,11 +55,16 @@ type SelectedModelType string
 
 const (
 	SelectedModelTypeLarge SelectedModelType = "large"
+	// SelectedModelTypeSmall is the fastest model, which is selected rather
+	// than configured.
+	SelectedModelTypeSmall SelectedModelType = "small"
 )
 
 const (
-	AgentCoder string = "coder"
-	AgentTask  string = "task"
+	AgentCoder    string = "coder"
+	AgentTask     string = "task"
+	AgentReviewer string = "reviewer"
+	AgentPlanner  string = "planner"
 )
 
 type SelectedModel struct {
@@ -115,6 +124,30 @@ type ProviderConfig struct {
 
 	// The provider models
 	Models []catwalk.Model `json:"models,omitempty" jsonschema:"description=List of models available from this provider"`
+
+	// Prompt cache breakpoints for providers that cache explicitly.
+	PromptCache *PromptCacheConfig `json:"prompt_cache,omitempty" jsonschema:"description=Where to place prompt cache breakpoints (Anthropic and Bedrock)"`
+}
+
+type CacheBreakpoint string
+
+const (
+	// CacheBreakpointSystem caches the system prompt.
+	CacheBreakpointSystem CacheBreakpoint = "system"
+	// CacheBreakpointTools caches the tool definitions.
+	CacheBreakpointTools CacheBreakpoint = "tools"
+	// CacheBreakpointTail caches the conversation up to its last messages,
+	// moving forward as the conversation grows.
+	CacheBreakpointTail CacheBreakpoint = "tail"
+)
+
+// PromptCacheConfig controls the cache breakpoints sent to providers that
+// cache prompts on request. Providers that cache automatically, like OpenAI
+// and Gemini, ignore it; their cached tokens are still accounted for.
+type PromptCacheConfig struct {
+	Disable      bool              `json:"disable,omitempty" jsonschema:"description=Send no cache breakpoints to this provider,default=false"`
+	Breakpoints  []CacheBreakpoint `json:"breakpoints,omitempty" jsonschema:"description=Parts of the request to mark as cache breakpoints; defaults to all of them,enum=system,enum=tools,enum=tail"`
+	TailMessages int               `json:"tail_messages,omitempty" jsonschema:"description=Number of trailing messages marked by the tail breakpoint,default=2,minimum=1,maximum=4"`
 }
 
 func (pc *ProviderConfig) SetupClaudeCode() {
@@ -172,6 +205,12 @@ type LSPConfig struct {
 type TUIOptions struct {
 	CompactMode bool   `json:"compact_mode,omitempty" jsonschema:"description=Enable compact mode for the TUI interface,default=false"`
 	DiffMode    string `json:"diff_mode,omitempty" jsonschema:"description=Diff mode for the TUI interface,enum=unified,enum=split"`
+	VimMode     bool   `json:"vim_mode,omitempty" jsonschema:"description=Enable vim modal editing in the chat editor,default=false"`
+	// Theme is a theme name or "auto" to pick DarkTheme or LightTheme from
+	// the terminal background.
+	Theme      string `json:"theme,omitempty" jsonschema:"description=TUI theme name or auto to follow the terminal background,default=auto,example=charmtone"`
+	DarkTheme  string `json:"dark_theme,omitempty" jsonschema:"description=Theme used by auto on dark terminal backgrounds,default=charmtone"`
+	LightTheme string `json:"light_theme,omitempty" jsonschema:"description=Theme used by auto on light terminal backgrounds,default=charmtone-light"`
 	// Here we can add themes later or any TUI related options
 	//
 
@@ -216,17 +255,97 @@ func (Attribution) JSONSchemaExtend(schema *jsonschema.Schema) {
 	}
 }
 
+// Sandbox configures the isolation of commands run by the bash tool. The
+// working directory is always writable.
+type Sandbox struct {
+	Enabled       bool     `json:"enabled,omitempty" jsonschema:"description=Run bash commands with the filesystem read-only outside the project and without network access (Linux only),default=false"`
+	Backend       string   `json:"backend,omitempty" jsonschema:"description=Sandbox implementation to use,enum=auto,enum=bubblewrap,enum=unshare,default=auto"`
+	Network       bool     `json:"network,omitempty" jsonschema:"description=Allow network access inside the sandbox,default=false"`
+	WritablePaths []string `json:"writable_paths,omitempty" jsonschema:"description=Additional paths commands may write to,example=~/.cache/go-build"`
+}
+
+// Telemetry configures the export of traces and metrics of agent runs to an
+// OpenTelemetry collector over OTLP.
+type Telemetry struct {
+	Enabled            bool              `json:"enabled,omitempty" jsonschema:"description=Export traces and metrics of agent turns, model calls and tool executions,default=false"`
+	Endpoint           string            `json:"endpoint,omitempty" jsonschema:"description=Collector address as host:port or URL; defaults to OTEL_EXPORTER_OTLP_ENDPOINT or localhost,example=localhost:4317,example=https://otlp.example.com"`
+	Protocol           string            `json:"protocol,omitempty" jsonschema:"description=OTLP transport,enum=grpc,enum=http,default=grpc"`
+	Insecure           bool              `json:"insecure,omitempty" jsonschema:"description=Connect to a host:port endpoint without TLS,default=false"`
+	Headers            map[string]string `json:"headers,omitempty" jsonschema:"description=Headers sent with every export; values can reference environment variables,example={\"authorization\":\"Bearer $OTLP_TOKEN\"}"`
+	ServiceName        string            `json:"service_name,omitempty" jsonschema:"description=Service name of the exported telemetry,default=nexora"`
+	ResourceAttributes map[string]string `json:"resource_attributes,omitempty" jsonschema:"description=Extra resource attributes identifying this instance,example={\"deployment.environment\":\"ci\"}"`
+	SampleRatio        float64           `json:"sample_ratio,omitempty" jsonschema:"description=Fraction of agent turns traced; 0 traces all of them,minimum=0,maximum=1"`
+	MetricInterval     int               `json:"metric_interval,omitempty" jsonschema:"description=Seconds between metric exports,default=60,minimum=1"`
+}
+
+// ResolvedHeaders returns the headers of t with environment variables
+// resolved.
+func (t Telemetry) ResolvedHeaders() map[string]string {
+	resolver := NewShellVariableResolver(env.New())
+	headers := make(map[string]string, len(t.Headers))
+	for k, v := range t.Headers {
+		resolved, err := resolver.ResolveValue(v)
+		if err != nil {
+			slog.Error("error resolving telemetry header variable", "error", err, "header", k)
+			continue
+		}
+		headers[k] = resolved
+	}
+	return headers
+}
+
+// SessionLog configures the session log, which records the tool calls,
+// model requests, edits and state transitions of every session in
+// databases or files for later analysis.
+type SessionLog struct {
+	Enabled    bool             `json:"enabled,omitempty" jsonschema:"description=Record tool calls, model requests, edits and state transitions of every session,default=false"`
+	InstanceID string           `json:"instance_id,omitempty" jsonschema:"description=Name of this instance in the records; defaults to NEXORA_INSTANCE_ID or the hostname,example=ci-runner-3"`
+	Sinks      []SessionLogSink `json:"sinks,omitempty" jsonschema:"description=Where records are written; defaults to a SQLite database in the data directory"`
+}
+
+// SessionLogSink is a database or file the session log is written to.
+type SessionLogSink struct {
+	Type       string `json:"type" jsonschema:"description=Kind of sink,enum=sqlite,enum=jsonl,enum=postgres"`
+	DSN        string `json:"dsn,omitempty" jsonschema:"description=PostgreSQL connection string; can reference environment variables,example=postgres://nexora:$PGPASSWORD@db/nexora_sessions"`
+	Path       string `json:"path,omitempty" jsonschema:"description=File of a sqlite or jsonl sink; relative paths are in the data directory,example=session_log.db,example=session_log/session.jsonl"`
+	MaxSizeMB  int    `json:"max_size_mb,omitempty" jsonschema:"description=Size in megabytes at which a jsonl file is rotated,default=100,minimum=1"`
+	MaxBackups int    `json:"max_backups,omitempty" jsonschema:"description=Rotated jsonl files to keep; 0 keeps all,minimum=0"`
+	MaxAgeDays int    `json:"max_age_days,omitempty" jsonschema:"description=Days after which rotated jsonl files are removed; 0 keeps them,minimum=0"`
+	Compress   bool   `json:"compress,omitempty" jsonschema:"description=Gzip rotated jsonl files,default=false"`
+}
+
+// ResolvedDSN returns the DSN of s with environment variables resolved.
+func (s SessionLogSink) ResolvedDSN() (string, error) {
+	if s.DSN == "" {
+		return "", nil
+	}
+	return NewShellVariableResolver(env.New()).ResolveValue(s.DSN)
+}
+
+// Delegates configures how the tasks of the delegate tool are run. By
+// default each delegate is a separate nexora process in a TMUX session.
+type Delegates struct {
+	Inline      bool `json:"inline,omitempty" jsonschema:"description=Run delegates inside the nexora process instead of in TMUX sessions,default=false"`
+	Timeout     int  `json:"timeout,omitempty" jsonschema:"description=Minutes a delegate may run before it is killed,default=30,minimum=1"`
+	IdleTimeout int  `json:"idle_timeout,omitempty" jsonschema:"description=Minutes a delegate may go without progress before it is killed,default=10,minimum=1"`
+}
+
 type Options struct {
 	ContextPaths              []string     `json:"context_paths,omitempty" jsonschema:"description=Paths to files containing context information for the AI,example=.cursorrules,example=NEXORA.md"`
 	TUI                       *TUIOptions  `json:"tui,omitempty" jsonschema:"description=Terminal user interface options"`
 	Debug                     bool         `json:"debug,omitempty" jsonschema:"description=Enable debug logging,default=false"`
 	DebugLSP                  bool         `json:"debug_lsp,omitempty" jsonschema:"description=Enable debug logging for LSP servers,default=false"`
+	AutoLSP                   bool         `json:"auto_lsp,omitempty" jsonschema:"description=Detect project languages and start their LSP servers without an lsp configuration,default=false"`
 	DisableAutoSummarize      bool         `json:"disable_auto_summarize,omitempty" jsonschema:"description=Disable automatic conversation summarization,default=false"`
 	DataDirectory             string       `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data (relative to working directory),default=.nexora,example=.nexora"` // Relative to the cwd
 	DisabledTools             []string     `json:"disabled_tools" jsonschema:"description=Tools to disable"`
 	DisableProviderAutoUpdate bool         `json:"disable_provider_auto_update,omitempty" jsonschema:"description=Disable providers auto-update,default=false"`
 	Attribution               *Attribution `json:"attribution,omitempty" jsonschema:"description=Attribution settings for generated content"`
 	IsTurboMode               bool         `json:"is_turbo_mode,omitempty" jsonschema:"description=Enable turbo mode for faster performance,default=false"`
+	Sandbox                   *Sandbox     `json:"sandbox,omitempty" jsonschema:"description=Run bash commands in a sandbox"`
+	Telemetry                 *Telemetry   `json:"telemetry,omitempty" jsonschema:"description=Export OpenTelemetry traces and metrics of agent runs"`
+	SessionLog                *SessionLog  `json:"session_log,omitempty" jsonschema:"description=Record tool calls, model requests and state transitions of sessions for analysis"`
+	Delegates                 *Delegates   `json:"delegates,omitempty" jsonschema:"description=How delegated tasks are run"`
 
 	InitializeAs string `json:"initialize_as,omitempty" jsonschema:"description=Name of the context file to create/update during project initialization,default=AGENTS.md,example=AGENTS.md,example=NEXORA.md,example=CLAUDE.md,example=docs/LLMs.md"`
 }
@@ -315,6 +434,22 @@ type Agent struct {
 
 	// Overrides the context paths for this agent
 	ContextPaths []string `json:"context_paths,omitempty"`
+
+	// SystemPrompt is the prompt of a user-defined agent, which takes the
+	// place of the built-in prompts. Only user-defined agents have one.
+	SystemPrompt string `json:"system_prompt,omitempty"`
+	// Color is the display color of a user-defined agent.
+	Color string `json:"color,omitempty"`
+	// Triggers are requests a user-defined agent is suited for, listed to
+	// the coder when it picks an agent.
+	Triggers []string `json:"triggers,omitempty"`
+	// Path is the file a user-defined agent was loaded from.
+	Path string `json:"path,omitempty"`
+}
+
+// IsCustom reports whether the agent is user-defined.
+func (a Agent) IsCustom() bool {
+	return a.SystemPrompt != ""
 }
 
 type Tools struct {
@@ -336,6 +471,61 @@ type AIOPSConfig struct {
 	Endpoint string        `json:"endpoint" yaml:"endpoint" jsonschema:"description=AIOPS service endpoint (e.g., http://gpu-box:8420)"`
 	Timeout  time.Duration `json:"timeout" yaml:"timeout" jsonschema:"description=Request timeout for AI operations"`
 	Fallback bool          `json:"fallback" yaml:"fallback" jsonschema:"description=Continue without AIOPs if unavailable"`
+
+	Scriptor      AIOPSScriptorConfig      `json:"scriptor,omitzero" yaml:"scriptor" jsonschema:"description=Batching of similar tool calls the model makes in one step"`
+	LoopDetection AIOPSLoopDetectionConfig `json:"loop_detection,omitzero" yaml:"loop_detection" jsonschema:"description=Detection of sessions stuck in a loop or drifting from their task"`
+}
+
+// AIOPSScriptorConfig configures the batching of the grep, view and edit
+// calls a model makes in one step, which runs in-process.
+type AIOPSScriptorConfig struct {
+	Disabled     bool `json:"disabled,omitempty" yaml:"disabled" jsonschema:"description=Run every tool call on its own,default=false"`
+	MinBatchSize int  `json:"min_batch_size,omitempty" yaml:"min_batch_size" jsonschema:"description=Number of similar calls in one step that are batched,default=3,example=3"`
+}
+
+// Loop policies of AIOPSLoopDetectionConfig.
+const (
+	LoopPolicyHeuristic = "heuristic"
+	LoopPolicyModel     = "model"
+	LoopPolicyAIOPS     = "aiops"
+)
+
+// AIOPSLoopDetectionConfig configures the policy that judges whether a
+// session is stuck in a loop or drifting from its task. The heuristics run
+// offline; the model and aiops policies add the judgment of the small model or
+// of the AIOPS service on top of them.
+type AIOPSLoopDetectionConfig struct {
+	Policy        string        `json:"policy,omitempty" yaml:"policy" jsonschema:"description=Policy that judges the progress of sessions,enum=heuristic,enum=model,enum=aiops,default=heuristic"`
+	CheckInterval int           `json:"check_interval,omitempty" yaml:"check_interval" jsonschema:"description=Number of tool calls between checks of the small model or AIOPS service,default=5,example=10"`
+	Timeout       time.Duration `json:"timeout,omitempty" yaml:"timeout" jsonschema:"description=Timeout of a check of the small model or AIOPS service,default=10s"`
+	MaxWarnings   *int          `json:"max_warnings,omitempty" yaml:"max_warnings" jsonschema:"description=Number of times the model is warned that it may be stuck before the session is paused,default=2,example=3"`
+}
+
+// Warnings returns the number of times the model is warned before the
+// session is paused.
+func (c AIOPSLoopDetectionConfig) Warnings() int {
+	return ptrValOr(c.MaxWarnings, 2)
+}
+
+// RecoveryConfig configures how sessions recover from failed tool calls.
+type RecoveryConfig struct {
+	MaxAttempts int            `json:"max_attempts,omitempty" yaml:"max_attempts" jsonschema:"description=Number of recovery attempts of a failed tool call across strategies,default=3,example=5"`
+	Rules       []RecoveryRule `json:"rules,omitempty" yaml:"rules" jsonschema:"description=Recovery rules tried in order before the built-in recovery strategies"`
+}
+
+// RecoveryRule is a recovery strategy declared in config. A failed tool call,
+// or a bash command exiting with a non-zero code, matches the rule when it
+// matches all of its error_type, tool and match conditions.
+type RecoveryRule struct {
+	Name       string            `json:"name" yaml:"name" jsonschema:"required,description=Name of the rule in logs and statistics,example=go mod tidy"`
+	ErrorType  string            `json:"error_type,omitempty" yaml:"error_type" jsonschema:"description=Error type the rule matches,enum=file_outdated,enum=edit_failed,enum=loop_detected,enum=timeout,enum=resource_limit,enum=panic,enum=command_failed,enum=generic"`
+	Tool       string            `json:"tool,omitempty" yaml:"tool" jsonschema:"description=Name of the tool the rule matches,example=bash"`
+	Match      string            `json:"match,omitempty" yaml:"match" jsonschema:"description=Regular expression the error or the output of the tool matches,example=^command failed: go build"`
+	Action     string            `json:"action" yaml:"action" jsonschema:"required,description=What the rule does,enum=run_command,enum=prompt,enum=switch_model,enum=pause"`
+	Command    string            `json:"command,omitempty" yaml:"command" jsonschema:"description=Shell command run_command runs,example=go mod tidy"`
+	Prompt     string            `json:"prompt,omitempty" yaml:"prompt" jsonschema:"description=Instructions for the model; added to the result of the failed tool call"`
+	Model      SelectedModelType `json:"model,omitempty" yaml:"model" jsonschema:"description=Model switch_model switches the session to,enum=large,enum=small"`
+	MaxRetries int               `json:"max_retries,omitempty" yaml:"max_retries" jsonschema:"description=Number of times the rule runs for a session before its next prompt,default=1,example=2"`
 }
 
 // Config holds the configuration for nexora.
@@ -362,6 +552,12 @@ type Config struct {
 
 	AIOPS AIOPSConfig `json:"aiops,omitempty" jsonschema:"description=AI operations service configuration for local model support"`
 
+	Recovery RecoveryConfig `json:"recovery,omitzero" jsonschema:"description=Recovery from failed tool calls"`
+
+	// Keybindings overrides TUI key bindings by action name, e.g.
+	// "chat.new_session". An empty list disables the action.
+	Keybindings map[string][]string `json:"keybindings,omitempty" jsonschema:"description=TUI key binding overrides by action name (scope.action) to a list of keys; an empty list disables the action,example={\"chat.new_session\":[\"ctrl+shift+n\"]}"`
+
 	Agents map[string]Agent `json:"-"`
 
 	// Internal
@@ -375,12 +571,43 @@ type Config struct {
 	dataConfigDir   string             `json:"-"`
 	knownProviders  []catwalk.Provider `json:"-"`
 	modelsNeedSetup bool               `json:"-"` // Flag to indicate if TUI setup is needed for models
+	httpTransport   http.RoundTripper  `json:"-"`
+	toolResults     ToolResultSource   `json:"-"`
 }
 
 func (c *Config) WorkingDir() string {
 	return c.workingDir
 }
 
+// HTTPTransport returns the transport provider requests are sent through,
+// or nil for the default.
+func (c *Config) HTTPTransport() http.RoundTripper {
+	return c.httpTransport
+}
+
+// SetHTTPTransport routes provider requests through rt, for example to
+// record or replay them.
+func (c *Config) SetHTTPTransport(rt http.RoundTripper) {
+	c.httpTransport = rt
+}
+
+// ToolResultSource answers tool calls instead of running them, for example
+// with the results of a recording. It returns ok false for calls it has no
+// result for.
+type ToolResultSource func(ctx context.Context, name, input string) (output string, isError, ok bool)
+
+// ToolResults returns the source tool calls are answered from, or nil when
+// tools run.
+func (c *Config) ToolResults() ToolResultSource {
+	return c.toolResults
+}
+
+// SetToolResults answers every tool call from source instead of running the
+// tool.
+func (c *Config) SetToolResults(source ToolResultSource) {
+	c.toolResults = source
+}
+
 // ModelsNeedSetup returns true if models need TUI setup
 func (c *Config) ModelsNeedSetup() bool {
 	return c.modelsNeedSetup
@@ -498,6 +725,28 @@ func (c *Config) SetCompactMode(enabled bool) error {
 	return c.SetConfigField("options.tui.compact_mode", enabled)
 }
 
+func (c *Config) SetTheme(name string) error {
+	if c.Options == nil {
+		c.Options = &Options{}
+	}
+	if c.Options.TUI == nil {
+		c.Options.TUI = &TUIOptions{}
+	}
+	c.Options.TUI.Theme = name
+	return c.SetConfigField("options.tui.theme", name)
+}
+
+func (c *Config) SetVimMode(enabled bool) error {
+	if c.Options == nil {
+		c.Options = &Options{}
+	}
+	if c.Options.TUI == nil {
+		c.Options.TUI = &TUIOptions{}
+	}
+	c.Options.TUI.VimMode = enabled
+	return c.SetConfigField("options.tui.vim_mode", enabled)
+}
+
 func (c *Config) Resolve(key string) (string, error) {
 	if c.resolver == nil {
 		return "", fmt.Errorf("no variable resolver configured")
@@ -816,6 +1065,12 @@ func allToolNames() []string {
 		"sourcegraph",
 		"view",
 		"write",
+		"notebook_edit",
+		"git_status",
+		"git_diff",
+		"git_log",
+		"git_commit",
+		"git_branch",
 	}
 }
 
@@ -828,11 +1083,26 @@ func resolveAllowedTools(allTools []string, disabledTools []string) []string {
 }
 
 func resolveReadOnlyTools(tools []string) []string {
-	readOnlyTools := []string{"glob", "grep", "ls", "sourcegraph", "view"}
+	readOnlyTools := []string{"glob", "grep", "ls", "sourcegraph", "view", "git_status", "git_diff", "git_log"}
 	// filter to only include tools that are in allowedtools (include mode)
 	return filterSlice(tools, readOnlyTools, true)
 }
 
+// resolveReviewTools returns the tools available to the reviewer agent: read
+// access to the tree, the diff and LSP, but no search outside the project.
+func resolveReviewTools(tools []string) []string {
+	reviewTools := []string{"glob", "grep", "ls", "view", "lsp_diagnostics", "lsp_references", "git_status", "git_diff", "git_log"}
+	return filterSlice(tools, reviewTools, true)
+}
+
+// resolvePlannerTools returns the tools available in plan mode: everything
+// that reads the project, plus submitting the plan. Nothing that edits files
+// or runs commands.
+func resolvePlannerTools(tools []string) []string {
+	plannerTools := []string{"glob", "grep", "ls", "sourcegraph", "view", "lsp_diagnostics", "lsp_references", "git_status", "git_diff", "git_log"}
+	return append(filterSlice(tools, plannerTools, true), "submit_plan")
+}
+
 func filterSlice(data []string, mask []string, include bool) []string {
 	filtered := []string{}
 	for _, s := range data {
@@ -868,10 +1138,109 @@ func (c *Config) SetupAgents() {
 			// NO MCPs or LSPs by default
 			AllowedMCP: map[string][]string{},
 		},
+
+		AgentReviewer: {
+			ID:           AgentReviewer,
+			Name:         "Reviewer",
+			Description:  "An agent that reviews the changes on a branch and reports findings.",
+			Model:        SelectedModelTypeLarge,
+			ContextPaths: c.Options.ContextPaths,
+			AllowedTools: resolveReviewTools(allowedTools),
+			AllowedMCP:   map[string][]string{},
+		},
+
+		AgentPlanner: {
+			ID:           AgentPlanner,
+			Name:         "Planner",
+			Description:  "An agent that explores the project read-only and proposes a plan for approval.",
+			Model:        SelectedModelTypeLarge,
+			ContextPaths: c.Options.ContextPaths,
+			AllowedTools: resolvePlannerTools(allowedTools),
+			// MCP tools may have side effects
+			AllowedMCP: map[string][]string{},
+		},
 	}
 	c.Agents = agents
 }
 
+// subAgentExcludedTools are the tools user-defined agents never get: they
+// run as sub-agents and cannot start sub-agents of their own.
+var subAgentExcludedTools = []string{"agent", "delegate", "submit_plan"}
+
+var agentIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// IsValidAgentID reports whether id can name an agent, which it must to be
+// mentioned as @id in a prompt.
+func IsValidAgentID(id string) bool {
+	return agentIDPattern.MatchString(id)
+}
+
+// CustomAgentsDirs returns the directories user-defined agents are loaded
+// from, in increasing order of precedence: agents/ in the global config
+// directory and .nexora/agents/ in the project.
+func (c *Config) CustomAgentsDirs() []string {
+	return []string{
+		filepath.Join(filepath.Dir(GlobalConfig()), "agents"),
+		filepath.Join(c.WorkingDir(), defaultDataDirectory, "agents"),
+	}
+}
+
+// AddCustomAgents registers user-defined agents. An agent's tools are limited
+// to the enabled tools, and it gets all of them when it lists none. Agents
+// named like a built-in agent are rejected.
+func (c *Config) AddCustomAgents(agents ...Agent) error {
+	if c.Agents == nil {
+		c.Agents = map[string]Agent{}
+	}
+	enabled := resolveAllowedTools(allToolNames(), c.Options.DisabledTools)
+	enabled = filterSlice(enabled, subAgentExcludedTools, false)
+
+	var errs []error
+	for _, agent := range agents {
+		if !IsValidAgentID(agent.ID) {
+			errs = append(errs, fmt.Errorf("agent %q: name may only contain letters, digits, '-' and '_'", agent.ID))
+			continue
+		}
+		if existing, ok := c.Agents[agent.ID]; ok && !existing.IsCustom() {
+			errs = append(errs, fmt.Errorf("agent %q: name is reserved for a built-in agent", agent.ID))
+			continue
+		}
+		if !agent.IsCustom() {
+			errs = append(errs, fmt.Errorf("agent %q: system prompt is required", agent.ID))
+			continue
+		}
+		if agent.AllowedTools == nil {
+			agent.AllowedTools = enabled
+		} else {
+			for _, tool := range agent.AllowedTools {
+				if !slices.Contains(allToolNames(), tool) {
+					slog.Warn("Ignoring unknown tool of agent", "agent", agent.ID, "tool", tool)
+				}
+			}
+			agent.AllowedTools = filterSlice(enabled, agent.AllowedTools, true)
+		}
+		if agent.Model == "" {
+			agent.Model = SelectedModelTypeLarge
+		}
+		if agent.ContextPaths == nil {
+			agent.ContextPaths = c.Options.ContextPaths
+		}
+		c.Agents[agent.ID] = agent
+	}
+	return errors.Join(errs...)
+}
+
+// CustomAgents returns the user-defined agents sorted by ID.
+func (c *Config) CustomAgents() []Agent {
+	var agents []Agent
+	for _, id := range slices.Sorted(maps.Keys(c.Agents)) {
+		if c.Agents[id].IsCustom() {
+			agents = append(agents, c.Agents[id])
+		}
+	}
+	return agents
+}
+
 func (c *Config) Resolver() VariableResolver {
 	return c.resolver
 }
@@ -251,6 +251,7 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			ExtraBody:          config.ExtraBody,
 			ExtraParams:        make(map[string]string),
 			Models:             p.Models,
+			PromptCache:        config.PromptCache,
 		}
 
 		// Apply environment variable overrides after base configuration
@@ -758,6 +759,16 @@ func GlobalDataDir() string {
 	return filepath.Join(home.Dir(), ".local", "share", appName)
 }
 
+// ThemeDirs returns the directories TUI theme files are loaded from, in
+// increasing order of precedence: themes/ in the global config directory and
+// in the data directory.
+func ThemeDirs() []string {
+	return []string{
+		filepath.Join(filepath.Dir(GlobalConfig()), "themes"),
+		filepath.Join(GlobalDataDir(), "themes"),
+	}
+}
+
 // EnsureGlobalDataDir creates the global data directory if it doesn't exist.
 // Returns the path to the directory.
 func EnsureGlobalDataDir() (string, error) {
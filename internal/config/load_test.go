@@ -527,7 +527,17 @@ func TestConfig_setupAgentsWithNoDisabledTools(t *testing.T) {
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
-	assert.Equal(t, []string{"glob", "grep", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
+	assert.Equal(t, []string{"glob", "grep", "ls", "sourcegraph", "view", "git_status", "git_diff", "git_log"}, taskAgent.AllowedTools)
+
+	reviewerAgent, ok := cfg.Agents[AgentReviewer]
+	require.True(t, ok)
+	assert.Equal(t, []string{"lsp_diagnostics", "lsp_references", "glob", "grep", "ls", "view", "git_status", "git_diff", "git_log"}, reviewerAgent.AllowedTools)
+	assert.Empty(t, reviewerAgent.AllowedMCP)
+
+	plannerAgent, ok := cfg.Agents[AgentPlanner]
+	require.True(t, ok)
+	assert.Equal(t, []string{"lsp_diagnostics", "lsp_references", "glob", "grep", "ls", "sourcegraph", "view", "git_status", "git_diff", "git_log", "submit_plan"}, plannerAgent.AllowedTools)
+	assert.Empty(t, plannerAgent.AllowedMCP)
 }
 
 func TestConfig_setupAgentsWithDisabledTools(t *testing.T) {
@@ -545,11 +555,11 @@ func TestConfig_setupAgentsWithDisabledTools(t *testing.T) {
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
 
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "delegate", "multiedit", "lsp_diagnostics", "lsp_references", "fetch", "agentic_fetch", "glob", "ls", "sourcegraph", "view", "write"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "delegate", "multiedit", "lsp_diagnostics", "lsp_references", "fetch", "agentic_fetch", "glob", "ls", "sourcegraph", "view", "write", "notebook_edit", "git_status", "git_diff", "git_log", "git_commit", "git_branch"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
-	assert.Equal(t, []string{"glob", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
+	assert.Equal(t, []string{"glob", "ls", "sourcegraph", "view", "git_status", "git_diff", "git_log"}, taskAgent.AllowedTools)
 }
 
 func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
@@ -561,6 +571,9 @@ func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 				"ls",
 				"sourcegraph",
 				"view",
+				"git_status",
+				"git_diff",
+				"git_log",
 			},
 		},
 	}
@@ -568,11 +581,65 @@ func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 	cfg.SetupAgents()
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "delegate", "download", "edit", "multiedit", "lsp_diagnostics", "lsp_references", "fetch", "agentic_fetch", "write"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "delegate", "download", "edit", "multiedit", "lsp_diagnostics", "lsp_references", "fetch", "agentic_fetch", "write", "notebook_edit", "git_commit", "git_branch"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
 	assert.Equal(t, []string{}, taskAgent.AllowedTools)
+
+	// The planner can still submit a plan.
+	plannerAgent, ok := cfg.Agents[AgentPlanner]
+	require.True(t, ok)
+	assert.Equal(t, []string{"lsp_diagnostics", "lsp_references", "submit_plan"}, plannerAgent.AllowedTools)
+}
+
+func TestConfig_addCustomAgents(t *testing.T) {
+	cfg := &Config{
+		Options: &Options{
+			DisabledTools: []string{"bash"},
+			ContextPaths:  []string{"AGENTS.md"},
+		},
+	}
+	cfg.SetupAgents()
+
+	err := cfg.AddCustomAgents(
+		Agent{ID: "reader", Name: "reader", SystemPrompt: "Read things.", AllowedTools: []string{"view", "grep", "bash", "agent", "nope"}},
+		Agent{ID: "anything", Name: "anything", SystemPrompt: "Do things."},
+	)
+	require.NoError(t, err)
+
+	reader, ok := cfg.Agents["reader"]
+	require.True(t, ok)
+	assert.True(t, reader.IsCustom())
+	assert.Equal(t, []string{"grep", "view"}, reader.AllowedTools)
+	assert.Equal(t, SelectedModelTypeLarge, reader.Model)
+	assert.Equal(t, []string{"AGENTS.md"}, reader.ContextPaths)
+
+	anything := cfg.Agents["anything"]
+	assert.NotContains(t, anything.AllowedTools, "agent")
+	assert.NotContains(t, anything.AllowedTools, "delegate")
+	assert.NotContains(t, anything.AllowedTools, "bash")
+	assert.Contains(t, anything.AllowedTools, "edit")
+
+	custom := cfg.CustomAgents()
+	require.Len(t, custom, 2)
+	assert.Equal(t, "anything", custom[0].ID)
+	assert.Equal(t, "reader", custom[1].ID)
+}
+
+func TestConfig_addCustomAgentsRejectsBuiltInNames(t *testing.T) {
+	cfg := &Config{Options: &Options{}}
+	cfg.SetupAgents()
+
+	err := cfg.AddCustomAgents(
+		Agent{ID: AgentCoder, Name: AgentCoder, SystemPrompt: "Hijack."},
+		Agent{ID: "empty", Name: "empty"},
+	)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reserved")
+	assert.Contains(t, err.Error(), "system prompt is required")
+	assert.False(t, cfg.Agents[AgentCoder].IsCustom())
+	assert.NotContains(t, cfg.Agents, "empty")
 }
 
 func TestConfig_configureProvidersWithDisabledProvider(t *testing.T) {
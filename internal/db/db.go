@@ -33,9 +33,18 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.createMessageStmt, err = db.PrepareContext(ctx, createMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateMessage: %w", err)
 	}
+	if q.createModelSpeedSampleStmt, err = db.PrepareContext(ctx, createModelSpeedSample); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateModelSpeedSample: %w", err)
+	}
+	if q.createRecoveryAttemptStmt, err = db.PrepareContext(ctx, createRecoveryAttempt); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateRecoveryAttempt: %w", err)
+	}
 	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateSession: %w", err)
 	}
+	if q.deleteAgentStateStmt, err = db.PrepareContext(ctx, deleteAgentState); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAgentState: %w", err)
+	}
 	if q.deleteCheckpointStmt, err = db.PrepareContext(ctx, deleteCheckpoint); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteCheckpoint: %w", err)
 	}
@@ -45,6 +54,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.deleteMessageStmt, err = db.PrepareContext(ctx, deleteMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteMessage: %w", err)
 	}
+	if q.deleteModelSpeedSamplesBeforeStmt, err = db.PrepareContext(ctx, deleteModelSpeedSamplesBefore); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteModelSpeedSamplesBefore: %w", err)
+	}
 	if q.deleteOldCheckpointsStmt, err = db.PrepareContext(ctx, deleteOldCheckpoints); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteOldCheckpoints: %w", err)
 	}
@@ -57,6 +69,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.deleteSessionMessagesStmt, err = db.PrepareContext(ctx, deleteSessionMessages); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSessionMessages: %w", err)
 	}
+	if q.getAgentStateStmt, err = db.PrepareContext(ctx, getAgentState); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAgentState: %w", err)
+	}
 	if q.getCheckpointStmt, err = db.PrepareContext(ctx, getCheckpoint); err != nil {
 		return nil, fmt.Errorf("error preparing query GetCheckpoint: %w", err)
 	}
@@ -75,6 +90,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getSessionByIDStmt, err = db.PrepareContext(ctx, getSessionByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSessionByID: %w", err)
 	}
+	if q.listAgentStatesStmt, err = db.PrepareContext(ctx, listAgentStates); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAgentStates: %w", err)
+	}
 	if q.listCheckpointsStmt, err = db.PrepareContext(ctx, listCheckpoints); err != nil {
 		return nil, fmt.Errorf("error preparing query ListCheckpoints: %w", err)
 	}
@@ -90,9 +108,18 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.listMessagesBySessionStmt, err = db.PrepareContext(ctx, listMessagesBySession); err != nil {
 		return nil, fmt.Errorf("error preparing query ListMessagesBySession: %w", err)
 	}
+	if q.listModelSpeedSamplesStmt, err = db.PrepareContext(ctx, listModelSpeedSamples); err != nil {
+		return nil, fmt.Errorf("error preparing query ListModelSpeedSamples: %w", err)
+	}
 	if q.listNewFilesStmt, err = db.PrepareContext(ctx, listNewFiles); err != nil {
 		return nil, fmt.Errorf("error preparing query ListNewFiles: %w", err)
 	}
+	if q.listRecoveryAttemptsStmt, err = db.PrepareContext(ctx, listRecoveryAttempts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRecoveryAttempts: %w", err)
+	}
+	if q.listRecoveryAttemptsBySessionStmt, err = db.PrepareContext(ctx, listRecoveryAttemptsBySession); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRecoveryAttemptsBySession: %w", err)
+	}
 	if q.listSessionsStmt, err = db.PrepareContext(ctx, listSessions); err != nil {
 		return nil, fmt.Errorf("error preparing query ListSessions: %w", err)
 	}
@@ -102,6 +129,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.updateSessionStmt, err = db.PrepareContext(ctx, updateSession); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateSession: %w", err)
 	}
+	if q.upsertAgentStateStmt, err = db.PrepareContext(ctx, upsertAgentState); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertAgentState: %w", err)
+	}
 	return &q, nil
 }
 
@@ -122,11 +152,26 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createMessageStmt: %w", cerr)
 		}
 	}
+	if q.createModelSpeedSampleStmt != nil {
+		if cerr := q.createModelSpeedSampleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createModelSpeedSampleStmt: %w", cerr)
+		}
+	}
+	if q.createRecoveryAttemptStmt != nil {
+		if cerr := q.createRecoveryAttemptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createRecoveryAttemptStmt: %w", cerr)
+		}
+	}
 	if q.createSessionStmt != nil {
 		if cerr := q.createSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createSessionStmt: %w", cerr)
 		}
 	}
+	if q.deleteAgentStateStmt != nil {
+		if cerr := q.deleteAgentStateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAgentStateStmt: %w", cerr)
+		}
+	}
 	if q.deleteCheckpointStmt != nil {
 		if cerr := q.deleteCheckpointStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteCheckpointStmt: %w", cerr)
@@ -142,6 +187,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteMessageStmt: %w", cerr)
 		}
 	}
+	if q.deleteModelSpeedSamplesBeforeStmt != nil {
+		if cerr := q.deleteModelSpeedSamplesBeforeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteModelSpeedSamplesBeforeStmt: %w", cerr)
+		}
+	}
 	if q.deleteOldCheckpointsStmt != nil {
 		if cerr := q.deleteOldCheckpointsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteOldCheckpointsStmt: %w", cerr)
@@ -162,6 +212,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteSessionMessagesStmt: %w", cerr)
 		}
 	}
+	if q.getAgentStateStmt != nil {
+		if cerr := q.getAgentStateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAgentStateStmt: %w", cerr)
+		}
+	}
 	if q.getCheckpointStmt != nil {
 		if cerr := q.getCheckpointStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getCheckpointStmt: %w", cerr)
@@ -192,6 +247,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getSessionByIDStmt: %w", cerr)
 		}
 	}
+	if q.listAgentStatesStmt != nil {
+		if cerr := q.listAgentStatesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAgentStatesStmt: %w", cerr)
+		}
+	}
 	if q.listCheckpointsStmt != nil {
 		if cerr := q.listCheckpointsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listCheckpointsStmt: %w", cerr)
@@ -217,11 +277,26 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listMessagesBySessionStmt: %w", cerr)
 		}
 	}
+	if q.listModelSpeedSamplesStmt != nil {
+		if cerr := q.listModelSpeedSamplesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listModelSpeedSamplesStmt: %w", cerr)
+		}
+	}
 	if q.listNewFilesStmt != nil {
 		if cerr := q.listNewFilesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listNewFilesStmt: %w", cerr)
 		}
 	}
+	if q.listRecoveryAttemptsStmt != nil {
+		if cerr := q.listRecoveryAttemptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRecoveryAttemptsStmt: %w", cerr)
+		}
+	}
+	if q.listRecoveryAttemptsBySessionStmt != nil {
+		if cerr := q.listRecoveryAttemptsBySessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRecoveryAttemptsBySessionStmt: %w", cerr)
+		}
+	}
 	if q.listSessionsStmt != nil {
 		if cerr := q.listSessionsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listSessionsStmt: %w", cerr)
@@ -237,6 +312,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing updateSessionStmt: %w", cerr)
 		}
 	}
+	if q.upsertAgentStateStmt != nil {
+		if cerr := q.upsertAgentStateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertAgentStateStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -274,65 +354,85 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                          DBTX
-	tx                          *sql.Tx
-	createCheckpointStmt        *sql.Stmt
-	createFileStmt              *sql.Stmt
-	createMessageStmt           *sql.Stmt
-	createSessionStmt           *sql.Stmt
-	deleteCheckpointStmt        *sql.Stmt
-	deleteFileStmt              *sql.Stmt
-	deleteMessageStmt           *sql.Stmt
-	deleteOldCheckpointsStmt    *sql.Stmt
-	deleteSessionStmt           *sql.Stmt
-	deleteSessionFilesStmt      *sql.Stmt
-	deleteSessionMessagesStmt   *sql.Stmt
-	getCheckpointStmt           *sql.Stmt
-	getFileStmt                 *sql.Stmt
-	getFileByPathAndSessionStmt *sql.Stmt
-	getLatestCheckpointStmt     *sql.Stmt
-	getMessageStmt              *sql.Stmt
-	getSessionByIDStmt          *sql.Stmt
-	listCheckpointsStmt         *sql.Stmt
-	listFilesByPathStmt         *sql.Stmt
-	listFilesBySessionStmt      *sql.Stmt
-	listLatestSessionFilesStmt  *sql.Stmt
-	listMessagesBySessionStmt   *sql.Stmt
-	listNewFilesStmt            *sql.Stmt
-	listSessionsStmt            *sql.Stmt
-	updateMessageStmt           *sql.Stmt
-	updateSessionStmt           *sql.Stmt
+	db                                DBTX
+	tx                                *sql.Tx
+	createCheckpointStmt              *sql.Stmt
+	createFileStmt                    *sql.Stmt
+	createMessageStmt                 *sql.Stmt
+	createModelSpeedSampleStmt        *sql.Stmt
+	createRecoveryAttemptStmt         *sql.Stmt
+	createSessionStmt                 *sql.Stmt
+	deleteAgentStateStmt              *sql.Stmt
+	deleteCheckpointStmt              *sql.Stmt
+	deleteFileStmt                    *sql.Stmt
+	deleteMessageStmt                 *sql.Stmt
+	deleteModelSpeedSamplesBeforeStmt *sql.Stmt
+	deleteOldCheckpointsStmt          *sql.Stmt
+	deleteSessionStmt                 *sql.Stmt
+	deleteSessionFilesStmt            *sql.Stmt
+	deleteSessionMessagesStmt         *sql.Stmt
+	getAgentStateStmt                 *sql.Stmt
+	getCheckpointStmt                 *sql.Stmt
+	getFileStmt                       *sql.Stmt
+	getFileByPathAndSessionStmt       *sql.Stmt
+	getLatestCheckpointStmt           *sql.Stmt
+	getMessageStmt                    *sql.Stmt
+	getSessionByIDStmt                *sql.Stmt
+	listAgentStatesStmt               *sql.Stmt
+	listCheckpointsStmt               *sql.Stmt
+	listFilesByPathStmt               *sql.Stmt
+	listFilesBySessionStmt            *sql.Stmt
+	listLatestSessionFilesStmt        *sql.Stmt
+	listMessagesBySessionStmt         *sql.Stmt
+	listModelSpeedSamplesStmt         *sql.Stmt
+	listNewFilesStmt                  *sql.Stmt
+	listRecoveryAttemptsStmt          *sql.Stmt
+	listRecoveryAttemptsBySessionStmt *sql.Stmt
+	listSessionsStmt                  *sql.Stmt
+	updateMessageStmt                 *sql.Stmt
+	updateSessionStmt                 *sql.Stmt
+	upsertAgentStateStmt              *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                          tx,
-		tx:                          tx,
-		createCheckpointStmt:        q.createCheckpointStmt,
-		createFileStmt:              q.createFileStmt,
-		createMessageStmt:           q.createMessageStmt,
-		createSessionStmt:           q.createSessionStmt,
-		deleteCheckpointStmt:        q.deleteCheckpointStmt,
-		deleteFileStmt:              q.deleteFileStmt,
-		deleteMessageStmt:           q.deleteMessageStmt,
-		deleteOldCheckpointsStmt:    q.deleteOldCheckpointsStmt,
-		deleteSessionStmt:           q.deleteSessionStmt,
-		deleteSessionFilesStmt:      q.deleteSessionFilesStmt,
-		deleteSessionMessagesStmt:   q.deleteSessionMessagesStmt,
-		getCheckpointStmt:           q.getCheckpointStmt,
-		getFileStmt:                 q.getFileStmt,
-		getFileByPathAndSessionStmt: q.getFileByPathAndSessionStmt,
-		getLatestCheckpointStmt:     q.getLatestCheckpointStmt,
-		getMessageStmt:              q.getMessageStmt,
-		getSessionByIDStmt:          q.getSessionByIDStmt,
-		listCheckpointsStmt:         q.listCheckpointsStmt,
-		listFilesByPathStmt:         q.listFilesByPathStmt,
-		listFilesBySessionStmt:      q.listFilesBySessionStmt,
-		listLatestSessionFilesStmt:  q.listLatestSessionFilesStmt,
-		listMessagesBySessionStmt:   q.listMessagesBySessionStmt,
-		listNewFilesStmt:            q.listNewFilesStmt,
-		listSessionsStmt:            q.listSessionsStmt,
-		updateMessageStmt:           q.updateMessageStmt,
-		updateSessionStmt:           q.updateSessionStmt,
+		db:                                tx,
+		tx:                                tx,
+		createCheckpointStmt:              q.createCheckpointStmt,
+		createFileStmt:                    q.createFileStmt,
+		createMessageStmt:                 q.createMessageStmt,
+		createModelSpeedSampleStmt:        q.createModelSpeedSampleStmt,
+		createRecoveryAttemptStmt:         q.createRecoveryAttemptStmt,
+		createSessionStmt:                 q.createSessionStmt,
+		deleteAgentStateStmt:              q.deleteAgentStateStmt,
+		deleteCheckpointStmt:              q.deleteCheckpointStmt,
+		deleteFileStmt:                    q.deleteFileStmt,
+		deleteMessageStmt:                 q.deleteMessageStmt,
+		deleteModelSpeedSamplesBeforeStmt: q.deleteModelSpeedSamplesBeforeStmt,
+		deleteOldCheckpointsStmt:          q.deleteOldCheckpointsStmt,
+		deleteSessionStmt:                 q.deleteSessionStmt,
+		deleteSessionFilesStmt:            q.deleteSessionFilesStmt,
+		deleteSessionMessagesStmt:         q.deleteSessionMessagesStmt,
+		getAgentStateStmt:                 q.getAgentStateStmt,
+		getCheckpointStmt:                 q.getCheckpointStmt,
+		getFileStmt:                       q.getFileStmt,
+		getFileByPathAndSessionStmt:       q.getFileByPathAndSessionStmt,
+		getLatestCheckpointStmt:           q.getLatestCheckpointStmt,
+		getMessageStmt:                    q.getMessageStmt,
+		getSessionByIDStmt:                q.getSessionByIDStmt,
+		listAgentStatesStmt:               q.listAgentStatesStmt,
+		listCheckpointsStmt:               q.listCheckpointsStmt,
+		listFilesByPathStmt:               q.listFilesByPathStmt,
+		listFilesBySessionStmt:            q.listFilesBySessionStmt,
+		listLatestSessionFilesStmt:        q.listLatestSessionFilesStmt,
+		listMessagesBySessionStmt:         q.listMessagesBySessionStmt,
+		listModelSpeedSamplesStmt:         q.listModelSpeedSamplesStmt,
+		listNewFilesStmt:                  q.listNewFilesStmt,
+		listRecoveryAttemptsStmt:          q.listRecoveryAttemptsStmt,
+		listRecoveryAttemptsBySessionStmt: q.listRecoveryAttemptsBySessionStmt,
+		listSessionsStmt:                  q.listSessionsStmt,
+		updateMessageStmt:                 q.updateMessageStmt,
+		updateSessionStmt:                 q.updateSessionStmt,
+		upsertAgentStateStmt:              q.upsertAgentStateStmt,
 	}
 }
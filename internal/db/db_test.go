@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/ncruces/go-sqlite3/embed"
 	"github.com/stretchr/testify/assert"
@@ -25,7 +26,11 @@ CREATE TABLE IF NOT EXISTS sessions (
     cost REAL NOT NULL DEFAULT 0.0 CHECK (cost >= 0.0),
     summary_message_id TEXT,
     updated_at INTEGER NOT NULL,  -- Unix timestamp in milliseconds
-    created_at INTEGER NOT NULL   -- Unix timestamp in milliseconds
+    created_at INTEGER NOT NULL,  -- Unix timestamp in milliseconds
+    total_input_tokens INTEGER NOT NULL DEFAULT 0 CHECK (total_input_tokens >= 0),
+    cache_read_tokens INTEGER NOT NULL DEFAULT 0 CHECK (cache_read_tokens >= 0),
+    cache_creation_tokens INTEGER NOT NULL DEFAULT 0 CHECK (cache_creation_tokens >= 0),
+    cache_savings REAL NOT NULL DEFAULT 0.0
 );
 
 -- Messages
@@ -53,6 +58,7 @@ CREATE TABLE IF NOT EXISTS files (
     is_new INTEGER DEFAULT 0 NOT NULL,
     created_at INTEGER NOT NULL,  -- Unix timestamp in milliseconds
     updated_at INTEGER NOT NULL,  -- Unix timestamp in milliseconds
+    message_id TEXT NOT NULL DEFAULT '',
     FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE,
     UNIQUE(path, session_id, version)
 );
@@ -102,6 +108,38 @@ CREATE TABLE IF NOT EXISTS checkpoints (
     FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
 );
 
+-- Model speed samples
+CREATE TABLE IF NOT EXISTS model_speed_samples (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    provider TEXT NOT NULL,
+    model TEXT NOT NULL,
+    ttft_ms INTEGER NOT NULL DEFAULT 0 CHECK (ttft_ms >= 0),
+    output_tokens INTEGER NOT NULL DEFAULT 0 CHECK (output_tokens >= 0),
+    duration_ms INTEGER NOT NULL DEFAULT 0 CHECK (duration_ms >= 0),
+    created_at INTEGER NOT NULL
+);
+
+-- Agent states
+CREATE TABLE IF NOT EXISTS agent_states (
+    session_id TEXT PRIMARY KEY,
+    state TEXT NOT NULL,
+    pause_reason TEXT NOT NULL DEFAULT '',
+    snapshot BLOB NOT NULL,
+    updated_at INTEGER NOT NULL,
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+-- Recovery attempts
+CREATE TABLE IF NOT EXISTS recovery_attempts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id TEXT NOT NULL,
+    strategy TEXT NOT NULL,
+    error_type TEXT NOT NULL,
+    success BOOLEAN NOT NULL DEFAULT 0,
+    retry_count INTEGER NOT NULL DEFAULT 0 CHECK (retry_count >= 0),
+    created_at INTEGER NOT NULL
+);
+
 -- Prompt Library
 CREATE TABLE IF NOT EXISTS prompt_library (
     id TEXT PRIMARY KEY,
@@ -613,3 +651,122 @@ func TestNullableFields(t *testing.T) {
 	require.True(t, child.ParentSessionID.Valid)
 	require.Equal(t, session.ID, child.ParentSessionID.String)
 }
+
+func TestModelSpeedSamples(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, createTestSchema(db))
+	q := New(db)
+
+	for _, ttft := range []int64{100, 200} {
+		require.NoError(t, q.CreateModelSpeedSample(ctx, CreateModelSpeedSampleParams{
+			Provider:     "openai",
+			Model:        "gpt-4o",
+			TtftMs:       ttft,
+			OutputTokens: 50,
+			DurationMs:   1000,
+		}))
+	}
+
+	samples, err := q.ListModelSpeedSamples(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	require.Equal(t, int64(200), samples[0].TtftMs, "newest sample first")
+	require.Equal(t, "gpt-4o", samples[0].Model)
+
+	require.NoError(t, q.DeleteModelSpeedSamplesBefore(ctx, samples[0].CreatedAt+1))
+	samples, err = q.ListModelSpeedSamples(ctx, 0)
+	require.NoError(t, err)
+	require.Empty(t, samples)
+}
+
+func TestAgentStates(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, createTestSchema(db))
+	q := New(db)
+
+	session, err := q.CreateSession(ctx, CreateSessionParams{ID: "s1", Title: "Paused"})
+	require.NoError(t, err)
+
+	_, err = q.GetAgentState(ctx, session.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, q.UpsertAgentState(ctx, UpsertAgentStateParams{
+		SessionID: session.ID,
+		State:     "ProcessingPrompt",
+		Snapshot:  []byte(`{}`),
+	}))
+	require.NoError(t, q.UpsertAgentState(ctx, UpsertAgentStateParams{
+		SessionID:   session.ID,
+		State:       "ResourcePaused",
+		PauseReason: "Memory usage 90% exceeds threshold",
+		Snapshot:    []byte(`{"state":"ResourcePaused"}`),
+	}))
+
+	state, err := q.GetAgentState(ctx, session.ID)
+	require.NoError(t, err)
+	require.Equal(t, "ResourcePaused", state.State)
+	require.Equal(t, "Memory usage 90% exceeds threshold", state.PauseReason)
+	require.JSONEq(t, `{"state":"ResourcePaused"}`, string(state.Snapshot))
+	require.Positive(t, state.UpdatedAt)
+
+	states, err := q.ListAgentStates(ctx)
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+
+	require.NoError(t, q.DeleteAgentState(ctx, session.ID))
+	states, err = q.ListAgentStates(ctx)
+	require.NoError(t, err)
+	require.Empty(t, states)
+}
+
+func TestRecoveryAttempts(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, createTestSchema(db))
+	q := New(db)
+
+	require.NoError(t, q.CreateRecoveryAttempt(ctx, CreateRecoveryAttemptParams{
+		SessionID: "s1",
+		Strategy:  "Edit Failed Recovery",
+		ErrorType: "edit_failed",
+		Success:   true,
+	}))
+	require.NoError(t, q.CreateRecoveryAttempt(ctx, CreateRecoveryAttemptParams{
+		SessionID:  "s2",
+		Strategy:   "go mod tidy",
+		ErrorType:  "command_failed",
+		RetryCount: 1,
+	}))
+
+	attempts, err := q.ListRecoveryAttempts(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	require.True(t, attempts[0].Success)
+	require.Equal(t, "go mod tidy", attempts[1].Strategy)
+	require.False(t, attempts[1].Success)
+	require.Equal(t, int64(1), attempts[1].RetryCount)
+	require.Positive(t, attempts[1].CreatedAt)
+
+	attempts, err = q.ListRecoveryAttemptsBySession(ctx, "s2")
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	require.Equal(t, "command_failed", attempts[0].ErrorType)
+
+	attempts, err = q.ListRecoveryAttempts(ctx, time.Now().Add(time.Hour).Unix())
+	require.NoError(t, err)
+	require.Empty(t, attempts)
+}
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sessions ADD COLUMN total_input_tokens INTEGER NOT NULL DEFAULT 0 CHECK (total_input_tokens >= 0);
+ALTER TABLE sessions ADD COLUMN cache_read_tokens INTEGER NOT NULL DEFAULT 0 CHECK (cache_read_tokens >= 0);
+ALTER TABLE sessions ADD COLUMN cache_creation_tokens INTEGER NOT NULL DEFAULT 0 CHECK (cache_creation_tokens >= 0);
+ALTER TABLE sessions ADD COLUMN cache_savings REAL NOT NULL DEFAULT 0.0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sessions DROP COLUMN cache_savings;
+ALTER TABLE sessions DROP COLUMN cache_creation_tokens;
+ALTER TABLE sessions DROP COLUMN cache_read_tokens;
+ALTER TABLE sessions DROP COLUMN total_input_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Migration: Add model speed samples measured from completions
+
+CREATE TABLE IF NOT EXISTS model_speed_samples (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    provider TEXT NOT NULL,
+    model TEXT NOT NULL,
+    ttft_ms INTEGER NOT NULL DEFAULT 0 CHECK (ttft_ms >= 0),
+    output_tokens INTEGER NOT NULL DEFAULT 0 CHECK (output_tokens >= 0),
+    duration_ms INTEGER NOT NULL DEFAULT 0 CHECK (duration_ms >= 0),
+    created_at INTEGER NOT NULL  -- Unix timestamp in seconds
+);
+
+CREATE INDEX idx_model_speed_samples_model ON model_speed_samples(provider, model, id DESC);
+CREATE INDEX idx_model_speed_samples_created_at ON model_speed_samples(created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_model_speed_samples_created_at;
+DROP INDEX IF EXISTS idx_model_speed_samples_model;
+DROP TABLE IF EXISTS model_speed_samples;
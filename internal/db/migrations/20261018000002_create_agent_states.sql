@@ -0,0 +1,17 @@
+-- +goose Up
+-- Migration: Add agent states to resume sessions' state machines after a restart
+
+CREATE TABLE IF NOT EXISTS agent_states (
+    session_id TEXT PRIMARY KEY,
+    state TEXT NOT NULL,
+    pause_reason TEXT NOT NULL DEFAULT '',
+    snapshot BLOB NOT NULL,
+    updated_at INTEGER NOT NULL,  -- Unix timestamp in seconds
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_agent_states_updated_at ON agent_states(updated_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_agent_states_updated_at;
+DROP TABLE IF EXISTS agent_states;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Migration: Add recovery attempts to keep statistics of error recovery
+
+CREATE TABLE IF NOT EXISTS recovery_attempts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id TEXT NOT NULL,
+    strategy TEXT NOT NULL,
+    error_type TEXT NOT NULL,
+    success BOOLEAN NOT NULL DEFAULT 0,
+    retry_count INTEGER NOT NULL DEFAULT 0 CHECK (retry_count >= 0),
+    created_at INTEGER NOT NULL  -- Unix timestamp in seconds
+);
+
+CREATE INDEX idx_recovery_attempts_session_id ON recovery_attempts(session_id);
+CREATE INDEX idx_recovery_attempts_created_at ON recovery_attempts(created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_recovery_attempts_created_at;
+DROP INDEX IF EXISTS idx_recovery_attempts_session_id;
+DROP TABLE IF EXISTS recovery_attempts;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Migration: Link file versions to the message that wrote them, so that a rewind can tell which turn they belong to
+-- +goose StatementBegin
+ALTER TABLE files ADD COLUMN message_id TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE files DROP COLUMN message_id;
+-- +goose StatementEnd
@@ -9,6 +9,14 @@ import (
 	"time"
 )
 
+type AgentState struct {
+	SessionID   string `json:"session_id"`
+	State       string `json:"state"`
+	PauseReason string `json:"pause_reason"`
+	Snapshot    []byte `json:"snapshot"`
+	UpdatedAt   int64  `json:"updated_at"`
+}
+
 type Checkpoint struct {
 	ID           string       `json:"id"`
 	SessionID    string       `json:"session_id"`
@@ -42,6 +50,7 @@ type File struct {
 	Version   int64  `json:"version"`
 	CreatedAt int64  `json:"created_at"`
 	UpdatedAt int64  `json:"updated_at"`
+	MessageID string `json:"message_id"`
 }
 
 type Message struct {
@@ -57,15 +66,39 @@ type Message struct {
 	IsSummaryMessage int64          `json:"is_summary_message"`
 }
 
+type ModelSpeedSample struct {
+	ID           int64  `json:"id"`
+	Provider     string `json:"provider"`
+	Model        string `json:"model"`
+	TtftMs       int64  `json:"ttft_ms"`
+	OutputTokens int64  `json:"output_tokens"`
+	DurationMs   int64  `json:"duration_ms"`
+	CreatedAt    int64  `json:"created_at"`
+}
+
+type RecoveryAttempt struct {
+	ID         int64  `json:"id"`
+	SessionID  string `json:"session_id"`
+	Strategy   string `json:"strategy"`
+	ErrorType  string `json:"error_type"`
+	Success    bool   `json:"success"`
+	RetryCount int64  `json:"retry_count"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
 type Session struct {
-	ID               string         `json:"id"`
-	ParentSessionID  sql.NullString `json:"parent_session_id"`
-	Title            string         `json:"title"`
-	MessageCount     int64          `json:"message_count"`
-	PromptTokens     int64          `json:"prompt_tokens"`
-	CompletionTokens int64          `json:"completion_tokens"`
-	Cost             float64        `json:"cost"`
-	UpdatedAt        int64          `json:"updated_at"`
-	CreatedAt        int64          `json:"created_at"`
-	SummaryMessageID sql.NullString `json:"summary_message_id"`
+	ID                  string         `json:"id"`
+	ParentSessionID     sql.NullString `json:"parent_session_id"`
+	Title               string         `json:"title"`
+	MessageCount        int64          `json:"message_count"`
+	PromptTokens        int64          `json:"prompt_tokens"`
+	CompletionTokens    int64          `json:"completion_tokens"`
+	Cost                float64        `json:"cost"`
+	UpdatedAt           int64          `json:"updated_at"`
+	CreatedAt           int64          `json:"created_at"`
+	SummaryMessageID    sql.NullString `json:"summary_message_id"`
+	TotalInputTokens    int64          `json:"total_input_tokens"`
+	CacheReadTokens     int64          `json:"cache_read_tokens"`
+	CacheCreationTokens int64          `json:"cache_creation_tokens"`
+	CacheSavings        float64        `json:"cache_savings"`
 }
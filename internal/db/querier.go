@@ -12,29 +12,39 @@ type Querier interface {
 	CreateCheckpoint(ctx context.Context, arg CreateCheckpointParams) (Checkpoint, error)
 	CreateFile(ctx context.Context, arg CreateFileParams) (File, error)
 	CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error)
+	CreateModelSpeedSample(ctx context.Context, arg CreateModelSpeedSampleParams) error
+	CreateRecoveryAttempt(ctx context.Context, arg CreateRecoveryAttemptParams) error
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	DeleteAgentState(ctx context.Context, sessionID string) error
 	DeleteCheckpoint(ctx context.Context, id string) error
 	DeleteFile(ctx context.Context, id string) error
 	DeleteMessage(ctx context.Context, id string) error
+	DeleteModelSpeedSamplesBefore(ctx context.Context, createdAt int64) error
 	DeleteOldCheckpoints(ctx context.Context, arg DeleteOldCheckpointsParams) error
 	DeleteSession(ctx context.Context, id string) error
 	DeleteSessionFiles(ctx context.Context, sessionID string) error
 	DeleteSessionMessages(ctx context.Context, sessionID string) error
+	GetAgentState(ctx context.Context, sessionID string) (AgentState, error)
 	GetCheckpoint(ctx context.Context, id string) (Checkpoint, error)
 	GetFile(ctx context.Context, id string) (File, error)
 	GetFileByPathAndSession(ctx context.Context, arg GetFileByPathAndSessionParams) (File, error)
 	GetLatestCheckpoint(ctx context.Context, sessionID string) (Checkpoint, error)
 	GetMessage(ctx context.Context, id string) (Message, error)
 	GetSessionByID(ctx context.Context, id string) (Session, error)
+	ListAgentStates(ctx context.Context) ([]AgentState, error)
 	ListCheckpoints(ctx context.Context, sessionID string) ([]Checkpoint, error)
 	ListFilesByPath(ctx context.Context, path string) ([]File, error)
 	ListFilesBySession(ctx context.Context, sessionID string) ([]File, error)
 	ListLatestSessionFiles(ctx context.Context, sessionID string) ([]File, error)
 	ListMessagesBySession(ctx context.Context, sessionID string) ([]Message, error)
+	ListModelSpeedSamples(ctx context.Context, createdAt int64) ([]ModelSpeedSample, error)
 	ListNewFiles(ctx context.Context) ([]File, error)
+	ListRecoveryAttempts(ctx context.Context, createdAt int64) ([]RecoveryAttempt, error)
+	ListRecoveryAttemptsBySession(ctx context.Context, sessionID string) ([]RecoveryAttempt, error)
 	ListSessions(ctx context.Context) ([]Session, error)
 	UpdateMessage(ctx context.Context, arg UpdateMessageParams) error
 	UpdateSession(ctx context.Context, arg UpdateSessionParams) (Session, error)
+	UpsertAgentState(ctx context.Context, arg UpsertAgentStateParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -33,7 +33,7 @@ INSERT INTO sessions (
     null,
     strftime('%s', 'now'),
     strftime('%s', 'now')
-) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, total_input_tokens, cache_read_tokens, cache_creation_tokens, cache_savings
 `
 
 type CreateSessionParams struct {
@@ -68,6 +68,10 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.UpdatedAt,
 		&i.CreatedAt,
 		&i.SummaryMessageID,
+		&i.TotalInputTokens,
+		&i.CacheReadTokens,
+		&i.CacheCreationTokens,
+		&i.CacheSavings,
 	)
 	return i, err
 }
@@ -83,7 +87,7 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 }
 
 const getSessionByID = `-- name: GetSessionByID :one
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, total_input_tokens, cache_read_tokens, cache_creation_tokens, cache_savings
 FROM sessions
 WHERE id = ? LIMIT 1
 `
@@ -102,12 +106,16 @@ func (q *Queries) GetSessionByID(ctx context.Context, id string) (Session, error
 		&i.UpdatedAt,
 		&i.CreatedAt,
 		&i.SummaryMessageID,
+		&i.TotalInputTokens,
+		&i.CacheReadTokens,
+		&i.CacheCreationTokens,
+		&i.CacheSavings,
 	)
 	return i, err
 }
 
 const listSessions = `-- name: ListSessions :many
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, total_input_tokens, cache_read_tokens, cache_creation_tokens, cache_savings
 FROM sessions
 WHERE parent_session_id is NULL
 ORDER BY created_at DESC
@@ -133,6 +141,10 @@ func (q *Queries) ListSessions(ctx context.Context) ([]Session, error) {
 			&i.UpdatedAt,
 			&i.CreatedAt,
 			&i.SummaryMessageID,
+			&i.TotalInputTokens,
+			&i.CacheReadTokens,
+			&i.CacheCreationTokens,
+			&i.CacheSavings,
 		); err != nil {
 			return nil, err
 		}
@@ -154,18 +166,26 @@ SET
     prompt_tokens = ?,
     completion_tokens = ?,
     summary_message_id = ?,
-    cost = ?
+    cost = ?,
+    total_input_tokens = ?,
+    cache_read_tokens = ?,
+    cache_creation_tokens = ?,
+    cache_savings = ?
 WHERE id = ?
-RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, total_input_tokens, cache_read_tokens, cache_creation_tokens, cache_savings
 `
 
 type UpdateSessionParams struct {
-	Title            string         `json:"title"`
-	PromptTokens     int64          `json:"prompt_tokens"`
-	CompletionTokens int64          `json:"completion_tokens"`
-	SummaryMessageID sql.NullString `json:"summary_message_id"`
-	Cost             float64        `json:"cost"`
-	ID               string         `json:"id"`
+	Title               string         `json:"title"`
+	PromptTokens        int64          `json:"prompt_tokens"`
+	CompletionTokens    int64          `json:"completion_tokens"`
+	SummaryMessageID    sql.NullString `json:"summary_message_id"`
+	Cost                float64        `json:"cost"`
+	TotalInputTokens    int64          `json:"total_input_tokens"`
+	CacheReadTokens     int64          `json:"cache_read_tokens"`
+	CacheCreationTokens int64          `json:"cache_creation_tokens"`
+	CacheSavings        float64        `json:"cache_savings"`
+	ID                  string         `json:"id"`
 }
 
 func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (Session, error) {
@@ -175,6 +195,10 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		arg.CompletionTokens,
 		arg.SummaryMessageID,
 		arg.Cost,
+		arg.TotalInputTokens,
+		arg.CacheReadTokens,
+		arg.CacheCreationTokens,
+		arg.CacheSavings,
 		arg.ID,
 	)
 	var i Session
@@ -189,6 +213,10 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		&i.UpdatedAt,
 		&i.CreatedAt,
 		&i.SummaryMessageID,
+		&i.TotalInputTokens,
+		&i.CacheReadTokens,
+		&i.CacheCreationTokens,
+		&i.CacheSavings,
 	)
 	return i, err
 }
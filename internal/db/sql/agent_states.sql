@@ -0,0 +1,33 @@
+-- name: UpsertAgentState :exec
+INSERT INTO agent_states (
+    session_id,
+    state,
+    pause_reason,
+    snapshot,
+    updated_at
+) VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    strftime('%s', 'now')
+)
+ON CONFLICT (session_id) DO UPDATE SET
+    state = excluded.state,
+    pause_reason = excluded.pause_reason,
+    snapshot = excluded.snapshot,
+    updated_at = excluded.updated_at;
+
+-- name: GetAgentState :one
+SELECT *
+FROM agent_states
+WHERE session_id = ? LIMIT 1;
+
+-- name: ListAgentStates :many
+SELECT *
+FROM agent_states
+ORDER BY updated_at DESC;
+
+-- name: DeleteAgentState :exec
+DELETE FROM agent_states
+WHERE session_id = ?;
@@ -0,0 +1,26 @@
+-- name: CreateModelSpeedSample :exec
+INSERT INTO model_speed_samples (
+    provider,
+    model,
+    ttft_ms,
+    output_tokens,
+    duration_ms,
+    created_at
+) VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    strftime('%s', 'now')
+);
+
+-- name: ListModelSpeedSamples :many
+SELECT *
+FROM model_speed_samples
+WHERE created_at >= ?
+ORDER BY provider, model, id DESC;
+
+-- name: DeleteModelSpeedSamplesBefore :exec
+DELETE FROM model_speed_samples
+WHERE created_at < ?;
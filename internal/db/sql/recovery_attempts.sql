@@ -0,0 +1,28 @@
+-- name: CreateRecoveryAttempt :exec
+INSERT INTO recovery_attempts (
+    session_id,
+    strategy,
+    error_type,
+    success,
+    retry_count,
+    created_at
+) VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    strftime('%s', 'now')
+);
+
+-- name: ListRecoveryAttempts :many
+SELECT *
+FROM recovery_attempts
+WHERE created_at >= ?
+ORDER BY id;
+
+-- name: ListRecoveryAttemptsBySession :many
+SELECT *
+FROM recovery_attempts
+WHERE session_id = ?
+ORDER BY id;
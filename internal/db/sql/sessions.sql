@@ -41,7 +41,11 @@ SET
     prompt_tokens = ?,
     completion_tokens = ?,
     summary_message_id = ?,
-    cost = ?
+    cost = ?,
+    total_input_tokens = ?,
+    cache_read_tokens = ?,
+    cache_creation_tokens = ?,
+    cache_savings = ?
 WHERE id = ?
 RETURNING *;
 
@@ -0,0 +1,22 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tokens formats a token count in human-readable form, e.g. 950, 110K or
+// 1.2M.
+func Tokens(tokens int64) string {
+	var s string
+	switch {
+	case tokens >= 1_000_000:
+		s = fmt.Sprintf("%.1fM", float64(tokens)/1_000_000)
+	case tokens >= 1_000:
+		s = fmt.Sprintf("%.1fK", float64(tokens)/1_000)
+	default:
+		return fmt.Sprintf("%d", tokens)
+	}
+	// Remove .0 suffix if present
+	return strings.Replace(s, ".0", "", 1)
+}
@@ -0,0 +1,21 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokens(t *testing.T) {
+	for tokens, want := range map[int64]string{
+		0:         "0",
+		950:       "950",
+		1_000:     "1K",
+		1_250:     "1.2K",
+		110_000:   "110K",
+		1_000_000: "1M",
+		1_560_000: "1.6M",
+	} {
+		assert.Equal(t, want, Tokens(tokens), tokens)
+	}
+}
@@ -0,0 +1,101 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Branch is a local branch.
+type Branch struct {
+	Name     string `json:"name"`
+	Hash     string `json:"hash"`
+	Upstream string `json:"upstream,omitempty"`
+	Current  bool   `json:"current,omitempty"`
+}
+
+// Branches lists local branches.
+func (r *Repo) Branches(ctx context.Context) ([]Branch, error) {
+	out, err := r.Run(ctx, "for-each-ref",
+		"--format=%(HEAD)"+logFieldSep+"%(refname:short)"+logFieldSep+"%(objectname)"+logFieldSep+"%(upstream:short)",
+		"refs/heads/",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []Branch
+	for line := range strings.SplitSeq(out, "\n") {
+		fields := strings.Split(line, logFieldSep)
+		if len(fields) < 4 {
+			continue
+		}
+		branches = append(branches, Branch{
+			Current:  fields[0] == "*",
+			Name:     fields[1],
+			Hash:     fields[2],
+			Upstream: fields[3],
+		})
+	}
+	return branches, nil
+}
+
+// CurrentBranch returns the checked out branch name, or an empty string
+// when HEAD is detached.
+func (r *Repo) CurrentBranch(ctx context.Context) (string, error) {
+	out, err := r.Run(ctx, "branch", "--show-current")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// CreateBranch creates a branch at start (HEAD when empty) and optionally
+// checks it out.
+func (r *Repo) CreateBranch(ctx context.Context, name, start string, checkout bool) error {
+	if err := checkRefArg(name, start); err != nil {
+		return err
+	}
+	args := []string{"branch", name}
+	if checkout {
+		args = []string{"switch", "--create", name}
+	}
+	if start != "" {
+		args = append(args, start)
+	}
+	_, err := r.Run(ctx, args...)
+	return err
+}
+
+// Switch checks out an existing branch.
+func (r *Repo) Switch(ctx context.Context, name string) error {
+	if err := checkRefArg(name); err != nil {
+		return err
+	}
+	_, err := r.Run(ctx, "switch", name)
+	return err
+}
+
+// DeleteBranch deletes a branch. Without force, git refuses to delete a
+// branch that is not fully merged.
+func (r *Repo) DeleteBranch(ctx context.Context, name string, force bool) error {
+	if err := checkRefArg(name); err != nil {
+		return err
+	}
+	flag := "--delete"
+	if force {
+		flag = "-D"
+	}
+	_, err := r.Run(ctx, "branch", flag, name)
+	return err
+}
+
+// checkRefArg rejects refs that git would parse as options.
+func checkRefArg(refs ...string) error {
+	for _, ref := range refs {
+		if strings.HasPrefix(ref, "-") {
+			return fmt.Errorf("%w: %q", ErrInvalidRef, ref)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrNoCommits is returned when the repository has no commits yet.
+	ErrNoCommits = errors.New("repository has no commits")
+	// ErrNothingToCommit is returned when a commit would be empty.
+	ErrNothingToCommit = errors.New("nothing to commit")
+	// ErrEmptyMessage is returned when a commit message is blank.
+	ErrEmptyMessage = errors.New("commit message is empty")
+	// ErrInvalidRef is returned for branch or revision names that look like
+	// command line options.
+	ErrInvalidRef = errors.New("invalid ref name")
+)
+
+// CommitOptions describes a commit to create.
+type CommitOptions struct {
+	Message string
+	// Footer is an optional paragraph placed between the message and the
+	// trailers, such as a "Generated with" line.
+	Footer string
+	// Trailers are "Key: value" lines appended to the message.
+	Trailers []string
+	// Paths are staged before committing.
+	Paths []string
+	// All stages every modification to tracked files.
+	All bool
+	// Amend replaces the last commit instead of creating a new one.
+	Amend bool
+}
+
+// FormatMessage assembles a commit message from its body, an optional
+// footer and trailers. Footers and trailers already present in the message
+// are not repeated, so the result is stable across retries.
+func FormatMessage(message, footer string, trailers []string) string {
+	message = strings.TrimSpace(message)
+	lines := strings.Split(message, "\n")
+	has := func(s string) bool {
+		return slices.ContainsFunc(lines, func(line string) bool {
+			return strings.EqualFold(strings.TrimSpace(line), strings.TrimSpace(s))
+		})
+	}
+
+	var b strings.Builder
+	b.WriteString(message)
+	if footer = strings.TrimSpace(footer); footer != "" && !has(footer) {
+		b.WriteString("\n\n")
+		b.WriteString(footer)
+	}
+
+	var missing []string
+	for _, t := range trailers {
+		if t = strings.TrimSpace(t); t != "" && !has(t) && !slices.Contains(missing, t) {
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) > 0 {
+		b.WriteString("\n\n")
+		b.WriteString(strings.Join(missing, "\n"))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// Commit stages the requested paths and records a commit.
+func (r *Repo) Commit(ctx context.Context, opts CommitOptions) (Commit, error) {
+	if strings.TrimSpace(opts.Message) == "" {
+		return Commit{}, ErrEmptyMessage
+	}
+
+	if len(opts.Paths) > 0 {
+		if _, err := r.Run(ctx, append([]string{"add", "--"}, opts.Paths...)...); err != nil {
+			return Commit{}, err
+		}
+	}
+	if opts.All {
+		if _, err := r.Run(ctx, "add", "--update"); err != nil {
+			return Commit{}, err
+		}
+	}
+
+	if !opts.Amend {
+		// diff --cached --quiet exits non-zero when something is staged.
+		if _, err := r.Run(ctx, "diff", "--cached", "--quiet"); err == nil {
+			return Commit{}, ErrNothingToCommit
+		}
+	}
+
+	args := []string{"commit", "--cleanup=whitespace", "-F", "-"}
+	if opts.Amend {
+		args = append(args, "--amend")
+	}
+	if err := r.runWithStdin(ctx, FormatMessage(opts.Message, opts.Footer, opts.Trailers), args...); err != nil {
+		return Commit{}, err
+	}
+	return r.Head(ctx)
+}
@@ -0,0 +1,130 @@
+package git
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// DiffOptions selects what a diff is computed against.
+type DiffOptions struct {
+	// Staged diffs the index against HEAD instead of the working tree
+	// against the index.
+	Staged bool
+	// Base diffs the working tree against a commit. When Base contains
+	// "..." or "..", it is passed through as a revision range.
+	Base string
+	// Paths limits the diff to the given paths.
+	Paths []string
+	// Context is the number of context lines; zero uses git's default.
+	Context int
+}
+
+// FileDiff summarises the changes to a single file.
+type FileDiff struct {
+	Path      string `json:"path"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Binary    bool   `json:"binary,omitempty"`
+}
+
+// Diff is a parsed diff with its unified patch.
+type Diff struct {
+	Files []FileDiff `json:"files"`
+	Patch string     `json:"-"`
+}
+
+// Additions returns the total number of added lines.
+func (d Diff) Additions() int {
+	var n int
+	for _, f := range d.Files {
+		n += f.Additions
+	}
+	return n
+}
+
+// Deletions returns the total number of deleted lines.
+func (d Diff) Deletions() int {
+	var n int
+	for _, f := range d.Files {
+		n += f.Deletions
+	}
+	return n
+}
+
+// Diff computes a diff of the working tree.
+func (r *Repo) Diff(ctx context.Context, opts DiffOptions) (Diff, error) {
+	if err := checkRefArg(opts.Base); err != nil {
+		return Diff{}, err
+	}
+	args := []string{"diff", "--no-color", "--no-ext-diff"}
+	if opts.Staged {
+		args = append(args, "--cached")
+	}
+	if opts.Context > 0 {
+		args = append(args, "-U"+strconv.Itoa(opts.Context))
+	}
+	if opts.Base != "" {
+		args = append(args, opts.Base)
+	}
+	args = append(args, "--")
+	args = append(args, opts.Paths...)
+
+	numstat, err := r.Run(ctx, append([]string{args[0], "--numstat", "-z"}, args[1:]...)...)
+	if err != nil {
+		return Diff{}, err
+	}
+	patch, err := r.Run(ctx, args...)
+	if err != nil {
+		return Diff{}, err
+	}
+	return Diff{Files: parseNumstat(numstat), Patch: patch}, nil
+}
+
+// parseNumstat parses `git diff --numstat -z` output.
+func parseNumstat(out string) []FileDiff {
+	var files []FileDiff
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		fields := strings.SplitN(entries[i], "\t", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		file := FileDiff{Path: fields[2]}
+		if fields[2] == "" && i+2 < len(entries) {
+			// Renames are reported as an empty path followed by the source
+			// and destination paths.
+			file.Path = entries[i+2]
+			i += 2
+		}
+		if fields[0] == "-" && fields[1] == "-" {
+			file.Binary = true
+		} else {
+			file.Additions, _ = strconv.Atoi(fields[0])
+			file.Deletions, _ = strconv.Atoi(fields[1])
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// MergeBase returns the best common ancestor of two commits.
+func (r *Repo) MergeBase(ctx context.Context, a, b string) (string, error) {
+	out, err := r.Run(ctx, "merge-base", a, b)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Show returns the contents of path at rev. A path that does not exist at
+// rev yields an empty string.
+func (r *Repo) Show(ctx context.Context, rev, path string) (string, error) {
+	if err := checkRefArg(rev); err != nil {
+		return "", err
+	}
+	if _, err := r.Run(ctx, "cat-file", "-e", rev+":"+path); err != nil {
+		return "", nil
+	}
+	return r.Run(ctx, "show", rev+":"+path)
+}
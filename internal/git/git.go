@@ -0,0 +1,73 @@
+// Package git provides structured access to a git working tree through the
+// git command line.
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// ErrNotRepository is returned when a directory is not inside a git work
+// tree.
+var ErrNotRepository = errors.New("not a git repository")
+
+// Repo is a git working tree.
+type Repo struct {
+	// Root is the top-level directory of the working tree.
+	Root string
+}
+
+// Open returns the repository containing dir.
+func Open(ctx context.Context, dir string) (*Repo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git executable not found: %w", err)
+	}
+	out, err := run(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotRepository, dir)
+	}
+	return &Repo{Root: strings.TrimSpace(out)}, nil
+}
+
+// Run executes git with args in the repository root and returns stdout.
+func (r *Repo) Run(ctx context.Context, args ...string) (string, error) {
+	return run(ctx, r.Root, args...)
+}
+
+func (r *Repo) runWithStdin(ctx context.Context, stdin string, args ...string) error {
+	_, err := runCmd(ctx, r.Root, strings.NewReader(stdin), args...)
+	return err
+}
+
+func run(ctx context.Context, dir string, args ...string) (string, error) {
+	return runCmd(ctx, dir, nil, args...)
+}
+
+func runCmd(ctx context.Context, dir string, stdin io.Reader, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdin = stdin
+	cmd.Dir = dir
+	// Keep output stable and never block on an editor or pager.
+	cmd.Env = append(cmd.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_EDITOR=true",
+		"GIT_PAGER=cat",
+		"LC_ALL=C",
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		return stdout.String(), fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,195 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRepo(t *testing.T) *Repo {
+	t.Helper()
+	dir := t.TempDir()
+	_, err := run(t.Context(), dir, "init", "--initial-branch=main")
+	require.NoError(t, err)
+	for _, kv := range [][2]string{
+		{"user.name", "Test"},
+		{"user.email", "test@example.com"},
+		{"commit.gpgsign", "false"},
+	} {
+		_, err := run(t.Context(), dir, "config", kv[0], kv[1])
+		require.NoError(t, err)
+	}
+	repo, err := Open(t.Context(), dir)
+	require.NoError(t, err)
+	return repo
+}
+
+func writeFile(t *testing.T, repo *Repo, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(repo.Root, name), []byte(content), 0o644))
+}
+
+func TestOpenNotRepository(t *testing.T) {
+	t.Parallel()
+	_, err := Open(t.Context(), t.TempDir())
+	require.ErrorIs(t, err, ErrNotRepository)
+}
+
+func TestStatusDiffCommitLog(t *testing.T) {
+	t.Parallel()
+	repo := newTestRepo(t)
+	ctx := t.Context()
+
+	writeFile(t, repo, "a.txt", "one\n")
+	status, err := repo.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "main", status.Branch)
+	require.Len(t, status.Files, 1)
+	require.True(t, status.Files[0].Untracked)
+
+	_, err = repo.Commit(ctx, CommitOptions{Message: "   "})
+	require.ErrorIs(t, err, ErrEmptyMessage)
+
+	_, err = repo.Commit(ctx, CommitOptions{Message: "nothing staged"})
+	require.ErrorIs(t, err, ErrNothingToCommit)
+
+	first, err := repo.Commit(ctx, CommitOptions{
+		Message:  "Add a.txt",
+		Footer:   "Generated with Nexora",
+		Trailers: []string{"Assisted-by: test-model via Nexora"},
+		Paths:    []string{"a.txt"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Add a.txt", first.Subject)
+	require.Equal(t, "Generated with Nexora\n\nAssisted-by: test-model via Nexora", first.Body)
+
+	status, err = repo.Status(ctx)
+	require.NoError(t, err)
+	require.True(t, status.Clean())
+
+	writeFile(t, repo, "a.txt", "one\ntwo\n")
+	status, err = repo.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []FileStatus{{Path: "a.txt", Staged: ".", Unstaged: "M"}}, status.Files)
+
+	diff, err := repo.Diff(ctx, DiffOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []FileDiff{{Path: "a.txt", Additions: 1}}, diff.Files)
+	require.Contains(t, diff.Patch, "+two")
+
+	staged, err := repo.Diff(ctx, DiffOptions{Staged: true})
+	require.NoError(t, err)
+	require.Empty(t, staged.Files)
+
+	_, err = repo.Commit(ctx, CommitOptions{Message: "Add two", All: true})
+	require.NoError(t, err)
+
+	commits, err := repo.Log(ctx, LogOptions{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	require.Equal(t, "Add two", commits[0].Subject)
+	require.Equal(t, first.Hash, commits[1].Hash)
+	require.Equal(t, "Test", commits[1].Author)
+
+	_, err = repo.Log(ctx, LogOptions{Ref: "--all"})
+	require.ErrorIs(t, err, ErrInvalidRef)
+}
+
+func TestBranches(t *testing.T) {
+	t.Parallel()
+	repo := newTestRepo(t)
+	ctx := t.Context()
+
+	writeFile(t, repo, "a.txt", "one\n")
+	_, err := repo.Commit(ctx, CommitOptions{Message: "init", Paths: []string{"a.txt"}})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.CreateBranch(ctx, "feature", "", true))
+	current, err := repo.CurrentBranch(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "feature", current)
+
+	writeFile(t, repo, "b.txt", "b\n")
+	_, err = repo.Commit(ctx, CommitOptions{Message: "feature work", Paths: []string{"b.txt"}})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Switch(ctx, "main"))
+	branches, err := repo.Branches(ctx)
+	require.NoError(t, err)
+	require.Len(t, branches, 2)
+	require.Equal(t, "feature", branches[0].Name)
+	require.False(t, branches[0].Current)
+	require.True(t, branches[1].Current)
+
+	base, err := repo.MergeBase(ctx, "main", "feature")
+	require.NoError(t, err)
+	require.Equal(t, branches[1].Hash, base)
+
+	content, err := repo.Show(ctx, "feature", "b.txt")
+	require.NoError(t, err)
+	require.Equal(t, "b\n", content)
+	content, err = repo.Show(ctx, "main", "b.txt")
+	require.NoError(t, err)
+	require.Empty(t, content)
+
+	// Unmerged branches are only deleted with force.
+	require.Error(t, repo.DeleteBranch(ctx, "feature", false))
+	require.NoError(t, repo.DeleteBranch(ctx, "feature", true))
+
+	require.ErrorIs(t, repo.CreateBranch(ctx, "-D", "", false), ErrInvalidRef)
+}
+
+func TestFormatMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		message  string
+		footer   string
+		trailers []string
+		want     string
+	}{
+		{
+			name:    "message only",
+			message: "Fix bug\n",
+			want:    "Fix bug\n",
+		},
+		{
+			name:     "footer and trailers",
+			message:  "Fix bug",
+			footer:   "Generated with Nexora",
+			trailers: []string{"Co-Authored-By: Nexora", "Co-Authored-By: Nexora"},
+			want:     "Fix bug\n\nGenerated with Nexora\n\nCo-Authored-By: Nexora\n",
+		},
+		{
+			name:     "existing trailer is not repeated",
+			message:  "Fix bug\n\nCo-Authored-By: Nexora",
+			trailers: []string{"Co-Authored-By: Nexora"},
+			want:     "Fix bug\n\nCo-Authored-By: Nexora\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, FormatMessage(tt.message, tt.footer, tt.trailers))
+		})
+	}
+}
+
+func TestParseStatusRenameAndConflict(t *testing.T) {
+	t.Parallel()
+	out := "# branch.oid abc\x00# branch.head main\x00# branch.upstream origin/main\x00# branch.ab +2 -1\x00" +
+		"2 R. N... 100644 100644 100644 abc abc R100 new name.go\x00old.go\x00" +
+		"u UU N... 100644 100644 100644 100644 a b c conflict.go\x00"
+
+	status := parseStatus(out)
+	require.Equal(t, "origin/main", status.Upstream)
+	require.Equal(t, 2, status.Ahead)
+	require.Equal(t, 1, status.Behind)
+	require.Equal(t, []FileStatus{
+		{Path: "new name.go", OrigPath: "old.go", Staged: "R", Unstaged: "."},
+		{Path: "conflict.go", Staged: "U", Unstaged: "U", Conflicted: true},
+	}, status.Files)
+}
@@ -0,0 +1,104 @@
+package git
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit is a single commit in the history.
+type Commit struct {
+	Hash    string    `json:"hash"`
+	Author  string    `json:"author"`
+	Email   string    `json:"email"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body,omitempty"`
+}
+
+// ShortHash returns the abbreviated commit hash.
+func (c Commit) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// LogOptions selects which commits Log returns.
+type LogOptions struct {
+	// Ref is the revision or range to list; empty means HEAD.
+	Ref string
+	// Limit caps the number of commits; zero means no limit.
+	Limit int
+	// Paths limits the history to commits touching the given paths.
+	Paths []string
+}
+
+// Separators that cannot appear in commit metadata.
+const (
+	logFieldSep  = "\x1f"
+	logRecordSep = "\x1e"
+)
+
+// Log lists commits, newest first.
+func (r *Repo) Log(ctx context.Context, opts LogOptions) ([]Commit, error) {
+	if err := checkRefArg(opts.Ref); err != nil {
+		return nil, err
+	}
+	args := []string{
+		"log",
+		"--no-color",
+		"--format=" + strings.Join([]string{"%H", "%an", "%ae", "%at", "%s", "%b"}, logFieldSep) + logRecordSep,
+	}
+	if opts.Limit > 0 {
+		args = append(args, "-n", strconv.Itoa(opts.Limit))
+	}
+	if opts.Ref != "" {
+		args = append(args, opts.Ref)
+	}
+	args = append(args, "--")
+	args = append(args, opts.Paths...)
+
+	out, err := r.Run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(out), nil
+}
+
+func parseLog(out string) []Commit {
+	var commits []Commit
+	for record := range strings.SplitSeq(out, logRecordSep) {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, logFieldSep, 6)
+		if len(fields) < 6 {
+			continue
+		}
+		unix, _ := strconv.ParseInt(fields[3], 10, 64)
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Email:   fields[2],
+			Date:    time.Unix(unix, 0),
+			Subject: fields[4],
+			Body:    strings.TrimSpace(fields[5]),
+		})
+	}
+	return commits
+}
+
+// Head returns the commit HEAD points to.
+func (r *Repo) Head(ctx context.Context) (Commit, error) {
+	commits, err := r.Log(ctx, LogOptions{Ref: "HEAD", Limit: 1})
+	if err != nil {
+		return Commit{}, err
+	}
+	if len(commits) == 0 {
+		return Commit{}, ErrNoCommits
+	}
+	return commits[0], nil
+}
@@ -0,0 +1,124 @@
+package git
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// FileStatus is the state of a single path in the working tree.
+type FileStatus struct {
+	Path string `json:"path"`
+	// OrigPath is the source path of a rename or copy.
+	OrigPath string `json:"orig_path,omitempty"`
+	// Staged and Unstaged use the porcelain status letters (M, A, D, R, C,
+	// T, U) or "." when unchanged.
+	Staged     string `json:"staged"`
+	Unstaged   string `json:"unstaged"`
+	Untracked  bool   `json:"untracked,omitempty"`
+	Conflicted bool   `json:"conflicted,omitempty"`
+}
+
+// Status is the parsed output of git status.
+type Status struct {
+	Branch   string       `json:"branch"`
+	Upstream string       `json:"upstream,omitempty"`
+	Ahead    int          `json:"ahead"`
+	Behind   int          `json:"behind"`
+	Detached bool         `json:"detached,omitempty"`
+	Files    []FileStatus `json:"files"`
+}
+
+// Clean reports whether the working tree has no changes.
+func (s Status) Clean() bool {
+	return len(s.Files) == 0
+}
+
+// Status returns the branch and per-file state of the working tree.
+func (r *Repo) Status(ctx context.Context) (Status, error) {
+	out, err := r.Run(ctx, "status", "--porcelain=v2", "--branch", "-z", "--untracked-files=all")
+	if err != nil {
+		return Status{}, err
+	}
+	return parseStatus(out), nil
+}
+
+// parseStatus parses NUL-separated `git status --porcelain=v2 --branch`
+// output.
+func parseStatus(out string) Status {
+	var status Status
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if entry == "" {
+			continue
+		}
+		switch entry[0] {
+		case '#':
+			fields := strings.Fields(entry)
+			if len(fields) < 3 {
+				continue
+			}
+			switch fields[1] {
+			case "branch.head":
+				status.Branch = fields[2]
+				status.Detached = fields[2] == "(detached)"
+			case "branch.upstream":
+				status.Upstream = fields[2]
+			case "branch.ab":
+				if len(fields) >= 4 {
+					status.Ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[2], "+"))
+					status.Behind, _ = strconv.Atoi(strings.TrimPrefix(fields[3], "-"))
+				}
+			}
+		case '1':
+			// 1 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <path>
+			fields := strings.SplitN(entry, " ", 9)
+			if len(fields) < 9 {
+				continue
+			}
+			status.Files = append(status.Files, FileStatus{
+				Path:     fields[8],
+				Staged:   fields[1][:1],
+				Unstaged: fields[1][1:],
+			})
+		case '2':
+			// 2 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <X><score> <path>, followed
+			// by the original path as the next entry.
+			fields := strings.SplitN(entry, " ", 10)
+			if len(fields) < 10 {
+				continue
+			}
+			file := FileStatus{
+				Path:     fields[9],
+				Staged:   fields[1][:1],
+				Unstaged: fields[1][1:],
+			}
+			if i+1 < len(entries) {
+				i++
+				file.OrigPath = entries[i]
+			}
+			status.Files = append(status.Files, file)
+		case 'u':
+			// u <XY> <sub> <m1> <m2> <m3> <mW> <h1> <h2> <h3> <path>
+			fields := strings.SplitN(entry, " ", 11)
+			if len(fields) < 11 {
+				continue
+			}
+			status.Files = append(status.Files, FileStatus{
+				Path:       fields[10],
+				Staged:     fields[1][:1],
+				Unstaged:   fields[1][1:],
+				Conflicted: true,
+			})
+		case '?':
+			status.Files = append(status.Files, FileStatus{
+				Path:      strings.TrimPrefix(entry, "? "),
+				Staged:    ".",
+				Unstaged:  "?",
+				Untracked: true,
+			})
+		}
+	}
+	return status
+}
@@ -23,6 +23,7 @@ type File struct {
 	Version   int64
 	CreatedAt int64
 	UpdatedAt int64
+	MessageID string // Message whose tool call wrote the version, if known
 }
 
 type Service interface {
@@ -37,6 +38,19 @@ type Service interface {
 	DeleteSessionFiles(ctx context.Context, sessionID string) error
 }
 
+type messageIDContextKey struct{}
+
+// WithMessageID returns a context in which file versions are recorded as
+// written by the message with the given ID.
+func WithMessageID(ctx context.Context, messageID string) context.Context {
+	return context.WithValue(ctx, messageIDContextKey{}, messageID)
+}
+
+func messageIDFromContext(ctx context.Context) string {
+	messageID, _ := ctx.Value(messageIDContextKey{}).(string)
+	return messageID
+}
+
 type service struct {
 	*pubsub.Broker[File]
 	db *sql.DB
@@ -98,6 +112,7 @@ func (s *service) createWithVersion(ctx context.Context, sessionID, path, conten
 			Path:      path,
 			Content:   content,
 			Version:   version,
+			MessageID: messageIDFromContext(ctx),
 		})
 		if txErr != nil {
 			// Rollback the transaction
@@ -206,5 +221,6 @@ func (s *service) fromDBItem(item db.File) File {
 		Version:   item.Version,
 		CreatedAt: item.CreatedAt,
 		UpdatedAt: item.UpdatedAt,
+		MessageID: item.MessageID,
 	}
 }
@@ -0,0 +1,182 @@
+package history
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/nexora/nexora/internal/diff"
+)
+
+// ErrRewindConflict is returned when a rewind would overwrite changes made
+// outside the agent since the last recorded version of a file.
+var ErrRewindConflict = errors.New("files were modified outside the agent")
+
+// RewindChange describes how a single file is restored by a rewind.
+type RewindChange struct {
+	Path string
+	// Current is the content currently on disk.
+	Current string
+	// Restored is the content the file will be restored to.
+	Restored string
+	// Remove is set when the file did not exist before the rewind point.
+	Remove bool
+	// Conflict is set when the file on disk differs from the last version
+	// the agent recorded, meaning the user edited it afterwards.
+	Conflict bool
+}
+
+// Diff returns a unified diff from the current content to the restored one.
+func (c RewindChange) Diff() (string, int, int) {
+	return diff.GenerateDiff(c.Current, c.Restored, c.Path)
+}
+
+// RewindPoint is the message a session is rewound to. Every file version
+// written while answering it or a later message is undone.
+type RewindPoint struct {
+	// MessageIDs are the IDs of the message and of every later message of
+	// the session.
+	MessageIDs []string
+	// Since is the creation time of the message (a unix timestamp in
+	// seconds). It places the versions that are not linked to a message,
+	// such as those recorded by earlier rewinds.
+	Since int64
+}
+
+// includes reports whether a file version was written at or after the rewind
+// point. Versions are placed by the message that wrote them, as timestamps
+// cannot order those written in the same second as the message.
+func (p RewindPoint) includes(f File) bool {
+	if f.MessageID != "" {
+		return slices.Contains(p.MessageIDs, f.MessageID)
+	}
+	return f.CreatedAt >= p.Since
+}
+
+// RewindPlan is the set of file changes needed to roll a session's working
+// tree back to the state before a rewind point.
+type RewindPlan struct {
+	SessionID string
+	Point     RewindPoint
+	Changes   []RewindChange
+}
+
+// Conflicts returns the changes that would overwrite user edits.
+func (p RewindPlan) Conflicts() []RewindChange {
+	var conflicts []RewindChange
+	for _, c := range p.Changes {
+		if c.Conflict {
+			conflicts = append(conflicts, c)
+		}
+	}
+	return conflicts
+}
+
+// PlanRewind computes the changes required to restore every file the agent
+// touched in a session at or after point to its content before that point.
+func PlanRewind(ctx context.Context, files Service, sessionID string, point RewindPoint) (RewindPlan, error) {
+	versions, err := files.ListBySession(ctx, sessionID)
+	if err != nil {
+		return RewindPlan{}, fmt.Errorf("failed to list file history: %w", err)
+	}
+
+	byPath := make(map[string][]File)
+	for _, f := range versions {
+		byPath[f.Path] = append(byPath[f.Path], f)
+	}
+
+	plan := RewindPlan{SessionID: sessionID, Point: point}
+	for path, fileVersions := range byPath {
+		slices.SortStableFunc(fileVersions, func(a, b File) int {
+			if c := cmp.Compare(a.Version, b.Version); c != 0 {
+				return c
+			}
+			return cmp.Compare(a.CreatedAt, b.CreatedAt)
+		})
+
+		latest := fileVersions[len(fileVersions)-1]
+		if !point.includes(latest) {
+			// Not touched since the rewind point.
+			continue
+		}
+
+		// The baseline is the last version recorded before the rewind point.
+		// When the file was first touched afterwards, the initial version
+		// holds its content from before the agent's first write.
+		baseline := fileVersions[0]
+		for _, f := range fileVersions {
+			if point.includes(f) {
+				break
+			}
+			baseline = f
+		}
+
+		change := RewindChange{
+			Path:     path,
+			Restored: baseline.Content,
+			Remove:   baseline.Version == InitialVersion && baseline.Content == "" && point.includes(baseline),
+		}
+
+		exists := true
+		current, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			change.Current = string(current)
+			change.Conflict = change.Current != latest.Content
+		case os.IsNotExist(err):
+			exists = false
+			change.Conflict = latest.Content != ""
+		default:
+			return RewindPlan{}, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		if change.Remove && !exists || !change.Remove && exists && change.Current == change.Restored {
+			continue
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	slices.SortFunc(plan.Changes, func(a, b RewindChange) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return plan, nil
+}
+
+// ApplyRewind writes the restored content of every change in plan to disk
+// and records it as a new version so later rewinds see it. Unless force is
+// set, it refuses to run when any change conflicts with user edits.
+func ApplyRewind(ctx context.Context, files Service, plan RewindPlan, force bool) error {
+	if conflicts := plan.Conflicts(); len(conflicts) > 0 && !force {
+		paths := make([]string, len(conflicts))
+		for i, c := range conflicts {
+			paths[i] = c.Path
+		}
+		return fmt.Errorf("%w: %s", ErrRewindConflict, strings.Join(paths, ", "))
+	}
+
+	for _, c := range plan.Changes {
+		if c.Remove {
+			if err := os.Remove(c.Path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %w", c.Path, err)
+			}
+		} else {
+			// Keep the mode of files that still exist, such as executables
+			perm := os.FileMode(0o644)
+			if info, err := os.Stat(c.Path); err == nil {
+				perm = info.Mode().Perm()
+			}
+			if err := os.WriteFile(c.Path, []byte(c.Restored), perm); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", c.Path, err)
+			}
+		}
+
+		if _, err := files.CreateVersion(ctx, plan.SessionID, c.Path, c.Restored); err != nil {
+			return fmt.Errorf("failed to record restored version of %s: %w", c.Path, err)
+		}
+	}
+	return nil
+}
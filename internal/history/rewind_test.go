@@ -0,0 +1,203 @@
+package history
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nexora/nexora/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeService struct {
+	*pubsub.Broker[File]
+	files []File
+	now   int64
+}
+
+func newFakeService() *fakeService {
+	return &fakeService{Broker: pubsub.NewBroker[File]()}
+}
+
+func (f *fakeService) add(sessionID, path, content string, version, createdAt int64) {
+	f.addByMessage(sessionID, "", path, content, version, createdAt)
+}
+
+func (f *fakeService) addByMessage(sessionID, messageID, path, content string, version, createdAt int64) {
+	f.files = append(f.files, File{
+		ID:        path,
+		SessionID: sessionID,
+		Path:      path,
+		Content:   content,
+		Version:   version,
+		CreatedAt: createdAt,
+		MessageID: messageID,
+	})
+}
+
+func (f *fakeService) Create(ctx context.Context, sessionID, path, content string) (File, error) {
+	f.addByMessage(sessionID, messageIDFromContext(ctx), path, content, InitialVersion, f.now)
+	return f.files[len(f.files)-1], nil
+}
+
+func (f *fakeService) CreateVersion(ctx context.Context, sessionID, path, content string) (File, error) {
+	next := int64(InitialVersion)
+	for _, file := range f.files {
+		if file.Path == path && file.Version >= next {
+			next = file.Version + 1
+		}
+	}
+	f.addByMessage(sessionID, messageIDFromContext(ctx), path, content, next, f.now)
+	return f.files[len(f.files)-1], nil
+}
+
+func (f *fakeService) Get(ctx context.Context, id string) (File, error) { return File{}, nil }
+
+func (f *fakeService) GetByPathAndSession(ctx context.Context, path, sessionID string) (File, error) {
+	return File{}, nil
+}
+
+func (f *fakeService) ListBySession(ctx context.Context, sessionID string) ([]File, error) {
+	var files []File
+	for _, file := range f.files {
+		if file.SessionID == sessionID {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+func (f *fakeService) ListLatestSessionFiles(ctx context.Context, sessionID string) ([]File, error) {
+	return nil, nil
+}
+
+func (f *fakeService) Delete(ctx context.Context, id string) error { return nil }
+
+func (f *fakeService) DeleteSessionFiles(ctx context.Context, sessionID string) error { return nil }
+
+func TestPlanRewind(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	edited := filepath.Join(dir, "edited.go")
+	created := filepath.Join(dir, "created.go")
+	untouched := filepath.Join(dir, "untouched.go")
+
+	svc := newFakeService()
+	// Turn 1 (t=10): edit and create files.
+	svc.add("s1", edited, "v0", 0, 10)
+	svc.add("s1", edited, "v1", 1, 10)
+	svc.add("s1", untouched, "u0", 0, 10)
+	svc.add("s1", untouched, "u1", 1, 10)
+	// Turn 2 (t=20): edit again and create a new file.
+	svc.add("s1", edited, "v2", 2, 20)
+	svc.add("s1", created, "", 0, 20)
+	svc.add("s1", created, "new", 1, 20)
+
+	require.NoError(t, os.WriteFile(edited, []byte("v2"), 0o600))
+	require.NoError(t, os.WriteFile(created, []byte("new"), 0o600))
+	require.NoError(t, os.WriteFile(untouched, []byte("u1"), 0o600))
+
+	t.Run("last turn", func(t *testing.T) {
+		plan, err := PlanRewind(t.Context(), svc, "s1", RewindPoint{Since: 20})
+		require.NoError(t, err)
+		require.Len(t, plan.Changes, 2)
+
+		require.Equal(t, created, plan.Changes[0].Path)
+		require.True(t, plan.Changes[0].Remove)
+
+		require.Equal(t, edited, plan.Changes[1].Path)
+		require.Equal(t, "v1", plan.Changes[1].Restored)
+		require.False(t, plan.Changes[1].Conflict)
+		require.Empty(t, plan.Conflicts())
+	})
+
+	t.Run("whole session", func(t *testing.T) {
+		plan, err := PlanRewind(t.Context(), svc, "s1", RewindPoint{Since: 10})
+		require.NoError(t, err)
+		require.Len(t, plan.Changes, 3)
+		require.Equal(t, "v0", plan.Changes[1].Restored)
+		require.Equal(t, "u0", plan.Changes[2].Restored)
+	})
+
+	t.Run("other session", func(t *testing.T) {
+		plan, err := PlanRewind(t.Context(), svc, "s2", RewindPoint{Since: 0})
+		require.NoError(t, err)
+		require.Empty(t, plan.Changes)
+	})
+}
+
+func TestPlanRewindSameSecond(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	edited := filepath.Join(dir, "edited.go")
+	created := filepath.Join(dir, "created.go")
+
+	// Both turns and their edits land in the same second.
+	svc := newFakeService()
+	svc.addByMessage("s1", "assistant-1", edited, "v0", 0, 10)
+	svc.addByMessage("s1", "assistant-1", edited, "v1", 1, 10)
+	svc.addByMessage("s1", "assistant-1", created, "", 0, 10)
+	svc.addByMessage("s1", "assistant-1", created, "new", 1, 10)
+	svc.addByMessage("s1", "assistant-2", edited, "v2", 2, 10)
+
+	require.NoError(t, os.WriteFile(edited, []byte("v2"), 0o600))
+	require.NoError(t, os.WriteFile(created, []byte("new"), 0o600))
+
+	point := RewindPoint{MessageIDs: []string{"user-2", "assistant-2"}, Since: 10}
+	plan, err := PlanRewind(t.Context(), svc, "s1", point)
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1)
+	require.Equal(t, edited, plan.Changes[0].Path)
+	require.Equal(t, "v1", plan.Changes[0].Restored)
+}
+
+func TestApplyRewind(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	edited := filepath.Join(dir, "edited.go")
+	created := filepath.Join(dir, "created.go")
+
+	svc := newFakeService()
+	svc.now = 30
+	svc.add("s1", edited, "before", 0, 20)
+	svc.add("s1", edited, "after", 1, 20)
+	svc.add("s1", created, "", 0, 20)
+	svc.add("s1", created, "new", 1, 20)
+
+	require.NoError(t, os.WriteFile(edited, []byte("user edit"), 0o755))
+	require.NoError(t, os.WriteFile(created, []byte("new"), 0o600))
+
+	plan, err := PlanRewind(t.Context(), svc, "s1", RewindPoint{Since: 20})
+	require.NoError(t, err)
+	require.Len(t, plan.Conflicts(), 1)
+
+	err = ApplyRewind(t.Context(), svc, plan, false)
+	require.ErrorIs(t, err, ErrRewindConflict)
+
+	content, err := os.ReadFile(edited)
+	require.NoError(t, err)
+	require.Equal(t, "user edit", string(content))
+
+	require.NoError(t, ApplyRewind(t.Context(), svc, plan, true))
+
+	content, err = os.ReadFile(edited)
+	require.NoError(t, err)
+	require.Equal(t, "before", string(content))
+	info, err := os.Stat(edited)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+	_, err = os.Stat(created)
+	require.True(t, os.IsNotExist(err))
+
+	// The restored state is recorded, so planning again finds nothing to do.
+	plan, err = PlanRewind(t.Context(), svc, "s1", RewindPoint{Since: 20})
+	require.NoError(t, err)
+	for _, c := range plan.Changes {
+		require.False(t, c.Conflict, c.Path)
+	}
+}
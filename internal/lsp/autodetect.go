@@ -6,7 +6,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+
+	"github.com/nexora/nexora/internal/config"
 )
 
 // DetectLanguage detects the programming language from a file path based on extension.
@@ -35,30 +38,55 @@ func DetectLanguage(path string) string {
 	return ""
 }
 
+type projectMarker struct {
+	marker   string
+	language string
+}
+
+// projectMarkers maps root marker files to the language they indicate, in
+// detection order.
+var projectMarkers = []projectMarker{
+	{"go.mod", "go"},
+	{"go.sum", "go"},
+	{"Cargo.toml", "rust"},
+	{"Cargo.lock", "rust"},
+	{"tsconfig.json", "typescript"},
+	{"package.json", "typescript"}, // Could be JS too, but assume TS
+	{"requirements.txt", "python"},
+	{"setup.py", "python"},
+	{"pyproject.toml", "python"},
+}
+
 // DetectLanguageFromDirectory detects the language of a project by examining marker files.
 // Returns the language identifier or empty string if detection fails.
 func DetectLanguageFromDirectory(dir string) string {
-	// Check for common project marker files
-	markers := map[string]string{
-		"go.mod":           "go",
-		"go.sum":           "go",
-		"Cargo.toml":       "rust",
-		"Cargo.lock":       "rust",
-		"tsconfig.json":    "typescript",
-		"package.json":     "typescript", // Could be JS too, but assume TS
-		"requirements.txt": "python",
-		"setup.py":         "python",
-		"pyproject.toml":   "python",
-	}
-
-	for marker, lang := range markers {
-		path := filepath.Join(dir, marker)
-		if _, err := os.Stat(path); err == nil {
-			return lang
+	if langs := DetectLanguagesFromDirectory(dir); len(langs) > 0 {
+		return langs[0]
+	}
+	return ""
+}
+
+// DetectLanguagesFromDirectory returns every language whose marker files are
+// present in dir, without duplicates and in a stable order.
+func DetectLanguagesFromDirectory(dir string) []string {
+	var langs []string
+	for _, m := range projectMarkers {
+		if slices.Contains(langs, m.language) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, m.marker)); err == nil {
+			langs = append(langs, m.language)
 		}
 	}
+	return langs
+}
 
-	return ""
+// IsProjectMarker reports whether name is a marker file used to detect the
+// project language.
+func IsProjectMarker(name string) bool {
+	return slices.ContainsFunc(projectMarkers, func(m projectMarker) bool {
+		return m.marker == name
+	})
 }
 
 // LSPServerInfo contains information about an LSP server for a language.
@@ -115,6 +143,27 @@ func GetLSPServerInfo(language string) *LSPServerInfo {
 	}
 }
 
+// AutoConfig returns the client configuration used to start the server for
+// language when it is not configured explicitly. Without root markers the
+// server starts even if the project has none, e.g. for a single new file.
+func AutoConfig(language string, withRootMarkers bool) (config.LSPConfig, bool) {
+	info := GetLSPServerInfo(language)
+	if info == nil {
+		return config.LSPConfig{}, false
+	}
+	cfg := config.LSPConfig{
+		Command: info.Command,
+		Args:    slices.Clone(info.Args),
+	}
+	for _, ft := range info.FileTypes {
+		cfg.FileTypes = append(cfg.FileTypes, strings.TrimPrefix(ft, "."))
+	}
+	if withRootMarkers {
+		cfg.RootMarkers = slices.Clone(info.RootMarkers)
+	}
+	return cfg, true
+}
+
 // GetLSPServerCommand returns the command name for a language's LSP server.
 func GetLSPServerCommand(language string) string {
 	info := GetLSPServerInfo(language)
@@ -192,12 +241,11 @@ func InstallLSPServer(ctx context.Context, language string, confirm ConfirmFunc)
 		return fmt.Errorf("empty install command")
 	}
 
+	// Capture the output instead of writing to the terminal, which may be
+	// owned by the TUI.
 	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("installation failed: %w", err)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("installation failed: %w: %s", err, strings.TrimSpace(string(out)))
 	}
 
 	// Verify installation succeeded
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -245,3 +246,45 @@ func TestInstallLSPServer(t *testing.T) {
 		})
 	}
 }
+
+func TestDetectLanguagesFromDirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, file := range []string{"pyproject.toml", "go.mod", "go.sum", "package.json"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, file), []byte{}, 0o644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	got := DetectLanguagesFromDirectory(tmpDir)
+	want := []string{"go", "typescript", "python"}
+	if !slices.Equal(got, want) {
+		t.Errorf("DetectLanguagesFromDirectory() = %v, want %v", got, want)
+	}
+	if got := DetectLanguagesFromDirectory(t.TempDir()); len(got) != 0 {
+		t.Errorf("DetectLanguagesFromDirectory(empty) = %v, want none", got)
+	}
+}
+
+func TestAutoConfig(t *testing.T) {
+	cfg, ok := AutoConfig("javascript", true)
+	if !ok {
+		t.Fatal("AutoConfig(javascript) not found")
+	}
+	if cfg.Command != "typescript-language-server" || !slices.Equal(cfg.Args, []string{"--stdio"}) {
+		t.Errorf("AutoConfig(javascript) command = %q %v", cfg.Command, cfg.Args)
+	}
+	if !slices.Equal(cfg.FileTypes, []string{"ts", "tsx", "js", "jsx"}) {
+		t.Errorf("AutoConfig(javascript) file types = %v", cfg.FileTypes)
+	}
+	if !slices.Equal(cfg.RootMarkers, []string{"package.json", "tsconfig.json"}) {
+		t.Errorf("AutoConfig(javascript) root markers = %v", cfg.RootMarkers)
+	}
+
+	cfg, _ = AutoConfig("go", false)
+	if len(cfg.RootMarkers) != 0 {
+		t.Errorf("AutoConfig(go, false) root markers = %v, want none", cfg.RootMarkers)
+	}
+	if _, ok := AutoConfig("cobol", true); ok {
+		t.Error("AutoConfig(cobol) should not be found")
+	}
+}
@@ -1,8 +1,117 @@
 package message
 
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxAttachmentSize is the largest file that can be attached to a prompt.
+// Providers accept less for some types; those limits are applied when the
+// prompt is sent.
+const MaxAttachmentSize = int64(20 * 1024 * 1024) // 20MB
+
+var (
+	ErrAttachmentTooLarge    = errors.New("attachment too large")
+	ErrUnsupportedAttachment = errors.New("unsupported attachment type")
+)
+
 type Attachment struct {
 	FilePath string
 	FileName string
 	MimeType string
 	Content  []byte
 }
+
+// NewAttachment creates an attachment for content, detecting its MIME type
+// from the content and, for text, the file name.
+func NewAttachment(path, name string, content []byte) Attachment {
+	return Attachment{
+		FilePath: path,
+		FileName: name,
+		MimeType: DetectMimeType(name, content),
+		Content:  content,
+	}
+}
+
+// ReadAttachment reads the file at path as an attachment. Only images, PDFs
+// and text files up to MaxAttachmentSize can be attached.
+func ReadAttachment(path string) (Attachment, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Attachment{}, err
+	}
+	if info.IsDir() {
+		return Attachment{}, fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > MaxAttachmentSize {
+		return Attachment{}, fmt.Errorf("%s: %w (%d bytes, max %d)", path, ErrAttachmentTooLarge, info.Size(), MaxAttachmentSize)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Attachment{}, err
+	}
+	attachment := NewAttachment(path, filepath.Base(path), content)
+	if !attachment.IsImage() && !attachment.IsPDF() && !attachment.IsText() {
+		return Attachment{}, fmt.Errorf("%s: %w %s", path, ErrUnsupportedAttachment, attachment.MimeType)
+	}
+	return attachment, nil
+}
+
+func (a Attachment) IsImage() bool {
+	return IsImageMimeType(a.MimeType)
+}
+
+func (a Attachment) IsPDF() bool {
+	return a.MimeType == "application/pdf"
+}
+
+func (a Attachment) IsText() bool {
+	return IsTextMimeType(a.MimeType)
+}
+
+// DetectMimeType sniffs the MIME type of content. Text is refined by the
+// extension of name, so that e.g. JSON is not sent as plain text. Parameters
+// such as the charset are dropped.
+func DetectMimeType(name string, content []byte) string {
+	sniffed := http.DetectContentType(content[:min(512, len(content))])
+	mimeType, _, _ := strings.Cut(sniffed, ";")
+	if mimeType == "application/octet-stream" && len(content) > 0 && utf8.Valid(content) {
+		mimeType = "text/plain"
+	}
+	if mimeType != "text/plain" {
+		return mimeType
+	}
+	if byExt, _, _ := strings.Cut(mime.TypeByExtension(filepath.Ext(name)), ";"); IsTextMimeType(byExt) {
+		return byExt
+	}
+	return mimeType
+}
+
+// IsImageMimeType reports whether mimeType is an image format models accept.
+func IsImageMimeType(mimeType string) bool {
+	switch mimeType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+// IsTextMimeType reports whether mimeType is text that can be inlined in a
+// prompt.
+func IsTextMimeType(mimeType string) bool {
+	if strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+	switch mimeType {
+	case "application/json", "application/xml", "application/yaml", "application/x-yaml",
+		"application/javascript", "application/toml", "application/x-sh":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package message_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nexora/nexora/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestDetectMimeType(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+	}{
+		{"shot.png", pngHeader, "image/png"},
+		{"renamed.txt", pngHeader, "image/png"},
+		{"doc.pdf", []byte("%PDF-1.7\n"), "application/pdf"},
+		{"notes.txt", []byte("hello"), "text/plain"},
+		{"data.json", []byte(`{"a": 1}`), "application/json"},
+		{"unknown", []byte("plain words"), "text/plain"},
+		{"blob.bin", []byte{0x00, 0xff, 0xfe, 0x01}, "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, message.DetectMimeType(tt.name, tt.content), tt.name)
+	}
+}
+
+func TestReadAttachment(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, content, 0o644))
+		return path
+	}
+
+	img, err := message.ReadAttachment(write("shot.png", pngHeader))
+	require.NoError(t, err)
+	require.Equal(t, "shot.png", img.FileName)
+	require.True(t, img.IsImage())
+
+	pdf, err := message.ReadAttachment(write("doc.pdf", []byte("%PDF-1.4\n")))
+	require.NoError(t, err)
+	require.True(t, pdf.IsPDF())
+
+	text, err := message.ReadAttachment(write("notes.md", []byte("# Notes\n")))
+	require.NoError(t, err)
+	require.True(t, text.IsText())
+
+	_, err = message.ReadAttachment(write("blob.bin", []byte{0x00, 0xff, 0xfe, 0x01}))
+	require.True(t, errors.Is(err, message.ErrUnsupportedAttachment))
+
+	big := write("big.txt", nil)
+	require.NoError(t, os.Truncate(big, message.MaxAttachmentSize+1))
+	_, err = message.ReadAttachment(big)
+	require.True(t, errors.Is(err, message.ErrAttachmentTooLarge))
+
+	_, err = message.ReadAttachment(dir)
+	require.Error(t, err)
+	_, err = message.ReadAttachment(filepath.Join(dir, "missing.png"))
+	require.Error(t, err)
+}
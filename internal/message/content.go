@@ -104,13 +104,14 @@ type ToolCall struct {
 func (ToolCall) isPart() {}
 
 type ToolResult struct {
-	ToolCallID string `json:"tool_call_id"`
-	Name       string `json:"name"`
-	Content    string `json:"content"`
-	Data       string `json:"data"`
-	MIMEType   string `json:"mime_type"`
-	Metadata   string `json:"metadata"`
-	IsError    bool   `json:"is_error"`
+	ToolCallID  string          `json:"tool_call_id"`
+	Name        string          `json:"name"`
+	Content     string          `json:"content"`
+	Data        string          `json:"data"`
+	MIMEType    string          `json:"mime_type"`
+	Metadata    string          `json:"metadata"`
+	IsError     bool            `json:"is_error"`
+	Attachments []BinaryContent `json:"attachments,omitempty"`
 }
 
 func (ToolResult) isPart() {}
@@ -120,6 +121,17 @@ type Finish struct {
 	Time    int64        `json:"time"`
 	Message string       `json:"message,omitempty"`
 	Details string       `json:"details,omitempty"`
+	Usage   *TokenUsage  `json:"usage,omitempty"`
+}
+
+// TokenUsage is the token usage reported for the request that produced a
+// message. InputTokens excludes the tokens read from or written to the
+// prompt cache.
+type TokenUsage struct {
+	InputTokens         int64 `json:"input_tokens"`
+	OutputTokens        int64 `json:"output_tokens"`
+	CacheReadTokens     int64 `json:"cache_read_tokens,omitempty"`
+	CacheCreationTokens int64 `json:"cache_creation_tokens,omitempty"`
 }
 
 func (Finish) isPart() {}
@@ -418,6 +430,17 @@ func (m *Message) AddFinish(reason FinishReason, message, details string) {
 	m.Parts = append(m.Parts, Finish{Reason: reason, Time: time.Now().Unix(), Message: message, Details: details})
 }
 
+// SetUsage records the token usage on the finish part of the message.
+func (m *Message) SetUsage(usage TokenUsage) {
+	for i, part := range m.Parts {
+		if c, ok := part.(Finish); ok {
+			c.Usage = &usage
+			m.Parts[i] = c
+			return
+		}
+	}
+}
+
 func (m *Message) AddImageURL(url, detail string) {
 	m.Parts = append(m.Parts, ImageURLContent{URL: url, Detail: detail})
 }
@@ -513,3 +536,23 @@ func (m *Message) ToAIMessage() []fantasy.Message {
 	}
 	return messages
 }
+
+// ToolAttachmentsMessage builds the user message that carries the files
+// attached to tool results. Tool results can only hold text or a single media
+// part, so attachments follow them as a user message.
+func ToolAttachmentsMessage(attachments []BinaryContent) fantasy.Message {
+	parts := []fantasy.MessagePart{
+		fantasy.TextPart{Text: "Files attached to the tool results above:"},
+	}
+	for _, attachment := range attachments {
+		parts = append(parts, fantasy.FilePart{
+			Filename:  attachment.Path,
+			Data:      attachment.Data,
+			MediaType: attachment.MIMEType,
+		})
+	}
+	return fantasy.Message{
+		Role:    fantasy.MessageRoleUser,
+		Content: parts,
+	}
+}
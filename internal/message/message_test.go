@@ -197,6 +197,36 @@ func (m *MockQuerier) DeleteCheckpoint(ctx context.Context, id string) error {
 func (m *MockQuerier) DeleteOldCheckpoints(ctx context.Context, arg db.DeleteOldCheckpointsParams) error {
 	return nil
 }
+func (m *MockQuerier) CreateModelSpeedSample(ctx context.Context, arg db.CreateModelSpeedSampleParams) error {
+	return nil
+}
+func (m *MockQuerier) ListModelSpeedSamples(ctx context.Context, createdAt int64) ([]db.ModelSpeedSample, error) {
+	return []db.ModelSpeedSample{}, nil
+}
+func (m *MockQuerier) DeleteModelSpeedSamplesBefore(ctx context.Context, createdAt int64) error {
+	return nil
+}
+func (m *MockQuerier) UpsertAgentState(ctx context.Context, arg db.UpsertAgentStateParams) error {
+	return nil
+}
+func (m *MockQuerier) GetAgentState(ctx context.Context, sessionID string) (db.AgentState, error) {
+	return db.AgentState{}, sql.ErrNoRows
+}
+func (m *MockQuerier) ListAgentStates(ctx context.Context) ([]db.AgentState, error) {
+	return []db.AgentState{}, nil
+}
+func (m *MockQuerier) DeleteAgentState(ctx context.Context, sessionID string) error {
+	return nil
+}
+func (m *MockQuerier) CreateRecoveryAttempt(ctx context.Context, arg db.CreateRecoveryAttemptParams) error {
+	return nil
+}
+func (m *MockQuerier) ListRecoveryAttempts(ctx context.Context, createdAt int64) ([]db.RecoveryAttempt, error) {
+	return []db.RecoveryAttempt{}, nil
+}
+func (m *MockQuerier) ListRecoveryAttemptsBySession(ctx context.Context, sessionID string) ([]db.RecoveryAttempt, error) {
+	return []db.RecoveryAttempt{}, nil
+}
 
 func TestNewService(t *testing.T) {
 	mock := NewMockQuerier()
@@ -336,6 +366,41 @@ func TestUpdateMessage(t *testing.T) {
 	assert.Contains(t, mock.calls, "UpdateMessage")
 }
 
+func TestFinishUsageRoundTrip(t *testing.T) {
+	mock := NewMockQuerier()
+	svc := message.NewService(mock)
+	ctx := context.Background()
+
+	msg := message.Message{Parts: []message.ContentPart{message.TextContent{Text: "Done"}}}
+	// Without a finish part there is nothing to record the usage on.
+	msg.SetUsage(message.TokenUsage{InputTokens: 1})
+	assert.Nil(t, msg.FinishPart())
+
+	msg.AddFinish(message.FinishReasonEndTurn, "", "")
+	msg.SetUsage(message.TokenUsage{
+		InputTokens:         120,
+		OutputTokens:        40,
+		CacheReadTokens:     9_000,
+		CacheCreationTokens: 300,
+	})
+
+	created, err := svc.Create(ctx, "test-session", message.CreateMessageParams{
+		Role:  message.Assistant,
+		Parts: msg.Parts,
+	})
+	require.NoError(t, err)
+
+	got, err := svc.Get(ctx, created.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.FinishPart())
+	assert.Equal(t, &message.TokenUsage{
+		InputTokens:         120,
+		OutputTokens:        40,
+		CacheReadTokens:     9_000,
+		CacheCreationTokens: 300,
+	}, got.FinishPart().Usage)
+}
+
 func TestDeleteSessionMessages(t *testing.T) {
 	mock := NewMockQuerier()
 	svc := message.NewService(mock)
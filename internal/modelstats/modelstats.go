@@ -0,0 +1,184 @@
+// Package modelstats records how fast models answer in practice and keeps
+// rolling statistics of their time to first token and output speed.
+package modelstats
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/nexora/nexora/internal/db"
+)
+
+const (
+	// WindowSize is the number of most recent samples the statistics of a
+	// model are computed from.
+	WindowSize = 20
+	// MaxAge is how long a sample is kept.
+	MaxAge = 30 * 24 * time.Hour
+	// MinSamples is the number of samples needed before the statistics of a
+	// model are trusted over the static speed table.
+	MinSamples = 3
+)
+
+// Sample is a single measured completion.
+type Sample struct {
+	Provider string
+	Model    string
+	// TTFT is the time from sending the request to the first streamed token.
+	TTFT time.Duration
+	// Duration is the time from sending the request to the end of the
+	// response.
+	Duration     time.Duration
+	OutputTokens int64
+}
+
+// TokensPerSecond returns the output speed of the sample, not counting the
+// time spent waiting for the first token. It returns 0 when the sample has
+// no output to measure.
+func (s Sample) TokensPerSecond() float64 {
+	generation := s.Duration - s.TTFT
+	if s.OutputTokens <= 0 || generation <= 0 {
+		return 0
+	}
+	return float64(s.OutputTokens) / generation.Seconds()
+}
+
+// Stats are the rolling statistics of a model.
+type Stats struct {
+	Provider string
+	Model    string
+	// Samples is the number of samples the statistics are computed from.
+	Samples int
+	// TTFT is the median time to first token.
+	TTFT time.Duration
+	// TokensPerSecond is the median output speed.
+	TokensPerSecond float64
+	LastMeasured    time.Time
+}
+
+// Reliable reports whether there are enough samples to trust the stats.
+func (s Stats) Reliable() bool {
+	return s.Samples >= MinSamples
+}
+
+// Latency estimates how long a completion of the given number of output
+// tokens takes.
+func (s Stats) Latency(outputTokens int) time.Duration {
+	latency := s.TTFT
+	if s.TokensPerSecond > 0 {
+		latency += time.Duration(float64(outputTokens) / s.TokensPerSecond * float64(time.Second))
+	}
+	return latency
+}
+
+// Key returns the key of a model in the map returned by Service.All.
+func Key(provider, model string) string {
+	return provider + ":" + model
+}
+
+type Service interface {
+	Record(ctx context.Context, sample Sample) error
+	All(ctx context.Context) (map[string]Stats, error)
+	Get(ctx context.Context, provider, model string) (Stats, bool, error)
+}
+
+type service struct {
+	q   db.Querier
+	now func() time.Time
+}
+
+func NewService(q db.Querier) Service {
+	return &service{q: q, now: time.Now}
+}
+
+// Record stores a sample and drops the samples that fell out of MaxAge.
+func (s *service) Record(ctx context.Context, sample Sample) error {
+	if sample.Provider == "" || sample.Model == "" || sample.TTFT < 0 || sample.Duration < sample.TTFT {
+		return nil
+	}
+	if err := s.q.CreateModelSpeedSample(ctx, db.CreateModelSpeedSampleParams{
+		Provider:     sample.Provider,
+		Model:        sample.Model,
+		TtftMs:       sample.TTFT.Milliseconds(),
+		OutputTokens: max(sample.OutputTokens, 0),
+		DurationMs:   sample.Duration.Milliseconds(),
+	}); err != nil {
+		return err
+	}
+	return s.q.DeleteModelSpeedSamplesBefore(ctx, s.now().Add(-MaxAge).Unix())
+}
+
+// All returns the stats of every measured model keyed by Key.
+func (s *service) All(ctx context.Context) (map[string]Stats, error) {
+	rows, err := s.q.ListModelSpeedSamples(ctx, s.now().Add(-MaxAge).Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	grouped := make(map[string][]db.ModelSpeedSample)
+	for _, row := range rows {
+		key := Key(row.Provider, row.Model)
+		// Rows come newest first, keep the window of each model.
+		if len(grouped[key]) < WindowSize {
+			grouped[key] = append(grouped[key], row)
+		}
+	}
+
+	stats := make(map[string]Stats, len(grouped))
+	for key, samples := range grouped {
+		stats[key] = compute(samples)
+	}
+	return stats, nil
+}
+
+func (s *service) Get(ctx context.Context, provider, model string) (Stats, bool, error) {
+	all, err := s.All(ctx)
+	if err != nil {
+		return Stats{}, false, err
+	}
+	stats, ok := all[Key(provider, model)]
+	return stats, ok, nil
+}
+
+func compute(rows []db.ModelSpeedSample) Stats {
+	stats := Stats{
+		Provider: rows[0].Provider,
+		Model:    rows[0].Model,
+		Samples:  len(rows),
+	}
+	ttfts := make([]float64, 0, len(rows))
+	speeds := make([]float64, 0, len(rows))
+	for _, row := range rows {
+		sample := Sample{
+			TTFT:         time.Duration(row.TtftMs) * time.Millisecond,
+			Duration:     time.Duration(row.DurationMs) * time.Millisecond,
+			OutputTokens: row.OutputTokens,
+		}
+		ttfts = append(ttfts, float64(sample.TTFT))
+		if tps := sample.TokensPerSecond(); tps > 0 {
+			speeds = append(speeds, tps)
+		}
+		if created := time.Unix(row.CreatedAt, 0); created.After(stats.LastMeasured) {
+			stats.LastMeasured = created
+		}
+	}
+	stats.TTFT = time.Duration(median(ttfts))
+	stats.TokensPerSecond = median(speeds)
+	return stats
+}
+
+// median is used rather than the mean so a single stalled request does not
+// skew the stats of a model.
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,113 @@
+package modelstats
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/nexora/nexora/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/ncruces/go-sqlite3/embed"
+)
+
+const schema = `
+CREATE TABLE model_speed_samples (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    provider TEXT NOT NULL,
+    model TEXT NOT NULL,
+    ttft_ms INTEGER NOT NULL DEFAULT 0,
+    output_tokens INTEGER NOT NULL DEFAULT 0,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    created_at INTEGER NOT NULL
+);`
+
+func newTestService(t *testing.T) *service {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	_, err = conn.Exec(schema)
+	require.NoError(t, err)
+	return &service{q: db.New(conn), now: time.Now}
+}
+
+func TestSampleTokensPerSecond(t *testing.T) {
+	s := Sample{TTFT: 500 * time.Millisecond, Duration: 2500 * time.Millisecond, OutputTokens: 100}
+	assert.InDelta(t, 50, s.TokensPerSecond(), 1e-9)
+
+	assert.Zero(t, Sample{TTFT: time.Second, Duration: time.Second, OutputTokens: 10}.TokensPerSecond())
+	assert.Zero(t, Sample{Duration: time.Second}.TokensPerSecond())
+}
+
+func TestServiceStats(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	for _, s := range []Sample{
+		{TTFT: 200 * time.Millisecond, Duration: 1200 * time.Millisecond, OutputTokens: 100},
+		{TTFT: 300 * time.Millisecond, Duration: 2300 * time.Millisecond, OutputTokens: 100},
+		// A stalled request does not move the median.
+		{TTFT: 30 * time.Second, Duration: 40 * time.Second, OutputTokens: 100},
+	} {
+		s.Provider, s.Model = "local", "qwen"
+		require.NoError(t, svc.Record(ctx, s))
+	}
+	require.NoError(t, svc.Record(ctx, Sample{Provider: "openai", Model: "gpt-4o", TTFT: time.Second, Duration: 3 * time.Second, OutputTokens: 100}))
+	// Invalid samples are dropped.
+	require.NoError(t, svc.Record(ctx, Sample{Provider: "openai", Model: "gpt-4o", TTFT: 2 * time.Second, Duration: time.Second}))
+
+	all, err := svc.All(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+
+	local := all[Key("local", "qwen")]
+	assert.Equal(t, 3, local.Samples)
+	assert.True(t, local.Reliable())
+	assert.Equal(t, 300*time.Millisecond, local.TTFT)
+	assert.InDelta(t, 50, local.TokensPerSecond, 1e-9)
+	assert.Equal(t, 1300*time.Millisecond, local.Latency(50))
+
+	gpt, ok, err := svc.Get(ctx, "openai", "gpt-4o")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, 1, gpt.Samples)
+	assert.False(t, gpt.Reliable())
+
+	_, ok, err = svc.Get(ctx, "openai", "missing")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestServiceWindow(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	// Old fast samples fall out of the window.
+	for range WindowSize {
+		require.NoError(t, svc.Record(ctx, Sample{Provider: "p", Model: "m", Duration: time.Second, OutputTokens: 1000}))
+	}
+	for range WindowSize {
+		require.NoError(t, svc.Record(ctx, Sample{Provider: "p", Model: "m", Duration: time.Second, OutputTokens: 10}))
+	}
+
+	stats, ok, err := svc.Get(ctx, "p", "m")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, WindowSize, stats.Samples)
+	assert.InDelta(t, 10, stats.TokensPerSecond, 1e-9)
+}
+
+func TestServiceMaxAge(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	require.NoError(t, svc.Record(ctx, Sample{Provider: "p", Model: "m", Duration: time.Second}))
+	svc.now = func() time.Time { return time.Now().Add(MaxAge + time.Hour) }
+
+	all, err := svc.All(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
@@ -0,0 +1,96 @@
+// Package replay records the provider traffic and tool results of a session
+// into a cassette and replays it against the current build to detect
+// behavior changes offline.
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nexora/nexora/internal/message"
+)
+
+const (
+	// sessionFile holds the prompt, model and tool calls of the recording.
+	sessionFile = "session.json"
+	// httpCassette is the go-vcr cassette name; go-vcr appends ".yaml".
+	httpCassette = "http"
+)
+
+// ErrNoCassette is returned when a directory does not contain a recording.
+var ErrNoCassette = errors.New("no cassette found")
+
+// ToolCall is a tool invocation and the result it produced.
+type ToolCall struct {
+	Name    string `json:"name"`
+	Input   string `json:"input"`
+	Output  string `json:"output"`
+	IsError bool   `json:"is_error,omitempty"`
+}
+
+// Session describes a recorded run.
+type Session struct {
+	Version    string     `json:"version"`
+	Prompt     string     `json:"prompt"`
+	Provider   string     `json:"provider"`
+	Model      string     `json:"model"`
+	GitHead    string     `json:"git_head,omitempty"`
+	RecordedAt time.Time  `json:"recorded_at"`
+	ToolCalls  []ToolCall `json:"tool_calls"`
+}
+
+// Save writes the session description into the cassette directory.
+func Save(dir string, s Session) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, sessionFile), append(data, '\n'), 0o644)
+}
+
+// Load reads the session description from the cassette directory.
+func Load(dir string) (Session, error) {
+	data, err := os.ReadFile(filepath.Join(dir, sessionFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return Session{}, fmt.Errorf("%w in %s", ErrNoCassette, dir)
+	}
+	if err != nil {
+		return Session{}, err
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Session{}, fmt.Errorf("invalid cassette %s: %w", dir, err)
+	}
+	return s, nil
+}
+
+// ToolCallsFromMessages returns the tool calls of a session in the order
+// they were made, paired with their results.
+func ToolCallsFromMessages(msgs []message.Message) []ToolCall {
+	results := make(map[string]message.ToolResult)
+	for _, msg := range msgs {
+		for _, r := range msg.ToolResults() {
+			results[r.ToolCallID] = r
+		}
+	}
+
+	var calls []ToolCall
+	for _, msg := range msgs {
+		for _, c := range msg.ToolCalls() {
+			call := ToolCall{Name: c.Name, Input: c.Input}
+			if r, ok := results[c.ID]; ok {
+				call.Output = r.Content
+				call.IsError = r.IsError
+			}
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
@@ -0,0 +1,103 @@
+package replay
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DiffKind classifies a difference between two tool-call sequences.
+type DiffKind string
+
+const (
+	DiffChanged DiffKind = "changed"
+	DiffMissing DiffKind = "missing"
+	DiffExtra   DiffKind = "extra"
+)
+
+// Difference is a single position where the replayed tool calls diverge
+// from the recorded ones.
+type Difference struct {
+	Index    int       `json:"index"`
+	Kind     DiffKind  `json:"kind"`
+	Recorded *ToolCall `json:"recorded,omitempty"`
+	Replayed *ToolCall `json:"replayed,omitempty"`
+	Fields   []string  `json:"fields,omitempty"`
+}
+
+// DiffOptions controls which parts of a tool call are compared.
+type DiffOptions struct {
+	// CompareOutput also compares tool output text. Outputs often contain
+	// timestamps or paths, so only the error status is compared by default.
+	CompareOutput bool
+}
+
+// DiffToolCalls compares recorded and replayed tool calls position by
+// position.
+func DiffToolCalls(recorded, replayed []ToolCall, opts DiffOptions) []Difference {
+	var diffs []Difference
+	for i := range max(len(recorded), len(replayed)) {
+		switch {
+		case i >= len(replayed):
+			diffs = append(diffs, Difference{Index: i, Kind: DiffMissing, Recorded: &recorded[i]})
+		case i >= len(recorded):
+			diffs = append(diffs, Difference{Index: i, Kind: DiffExtra, Replayed: &replayed[i]})
+		default:
+			if fields := changedFields(recorded[i], replayed[i], opts); len(fields) > 0 {
+				diffs = append(diffs, Difference{
+					Index:    i,
+					Kind:     DiffChanged,
+					Recorded: &recorded[i],
+					Replayed: &replayed[i],
+					Fields:   fields,
+				})
+			}
+		}
+	}
+	return diffs
+}
+
+func changedFields(a, b ToolCall, opts DiffOptions) []string {
+	var fields []string
+	if a.Name != b.Name {
+		fields = append(fields, "name")
+	}
+	if !jsonEqual([]byte(a.Input), []byte(b.Input)) {
+		fields = append(fields, "input")
+	}
+	if a.IsError != b.IsError {
+		fields = append(fields, "is_error")
+	}
+	if opts.CompareOutput && a.Output != b.Output {
+		fields = append(fields, "output")
+	}
+	return fields
+}
+
+// WriteDiff writes a human readable report of diffs.
+func WriteDiff(w io.Writer, diffs []Difference) {
+	for _, d := range diffs {
+		switch d.Kind {
+		case DiffMissing:
+			fmt.Fprintf(w, "#%d missing: %s\n", d.Index+1, describeCall(d.Recorded))
+		case DiffExtra:
+			fmt.Fprintf(w, "#%d extra:   %s\n", d.Index+1, describeCall(d.Replayed))
+		case DiffChanged:
+			fmt.Fprintf(w, "#%d changed (%s):\n", d.Index+1, strings.Join(d.Fields, ", "))
+			fmt.Fprintf(w, "  - %s\n", describeCall(d.Recorded))
+			fmt.Fprintf(w, "  + %s\n", describeCall(d.Replayed))
+		}
+	}
+}
+
+func describeCall(c *ToolCall) string {
+	const maxLen = 120
+	s := fmt.Sprintf("%s %s", c.Name, c.Input)
+	if c.IsError {
+		s += " [error]"
+	}
+	if len(s) > maxLen {
+		s = s[:maxLen] + "…"
+	}
+	return s
+}
@@ -0,0 +1,180 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/dnaeon/go-vcr.v4/pkg/cassette"
+)
+
+// ErrRequestNotRecorded is returned when a replayed run makes a provider
+// request that has no recorded response left.
+var ErrRequestNotRecorded = errors.New("no recorded response for request")
+
+// RequestDrift describes a replayed request whose body differs from the
+// recorded one, for example because the system prompt or a tool
+// description changed.
+type RequestDrift struct {
+	Index  int      `json:"index"`
+	Method string   `json:"method"`
+	URL    string   `json:"url"`
+	Fields []string `json:"fields"`
+}
+
+// Player is an http.RoundTripper that answers provider requests from a
+// cassette instead of the network.
+//
+// Requests are matched to unused interactions with the same method and URL.
+// An interaction with an identical JSON body is preferred; otherwise the
+// oldest unused one is returned and the difference is reported as drift, so
+// a changed prompt still replays the recorded responses.
+type Player struct {
+	mu           sync.Mutex
+	interactions []*cassette.Interaction
+	used         []bool
+	requests     int
+	drift        []RequestDrift
+}
+
+// NewPlayer loads the cassette in dir.
+func NewPlayer(dir string) (*Player, error) {
+	c, err := cassette.Load(filepath.Join(dir, httpCassette))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w in %s", ErrNoCassette, dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Player{
+		interactions: c.Interactions,
+		used:         make([]bool, len(c.Interactions)),
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (p *Player) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+	}
+	reqURL := scrubURL(req.URL.String())
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	index := p.requests
+	p.requests++
+
+	match := -1
+	for i, interaction := range p.interactions {
+		if p.used[i] || interaction.Request.Method != req.Method || interaction.Request.URL != reqURL {
+			continue
+		}
+		if match < 0 {
+			match = i
+		}
+		if jsonEqual(body, []byte(interaction.Request.Body)) {
+			match = i
+			break
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrRequestNotRecorded, req.Method, reqURL)
+	}
+	p.used[match] = true
+
+	interaction := p.interactions[match]
+	if fields := differingFields([]byte(interaction.Request.Body), body); len(fields) > 0 {
+		p.drift = append(p.drift, RequestDrift{
+			Index:  index,
+			Method: req.Method,
+			URL:    reqURL,
+			Fields: fields,
+		})
+	}
+
+	recorded := interaction.Response
+	return &http.Response{
+		Status:        recorded.Status,
+		StatusCode:    recorded.Code,
+		Proto:         recorded.Proto,
+		ProtoMajor:    recorded.ProtoMajor,
+		ProtoMinor:    recorded.ProtoMinor,
+		Header:        recorded.Headers.Clone(),
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Drift returns the replayed requests whose bodies differed from the
+// recording.
+func (p *Player) Drift() []RequestDrift {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.drift)
+}
+
+// Unused returns how many recorded interactions were never requested.
+func (p *Player) Unused() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var n int
+	for _, used := range p.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
+
+func jsonEqual(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// differingFields returns the top-level JSON fields that differ between the
+// recorded and replayed request bodies.
+func differingFields(recorded, replayed []byte) []string {
+	if jsonEqual(recorded, replayed) {
+		return nil
+	}
+	var a, b map[string]any
+	if json.Unmarshal(recorded, &a) != nil || json.Unmarshal(replayed, &b) != nil {
+		return []string{"body"}
+	}
+	var fields []string
+	for k, v := range a {
+		if !reflect.DeepEqual(v, b[k]) {
+			fields = append(fields, k)
+		}
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			fields = append(fields, k)
+		}
+	}
+	slices.Sort(fields)
+	return fields
+}
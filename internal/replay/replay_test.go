@@ -0,0 +1,143 @@
+package replay
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nexora/nexora/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "secret-id")
+		_, _ = w.Write([]byte(`{"echo":` + string(body) + `}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	rec, err := NewRecorder(dir, http.DefaultTransport)
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: rec}
+	post := func(client *http.Client, body string) string {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/v1/messages?key=sk-secret", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer sk-secret")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		out, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(out)
+	}
+	require.Equal(t, `{"echo":{"n":1}}`, post(client, `{"n":1}`))
+	require.Equal(t, `{"echo":{"n":2}}`, post(client, `{"n":2}`))
+	require.NoError(t, rec.Stop())
+
+	player, err := NewPlayer(dir)
+	require.NoError(t, err)
+	client = &http.Client{Transport: player}
+
+	// An identical body is preferred over the oldest interaction.
+	require.Equal(t, `{"echo":{"n":2}}`, post(client, `{"n": 2}`))
+	// A changed body still replays, but is reported as drift.
+	require.Equal(t, `{"echo":{"n":1}}`, post(client, `{"n":3,"extra":true}`))
+	require.Equal(t, []RequestDrift{{
+		Index:  1,
+		Method: http.MethodPost,
+		URL:    server.URL + "/v1/messages",
+		Fields: []string{"extra", "n"},
+	}}, player.Drift())
+	require.Zero(t, player.Unused())
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/v1/messages", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	require.ErrorIs(t, err, ErrRequestNotRecorded)
+}
+
+func TestDiffToolCalls(t *testing.T) {
+	t.Parallel()
+
+	recorded := []ToolCall{
+		{Name: "view", Input: `{"file_path":"a.go"}`, Output: "one"},
+		{Name: "edit", Input: `{"file_path":"a.go"}`, Output: "ok"},
+		{Name: "bash", Input: `{"command":"go test"}`},
+	}
+	replayed := []ToolCall{
+		{Name: "view", Input: `{ "file_path": "a.go" }`, Output: "two"},
+		{Name: "edit", Input: `{"file_path":"a.go"}`, IsError: true},
+	}
+
+	diffs := DiffToolCalls(recorded, replayed, DiffOptions{})
+	require.Len(t, diffs, 2)
+	require.Equal(t, DiffChanged, diffs[0].Kind)
+	require.Equal(t, []string{"is_error"}, diffs[0].Fields)
+	require.Equal(t, DiffMissing, diffs[1].Kind)
+	require.Equal(t, 2, diffs[1].Index)
+
+	diffs = DiffToolCalls(recorded[:1], replayed[:1], DiffOptions{CompareOutput: true})
+	require.Len(t, diffs, 1)
+	require.Equal(t, []string{"output"}, diffs[0].Fields)
+
+	var b strings.Builder
+	WriteDiff(&b, DiffToolCalls(nil, replayed[:1], DiffOptions{}))
+	require.Contains(t, b.String(), "#1 extra:")
+}
+
+func TestToolCallsFromMessages(t *testing.T) {
+	t.Parallel()
+
+	msgs := []message.Message{
+		{Role: message.Assistant, Parts: []message.ContentPart{
+			message.ToolCall{ID: "1", Name: "view", Input: `{}`},
+			message.ToolCall{ID: "2", Name: "grep", Input: `{}`},
+		}},
+		{Role: message.Tool, Parts: []message.ContentPart{
+			message.ToolResult{ToolCallID: "2", Content: "no matches", IsError: true},
+			message.ToolResult{ToolCallID: "1", Content: "package main"},
+		}},
+	}
+	require.Equal(t, []ToolCall{
+		{Name: "view", Input: `{}`, Output: "package main"},
+		{Name: "grep", Input: `{}`, Output: "no matches", IsError: true},
+	}, ToolCallsFromMessages(msgs))
+}
+
+func TestToolResults(t *testing.T) {
+	t.Parallel()
+
+	results := NewToolResults([]ToolCall{
+		{Name: "view", Input: `{"file_path":"a.go"}`, Output: "first"},
+		{Name: "bash", Input: `{"command":"go test"}`, Output: "FAIL", IsError: true},
+		{Name: "view", Input: `{"file_path":"a.go"}`, Output: "second"},
+	})
+
+	output, isError, ok := results.Result(t.Context(), "view", `{"file_path": "a.go"}`)
+	require.True(t, ok)
+	require.False(t, isError)
+	require.Equal(t, "first", output)
+
+	output, isError, ok = results.Result(t.Context(), "bash", `{"command":"go test"}`)
+	require.True(t, ok)
+	require.True(t, isError)
+	require.Equal(t, "FAIL", output)
+
+	output, _, ok = results.Result(t.Context(), "view", `{"file_path":"a.go"}`)
+	require.True(t, ok)
+	require.Equal(t, "second", output)
+
+	_, _, ok = results.Result(t.Context(), "view", `{"file_path":"a.go"}`)
+	require.False(t, ok)
+	_, _, ok = results.Result(t.Context(), "edit", `{"file_path":"a.go"}`)
+	require.False(t, ok)
+	require.Equal(t, 2, results.Missed())
+}
@@ -0,0 +1,50 @@
+package replay
+
+import (
+	"context"
+	"sync"
+)
+
+// ToolResults answers tool calls with the results of a recording, so that a
+// replay does not run them against the working tree.
+//
+// A call is answered with the oldest unused recorded call with the same name
+// and input. Calls that were not recorded are left unanswered.
+type ToolResults struct {
+	mu     sync.Mutex
+	calls  []ToolCall
+	used   []bool
+	missed int
+}
+
+// NewToolResults serves the results of the recorded calls.
+func NewToolResults(calls []ToolCall) *ToolResults {
+	return &ToolResults{
+		calls: calls,
+		used:  make([]bool, len(calls)),
+	}
+}
+
+// Result returns the recorded result of a call, and false if the call was
+// not recorded or its results were all served already. It implements
+// config.ToolResultSource.
+func (r *ToolResults) Result(_ context.Context, name, input string) (string, bool, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, call := range r.calls {
+		if r.used[i] || call.Name != name || !jsonEqual([]byte(call.Input), []byte(input)) {
+			continue
+		}
+		r.used[i] = true
+		return call.Output, call.IsError, true
+	}
+	r.missed++
+	return "", false, false
+}
+
+// Missed returns how many calls had no recorded result.
+func (r *ToolResults) Missed() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.missed
+}
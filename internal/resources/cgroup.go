@@ -0,0 +1,194 @@
+package resources
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cgroup reads the limits and usage of the cgroup v2 nexora runs in. Limits
+// can be set on any ancestor of that cgroup, so each resource is read from
+// the closest cgroup that limits it.
+type cgroup struct {
+	// mount is where the cgroup v2 hierarchy is mounted, e.g. /sys/fs/cgroup.
+	mount string
+	// dir is the path of nexora's cgroup in the hierarchy, e.g. /user.slice.
+	dir string
+
+	// Previous CPU usage sample, to compute utilization.
+	lastUsage time.Duration
+	lastTime  time.Time
+}
+
+// cgroupLimits is what a cgroup limits, and how much of it is used.
+type cgroupLimits struct {
+	MemUsed  uint64
+	MemLimit uint64 // 0 when memory isn't limited
+
+	CPUPercent float64 // Of the CPU limit
+	CPULimit   float64 // In CPUs; 0 when CPU isn't limited
+
+	PidsCurrent uint64
+	PidsMax     uint64 // 0 when the number of processes isn't limited
+}
+
+// detectCgroup returns nexora's cgroup when the host uses cgroup v2.
+func detectCgroup() *cgroup {
+	return findCgroup("/proc/self/cgroup", "/sys/fs/cgroup")
+}
+
+// findCgroup reads the cgroup of the process from procCgroup, a
+// /proc/<pid>/cgroup file, in the hierarchy mounted at mount.
+func findCgroup(procCgroup, mount string) *cgroup {
+	if _, err := os.Stat(filepath.Join(mount, "cgroup.controllers")); err != nil {
+		return nil // Not cgroup v2
+	}
+	data, err := os.ReadFile(procCgroup)
+	if err != nil {
+		return nil
+	}
+	for line := range strings.SplitSeq(string(data), "\n") {
+		// The v2 hierarchy is the line "0::<path>".
+		if dir, ok := strings.CutPrefix(line, "0::"); ok {
+			dir = path.Clean("/" + strings.TrimSpace(dir))
+			// Inside a container with a private cgroup namespace the
+			// path can point outside the mount; the mount is then the
+			// container's own cgroup.
+			if _, err := os.Stat(filepath.Join(mount, dir)); err != nil {
+				dir = "/"
+			}
+			return &cgroup{mount: mount, dir: dir}
+		}
+	}
+	return nil
+}
+
+// ancestors returns the directories of the cgroup and its ancestors, closest
+// first.
+func (c *cgroup) ancestors() []string {
+	var dirs []string
+	for dir := c.dir; ; dir = path.Dir(dir) {
+		dirs = append(dirs, filepath.Join(c.mount, dir))
+		if dir == "/" {
+			return dirs
+		}
+	}
+}
+
+// limited returns the directory of the closest cgroup where file holds a
+// limit rather than "max", along with the limit's fields.
+func (c *cgroup) limited(file string) (string, []string, bool) {
+	for _, dir := range c.ancestors() {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			continue
+		}
+		fields := strings.Fields(string(data))
+		if len(fields) > 0 && fields[0] != "max" {
+			return dir, fields, true
+		}
+	}
+	return "", nil, false
+}
+
+// read returns the limits of the cgroup at now.
+func (c *cgroup) read(now time.Time) cgroupLimits {
+	var l cgroupLimits
+	c.readMemory(&l)
+	c.readCPU(&l, now)
+	c.readPids(&l)
+	return l
+}
+
+func (c *cgroup) readMemory(l *cgroupLimits) {
+	dir, fields, ok := c.limited("memory.max")
+	if !ok {
+		return
+	}
+	limit, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return
+	}
+	used, ok := readUint(filepath.Join(dir, "memory.current"))
+	if !ok {
+		return
+	}
+	// Like the kernel's OOM killer and kubelet, don't count page cache
+	// that can be reclaimed.
+	if inactive, ok := readStat(filepath.Join(dir, "memory.stat"), "inactive_file"); ok && inactive < used {
+		used -= inactive
+	}
+	l.MemUsed, l.MemLimit = used, limit
+}
+
+func (c *cgroup) readCPU(l *cgroupLimits, now time.Time) {
+	dir, fields, ok := c.limited("cpu.max")
+	if !ok || len(fields) < 2 {
+		return
+	}
+	quota, err1 := strconv.ParseFloat(fields[0], 64)
+	period, err2 := strconv.ParseFloat(fields[1], 64)
+	if err1 != nil || err2 != nil || period <= 0 {
+		return
+	}
+	l.CPULimit = quota / period
+
+	usec, ok := readStat(filepath.Join(dir, "cpu.stat"), "usage_usec")
+	if !ok {
+		return
+	}
+	usage := time.Duration(usec) * time.Microsecond
+	if !c.lastTime.IsZero() && now.After(c.lastTime) {
+		used := (usage - c.lastUsage).Seconds()
+		available := now.Sub(c.lastTime).Seconds() * l.CPULimit
+		l.CPUPercent = min(100*used/available, 100)
+	}
+	c.lastUsage, c.lastTime = usage, now
+}
+
+func (c *cgroup) readPids(l *cgroupLimits) {
+	dir, fields, ok := c.limited("pids.max")
+	if !ok {
+		return
+	}
+	limit, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return
+	}
+	current, ok := readUint(filepath.Join(dir, "pids.current"))
+	if !ok {
+		return
+	}
+	l.PidsCurrent, l.PidsMax = current, limit
+}
+
+func readUint(file string) (uint64, bool) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	return v, err == nil
+}
+
+// readStat returns the value of key in a flat keyed file like memory.stat.
+func readStat(file, key string) (uint64, bool) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		k, v, ok := strings.Cut(scanner.Text(), " ")
+		if ok && k == key {
+			n, err := strconv.ParseUint(v, 10, 64)
+			return n, err == nil
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,139 @@
+package resources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeCgroup writes the files of a fake cgroup v2 hierarchy under mount.
+func writeCgroup(t *testing.T, mount string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(mount, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestFindCgroup(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	mount := filepath.Join(dir, "cgroup")
+	proc := filepath.Join(dir, "proc_cgroup")
+
+	t.Run("not cgroup v2", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(proc, []byte("0::/user.slice\n"), 0o644))
+		require.Nil(t, findCgroup(proc, mount))
+	})
+
+	writeCgroup(t, mount, map[string]string{
+		"cgroup.controllers":                 "cpu memory pids\n",
+		"user.slice/nexora.scope/memory.max": "max\n",
+	})
+
+	t.Run("own cgroup", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(proc, []byte("0::/user.slice/nexora.scope\n"), 0o644))
+		c := findCgroup(proc, mount)
+		require.NotNil(t, c)
+		require.Equal(t, "/user.slice/nexora.scope", c.dir)
+		require.Equal(t, []string{
+			filepath.Join(mount, "user.slice/nexora.scope"),
+			filepath.Join(mount, "user.slice"),
+			mount,
+		}, c.ancestors())
+	})
+
+	t.Run("private namespace", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(proc, []byte("0::/../../kubepods/pod1\n"), 0o644))
+		c := findCgroup(proc, mount)
+		require.NotNil(t, c)
+		require.Equal(t, "/", c.dir)
+	})
+}
+
+func TestCgroupRead(t *testing.T) {
+	t.Parallel()
+
+	mount := t.TempDir()
+	writeCgroup(t, mount, map[string]string{
+		// The limits are set on the parent, as systemd and Kubernetes do.
+		"pod/memory.max":     "1073741824\n",
+		"pod/memory.current": "536870912\n",
+		"pod/memory.stat":    "anon 100\nfile 300\ninactive_file 268435456\nactive_file 5\n",
+		"pod/cpu.max":        "200000 100000\n",
+		"pod/cpu.stat":       "usage_usec 1000000\nuser_usec 800000\n",
+		"pod/pids.max":       "100\n",
+		"pod/pids.current":   "42\n",
+		"pod/app/memory.max": "max\n",
+		"pod/app/cpu.max":    "max 100000\n",
+		"pod/app/pids.max":   "max\n",
+	})
+	c := &cgroup{mount: mount, dir: "/pod/app"}
+
+	start := time.Now()
+	limits := c.read(start)
+	require.Equal(t, uint64(1<<30), limits.MemLimit)
+	require.Equal(t, uint64(256<<20), limits.MemUsed, "inactive page cache isn't used memory")
+	require.Equal(t, 2.0, limits.CPULimit)
+	require.Zero(t, limits.CPUPercent, "the first read has no usage to compare to")
+	require.Equal(t, uint64(100), limits.PidsMax)
+	require.Equal(t, uint64(42), limits.PidsCurrent)
+
+	// One CPU second over one second is half of two CPUs.
+	writeCgroup(t, mount, map[string]string{"pod/cpu.stat": "usage_usec 2000000\n"})
+	limits = c.read(start.Add(time.Second))
+	require.InDelta(t, 50.0, limits.CPUPercent, 0.001)
+}
+
+func TestCgroupReadUnlimited(t *testing.T) {
+	t.Parallel()
+
+	mount := t.TempDir()
+	writeCgroup(t, mount, map[string]string{
+		"memory.max": "max\n",
+		"cpu.max":    "max 100000\n",
+		"pids.max":   "max\n",
+	})
+	c := &cgroup{mount: mount, dir: "/"}
+
+	require.Equal(t, cgroupLimits{}, c.read(time.Now()))
+}
+
+func TestApplyCgroup(t *testing.T) {
+	t.Parallel()
+
+	host := ResourceSnapshot{
+		CPUPercent: 10,
+		CPULimit:   16,
+		MemUsed:    8 << 30,
+		MemTotal:   64 << 30,
+		MemPercent: 12.5,
+	}
+
+	snapshot := host
+	applyCgroup(&snapshot, cgroupLimits{
+		MemUsed:     768 << 20,
+		MemLimit:    1 << 30,
+		CPUPercent:  90,
+		CPULimit:    2,
+		PidsCurrent: 10,
+		PidsMax:     50,
+	})
+	require.True(t, snapshot.Cgroup)
+	require.Equal(t, 75.0, snapshot.MemPercent)
+	require.Equal(t, uint64(1<<30), snapshot.MemTotal)
+	require.Equal(t, 90.0, snapshot.CPUPercent)
+	require.Equal(t, 2.0, snapshot.CPULimit)
+	require.Equal(t, uint64(50), snapshot.PidsMax)
+
+	// Limits above what the host has don't apply.
+	snapshot = host
+	applyCgroup(&snapshot, cgroupLimits{MemUsed: 1 << 30, MemLimit: 128 << 30, CPULimit: 32})
+	require.False(t, snapshot.Cgroup)
+	require.Equal(t, host, snapshot)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"runtime"
 	"sync"
 	"time"
 
@@ -35,6 +36,11 @@ type Monitor struct {
 
 	// State machine integration (optional)
 	stateMachine *state.StateMachine
+
+	// Sources
+	cgroup   *cgroup // nil when not in a cgroup v2
+	tree     *processTree
+	trackers []func() []int32 // Extra process trees to account, e.g. tmux panes
 }
 
 // Config defines resource monitoring configuration.
@@ -43,6 +49,9 @@ type Config struct {
 	CPUThreshold  float64       // CPU usage percentage (0-100), default 80
 	MemThreshold  float64       // Memory usage percentage (0-100), default 85
 	DiskMinFree   uint64        // Minimum free disk space in bytes, default 5GB
+	FDThreshold   float64       // Open file descriptors percentage of the limit (0-100), default 90
+	MaxProcesses  int           // Processes in nexora's process tree, default 512
+	PidsThreshold float64       // Processes percentage of the cgroup's pids.max (0-100), default 90
 	CheckInterval time.Duration // Check interval, default 5s
 
 	// Actions
@@ -56,6 +65,9 @@ func DefaultConfig() Config {
 		CPUThreshold:    80.0,
 		MemThreshold:    85.0,
 		DiskMinFree:     5 * 1024 * 1024 * 1024, // 5GB
+		FDThreshold:     90.0,
+		MaxProcesses:    512,
+		PidsThreshold:   90.0,
 		CheckInterval:   5 * time.Second,
 		EnableAutoPause: true,
 		MaxViolations:   3,
@@ -76,6 +88,15 @@ func NewMonitor(config Config) *Monitor {
 	if config.DiskMinFree == 0 {
 		config.DiskMinFree = 5 * 1024 * 1024 * 1024
 	}
+	if config.FDThreshold == 0 {
+		config.FDThreshold = 90.0
+	}
+	if config.MaxProcesses == 0 {
+		config.MaxProcesses = 512
+	}
+	if config.PidsThreshold == 0 {
+		config.PidsThreshold = 90.0
+	}
 	if config.MaxViolations == 0 {
 		config.MaxViolations = 3
 	}
@@ -83,6 +104,8 @@ func NewMonitor(config Config) *Monitor {
 	return &Monitor{
 		config:     config,
 		violations: make([]Violation, 0),
+		cgroup:     detectCgroup(),
+		tree:       newProcessTree(),
 	}
 }
 
@@ -109,6 +132,16 @@ func (m *Monitor) SetCallbacks(
 	m.onViolation = onViolation
 }
 
+// TrackProcesses adds the processes returned by pids, and their children, to
+// the process tree the monitor accounts. Nexora's own children are always
+// accounted; this is for processes started by someone else on its behalf,
+// like the shells of tmux panes.
+func (m *Monitor) TrackProcesses(pids func() []int32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.trackers = append(m.trackers, pids)
+}
+
 // Start begins resource monitoring.
 func (m *Monitor) Start(ctx context.Context) error {
 	m.mu.Lock()
@@ -129,6 +162,7 @@ func (m *Monitor) Start(ctx context.Context) error {
 		"mem_threshold", m.config.MemThreshold,
 		"disk_min_free_gb", float64(m.config.DiskMinFree)/(1024*1024*1024),
 		"check_interval", m.config.CheckInterval,
+		"cgroup", m.cgroup != nil,
 	)
 
 	return nil
@@ -174,11 +208,19 @@ func (m *Monitor) check() {
 	m.lastSnapshot = snapshot
 	m.mu.Unlock()
 
-	// Check for violations
+	for _, v := range m.violationsOf(snapshot) {
+		m.handleViolation(v)
+	}
+}
+
+// violationsOf returns the thresholds snapshot exceeds.
+func (m *Monitor) violationsOf(snapshot ResourceSnapshot) []Violation {
+	var violations []Violation
+
 	if snapshot.CPUPercent > m.config.CPUThreshold {
-		m.handleViolation(Violation{
+		violations = append(violations, Violation{
 			Type:      ViolationTypeCPU,
-			Timestamp: time.Now(),
+			Timestamp: snapshot.Timestamp,
 			Value:     snapshot.CPUPercent,
 			Threshold: m.config.CPUThreshold,
 			Message:   fmt.Sprintf("CPU usage %.1f%% exceeds threshold %.1f%%", snapshot.CPUPercent, m.config.CPUThreshold),
@@ -186,30 +228,68 @@ func (m *Monitor) check() {
 	}
 
 	if snapshot.MemPercent > m.config.MemThreshold {
-		m.handleViolation(Violation{
+		violations = append(violations, Violation{
 			Type:      ViolationTypeMem,
-			Timestamp: time.Now(),
+			Timestamp: snapshot.Timestamp,
 			Value:     snapshot.MemPercent,
 			Threshold: m.config.MemThreshold,
 			Message:   fmt.Sprintf("Memory usage %.1f%% exceeds threshold %.1f%%", snapshot.MemPercent, m.config.MemThreshold),
 		})
 	}
 
-	if snapshot.DiskFree < m.config.DiskMinFree {
-		m.handleViolation(Violation{
+	if snapshot.DiskTotal > 0 && snapshot.DiskFree < m.config.DiskMinFree {
+		violations = append(violations, Violation{
 			Type:      ViolationTypeDisk,
-			Timestamp: time.Now(),
+			Timestamp: snapshot.Timestamp,
 			Value:     float64(snapshot.DiskFree),
 			Threshold: float64(m.config.DiskMinFree),
 			Message:   fmt.Sprintf("Disk free %.2fGB below threshold %.2fGB", float64(snapshot.DiskFree)/(1024*1024*1024), float64(m.config.DiskMinFree)/(1024*1024*1024)),
 		})
 	}
+
+	if procs := snapshot.Processes; procs.FDLimit > 0 {
+		percent := 100 * float64(procs.OpenFDs) / float64(procs.FDLimit)
+		if percent > m.config.FDThreshold {
+			violations = append(violations, Violation{
+				Type:      ViolationTypeFDs,
+				Timestamp: snapshot.Timestamp,
+				Value:     percent,
+				Threshold: m.config.FDThreshold,
+				Message:   fmt.Sprintf("Open file descriptors %d are %.1f%% of the limit %d", procs.OpenFDs, percent, procs.FDLimit),
+			})
+		}
+	}
+
+	if count := snapshot.Processes.Count; count > m.config.MaxProcesses {
+		violations = append(violations, Violation{
+			Type:      ViolationTypeProc,
+			Timestamp: snapshot.Timestamp,
+			Value:     float64(count),
+			Threshold: float64(m.config.MaxProcesses),
+			Message:   fmt.Sprintf("Process tree has %d processes, more than %d", count, m.config.MaxProcesses),
+		})
+	} else if snapshot.PidsMax > 0 {
+		percent := 100 * float64(snapshot.PidsCurrent) / float64(snapshot.PidsMax)
+		if percent > m.config.PidsThreshold {
+			violations = append(violations, Violation{
+				Type:      ViolationTypeProc,
+				Timestamp: snapshot.Timestamp,
+				Value:     percent,
+				Threshold: m.config.PidsThreshold,
+				Message:   fmt.Sprintf("Cgroup processes %d are %.1f%% of the limit %d", snapshot.PidsCurrent, percent, snapshot.PidsMax),
+			})
+		}
+	}
+
+	return violations
 }
 
-// collectSnapshot collects current resource usage.
+// collectSnapshot collects current resource usage. CPU and memory are those
+// of the cgroup when it is more limited than the host.
 func (m *Monitor) collectSnapshot() ResourceSnapshot {
 	snapshot := ResourceSnapshot{
 		Timestamp: time.Now(),
+		CPULimit:  float64(runtime.NumCPU()),
 	}
 
 	// CPU usage
@@ -224,6 +304,10 @@ func (m *Monitor) collectSnapshot() ResourceSnapshot {
 		snapshot.MemPercent = vmem.UsedPercent
 	}
 
+	if m.cgroup != nil {
+		applyCgroup(&snapshot, m.cgroup.read(snapshot.Timestamp))
+	}
+
 	// Disk usage (current directory)
 	if usage, err := disk.Usage("."); err == nil {
 		snapshot.DiskUsed = usage.Used
@@ -232,9 +316,36 @@ func (m *Monitor) collectSnapshot() ResourceSnapshot {
 		snapshot.DiskPercent = usage.UsedPercent
 	}
 
+	m.mu.RLock()
+	trackers := m.trackers
+	m.mu.RUnlock()
+	var roots []int32
+	for _, pids := range trackers {
+		roots = append(roots, pids()...)
+	}
+	snapshot.Processes = m.tree.collect(roots)
+
 	return snapshot
 }
 
+// applyCgroup replaces the host's CPU and memory in snapshot with the
+// cgroup's limits when these are lower.
+func applyCgroup(snapshot *ResourceSnapshot, limits cgroupLimits) {
+	if limits.MemLimit > 0 && (snapshot.MemTotal == 0 || limits.MemLimit < snapshot.MemTotal) {
+		snapshot.Cgroup = true
+		snapshot.MemUsed = limits.MemUsed
+		snapshot.MemTotal = limits.MemLimit
+		snapshot.MemPercent = 100 * float64(limits.MemUsed) / float64(limits.MemLimit)
+	}
+	if limits.CPULimit > 0 && limits.CPULimit < snapshot.CPULimit {
+		snapshot.Cgroup = true
+		snapshot.CPUPercent = limits.CPUPercent
+		snapshot.CPULimit = limits.CPULimit
+	}
+	snapshot.PidsCurrent = limits.PidsCurrent
+	snapshot.PidsMax = limits.PidsMax
+}
+
 // handleViolation processes a resource violation.
 func (m *Monitor) handleViolation(v Violation) {
 	m.mu.Lock()
@@ -282,7 +393,8 @@ func (m *Monitor) handleViolation(v Violation) {
 				"max", m.config.MaxViolations,
 			)
 			// Transition to resource paused state
-			_ = m.stateMachine.TransitionTo(state.StateResourcePaused)
+			_ = m.stateMachine.Pause(state.StateResourcePaused,
+				fmt.Sprintf("%d resource violations, last: %s", violationCount, v.Message))
 		}
 	}
 }
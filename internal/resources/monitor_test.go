@@ -2,6 +2,7 @@ package resources
 
 import (
 	"context"
+	"os"
 	"testing"
 	"time"
 
@@ -247,3 +248,50 @@ func TestMonitorIntegration(t *testing.T) {
 
 	m.Stop()
 }
+
+func TestMonitorProcessViolations(t *testing.T) {
+	t.Parallel()
+
+	m := NewMonitor(Config{FDThreshold: 80, MaxProcesses: 10, PidsThreshold: 90})
+	now := time.Now()
+	base := ResourceSnapshot{Timestamp: now, DiskTotal: 1 << 40, DiskFree: 1 << 40}
+
+	require.Empty(t, m.violationsOf(base))
+
+	snapshot := base
+	snapshot.Processes = ProcessUsage{Count: 3, OpenFDs: 900, FDLimit: 1024}
+	violations := m.violationsOf(snapshot)
+	require.Len(t, violations, 1)
+	require.Equal(t, ViolationTypeFDs, violations[0].Type)
+	require.InDelta(t, 87.9, violations[0].Value, 0.1)
+
+	snapshot = base
+	snapshot.Processes = ProcessUsage{Count: 11}
+	violations = m.violationsOf(snapshot)
+	require.Len(t, violations, 1)
+	require.Equal(t, ViolationTypeProc, violations[0].Type)
+	require.Equal(t, 11.0, violations[0].Value)
+
+	snapshot = base
+	snapshot.PidsCurrent, snapshot.PidsMax = 95, 100
+	violations = m.violationsOf(snapshot)
+	require.Len(t, violations, 1)
+	require.Equal(t, ViolationTypeProc, violations[0].Type)
+	require.Contains(t, violations[0].Message, "Cgroup processes 95")
+}
+
+func TestMonitorTrackProcesses(t *testing.T) {
+	t.Parallel()
+
+	m := NewMonitor(DefaultConfig())
+	called := false
+	m.TrackProcesses(func() []int32 {
+		called = true
+		return []int32{int32(os.Getpid())}
+	})
+
+	snapshot := m.collectSnapshot()
+	require.True(t, called)
+	require.GreaterOrEqual(t, snapshot.Processes.Count, 1)
+	require.Positive(t, snapshot.CPULimit)
+}
@@ -0,0 +1,111 @@
+package resources
+
+import (
+	"os"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// ProcessUsage is the resource usage of nexora's process tree: nexora, the
+// shells and tools it runs, and tracked processes like tmux panes.
+type ProcessUsage struct {
+	Count      int     // Processes in the tree
+	CPUPercent float64 // CPU used, in percent of one CPU
+	MemRSS     uint64  // Resident memory of all processes in bytes
+	OpenFDs    int     // File descriptors open in nexora's own process
+	FDLimit    uint64  // Soft limit of open file descriptors of nexora's own process
+}
+
+// processTree measures the processes descending from a set of roots. It
+// keeps the processes it has seen so that their CPU usage is measured
+// between two collections.
+type processTree struct {
+	procs map[int32]*process.Process
+}
+
+func newProcessTree() *processTree {
+	return &processTree{procs: make(map[int32]*process.Process)}
+}
+
+// collect measures nexora's process and the trees of the extra roots.
+func (t *processTree) collect(roots []int32) ProcessUsage {
+	self := int32(os.Getpid())
+	pids := descendants(append([]int32{self}, roots...), parents())
+
+	var usage ProcessUsage
+	seen := make(map[int32]*process.Process, len(pids))
+	for _, pid := range pids {
+		p, ok := t.procs[pid]
+		if !ok {
+			var err error
+			if p, err = process.NewProcess(pid); err != nil {
+				continue // Exited
+			}
+		}
+		seen[pid] = p
+		usage.Count++
+		if cpu, err := p.Percent(0); err == nil {
+			usage.CPUPercent += cpu
+		}
+		if mem, err := p.MemoryInfo(); err == nil {
+			usage.MemRSS += mem.RSS
+		}
+	}
+	t.procs = seen
+
+	if p, ok := seen[self]; ok {
+		if fds, err := p.NumFDs(); err == nil {
+			usage.OpenFDs = int(fds)
+		}
+		if limits, err := p.Rlimit(); err == nil {
+			for _, l := range limits {
+				if l.Resource == process.RLIMIT_NOFILE {
+					usage.FDLimit = l.Soft
+				}
+			}
+		}
+	}
+	return usage
+}
+
+// parents maps the running processes to their parent.
+func parents() map[int32]int32 {
+	pids, err := process.Pids()
+	if err != nil {
+		return nil
+	}
+	ppids := make(map[int32]int32, len(pids))
+	for _, pid := range pids {
+		p, err := process.NewProcess(pid)
+		if err != nil {
+			continue
+		}
+		if ppid, err := p.Ppid(); err == nil {
+			ppids[pid] = ppid
+		}
+	}
+	return ppids
+}
+
+// descendants returns roots and every process descending from them, given
+// the parent of each process. Each process is returned once.
+func descendants(roots []int32, parents map[int32]int32) []int32 {
+	children := make(map[int32][]int32, len(parents))
+	for pid, ppid := range parents {
+		children[ppid] = append(children[ppid], pid)
+	}
+	seen := make(map[int32]bool)
+	var result []int32
+	queue := roots
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		if pid <= 0 || seen[pid] {
+			continue
+		}
+		seen[pid] = true
+		result = append(result, pid)
+		queue = append(queue, children[pid]...)
+	}
+	return result
+}
@@ -0,0 +1,51 @@
+package resources
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescendants(t *testing.T) {
+	t.Parallel()
+
+	parents := map[int32]int32{
+		1:  0,
+		10: 1,
+		11: 10,
+		12: 10,
+		13: 12,
+		20: 1,
+		21: 20,
+	}
+
+	require.ElementsMatch(t, []int32{10, 11, 12, 13}, descendants([]int32{10}, parents))
+	require.ElementsMatch(t, []int32{12, 13, 20, 21}, descendants([]int32{12, 20, 13}, parents))
+	require.Equal(t, []int32{99}, descendants([]int32{99}, parents))
+	require.Empty(t, descendants([]int32{0}, parents))
+}
+
+func TestProcessTreeCollect(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("sleep unavailable: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	f, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+
+	usage := newProcessTree().collect(nil)
+	require.GreaterOrEqual(t, usage.Count, 2, "nexora and its sleep child")
+	require.Positive(t, usage.MemRSS)
+	require.Positive(t, usage.OpenFDs)
+	require.Positive(t, usage.FDLimit)
+}
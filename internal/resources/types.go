@@ -9,12 +9,17 @@ import (
 type ResourceSnapshot struct {
 	Timestamp time.Time
 
+	// Cgroup is set when CPU or memory are those of the cgroup v2 nexora
+	// runs in rather than the host's, e.g. inside a container.
+	Cgroup bool
+
 	// CPU
-	CPUPercent float64 // CPU usage percentage (0-100)
+	CPUPercent float64 // CPU usage percentage of CPULimit (0-100)
+	CPULimit   float64 // CPUs available to nexora
 
 	// Memory
 	MemUsed    uint64  // Memory used in bytes
-	MemTotal   uint64  // Total memory in bytes
+	MemTotal   uint64  // Total memory in bytes, or the cgroup limit
 	MemPercent float64 // Memory usage percentage (0-100)
 
 	// Disk
@@ -22,6 +27,19 @@ type ResourceSnapshot struct {
 	DiskTotal   uint64  // Total disk in bytes
 	DiskFree    uint64  // Free disk in bytes
 	DiskPercent float64 // Disk usage percentage (0-100)
+
+	// Processes
+	Processes   ProcessUsage // Nexora's own process tree
+	PidsCurrent uint64       // Processes in the cgroup
+	PidsMax     uint64       // Process limit of the cgroup; 0 when unlimited
+}
+
+// MemAvailable returns the memory left before MemTotal in bytes.
+func (s ResourceSnapshot) MemAvailable() uint64 {
+	if s.MemUsed >= s.MemTotal {
+		return 0
+	}
+	return s.MemTotal - s.MemUsed
 }
 
 // ViolationType represents the type of resource violation.
@@ -31,6 +49,8 @@ const (
 	ViolationTypeCPU  ViolationType = "cpu"
 	ViolationTypeMem  ViolationType = "memory"
 	ViolationTypeDisk ViolationType = "disk"
+	ViolationTypeFDs  ViolationType = "file_descriptors"
+	ViolationTypeProc ViolationType = "processes"
 )
 
 // Violation represents a resource threshold violation.
@@ -50,7 +70,7 @@ func (v Violation) String() string {
 
 // Summary returns formatted resource usage summary.
 func (s ResourceSnapshot) Summary() string {
-	return fmt.Sprintf("CPU: %.1f%% | Memory: %.1f%% (%.2fGB/%.2fGB) | Disk: %.1f%% (%.2fGB free)",
+	summary := fmt.Sprintf("CPU: %.1f%% | Memory: %.1f%% (%.2fGB/%.2fGB) | Disk: %.1f%% (%.2fGB free)",
 		s.CPUPercent,
 		s.MemPercent,
 		float64(s.MemUsed)/(1024*1024*1024),
@@ -58,4 +78,14 @@ func (s ResourceSnapshot) Summary() string {
 		s.DiskPercent,
 		float64(s.DiskFree)/(1024*1024*1024),
 	)
+	if s.CPULimit > 0 {
+		summary += fmt.Sprintf(" | CPUs: %.1f", s.CPULimit)
+	}
+	if s.Cgroup {
+		summary += " (cgroup)"
+	}
+	if s.Processes.Count > 0 {
+		summary += fmt.Sprintf(" | Processes: %d (%d fds)", s.Processes.Count, s.Processes.OpenFDs)
+	}
+	return summary
 }
@@ -0,0 +1,48 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nexora/nexora/internal/git"
+)
+
+// MaxPatchLength bounds how much of the patch is inlined into the prompt.
+// Larger diffs are truncated and the reviewer is told to read the rest with
+// git_diff.
+const MaxPatchLength = 60000
+
+// Prompt builds the user prompt asking the reviewer agent to review diff.
+func Prompt(base, head string, diff git.Diff) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Review the changes on %s against %s.\n\n", head, base)
+
+	b.WriteString("<files>\n")
+	for _, f := range diff.Files {
+		if f.Binary {
+			fmt.Fprintf(&b, "%s (binary)\n", f.Path)
+			continue
+		}
+		fmt.Fprintf(&b, "%s +%d -%d\n", f.Path, f.Additions, f.Deletions)
+	}
+	b.WriteString("</files>\n\n")
+
+	patch := diff.Patch
+	truncated := len(patch) > MaxPatchLength
+	if truncated {
+		patch = patch[:MaxPatchLength]
+		if i := strings.LastIndexByte(patch, '\n'); i > 0 {
+			patch = patch[:i+1]
+		}
+	}
+	b.WriteString("<diff>\n")
+	b.WriteString(patch)
+	if !strings.HasSuffix(patch, "\n") {
+		b.WriteByte('\n')
+	}
+	b.WriteString("</diff>\n")
+	if truncated {
+		fmt.Fprintf(&b, "\nThe diff was truncated. Use git_diff with base %q and paths to read the remaining files.\n", base)
+	}
+	return b.String()
+}
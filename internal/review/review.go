@@ -0,0 +1,199 @@
+// Package review turns a branch diff into a review prompt and parses the
+// structured findings the reviewer agent returns.
+package review
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// ErrNoFindings is returned when the reviewer response contains no findings
+// block.
+var ErrNoFindings = errors.New("review response contains no findings")
+
+// Severity ranks how important a finding is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// ParseSeverity normalises a severity reported by the model. Unknown values
+// are treated as informational.
+func ParseSeverity(s string) Severity {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "error", "critical", "high", "blocker":
+		return SeverityError
+	case "warning", "warn", "medium":
+		return SeverityWarning
+	default:
+		return SeverityInfo
+	}
+}
+
+// SeverityAtLeast reports whether s is as severe as threshold or worse.
+func SeverityAtLeast(s, threshold Severity) bool {
+	return s.rank() <= threshold.rank()
+}
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityError:
+		return 0
+	case SeverityWarning:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Finding is a single review comment anchored to a line range in the new
+// version of a file.
+type Finding struct {
+	File       string   `json:"file"`
+	StartLine  int      `json:"start_line"`
+	EndLine    int      `json:"end_line"`
+	Severity   Severity `json:"severity"`
+	Title      string   `json:"title"`
+	Suggestion string   `json:"suggestion,omitempty"`
+}
+
+// Report is the result of reviewing a branch against its base.
+type Report struct {
+	Base      string    `json:"base"`
+	MergeBase string    `json:"merge_base"`
+	Head      string    `json:"head"`
+	Summary   string    `json:"summary,omitempty"`
+	Findings  []Finding `json:"findings"`
+}
+
+// Count returns the number of findings with the given severity.
+func (r Report) Count(severity Severity) int {
+	var n int
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// FindingsFor returns the findings for a file.
+func (r Report) FindingsFor(path string) []Finding {
+	var findings []Finding
+	for _, f := range r.Findings {
+		if f.File == path {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, report Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// ParseResponse extracts the findings block from the reviewer's final
+// message. The reviewer is asked to answer with a fenced JSON object; a bare
+// JSON object is accepted as well.
+func ParseResponse(text string) (summary string, findings []Finding, err error) {
+	raw := extractJSON(text)
+	if raw == "" {
+		return "", nil, ErrNoFindings
+	}
+
+	var resp struct {
+		Summary  string `json:"summary"`
+		Findings []struct {
+			File       string `json:"file"`
+			StartLine  int    `json:"start_line"`
+			EndLine    int    `json:"end_line"`
+			Severity   string `json:"severity"`
+			Title      string `json:"title"`
+			Suggestion string `json:"suggestion"`
+		} `json:"findings"`
+	}
+	if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+		return "", nil, fmt.Errorf("invalid findings JSON: %w", err)
+	}
+
+	findings = make([]Finding, 0, len(resp.Findings))
+	for _, f := range resp.Findings {
+		if f.File == "" || f.Title == "" {
+			continue
+		}
+		finding := Finding{
+			File:       strings.TrimPrefix(f.File, "./"),
+			StartLine:  max(f.StartLine, 1),
+			EndLine:    f.EndLine,
+			Severity:   ParseSeverity(f.Severity),
+			Title:      strings.TrimSpace(f.Title),
+			Suggestion: strings.TrimSpace(f.Suggestion),
+		}
+		if finding.EndLine < finding.StartLine {
+			finding.EndLine = finding.StartLine
+		}
+		findings = append(findings, finding)
+	}
+	sortFindings(findings)
+	return strings.TrimSpace(resp.Summary), findings, nil
+}
+
+// extractJSON returns the last fenced json block in text, or the outermost
+// JSON object when there is no fence.
+func extractJSON(text string) string {
+	const fence = "```"
+	var last string
+	rest := text
+	for {
+		start := strings.Index(rest, fence)
+		if start < 0 {
+			break
+		}
+		body := rest[start+len(fence):]
+		nl := strings.IndexByte(body, '\n')
+		if nl < 0 {
+			break
+		}
+		lang := strings.TrimSpace(body[:nl])
+		body = body[nl+1:]
+		end := strings.Index(body, fence)
+		if end < 0 {
+			break
+		}
+		if lang == "" || lang == "json" {
+			last = strings.TrimSpace(body[:end])
+		}
+		rest = body[end+len(fence):]
+	}
+	if last != "" {
+		return last
+	}
+
+	start := strings.IndexByte(text, '{')
+	end := strings.LastIndexByte(text, '}')
+	if start < 0 || end < start {
+		return ""
+	}
+	return text[start : end+1]
+}
+
+func sortFindings(findings []Finding) {
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		return cmp.Or(
+			cmp.Compare(a.Severity.rank(), b.Severity.rank()),
+			strings.Compare(a.File, b.File),
+			cmp.Compare(a.StartLine, b.StartLine),
+		)
+	})
+}
@@ -0,0 +1,85 @@
+package review
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nexora/nexora/internal/git"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResponse(t *testing.T) {
+	t.Parallel()
+
+	text := "I looked at the changes.\n\n```json\n" + `{
+  "summary": "Mostly fine.",
+  "findings": [
+    {"file": "./b.go", "start_line": 4, "severity": "low", "title": "Naming"},
+    {"file": "a.go", "start_line": 10, "end_line": 12, "severity": "HIGH", "title": "Nil dereference", "suggestion": "Check err first"},
+    {"file": "", "start_line": 1, "severity": "error", "title": "dropped"}
+  ]
+}` + "\n```\n"
+
+	summary, findings, err := ParseResponse(text)
+	require.NoError(t, err)
+	require.Equal(t, "Mostly fine.", summary)
+	require.Equal(t, []Finding{
+		{File: "a.go", StartLine: 10, EndLine: 12, Severity: SeverityError, Title: "Nil dereference", Suggestion: "Check err first"},
+		{File: "b.go", StartLine: 4, EndLine: 4, Severity: SeverityInfo, Title: "Naming"},
+	}, findings)
+}
+
+func TestParseResponse_BareJSON(t *testing.T) {
+	t.Parallel()
+
+	_, findings, err := ParseResponse(`{"findings": [{"file": "a.go", "start_line": 1, "severity": "warning", "title": "x"}]}`)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	require.Equal(t, SeverityWarning, findings[0].Severity)
+
+	_, _, err = ParseResponse("Looks good to me.")
+	require.ErrorIs(t, err, ErrNoFindings)
+}
+
+func TestWriteSARIF(t *testing.T) {
+	t.Parallel()
+
+	report := Report{Findings: []Finding{
+		{File: "a.go", StartLine: 3, EndLine: 5, Severity: SeverityInfo, Title: "Consider a helper", Suggestion: "Extract it"},
+	}}
+	var buf bytes.Buffer
+	require.NoError(t, WriteSARIF(&buf, report, "v1.0.0"))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	result := log.Runs[0].Results[0]
+	require.Equal(t, "note", result.Level)
+	require.Equal(t, "a.go", result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	require.Equal(t, 5, result.Locations[0].PhysicalLocation.Region.EndLine)
+	require.Equal(t, "Extract it", result.Fixes[0].Description.Text)
+}
+
+func TestPrompt_TruncatesLargePatch(t *testing.T) {
+	t.Parallel()
+
+	patch := strings.Repeat("+line\n", MaxPatchLength/6+10)
+	prompt := Prompt("main", "feature", git.Diff{
+		Files: []git.FileDiff{{Path: "a.go", Additions: 3}},
+		Patch: patch,
+	})
+	require.Contains(t, prompt, "a.go +3 -0")
+	require.Contains(t, prompt, "The diff was truncated")
+	require.Less(t, len(prompt), MaxPatchLength+1000)
+}
+
+func TestSeverityAtLeast(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, SeverityAtLeast(SeverityError, SeverityWarning))
+	require.True(t, SeverityAtLeast(SeverityWarning, SeverityWarning))
+	require.False(t, SeverityAtLeast(SeverityInfo, SeverityWarning))
+}
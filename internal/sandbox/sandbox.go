@@ -0,0 +1,240 @@
+// Package sandbox runs shell commands in Linux namespaces with the project
+// directory writable, the rest of the filesystem read-only and, by default,
+// no network access.
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+)
+
+// Backend selects how the sandbox is created.
+type Backend string
+
+const (
+	// BackendAuto uses bubblewrap when installed and falls back to unshare.
+	BackendAuto Backend = "auto"
+	// BackendBubblewrap uses bwrap.
+	BackendBubblewrap Backend = "bubblewrap"
+	// BackendUnshare uses unshare(1) with a user, mount and network
+	// namespace.
+	BackendUnshare Backend = "unshare"
+)
+
+// ErrUnavailable is returned when no sandbox backend can be used on this
+// system.
+var ErrUnavailable = errors.New("sandbox unavailable")
+
+// setupFailedExitCode is the exit code of the unshare setup script when it
+// cannot make the filesystem read-only.
+const setupFailedExitCode = 125
+
+// Options configures a sandbox.
+type Options struct {
+	Backend Backend
+	// ProjectDir is always writable.
+	ProjectDir string
+	// WritablePaths are additional writable paths, e.g. build caches.
+	WritablePaths []string
+	// Network allows network access.
+	Network bool
+}
+
+// Sandbox wraps commands so they run isolated.
+type Sandbox struct {
+	backend  Backend
+	binary   string
+	shell    string
+	writable []string
+	network  bool
+}
+
+// New checks that a backend is available and returns a sandbox for opts.
+func New(opts Options) (*Sandbox, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("%w: only supported on Linux", ErrUnavailable)
+	}
+	if opts.ProjectDir == "" {
+		return nil, errors.New("sandbox: project directory is required")
+	}
+
+	backend := opts.Backend
+	if backend == "" {
+		backend = BackendAuto
+	}
+	var binary string
+	switch backend {
+	case BackendAuto:
+		if path, err := exec.LookPath("bwrap"); err == nil {
+			backend, binary = BackendBubblewrap, path
+		} else if path, err := exec.LookPath("unshare"); err == nil {
+			backend, binary = BackendUnshare, path
+		} else {
+			return nil, fmt.Errorf("%w: install bubblewrap (bwrap) or util-linux unshare", ErrUnavailable)
+		}
+	case BackendBubblewrap, BackendUnshare:
+		name := "bwrap"
+		if backend == BackendUnshare {
+			name = "unshare"
+		}
+		path, err := exec.LookPath(name)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s not found", ErrUnavailable, name)
+		}
+		binary = path
+	default:
+		return nil, fmt.Errorf("sandbox: unknown backend %q", backend)
+	}
+
+	shellPath, err := exec.LookPath("bash")
+	if err != nil {
+		shellPath = "/bin/sh"
+	}
+
+	var writable []string
+	for _, p := range append([]string{opts.ProjectDir}, opts.WritablePaths...) {
+		abs, err := resolvePath(p)
+		if err != nil {
+			// Paths that do not exist yet cannot be bind mounted.
+			continue
+		}
+		if !slices.Contains(writable, abs) {
+			writable = append(writable, abs)
+		}
+	}
+	if len(writable) == 0 {
+		return nil, fmt.Errorf("sandbox: project directory %s does not exist", opts.ProjectDir)
+	}
+
+	return &Sandbox{
+		backend:  backend,
+		binary:   binary,
+		shell:    shellPath,
+		writable: writable,
+		network:  opts.Network,
+	}, nil
+}
+
+// Backend returns the backend in use.
+func (s *Sandbox) Backend() Backend {
+	return s.backend
+}
+
+// Network reports whether commands have network access.
+func (s *Sandbox) Network() bool {
+	return s.network
+}
+
+// WritablePaths returns the paths commands may write to, besides /tmp.
+func (s *Sandbox) WritablePaths() []string {
+	return slices.Clone(s.writable)
+}
+
+// Command returns a command that runs script with the shell inside the
+// sandbox, starting in dir.
+func (s *Sandbox) Command(ctx context.Context, dir, script string) *exec.Cmd {
+	args := s.Args(dir, script)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "TMPDIR=/tmp", "NEXORA_SANDBOX=1")
+	return cmd
+}
+
+// Args returns the full argument vector used to run script in dir.
+func (s *Sandbox) Args(dir, script string) []string {
+	if s.backend == BackendUnshare {
+		return s.unshareArgs(dir, script)
+	}
+	return s.bwrapArgs(dir, script)
+}
+
+func (s *Sandbox) bwrapArgs(dir, script string) []string {
+	args := []string{
+		s.binary,
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+	}
+	for _, p := range s.writable {
+		args = append(args, "--bind", p, p)
+	}
+	args = append(args, "--unshare-pid", "--unshare-ipc", "--unshare-uts")
+	if !s.network {
+		args = append(args, "--unshare-net")
+	}
+	args = append(args,
+		"--die-with-parent",
+		"--new-session",
+		"--chdir", dir,
+		"--", s.shell, "-c", script,
+	)
+	return args
+}
+
+// unshareSetup makes every mount read-only except the writable paths, /tmp,
+// /dev and /proc, then runs the command. Its arguments are the shell, the
+// working directory, the script and the writable paths. The host /tmp is
+// kept reachable under /dev/shm until the writable paths below it are bound,
+// since the private /tmp hides them.
+const unshareSetup = `set -e
+sh_bin=$1; dir=$2; script=$3; shift 3
+fail() { echo "nexora sandbox: $1" >&2; exit 125; }
+host_tmp=/dev/shm/.nexora-host-tmp
+mount -t tmpfs tmpfs /dev/shm || fail "cannot mount /dev/shm"
+mkdir -p "$host_tmp"
+mount --rbind /tmp "$host_tmp" || fail "cannot bind /tmp"
+mount -t tmpfs tmpfs /tmp || fail "cannot mount /tmp"
+for p in "$@"; do
+  case "$p" in
+  /tmp|/tmp/*) src="$host_tmp${p#/tmp}"; mkdir -p "$p" ;;
+  *) src=$p ;;
+  esac
+  mount --bind "$src" "$p" || fail "cannot bind $p"
+done
+umount -l "$host_tmp" && rmdir "$host_tmp"
+mount -o remount,bind,ro / || fail "cannot make / read-only"
+awk '{print $2}' /proc/self/mounts | while read -r mp; do
+  case "$mp" in /|/tmp|/tmp/*|/dev|/dev/*|/proc|/proc/*) continue ;; esac
+  skip=
+  for p in "$@"; do [ "$mp" = "$p" ] && skip=1; done
+  [ -n "$skip" ] || mount -o remount,bind,ro "$mp" 2>/dev/null || true
+done
+cd "$dir"
+exec "$sh_bin" -c "$script"
+`
+
+func (s *Sandbox) unshareArgs(dir, script string) []string {
+	args := []string{
+		s.binary,
+		"--user", "--map-root-user",
+		"--mount", "--pid", "--fork", "--mount-proc",
+		"--ipc", "--uts",
+	}
+	if !s.network {
+		args = append(args, "--net")
+	}
+	args = append(args, "--", "/bin/sh", "-c", unshareSetup, "nexora-sandbox", s.shell, dir, script)
+	return append(args, s.writable...)
+}
+
+func resolvePath(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(resolved); err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
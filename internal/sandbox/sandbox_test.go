@@ -0,0 +1,111 @@
+package sandbox
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBwrapArgs(t *testing.T) {
+	t.Parallel()
+
+	s := &Sandbox{
+		backend:  BackendBubblewrap,
+		binary:   "/usr/bin/bwrap",
+		shell:    "/bin/bash",
+		writable: []string{"/work/project", "/home/u/.cache/go-build"},
+	}
+	args := s.Args("/work/project/sub", "go test ./...")
+	require.Equal(t, []string{
+		"/usr/bin/bwrap",
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+		"--bind", "/work/project", "/work/project",
+		"--bind", "/home/u/.cache/go-build", "/home/u/.cache/go-build",
+		"--unshare-pid", "--unshare-ipc", "--unshare-uts",
+		"--unshare-net",
+		"--die-with-parent",
+		"--new-session",
+		"--chdir", "/work/project/sub",
+		"--", "/bin/bash", "-c", "go test ./...",
+	}, args)
+
+	s.network = true
+	require.NotContains(t, s.Args("/work/project", "true"), "--unshare-net")
+}
+
+func TestUnshareArgs(t *testing.T) {
+	t.Parallel()
+
+	s := &Sandbox{
+		backend:  BackendUnshare,
+		binary:   "/usr/bin/unshare",
+		shell:    "/bin/bash",
+		writable: []string{"/work/project"},
+	}
+	args := s.Args("/work/project", "make")
+	require.Contains(t, args, "--net")
+	require.Equal(t, []string{"nexora-sandbox", "/bin/bash", "/work/project", "make", "/work/project"}, args[len(args)-5:])
+}
+
+func TestDetectViolations(t *testing.T) {
+	t.Parallel()
+
+	output := "touch: cannot touch '/etc/nexora': Read-only file system\n" +
+		"curl: (6) Could not resolve host: example.com\n" +
+		"curl: (6) Could not resolve host: example.com\n" +
+		"PermissionError: [Errno 30] Read-only file system: '/usr/lib/x.pyc'\n"
+	require.Equal(t, []Violation{
+		{Kind: ViolationFilesystem, Path: "/etc/nexora", Detail: "write outside the writable directories"},
+		{Kind: ViolationNetwork, Detail: "network access is disabled"},
+		{Kind: ViolationFilesystem, Path: "/usr/lib/x.pyc", Detail: "write outside the writable directories"},
+	}, DetectViolations(output, 1, false))
+
+	require.Empty(t, DetectViolations("Could not resolve host: example.com", 6, true))
+
+	require.Equal(t, []Violation{{Kind: ViolationSetup, Detail: "cannot make / read-only"}},
+		DetectViolations("nexora sandbox: cannot make / read-only", setupFailedExitCode, false))
+}
+
+func TestSandboxIsolation(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("sandbox is Linux only")
+	}
+	project := t.TempDir()
+	s, err := New(Options{ProjectDir: project})
+	if err != nil {
+		t.Skipf("sandbox unavailable: %v", err)
+	}
+
+	// The temp dir lives under /tmp, which the sandbox replaces, so try to
+	// write next to the test instead.
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	outside := filepath.Join(wd, "sandbox-escape.txt")
+	t.Cleanup(func() { os.Remove(outside) })
+	script := "echo ok > inside.txt && echo no > " + outside
+	cmd := s.Command(t.Context(), project, script)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err = cmd.Run()
+	if bytes.Contains(out.Bytes(), []byte("nexora sandbox:")) || bytes.Contains(out.Bytes(), []byte("bwrap:")) {
+		t.Skipf("namespaces not permitted here: %s", out.String())
+	}
+	require.Error(t, err, out.String())
+
+	data, readErr := os.ReadFile(filepath.Join(project, "inside.txt"))
+	require.NoError(t, readErr)
+	require.Equal(t, "ok\n", string(data))
+	require.NoFileExists(t, outside)
+
+	violations := DetectViolations(out.String(), 1, false)
+	require.Len(t, violations, 1, out.String())
+	require.Equal(t, Violation{Kind: ViolationFilesystem, Path: outside, Detail: "write outside the writable directories"}, violations[0])
+}
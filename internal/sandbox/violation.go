@@ -0,0 +1,87 @@
+package sandbox
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ViolationKind classifies what a sandboxed command was prevented from
+// doing.
+type ViolationKind string
+
+const (
+	ViolationFilesystem ViolationKind = "filesystem"
+	ViolationNetwork    ViolationKind = "network"
+	ViolationSetup      ViolationKind = "setup"
+)
+
+// Violation is an operation the sandbox blocked.
+type Violation struct {
+	Kind ViolationKind `json:"kind"`
+	// Path is the file that could not be written, when known.
+	Path   string `json:"path,omitempty"`
+	Detail string `json:"detail"`
+}
+
+var (
+	// Most tools report the path right before the error, e.g.
+	// "touch: cannot touch '/etc/x': Read-only file system", while Python
+	// puts it after: "[Errno 30] Read-only file system: '/etc/x'".
+	readOnlyPathPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`['"‘]?(/[^\s'"’:]+)['"’]?: [Rr]ead-only file system`),
+		regexp.MustCompile(`[Rr]ead-only file system: ['"]?(/[^\s'"]+)`),
+	}
+	networkPatterns = []string{
+		"network is unreachable",
+		"could not resolve host",
+		"temporary failure in name resolution",
+		"name or service not known",
+		"no such host",
+		"dial tcp",
+		"connection refused",
+	}
+)
+
+// DetectViolations inspects the output of a sandboxed command for
+// operations the sandbox blocked.
+func DetectViolations(output string, exitCode int, network bool) []Violation {
+	var violations []Violation
+	seen := make(map[string]bool)
+	add := func(v Violation) {
+		key := string(v.Kind) + "\x00" + v.Path + "\x00" + v.Detail
+		if !seen[key] {
+			seen[key] = true
+			violations = append(violations, v)
+		}
+	}
+
+	for line := range strings.SplitSeq(output, "\n") {
+		line = strings.TrimSpace(line)
+		lower := strings.ToLower(line)
+		switch {
+		case exitCode == setupFailedExitCode && strings.HasPrefix(line, "nexora sandbox:"):
+			add(Violation{Kind: ViolationSetup, Detail: strings.TrimSpace(strings.TrimPrefix(line, "nexora sandbox:"))})
+		case strings.Contains(lower, "read-only file system"):
+			v := Violation{Kind: ViolationFilesystem, Detail: "write outside the writable directories"}
+			for _, re := range readOnlyPathPatterns {
+				if m := re.FindStringSubmatch(line); m != nil {
+					v.Path = m[1]
+					break
+				}
+			}
+			add(v)
+		case !network && containsAny(lower, networkPatterns):
+			add(Violation{Kind: ViolationNetwork, Detail: "network access is disabled"})
+		}
+	}
+	return violations
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
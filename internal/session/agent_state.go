@@ -0,0 +1,146 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nexora/nexora/internal/agent/state"
+	"github.com/nexora/nexora/internal/db"
+	"github.com/nexora/nexora/internal/pubsub"
+)
+
+// AgentState is the persisted state machine of a session's agent: its state,
+// why it is paused, and the progress and phases to resume from after a
+// restart. It is kept next to the session's checkpoints.
+type AgentState struct {
+	SessionID   string
+	State       state.AgentState
+	PauseReason string
+	UpdatedAt   int64
+	Snapshot    state.Snapshot
+}
+
+// IsPaused returns true if the session's agent is paused until resumed.
+func (s AgentState) IsPaused() bool {
+	return s.State.IsPaused()
+}
+
+// AgentStateService persists the state machines of sessions' agents.
+type AgentStateService interface {
+	pubsub.Suscriber[AgentState]
+	Save(ctx context.Context, snapshot state.Snapshot) (AgentState, error)
+	// Get returns sql.ErrNoRows when the session has no saved state.
+	Get(ctx context.Context, sessionID string) (AgentState, error)
+	List(ctx context.Context) ([]AgentState, error)
+	// Resume resumes the saved state of a paused session. Running agents
+	// resume through their state machine instead, which saves it.
+	Resume(ctx context.Context, sessionID string) (AgentState, error)
+	Delete(ctx context.Context, sessionID string) error
+}
+
+type agentStateService struct {
+	*pubsub.Broker[AgentState]
+	q db.Querier
+}
+
+// NewAgentStateService creates a new agent state service.
+func NewAgentStateService(q db.Querier) AgentStateService {
+	return &agentStateService{
+		Broker: pubsub.NewBroker[AgentState](),
+		q:      q,
+	}
+}
+
+func (s *agentStateService) Save(ctx context.Context, snapshot state.Snapshot) (AgentState, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return AgentState{}, fmt.Errorf("failed to serialize agent state: %w", err)
+	}
+	err = s.q.UpsertAgentState(ctx, db.UpsertAgentStateParams{
+		SessionID:   snapshot.SessionID,
+		State:       snapshot.State,
+		PauseReason: snapshot.PauseReason,
+		Snapshot:    data,
+	})
+	if err != nil {
+		return AgentState{}, fmt.Errorf("failed to save agent state: %w", err)
+	}
+	saved := AgentState{
+		SessionID:   snapshot.SessionID,
+		State:       snapshot.AgentState(),
+		PauseReason: snapshot.PauseReason,
+		Snapshot:    snapshot,
+	}
+	s.Publish(pubsub.UpdatedEvent, saved)
+	return saved, nil
+}
+
+func (s *agentStateService) Get(ctx context.Context, sessionID string) (AgentState, error) {
+	dbState, err := s.q.GetAgentState(ctx, sessionID)
+	if err != nil {
+		return AgentState{}, err
+	}
+	return s.fromDBItem(dbState)
+}
+
+func (s *agentStateService) List(ctx context.Context) ([]AgentState, error) {
+	dbStates, err := s.q.ListAgentStates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent states: %w", err)
+	}
+	states := make([]AgentState, 0, len(dbStates))
+	for _, dbState := range dbStates {
+		agentState, err := s.fromDBItem(dbState)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, agentState)
+	}
+	return states, nil
+}
+
+func (s *agentStateService) Resume(ctx context.Context, sessionID string) (AgentState, error) {
+	saved, err := s.Get(ctx, sessionID)
+	if err != nil {
+		return AgentState{}, err
+	}
+	sm, err := state.RestoreStateMachine(state.Config{SessionID: sessionID}, saved.Snapshot)
+	if err != nil {
+		return AgentState{}, err
+	}
+	if err := sm.Resume(); err != nil {
+		return AgentState{}, err
+	}
+	return s.Save(ctx, sm.Snapshot())
+}
+
+func (s *agentStateService) Delete(ctx context.Context, sessionID string) error {
+	saved, err := s.Get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if err := s.q.DeleteAgentState(ctx, sessionID); err != nil {
+		return fmt.Errorf("failed to delete agent state: %w", err)
+	}
+	s.Publish(pubsub.DeletedEvent, saved)
+	return nil
+}
+
+func (s *agentStateService) fromDBItem(item db.AgentState) (AgentState, error) {
+	var snapshot state.Snapshot
+	if err := json.Unmarshal(item.Snapshot, &snapshot); err != nil {
+		return AgentState{}, fmt.Errorf("failed to parse agent state of session %s: %w", item.SessionID, err)
+	}
+	agentState, ok := state.ParseAgentState(item.State)
+	if !ok {
+		return AgentState{}, fmt.Errorf("unknown agent state %q of session %s", item.State, item.SessionID)
+	}
+	return AgentState{
+		SessionID:   item.SessionID,
+		State:       agentState,
+		PauseReason: item.PauseReason,
+		UpdatedAt:   item.UpdatedAt,
+		Snapshot:    snapshot,
+	}, nil
+}
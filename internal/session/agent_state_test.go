@@ -0,0 +1,70 @@
+package session_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/nexora/nexora/internal/agent/state"
+	"github.com/nexora/nexora/internal/pubsub"
+	"github.com/nexora/nexora/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentState_SaveAndResume(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup()
+
+	q := tdb.Querier()
+	svc := session.NewAgentStateService(q)
+	sess, err := session.NewService(q).Create(ctx, "Agent State Session")
+	require.NoError(t, err)
+
+	_, err = svc.Get(ctx, sess.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	events := svc.Subscribe(ctx)
+
+	sm := state.NewStateMachine(state.Config{SessionID: sess.ID})
+	require.NoError(t, sm.TransitionTo(state.StateProcessingPrompt))
+	sm.RecordToolCall(context.Background(), "edit", "main.go", "", "old_string not found", false)
+	require.NoError(t, sm.TransitionTo(state.StateLoopPaused))
+	sm.SetPauseReason("Same error on 'main.go' repeated 3 times")
+
+	_, err = svc.Save(ctx, sm.Snapshot())
+	require.NoError(t, err)
+	event := <-events
+	require.Equal(t, pubsub.UpdatedEvent, event.Type)
+	require.True(t, event.Payload.IsPaused())
+
+	saved, err := svc.Get(ctx, sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, state.StateLoopPaused, saved.State)
+	require.Equal(t, "Same error on 'main.go' repeated 3 times", saved.PauseReason)
+	require.Equal(t, 1, saved.Snapshot.ToolCallCount)
+	require.Equal(t, 1, saved.Snapshot.Progress.ConsecutiveErrors)
+
+	states, err := svc.List(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, states)
+
+	resumed, err := svc.Resume(ctx, sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, state.StateIdle, resumed.State)
+	require.Empty(t, resumed.PauseReason)
+
+	_, err = svc.Resume(ctx, sess.ID)
+	require.Error(t, err, "an idle session can't be resumed")
+
+	saved, err = svc.Get(ctx, sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, state.StateIdle, saved.State)
+	require.Equal(t, 1, saved.Snapshot.ToolCallCount, "progress survives resuming")
+
+	require.NoError(t, svc.Delete(ctx, sess.ID))
+	_, err = svc.Get(ctx, sess.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
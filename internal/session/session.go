@@ -22,6 +22,25 @@ type Session struct {
 	Cost             float64
 	CreatedAt        int64
 	UpdatedAt        int64
+
+	// Prompt cache accounting, summed over every request of the session.
+	// TotalInputTokens includes the tokens read from and written to the
+	// cache.
+	TotalInputTokens    int64
+	CacheReadTokens     int64
+	CacheCreationTokens int64
+	// CacheSavings is what caching saved compared to sending every prompt
+	// uncached, net of the cache write premium. It can be negative.
+	CacheSavings float64
+}
+
+// CacheHitRate returns the share of input tokens that were read from the
+// prompt cache, between 0 and 1.
+func (s Session) CacheHitRate() float64 {
+	if s.TotalInputTokens == 0 {
+		return 0
+	}
+	return float64(s.CacheReadTokens) / float64(s.TotalInputTokens)
 }
 
 type Service interface {
@@ -128,7 +147,11 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 			String: session.SummaryMessageID,
 			Valid:  session.SummaryMessageID != "",
 		},
-		Cost: session.Cost,
+		Cost:                session.Cost,
+		TotalInputTokens:    session.TotalInputTokens,
+		CacheReadTokens:     session.CacheReadTokens,
+		CacheCreationTokens: session.CacheCreationTokens,
+		CacheSavings:        session.CacheSavings,
 	})
 	if err != nil {
 		return Session{}, err
@@ -162,6 +185,11 @@ func (s service) fromDBItem(item db.Session) Session {
 		Cost:             item.Cost,
 		CreatedAt:        item.CreatedAt,
 		UpdatedAt:        item.UpdatedAt,
+
+		TotalInputTokens:    item.TotalInputTokens,
+		CacheReadTokens:     item.CacheReadTokens,
+		CacheCreationTokens: item.CacheCreationTokens,
+		CacheSavings:        item.CacheSavings,
 	}
 }
 
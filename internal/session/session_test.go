@@ -82,6 +82,10 @@ func (m *MockQuerier) UpdateSession(ctx context.Context, params db.UpdateSession
 	session.PromptTokens = params.PromptTokens
 	session.CompletionTokens = params.CompletionTokens
 	session.Cost = params.Cost
+	session.TotalInputTokens = params.TotalInputTokens
+	session.CacheReadTokens = params.CacheReadTokens
+	session.CacheCreationTokens = params.CacheCreationTokens
+	session.CacheSavings = params.CacheSavings
 	if params.SummaryMessageID.Valid {
 		session.SummaryMessageID = params.SummaryMessageID
 	}
@@ -146,6 +150,36 @@ func (m *MockQuerier) DeleteCheckpoint(ctx context.Context, id string) error {
 func (m *MockQuerier) DeleteOldCheckpoints(ctx context.Context, params db.DeleteOldCheckpointsParams) error {
 	return nil
 }
+func (m *MockQuerier) CreateModelSpeedSample(ctx context.Context, params db.CreateModelSpeedSampleParams) error {
+	return nil
+}
+func (m *MockQuerier) ListModelSpeedSamples(ctx context.Context, createdAt int64) ([]db.ModelSpeedSample, error) {
+	return []db.ModelSpeedSample{}, nil
+}
+func (m *MockQuerier) DeleteModelSpeedSamplesBefore(ctx context.Context, createdAt int64) error {
+	return nil
+}
+func (m *MockQuerier) UpsertAgentState(ctx context.Context, params db.UpsertAgentStateParams) error {
+	return nil
+}
+func (m *MockQuerier) GetAgentState(ctx context.Context, sessionID string) (db.AgentState, error) {
+	return db.AgentState{}, sql.ErrNoRows
+}
+func (m *MockQuerier) ListAgentStates(ctx context.Context) ([]db.AgentState, error) {
+	return []db.AgentState{}, nil
+}
+func (m *MockQuerier) DeleteAgentState(ctx context.Context, sessionID string) error {
+	return nil
+}
+func (m *MockQuerier) CreateRecoveryAttempt(ctx context.Context, params db.CreateRecoveryAttemptParams) error {
+	return nil
+}
+func (m *MockQuerier) ListRecoveryAttempts(ctx context.Context, createdAt int64) ([]db.RecoveryAttempt, error) {
+	return []db.RecoveryAttempt{}, nil
+}
+func (m *MockQuerier) ListRecoveryAttemptsBySession(ctx context.Context, sessionID string) ([]db.RecoveryAttempt, error) {
+	return []db.RecoveryAttempt{}, nil
+}
 
 // TestDB provides an in-memory SQLite database for testing
 type TestDB struct {
@@ -229,7 +263,11 @@ CREATE TABLE IF NOT EXISTS sessions (
     cost REAL NOT NULL DEFAULT 0.0 CHECK (cost >= 0.0),
     summary_message_id TEXT,
     updated_at INTEGER NOT NULL,  -- Unix timestamp in milliseconds
-    created_at INTEGER NOT NULL   -- Unix timestamp in milliseconds
+    created_at INTEGER NOT NULL,  -- Unix timestamp in milliseconds
+    total_input_tokens INTEGER NOT NULL DEFAULT 0 CHECK (total_input_tokens >= 0),
+    cache_read_tokens INTEGER NOT NULL DEFAULT 0 CHECK (cache_read_tokens >= 0),
+    cache_creation_tokens INTEGER NOT NULL DEFAULT 0 CHECK (cache_creation_tokens >= 0),
+    cache_savings REAL NOT NULL DEFAULT 0.0
 );
 
 CREATE TRIGGER IF NOT EXISTS update_sessions_updated_at
@@ -341,6 +379,16 @@ CREATE TABLE IF NOT EXISTS checkpoints (
 CREATE INDEX IF NOT EXISTS idx_checkpoints_session_id ON checkpoints(session_id);
 CREATE INDEX IF NOT EXISTS idx_checkpoints_timestamp ON checkpoints(session_id, timestamp DESC);
 CREATE INDEX IF NOT EXISTS idx_checkpoints_created_at ON checkpoints(created_at DESC);
+
+-- Agent states
+CREATE TABLE IF NOT EXISTS agent_states (
+    session_id TEXT PRIMARY KEY,
+    state TEXT NOT NULL,
+    pause_reason TEXT NOT NULL DEFAULT '',
+    snapshot BLOB NOT NULL,
+    updated_at INTEGER NOT NULL,
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
 `
 
 	if _, err := db.Exec(schema); err != nil {
@@ -575,6 +623,20 @@ func TestWithRealDB(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "Updated Session", saved.Title)
 
+	// Cache accounting round-trips
+	saved.TotalInputTokens = 10_000
+	saved.CacheReadTokens = 7_500
+	saved.CacheCreationTokens = 1_000
+	saved.CacheSavings = -0.25
+	saved, err = svc.Save(ctx, saved)
+	require.NoError(t, err)
+	retrieved, err = svc.Get(ctx, sess.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7_500), retrieved.CacheReadTokens)
+	assert.Equal(t, int64(1_000), retrieved.CacheCreationTokens)
+	assert.InDelta(t, -0.25, retrieved.CacheSavings, 1e-9)
+	assert.InDelta(t, 0.75, retrieved.CacheHitRate(), 1e-9)
+
 	// List sessions
 	sessions, err := svc.List(ctx)
 	require.NoError(t, err)
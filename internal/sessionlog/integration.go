@@ -1,15 +1,17 @@
 package sessionlog
 
 // This file contains integration helpers for Nexora v0.25
-// The session logger can be integrated by:
-// 1. Creating a *Manager during coordinator initialization
-// 2. Calling LogEditOperation() in tools/edit.go when failures occur
-// 3. Calling LogViewOperation() in tools/view.go
-// 4. Calling StartSession/EndSession in coordinator.Run()
+// The session logger is integrated by:
+// 1. Creating a *Manager at app startup from the session_log options, or
+//    from the environment with ConfigFromEnv
+// 2. Calling StartSession, LogToolCall, LogLLMRequest, LogStateTransition
+//    and LogEditOperation from the session agent
+// 3. Closing the Manager on shutdown, which ends the sessions still open
 
 import (
 	"context"
-	"log/slog"
+	"os"
+	"strings"
 )
 
 // global instance can be set by the application
@@ -69,13 +71,35 @@ func LogViewSuccessSafe(ctx context.Context, sessionID, instanceID string, view
 	globalManager.LogViewOperation(ctx, view)
 }
 
-// InitializeFromEnv initializes the global session logger from environment variables
-// Set these env vars to enable session logging:
-// NEXORA_SESSION_LOG_ENABLED=true
-// NEXORA_SESSION_LOG_PG_CONN=user=postgres dbname=nexora_sessions host=localhost sslmode=disable
-// NEXORA_SESSION_LOG_INSTANCE_ID=nexora-1 (defaults to hostname)
-func InitializeFromEnv() error {
-	// TODO: Read from environment and initialize
-	slog.Info("Session logging not yet configured from environment")
-	return nil
+// ConfigFromEnv returns the session log configuration of the environment:
+// NEXORA_SESSION_LOG_DSN adds a PostgreSQL sink, NEXORA_SESSION_LOG_SQLITE a
+// SQLite sink and NEXORA_SESSION_LOG_JSONL a JSONL sink, each taking a
+// connection string or path. Logging is enabled when a sink is set and
+// NEXORA_SESSION_LOG_ENABLED isn't false. NEXORA_INSTANCE_ID names the
+// instance and defaults to the hostname.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		PostgresConnStr: os.Getenv("NEXORA_SESSION_LOG_DSN"),
+		InstanceID:      os.Getenv("NEXORA_INSTANCE_ID"),
+	}
+	if path := os.Getenv("NEXORA_SESSION_LOG_SQLITE"); path != "" {
+		cfg.Sinks = append(cfg.Sinks, SinkConfig{Type: SinkSQLite, Path: path})
+	}
+	if path := os.Getenv("NEXORA_SESSION_LOG_JSONL"); path != "" {
+		cfg.Sinks = append(cfg.Sinks, SinkConfig{Type: SinkJSONL, Path: path})
+	}
+	cfg.Enabled = (cfg.PostgresConnStr != "" || len(cfg.Sinks) > 0) &&
+		!strings.EqualFold(os.Getenv("NEXORA_SESSION_LOG_ENABLED"), "false")
+	return cfg
+}
+
+// InitializeFromEnv sets the global manager to one configured by
+// ConfigFromEnv and returns it.
+func InitializeFromEnv() (*Manager, error) {
+	m, err := NewManager(ConfigFromEnv())
+	if err != nil {
+		return nil, err
+	}
+	SetGlobalManager(m)
+	return m, nil
 }
@@ -0,0 +1,126 @@
+package sessionlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// JSONLLogger handles logging to a rotating file of JSON lines. Each line is
+// a record with its "time" and "type" (session_start, session_end, edit,
+// view, tool_call, llm_request or state_transition) next to its fields, so
+// it can be read with jq or loaded into any database.
+type JSONLLogger struct {
+	out *lumberjack.Logger
+	now func() time.Time
+}
+
+var _ Sink = (*JSONLLogger)(nil)
+
+// NewJSONLLogger creates a logger appending to the file at cfg.Path, which
+// is rotated as configured by the Max fields of cfg.
+func NewJSONLLogger(cfg SinkConfig) (*JSONLLogger, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("jsonl session log path is not set")
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create session log directory: %w", err)
+	}
+	maxSize := cfg.MaxSizeMB
+	if maxSize <= 0 {
+		maxSize = 100
+	}
+	return &JSONLLogger{
+		out: &lumberjack.Logger{
+			Filename:   cfg.Path,
+			MaxSize:    maxSize,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAgeDays,
+			Compress:   cfg.Compress,
+		},
+		now: time.Now,
+	}, nil
+}
+
+// write appends v as a line of the given type.
+func (l *JSONLLogger) write(recordType string, v any) error {
+	fields, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	header, err := json.Marshal(struct {
+		Time time.Time `json:"time"`
+		Type string    `json:"type"`
+	}{l.now().UTC(), recordType})
+	if err != nil {
+		return err
+	}
+	// Splice the fields of v into the header object.
+	var line bytes.Buffer
+	line.Write(header[:len(header)-1])
+	if len(fields) > 2 {
+		line.WriteByte(',')
+		line.Write(fields[1:])
+	} else {
+		line.WriteByte('}')
+	}
+	line.WriteByte('\n')
+	// lumberjack serializes writes, so lines of concurrent writers don't mix.
+	_, err = l.out.Write(line.Bytes())
+	return err
+}
+
+// SessionStart logs the start of a session
+func (l *JSONLLogger) SessionStart(_ context.Context, sessionID, instanceID string, metadata map[string]interface{}) error {
+	return l.write("session_start", struct {
+		SessionID  string                 `json:"session_id"`
+		InstanceID string                 `json:"instance_id"`
+		Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	}{sessionID, instanceID, metadata})
+}
+
+// SessionEnd logs the end of a session
+func (l *JSONLLogger) SessionEnd(_ context.Context, sessionID, status string, errorCount, toolCount int) error {
+	return l.write("session_end", struct {
+		SessionID  string `json:"session_id"`
+		Status     string `json:"status"`
+		ErrorCount int    `json:"error_count"`
+		ToolCount  int    `json:"tool_count"`
+	}{sessionID, status, errorCount, toolCount})
+}
+
+// EditOperation logs an edit operation
+func (l *JSONLLogger) EditOperation(_ context.Context, edit EditOperationLog) error {
+	return l.write("edit", edit)
+}
+
+// ViewOperation logs a view operation
+func (l *JSONLLogger) ViewOperation(_ context.Context, view ViewOperationLog) error {
+	return l.write("view", view)
+}
+
+// ToolCall logs a tool call
+func (l *JSONLLogger) ToolCall(_ context.Context, call ToolCallLog) error {
+	return l.write("tool_call", call)
+}
+
+// LLMRequest logs the summary of a model request
+func (l *JSONLLogger) LLMRequest(_ context.Context, req LLMRequestLog) error {
+	return l.write("llm_request", req)
+}
+
+// StateTransition logs a state machine transition
+func (l *JSONLLogger) StateTransition(_ context.Context, transition StateTransitionLog) error {
+	return l.write("state_transition", transition)
+}
+
+// Close closes the log file
+func (l *JSONLLogger) Close() error {
+	return l.out.Close()
+}
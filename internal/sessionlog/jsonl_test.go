@@ -0,0 +1,82 @@
+package sessionlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session_log", "session.jsonl")
+	l, err := NewJSONLLogger(SinkConfig{Type: SinkJSONL, Path: path})
+	require.NoError(t, err)
+	l.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	ctx := t.Context()
+	require.NoError(t, l.SessionStart(ctx, "s1", "i1", nil))
+	require.NoError(t, l.ToolCall(ctx, ToolCallLog{SessionID: "s1", InstanceID: "i1", ToolName: "bash", Status: "success", DurationMS: 12.5}))
+	require.NoError(t, l.EditOperation(ctx, EditOperationLog{SessionID: "s1", FilePath: "main.go", Status: "failure", FailureReason: "not_found"}))
+	require.NoError(t, l.LLMRequest(ctx, LLMRequestLog{SessionID: "s1", Model: "gpt", OutputTokens: 10}))
+	require.NoError(t, l.StateTransition(ctx, StateTransitionLog{SessionID: "s1", FromState: "idle", ToState: "processing_prompt"}))
+	require.NoError(t, l.SessionEnd(ctx, "s1", "completed", 0, 1))
+	require.NoError(t, l.Close())
+
+	lines := readJSONL(t, path)
+	require.Len(t, lines, 6)
+	var types []string
+	for _, line := range lines {
+		require.Equal(t, "2026-01-02T03:04:05Z", line["time"])
+		require.Equal(t, "s1", line["session_id"])
+		types = append(types, line["type"].(string))
+	}
+	require.Equal(t, []string{"session_start", "tool_call", "edit", "llm_request", "state_transition", "session_end"}, types)
+	require.Equal(t, "bash", lines[1]["tool_name"])
+	require.Equal(t, 12.5, lines[1]["duration_ms"])
+	require.Equal(t, "not_found", lines[2]["failure_reason"])
+	require.Equal(t, "processing_prompt", lines[4]["to_state"])
+	require.Equal(t, "completed", lines[5]["status"])
+}
+
+func TestJSONLLoggerRotates(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewJSONLLogger(SinkConfig{Type: SinkJSONL, Path: filepath.Join(dir, "session.jsonl"), MaxSizeMB: 1})
+	require.NoError(t, err)
+
+	// Each record is around 1KB, so 1100 of them don't fit in one file.
+	errorMsg := strings.Repeat("x", 1000)
+	for range 1100 {
+		require.NoError(t, l.ToolCall(t.Context(), ToolCallLog{SessionID: "s1", ToolName: "bash", Status: "error", Error: errorMsg}))
+	}
+	require.NoError(t, l.Close())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	total := 0
+	for _, e := range entries {
+		total += len(readJSONL(t, filepath.Join(dir, e.Name())))
+	}
+	require.Equal(t, 1100, total)
+}
+
+func readJSONL(t *testing.T, path string) []map[string]any {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var lines []map[string]any
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line), scanner.Text())
+		lines = append(lines, line)
+	}
+	require.NoError(t, scanner.Err())
+	return lines
+}
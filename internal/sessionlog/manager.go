@@ -2,6 +2,7 @@ package sessionlog
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"os"
 	"sync"
@@ -10,11 +11,14 @@ import (
 
 // Config holds session logger configuration
 type Config struct {
-	// PostgreSQL connection string
+	// PostgreSQL connection string; when set, a postgres sink is added to
+	// Sinks
 	PostgresConnStr string
-	// Instance ID for this Nexora instance
+	// Sinks the records are written to
+	Sinks []SinkConfig
+	// Instance ID for this Nexora instance (default: hostname)
 	InstanceID string
-	// Enable logging (default: true)
+	// Enable logging
 	Enabled bool
 	// Batch size for async writes (default: 50)
 	BatchSize int
@@ -22,143 +26,228 @@ type Config struct {
 	BatchTimeout time.Duration
 }
 
-// Manager manages session logging across PostgreSQL and SQLite
+// Manager batches session log records and writes them to its sinks. All
+// methods are safe to call on a nil or disabled Manager, and do nothing.
 type Manager struct {
 	config     Config
-	pgLogger   *PostgreSQLLogger
-	sessionID  string
+	sinks      []Sink
 	instanceID string
-	mu         sync.Mutex
-	editQueue  []EditOperationLog
-	viewQueue  []ViewOperationLog
-	closeChan  chan struct{}
-	wg         sync.WaitGroup
+
+	mu       sync.Mutex
+	queue    []record
+	sessions map[string]*sessionCounts
+	closed   bool
+
+	// writeMu keeps batches in order when flushes overlap.
+	writeMu   sync.Mutex
+	closeChan chan struct{}
+	wg        sync.WaitGroup
+	// pending tracks batches written in the background.
+	pending sync.WaitGroup
+}
+
+// sessionCounts counts the tool calls of a session for its end record.
+type sessionCounts struct {
+	tools  int
+	errors int
 }
 
-// NewManager creates a new session log manager
+// NewManager creates a new session log manager. Sinks that can't be opened
+// are skipped with a warning, so an unreachable database doesn't stop the
+// application.
 func NewManager(config Config) (*Manager, error) {
 	if !config.Enabled {
 		return &Manager{config: config, closeChan: make(chan struct{})}, nil
 	}
 
+	sinkConfigs := config.Sinks
+	if config.PostgresConnStr != "" {
+		sinkConfigs = append([]SinkConfig{{Type: SinkPostgres, DSN: config.PostgresConnStr}}, sinkConfigs...)
+	}
+	var sinks []Sink
+	for _, sc := range sinkConfigs {
+		sink, err := OpenSink(sc)
+		if err != nil {
+			slog.Warn("Failed to initialize session log sink", "type", sc.Type, "error", err)
+			continue
+		}
+		sinks = append(sinks, sink)
+	}
+	return newManager(config, sinks), nil
+}
+
+func newManager(config Config, sinks []Sink) *Manager {
 	if config.BatchSize == 0 {
 		config.BatchSize = 50
 	}
 	if config.BatchTimeout == 0 {
 		config.BatchTimeout = 100 * time.Millisecond
 	}
-
-	pgLogger, err := NewPostgreSQLLogger(config.PostgresConnStr)
-	if err != nil {
-		slog.Warn("Failed to initialize PostgreSQL logger", "error", err)
-		// Don't fail completely if PG is unavailable
-		pgLogger = nil
+	if config.InstanceID == "" {
+		config.InstanceID, _ = os.Hostname()
 	}
 
 	m := &Manager{
 		config:     config,
-		pgLogger:   pgLogger,
+		sinks:      sinks,
 		instanceID: config.InstanceID,
+		queue:      make([]record, 0, config.BatchSize),
+		sessions:   make(map[string]*sessionCounts),
 		closeChan:  make(chan struct{}),
-		editQueue:  make([]EditOperationLog, 0, config.BatchSize),
-		viewQueue:  make([]ViewOperationLog, 0, config.BatchSize),
 	}
 
 	// Start background flusher
-	if pgLogger != nil {
+	if m.active() {
 		m.wg.Add(1)
 		go m.flusherLoop()
 	}
 
-	return m, nil
+	return m
 }
 
-// StartSession starts a new session
-func (m *Manager) StartSession(ctx context.Context, sessionID string, metadata map[string]interface{}) error {
-	if !m.config.Enabled || m.pgLogger == nil {
-		return nil
+// active reports whether records are written anywhere.
+func (m *Manager) active() bool {
+	return m != nil && m.config.Enabled && len(m.sinks) > 0
+}
+
+// InstanceID returns the instance ID records are logged with.
+func (m *Manager) InstanceID() string {
+	if m == nil {
+		return ""
+	}
+	return m.instanceID
+}
+
+// StartSession logs the start of a session. Starting a session that was
+// already started does nothing.
+func (m *Manager) StartSession(ctx context.Context, sessionID string, metadata map[string]interface{}) {
+	if !m.active() {
+		return
 	}
+	m.mu.Lock()
+	if _, ok := m.sessions[sessionID]; ok {
+		m.mu.Unlock()
+		return
+	}
+	m.sessions[sessionID] = &sessionCounts{}
+	m.mu.Unlock()
 
-	m.sessionID = sessionID
-	return m.pgLogger.SessionStart(ctx, sessionID, m.instanceID, metadata)
+	m.enqueue(sessionStart{sessionID: sessionID, instanceID: m.instanceID, metadata: metadata})
 }
 
-// EndSession ends the current session
-func (m *Manager) EndSession(ctx context.Context, status string, errorCount, toolCount int) error {
-	if !m.config.Enabled || m.pgLogger == nil {
-		return nil
+// EndSession logs the end of a session with the number of tool calls and
+// tool errors logged for it, and flushes what is queued.
+func (m *Manager) EndSession(ctx context.Context, sessionID, status string) {
+	if !m.active() {
+		return
+	}
+	m.mu.Lock()
+	counts, ok := m.sessions[sessionID]
+	delete(m.sessions, sessionID)
+	m.mu.Unlock()
+	if !ok {
+		return
 	}
 
-	// Flush any pending operations
+	m.enqueue(sessionEnd{sessionID: sessionID, status: status, errorCount: counts.errors, toolCount: counts.tools})
 	m.flush(ctx)
-
-	return m.pgLogger.SessionEnd(ctx, m.sessionID, status, errorCount, toolCount)
 }
 
 // LogEditOperation logs an edit operation asynchronously
 func (m *Manager) LogEditOperation(ctx context.Context, edit EditOperationLog) {
-	if !m.config.Enabled || m.pgLogger == nil {
+	if !m.active() {
+		return
+	}
+	edit.InstanceID = m.instanceID
+	m.enqueue(edit)
+}
+
+// LogViewOperation logs a view operation asynchronously
+func (m *Manager) LogViewOperation(ctx context.Context, view ViewOperationLog) {
+	if !m.active() {
 		return
 	}
+	view.InstanceID = m.instanceID
+	m.enqueue(view)
+}
 
+// LogToolCall logs a tool call asynchronously
+func (m *Manager) LogToolCall(ctx context.Context, call ToolCallLog) {
+	if !m.active() {
+		return
+	}
+	call.InstanceID = m.instanceID
 	m.mu.Lock()
-	m.editQueue = append(m.editQueue, edit)
-	shouldFlush := len(m.editQueue) >= m.config.BatchSize
+	if counts, ok := m.sessions[call.SessionID]; ok {
+		counts.tools++
+		if call.Status != "success" {
+			counts.errors++
+		}
+	}
 	m.mu.Unlock()
+	m.enqueue(call)
+}
 
-	if shouldFlush {
-		m.flush(ctx)
+// LogLLMRequest logs the summary of a model request asynchronously
+func (m *Manager) LogLLMRequest(ctx context.Context, req LLMRequestLog) {
+	if !m.active() {
+		return
 	}
+	req.InstanceID = m.instanceID
+	m.enqueue(req)
 }
 
-// LogViewOperation logs a view operation asynchronously
-func (m *Manager) LogViewOperation(ctx context.Context, view ViewOperationLog) {
-	if !m.config.Enabled || m.pgLogger == nil {
+// LogStateTransition logs a state machine transition asynchronously
+func (m *Manager) LogStateTransition(ctx context.Context, transition StateTransitionLog) {
+	if !m.active() {
 		return
 	}
+	transition.InstanceID = m.instanceID
+	m.enqueue(transition)
+}
 
+// enqueue queues r and writes the queue in the background once it holds a
+// full batch.
+func (m *Manager) enqueue(r record) {
 	m.mu.Lock()
-	m.viewQueue = append(m.viewQueue, view)
-	shouldFlush := len(m.viewQueue) >= m.config.BatchSize
+	if m.closed {
+		m.mu.Unlock()
+		return
+	}
+	m.queue = append(m.queue, r)
+	shouldFlush := len(m.queue) >= m.config.BatchSize
+	if shouldFlush {
+		m.pending.Add(1)
+	}
 	m.mu.Unlock()
 
 	if shouldFlush {
-		m.flush(ctx)
+		go func() {
+			defer m.pending.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			m.flush(ctx)
+		}()
 	}
 }
 
-// flush writes queued operations to PostgreSQL
+// flush writes queued records to every sink
 func (m *Manager) flush(ctx context.Context) {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+
 	m.mu.Lock()
-	edits := make([]EditOperationLog, len(m.editQueue))
-	views := make([]ViewOperationLog, len(m.viewQueue))
-	copy(edits, m.editQueue)
-	copy(views, m.viewQueue)
-	m.editQueue = m.editQueue[:0]
-	m.viewQueue = m.viewQueue[:0]
+	batch := m.queue
+	m.queue = make([]record, 0, m.config.BatchSize)
 	m.mu.Unlock()
 
-	if len(edits) == 0 && len(views) == 0 {
-		return
-	}
-
-	// Write to PostgreSQL in background
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		for _, edit := range edits {
-			if err := m.pgLogger.EditOperation(ctx, edit); err != nil {
-				slog.Error("Failed to log edit operation", "error", err, "file", edit.FilePath)
-			}
-		}
-
-		for _, view := range views {
-			if err := m.pgLogger.ViewOperation(ctx, view); err != nil {
-				slog.Error("Failed to log view operation", "error", err, "file", view.FilePath)
+	for _, r := range batch {
+		for _, sink := range m.sinks {
+			if err := r.writeTo(ctx, sink); err != nil {
+				slog.Error("Failed to write session log record", append(r.describe(), "error", err)...)
 			}
 		}
-	}()
+	}
 }
 
 // flusherLoop periodically flushes queued operations
@@ -173,23 +262,43 @@ func (m *Manager) flusherLoop() {
 		case <-m.closeChan:
 			return
 		case <-ticker.C:
-			m.flush(context.Background())
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			m.flush(ctx)
+			cancel()
 		}
 	}
 }
 
-// Close closes the logger and flushes any pending operations
+// Close ends the sessions still open, flushes any pending records and
+// closes the sinks.
 func (m *Manager) Close(ctx context.Context) error {
+	if !m.active() {
+		return nil
+	}
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	for sessionID, counts := range m.sessions {
+		m.queue = append(m.queue, sessionEnd{sessionID: sessionID, status: "closed", errorCount: counts.errors, toolCount: counts.tools})
+	}
+	clear(m.sessions)
+	m.closed = true
+	m.mu.Unlock()
+
 	close(m.closeChan)
 	m.wg.Wait()
+	m.pending.Wait()
 
 	// Final flush
 	m.flush(ctx)
 
-	if m.pgLogger != nil {
-		return m.pgLogger.Close()
+	var errs []error
+	for _, sink := range m.sinks {
+		errs = append(errs, sink.Close())
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 // DefaultConfig returns a default configuration
@@ -203,3 +312,34 @@ func DefaultConfig() Config {
 		BatchTimeout:    100 * time.Millisecond,
 	}
 }
+
+// sessionStart and sessionEnd are queued with the other records so that
+// sinks see a session start before the records of the session.
+type sessionStart struct {
+	sessionID  string
+	instanceID string
+	metadata   map[string]interface{}
+}
+
+func (s sessionStart) writeTo(ctx context.Context, sink Sink) error {
+	return sink.SessionStart(ctx, s.sessionID, s.instanceID, s.metadata)
+}
+
+func (s sessionStart) describe() []any {
+	return []any{"kind", "session_start", "session_id", s.sessionID}
+}
+
+type sessionEnd struct {
+	sessionID  string
+	status     string
+	errorCount int
+	toolCount  int
+}
+
+func (s sessionEnd) writeTo(ctx context.Context, sink Sink) error {
+	return sink.SessionEnd(ctx, s.sessionID, s.status, s.errorCount, s.toolCount)
+}
+
+func (s sessionEnd) describe() []any {
+	return []any{"kind", "session_end", "session_id", s.sessionID}
+}
@@ -0,0 +1,185 @@
+package sessionlog
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memorySink records what is written to it.
+type memorySink struct {
+	mu      sync.Mutex
+	records []string
+	ends    map[string][3]any
+	closed  bool
+	fail    bool
+}
+
+func (s *memorySink) add(kind string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.New("sink down")
+	}
+	s.records = append(s.records, kind)
+	return nil
+}
+
+func (s *memorySink) SessionStart(_ context.Context, sessionID, _ string, _ map[string]interface{}) error {
+	return s.add("start " + sessionID)
+}
+
+func (s *memorySink) SessionEnd(_ context.Context, sessionID, status string, errorCount, toolCount int) error {
+	s.mu.Lock()
+	if s.ends == nil {
+		s.ends = make(map[string][3]any)
+	}
+	s.ends[sessionID] = [3]any{status, errorCount, toolCount}
+	s.mu.Unlock()
+	return s.add("end " + sessionID)
+}
+
+func (s *memorySink) EditOperation(_ context.Context, edit EditOperationLog) error {
+	return s.add("edit " + edit.FilePath)
+}
+
+func (s *memorySink) ViewOperation(_ context.Context, view ViewOperationLog) error {
+	return s.add("view " + view.FilePath)
+}
+
+func (s *memorySink) ToolCall(_ context.Context, call ToolCallLog) error {
+	return s.add("tool " + call.ToolName)
+}
+
+func (s *memorySink) LLMRequest(_ context.Context, req LLMRequestLog) error {
+	return s.add("llm " + req.Model)
+}
+
+func (s *memorySink) StateTransition(_ context.Context, transition StateTransitionLog) error {
+	return s.add("state " + transition.ToState)
+}
+
+func (s *memorySink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *memorySink) Records() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.records...)
+}
+
+func TestManagerWritesToEverySink(t *testing.T) {
+	a, b := &memorySink{}, &memorySink{}
+	m := newManager(Config{Enabled: true, InstanceID: "test", BatchTimeout: time.Hour}, []Sink{a, b})
+
+	ctx := t.Context()
+	m.StartSession(ctx, "s1", nil)
+	m.StartSession(ctx, "s1", nil)
+	m.LogStateTransition(ctx, StateTransitionLog{SessionID: "s1", FromState: "idle", ToState: "processing_prompt"})
+	m.LogToolCall(ctx, ToolCallLog{SessionID: "s1", ToolName: "bash", Status: "success"})
+	m.LogToolCall(ctx, ToolCallLog{SessionID: "s1", ToolName: "edit", Status: "error"})
+	m.LogEditOperation(ctx, EditOperationLog{SessionID: "s1", FilePath: "main.go", Status: "failure"})
+	m.LogLLMRequest(ctx, LLMRequestLog{SessionID: "s1", Model: "gpt"})
+	m.EndSession(ctx, "s1", "completed")
+
+	want := []string{"start s1", "state processing_prompt", "tool bash", "tool edit", "edit main.go", "llm gpt", "end s1"}
+	require.Equal(t, want, a.Records())
+	require.Equal(t, want, b.Records())
+	require.Equal(t, [3]any{"completed", 1, 2}, a.ends["s1"])
+
+	require.NoError(t, m.Close(ctx))
+	require.True(t, a.closed)
+	require.True(t, b.closed)
+}
+
+func TestManagerFlushesFullBatches(t *testing.T) {
+	sink := &memorySink{}
+	m := newManager(Config{Enabled: true, BatchSize: 2, BatchTimeout: time.Hour}, []Sink{sink})
+	t.Cleanup(func() { _ = m.Close(context.Background()) })
+
+	m.LogViewOperation(t.Context(), ViewOperationLog{FilePath: "a.go"})
+	require.Empty(t, sink.Records())
+	m.LogViewOperation(t.Context(), ViewOperationLog{FilePath: "b.go"})
+	require.Eventually(t, func() bool { return len(sink.Records()) == 2 }, time.Second, 10*time.Millisecond)
+}
+
+func TestManagerFlushesPeriodically(t *testing.T) {
+	sink := &memorySink{}
+	m := newManager(Config{Enabled: true, BatchTimeout: 10 * time.Millisecond}, []Sink{sink})
+	t.Cleanup(func() { _ = m.Close(context.Background()) })
+
+	m.LogToolCall(t.Context(), ToolCallLog{ToolName: "view"})
+	require.Eventually(t, func() bool { return len(sink.Records()) == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestManagerCloseEndsOpenSessions(t *testing.T) {
+	sink := &memorySink{}
+	m := newManager(Config{Enabled: true, BatchTimeout: time.Hour}, []Sink{sink})
+	m.StartSession(t.Context(), "s1", nil)
+	m.LogToolCall(t.Context(), ToolCallLog{SessionID: "s1", ToolName: "bash", Status: "success"})
+
+	require.NoError(t, m.Close(t.Context()))
+	require.Equal(t, []string{"start s1", "tool bash", "end s1"}, sink.Records())
+	require.Equal(t, [3]any{"closed", 0, 1}, sink.ends["s1"])
+
+	// Records after Close are dropped.
+	m.LogToolCall(t.Context(), ToolCallLog{SessionID: "s1", ToolName: "bash"})
+	require.NoError(t, m.Close(t.Context()))
+	require.Len(t, sink.Records(), 3)
+}
+
+func TestManagerKeepsWritingWhenASinkFails(t *testing.T) {
+	broken, ok := &memorySink{fail: true}, &memorySink{}
+	m := newManager(Config{Enabled: true, BatchTimeout: time.Hour}, []Sink{broken, ok})
+	m.LogToolCall(t.Context(), ToolCallLog{ToolName: "bash"})
+	require.NoError(t, m.Close(t.Context()))
+	require.Equal(t, []string{"tool bash"}, ok.Records())
+}
+
+func TestDisabledManager(t *testing.T) {
+	var nilManager *Manager
+	nilManager.StartSession(t.Context(), "s1", nil)
+	nilManager.LogToolCall(t.Context(), ToolCallLog{})
+	require.NoError(t, nilManager.Close(t.Context()))
+
+	m, err := NewManager(Config{Enabled: false, Sinks: []SinkConfig{{Type: SinkSQLite, Path: t.TempDir() + "/log.db"}}})
+	require.NoError(t, err)
+	m.LogToolCall(t.Context(), ToolCallLog{})
+	require.NoError(t, m.Close(t.Context()))
+}
+
+func TestNewManagerSkipsSinksThatFailToOpen(t *testing.T) {
+	m, err := NewManager(Config{Enabled: true, Sinks: []SinkConfig{
+		{Type: "mongodb"},
+		{Type: SinkJSONL, Path: t.TempDir() + "/session.jsonl"},
+	}})
+	require.NoError(t, err)
+	require.Len(t, m.sinks, 1)
+	require.NoError(t, m.Close(t.Context()))
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("NEXORA_SESSION_LOG_DSN", "")
+	t.Setenv("NEXORA_SESSION_LOG_SQLITE", "")
+	t.Setenv("NEXORA_SESSION_LOG_JSONL", "")
+	t.Setenv("NEXORA_SESSION_LOG_ENABLED", "")
+	require.False(t, ConfigFromEnv().Enabled)
+
+	t.Setenv("NEXORA_SESSION_LOG_SQLITE", "/tmp/log.db")
+	t.Setenv("NEXORA_INSTANCE_ID", "worker-1")
+	cfg := ConfigFromEnv()
+	require.True(t, cfg.Enabled)
+	require.Equal(t, "worker-1", cfg.InstanceID)
+	require.Equal(t, []SinkConfig{{Type: SinkSQLite, Path: "/tmp/log.db"}}, cfg.Sinks)
+
+	t.Setenv("NEXORA_SESSION_LOG_ENABLED", "false")
+	require.False(t, ConfigFromEnv().Enabled)
+}
@@ -3,7 +3,6 @@ package sessionlog
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"time"
 
@@ -12,10 +11,102 @@ import (
 
 // PostgreSQLLogger handles logging to PostgreSQL
 type PostgreSQLLogger struct {
-	db *sql.DB
+	sqlLogger
 }
 
-// NewPostgreSQLLogger creates a new PostgreSQL logger
+var _ Sink = (*PostgreSQLLogger)(nil)
+
+// postgresSchema creates the tables queries.sql runs against.
+var postgresSchema = []string{
+	`CREATE TABLE IF NOT EXISTS nexora_sessions (
+		session_id TEXT PRIMARY KEY,
+		instance_id TEXT NOT NULL,
+		status TEXT NOT NULL,
+		metadata JSONB,
+		started_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		ended_at TIMESTAMPTZ,
+		duration_seconds INTEGER,
+		error_count INTEGER NOT NULL DEFAULT 0,
+		tool_count INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS nexora_edit_operations (
+		id BIGSERIAL PRIMARY KEY,
+		session_id TEXT NOT NULL,
+		instance_id TEXT NOT NULL,
+		file_path TEXT NOT NULL,
+		status TEXT NOT NULL,
+		failure_reason TEXT,
+		old_string_length INTEGER,
+		new_string_length INTEGER,
+		replacement_count INTEGER,
+		attempt_count INTEGER,
+		duration_ms DOUBLE PRECISION,
+		has_tabs BOOLEAN,
+		has_mixed_indent BOOLEAN,
+		file_line_endings TEXT,
+		metadata JSONB,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS nexora_view_operations (
+		id BIGSERIAL PRIMARY KEY,
+		session_id TEXT NOT NULL,
+		instance_id TEXT NOT NULL,
+		file_path TEXT NOT NULL,
+		offset_line INTEGER,
+		limit_lines INTEGER,
+		file_size_bytes BIGINT,
+		total_lines INTEGER,
+		status TEXT NOT NULL,
+		error_reason TEXT,
+		duration_ms DOUBLE PRECISION,
+		metadata JSONB,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS nexora_tool_calls (
+		id BIGSERIAL PRIMARY KEY,
+		session_id TEXT NOT NULL,
+		instance_id TEXT NOT NULL,
+		tool_call_id TEXT,
+		tool_name TEXT NOT NULL,
+		status TEXT NOT NULL,
+		error TEXT,
+		input_bytes INTEGER,
+		duration_ms DOUBLE PRECISION,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS nexora_llm_requests (
+		id BIGSERIAL PRIMARY KEY,
+		session_id TEXT NOT NULL,
+		instance_id TEXT NOT NULL,
+		provider TEXT,
+		model TEXT NOT NULL,
+		input_tokens BIGINT,
+		output_tokens BIGINT,
+		cache_read_tokens BIGINT,
+		cache_creation_tokens BIGINT,
+		cost DOUBLE PRECISION,
+		ttft_ms DOUBLE PRECISION,
+		duration_ms DOUBLE PRECISION,
+		finish_reason TEXT,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS nexora_state_transitions (
+		id BIGSERIAL PRIMARY KEY,
+		session_id TEXT NOT NULL,
+		instance_id TEXT NOT NULL,
+		from_state TEXT NOT NULL,
+		to_state TEXT NOT NULL,
+		tool_calls INTEGER,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS nexora_edit_operations_timestamp_idx ON nexora_edit_operations (timestamp)`,
+	`CREATE INDEX IF NOT EXISTS nexora_tool_calls_timestamp_idx ON nexora_tool_calls (timestamp)`,
+	`CREATE INDEX IF NOT EXISTS nexora_llm_requests_timestamp_idx ON nexora_llm_requests (timestamp)`,
+}
+
+// NewPostgreSQLLogger creates a new PostgreSQL logger, creating its tables
+// when they don't exist.
 func NewPostgreSQLLogger(connStr string) (*PostgreSQLLogger, error) {
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
@@ -24,6 +115,7 @@ func NewPostgreSQLLogger(connStr string) (*PostgreSQLLogger, error) {
 
 	// Test connection
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping PostgreSQL: %w", err)
 	}
 
@@ -32,128 +124,51 @@ func NewPostgreSQLLogger(connStr string) (*PostgreSQLLogger, error) {
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(5 * time.Minute)
 
-	return &PostgreSQLLogger{db: db}, nil
-}
-
-// SessionStart logs the start of a session
-func (l *PostgreSQLLogger) SessionStart(ctx context.Context, sessionID, instanceID string, metadata map[string]interface{}) error {
-	metadataJSON, _ := json.Marshal(metadata)
-
-	query := `
-		INSERT INTO nexora_sessions (session_id, instance_id, status, metadata)
-		VALUES ($1, $2, $3, $4)
-	`
-	_, err := l.db.ExecContext(ctx, query, sessionID, instanceID, "active", metadataJSON)
-	return err
-}
-
-// SessionEnd logs the end of a session
-func (l *PostgreSQLLogger) SessionEnd(ctx context.Context, sessionID string, status string, errorCount, toolCount int) error {
-	query := `
-		UPDATE nexora_sessions
-		SET ended_at = CURRENT_TIMESTAMP,
-		    duration_seconds = EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - started_at))::INTEGER,
-		    status = $2,
-		    error_count = $3,
-		    tool_count = $4
-		WHERE session_id = $1
-	`
-	_, err := l.db.ExecContext(ctx, query, sessionID, status, errorCount, toolCount)
-	return err
-}
-
-// EditOperation logs an edit operation
-func (l *PostgreSQLLogger) EditOperation(ctx context.Context, edit EditOperationLog) error {
-	metadataJSON, _ := json.Marshal(edit.Metadata)
-
-	query := `
-		INSERT INTO nexora_edit_operations (
-			session_id, instance_id, file_path, status, failure_reason,
-			old_string_length, new_string_length, replacement_count, attempt_count,
-			duration_ms, has_tabs, has_mixed_indent, file_line_endings, metadata
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
-	`
-	_, err := l.db.ExecContext(ctx, query,
-		edit.SessionID,
-		edit.InstanceID,
-		edit.FilePath,
-		edit.Status,
-		edit.FailureReason,
-		edit.OldStringLength,
-		edit.NewStringLength,
-		edit.ReplacementCount,
-		edit.AttemptCount,
-		edit.DurationMS,
-		edit.HasTabs,
-		edit.HasMixedIndent,
-		edit.FileLineEndings,
-		metadataJSON,
-	)
-	return err
-}
-
-// ViewOperation logs a view operation
-func (l *PostgreSQLLogger) ViewOperation(ctx context.Context, view ViewOperationLog) error {
-	metadataJSON, _ := json.Marshal(view.Metadata)
-
-	query := `
-		INSERT INTO nexora_view_operations (
-			session_id, instance_id, file_path, offset_line, limit_lines,
-			file_size_bytes, total_lines, status, error_reason, duration_ms, metadata
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-	`
-	_, err := l.db.ExecContext(ctx, query,
-		view.SessionID,
-		view.InstanceID,
-		view.FilePath,
-		view.OffsetLine,
-		view.LimitLines,
-		view.FileSizeBytes,
-		view.TotalLines,
-		view.Status,
-		view.ErrorReason,
-		view.DurationMS,
-		metadataJSON,
-	)
-	return err
-}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := createSchema(ctx, db, postgresSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create PostgreSQL session log tables: %w", err)
+	}
 
-// Close closes the database connection
-func (l *PostgreSQLLogger) Close() error {
-	return l.db.Close()
+	return &PostgreSQLLogger{sqlLogger{
+		db:           db,
+		numbered:     true,
+		durationExpr: "EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - started_at))::INTEGER",
+	}}, nil
 }
 
 // ===================== Types =====================
 
 // EditOperationLog represents an edit operation log entry
 type EditOperationLog struct {
-	SessionID        string
-	InstanceID       string
-	FilePath         string
-	Status           string // 'success', 'failure'
-	FailureReason    string // 'whitespace', 'not_found', etc
-	OldStringLength  int
-	NewStringLength  int
-	ReplacementCount int
-	AttemptCount     int
-	DurationMS       float64
-	HasTabs          bool
-	HasMixedIndent   bool
-	FileLineEndings  string // 'LF', 'CRLF', 'Mixed'
-	Metadata         map[string]interface{}
+	SessionID        string                 `json:"session_id"`
+	InstanceID       string                 `json:"instance_id"`
+	FilePath         string                 `json:"file_path"`
+	Status           string                 `json:"status"`                   // 'success', 'failure'
+	FailureReason    string                 `json:"failure_reason,omitempty"` // 'whitespace', 'not_found', etc
+	OldStringLength  int                    `json:"old_string_length"`
+	NewStringLength  int                    `json:"new_string_length"`
+	ReplacementCount int                    `json:"replacement_count"`
+	AttemptCount     int                    `json:"attempt_count"`
+	DurationMS       float64                `json:"duration_ms"`
+	HasTabs          bool                   `json:"has_tabs"`
+	HasMixedIndent   bool                   `json:"has_mixed_indent"`
+	FileLineEndings  string                 `json:"file_line_endings,omitempty"` // 'LF', 'CRLF', 'Mixed'
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // ViewOperationLog represents a view operation log entry
 type ViewOperationLog struct {
-	SessionID     string
-	InstanceID    string
-	FilePath      string
-	OffsetLine    int
-	LimitLines    int
-	FileSizeBytes int64
-	TotalLines    int
-	Status        string // 'success', 'error'
-	ErrorReason   string
-	DurationMS    float64
-	Metadata      map[string]interface{}
+	SessionID     string                 `json:"session_id"`
+	InstanceID    string                 `json:"instance_id"`
+	FilePath      string                 `json:"file_path"`
+	OffsetLine    int                    `json:"offset_line"`
+	LimitLines    int                    `json:"limit_lines"`
+	FileSizeBytes int64                  `json:"file_size_bytes"`
+	TotalLines    int                    `json:"total_lines"`
+	Status        string                 `json:"status"` // 'success', 'error'
+	ErrorReason   string                 `json:"error_reason,omitempty"`
+	DurationMS    float64                `json:"duration_ms"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
 }
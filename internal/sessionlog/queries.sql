@@ -185,3 +185,46 @@ FROM (
   SELECT DISTINCT instance_id FROM nexora_view_operations
 ) i
 ORDER BY instance_id;
+
+-- =====================================================
+-- TOOLS, MODELS & STATES
+-- =====================================================
+
+-- 13. Tool error rates (last 24 hours)
+SELECT 
+  tool_name,
+  COUNT(*) as calls,
+  SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END) as errors,
+  ROUND(100.0 * SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END) / COUNT(*), 2) as error_rate,
+  ROUND(AVG(duration_ms)::NUMERIC, 2) as avg_duration_ms
+FROM nexora_tool_calls
+WHERE timestamp > NOW() - INTERVAL '24 hours'
+GROUP BY tool_name
+ORDER BY errors DESC;
+
+-- 14. Model usage and cost by instance (last 24 hours)
+SELECT 
+  instance_id,
+  provider,
+  model,
+  COUNT(*) as requests,
+  SUM(input_tokens) as input_tokens,
+  SUM(output_tokens) as output_tokens,
+  ROUND(SUM(cost)::NUMERIC, 4) as cost,
+  ROUND(AVG(NULLIF(ttft_ms, 0))::NUMERIC, 2) as avg_ttft_ms,
+  ROUND(AVG(duration_ms)::NUMERIC, 2) as avg_duration_ms
+FROM nexora_llm_requests
+WHERE timestamp > NOW() - INTERVAL '24 hours'
+GROUP BY instance_id, provider, model
+ORDER BY cost DESC;
+
+-- 15. State transitions (last 24 hours)
+SELECT 
+  from_state,
+  to_state,
+  COUNT(*) as transitions,
+  COUNT(DISTINCT session_id) as sessions
+FROM nexora_state_transitions
+WHERE timestamp > NOW() - INTERVAL '24 hours'
+GROUP BY from_state, to_state
+ORDER BY transitions DESC;
@@ -0,0 +1,144 @@
+package sessionlog
+
+import (
+	"context"
+	"fmt"
+)
+
+// Sink types that can be configured.
+const (
+	SinkPostgres = "postgres"
+	SinkSQLite   = "sqlite"
+	SinkJSONL    = "jsonl"
+)
+
+// Sink stores session log records. The Manager batches records and writes
+// each of them to every sink it has, so a sink only needs to store them.
+type Sink interface {
+	SessionStart(ctx context.Context, sessionID, instanceID string, metadata map[string]interface{}) error
+	SessionEnd(ctx context.Context, sessionID, status string, errorCount, toolCount int) error
+	EditOperation(ctx context.Context, edit EditOperationLog) error
+	ViewOperation(ctx context.Context, view ViewOperationLog) error
+	ToolCall(ctx context.Context, call ToolCallLog) error
+	LLMRequest(ctx context.Context, req LLMRequestLog) error
+	StateTransition(ctx context.Context, transition StateTransitionLog) error
+	Close() error
+}
+
+// SinkConfig configures one sink.
+type SinkConfig struct {
+	// Type is postgres, sqlite or jsonl.
+	Type string
+	// DSN is the connection string of a postgres sink.
+	DSN string
+	// Path is the database file of a sqlite sink or the log file of a jsonl
+	// sink.
+	Path string
+	// MaxSizeMB is the size at which a jsonl file is rotated (default: 100).
+	MaxSizeMB int
+	// MaxBackups is the number of rotated jsonl files kept; 0 keeps all.
+	MaxBackups int
+	// MaxAgeDays is the age after which rotated jsonl files are removed; 0
+	// keeps them.
+	MaxAgeDays int
+	// Compress gzips rotated jsonl files.
+	Compress bool
+}
+
+// OpenSink opens the sink described by cfg.
+func OpenSink(cfg SinkConfig) (Sink, error) {
+	switch cfg.Type {
+	case SinkPostgres:
+		return NewPostgreSQLLogger(cfg.DSN)
+	case SinkSQLite:
+		return NewSQLiteLogger(cfg.Path)
+	case SinkJSONL:
+		return NewJSONLLogger(cfg)
+	default:
+		return nil, fmt.Errorf("unknown session log sink %q", cfg.Type)
+	}
+}
+
+// ToolCallLog represents a tool call log entry
+type ToolCallLog struct {
+	SessionID  string  `json:"session_id"`
+	InstanceID string  `json:"instance_id"`
+	ToolCallID string  `json:"tool_call_id"`
+	ToolName   string  `json:"tool_name"`
+	Status     string  `json:"status"` // 'success', 'error'
+	Error      string  `json:"error,omitempty"`
+	InputBytes int     `json:"input_bytes"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// LLMRequestLog represents a summary of one request to a model
+type LLMRequestLog struct {
+	SessionID           string  `json:"session_id"`
+	InstanceID          string  `json:"instance_id"`
+	Provider            string  `json:"provider"`
+	Model               string  `json:"model"`
+	InputTokens         int64   `json:"input_tokens"`
+	OutputTokens        int64   `json:"output_tokens"`
+	CacheReadTokens     int64   `json:"cache_read_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens"`
+	Cost                float64 `json:"cost"`
+	TTFTMS              float64 `json:"ttft_ms,omitempty"`
+	DurationMS          float64 `json:"duration_ms"`
+	FinishReason        string  `json:"finish_reason"`
+}
+
+// StateTransitionLog represents a transition of a session's state machine
+type StateTransitionLog struct {
+	SessionID  string `json:"session_id"`
+	InstanceID string `json:"instance_id"`
+	FromState  string `json:"from_state"`
+	ToState    string `json:"to_state"`
+	ToolCalls  int    `json:"tool_calls"`
+}
+
+// record is a queued log entry.
+type record interface {
+	writeTo(ctx context.Context, s Sink) error
+	// describe returns attributes identifying the record in error logs.
+	describe() []any
+}
+
+func (e EditOperationLog) writeTo(ctx context.Context, s Sink) error {
+	return s.EditOperation(ctx, e)
+}
+
+func (e EditOperationLog) describe() []any {
+	return []any{"kind", "edit", "file", e.FilePath}
+}
+
+func (v ViewOperationLog) writeTo(ctx context.Context, s Sink) error {
+	return s.ViewOperation(ctx, v)
+}
+
+func (v ViewOperationLog) describe() []any {
+	return []any{"kind", "view", "file", v.FilePath}
+}
+
+func (c ToolCallLog) writeTo(ctx context.Context, s Sink) error {
+	return s.ToolCall(ctx, c)
+}
+
+func (c ToolCallLog) describe() []any {
+	return []any{"kind", "tool_call", "tool", c.ToolName}
+}
+
+func (r LLMRequestLog) writeTo(ctx context.Context, s Sink) error {
+	return s.LLMRequest(ctx, r)
+}
+
+func (r LLMRequestLog) describe() []any {
+	return []any{"kind", "llm_request", "model", r.Model}
+}
+
+func (t StateTransitionLog) writeTo(ctx context.Context, s Sink) error {
+	return s.StateTransition(ctx, t)
+}
+
+func (t StateTransitionLog) describe() []any {
+	return []any{"kind", "state_transition", "to", t.ToState}
+}
@@ -0,0 +1,197 @@
+package sessionlog
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// sqlLogger writes session logs to the nexora_* tables of a SQL database.
+// Statements are written with ? placeholders and rebound for databases that
+// number them.
+type sqlLogger struct {
+	db *sql.DB
+	// numbered reports whether the database uses $1 style placeholders.
+	numbered bool
+	// durationExpr computes the seconds between started_at and now.
+	durationExpr string
+}
+
+func (l *sqlLogger) exec(ctx context.Context, query string, args ...any) error {
+	if l.numbered {
+		query = numberPlaceholders(query)
+	}
+	_, err := l.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// numberPlaceholders replaces the ? placeholders of query with $1, $2, ...
+func numberPlaceholders(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteByte('$')
+		b.WriteString(strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+// jsonText encodes v for a JSON column, which both databases accept as text.
+func jsonText(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil || string(data) == "null" {
+		return "{}"
+	}
+	return string(data)
+}
+
+// SessionStart logs the start of a session
+func (l *sqlLogger) SessionStart(ctx context.Context, sessionID, instanceID string, metadata map[string]interface{}) error {
+	return l.exec(ctx, `
+		INSERT INTO nexora_sessions (session_id, instance_id, status, metadata)
+		VALUES (?, ?, 'active', ?)
+		ON CONFLICT (session_id) DO UPDATE
+		SET instance_id = excluded.instance_id, status = 'active', ended_at = NULL
+	`, sessionID, instanceID, jsonText(metadata))
+}
+
+// SessionEnd logs the end of a session
+func (l *sqlLogger) SessionEnd(ctx context.Context, sessionID string, status string, errorCount, toolCount int) error {
+	return l.exec(ctx, `
+		UPDATE nexora_sessions
+		SET ended_at = CURRENT_TIMESTAMP,
+		    duration_seconds = `+l.durationExpr+`,
+		    status = ?,
+		    error_count = ?,
+		    tool_count = ?
+		WHERE session_id = ?
+	`, status, errorCount, toolCount, sessionID)
+}
+
+// EditOperation logs an edit operation
+func (l *sqlLogger) EditOperation(ctx context.Context, edit EditOperationLog) error {
+	return l.exec(ctx, `
+		INSERT INTO nexora_edit_operations (
+			session_id, instance_id, file_path, status, failure_reason,
+			old_string_length, new_string_length, replacement_count, attempt_count,
+			duration_ms, has_tabs, has_mixed_indent, file_line_endings, metadata
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		edit.SessionID,
+		edit.InstanceID,
+		edit.FilePath,
+		edit.Status,
+		edit.FailureReason,
+		edit.OldStringLength,
+		edit.NewStringLength,
+		edit.ReplacementCount,
+		edit.AttemptCount,
+		edit.DurationMS,
+		edit.HasTabs,
+		edit.HasMixedIndent,
+		edit.FileLineEndings,
+		jsonText(edit.Metadata),
+	)
+}
+
+// ViewOperation logs a view operation
+func (l *sqlLogger) ViewOperation(ctx context.Context, view ViewOperationLog) error {
+	return l.exec(ctx, `
+		INSERT INTO nexora_view_operations (
+			session_id, instance_id, file_path, offset_line, limit_lines,
+			file_size_bytes, total_lines, status, error_reason, duration_ms, metadata
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		view.SessionID,
+		view.InstanceID,
+		view.FilePath,
+		view.OffsetLine,
+		view.LimitLines,
+		view.FileSizeBytes,
+		view.TotalLines,
+		view.Status,
+		view.ErrorReason,
+		view.DurationMS,
+		jsonText(view.Metadata),
+	)
+}
+
+// ToolCall logs a tool call
+func (l *sqlLogger) ToolCall(ctx context.Context, call ToolCallLog) error {
+	return l.exec(ctx, `
+		INSERT INTO nexora_tool_calls (
+			session_id, instance_id, tool_call_id, tool_name, status, error,
+			input_bytes, duration_ms
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		call.SessionID,
+		call.InstanceID,
+		call.ToolCallID,
+		call.ToolName,
+		call.Status,
+		call.Error,
+		call.InputBytes,
+		call.DurationMS,
+	)
+}
+
+// LLMRequest logs the summary of a model request
+func (l *sqlLogger) LLMRequest(ctx context.Context, req LLMRequestLog) error {
+	return l.exec(ctx, `
+		INSERT INTO nexora_llm_requests (
+			session_id, instance_id, provider, model, input_tokens, output_tokens,
+			cache_read_tokens, cache_creation_tokens, cost, ttft_ms, duration_ms,
+			finish_reason
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		req.SessionID,
+		req.InstanceID,
+		req.Provider,
+		req.Model,
+		req.InputTokens,
+		req.OutputTokens,
+		req.CacheReadTokens,
+		req.CacheCreationTokens,
+		req.Cost,
+		req.TTFTMS,
+		req.DurationMS,
+		req.FinishReason,
+	)
+}
+
+// StateTransition logs a state machine transition
+func (l *sqlLogger) StateTransition(ctx context.Context, transition StateTransitionLog) error {
+	return l.exec(ctx, `
+		INSERT INTO nexora_state_transitions (
+			session_id, instance_id, from_state, to_state, tool_calls
+		) VALUES (?, ?, ?, ?, ?)
+	`,
+		transition.SessionID,
+		transition.InstanceID,
+		transition.FromState,
+		transition.ToState,
+		transition.ToolCalls,
+	)
+}
+
+// Close closes the database connection
+func (l *sqlLogger) Close() error {
+	return l.db.Close()
+}
+
+// createSchema runs the statements creating the tables of a sink.
+func createSchema(ctx context.Context, db *sql.DB, statements []string) error {
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package sessionlog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ncruces/go-sqlite3"
+	"github.com/ncruces/go-sqlite3/driver"
+	_ "github.com/ncruces/go-sqlite3/embed"
+)
+
+// SQLiteLogger handles logging to a local SQLite database, for teams
+// without a PostgreSQL server. queries_sqlite.sql has the analytics queries
+// of queries.sql for it.
+type SQLiteLogger struct {
+	sqlLogger
+}
+
+var _ Sink = (*SQLiteLogger)(nil)
+
+// sqliteSchema mirrors postgresSchema with SQLite types. Timestamps are UTC
+// text, which compares correctly with datetime('now', ...).
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS nexora_sessions (
+		session_id TEXT PRIMARY KEY,
+		instance_id TEXT NOT NULL,
+		status TEXT NOT NULL,
+		metadata TEXT,
+		started_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		ended_at TEXT,
+		duration_seconds INTEGER,
+		error_count INTEGER NOT NULL DEFAULT 0,
+		tool_count INTEGER NOT NULL DEFAULT 0,
+		created_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS nexora_edit_operations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id TEXT NOT NULL,
+		instance_id TEXT NOT NULL,
+		file_path TEXT NOT NULL,
+		status TEXT NOT NULL,
+		failure_reason TEXT,
+		old_string_length INTEGER,
+		new_string_length INTEGER,
+		replacement_count INTEGER,
+		attempt_count INTEGER,
+		duration_ms REAL,
+		has_tabs INTEGER,
+		has_mixed_indent INTEGER,
+		file_line_endings TEXT,
+		metadata TEXT,
+		timestamp TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS nexora_view_operations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id TEXT NOT NULL,
+		instance_id TEXT NOT NULL,
+		file_path TEXT NOT NULL,
+		offset_line INTEGER,
+		limit_lines INTEGER,
+		file_size_bytes INTEGER,
+		total_lines INTEGER,
+		status TEXT NOT NULL,
+		error_reason TEXT,
+		duration_ms REAL,
+		metadata TEXT,
+		timestamp TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS nexora_tool_calls (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id TEXT NOT NULL,
+		instance_id TEXT NOT NULL,
+		tool_call_id TEXT,
+		tool_name TEXT NOT NULL,
+		status TEXT NOT NULL,
+		error TEXT,
+		input_bytes INTEGER,
+		duration_ms REAL,
+		timestamp TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS nexora_llm_requests (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id TEXT NOT NULL,
+		instance_id TEXT NOT NULL,
+		provider TEXT,
+		model TEXT NOT NULL,
+		input_tokens INTEGER,
+		output_tokens INTEGER,
+		cache_read_tokens INTEGER,
+		cache_creation_tokens INTEGER,
+		cost REAL,
+		ttft_ms REAL,
+		duration_ms REAL,
+		finish_reason TEXT,
+		timestamp TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS nexora_state_transitions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id TEXT NOT NULL,
+		instance_id TEXT NOT NULL,
+		from_state TEXT NOT NULL,
+		to_state TEXT NOT NULL,
+		tool_calls INTEGER,
+		timestamp TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS nexora_edit_operations_timestamp_idx ON nexora_edit_operations (timestamp)`,
+	`CREATE INDEX IF NOT EXISTS nexora_tool_calls_timestamp_idx ON nexora_tool_calls (timestamp)`,
+	`CREATE INDEX IF NOT EXISTS nexora_llm_requests_timestamp_idx ON nexora_llm_requests (timestamp)`,
+}
+
+// NewSQLiteLogger creates a logger writing to the SQLite database at path,
+// creating the file and its tables when they don't exist.
+func NewSQLiteLogger(path string) (*SQLiteLogger, error) {
+	if path == "" {
+		return nil, fmt.Errorf("sqlite session log path is not set")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create session log directory: %w", err)
+	}
+
+	pragmas := []string{
+		"PRAGMA journal_mode = WAL;",
+		"PRAGMA synchronous = NORMAL;",
+		// Several instances may share the database.
+		"PRAGMA busy_timeout = 5000;",
+	}
+	db, err := driver.Open(path, func(c *sqlite3.Conn) error {
+		for _, pragma := range pragmas {
+			if err := c.Exec(pragma); err != nil {
+				return fmt.Errorf("failed to set pragma `%s`: %w", pragma, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite session log: %w", err)
+	}
+
+	if err := createSchema(context.Background(), db, sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create SQLite session log tables: %w", err)
+	}
+
+	return &SQLiteLogger{sqlLogger{
+		db:           db,
+		durationExpr: "CAST((julianday(CURRENT_TIMESTAMP) - julianday(started_at)) * 86400 AS INTEGER)",
+	}}, nil
+}
@@ -0,0 +1,103 @@
+package sessionlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "session_log.db")
+	l, err := NewSQLiteLogger(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	ctx := t.Context()
+	require.NoError(t, l.SessionStart(ctx, "s1", "i1", map[string]interface{}{"model": "gpt"}))
+	// Starting a session again, as after a restart, reopens it.
+	require.NoError(t, l.SessionStart(ctx, "s1", "i2", nil))
+	require.NoError(t, l.EditOperation(ctx, EditOperationLog{SessionID: "s1", InstanceID: "i2", FilePath: "main.go", Status: "failure", FailureReason: "not_found", HasTabs: true}))
+	require.NoError(t, l.EditOperation(ctx, EditOperationLog{SessionID: "s1", InstanceID: "i2", FilePath: "main.go", Status: "success"}))
+	require.NoError(t, l.ViewOperation(ctx, ViewOperationLog{SessionID: "s1", InstanceID: "i2", FilePath: "main.go", Status: "success", DurationMS: 1.5}))
+	require.NoError(t, l.ToolCall(ctx, ToolCallLog{SessionID: "s1", InstanceID: "i2", ToolCallID: "c1", ToolName: "edit", Status: "error", Error: "old_string not found"}))
+	require.NoError(t, l.LLMRequest(ctx, LLMRequestLog{SessionID: "s1", InstanceID: "i2", Provider: "openai", Model: "gpt", InputTokens: 100, OutputTokens: 20, Cost: 0.01}))
+	require.NoError(t, l.StateTransition(ctx, StateTransitionLog{SessionID: "s1", InstanceID: "i2", FromState: "idle", ToState: "processing_prompt"}))
+	require.NoError(t, l.SessionEnd(ctx, "s1", "completed", 1, 1))
+
+	var instanceID, status string
+	var errorCount, toolCount int
+	var duration *int
+	require.NoError(t, l.db.QueryRowContext(ctx,
+		`SELECT instance_id, status, error_count, tool_count, duration_seconds FROM nexora_sessions WHERE session_id = 's1'`,
+	).Scan(&instanceID, &status, &errorCount, &toolCount, &duration))
+	require.Equal(t, "i2", instanceID)
+	require.Equal(t, "completed", status)
+	require.Equal(t, 1, errorCount)
+	require.Equal(t, 1, toolCount)
+	require.NotNil(t, duration)
+
+	var successRate float64
+	require.NoError(t, l.db.QueryRowContext(ctx,
+		`SELECT 100.0 * SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END) / COUNT(*) FROM nexora_edit_operations`,
+	).Scan(&successRate))
+	require.Equal(t, 50.0, successRate)
+
+	for table, want := range map[string]int{
+		"nexora_view_operations":   1,
+		"nexora_tool_calls":        1,
+		"nexora_llm_requests":      1,
+		"nexora_state_transitions": 1,
+	} {
+		var n int
+		require.NoError(t, l.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&n))
+		require.Equal(t, want, n, table)
+	}
+}
+
+func TestSQLiteQueries(t *testing.T) {
+	l, err := NewSQLiteLogger(filepath.Join(t.TempDir(), "session_log.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	require.NoError(t, l.SessionStart(t.Context(), "s1", "i1", nil))
+	for range 5 {
+		require.NoError(t, l.EditOperation(t.Context(), EditOperationLog{SessionID: "s1", InstanceID: "i1", FilePath: "main.go", Status: "failure", FailureReason: "not_found"}))
+		require.NoError(t, l.ViewOperation(t.Context(), ViewOperationLog{SessionID: "s1", InstanceID: "i1", FilePath: "main.go", Status: "success", DurationMS: 2}))
+	}
+
+	data, err := os.ReadFile("queries_sqlite.sql")
+	require.NoError(t, err)
+	queries := 0
+	for stmt := range strings.SplitSeq(string(data), ";\n") {
+		if strings.TrimSpace(stripComments(stmt)) == "" {
+			continue
+		}
+		rows, err := l.db.QueryContext(t.Context(), stmt)
+		require.NoError(t, err, stmt)
+		for rows.Next() {
+		}
+		require.NoError(t, rows.Err(), stmt)
+		rows.Close()
+		queries++
+	}
+	require.Equal(t, 15, queries)
+}
+
+func stripComments(stmt string) string {
+	var lines []string
+	for line := range strings.SplitSeq(stmt, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestNumberPlaceholders(t *testing.T) {
+	require.Equal(t,
+		"INSERT INTO t (a, b) VALUES ($1, $2) ON CONFLICT DO UPDATE SET c = $3",
+		numberPlaceholders("INSERT INTO t (a, b) VALUES (?, ?) ON CONFLICT DO UPDATE SET c = ?"),
+	)
+}
@@ -374,3 +374,29 @@ func TestSplitArgsFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestBlockedCommand(t *testing.T) {
+	blockFuncs := []BlockFunc{
+		CommandsBlocker([]string{"curl"}),
+		ArgumentsBlocker("git", []string{"push"}, []string{"--force"}),
+	}
+	tests := []struct {
+		command string
+		blocked string
+	}{
+		{command: "echo hello"},
+		{command: "curl https://example.com", blocked: "curl https://example.com"},
+		{command: "ls && 'curl' -s example.com | head", blocked: "curl -s example.com"},
+		{command: "echo $(curl example.com)", blocked: "curl example.com"},
+		{command: "git push --force origin main", blocked: "git push --force origin main"},
+		{command: "git push origin main"},
+		{command: "if true; then"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			blocked, ok := BlockedCommand(tt.command, blockFuncs)
+			require.Equal(t, tt.blocked != "", ok)
+			require.Equal(t, tt.blocked, blocked)
+		})
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"slices"
 	"strings"
 	"sync"
@@ -200,6 +201,45 @@ func ArgumentsBlocker(cmd string, args []string, flags []string) BlockFunc {
 	}
 }
 
+// BlockedCommand checks every simple command in command, including those in
+// pipelines, lists and substitutions, against blockFuncs without running it,
+// for commands that do not run in this shell, such as in a sandbox or TMUX.
+// It returns the first blocked command.
+func BlockedCommand(command string, blockFuncs []BlockFunc) (string, bool) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		// The shell rejects it too
+		return "", false
+	}
+
+	var blocked []string
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok || blocked != nil {
+			return blocked == nil
+		}
+		args := make([]string, 0, len(call.Args))
+		for _, word := range call.Args {
+			arg, err := expand.Literal(nil, word)
+			if err != nil {
+				arg = word.Lit()
+			}
+			args = append(args, arg)
+		}
+		if len(args) == 0 {
+			return true
+		}
+		for _, blockFunc := range blockFuncs {
+			if blockFunc(args) {
+				blocked = args
+				return false
+			}
+		}
+		return true
+	})
+	return strings.Join(blocked, " "), blocked != nil
+}
+
 func splitArgsFlags(parts []string) (args []string, flags []string) {
 	args = make([]string, 0, len(parts))
 	flags = make([]string, 0, len(parts))
@@ -311,5 +351,9 @@ func ExitCode(err error) int {
 	if errors.As(err, &exitErr) {
 		return int(exitErr)
 	}
+	var processErr *exec.ExitError
+	if errors.As(err, &processErr) && processErr.ExitCode() >= 0 {
+		return processErr.ExitCode()
+	}
 	return 1
 }
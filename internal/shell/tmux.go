@@ -3,10 +3,12 @@ package shell
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"os/exec"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -66,6 +68,7 @@ type TmuxSession struct {
 	ID          string // Nexora's internal ID (e.g., "nexora-abc123-def456")
 	SessionName string // TMUX session name
 	PaneID      string // TMUX pane identifier
+	PanePID     int32  // PID of the pane's shell; 0 when unknown
 	WorkingDir  string
 	Command     string // Last command executed
 	Description string
@@ -75,6 +78,10 @@ type TmuxSession struct {
 	done        chan struct{}
 }
 
+// EnvDelegateTask is set in the environment of the nexora processes that run
+// delegated tasks in TMUX sessions, to the ID of their task.
+const EnvDelegateTask = "NEXORA_DELEGATE_TASK"
+
 // TmuxManager manages TMUX sessions with pooling support
 type TmuxManager struct {
 	sessions        map[string]*TmuxSession
@@ -98,6 +105,10 @@ type TmuxManager struct {
 var (
 	tmuxManager     *TmuxManager
 	tmuxManagerOnce sync.Once
+
+	// startedTmuxManager is tmuxManager once it is created, so that
+	// TmuxPanePIDs doesn't create it.
+	startedTmuxManager atomic.Pointer[TmuxManager]
 )
 
 // GetTmuxManager returns the singleton TMUX manager
@@ -114,10 +125,59 @@ func GetTmuxManager() *TmuxManager {
 		tmuxManager.recoverOrphanedSessions()
 		// Start background cleanup
 		tmuxManager.startCleanup()
+		startedTmuxManager.Store(tmuxManager)
 	})
 	return tmuxManager
 }
 
+// TmuxPanePIDs returns the PIDs of the shells of the panes of nexora's TMUX
+// sessions. These are children of the TMUX server rather than of nexora, so
+// resource monitoring tracks them explicitly.
+func TmuxPanePIDs() []int32 {
+	m := startedTmuxManager.Load()
+	if m == nil {
+		return nil
+	}
+	return m.PanePIDs()
+}
+
+// PanePIDs returns the PIDs of the shells of the sessions' panes.
+func (m *TmuxManager) PanePIDs() []int32 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	pids := make([]int32, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		if session.PanePID > 0 {
+			pids = append(pids, session.PanePID)
+		}
+	}
+	return pids
+}
+
+// paneInfo returns the ID and shell PID of the first pane of a TMUX session.
+func paneInfo(sessionName string) (string, int32, error) {
+	output, err := exec.Command("tmux", "list-panes", "-t", sessionName, "-F", "#{pane_id} #{pane_pid}").CombinedOutput()
+	if err != nil {
+		return "", 0, err
+	}
+	paneID, pid := parsePaneInfo(string(output))
+	return paneID, pid, nil
+}
+
+// parsePaneInfo parses the "#{pane_id} #{pane_pid}" lines of list-panes.
+func parsePaneInfo(output string) (string, int32) {
+	line, _, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	paneID, pidText, _ := strings.Cut(line, " ")
+	if paneID == "" {
+		paneID = "%0" // Default pane
+	}
+	pid, err := strconv.ParseInt(pidText, 10, 32)
+	if err != nil {
+		return paneID, 0
+	}
+	return paneID, int32(pid)
+}
+
 // GetTmuxManagerWithConfig returns manager with custom config (for testing)
 func GetTmuxManagerWithConfig(config PoolConfig) *TmuxManager {
 	m := GetTmuxManager()
@@ -160,22 +220,18 @@ func (m *TmuxManager) NewTmuxSession(sessionID, workingDir, command, description
 	}
 
 	// Get the pane ID
-	paneCmd := exec.Command("tmux", "list-panes", "-t", sessionName, "-F", "#{pane_id}")
-	paneOutput, err := paneCmd.CombinedOutput()
+	paneID, panePID, err := paneInfo(sessionName)
 	if err != nil {
 		// Cleanup on failure
 		exec.Command("tmux", "kill-session", "-t", sessionName).Run()
 		return nil, fmt.Errorf("failed to get pane ID: %w", err)
 	}
-	paneID := strings.TrimSpace(string(paneOutput))
-	if paneID == "" {
-		paneID = "%0" // Default pane
-	}
 
 	session := &TmuxSession{
 		ID:          sessionID,
 		SessionName: sessionName,
 		PaneID:      paneID,
+		PanePID:     panePID,
 		WorkingDir:  workingDir,
 		Command:     command,
 		Description: description,
@@ -581,6 +637,12 @@ func (m *TmuxManager) cleanupIdleSessions() {
 // recoverOrphanedSessions discovers and kills orphaned nexora-* tmux sessions
 // from previous runs that are not tracked in memory
 func (m *TmuxManager) recoverOrphanedSessions() {
+	// A delegate shares the TMUX server of the nexora that started it, whose
+	// sessions, including the delegate's own, are not orphans
+	if os.Getenv(EnvDelegateTask) != "" {
+		return
+	}
+
 	// List all tmux sessions
 	cmd := exec.Command("tmux", "list-sessions", "-F", "#{session_name}")
 	output, err := cmd.CombinedOutput()
@@ -698,21 +760,17 @@ func (m *TmuxManager) GetPooledSession(workingDir string) (*TmuxSession, error)
 		}
 	}
 
-	paneCmd := exec.Command("tmux", "list-panes", "-t", sessionName, "-F", "#{pane_id}")
-	paneOutput, err := paneCmd.CombinedOutput()
+	paneID, panePID, err := paneInfo(sessionName)
 	if err != nil {
 		exec.Command("tmux", "kill-session", "-t", sessionName).Run()
 		return nil, fmt.Errorf("failed to get pane ID: %w", err)
 	}
-	paneID := strings.TrimSpace(string(paneOutput))
-	if paneID == "" {
-		paneID = "%0"
-	}
 
 	session := &TmuxSession{
 		ID:          sessionID,
 		SessionName: sessionName,
 		PaneID:      paneID,
+		PanePID:     panePID,
 		WorkingDir:  workingDir,
 		Description: "Pooled session",
 		StartedAt:   time.Now(),
@@ -211,3 +211,17 @@ func TestOrphanedSessionDetection(t *testing.T) {
 		t.Logf("Session count OK: %d (threshold: %d)", count, leakThreshold)
 	}
 }
+
+func TestParsePaneInfo(t *testing.T) {
+	paneID, pid := parsePaneInfo("%3 12345\n")
+	assert.Equal(t, "%3", paneID)
+	assert.Equal(t, int32(12345), pid)
+
+	paneID, pid = parsePaneInfo("%1 100\n%2 200\n")
+	assert.Equal(t, "%1", paneID)
+	assert.Equal(t, int32(100), pid)
+
+	paneID, pid = parsePaneInfo("")
+	assert.Equal(t, "%0", paneID)
+	assert.Zero(t, pid)
+}
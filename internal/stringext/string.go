@@ -19,3 +19,16 @@ func ContainsAny(str string, args ...string) bool {
 	}
 	return false
 }
+
+// Truncate shortens text to at most limit runes, ending it with "..." when
+// it is cut. It never splits a multibyte character.
+func Truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	if limit <= 3 {
+		return string(runes[:max(limit, 0)])
+	}
+	return string(runes[:limit-3]) + "..."
+}
@@ -322,3 +322,28 @@ func BenchmarkContainsAny(b *testing.B) {
 		})
 	}
 }
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		limit    int
+		expected string
+	}{
+		{name: "short", text: "hello", limit: 10, expected: "hello"},
+		{name: "exact", text: "hello", limit: 5, expected: "hello"},
+		{name: "long", text: "hello world", limit: 8, expected: "hello..."},
+		{name: "multibyte", text: "héllo wörld", limit: 8, expected: "héllo..."},
+		{name: "multibyte at cut", text: "日本語のテキスト", limit: 6, expected: "日本語..."},
+		{name: "tiny limit", text: "hello", limit: 2, expected: "he"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Truncate(tt.text, tt.limit)
+			if result != tt.expected {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.text, tt.limit, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nexora/nexora/internal/pubsub"
+)
+
+// PlanStatus is the approval state of a plan.
+type PlanStatus string
+
+const (
+	PlanPending  PlanStatus = "pending"
+	PlanApproved PlanStatus = "approved"
+	PlanRejected PlanStatus = "rejected"
+)
+
+// ErrNoPlan is returned when a session has no submitted plan.
+var ErrNoPlan = errors.New("no plan submitted")
+
+// PlanStep is a single item of a plan's checklist.
+type PlanStep struct {
+	Title   string   `json:"title"`
+	Details string   `json:"details,omitempty"`
+	Files   []string `json:"files,omitempty"`
+}
+
+// Plan is the checklist a planning turn produces for the user to approve
+// before anything is changed.
+type Plan struct {
+	SessionID string     `json:"session_id"`
+	TaskID    string     `json:"task_id"`
+	Summary   string     `json:"summary"`
+	Steps     []PlanStep `json:"steps"`
+	Status    PlanStatus `json:"status"`
+	Created   time.Time  `json:"created"`
+}
+
+// Checklist renders the plan as a markdown checklist.
+func (p Plan) Checklist() string {
+	var b strings.Builder
+	if p.Summary != "" {
+		b.WriteString(p.Summary)
+		b.WriteString("\n\n")
+	}
+	for i, step := range p.Steps {
+		fmt.Fprintf(&b, "- [ ] %d. %s\n", i+1, step.Title)
+		if step.Details != "" {
+			fmt.Fprintf(&b, "      %s\n", step.Details)
+		}
+		if len(step.Files) > 0 {
+			fmt.Fprintf(&b, "      Files: %s\n", strings.Join(step.Files, ", "))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// Prompt returns the instructions that hand an approved plan to the agent
+// that executes it.
+func (p Plan) Prompt() string {
+	return "The user approved the following plan. Carry it out step by step, " +
+		"keeping to its scope, and say which step you are working on as you go.\n\n" +
+		"<plan>\n" + p.Checklist() + "\n</plan>"
+}
+
+// PlanService keeps the plan of each session and tracks it as a task whose
+// milestones are the plan's steps.
+type PlanService interface {
+	pubsub.Suscriber[Plan]
+	// Submit stores the plan of a session, replacing any pending one.
+	Submit(ctx context.Context, plan Plan) (Plan, error)
+	Get(sessionID string) (Plan, bool)
+	Approve(ctx context.Context, sessionID string) (Plan, error)
+	Reject(ctx context.Context, sessionID string) (Plan, error)
+}
+
+type planService struct {
+	*pubsub.Broker[Plan]
+	mu    sync.Mutex
+	tasks Service
+	plans map[string]Plan
+}
+
+// NewPlanService creates a plan service that records plans in tasks.
+func NewPlanService(tasks Service) PlanService {
+	return &planService{
+		Broker: pubsub.NewBroker[Plan](),
+		tasks:  tasks,
+		plans:  make(map[string]Plan),
+	}
+}
+
+func (s *planService) Submit(ctx context.Context, plan Plan) (Plan, error) {
+	if plan.SessionID == "" {
+		return Plan{}, errors.New("plan has no session")
+	}
+	var steps []PlanStep
+	var milestones []string
+	for _, step := range plan.Steps {
+		step.Title = strings.TrimSpace(step.Title)
+		if step.Title == "" {
+			continue
+		}
+		steps = append(steps, step)
+		milestones = append(milestones, step.Title)
+	}
+	if len(steps) == 0 {
+		return Plan{}, errors.New("plan has no steps")
+	}
+	plan.Steps = steps
+	plan.Summary = strings.TrimSpace(plan.Summary)
+
+	s.mu.Lock()
+	title := plan.Summary
+	if i := strings.IndexByte(title, '\n'); i >= 0 {
+		title = title[:i]
+	}
+	t := s.tasks.CreateTask(ctx, plan.SessionID, "Plan: "+title, plan.Summary, "planning", milestones)
+	plan.TaskID = t.ID
+	plan.Status = PlanPending
+	plan.Created = time.Now()
+	s.plans[plan.SessionID] = plan
+	s.mu.Unlock()
+
+	s.Publish(pubsub.CreatedEvent, plan)
+	return plan, nil
+}
+
+func (s *planService) Get(sessionID string) (Plan, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	plan, ok := s.plans[sessionID]
+	return plan, ok
+}
+
+func (s *planService) Approve(ctx context.Context, sessionID string) (Plan, error) {
+	return s.setStatus(ctx, sessionID, PlanApproved)
+}
+
+func (s *planService) Reject(ctx context.Context, sessionID string) (Plan, error) {
+	return s.setStatus(ctx, sessionID, PlanRejected)
+}
+
+func (s *planService) setStatus(ctx context.Context, sessionID string, status PlanStatus) (Plan, error) {
+	s.mu.Lock()
+	plan, ok := s.plans[sessionID]
+	if !ok {
+		s.mu.Unlock()
+		return Plan{}, ErrNoPlan
+	}
+	if plan.Status != PlanPending {
+		s.mu.Unlock()
+		return plan, fmt.Errorf("plan already %s", plan.Status)
+	}
+	plan.Status = status
+	s.plans[sessionID] = plan
+	switch status {
+	case PlanApproved:
+		s.tasks.UpdateTaskContext(ctx, sessionID, "executing")
+	case PlanRejected:
+		s.tasks.CloseTask(ctx, sessionID)
+	}
+	s.mu.Unlock()
+
+	s.Publish(pubsub.UpdatedEvent, plan)
+	return plan, nil
+}
@@ -0,0 +1,106 @@
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nexora/nexora/internal/pubsub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanService_Submit(t *testing.T) {
+	ctx := context.Background()
+	tasks := NewService()
+	plans := NewPlanService(tasks)
+	events := plans.Subscribe(t.Context())
+
+	plan, err := plans.Submit(ctx, Plan{
+		SessionID: "s1",
+		Summary:   "Add a --plan flag\nMore detail.",
+		Steps: []PlanStep{
+			{Title: " Add the flag ", Files: []string{"internal/cmd/run.go"}},
+			{Title: "  "},
+			{Title: "Document it", Details: "README usage section"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, PlanPending, plan.Status)
+	require.Len(t, plan.Steps, 2)
+	assert.Equal(t, "Add the flag", plan.Steps[0].Title)
+
+	event := <-events
+	assert.Equal(t, pubsub.CreatedEvent, event.Type)
+	assert.Equal(t, "s1", event.Payload.SessionID)
+
+	// The steps become the milestones of the session's task.
+	active, ok := tasks.GetActiveTask(ctx, "s1")
+	require.True(t, ok)
+	assert.Equal(t, plan.TaskID, active.ID)
+	assert.Equal(t, "Plan: Add a --plan flag", active.Title)
+	require.Len(t, active.Milestones, 2)
+	assert.Equal(t, "Document it", active.Milestones[1].Description)
+
+	_, err = plans.Submit(ctx, Plan{SessionID: "s1", Steps: []PlanStep{{}}})
+	assert.Error(t, err)
+	_, err = plans.Submit(ctx, Plan{Steps: []PlanStep{{Title: "x"}}})
+	assert.Error(t, err)
+}
+
+func TestPlanService_Approve(t *testing.T) {
+	ctx := context.Background()
+	tasks := NewService()
+	plans := NewPlanService(tasks)
+
+	_, err := plans.Approve(ctx, "s1")
+	assert.ErrorIs(t, err, ErrNoPlan)
+
+	_, err = plans.Submit(ctx, Plan{SessionID: "s1", Steps: []PlanStep{{Title: "Step"}}})
+	require.NoError(t, err)
+	plan, err := plans.Approve(ctx, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, PlanApproved, plan.Status)
+
+	active, ok := tasks.GetActiveTask(ctx, "s1")
+	require.True(t, ok)
+	assert.Equal(t, "executing", active.Context)
+
+	// A decided plan cannot be decided again.
+	_, err = plans.Reject(ctx, "s1")
+	assert.Error(t, err)
+}
+
+func TestPlanService_Reject(t *testing.T) {
+	ctx := context.Background()
+	tasks := NewService()
+	plans := NewPlanService(tasks)
+
+	_, err := plans.Submit(ctx, Plan{SessionID: "s1", Steps: []PlanStep{{Title: "Step"}}})
+	require.NoError(t, err)
+	plan, err := plans.Reject(ctx, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, PlanRejected, plan.Status)
+
+	_, ok := tasks.GetActiveTask(ctx, "s1")
+	assert.False(t, ok)
+	got, ok := plans.Get("s1")
+	require.True(t, ok)
+	assert.Equal(t, PlanRejected, got.Status)
+}
+
+func TestPlanChecklist(t *testing.T) {
+	plan := Plan{
+		Summary: "Refactor the parser.",
+		Steps: []PlanStep{
+			{Title: "Split the lexer", Files: []string{"lexer.go", "parser.go"}},
+			{Title: "Add tests", Details: "Cover error recovery"},
+		},
+	}
+	want := "Refactor the parser.\n\n" +
+		"- [ ] 1. Split the lexer\n" +
+		"      Files: lexer.go, parser.go\n" +
+		"- [ ] 2. Add tests\n" +
+		"      Cover error recovery"
+	assert.Equal(t, want, plan.Checklist())
+	assert.Contains(t, plan.Prompt(), "<plan>\n"+want+"\n</plan>")
+}
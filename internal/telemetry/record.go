@@ -0,0 +1,250 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/nexora/nexora/internal/telemetry"
+
+// Outcomes of turns and tool calls.
+const (
+	OutcomeSuccess  = "success"
+	OutcomeError    = "error"
+	OutcomeCanceled = "canceled"
+)
+
+// current holds the instruments while Setup is in effect. Without them
+// nothing is recorded.
+var current atomic.Pointer[instruments]
+
+type instruments struct {
+	tracer trace.Tracer
+
+	turns        metric.Int64Counter
+	turnDuration metric.Float64Histogram
+	llmCalls     metric.Int64Counter
+	llmDuration  metric.Float64Histogram
+	llmTTFT      metric.Float64Histogram
+	tokens       metric.Int64Counter
+	cost         metric.Float64Counter
+	toolCalls    metric.Int64Counter
+	toolDuration metric.Float64Histogram
+}
+
+func newInstruments(tp trace.TracerProvider, mp metric.MeterProvider) (*instruments, error) {
+	meter := mp.Meter(instrumentationName)
+	inst := &instruments{tracer: tp.Tracer(instrumentationName)}
+	var err, e error
+	inst.turns, e = meter.Int64Counter("nexora.agent.turns",
+		metric.WithDescription("Agent turns run"), metric.WithUnit("{turn}"))
+	err = errors.Join(err, e)
+	inst.turnDuration, e = meter.Float64Histogram("nexora.agent.turn.duration",
+		metric.WithDescription("Duration of agent turns"), metric.WithUnit("s"))
+	err = errors.Join(err, e)
+	inst.llmCalls, e = meter.Int64Counter("nexora.llm.calls",
+		metric.WithDescription("Requests sent to models"), metric.WithUnit("{call}"))
+	err = errors.Join(err, e)
+	inst.llmDuration, e = meter.Float64Histogram("nexora.llm.duration",
+		metric.WithDescription("Duration of model requests"), metric.WithUnit("s"))
+	err = errors.Join(err, e)
+	inst.llmTTFT, e = meter.Float64Histogram("nexora.llm.ttft",
+		metric.WithDescription("Time from a model request to its first streamed token"), metric.WithUnit("s"))
+	err = errors.Join(err, e)
+	inst.tokens, e = meter.Int64Counter("nexora.llm.tokens",
+		metric.WithDescription("Tokens used by model requests, by type"), metric.WithUnit("{token}"))
+	err = errors.Join(err, e)
+	inst.cost, e = meter.Float64Counter("nexora.llm.cost",
+		metric.WithDescription("Cost of model requests"), metric.WithUnit("USD"))
+	err = errors.Join(err, e)
+	inst.toolCalls, e = meter.Int64Counter("nexora.tool.calls",
+		metric.WithDescription("Tool executions"), metric.WithUnit("{call}"))
+	err = errors.Join(err, e)
+	inst.toolDuration, e = meter.Float64Histogram("nexora.tool.duration",
+		metric.WithDescription("Duration of tool executions"), metric.WithUnit("s"))
+	err = errors.Join(err, e)
+	if err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+// Enabled reports whether telemetry is being exported.
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+// Turn is an agent turn being recorded. A nil Turn records nothing.
+type Turn struct {
+	inst  *instruments
+	span  trace.Span
+	start time.Time
+	attrs []attribute.KeyValue
+}
+
+// StartTurn starts the span of an agent turn of sessionID with the given
+// model. Model calls and tool executions recorded with the returned context
+// are children of the turn.
+func StartTurn(ctx context.Context, sessionID, provider, model string) (context.Context, *Turn) {
+	inst := current.Load()
+	if inst == nil {
+		return ctx, nil
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("gen_ai.system", provider),
+		attribute.String("gen_ai.request.model", model),
+	}
+	ctx, span := inst.tracer.Start(ctx, "agent.turn",
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(append(attrs, attribute.String("session.id", sessionID))...),
+	)
+	return ctx, &Turn{inst: inst, span: span, start: time.Now(), attrs: attrs}
+}
+
+// End ends the turn, which failed when err is not nil.
+func (t *Turn) End(err error) {
+	if t == nil {
+		return
+	}
+	outcome := outcomeOf(err)
+	if outcome == OutcomeError {
+		t.span.RecordError(err)
+		t.span.SetStatus(codes.Error, err.Error())
+	}
+	t.span.SetAttributes(attribute.String("nexora.outcome", outcome))
+	t.span.End()
+
+	ctx := context.Background()
+	attrs := metric.WithAttributes(append(t.attrs, attribute.String("nexora.outcome", outcome))...)
+	t.inst.turns.Add(ctx, 1, attrs)
+	t.inst.turnDuration.Record(ctx, time.Since(t.start).Seconds(), attrs)
+}
+
+// LLMCall is a finished request to a model.
+type LLMCall struct {
+	Provider string
+	Model    string
+	Start    time.Time
+	// TTFT is the time to the first streamed token, zero when unknown.
+	TTFT     time.Duration
+	Duration time.Duration
+
+	InputTokens         int64
+	OutputTokens        int64
+	CacheReadTokens     int64
+	CacheCreationTokens int64
+	Cost                float64
+	FinishReason        string
+}
+
+// RecordLLMCall records call as a span in the turn of ctx and adds it to the
+// model metrics.
+func RecordLLMCall(ctx context.Context, call LLMCall) {
+	inst := current.Load()
+	if inst == nil {
+		return
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("gen_ai.system", call.Provider),
+		attribute.String("gen_ai.request.model", call.Model),
+	}
+	_, span := inst.tracer.Start(ctx, "chat "+call.Model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(call.Start),
+		trace.WithAttributes(attrs...),
+		trace.WithAttributes(
+			attribute.String("gen_ai.operation.name", "chat"),
+			attribute.Int64("gen_ai.usage.input_tokens", call.InputTokens),
+			attribute.Int64("gen_ai.usage.output_tokens", call.OutputTokens),
+			attribute.Int64("nexora.usage.cache_read_tokens", call.CacheReadTokens),
+			attribute.Int64("nexora.usage.cache_creation_tokens", call.CacheCreationTokens),
+			attribute.Float64("nexora.cost", call.Cost),
+		),
+	)
+	if call.TTFT > 0 {
+		span.SetAttributes(attribute.Float64("nexora.ttft", call.TTFT.Seconds()))
+	}
+	if call.FinishReason != "" {
+		span.SetAttributes(attribute.StringSlice("gen_ai.response.finish_reasons", []string{call.FinishReason}))
+	}
+	span.End(trace.WithTimestamp(call.Start.Add(call.Duration)))
+
+	inst.llmCalls.Add(ctx, 1, metric.WithAttributes(attrs...))
+	inst.llmDuration.Record(ctx, call.Duration.Seconds(), metric.WithAttributes(attrs...))
+	if call.TTFT > 0 {
+		inst.llmTTFT.Record(ctx, call.TTFT.Seconds(), metric.WithAttributes(attrs...))
+	}
+	for tokenType, n := range map[string]int64{
+		"input":          call.InputTokens,
+		"output":         call.OutputTokens,
+		"cache_read":     call.CacheReadTokens,
+		"cache_creation": call.CacheCreationTokens,
+	} {
+		if n > 0 {
+			inst.tokens.Add(ctx, n, metric.WithAttributes(append(attrs, attribute.String("gen_ai.token.type", tokenType))...))
+		}
+	}
+	if call.Cost > 0 {
+		inst.cost.Add(ctx, call.Cost, metric.WithAttributes(attrs...))
+	}
+}
+
+// ToolCall is a finished tool execution.
+type ToolCall struct {
+	ID       string
+	Name     string
+	Start    time.Time
+	Duration time.Duration
+	// Err is the error the tool returned to the model, if any.
+	Err error
+}
+
+// RecordToolCall records call as a span in the turn of ctx and adds it to
+// the tool metrics.
+func RecordToolCall(ctx context.Context, call ToolCall) {
+	inst := current.Load()
+	if inst == nil {
+		return
+	}
+	outcome := outcomeOf(call.Err)
+	_, span := inst.tracer.Start(ctx, "execute_tool "+call.Name,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithTimestamp(call.Start),
+		trace.WithAttributes(
+			attribute.String("gen_ai.operation.name", "execute_tool"),
+			attribute.String("gen_ai.tool.name", call.Name),
+			attribute.String("gen_ai.tool.call.id", call.ID),
+			attribute.String("nexora.outcome", outcome),
+		),
+	)
+	if outcome == OutcomeError {
+		span.RecordError(call.Err)
+		span.SetStatus(codes.Error, call.Err.Error())
+	}
+	span.End(trace.WithTimestamp(call.Start.Add(call.Duration)))
+
+	attrs := metric.WithAttributes(
+		attribute.String("gen_ai.tool.name", call.Name),
+		attribute.String("nexora.outcome", outcome),
+	)
+	inst.toolCalls.Add(ctx, 1, attrs)
+	inst.toolDuration.Record(ctx, call.Duration.Seconds(), attrs)
+}
+
+func outcomeOf(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeSuccess
+	case errors.Is(err, context.Canceled):
+		return OutcomeCanceled
+	default:
+		return OutcomeError
+	}
+}
@@ -0,0 +1,218 @@
+// Package telemetry exports traces and metrics of agent runs over OTLP, so
+// that fleets of headless workers can be watched from an OpenTelemetry
+// collector.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+)
+
+// DefaultMetricInterval is how often metrics are exported when Config
+// doesn't say.
+const DefaultMetricInterval = time.Minute
+
+// Config configures the OTLP exporters.
+type Config struct {
+	// Endpoint is the collector address, as host:port or a URL. Empty uses
+	// the OTEL_EXPORTER_OTLP_ENDPOINT environment variable, or localhost.
+	Endpoint string
+	// Protocol is grpc or http; empty means grpc.
+	Protocol string
+	// Insecure disables TLS for host:port endpoints. URLs use their scheme.
+	Insecure bool
+	Headers  map[string]string
+
+	ServiceName        string
+	ServiceVersion     string
+	ResourceAttributes map[string]string
+
+	// SampleRatio is the fraction of agent turns traced. Zero or less means
+	// every turn.
+	SampleRatio    float64
+	MetricInterval time.Duration
+}
+
+// Setup starts exporting to the collector of cfg. Until the returned
+// shutdown function is called, the Start and Record functions of this
+// package produce spans and metrics; shutdown flushes what is pending.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	if cfg.Protocol == "" {
+		cfg.Protocol = ProtocolGRPC
+	}
+	if cfg.Protocol != ProtocolGRPC && cfg.Protocol != ProtocolHTTP {
+		return nil, fmt.Errorf("unsupported telemetry protocol %q", cfg.Protocol)
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "nexora"
+	}
+	if cfg.MetricInterval <= 0 {
+		cfg.MetricInterval = DefaultMetricInterval
+	}
+
+	res, err := newResource(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	spanExporter, err := newSpanExporter(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("create trace exporter: %w", err)
+	}
+	metricExporter, err := newMetricExporter(ctx, cfg)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("create metric exporter: %w", err), spanExporter.Shutdown(ctx))
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+	)
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter, sdkmetric.WithInterval(cfg.MetricInterval))),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+
+	inst, err := newInstruments(tracerProvider, meterProvider)
+	if err != nil {
+		return nil, errors.Join(err, tracerProvider.Shutdown(ctx), meterProvider.Shutdown(ctx))
+	}
+	current.Store(inst)
+
+	return func(ctx context.Context) error {
+		current.CompareAndSwap(inst, nil)
+		return errors.Join(tracerProvider.Shutdown(ctx), meterProvider.Shutdown(ctx))
+	}, nil
+}
+
+func newResource(ctx context.Context, cfg Config) (*resource.Resource, error) {
+	attrs := []attribute.KeyValue{
+		attribute.String("service.name", cfg.ServiceName),
+	}
+	if cfg.ServiceVersion != "" {
+		attrs = append(attrs, attribute.String("service.version", cfg.ServiceVersion))
+	}
+	for k, v := range cfg.ResourceAttributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	// Attributes of the config win over OTEL_RESOURCE_ATTRIBUTES.
+	return resource.New(ctx,
+		resource.WithHost(),
+		resource.WithFromEnv(),
+		resource.WithAttributes(attrs...),
+	)
+}
+
+func newSpanExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	if cfg.Protocol == ProtocolHTTP {
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			host, urlPath, insecure, err := httpEndpoint(cfg.Endpoint, "/v1/traces")
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, otlptracehttp.WithEndpoint(host), otlptracehttp.WithURLPath(urlPath))
+			if insecure || cfg.Insecure {
+				opts = append(opts, otlptracehttp.WithInsecure())
+			}
+		} else if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	}
+
+	var opts []otlptracegrpc.Option
+	switch {
+	case strings.Contains(cfg.Endpoint, "://"):
+		opts = append(opts, otlptracegrpc.WithEndpointURL(cfg.Endpoint))
+	case cfg.Endpoint != "":
+		opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+	}
+	return otlptracegrpc.New(ctx, opts...)
+}
+
+func newMetricExporter(ctx context.Context, cfg Config) (sdkmetric.Exporter, error) {
+	if cfg.Protocol == ProtocolHTTP {
+		var opts []otlpmetrichttp.Option
+		if cfg.Endpoint != "" {
+			host, urlPath, insecure, err := httpEndpoint(cfg.Endpoint, "/v1/metrics")
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, otlpmetrichttp.WithEndpoint(host), otlpmetrichttp.WithURLPath(urlPath))
+			if insecure || cfg.Insecure {
+				opts = append(opts, otlpmetrichttp.WithInsecure())
+			}
+		} else if cfg.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlpmetrichttp.WithHeaders(cfg.Headers))
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+	}
+
+	var opts []otlpmetricgrpc.Option
+	switch {
+	case strings.Contains(cfg.Endpoint, "://"):
+		opts = append(opts, otlpmetricgrpc.WithEndpointURL(cfg.Endpoint))
+	case cfg.Endpoint != "":
+		opts = append(opts, otlpmetricgrpc.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlpmetricgrpc.WithHeaders(cfg.Headers))
+	}
+	return otlpmetricgrpc.New(ctx, opts...)
+}
+
+// httpEndpoint splits an OTLP/HTTP endpoint into the host and the path to
+// post signal to. A URL without a path gets the default path of the signal,
+// like the OTEL_EXPORTER_OTLP_ENDPOINT variable does.
+func httpEndpoint(endpoint, signalPath string) (host, urlPath string, insecure bool, err error) {
+	if !strings.Contains(endpoint, "://") {
+		return endpoint, signalPath, false, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", false, fmt.Errorf("invalid telemetry endpoint: %w", err)
+	}
+	return u.Host, path.Join("/", u.Path, signalPath), u.Scheme == "http", nil
+}
@@ -0,0 +1,140 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nexora/nexora/internal/telemetry/telemetrytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	collector := telemetrytest.NewCollector(t)
+	shutdown, err := Setup(t.Context(), Config{
+		Endpoint:           collector.Endpoint(),
+		Protocol:           ProtocolHTTP,
+		ServiceVersion:     "v1.2.3",
+		ResourceAttributes: map[string]string{"deployment.environment": "test"},
+	})
+	require.NoError(t, err)
+	require.True(t, Enabled())
+
+	ctx, turn := StartTurn(t.Context(), "session-1", "anthropic", "claude-sonnet")
+	start := time.Now()
+	RecordLLMCall(ctx, LLMCall{
+		Provider:     "anthropic",
+		Model:        "claude-sonnet",
+		Start:        start,
+		TTFT:         300 * time.Millisecond,
+		Duration:     2 * time.Second,
+		InputTokens:  1200,
+		OutputTokens: 80,
+		Cost:         0.01,
+		FinishReason: "tool_use",
+	})
+	RecordToolCall(ctx, ToolCall{ID: "call-1", Name: "bash", Start: start, Duration: time.Second})
+	RecordToolCall(ctx, ToolCall{ID: "call-2", Name: "edit", Start: start, Duration: time.Second, Err: errors.New("old_string not found")})
+	turn.End(nil)
+
+	require.NoError(t, shutdown(context.Background()))
+	require.False(t, Enabled())
+
+	turnSpan, ok := collector.Span("agent.turn")
+	require.True(t, ok)
+	require.Equal(t, "session-1", turnSpan.Attributes["session.id"])
+	require.Equal(t, OutcomeSuccess, turnSpan.Attributes["nexora.outcome"])
+	require.Equal(t, "nexora", turnSpan.Resource["service.name"])
+	require.Equal(t, "v1.2.3", turnSpan.Resource["service.version"])
+	require.Equal(t, "test", turnSpan.Resource["deployment.environment"])
+
+	llmSpan, ok := collector.Span("chat claude-sonnet")
+	require.True(t, ok)
+	require.Equal(t, turnSpan.SpanID, llmSpan.ParentSpanID)
+	require.Equal(t, int64(1200), llmSpan.Attributes["gen_ai.usage.input_tokens"])
+	require.Equal(t, int64(80), llmSpan.Attributes["gen_ai.usage.output_tokens"])
+	require.Equal(t, 0.01, llmSpan.Attributes["nexora.cost"])
+	require.Equal(t, 0.3, llmSpan.Attributes["nexora.ttft"])
+
+	toolSpan, ok := collector.Span("execute_tool bash")
+	require.True(t, ok)
+	require.Equal(t, turnSpan.SpanID, toolSpan.ParentSpanID)
+	require.False(t, toolSpan.Error)
+	failed, ok := collector.Span("execute_tool edit")
+	require.True(t, ok)
+	require.True(t, failed.Error)
+	require.Equal(t, "old_string not found", failed.StatusMessage)
+	require.Equal(t, OutcomeError, failed.Attributes["nexora.outcome"])
+
+	turns, ok := collector.Metric("nexora.agent.turns")
+	require.True(t, ok)
+	require.Equal(t, 1.0, turns.Value)
+	tokens, ok := collector.Metric("nexora.llm.tokens")
+	require.True(t, ok)
+	require.Equal(t, 1280.0, tokens.Value)
+	toolCalls, ok := collector.Metric("nexora.tool.calls")
+	require.True(t, ok)
+	require.Equal(t, 2.0, toolCalls.Value)
+	ttft, ok := collector.Metric("nexora.llm.ttft")
+	require.True(t, ok)
+	require.Equal(t, uint64(1), ttft.Count)
+	require.Equal(t, "s", ttft.Unit)
+}
+
+func TestTurnOutcome(t *testing.T) {
+	collector := telemetrytest.NewCollector(t)
+	shutdown, err := Setup(t.Context(), Config{Endpoint: collector.Endpoint(), Protocol: ProtocolHTTP})
+	require.NoError(t, err)
+
+	_, turn := StartTurn(t.Context(), "session-1", "openai", "gpt")
+	turn.End(context.Canceled)
+	_, turn = StartTurn(t.Context(), "session-2", "openai", "gpt")
+	turn.End(errors.New("rate limited"))
+	require.NoError(t, shutdown(context.Background()))
+
+	spans := collector.Spans()
+	require.Len(t, spans, 2)
+	outcomes := map[any]bool{}
+	for _, s := range spans {
+		outcomes[s.Attributes["nexora.outcome"]] = s.Error
+	}
+	require.Equal(t, map[any]bool{OutcomeCanceled: false, OutcomeError: true}, outcomes)
+}
+
+func TestDisabled(t *testing.T) {
+	ctx, turn := StartTurn(t.Context(), "session-1", "openai", "gpt")
+	require.Nil(t, turn)
+	require.Equal(t, t.Context(), ctx)
+	// Nothing is recorded, and nothing panics.
+	RecordLLMCall(ctx, LLMCall{Model: "gpt"})
+	RecordToolCall(ctx, ToolCall{Name: "bash"})
+	turn.End(nil)
+}
+
+func TestSetupRejectsUnknownProtocol(t *testing.T) {
+	_, err := Setup(t.Context(), Config{Protocol: "udp"})
+	require.Error(t, err)
+}
+
+func TestHTTPEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		host     string
+		path     string
+		insecure bool
+	}{
+		{"localhost:4318", "localhost:4318", "/v1/traces", false},
+		{"http://collector:4318", "collector:4318", "/v1/traces", true},
+		{"https://otlp.example.com/otlp/", "otlp.example.com", "/otlp/v1/traces", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			host, path, insecure, err := httpEndpoint(tt.endpoint, "/v1/traces")
+			require.NoError(t, err)
+			require.Equal(t, tt.host, host)
+			require.Equal(t, tt.path, path)
+			require.Equal(t, tt.insecure, insecure)
+		})
+	}
+}
@@ -0,0 +1,226 @@
+// Package telemetrytest provides an in-process OTLP/HTTP collector that
+// tests can export telemetry to.
+package telemetrytest
+
+import (
+	"compress/gzip"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Span is a span the collector received.
+type Span struct {
+	Name          string
+	TraceID       string
+	SpanID        string
+	ParentSpanID  string
+	Attributes    map[string]any
+	Error         bool
+	StatusMessage string
+	// Resource holds the attributes of the resource that sent the span.
+	Resource map[string]any
+}
+
+// Metric is the last exported value of a metric, added up across its
+// attribute sets: the total of a sum, or the count and sum of a histogram.
+type Metric struct {
+	Name  string
+	Unit  string
+	Value float64
+	Count uint64
+}
+
+// Collector receives OTLP/HTTP exports of traces and metrics in protobuf.
+type Collector struct {
+	server *httptest.Server
+
+	mu      sync.Mutex
+	spans   []Span
+	metrics map[string]Metric
+}
+
+// NewCollector starts a collector that is closed when the test ends.
+func NewCollector(t testing.TB) *Collector {
+	t.Helper()
+	c := &Collector{metrics: make(map[string]Metric)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/traces", func(w http.ResponseWriter, r *http.Request) {
+		var req coltracepb.ExportTraceServiceRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		c.addSpans(&req)
+		respond(w, &coltracepb.ExportTraceServiceResponse{})
+	})
+	mux.HandleFunc("POST /v1/metrics", func(w http.ResponseWriter, r *http.Request) {
+		var req colmetricpb.ExportMetricsServiceRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		c.addMetrics(&req)
+		respond(w, &colmetricpb.ExportMetricsServiceResponse{})
+	})
+	c.server = httptest.NewServer(mux)
+	t.Cleanup(c.server.Close)
+	return c
+}
+
+// Endpoint returns the URL to export to with the http protocol.
+func (c *Collector) Endpoint() string {
+	return c.server.URL
+}
+
+// Spans returns the spans received so far, in the order they arrived.
+func (c *Collector) Spans() []Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Span(nil), c.spans...)
+}
+
+// Span returns the first span received with the given name.
+func (c *Collector) Span(name string) (Span, bool) {
+	for _, s := range c.Spans() {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Span{}, false
+}
+
+// Metric returns the last exported value of the metric with the given name.
+func (c *Collector) Metric(name string) (Metric, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.metrics[name]
+	return m, ok
+}
+
+func (c *Collector) addSpans(req *coltracepb.ExportTraceServiceRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rs := range req.GetResourceSpans() {
+		resource := attributes(rs.GetResource().GetAttributes())
+		for _, ss := range rs.GetScopeSpans() {
+			for _, s := range ss.GetSpans() {
+				c.spans = append(c.spans, Span{
+					Name:          s.GetName(),
+					TraceID:       hex.EncodeToString(s.GetTraceId()),
+					SpanID:        hex.EncodeToString(s.GetSpanId()),
+					ParentSpanID:  hex.EncodeToString(s.GetParentSpanId()),
+					Attributes:    attributes(s.GetAttributes()),
+					Error:         s.GetStatus().GetCode() == tracepb.Status_STATUS_CODE_ERROR,
+					StatusMessage: s.GetStatus().GetMessage(),
+					Resource:      resource,
+				})
+			}
+		}
+	}
+}
+
+func (c *Collector) addMetrics(req *colmetricpb.ExportMetricsServiceRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rm := range req.GetResourceMetrics() {
+		for _, sm := range rm.GetScopeMetrics() {
+			for _, m := range sm.GetMetrics() {
+				metric := Metric{Name: m.GetName(), Unit: m.GetUnit()}
+				switch data := m.GetData().(type) {
+				case *metricpb.Metric_Sum:
+					for _, dp := range data.Sum.GetDataPoints() {
+						metric.Value += numberValue(dp)
+						metric.Count++
+					}
+				case *metricpb.Metric_Gauge:
+					for _, dp := range data.Gauge.GetDataPoints() {
+						metric.Value += numberValue(dp)
+						metric.Count++
+					}
+				case *metricpb.Metric_Histogram:
+					for _, dp := range data.Histogram.GetDataPoints() {
+						metric.Value += dp.GetSum()
+						metric.Count += dp.GetCount()
+					}
+				}
+				c.metrics[metric.Name] = metric
+			}
+		}
+	}
+}
+
+func numberValue(dp *metricpb.NumberDataPoint) float64 {
+	if v, ok := dp.GetValue().(*metricpb.NumberDataPoint_AsInt); ok {
+		return float64(v.AsInt)
+	}
+	return dp.GetAsDouble()
+}
+
+func attributes(kvs []*commonpb.KeyValue) map[string]any {
+	attrs := make(map[string]any, len(kvs))
+	for _, kv := range kvs {
+		attrs[kv.GetKey()] = value(kv.GetValue())
+	}
+	return attrs
+}
+
+func value(v *commonpb.AnyValue) any {
+	switch v := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return v.StringValue
+	case *commonpb.AnyValue_IntValue:
+		return v.IntValue
+	case *commonpb.AnyValue_DoubleValue:
+		return v.DoubleValue
+	case *commonpb.AnyValue_BoolValue:
+		return v.BoolValue
+	case *commonpb.AnyValue_ArrayValue:
+		values := make([]any, 0, len(v.ArrayValue.GetValues()))
+		for _, item := range v.ArrayValue.GetValues() {
+			values = append(values, value(item))
+		}
+		return values
+	}
+	return nil
+}
+
+func decode(w http.ResponseWriter, r *http.Request, msg proto.Message) bool {
+	body := io.Reader(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return false
+		}
+		defer gz.Close()
+		body = gz
+	}
+	data, err := io.ReadAll(body)
+	if err == nil {
+		err = proto.Unmarshal(data, msg)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func respond(w http.ResponseWriter, msg proto.Message) {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(data)
+}
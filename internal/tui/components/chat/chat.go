@@ -26,6 +26,8 @@ import (
 type SendMsg struct {
 	Text        string
 	Attachments []message.Attachment
+	// Plan sends the message to the read-only planner instead of the coder.
+	Plan bool
 }
 
 // IdleMsg is sent when user has been idle (not typing) for a threshold duration.
@@ -127,6 +129,10 @@ func (m *messageListCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		}
 	}
 	switch msg := msg.(type) {
+	case styles.ThemeChangedMsg:
+		// Rendered items are cached until the width changes.
+		cmds = append(cmds, m.listCmp.SetSize(0, 0), m.SetSize(m.width, m.height))
+		return m, tea.Batch(cmds...)
 	case tea.KeyPressMsg:
 		if m.listCmp.IsFocused() && m.listCmp.HasSelection() {
 			switch {
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
-	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -18,6 +17,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/nexora/nexora/internal/app"
+	"github.com/nexora/nexora/internal/clipboard"
 	"github.com/nexora/nexora/internal/fsext"
 	"github.com/nexora/nexora/internal/message"
 	"github.com/nexora/nexora/internal/session"
@@ -25,10 +25,14 @@ import (
 	"github.com/nexora/nexora/internal/tui/components/completions"
 	"github.com/nexora/nexora/internal/tui/components/core"
 	"github.com/nexora/nexora/internal/tui/components/core/layout"
+	"github.com/nexora/nexora/internal/tui/components/core/status"
 	"github.com/nexora/nexora/internal/tui/components/dialogs"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/agents"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/commands"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/filepicker"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/plan"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/quit"
+	"github.com/nexora/nexora/internal/tui/keymap"
 	"github.com/nexora/nexora/internal/tui/styles"
 	"github.com/nexora/nexora/internal/tui/util"
 )
@@ -79,6 +83,13 @@ type editorCmp struct {
 
 	// Idle detection for background compaction
 	idleSeq int // incremented on each keypress to invalidate old timers
+
+	// Plan mode sends messages to the read-only planner
+	planMode bool
+
+	// Vim modal editing, nil while vim mode is off
+	vim       *vim
+	vimStatus string // mode last shown in the status bar
 }
 
 var DeleteKeyMaps = DeleteAttachmentKeyMaps{
@@ -97,8 +108,9 @@ var DeleteKeyMaps = DeleteAttachmentKeyMaps{
 }
 
 const (
-	maxAttachments = 5
-	maxFileResults = 25
+	maxAttachments   = 5
+	maxFileResults   = 25
+	clipboardTimeout = 5 * time.Second
 )
 
 type OpenEditorMsg struct {
@@ -146,7 +158,7 @@ func (m *editorCmp) openEditor(value string) tea.Cmd {
 }
 
 func (m *editorCmp) Init() tea.Cmd {
-	return nil
+	return m.syncVimMode()
 }
 
 func (m *editorCmp) send() tea.Cmd {
@@ -160,6 +172,9 @@ func (m *editorCmp) send() tea.Cmd {
 	}
 
 	m.textarea.Reset()
+	if m.vim != nil {
+		m.vim.Reset()
+	}
 	attachments := m.attachments
 
 	m.attachments = nil
@@ -174,10 +189,54 @@ func (m *editorCmp) send() tea.Cmd {
 		util.CmdHandler(chat.SendMsg{
 			Text:        value,
 			Attachments: attachments,
+			Plan:        m.planMode,
 		}),
 	)
 }
 
+// pasteImage attaches the image in the system clipboard.
+func (m *editorCmp) pasteImage() tea.Msg {
+	ctx, cancel := context.WithTimeout(context.Background(), clipboardTimeout)
+	defer cancel()
+	data, err := clipboard.ReadImage(ctx)
+	if err != nil {
+		return util.InfoMsg{Type: util.InfoTypeWarn, Msg: err.Error()}
+	}
+	attachment, err := clipboardAttachment(data, time.Now())
+	if err != nil {
+		return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+	}
+	return filepicker.FilePickedMsg{Attachment: attachment}
+}
+
+// clipboardAttachment names pasted image data and checks it is an image the
+// models accept, within the attachment size limit.
+func clipboardAttachment(data []byte, now time.Time) (message.Attachment, error) {
+	mimeType := message.DetectMimeType("", data)
+	if !message.IsImageMimeType(mimeType) {
+		return message.Attachment{}, fmt.Errorf("clipboard holds %s, not a supported image", mimeType)
+	}
+	if int64(len(data)) > filepicker.MaxAttachmentSize {
+		return message.Attachment{}, fmt.Errorf("clipboard image too large, max %dMB", filepicker.MaxAttachmentSize/(1024*1024))
+	}
+	name := fmt.Sprintf("clipboard-%s.%s", now.Format("20060102-150405"), strings.TrimPrefix(mimeType, "image/"))
+	return message.NewAttachment(name, name, data), nil
+}
+
+// mentionAgent addresses the prompt value to agentID, replacing the agent
+// it was addressed to, if any.
+func mentionAgent(value, agentID string) string {
+	rest := strings.TrimLeftFunc(value, unicode.IsSpace)
+	if strings.HasPrefix(rest, "@") {
+		if i := strings.IndexFunc(rest, unicode.IsSpace); i >= 0 {
+			rest = strings.TrimLeftFunc(rest[i:], unicode.IsSpace)
+		} else {
+			rest = ""
+		}
+	}
+	return "@" + agentID + " " + rest
+}
+
 func (m *editorCmp) repositionCompletions() tea.Msg {
 	x, y := m.completionsPosition()
 	return completions.RepositionCompletionsMsg{X: x, Y: y}
@@ -240,6 +299,10 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	case OpenEditorMsg:
 		m.textarea.SetValue(msg.Text)
 		m.textarea.MoveToEnd()
+	case agents.MentionAgentMsg:
+		m.textarea.SetValue(mentionAgent(m.textarea.Value(), msg.ID))
+		m.textarea.MoveToEnd()
+		return m, m.Focus()
 	case tea.PasteMsg:
 		path := strings.ReplaceAll(msg.Content, "\\ ", " ")
 		// try to get an image
@@ -265,15 +328,13 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			return m, cmd
 		}
 
-		content, err := os.ReadFile(path)
+		// The file is attached whatever its content turns out to be, as
+		// long as it is a type the models accept.
+		attachment, err := message.ReadAttachment(path)
 		if err != nil {
 			m.textarea, cmd = m.textarea.Update(msg)
 			return m, cmd
 		}
-		mimeBufferSize := min(512, len(content))
-		mimeType := http.DetectContentType(content[:mimeBufferSize])
-		fileName := filepath.Base(path)
-		attachment := message.Attachment{FilePath: path, FileName: fileName, MimeType: mimeType, Content: content}
 		return m, util.CmdHandler(filepicker.FilePickedMsg{
 			Attachment: attachment,
 		})
@@ -281,7 +342,24 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	case commands.ToggleYoloModeMsg:
 		m.setEditorPrompt()
 		return m, nil
+	case commands.TogglePlanModeMsg:
+		m.setPlanMode(!m.planMode)
+		return m, nil
+	case plan.PlanResponseMsg:
+		// Executing an approved plan needs the full set of tools again.
+		if msg.Approved && msg.SessionID == m.session.ID {
+			m.setPlanMode(false)
+		}
+		return m, nil
+	case styles.ThemeChangedMsg:
+		m.textarea.SetStyles(styles.CurrentTheme().S().TextArea)
+		return m, nil
 	case tea.KeyPressMsg:
+		if handled, cmd := m.handleVimKey(msg); handled {
+			return m, cmd
+		} else if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 		cur := m.textarea.Cursor()
 		curIdx := m.textarea.Width()*cur.Y + cur.X
 		switch {
@@ -323,6 +401,13 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 				return m, nil
 			}
 		}
+		if key.Matches(msg, m.keyMap.PasteImage) {
+			return m, m.pasteImage
+		}
+		if key.Matches(msg, m.keyMap.PlanMode) {
+			m.setPlanMode(!m.planMode)
+			return m, nil
+		}
 		if key.Matches(msg, m.keyMap.OpenEditor) {
 			if m.app.AgentCoordinator.IsSessionBusy(m.session.ID) {
 				return m, util.ReportWarn("Agent is working, please wait...")
@@ -398,7 +483,82 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// syncVimMode turns the vim layer on or off to follow the setting and
+// returns a command updating the mode shown in the status bar.
+func (m *editorCmp) syncVimMode() tea.Cmd {
+	enabled := m.app != nil && m.app.GetVimMode()
+	switch {
+	case enabled && m.vim == nil:
+		m.vim = newVim()
+	case !enabled:
+		m.vim = nil
+	}
+	label := ""
+	if m.vim != nil {
+		label = formatVimMode(m.vim.Mode(), m.vim.Pending())
+	}
+	if label == m.vimStatus {
+		return nil
+	}
+	m.vimStatus = label
+	return util.CmdHandler(status.ModeMsg{Mode: label})
+}
+
+// handleVimKey passes a key to the vim layer. It reports whether the key
+// was consumed; keys that are not are handled by the editor as usual.
+func (m *editorCmp) handleVimKey(msg tea.KeyPressMsg) (bool, tea.Cmd) {
+	cmd := m.syncVimMode()
+	if m.vim == nil || m.isCompletionsOpen || m.deleteMode {
+		return false, cmd
+	}
+	b := m.vimBuffer()
+	if !m.vim.HandleKey(b, vimKeyName(msg.Text, msg.Keystroke())) {
+		return false, cmd
+	}
+	m.setVimBuffer(b)
+	return true, m.syncVimMode()
+}
+
+// vimBuffer returns the textarea content with the cursor as a rune offset.
+func (m *editorCmp) vimBuffer() *vimBuffer {
+	lines := strings.Split(m.textarea.Value(), "\n")
+	cursor := 0
+	for _, line := range lines[:min(m.textarea.Line(), len(lines))] {
+		cursor += len([]rune(line)) + 1
+	}
+	li := m.textarea.LineInfo()
+	cursor += li.StartColumn + li.ColumnOffset
+	return &vimBuffer{text: []rune(m.textarea.Value()), cursor: cursor}
+}
+
+// setVimBuffer writes b back to the textarea if it changed and moves the
+// cursor.
+func (m *editorCmp) setVimBuffer(b *vimBuffer) {
+	if text := string(b.text); text != m.textarea.Value() {
+		m.textarea.SetValue(text)
+	}
+	row := b.lineOf(b.cursor)
+	m.textarea.MoveToBegin()
+	// CursorDown moves by wrapped rows, so a line may take several steps.
+	for range len(b.text) {
+		if m.textarea.Line() >= row {
+			break
+		}
+		m.textarea.CursorDown()
+	}
+	m.textarea.SetCursorColumn(b.cursor - b.lineStart(b.cursor))
+}
+
+func (m *editorCmp) setPlanMode(on bool) {
+	m.planMode = on
+	m.setEditorPrompt()
+}
+
 func (m *editorCmp) setEditorPrompt() {
+	if m.planMode {
+		m.textarea.SetPromptFunc(4, planPromptFunc)
+		return
+	}
 	if m.app.Permissions.SkipRequests() {
 		m.textarea.SetPromptFunc(4, yoloPromptFunc)
 		return
@@ -449,7 +609,7 @@ func (m *editorCmp) randomizePlaceholders() {
 func (m *editorCmp) View() string {
 	t := styles.CurrentTheme()
 	// Update placeholder
-	if m.app.AgentCoordinator != nil && m.app.AgentCoordinator.IsBusy() {
+	if m.app.AgentCoordinator != nil && m.app.AgentCoordinator.IsSessionBusy(m.session.ID) {
 		m.textarea.Placeholder = m.workingPlaceholder
 	} else {
 		m.textarea.Placeholder = m.readyPlaceholder
@@ -457,6 +617,9 @@ func (m *editorCmp) View() string {
 	if m.app.Permissions.SkipRequests() {
 		m.textarea.Placeholder = "Yolo mode!"
 	}
+	if m.planMode {
+		m.textarea.Placeholder = "Plan mode: describe what to plan"
+	}
 	if len(m.attachments) == 0 {
 		content := t.S().Base.Padding(1).Render(
 			m.textarea.View(),
@@ -600,6 +763,21 @@ func yoloPromptFunc(info textarea.PromptInfo) string {
 	return fmt.Sprintf("%s ", t.YoloDotsBlurred)
 }
 
+func planPromptFunc(info textarea.PromptInfo) string {
+	t := styles.CurrentTheme()
+	if info.LineNumber == 0 {
+		icon := t.S().Base.Foreground(t.BgBase).Background(t.Info).Bold(true)
+		if !info.Focused {
+			icon = icon.Background(t.FgSubtle)
+		}
+		return icon.Render(" P ") + " "
+	}
+	if info.Focused {
+		return t.S().Base.Foreground(t.Info).Render("::: ")
+	}
+	return t.S().Muted.Render("::: ")
+}
+
 func New(app *app.App) Editor {
 	t := styles.CurrentTheme()
 	ta := textarea.New()
@@ -613,7 +791,7 @@ func New(app *app.App) Editor {
 		// Component manages editor state without direct app coupling
 		app:      app,
 		textarea: ta,
-		keyMap:   DefaultEditorKeyMap(),
+		keyMap:   keymap.Apply(keymap.ScopeEditor, DefaultEditorKeyMap()),
 	}
 	e.setEditorPrompt()
 
@@ -1,7 +1,9 @@
 package editor
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"charm.land/bubbles/v2/textarea"
 	tea "charm.land/bubbletea/v2"
@@ -9,9 +11,11 @@ import (
 	"github.com/nexora/nexora/internal/message"
 	"github.com/nexora/nexora/internal/permission"
 	"github.com/nexora/nexora/internal/session"
+	"github.com/nexora/nexora/internal/tui/components/chat"
 	"github.com/nexora/nexora/internal/tui/components/completions"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/commands"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/filepicker"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/plan"
 )
 
 // createTestApp creates a minimal app instance for testing
@@ -707,3 +711,83 @@ func TestEditorToggleYoloModeMsg(t *testing.T) {
 		t.Log("ToggleYoloModeMsg may return a command")
 	}
 }
+
+// TestEditorPlanMode tests toggling plan mode and sending in plan mode
+func TestEditorPlanMode(t *testing.T) {
+	testApp := createTestApp()
+	editor := New(testApp).(*editorCmp)
+	editor.SetSize(80, 10)
+	editor.SetSession(session.Session{ID: "s1"})
+
+	editor.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyTab, Mod: tea.ModShift}))
+	if !editor.planMode {
+		t.Fatal("Expected shift+tab to turn plan mode on")
+	}
+	if !strings.Contains(editor.View(), "Plan mode") {
+		t.Error("Expected plan mode placeholder")
+	}
+
+	editor.textarea.SetValue("Plan the refactor")
+	msg := editor.send()()
+	send, ok := msg.(chat.SendMsg)
+	if !ok {
+		t.Fatalf("Expected SendMsg, got %T", msg)
+	}
+	if !send.Plan {
+		t.Error("Expected message to be sent in plan mode")
+	}
+
+	editor.Update(plan.PlanResponseMsg{SessionID: "s1", Approved: false})
+	if !editor.planMode {
+		t.Error("Expected plan mode to stay on after a rejected plan")
+	}
+	editor.Update(plan.PlanResponseMsg{SessionID: "s1", Approved: true})
+	if editor.planMode {
+		t.Error("Expected plan mode to turn off after an approved plan")
+	}
+
+	editor.Update(commands.TogglePlanModeMsg{})
+	if !editor.planMode {
+		t.Error("Expected TogglePlanModeMsg to turn plan mode on")
+	}
+}
+
+// TestClipboardAttachment tests naming and validating pasted images
+func TestClipboardAttachment(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	attachment, err := clipboardAttachment(png, now)
+	if err != nil {
+		t.Fatalf("Expected image to be attached, got %v", err)
+	}
+	if attachment.FileName != "clipboard-20261018-093000.png" || attachment.MimeType != "image/png" {
+		t.Errorf("Unexpected attachment %s (%s)", attachment.FileName, attachment.MimeType)
+	}
+
+	if _, err := clipboardAttachment([]byte("just text"), now); err == nil {
+		t.Error("Expected text in the clipboard to be rejected")
+	}
+
+	big := append(png, make([]byte, filepicker.MaxAttachmentSize)...)
+	if _, err := clipboardAttachment(big, now); err == nil {
+		t.Error("Expected images over the size limit to be rejected")
+	}
+}
+
+func TestMentionAgent(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", "@docs "},
+		{"write the docs", "@docs write the docs"},
+		{"@reviewer check this", "@docs check this"},
+		{"  @reviewer", "@docs "},
+	}
+	for _, tt := range tests {
+		if got := mentionAgent(tt.value, "docs"); got != tt.want {
+			t.Errorf("mentionAgent(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
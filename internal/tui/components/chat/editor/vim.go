@@ -0,0 +1,1172 @@
+package editor
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// VimMode is the mode of the vim editing layer.
+type VimMode string
+
+const (
+	VimModeInsert     VimMode = "insert"
+	VimModeNormal     VimMode = "normal"
+	VimModeVisual     VimMode = "visual"
+	VimModeVisualLine VimMode = "visual-line"
+)
+
+// Label returns the text shown in the status bar for the mode.
+func (m VimMode) Label() string {
+	switch m {
+	case VimModeNormal:
+		return "NORMAL"
+	case VimModeVisual:
+		return "VISUAL"
+	case VimModeVisualLine:
+		return "V-LINE"
+	default:
+		return "INSERT"
+	}
+}
+
+const (
+	unnamedRegister   = '"'
+	yankRegister      = '0'
+	blackHoleRegister = '_'
+	maxVimCount       = 9999
+	maxUndoLevels     = 100
+)
+
+// vimBuffer is the text being edited as runes, with the cursor as an offset
+// into it. Lines are separated by '\n'.
+type vimBuffer struct {
+	text   []rune
+	cursor int
+}
+
+type vimRegister struct {
+	text     string
+	linewise bool
+}
+
+type vimSnapshot struct {
+	text   string
+	cursor int
+}
+
+// vim implements modal editing on top of a vimBuffer. Insert mode typing is
+// left to the textarea; vim only handles the keys that change modes and the
+// normal and visual mode commands.
+type vim struct {
+	mode      VimMode
+	pending   []string
+	registers map[rune]vimRegister
+	undo      []vimSnapshot
+	redo      []vimSnapshot
+	// visualStart is the other end of the selection in visual modes.
+	visualStart int
+	// insertUndo is set when entering insert mode pushed an undo snapshot
+	// that should be dropped if nothing is typed.
+	insertUndo bool
+}
+
+func newVim() *vim {
+	return &vim{
+		mode:      VimModeInsert,
+		registers: make(map[rune]vimRegister),
+	}
+}
+
+// Mode returns the current mode.
+func (v *vim) Mode() VimMode {
+	return v.mode
+}
+
+// Pending returns the keys of an incomplete command, e.g. "2d".
+func (v *vim) Pending() string {
+	return strings.Join(v.pending, "")
+}
+
+// Reset returns to insert mode and forgets the undo history, e.g. after the
+// message was sent. Registers are kept.
+func (v *vim) Reset() {
+	v.mode = VimModeInsert
+	v.pending = nil
+	v.undo = nil
+	v.redo = nil
+	v.insertUndo = false
+}
+
+// HandleKey processes a key and reports whether it was consumed. Keys are
+// single characters for printable input or names such as "esc" and
+// "ctrl+r".
+func (v *vim) HandleKey(b *vimBuffer, key string) bool {
+	switch v.mode {
+	case VimModeInsert:
+		if key != "esc" {
+			return false
+		}
+		v.leaveInsert(b)
+		return true
+	case VimModeVisual, VimModeVisualLine:
+		return v.handleVisual(b, key)
+	default:
+		return v.handleNormal(b, key)
+	}
+}
+
+func (v *vim) leaveInsert(b *vimBuffer) {
+	if v.insertUndo && len(v.undo) > 0 && v.undo[len(v.undo)-1].text == string(b.text) {
+		v.undo = v.undo[:len(v.undo)-1]
+	}
+	v.insertUndo = false
+	v.mode = VimModeNormal
+	if b.cursor > b.lineStart(b.cursor) {
+		b.cursor--
+	}
+	b.clampNormal()
+}
+
+func (v *vim) enterInsert(b *vimBuffer, pos int, saveUndo bool) {
+	if saveUndo {
+		v.saveUndo(b)
+		v.insertUndo = true
+	}
+	b.cursor = min(max(pos, 0), len(b.text))
+	v.mode = VimModeInsert
+}
+
+func (v *vim) saveUndo(b *vimBuffer) {
+	v.undo = append(v.undo, vimSnapshot{text: string(b.text), cursor: b.cursor})
+	if len(v.undo) > maxUndoLevels {
+		v.undo = v.undo[1:]
+	}
+	v.redo = nil
+}
+
+func (v *vim) restore(b *vimBuffer, from, to *[]vimSnapshot, count int) {
+	for range count {
+		if len(*from) == 0 {
+			return
+		}
+		s := (*from)[len(*from)-1]
+		*from = (*from)[:len(*from)-1]
+		*to = append(*to, vimSnapshot{text: string(b.text), cursor: b.cursor})
+		b.text = []rune(s.text)
+		b.cursor = s.cursor
+		b.clampNormal()
+	}
+}
+
+// vimCommand is a parsed normal or visual mode command.
+type vimCommand struct {
+	register rune
+	count    int
+	op       string
+	motion   string
+	arg      string
+}
+
+func (c vimCommand) times() int {
+	return max(c.count, 1)
+}
+
+type parseResult int
+
+const (
+	parseIncomplete parseResult = iota
+	parseComplete
+	parseInvalid
+)
+
+var (
+	vimOperators = []string{"d", "c", "y"}
+	vimMotions   = []string{
+		"h", "j", "k", "l", "w", "b", "e", "W", "B", "E", "0", "^", "$", "G",
+		"left", "right", "up", "down", "home", "end", "space", "backspace",
+	}
+	// vimCharMotions take a character argument.
+	vimCharMotions = []string{"f", "F", "t", "T"}
+	vimActions     = []string{
+		"i", "a", "I", "A", "o", "O", "x", "X", "s", "S", "D", "C", "Y",
+		"p", "P", "u", "ctrl+r", "v", "V", "J", "~", "esc",
+	}
+	vimTextObjects = []string{"w", "W", "\"", "'", "`", "(", ")", "b", "[", "]", "{", "}", "B", "<", ">"}
+)
+
+// parseVimCommand parses keys typed in normal or visual mode. In visual
+// mode operators apply to the selection and "i"/"a" select text objects.
+func parseVimCommand(keys []string, visual bool) (vimCommand, parseResult) {
+	var cmd vimCommand
+	i := 0
+	next := func() (string, bool) {
+		if i >= len(keys) {
+			return "", false
+		}
+		k := keys[i]
+		i++
+		return k, true
+	}
+	readCount := func() int {
+		n := 0
+		for i < len(keys) {
+			k := keys[i]
+			if len(k) != 1 || k[0] < '0' || k[0] > '9' || (k == "0" && n == 0) {
+				break
+			}
+			n = min(n*10+int(k[0]-'0'), maxVimCount)
+			i++
+		}
+		return n
+	}
+
+	k, ok := next()
+	if !ok {
+		return cmd, parseIncomplete
+	}
+	if k == "\"" {
+		r, ok := next()
+		if !ok {
+			return cmd, parseIncomplete
+		}
+		reg := []rune(r)
+		if len(reg) != 1 || !(unicode.IsLetter(reg[0]) || unicode.IsDigit(reg[0]) || reg[0] == unnamedRegister || reg[0] == blackHoleRegister) {
+			return cmd, parseInvalid
+		}
+		cmd.register = reg[0]
+	} else {
+		i--
+	}
+
+	cmd.count = readCount()
+	k, ok = next()
+	if !ok {
+		return cmd, parseIncomplete
+	}
+
+	if slices.Contains(vimOperators, k) {
+		cmd.op = k
+		if visual {
+			return cmd, parseComplete
+		}
+		if n := readCount(); n > 0 {
+			cmd.count = max(cmd.count, 1) * n
+		}
+		k, ok = next()
+		if !ok {
+			return cmd, parseIncomplete
+		}
+		if k == cmd.op {
+			cmd.motion = "line"
+			return cmd, parseComplete
+		}
+		if k == "i" || k == "a" {
+			obj, ok := next()
+			if !ok {
+				return cmd, parseIncomplete
+			}
+			if !slices.Contains(vimTextObjects, obj) {
+				return cmd, parseInvalid
+			}
+			cmd.motion, cmd.arg = k, obj
+			return cmd, parseComplete
+		}
+		return parseVimMotion(cmd, k, next)
+	}
+
+	if visual && (k == "i" || k == "a") {
+		obj, ok := next()
+		if !ok {
+			return cmd, parseIncomplete
+		}
+		if !slices.Contains(vimTextObjects, obj) {
+			return cmd, parseInvalid
+		}
+		cmd.motion, cmd.arg = k, obj
+		return cmd, parseComplete
+	}
+	if k == "r" {
+		r, ok := next()
+		if !ok {
+			return cmd, parseIncomplete
+		}
+		if len([]rune(r)) != 1 {
+			return cmd, parseInvalid
+		}
+		cmd.op, cmd.arg = "r", r
+		return cmd, parseComplete
+	}
+	if slices.Contains(vimActions, k) {
+		cmd.op = k
+		return cmd, parseComplete
+	}
+	return parseVimMotion(cmd, k, next)
+}
+
+func parseVimMotion(cmd vimCommand, k string, next func() (string, bool)) (vimCommand, parseResult) {
+	switch {
+	case k == "g":
+		k2, ok := next()
+		if !ok {
+			return cmd, parseIncomplete
+		}
+		if k2 != "g" {
+			return cmd, parseInvalid
+		}
+		cmd.motion = "gg"
+	case slices.Contains(vimCharMotions, k):
+		r, ok := next()
+		if !ok {
+			return cmd, parseIncomplete
+		}
+		if r == "space" {
+			r = " "
+		}
+		if len([]rune(r)) != 1 {
+			return cmd, parseInvalid
+		}
+		cmd.motion, cmd.arg = k, r
+	case slices.Contains(vimMotions, k):
+		cmd.motion = k
+	default:
+		return cmd, parseInvalid
+	}
+	return cmd, parseComplete
+}
+
+func (v *vim) handleNormal(b *vimBuffer, key string) bool {
+	// Leave keys such as ctrl+c or enter to the editor unless they are part
+	// of a command.
+	if len(v.pending) == 0 && isPassthroughKey(key) {
+		return false
+	}
+	v.pending = append(v.pending, key)
+	cmd, res := parseVimCommand(v.pending, false)
+	switch res {
+	case parseIncomplete:
+		return true
+	case parseInvalid:
+		v.pending = nil
+		return true
+	}
+	v.pending = nil
+	v.execNormal(b, cmd)
+	return true
+}
+
+func (v *vim) handleVisual(b *vimBuffer, key string) bool {
+	if len(v.pending) == 0 && isPassthroughKey(key) {
+		return false
+	}
+	v.pending = append(v.pending, key)
+	cmd, res := parseVimCommand(v.pending, true)
+	switch res {
+	case parseIncomplete:
+		return true
+	case parseInvalid:
+		v.pending = nil
+		return true
+	}
+	v.pending = nil
+	v.execVisual(b, cmd)
+	return true
+}
+
+// isPassthroughKey reports whether a key not bound in normal mode should
+// reach the editor, e.g. enter to send or ctrl shortcuts.
+func isPassthroughKey(key string) bool {
+	if key == "ctrl+r" {
+		return false
+	}
+	return key == "enter" || key == "tab" || strings.HasPrefix(key, "ctrl+") || strings.HasPrefix(key, "alt+")
+}
+
+func (v *vim) execNormal(b *vimBuffer, cmd vimCommand) {
+	n := cmd.times()
+	switch cmd.op {
+	case "":
+		if target, _, _, ok := v.motion(b, cmd, false); ok {
+			b.cursor = target
+			b.clampNormal()
+		}
+	case "d", "c", "y":
+		v.operate(b, cmd)
+	case "esc":
+	case "i":
+		v.enterInsert(b, b.cursor, true)
+	case "a":
+		pos := b.cursor
+		if pos < b.lineEnd(pos) {
+			pos++
+		}
+		v.enterInsert(b, pos, true)
+	case "I":
+		v.enterInsert(b, b.firstNonBlank(b.cursor), true)
+	case "A":
+		v.enterInsert(b, b.lineEnd(b.cursor), true)
+	case "o":
+		v.saveUndo(b)
+		v.insertUndo = true
+		end := b.lineEnd(b.cursor)
+		b.insert(end, "\n")
+		v.enterInsert(b, end+1, false)
+	case "O":
+		v.saveUndo(b)
+		v.insertUndo = true
+		start := b.lineStart(b.cursor)
+		b.insert(start, "\n")
+		v.enterInsert(b, start, false)
+	case "x", "X", "s", "D", "C", "Y", "S":
+		expanded := map[string]vimCommand{
+			"x": {op: "d", motion: "l"},
+			"X": {op: "d", motion: "h"},
+			"s": {op: "c", motion: "l"},
+			"D": {op: "d", motion: "$"},
+			"C": {op: "c", motion: "$"},
+			"Y": {op: "y", motion: "line"},
+			"S": {op: "c", motion: "line"},
+		}[cmd.op]
+		expanded.register, expanded.count = cmd.register, cmd.count
+		v.operate(b, expanded)
+	case "p", "P":
+		v.paste(b, cmd.register, n, cmd.op == "P")
+	case "u":
+		v.restore(b, &v.undo, &v.redo, n)
+	case "ctrl+r":
+		v.restore(b, &v.redo, &v.undo, n)
+	case "v":
+		v.mode = VimModeVisual
+		v.visualStart = b.cursor
+	case "V":
+		v.mode = VimModeVisualLine
+		v.visualStart = b.cursor
+	case "r":
+		end := b.cursor + n
+		if end > b.lineEnd(b.cursor) {
+			return
+		}
+		v.saveUndo(b)
+		r := []rune(cmd.arg)[0]
+		for i := b.cursor; i < end; i++ {
+			b.text[i] = r
+		}
+		b.cursor = end - 1
+	case "J":
+		v.saveUndo(b)
+		for range max(n-1, 1) {
+			end := b.lineEnd(b.cursor)
+			if end >= len(b.text) {
+				break
+			}
+			next := end + 1
+			for next < len(b.text) && (b.text[next] == ' ' || b.text[next] == '\t') {
+				next++
+			}
+			sep := " "
+			if next >= len(b.text) || b.text[next] == '\n' || (end > b.lineStart(end) && b.text[end-1] == ' ') {
+				sep = ""
+			}
+			b.replace(end, next, sep)
+			b.cursor = end
+		}
+	case "~":
+		end := min(b.cursor+n, b.lineEnd(b.cursor))
+		if end <= b.cursor {
+			return
+		}
+		v.saveUndo(b)
+		for i := b.cursor; i < end; i++ {
+			r := b.text[i]
+			if unicode.IsUpper(r) {
+				b.text[i] = unicode.ToLower(r)
+			} else {
+				b.text[i] = unicode.ToUpper(r)
+			}
+		}
+		b.cursor = end
+		b.clampNormal()
+	}
+}
+
+func (v *vim) execVisual(b *vimBuffer, cmd vimCommand) {
+	switch cmd.op {
+	case "":
+		if cmd.motion == "i" || cmd.motion == "a" {
+			if start, end, ok := b.textObject(cmd.motion == "a", cmd.arg); ok && end > start {
+				v.visualStart = start
+				b.cursor = end - 1
+			}
+			return
+		}
+		if target, _, _, ok := v.motion(b, cmd, false); ok {
+			b.cursor = target
+			b.clampNormal()
+		}
+	case "esc":
+		v.mode = VimModeNormal
+	case "v", "V":
+		mode := VimModeVisual
+		if cmd.op == "V" {
+			mode = VimModeVisualLine
+		}
+		if v.mode == mode {
+			v.mode = VimModeNormal
+		} else {
+			v.mode = mode
+		}
+	case "d", "x", "c", "s", "y", "D", "X", "Y", "C", "S":
+		start, end := v.selection(b)
+		linewise := v.mode == VimModeVisualLine || strings.Contains("DXYCS", cmd.op)
+		op := map[string]string{"x": "d", "X": "d", "D": "d", "s": "c", "S": "c", "C": "c", "Y": "y"}[cmd.op]
+		if op == "" {
+			op = cmd.op
+		}
+		v.mode = VimModeNormal
+		v.apply(b, op, cmd.register, start, end, linewise)
+	case "p", "P":
+		start, end := v.selection(b)
+		linewise := v.mode == VimModeVisualLine
+		v.mode = VimModeNormal
+		reg, ok := v.register(cmd.register)
+		if !ok {
+			return
+		}
+		v.saveUndo(b)
+		if linewise {
+			start, end, _ = b.lineRange(start, end)
+		}
+		text := reg.text
+		if reg.linewise && !linewise {
+			text = strings.TrimSuffix(text, "\n")
+		}
+		if linewise && !reg.linewise {
+			text += "\n"
+		}
+		b.replace(start, end, strings.Repeat(text, cmd.times()))
+		b.cursor = start
+		b.clampNormal()
+	default:
+		if cmd.op == "u" || cmd.op == "~" || cmd.op == "J" || cmd.op == "r" {
+			v.mode = VimModeNormal
+			v.execNormal(b, cmd)
+		}
+	}
+}
+
+// selection returns the selected range as [start, end).
+func (v *vim) selection(b *vimBuffer) (int, int) {
+	start, end := v.visualStart, b.cursor
+	if start > end {
+		start, end = end, start
+	}
+	return start, min(end+1, len(b.text))
+}
+
+// Selection returns the selected range as [start, end) in visual modes.
+func (v *vim) Selection(b *vimBuffer) (int, int, bool) {
+	switch v.mode {
+	case VimModeVisual:
+		start, end := v.selection(b)
+		return start, end, true
+	case VimModeVisualLine:
+		start, end := v.selection(b)
+		start, end, _ = b.lineRange(start, end)
+		return start, end, true
+	}
+	return 0, 0, false
+}
+
+// motion returns where a motion moves the cursor, and whether the range it
+// covers is inclusive or linewise when used with an operator.
+func (v *vim) motion(b *vimBuffer, cmd vimCommand, forOperator bool) (target int, inclusive, linewise bool, ok bool) {
+	n := cmd.times()
+	c := b.cursor
+	switch cmd.motion {
+	case "h", "left", "backspace":
+		return max(b.lineStart(c), c-n), false, false, true
+	case "l", "right", "space":
+		limit := b.lineEnd(c)
+		if !forOperator {
+			limit = max(limit-1, b.lineStart(c))
+		}
+		return min(limit, c+n), false, false, true
+	case "j", "down":
+		return b.moveLines(c, n), false, true, true
+	case "k", "up":
+		return b.moveLines(c, -n), false, true, true
+	case "w", "W":
+		target := c
+		for range n {
+			target = b.wordForward(target, cmd.motion == "W")
+		}
+		if forOperator && b.lineOf(target) > b.lineOf(c) {
+			// dw on the last word of a line does not join lines.
+			target = max(b.lineEnd(c), c)
+		}
+		return target, false, false, true
+	case "e", "E":
+		target := c
+		for range n {
+			target = b.wordEnd(target, cmd.motion == "E")
+		}
+		return target, true, false, true
+	case "b", "B":
+		target := c
+		for range n {
+			target = b.wordBackward(target, cmd.motion == "B")
+		}
+		return target, false, false, true
+	case "0", "home":
+		return b.lineStart(c), false, false, true
+	case "^":
+		return b.firstNonBlank(c), false, false, true
+	case "$", "end":
+		target := b.lineEnd(b.moveLines(c, n-1))
+		if target > b.lineStart(target) {
+			target--
+		}
+		return target, true, false, true
+	case "gg", "G":
+		line := 0
+		if cmd.count > 0 {
+			line = cmd.count - 1
+		} else if cmd.motion == "G" {
+			line = b.lineCount() - 1
+		}
+		return b.firstNonBlank(b.lineOffset(line)), false, true, true
+	case "f", "t":
+		r := []rune(cmd.arg)[0]
+		pos := c
+		for range n {
+			start := pos + 1
+			if cmd.motion == "t" && pos == c {
+				start = pos + 2
+			}
+			found := -1
+			for i := start; i < b.lineEnd(c); i++ {
+				if b.text[i] == r {
+					found = i
+					break
+				}
+			}
+			if found < 0 {
+				return c, false, false, false
+			}
+			pos = found
+		}
+		if cmd.motion == "t" {
+			pos--
+		}
+		return pos, true, false, true
+	case "F", "T":
+		r := []rune(cmd.arg)[0]
+		pos := c
+		for range n {
+			start := pos - 1
+			if cmd.motion == "T" && pos == c {
+				start = pos - 2
+			}
+			found := -1
+			for i := start; i >= b.lineStart(c); i-- {
+				if b.text[i] == r {
+					found = i
+					break
+				}
+			}
+			if found < 0 {
+				return c, false, false, false
+			}
+			pos = found
+		}
+		if cmd.motion == "T" {
+			pos++
+		}
+		return pos, false, false, true
+	}
+	return c, false, false, false
+}
+
+// operate applies an operator with a motion or text object.
+func (v *vim) operate(b *vimBuffer, cmd vimCommand) {
+	var start, end int
+	linewise := false
+	switch cmd.motion {
+	case "line":
+		start = b.cursor
+		end = b.moveLines(b.cursor, cmd.times()-1)
+		linewise = true
+	case "i", "a":
+		var ok bool
+		start, end, ok = b.textObject(cmd.motion == "a", cmd.arg)
+		if !ok {
+			return
+		}
+	default:
+		if cmd.op == "c" && (cmd.motion == "w" || cmd.motion == "W") && b.cursor < len(b.text) && !unicode.IsSpace(b.text[b.cursor]) {
+			// cw behaves like ce.
+			cmd.motion = strings.ToLower(cmd.motion)
+			if cmd.motion == "w" {
+				cmd.motion = "e"
+			} else {
+				cmd.motion = "E"
+			}
+		}
+		target, inclusive, lw, ok := v.motion(b, cmd, true)
+		if !ok {
+			return
+		}
+		start, end, linewise = min(b.cursor, target), max(b.cursor, target), lw
+		if inclusive && !linewise {
+			end = min(end+1, len(b.text))
+		}
+	}
+	if linewise {
+		// apply takes an exclusive end.
+		start, end = min(start, end), max(start, end)+1
+	}
+	v.apply(b, cmd.op, cmd.register, start, end, linewise)
+}
+
+// apply runs op on [start, end), which for linewise ranges may be any
+// offsets within the first and last line.
+func (v *vim) apply(b *vimBuffer, op string, register rune, start, end int, linewise bool) {
+	if linewise {
+		var withNewline int
+		start, end, withNewline = b.lineRange(start, end)
+		text := string(b.text[start:end])
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		switch op {
+		case "y":
+			v.setRegister(register, vimRegister{text: text, linewise: true}, true)
+			if start < b.lineStart(b.cursor) {
+				b.cursor = start
+			}
+		case "d":
+			v.saveUndo(b)
+			v.setRegister(register, vimRegister{text: text, linewise: true}, false)
+			from, to := start, end
+			if withNewline < 0 && from > 0 {
+				// Deleting the last lines also removes the newline before them.
+				from--
+			}
+			b.replace(from, to, "")
+			b.cursor = b.firstNonBlank(min(from, max(len(b.text)-1, 0)))
+			b.clampNormal()
+		case "c":
+			v.saveUndo(b)
+			v.setRegister(register, vimRegister{text: text, linewise: true}, false)
+			contentEnd := end
+			if withNewline >= 0 {
+				contentEnd = withNewline
+			}
+			b.replace(start, contentEnd, "")
+			v.enterInsert(b, start, false)
+		}
+		return
+	}
+
+	if end < start {
+		start, end = end, start
+	}
+	text := string(b.text[start:end])
+	switch op {
+	case "y":
+		v.setRegister(register, vimRegister{text: text}, true)
+		b.cursor = start
+		b.clampNormal()
+	case "d":
+		if start == end {
+			return
+		}
+		v.saveUndo(b)
+		v.setRegister(register, vimRegister{text: text}, false)
+		b.replace(start, end, "")
+		b.cursor = start
+		b.clampNormal()
+	case "c":
+		v.saveUndo(b)
+		v.setRegister(register, vimRegister{text: text}, false)
+		b.replace(start, end, "")
+		v.enterInsert(b, start, false)
+	}
+}
+
+// setRegister stores text like vim does: in the unnamed register and the
+// named one, and for yanks also in register 0. Uppercase names append.
+func (v *vim) setRegister(name rune, reg vimRegister, yank bool) {
+	if name == blackHoleRegister {
+		return
+	}
+	if unicode.IsUpper(name) {
+		lower := unicode.ToLower(name)
+		if prev, ok := v.registers[lower]; ok {
+			reg = vimRegister{text: prev.text + reg.text, linewise: prev.linewise || reg.linewise}
+		}
+		name = lower
+	}
+	if name != 0 && name != unnamedRegister {
+		v.registers[name] = reg
+	}
+	if yank && (name == 0 || name == unnamedRegister) {
+		v.registers[yankRegister] = reg
+	}
+	v.registers[unnamedRegister] = reg
+}
+
+func (v *vim) register(name rune) (vimRegister, bool) {
+	if name == 0 {
+		name = unnamedRegister
+	}
+	reg, ok := v.registers[unicode.ToLower(name)]
+	return reg, ok && reg.text != ""
+}
+
+// Register returns the content of a register, for tests and the status
+// line.
+func (v *vim) Register(name rune) string {
+	reg, _ := v.register(name)
+	return reg.text
+}
+
+func (v *vim) paste(b *vimBuffer, name rune, count int, before bool) {
+	reg, ok := v.register(name)
+	if !ok {
+		return
+	}
+	v.saveUndo(b)
+	text := strings.Repeat(reg.text, count)
+	if reg.linewise {
+		if before {
+			pos := b.lineStart(b.cursor)
+			b.insert(pos, text)
+			b.cursor = b.firstNonBlank(pos)
+			return
+		}
+		end := b.lineEnd(b.cursor)
+		if end >= len(b.text) {
+			b.insert(end, "\n"+strings.TrimSuffix(text, "\n"))
+		} else {
+			b.insert(end+1, text)
+		}
+		b.cursor = b.firstNonBlank(end + 1)
+		return
+	}
+	pos := b.cursor
+	if !before && pos < b.lineEnd(pos) {
+		pos++
+	}
+	b.insert(pos, text)
+	b.cursor = pos + len([]rune(text)) - 1
+	b.clampNormal()
+}
+
+// Buffer helpers.
+
+func (b *vimBuffer) lineStart(p int) int {
+	p = min(p, len(b.text))
+	for p > 0 && b.text[p-1] != '\n' {
+		p--
+	}
+	return p
+}
+
+func (b *vimBuffer) lineEnd(p int) int {
+	for p < len(b.text) && b.text[p] != '\n' {
+		p++
+	}
+	return p
+}
+
+func (b *vimBuffer) lineOf(p int) int {
+	line := 0
+	for i := 0; i < min(p, len(b.text)); i++ {
+		if b.text[i] == '\n' {
+			line++
+		}
+	}
+	return line
+}
+
+func (b *vimBuffer) lineCount() int {
+	return b.lineOf(len(b.text)) + 1
+}
+
+// lineOffset returns the offset of the start of line n, clamped to the
+// buffer.
+func (b *vimBuffer) lineOffset(n int) int {
+	p := 0
+	for line := 0; line < n; line++ {
+		end := b.lineEnd(p)
+		if end >= len(b.text) {
+			break
+		}
+		p = end + 1
+	}
+	return p
+}
+
+func (b *vimBuffer) firstNonBlank(p int) int {
+	p = b.lineStart(p)
+	end := b.lineEnd(p)
+	for p < end && (b.text[p] == ' ' || b.text[p] == '\t') {
+		p++
+	}
+	return p
+}
+
+// moveLines moves the cursor n lines down (or up when negative), keeping
+// the column where possible.
+func (b *vimBuffer) moveLines(p, n int) int {
+	col := p - b.lineStart(p)
+	line := min(max(b.lineOf(p)+n, 0), b.lineCount()-1)
+	start := b.lineOffset(line)
+	return min(start+col, max(b.lineEnd(start)-1, start))
+}
+
+// lineRange expands [start, end) to whole lines. It returns the range
+// including the trailing newline if there is one, and the offset of that
+// newline or -1 when the range ends the buffer.
+func (b *vimBuffer) lineRange(start, end int) (int, int, int) {
+	start = b.lineStart(start)
+	last := max(end-1, start)
+	lineEnd := b.lineEnd(last)
+	if lineEnd < len(b.text) {
+		return start, lineEnd + 1, lineEnd
+	}
+	return start, lineEnd, -1
+}
+
+// clampNormal keeps the cursor on a character, as normal mode does not
+// allow it past the end of a line.
+func (b *vimBuffer) clampNormal() {
+	b.cursor = min(max(b.cursor, 0), len(b.text))
+	if b.cursor > b.lineStart(b.cursor) && b.cursor == b.lineEnd(b.cursor) {
+		b.cursor--
+	}
+}
+
+func (b *vimBuffer) insert(p int, s string) {
+	b.text = slices.Insert(b.text, p, []rune(s)...)
+}
+
+func (b *vimBuffer) replace(start, end int, s string) {
+	b.text = slices.Replace(b.text, start, end, []rune(s)...)
+}
+
+// charClass groups runes into whitespace (0), word characters (1) and
+// punctuation (2). For WORD motions everything but whitespace is one class.
+func charClass(r rune, big bool) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case big || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (b *vimBuffer) class(p int, big bool) int {
+	return charClass(b.text[p], big)
+}
+
+func (b *vimBuffer) wordForward(p int, big bool) int {
+	n := len(b.text)
+	if p >= n {
+		return n
+	}
+	if cls := b.class(p, big); cls != 0 {
+		for p < n && b.class(p, big) == cls {
+			p++
+		}
+	}
+	for p < n && b.class(p, big) == 0 {
+		if b.text[p] == '\n' {
+			p++
+			// An empty line counts as a word.
+			if p < n && b.text[p] == '\n' {
+				break
+			}
+			continue
+		}
+		p++
+	}
+	return p
+}
+
+func (b *vimBuffer) wordEnd(p int, big bool) int {
+	n := len(b.text)
+	p++
+	for p < n && b.class(p, big) == 0 {
+		p++
+	}
+	if p >= n {
+		return max(n-1, 0)
+	}
+	cls := b.class(p, big)
+	for p+1 < n && b.class(p+1, big) == cls {
+		p++
+	}
+	return p
+}
+
+func (b *vimBuffer) wordBackward(p int, big bool) int {
+	if p <= 0 {
+		return 0
+	}
+	p--
+	for p > 0 && b.class(p, big) == 0 {
+		p--
+	}
+	if b.class(p, big) == 0 {
+		return p
+	}
+	cls := b.class(p, big)
+	for p > 0 && b.class(p-1, big) == cls {
+		p--
+	}
+	return p
+}
+
+// textObject returns the [start, end) range of a text object around the
+// cursor.
+func (b *vimBuffer) textObject(around bool, obj string) (int, int, bool) {
+	switch obj {
+	case "w", "W":
+		return b.wordObject(around, obj == "W")
+	case "\"", "'", "`":
+		return b.quoteObject(around, []rune(obj)[0])
+	case "(", ")", "b":
+		return b.bracketObject(around, '(', ')')
+	case "[", "]":
+		return b.bracketObject(around, '[', ']')
+	case "{", "}", "B":
+		return b.bracketObject(around, '{', '}')
+	case "<", ">":
+		return b.bracketObject(around, '<', '>')
+	}
+	return 0, 0, false
+}
+
+func (b *vimBuffer) wordObject(around, big bool) (int, int, bool) {
+	c := b.cursor
+	if c >= len(b.text) || b.text[c] == '\n' {
+		return 0, 0, false
+	}
+	lineStart, lineEnd := b.lineStart(c), b.lineEnd(c)
+	cls := b.class(c, big)
+	start, end := c, c+1
+	for start > lineStart && b.class(start-1, big) == cls {
+		start--
+	}
+	for end < lineEnd && b.class(end, big) == cls {
+		end++
+	}
+	if !around {
+		return start, end, true
+	}
+	trailing := end
+	for trailing < lineEnd && b.class(trailing, big) == 0 {
+		trailing++
+	}
+	if trailing > end {
+		return start, trailing, true
+	}
+	for start > lineStart && b.class(start-1, big) == 0 {
+		start--
+	}
+	return start, end, true
+}
+
+func (b *vimBuffer) quoteObject(around bool, q rune) (int, int, bool) {
+	c := b.cursor
+	lineStart, lineEnd := b.lineStart(c), b.lineEnd(c)
+	var quotes []int
+	for i := lineStart; i < lineEnd; i++ {
+		if b.text[i] == q && (i == lineStart || b.text[i-1] != '\\') {
+			quotes = append(quotes, i)
+		}
+	}
+	for i := 0; i+1 < len(quotes); i += 2 {
+		open, closing := quotes[i], quotes[i+1]
+		if c > closing {
+			continue
+		}
+		if around {
+			return open, closing + 1, true
+		}
+		return open + 1, closing, true
+	}
+	return 0, 0, false
+}
+
+func (b *vimBuffer) bracketObject(around bool, open, closing rune) (int, int, bool) {
+	c := min(b.cursor, len(b.text)-1)
+	if c < 0 {
+		return 0, 0, false
+	}
+	start := -1
+	depth := 0
+	for i := c; i >= 0; i-- {
+		switch b.text[i] {
+		case closing:
+			if i != c {
+				depth++
+			}
+		case open:
+			if depth == 0 {
+				start = i
+			} else {
+				depth--
+			}
+		}
+		if start >= 0 {
+			break
+		}
+	}
+	if start < 0 {
+		return 0, 0, false
+	}
+	depth = 0
+	for i := start + 1; i < len(b.text); i++ {
+		switch b.text[i] {
+		case open:
+			depth++
+		case closing:
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if around {
+				return start, i + 1, true
+			}
+			return start + 1, i, true
+		}
+	}
+	return 0, 0, false
+}
+
+// vimKeyName converts a key press into the name used by vim: the typed
+// text for printable keys and the keystroke otherwise.
+func vimKeyName(text, keystroke string) string {
+	if text != "" {
+		if text == " " {
+			return "space"
+		}
+		return text
+	}
+	return keystroke
+}
+
+// formatVimMode renders the mode and any pending keys for the status bar.
+func formatVimMode(mode VimMode, pending string) string {
+	label := mode.Label()
+	if pending != "" {
+		label += " " + strconv.Quote(pending)[1:len(strconv.Quote(pending))-1]
+	}
+	return label
+}
@@ -0,0 +1,216 @@
+package editor
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// vimKeys splits a key sequence into keys. Named keys are written in angle
+// brackets, e.g. "dw<esc>".
+func vimKeys(seq string) []string {
+	var keys []string
+	runes := []rune(seq)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '<' {
+			if j := slices.Index(runes[i+1:], '>'); j > 0 {
+				keys = append(keys, string(runes[i+1:i+1+j]))
+				i += j + 1
+				continue
+			}
+		}
+		keys = append(keys, string(runes[i]))
+	}
+	return keys
+}
+
+func runVim(t *testing.T, v *vim, text string, cursor int, seq string) *vimBuffer {
+	t.Helper()
+	b := &vimBuffer{text: []rune(text), cursor: cursor}
+	for _, key := range vimKeys(seq) {
+		if !v.HandleKey(b, key) {
+			// Insert mode typing is done by the textarea.
+			require.Equal(t, VimModeInsert, v.Mode(), "key %q not handled", key)
+			b.insert(b.cursor, key)
+			b.cursor++
+		}
+	}
+	return b
+}
+
+func TestVimMotions(t *testing.T) {
+	t.Parallel()
+
+	const text = "foo bar.baz qux\n  second line\nthird"
+	tests := []struct {
+		keys   string
+		cursor int
+	}{
+		{"w", 4},
+		{"2w", 7},
+		{"2W", 12},
+		{"e", 2},
+		{"4e", 10},
+		{"$", 14},
+		{"$0", 0},
+		{"j^", 18},
+		{"G", 30},
+		{"Ggg", 0},
+		{"2G", 18},
+		{"fz", 10},
+		{"tz", 9},
+		{"$Fb", 8},
+		{"$b", 12},
+		{"$B", 12},
+		{"jjk", 16},
+		{"llh", 1},
+	}
+	for _, tt := range tests {
+		v := newVim()
+		v.mode = VimModeNormal
+		b := runVim(t, v, text, 0, tt.keys)
+		require.Equal(t, tt.cursor, b.cursor, "keys %q", tt.keys)
+		require.Equal(t, text, string(b.text))
+	}
+}
+
+func TestVimOperators(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		text   string
+		cursor int
+		keys   string
+		want   string
+		mode   VimMode
+	}{
+		{"delete word", "foo bar baz", 4, "dw", "foo baz", VimModeNormal},
+		{"delete last word", "foo bar\nbaz", 4, "dw", "foo \nbaz", VimModeNormal},
+		{"delete two words", "foo bar baz", 0, "d2w", "baz", VimModeNormal},
+		{"delete to end", "foo bar baz", 4, "D", "foo ", VimModeNormal},
+		{"delete line", "one\ntwo\nthree", 5, "dd", "one\nthree", VimModeNormal},
+		{"delete last line", "one\ntwo", 5, "dd", "one", VimModeNormal},
+		{"delete lines", "one\ntwo\nthree", 0, "2dd", "three", VimModeNormal},
+		{"delete down", "one\ntwo\nthree", 0, "dj", "three", VimModeNormal},
+		{"delete to char", "foo(bar)", 0, "df(", "bar)", VimModeNormal},
+		{"change word", "foo bar", 0, "cwqux<esc>", "qux bar", VimModeNormal},
+		{"change inner word", "foo bar baz", 5, "ciwx<esc>", "foo x baz", VimModeNormal},
+		{"delete around word", "foo bar baz", 5, "daw", "foo baz", VimModeNormal},
+		{"change inner quotes", `say "hello" now`, 6, `ci"bye<esc>`, `say "bye" now`, VimModeNormal},
+		{"delete around parens", "f(a, (b)) + 1", 3, "da(", "f + 1", VimModeNormal},
+		{"change inner braces", "f{a {b}}", 3, "ci{x", "f{x}", VimModeInsert},
+		{"change line", "  one\ntwo", 0, "ccx<esc>", "x\ntwo", VimModeNormal},
+		{"delete char", "abc", 1, "x", "ac", VimModeNormal},
+		{"delete chars", "abcdef", 1, "3x", "aef", VimModeNormal},
+		{"delete before", "abc", 2, "X", "ac", VimModeNormal},
+		{"replace", "abc", 0, "2rz", "zzc", VimModeNormal},
+		{"toggle case", "abc", 0, "2~", "ABc", VimModeNormal},
+		{"join", "one\n  two", 0, "J", "one two", VimModeNormal},
+		{"open below", "one\nthree", 0, "otwo<esc>", "one\ntwo\nthree", VimModeNormal},
+		{"open above", "two", 0, "Oone<esc>", "one\ntwo", VimModeNormal},
+		{"append at end", "foo", 0, "A!<esc>", "foo!", VimModeNormal},
+		{"insert at start", "  foo", 4, "I-<esc>", "  -foo", VimModeNormal},
+		{"append", "fo", 0, "ao<esc>", "foo", VimModeNormal},
+		{"visual delete", "foo bar baz", 4, "ved", "foo  baz", VimModeNormal},
+		{"visual line delete", "one\ntwo\nthree", 4, "Vjd", "one", VimModeNormal},
+		{"visual change", "foo bar", 0, "veclx<esc>", "lx bar", VimModeNormal},
+		{"visual inner word", "foo bar baz", 5, "viwd", "foo  baz", VimModeNormal},
+		{"invalid command", "foo", 0, "dq", "foo", VimModeNormal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			v := newVim()
+			v.mode = VimModeNormal
+			b := runVim(t, v, tt.text, tt.cursor, tt.keys)
+			require.Equal(t, tt.want, string(b.text))
+			require.Equal(t, tt.mode, v.Mode())
+			require.Empty(t, v.Pending())
+		})
+	}
+}
+
+func TestVimRegisters(t *testing.T) {
+	t.Parallel()
+
+	v := newVim()
+	v.mode = VimModeNormal
+	b := runVim(t, v, "one two", 0, "yw$p")
+	require.Equal(t, "one twoone ", string(b.text))
+	require.Equal(t, "one ", v.Register('0'))
+
+	b = runVim(t, v, "one\ntwo", 0, "yyjp")
+	require.Equal(t, "one\ntwo\none", string(b.text))
+
+	b = runVim(t, v, "one\ntwo", 4, "ddP")
+	require.Equal(t, "two\none", string(b.text))
+	require.Equal(t, "one\n", v.Register('0'), "deletes do not change the yank register")
+
+	b = runVim(t, v, "a b c", 0, `"ayw"Ayw"_dw"ap`)
+	require.Equal(t, "ba a  c", string(b.text))
+	require.Equal(t, "a a ", v.Register('a'))
+
+	b = runVim(t, v, "foo bar", 0, `"ayiwwviw"ap`)
+	require.Equal(t, "foo foo", string(b.text))
+}
+
+func TestVimUndoRedo(t *testing.T) {
+	t.Parallel()
+
+	v := newVim()
+	v.mode = VimModeNormal
+	b := runVim(t, v, "one two three", 0, "dwdw")
+	require.Equal(t, "three", string(b.text))
+
+	for _, key := range vimKeys("u") {
+		v.HandleKey(b, key)
+	}
+	require.Equal(t, "two three", string(b.text))
+	for _, key := range vimKeys("u") {
+		v.HandleKey(b, key)
+	}
+	require.Equal(t, "one two three", string(b.text))
+	for _, key := range vimKeys("<ctrl+r>") {
+		v.HandleKey(b, key)
+	}
+	require.Equal(t, "two three", string(b.text))
+
+	// An insert session is undone as one change, and an empty one is not
+	// recorded at all.
+	b = runVim(t, v, "x", 0, "ifoo<esc>i<esc>u")
+	require.Equal(t, "x", string(b.text))
+}
+
+func TestVimModes(t *testing.T) {
+	t.Parallel()
+
+	v := newVim()
+	b := &vimBuffer{text: []rune("hello"), cursor: 5}
+	require.False(t, v.HandleKey(b, "h"), "insert mode leaves typing to the textarea")
+	require.True(t, v.HandleKey(b, "esc"))
+	require.Equal(t, VimModeNormal, v.Mode())
+	require.Equal(t, 4, b.cursor)
+
+	require.False(t, v.HandleKey(b, "enter"), "enter still sends the message")
+	require.False(t, v.HandleKey(b, "ctrl+c"))
+
+	require.True(t, v.HandleKey(b, "2"))
+	require.True(t, v.HandleKey(b, "d"))
+	require.Equal(t, "2d", v.Pending())
+	require.Equal(t, "NORMAL 2d", formatVimMode(v.Mode(), v.Pending()))
+	require.True(t, v.HandleKey(b, "esc"))
+	require.Empty(t, v.Pending())
+
+	require.True(t, v.HandleKey(b, "V"))
+	require.Equal(t, VimModeVisualLine, v.Mode())
+	start, end, ok := v.Selection(b)
+	require.True(t, ok)
+	require.Equal(t, [2]int{0, 5}, [2]int{start, end})
+	require.True(t, v.HandleKey(b, "esc"))
+	require.Equal(t, VimModeNormal, v.Mode())
+
+	v.Reset()
+	require.Equal(t, VimModeInsert, v.Mode())
+}
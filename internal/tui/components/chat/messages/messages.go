@@ -16,6 +16,7 @@ import (
 
 	"github.com/atotto/clipboard"
 	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/format"
 	"github.com/nexora/nexora/internal/message"
 	"github.com/nexora/nexora/internal/tui/components/anim"
 	"github.com/nexora/nexora/internal/tui/components/core"
@@ -413,6 +414,9 @@ func (m *assistantSectionModel) View() string {
 	durationStr := t.S().Subtle.Render(duration.String())
 	timestamp := t.S().Subtle.Render(finishTime.UTC().Format("2006-01-02 15:04:05 MST"))
 	infoMsg := fmt.Sprintf("%s · %s", durationStr, timestamp)
+	if cacheInfo := formatMessageCacheUsage(finishData.Usage); cacheInfo != "" {
+		infoMsg = fmt.Sprintf("%s · %s", infoMsg, t.S().Subtle.Render(cacheInfo))
+	}
 	icon := t.S().Subtle.Render(styles.ModelIcon)
 	model := config.Get().GetModel(m.message.Provider, m.message.Model)
 
@@ -440,6 +444,22 @@ func (m *assistantSectionModel) View() string {
 	)
 }
 
+// formatMessageCacheUsage describes the prompt cache reads and writes of the
+// request that produced a message.
+func formatMessageCacheUsage(usage *message.TokenUsage) string {
+	if usage == nil {
+		return ""
+	}
+	var parts []string
+	if usage.CacheReadTokens > 0 {
+		parts = append(parts, fmt.Sprintf("%s cache read", format.Tokens(usage.CacheReadTokens)))
+	}
+	if usage.CacheCreationTokens > 0 {
+		parts = append(parts, fmt.Sprintf("%s cache write", format.Tokens(usage.CacheCreationTokens)))
+	}
+	return strings.Join(parts, ", ")
+}
+
 func (m *assistantSectionModel) GetSize() (int, int) {
 	return m.width, 1
 }
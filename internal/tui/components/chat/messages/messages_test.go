@@ -290,3 +290,20 @@ func TestMessageCmpEmptyContent(t *testing.T) {
 	// Should handle empty content gracefully
 	_ = cmp.View()
 }
+
+func TestFormatMessageCacheUsage(t *testing.T) {
+	tests := []struct {
+		usage *message.TokenUsage
+		want  string
+	}{
+		{nil, ""},
+		{&message.TokenUsage{InputTokens: 100, OutputTokens: 20}, ""},
+		{&message.TokenUsage{CacheReadTokens: 12_000}, "12K cache read"},
+		{&message.TokenUsage{CacheReadTokens: 900, CacheCreationTokens: 1_500}, "900 cache read, 1.5K cache write"},
+	}
+	for _, tt := range tests {
+		if got := formatMessageCacheUsage(tt.usage); got != tt.want {
+			t.Errorf("formatMessageCacheUsage(%+v) = %q, want %q", tt.usage, got, tt.want)
+		}
+	}
+}
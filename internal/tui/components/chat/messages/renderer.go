@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -188,6 +189,7 @@ func init() {
 	registry.register(tools.EditToolName, func() renderer { return editRenderer{} })
 	registry.register(tools.MultiEditToolName, func() renderer { return multiEditRenderer{} })
 	registry.register(tools.WriteToolName, func() renderer { return writeRenderer{} })
+	registry.register(tools.NotebookEditToolName, func() renderer { return notebookEditRenderer{} })
 	registry.register(tools.FetchToolName, func() renderer { return simpleFetchRenderer{} })
 	registry.register(tools.AgenticFetchToolName, func() renderer { return agenticFetchRenderer{} })
 	registry.register(tools.WebFetchToolName, func() renderer { return webFetchRenderer{} })
@@ -440,6 +442,7 @@ func (vr viewRenderer) Render(v *toolCallCmp) string {
 		addMain(file).
 		addKeyValue("limit", formatNonZero(params.Limit)).
 		addKeyValue("offset", formatNonZero(params.Offset)).
+		addKeyValue("pages", params.Pages).
 		build()
 
 	return vr.renderWithParams(v, "View", args, func() string {
@@ -451,6 +454,11 @@ func (vr viewRenderer) Render(v *toolCallCmp) string {
 		if err := vr.unmarshalParams(v.result.Metadata, &meta); err != nil {
 			return renderPlainContent(v, v.result.Content)
 		}
+		// PDF text and notebook cells are not source code of the file type.
+		switch strings.ToLower(filepath.Ext(meta.FilePath)) {
+		case ".pdf", ".ipynb":
+			return renderPlainContent(v, meta.Content)
+		}
 		return renderCodeContent(v, meta.FilePath, meta.Content, params.Offset)
 	})
 }
@@ -596,6 +604,56 @@ func (wr writeRenderer) Render(v *toolCallCmp) string {
 	})
 }
 
+// -----------------------------------------------------------------------------
+//  Notebook edit renderer
+// -----------------------------------------------------------------------------
+
+// notebookEditRenderer shows the edited notebook cell as a diff
+type notebookEditRenderer struct {
+	baseRenderer
+}
+
+// Render displays the notebook and cell with a diff of the cell source
+func (nr notebookEditRenderer) Render(v *toolCallCmp) string {
+	t := styles.CurrentTheme()
+	var params tools.NotebookEditParams
+	var args []string
+	if err := nr.unmarshalParams(v.call.Input, &params); err == nil {
+		args = newParamBuilder().
+			addMain(fsext.PrettyPath(params.NotebookPath)).
+			addKeyValue("cell", fmt.Sprintf("%d", params.CellIndex)).
+			addKeyValue("mode", params.EditMode).
+			build()
+	}
+
+	return nr.renderWithParams(v, "Notebook Edit", args, func() string {
+		var meta tools.NotebookEditResponseMetadata
+		if err := nr.unmarshalParams(v.result.Metadata, &meta); err != nil {
+			return renderPlainContent(v, v.result.Content)
+		}
+
+		cell := fmt.Sprintf("%s [cell %d]", fsext.PrettyPath(meta.FilePath), meta.CellIndex)
+		formatter := core.DiffFormatter().
+			Before(cell, meta.OldSource).
+			After(cell, meta.NewSource).
+			Width(v.textWidth() - 2) // -2 for padding
+		if v.textWidth() > 120 {
+			formatter = formatter.Split()
+		}
+		formatted := formatter.String()
+		if lipgloss.Height(formatted) > responseContextHeight {
+			contentLines := strings.Split(formatted, "\n")
+			truncateMessage := t.S().Muted.
+				Background(t.BgBaseLighter).
+				PaddingLeft(2).
+				Width(v.textWidth() - 2).
+				Render(fmt.Sprintf("… (%d lines)", len(contentLines)-responseContextHeight))
+			formatted = strings.Join(contentLines[:responseContextHeight], "\n") + "\n" + truncateMessage
+		}
+		return formatted
+	})
+}
+
 // -----------------------------------------------------------------------------
 //  Fetch renderer
 // -----------------------------------------------------------------------------
@@ -834,7 +892,11 @@ func (gr grepRenderer) Render(v *toolCallCmp) string {
 			addMain(params.Pattern).
 			addKeyValue("path", params.Path).
 			addKeyValue("include", params.Include).
+			addKeyValue("type", params.Type).
+			addKeyValue("mode", params.OutputMode).
 			addFlag("literal", params.LiteralText).
+			addFlag("ignore case", params.IgnoreCase).
+			addFlag("multiline", params.Multiline).
 			build()
 	}
 
@@ -1309,6 +1371,8 @@ func prettifyToolName(name string) string {
 		return "View"
 	case tools.WriteToolName:
 		return "Write"
+	case tools.NotebookEditToolName:
+		return "Notebook Edit"
 	default:
 		return name
 	}
@@ -264,6 +264,9 @@ func (m *toolCallCmp) formatParametersForCopy() string {
 			if params.Offset > 0 {
 				parts = append(parts, fmt.Sprintf("**Offset:** %d", params.Offset))
 			}
+			if params.Pages != "" {
+				parts = append(parts, fmt.Sprintf("**Pages:** %s", params.Pages))
+			}
 			return strings.Join(parts, "\n")
 		}
 	case tools.EditToolName:
@@ -284,6 +287,17 @@ func (m *toolCallCmp) formatParametersForCopy() string {
 		if json.Unmarshal([]byte(m.call.Input), &params) == nil {
 			return fmt.Sprintf("**File:** %s", fsext.PrettyPath(params.FilePath))
 		}
+	case tools.NotebookEditToolName:
+		var params tools.NotebookEditParams
+		if json.Unmarshal([]byte(m.call.Input), &params) == nil {
+			var parts []string
+			parts = append(parts, fmt.Sprintf("**Notebook:** %s", fsext.PrettyPath(params.NotebookPath)))
+			parts = append(parts, fmt.Sprintf("**Cell:** %d", params.CellIndex))
+			if params.EditMode != "" {
+				parts = append(parts, fmt.Sprintf("**Mode:** %s", params.EditMode))
+			}
+			return strings.Join(parts, "\n")
+		}
 	case tools.FetchToolName:
 		var params tools.FetchParams
 		if json.Unmarshal([]byte(m.call.Input), &params) == nil {
@@ -325,9 +339,21 @@ func (m *toolCallCmp) formatParametersForCopy() string {
 			if params.Include != "" {
 				parts = append(parts, fmt.Sprintf("**Include:** %s", params.Include))
 			}
+			if params.Type != "" {
+				parts = append(parts, fmt.Sprintf("**Type:** %s", params.Type))
+			}
+			if params.OutputMode != "" {
+				parts = append(parts, fmt.Sprintf("**Output mode:** %s", params.OutputMode))
+			}
 			if params.LiteralText {
 				parts = append(parts, "**Literal:** true")
 			}
+			if params.IgnoreCase {
+				parts = append(parts, "**Ignore case:** true")
+			}
+			if params.Multiline {
+				parts = append(parts, "**Multiline:** true")
+			}
 			return strings.Join(parts, "\n")
 		}
 	case tools.GlobToolName:
@@ -5,13 +5,18 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/nexora/nexora/internal/agent/delegation"
+	"github.com/nexora/nexora/internal/agent/state"
 	"github.com/nexora/nexora/internal/config"
 	"github.com/nexora/nexora/internal/csync"
 	"github.com/nexora/nexora/internal/diff"
+	"github.com/nexora/nexora/internal/format"
 	"github.com/nexora/nexora/internal/fsext"
 	"github.com/nexora/nexora/internal/history"
 	"github.com/nexora/nexora/internal/home"
@@ -43,6 +48,7 @@ const (
 	DefaultMaxFilesShown = 10
 	DefaultMaxLSPsShown  = 8
 	DefaultMaxMCPsShown  = 8
+	MaxDelegatesShown    = 5
 	MinItemsPerSection   = 2 // Minimum items to show per section
 )
 
@@ -56,6 +62,12 @@ type SessionFilesMsg struct {
 	Files []SessionFile
 }
 
+// AgentStateMsg carries the saved agent state of a session, to show when it
+// is paused.
+type AgentStateMsg struct {
+	State session.AgentState
+}
+
 type Sidebar interface {
 	util.Model
 	core.Sizeable
@@ -72,6 +84,8 @@ type sidebarCmp struct {
 	compactMode   bool
 	history       history.Service
 	files         *csync.Map[string, SessionFile]
+	agentState    session.AgentState
+	delegates     map[string]delegation.Status // By task ID
 }
 
 func New(history history.Service, lspClients *csync.Map[string, *lsp.Client], compact bool) Sidebar {
@@ -80,6 +94,7 @@ func New(history history.Service, lspClients *csync.Map[string, *lsp.Client], co
 		history:     history,
 		compactMode: compact,
 		files:       csync.NewMap[string, SessionFile](),
+		delegates:   make(map[string]delegation.Status),
 	}
 }
 
@@ -96,8 +111,23 @@ func (m *sidebarCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case AgentStateMsg:
+		if msg.State.SessionID == m.session.ID {
+			m.agentState = msg.State
+		}
 	case chat.SessionClearedMsg:
 		m.session = session.Session{}
+		m.agentState = session.AgentState{}
+	case pubsub.Event[session.AgentState]:
+		if msg.Payload.SessionID == m.session.ID {
+			if msg.Type == pubsub.DeletedEvent {
+				m.agentState = session.AgentState{}
+			} else {
+				m.agentState = msg.Payload
+			}
+		}
+	case pubsub.Event[delegation.Status]:
+		m.delegates[msg.Payload.TaskID] = msg.Payload
 	case pubsub.Event[history.File]:
 		return m, m.handleFileHistoryEvent(msg)
 	case pubsub.Event[session.Session]:
@@ -138,6 +168,11 @@ func (m *sidebarCmp) View() string {
 	} else if m.session.ID != "" {
 		parts = append(parts, t.S().Text.Render(m.session.Title), "")
 	}
+	if paused := m.pausedBlock(); paused != "" {
+		parts = append(parts, paused, "")
+	} else if loop := m.loopBlock(); loop != "" {
+		parts = append(parts, loop, "")
+	}
 
 	if !m.compactMode {
 		parts = append(parts,
@@ -148,6 +183,9 @@ func (m *sidebarCmp) View() string {
 	parts = append(parts,
 		m.currentModelBlock(),
 	)
+	if delegates := m.delegatesBlock(); delegates != "" {
+		parts = append(parts, "", delegates)
+	}
 
 	// Check if we should use horizontal layout for sections
 	if m.compactMode && m.width > m.height {
@@ -507,23 +545,7 @@ func (m *sidebarCmp) mcpBlock() string {
 func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
 	t := styles.CurrentTheme()
 	// Format tokens in human-readable format (e.g., 110K, 1.2M)
-	var formattedTokens string
-	switch {
-	case tokens >= 1_000_000:
-		formattedTokens = fmt.Sprintf("%.1fM", float64(tokens)/1_000_000)
-	case tokens >= 1_000:
-		formattedTokens = fmt.Sprintf("%.1fK", float64(tokens)/1_000)
-	default:
-		formattedTokens = fmt.Sprintf("%d", tokens)
-	}
-
-	// Remove .0 suffix if present
-	if strings.HasSuffix(formattedTokens, ".0K") {
-		formattedTokens = strings.Replace(formattedTokens, ".0K", "K", 1)
-	}
-	if strings.HasSuffix(formattedTokens, ".0M") {
-		formattedTokens = strings.Replace(formattedTokens, ".0M", "M", 1)
-	}
+	formattedTokens := format.Tokens(tokens)
 
 	// Calculate percentage, handling zero context window
 	percentage := 0.0
@@ -546,6 +568,24 @@ func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
 	return fmt.Sprintf("%s %s", formattedTokens, formattedCost)
 }
 
+// formatCacheUsage summarizes the prompt cache use of a session: the share of
+// input tokens read from the cache and what that saved.
+func formatCacheUsage(sess session.Session) string {
+	if sess.CacheReadTokens == 0 && sess.CacheCreationTokens == 0 {
+		return ""
+	}
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+
+	hitRate := baseStyle.Foreground(t.FgMuted).Render(fmt.Sprintf("%d%%", int(sess.CacheHitRate()*100)))
+	cached := baseStyle.Foreground(t.FgSubtle).Render(fmt.Sprintf("(%s cached)", format.Tokens(sess.CacheReadTokens)))
+	savings := fmt.Sprintf("saved $%.2f", sess.CacheSavings)
+	if sess.CacheSavings < 0 {
+		savings = fmt.Sprintf("cost $%.2f", -sess.CacheSavings)
+	}
+	return fmt.Sprintf("%s %s %s", hitRate, cached, baseStyle.Foreground(t.FgMuted).Render(savings))
+}
+
 func (s *sidebarCmp) currentModelBlock() string {
 	cfg := config.Get()
 	agentCfg := cfg.Agents[config.AgentCoder]
@@ -605,6 +645,9 @@ func (s *sidebarCmp) currentModelBlock() string {
 				s.session.Cost,
 			),
 		)
+		if cacheUsage := formatCacheUsage(s.session); cacheUsage != "" {
+			parts = append(parts, "  "+t.S().Subtle.Render("Cache")+" "+cacheUsage)
+		}
 	}
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
@@ -612,9 +655,100 @@ func (s *sidebarCmp) currentModelBlock() string {
 	)
 }
 
+// pausedBlock shows why the session is paused and how to resume it.
+func (m *sidebarCmp) pausedBlock() string {
+	if m.session.ID == "" || m.agentState.SessionID != m.session.ID || !m.agentState.IsPaused() {
+		return ""
+	}
+	t := styles.CurrentTheme()
+	parts := []string{t.S().Warning.Render("⏸ Paused: " + m.agentState.State.String())}
+	if reason := m.agentState.PauseReason; reason != "" {
+		parts = append(parts, t.S().Muted.Render(reason))
+	}
+	parts = append(parts, t.S().Subtle.Render("Send a prompt or run Resume Paused Session to continue"))
+	return lipgloss.JoinVertical(lipgloss.Left, parts...)
+}
+
+// loopBlock shows the verdict of the last loop check when the session may be
+// stuck in a loop or drifting from its task.
+func (m *sidebarCmp) loopBlock() string {
+	if m.session.ID == "" || m.agentState.SessionID != m.session.ID {
+		return ""
+	}
+	verdict := m.agentState.Snapshot.Progress.Verdict
+	if verdict == nil || !verdict.Stuck() {
+		return ""
+	}
+	t := styles.CurrentTheme()
+	title := "⚠ Possible loop: "
+	if verdict.Pattern == state.PatternDrift {
+		title = "⚠ Possible drift: "
+	}
+	parts := []string{t.S().Warning.Render(title + strings.ReplaceAll(verdict.Pattern, "_", " "))}
+	if verdict.Reason != "" {
+		parts = append(parts, t.S().Muted.Render(verdict.Reason))
+	}
+	parts = append(parts, t.S().Subtle.Render(fmt.Sprintf("Judged by the %s policy", verdict.Policy)))
+	return lipgloss.JoinVertical(lipgloss.Left, parts...)
+}
+
+// delegatesBlock shows the live status of the delegates of the session, the
+// latest started first.
+func (m *sidebarCmp) delegatesBlock() string {
+	var statuses []delegation.Status
+	for _, status := range m.delegates {
+		if m.session.ID != "" && status.ParentSession == m.session.ID {
+			statuses = append(statuses, status)
+		}
+	}
+	if len(statuses) == 0 {
+		return ""
+	}
+	slices.SortFunc(statuses, func(a, b delegation.Status) int {
+		return b.StartedAt.Compare(a.StartedAt)
+	})
+
+	t := styles.CurrentTheme()
+	parts := []string{core.Section("Delegates", m.getMaxWidth()), ""}
+	for _, status := range statuses[:min(len(statuses), MaxDelegatesShown)] {
+		icon := t.ItemBusyIcon
+		description := string(status.State)
+		switch status.State {
+		case delegation.TaskStatusCompleted:
+			icon = t.ItemOnlineIcon
+		case delegation.TaskStatusFailed, delegation.TaskStatusTimeout, delegation.TaskStatusCancelled:
+			icon = t.ItemErrorIcon
+			if status.Error != "" {
+				description = status.Error
+			}
+		}
+		if !status.Finished() && status.ToolCalls > 0 {
+			description = fmt.Sprintf("%d tools, last %s", status.ToolCalls, status.LastTool)
+		}
+
+		elapsed := status.LastActivity.Sub(status.StartedAt)
+		if !status.Finished() {
+			elapsed = time.Since(status.StartedAt)
+		}
+		parts = append(parts, core.Status(core.StatusOpts{
+			Icon:         icon.String(),
+			Title:        ansi.Truncate(status.Description, m.getMaxWidth()/2, "…"),
+			Description:  description,
+			ExtraContent: t.S().Subtle.Render(elapsed.Round(time.Second).String()),
+		}, m.getMaxWidth()))
+	}
+	if hidden := len(statuses) - MaxDelegatesShown; hidden > 0 {
+		parts = append(parts, t.S().Subtle.Render(fmt.Sprintf("…and %d more", hidden)))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, parts...)
+}
+
 // SetSession implements Sidebar.
-func (m *sidebarCmp) SetSession(session session.Session) tea.Cmd {
-	m.session = session
+func (m *sidebarCmp) SetSession(sess session.Session) tea.Cmd {
+	if sess.ID != m.session.ID {
+		m.agentState = session.AgentState{}
+	}
+	m.session = sess
 	return m.loadSessionFiles
 }
 
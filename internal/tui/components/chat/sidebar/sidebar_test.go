@@ -2,13 +2,18 @@ package sidebar
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
+	"github.com/nexora/nexora/internal/agent/delegation"
+	"github.com/nexora/nexora/internal/agent/state"
 	"github.com/nexora/nexora/internal/csync"
 	"github.com/nexora/nexora/internal/history"
 	"github.com/nexora/nexora/internal/lsp"
 	"github.com/nexora/nexora/internal/pubsub"
+	"github.com/nexora/nexora/internal/session"
 	"github.com/nexora/nexora/internal/tui/components/chat"
 )
 
@@ -136,6 +141,41 @@ func TestSidebarUpdate(t *testing.T) {
 	}
 }
 
+func TestSidebarPausedBlock(t *testing.T) {
+	sidebar := New(&mockHistoryService{}, csync.NewMap[string, *lsp.Client](), false).(*sidebarCmp)
+	sidebar.session = session.Session{ID: "session-1"}
+
+	sidebar.Update(pubsub.Event[session.AgentState]{
+		Type: pubsub.UpdatedEvent,
+		Payload: session.AgentState{
+			SessionID:   "session-2",
+			State:       state.StateLoopPaused,
+			PauseReason: "repeated error",
+		},
+	})
+	if block := sidebar.pausedBlock(); block != "" {
+		t.Errorf("Expected no paused block for another session, got %q", block)
+	}
+
+	sidebar.Update(AgentStateMsg{State: session.AgentState{
+		SessionID:   "session-1",
+		State:       state.StateLoopPaused,
+		PauseReason: "repeated error",
+	}})
+	block := sidebar.pausedBlock()
+	if !strings.Contains(block, "LoopPaused") || !strings.Contains(block, "repeated error") {
+		t.Errorf("Expected the paused block to show the state and reason, got %q", block)
+	}
+
+	sidebar.Update(pubsub.Event[session.AgentState]{
+		Type:    pubsub.UpdatedEvent,
+		Payload: session.AgentState{SessionID: "session-1", State: state.StateIdle},
+	})
+	if block := sidebar.pausedBlock(); block != "" {
+		t.Errorf("Expected no paused block once resumed, got %q", block)
+	}
+}
+
 func TestGetMaxWidth(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -236,6 +276,39 @@ func TestFormatTokensAndCost(t *testing.T) {
 	}
 }
 
+func TestFormatCacheUsage(t *testing.T) {
+	if got := formatCacheUsage(session.Session{TotalInputTokens: 1000}); got != "" {
+		t.Errorf("formatCacheUsage() without cache tokens should be empty, got '%s'", got)
+	}
+
+	tests := []struct {
+		name         string
+		session      session.Session
+		wantContains []string
+	}{
+		{
+			name:         "savings",
+			session:      session.Session{TotalInputTokens: 200_000, CacheReadTokens: 150_000, CacheSavings: 0.4},
+			wantContains: []string{"75%", "150K cached", "saved $0.40"},
+		},
+		{
+			name:         "writes only",
+			session:      session.Session{TotalInputTokens: 20_000, CacheCreationTokens: 20_000, CacheSavings: -0.02},
+			wantContains: []string{"0%", "cost $0.02"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := formatCacheUsage(tt.session)
+			for _, want := range tt.wantContains {
+				if !contains(result, want) {
+					t.Errorf("formatCacheUsage() result should contain '%s', got '%s'", want, result)
+				}
+			}
+		})
+	}
+}
+
 func TestGetDynamicLimits(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -327,3 +400,83 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestSidebarLoopBlock(t *testing.T) {
+	sidebar := New(&mockHistoryService{}, csync.NewMap[string, *lsp.Client](), false).(*sidebarCmp)
+	sidebar.session = session.Session{ID: "session-1"}
+	if block := sidebar.loopBlock(); block != "" {
+		t.Errorf("Expected no loop block without a verdict, got %q", block)
+	}
+
+	verdict := state.Verdict{
+		Severity: state.SeverityWarn,
+		Pattern:  state.PatternDrift,
+		Reason:   "None of the last 10 actions touched what the task names",
+		Policy:   "heuristic",
+	}
+	sidebar.Update(AgentStateMsg{State: session.AgentState{
+		SessionID: "session-1",
+		State:     state.StateProcessingPrompt,
+		Snapshot:  state.Snapshot{Progress: state.ProgressSnapshot{Verdict: &verdict}},
+	}})
+	block := sidebar.loopBlock()
+	if !strings.Contains(block, "drift") || !strings.Contains(block, "None of the last 10 actions") || !strings.Contains(block, "heuristic") {
+		t.Errorf("Expected the loop block to show the verdict, got %q", block)
+	}
+
+	sidebar.Update(pubsub.Event[session.AgentState]{
+		Type:    pubsub.UpdatedEvent,
+		Payload: session.AgentState{SessionID: "session-1", State: state.StateProcessingPrompt},
+	})
+	if block := sidebar.loopBlock(); block != "" {
+		t.Errorf("Expected no loop block once back on track, got %q", block)
+	}
+}
+
+func TestSidebarDelegatesBlock(t *testing.T) {
+	sidebar := New(&mockHistoryService{}, csync.NewMap[string, *lsp.Client](), false).(*sidebarCmp)
+	sidebar.session = session.Session{ID: "session-1"}
+	sidebar.width = 60
+	if block := sidebar.delegatesBlock(); block != "" {
+		t.Errorf("Expected no delegates block without delegates, got %q", block)
+	}
+
+	started := time.Now().Add(-time.Minute)
+	sidebar.Update(pubsub.Event[delegation.Status]{
+		Type: pubsub.UpdatedEvent,
+		Payload: delegation.Status{
+			TaskID:        "task-other",
+			ParentSession: "session-2",
+			Description:   "Task of another session",
+			State:         delegation.TaskStatusRunning,
+			StartedAt:     started,
+		},
+	})
+	if block := sidebar.delegatesBlock(); block != "" {
+		t.Errorf("Expected no delegates block for another session, got %q", block)
+	}
+
+	running := delegation.Status{
+		TaskID:        "task-1",
+		ParentSession: "session-1",
+		Description:   "Review the parser",
+		State:         delegation.TaskStatusRunning,
+		ToolCalls:     4,
+		LastTool:      "grep",
+		StartedAt:     started,
+	}
+	sidebar.Update(pubsub.Event[delegation.Status]{Type: pubsub.UpdatedEvent, Payload: running})
+	block := sidebar.delegatesBlock()
+	if !strings.Contains(block, "Delegates") || !strings.Contains(block, "Review the parser") || !strings.Contains(block, "4 tools, last grep") {
+		t.Errorf("Expected the delegates block to show the running delegate, got %q", block)
+	}
+
+	failed := running
+	failed.State = delegation.TaskStatusTimeout
+	failed.Error = "delegate made no progress"
+	sidebar.Update(pubsub.Event[delegation.Status]{Type: pubsub.UpdatedEvent, Payload: failed})
+	block = sidebar.delegatesBlock()
+	if !strings.Contains(block, "no progress") || strings.Contains(block, "last grep") {
+		t.Errorf("Expected the delegates block to show the failure, got %q", block)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	lspcomponent "github.com/nexora/nexora/internal/tui/components/lsp"
 	"github.com/nexora/nexora/internal/tui/components/mcp"
 	"github.com/nexora/nexora/internal/tui/exp/list"
+	"github.com/nexora/nexora/internal/tui/keymap"
 	"github.com/nexora/nexora/internal/tui/styles"
 	"github.com/nexora/nexora/internal/tui/util"
 	"github.com/nexora/nexora/internal/version"
@@ -95,7 +96,7 @@ type splashCmp struct {
 }
 
 func New() Splash {
-	keyMap := DefaultKeyMap()
+	keyMap := keymap.Apply(keymap.ScopeSplash, DefaultKeyMap())
 	listKeyMap := list.DefaultKeyMap()
 	listKeyMap.Down.SetEnabled(false)
 	listKeyMap.Up.SetEnabled(false)
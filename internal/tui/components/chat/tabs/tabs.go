@@ -0,0 +1,59 @@
+// Package tabs renders the bar of windows above the chat.
+package tabs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/nexora/nexora/internal/tui/styles"
+)
+
+// Height is the height of the tab bar.
+const Height = 1
+
+// minTitleWidth is the narrowest a tab title is truncated to.
+const minTitleWidth = 6
+
+// Tab is a window shown in the tab bar.
+type Tab struct {
+	Title  string
+	Active bool
+	// Busy marks a window whose agent is working.
+	Busy bool
+	// Pending marks a window waiting for a permission to be answered.
+	Pending bool
+}
+
+// View renders tabs on one line of width cells. Tabs are numbered from 1 so
+// that the number can be used to switch to them.
+func View(tabs []Tab, width int) string {
+	t := styles.CurrentTheme()
+	if len(tabs) == 0 || width <= 0 {
+		return ""
+	}
+
+	titleWidth := max(minTitleWidth, width/len(tabs)-8)
+	rendered := make([]string, len(tabs))
+	for i, tab := range tabs {
+		title := ansi.Truncate(tab.Title, titleWidth, "…")
+		if title == "" {
+			title = "New Session"
+		}
+		style := t.S().Base.Foreground(t.FgMuted).Background(t.BgBaseLighter)
+		if tab.Active {
+			style = t.S().Base.Foreground(t.FgBase).Background(t.BgOverlay).Bold(true)
+		}
+		label := style.Render(fmt.Sprintf(" %d %s ", i+1, title))
+		if tab.Pending {
+			label += style.Foreground(t.Error).Render("! ")
+		}
+		if tab.Busy {
+			label += style.Foreground(t.Yellow).Render("● ")
+		}
+		rendered[i] = label
+	}
+
+	bar := strings.Join(rendered, t.S().Base.Render(" "))
+	return ansi.Truncate(bar, width, "…")
+}
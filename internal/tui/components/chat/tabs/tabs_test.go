@@ -0,0 +1,46 @@
+package tabs
+
+import (
+	"strings"
+	"testing"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestView(t *testing.T) {
+	view := ansi.Strip(View([]Tab{
+		{Title: "Fix the parser", Active: true},
+		{Title: "Write docs", Busy: true},
+		{Title: "Refactor", Pending: true},
+	}, 120))
+
+	for _, want := range []string{"1 Fix the parser", "2 Write docs ●", "3 Refactor !"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in %q", want, view)
+		}
+	}
+}
+
+func TestView_Truncates(t *testing.T) {
+	view := View([]Tab{
+		{Title: strings.Repeat("long title ", 10), Active: true},
+		{Title: strings.Repeat("other title ", 10)},
+	}, 40)
+
+	if w := lipgloss.Width(view); w > 40 {
+		t.Errorf("expected the bar to fit in 40 cells, got %d", w)
+	}
+	if !strings.Contains(ansi.Strip(view), "…") {
+		t.Error("expected long titles to be truncated")
+	}
+}
+
+func TestView_Empty(t *testing.T) {
+	if view := View(nil, 80); view != "" {
+		t.Errorf("expected no bar without tabs, got %q", view)
+	}
+	if view := ansi.Strip(View([]Tab{{}}, 80)); !strings.Contains(view, "New Session") {
+		t.Errorf("expected untitled tabs to be named, got %q", view)
+	}
+}
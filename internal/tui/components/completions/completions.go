@@ -7,6 +7,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/nexora/nexora/internal/tui/exp/list"
+	"github.com/nexora/nexora/internal/tui/keymap"
 	"github.com/nexora/nexora/internal/tui/styles"
 	"github.com/nexora/nexora/internal/tui/util"
 )
@@ -75,7 +76,7 @@ type completionsCmp struct {
 }
 
 func New() Completions {
-	completionsKeyMap := DefaultKeyMap()
+	completionsKeyMap := keymap.Apply(keymap.ScopeCompletions, DefaultKeyMap())
 	keyMap := list.DefaultKeyMap()
 	keyMap.Up.SetEnabled(false)
 	keyMap.Down.SetEnabled(false)
@@ -17,8 +17,15 @@ type StatusCmp interface {
 	SetKeyMap(keyMap help.KeyMap)
 }
 
+// ModeMsg sets the editor mode shown at the start of the status bar, e.g.
+// the vim mode. An empty mode hides it.
+type ModeMsg struct {
+	Mode string
+}
+
 type statusCmp struct {
 	info       util.InfoMsg
+	mode       string
 	width      int
 	messageTTL time.Duration
 	help       help.Model
@@ -53,17 +60,27 @@ func (m *statusCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		return m, m.clearMessageCmd(ttl)
 	case util.ClearStatusMsg:
 		m.info = util.InfoMsg{}
+	case ModeMsg:
+		m.mode = msg.Mode
+	case styles.ThemeChangedMsg:
+		m.help.Styles = styles.CurrentTheme().S().Help
 	}
 	return m, nil
 }
 
 func (m *statusCmp) View() string {
 	t := styles.CurrentTheme()
-	status := t.S().Base.Padding(0, 1, 1, 1).Render(m.help.View(m.keyMap))
 	if m.info.Msg != "" {
-		status = m.infoMsg()
+		return m.infoMsg()
+	}
+	if m.mode == "" {
+		m.help.SetWidth(m.width - 2)
+		return t.S().Base.Padding(0, 1, 1, 1).Render(m.help.View(m.keyMap))
 	}
-	return status
+	mode := t.S().Base.Foreground(t.BgSubtle).Background(t.Primary).Padding(0, 1).Bold(true).Render(m.mode)
+	m.help.SetWidth(m.width - lipgloss.Width(mode) - 3)
+	help := t.S().Base.Padding(0, 1).Render(m.help.View(m.keyMap))
+	return t.S().Base.PaddingBottom(1).Render(lipgloss.JoinHorizontal(lipgloss.Top, " ", mode, help))
 }
 
 func (m *statusCmp) infoMsg() string {
@@ -292,3 +292,17 @@ func TestStatusCmpInterface(t *testing.T) {
 	cmp := NewStatusCmp()
 	var _ StatusCmp = cmp
 }
+
+// TestStatusCmpModeMsg tests that the editor mode is shown before the help
+func TestStatusCmpModeMsg(t *testing.T) {
+	t.Parallel()
+
+	cmp := NewStatusCmp()
+	cmp.SetKeyMap(&dummyKeyMap{})
+	cmp.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	cmp.Update(ModeMsg{Mode: "NORMAL"})
+	assert.Contains(t, cmp.View(), "NORMAL")
+
+	cmp.Update(ModeMsg{})
+	assert.NotContains(t, cmp.View(), "NORMAL")
+}
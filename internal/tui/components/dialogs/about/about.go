@@ -7,6 +7,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/nexora/nexora/internal/tui/components/dialogs"
+	"github.com/nexora/nexora/internal/tui/keymap"
 	"github.com/nexora/nexora/internal/tui/styles"
 	"github.com/nexora/nexora/internal/tui/util"
 	"github.com/nexora/nexora/internal/version"
@@ -30,7 +31,7 @@ type aboutDialogCmp struct {
 // NewAboutDialog creates a new about dialog.
 func NewAboutDialog() AboutDialog {
 	return &aboutDialogCmp{
-		keymap: DefaultKeymap(),
+		keymap: keymap.Apply(keymap.ScopeAbout, DefaultKeymap()),
 	}
 }
 
@@ -0,0 +1,187 @@
+package agents
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/fsext"
+	"github.com/nexora/nexora/internal/tui/components/core"
+	"github.com/nexora/nexora/internal/tui/components/dialogs"
+	"github.com/nexora/nexora/internal/tui/keymap"
+	"github.com/nexora/nexora/internal/tui/styles"
+	"github.com/nexora/nexora/internal/tui/util"
+)
+
+const (
+	AgentsDialogID dialogs.DialogID = "agents"
+
+	defaultWidth  = 80
+	maxListHeight = 10
+	detailsHeight = 8
+)
+
+// MentionAgentMsg is sent when the user picks an agent to mention in the
+// prompt.
+type MentionAgentMsg struct {
+	ID string
+}
+
+// AgentsDialog lists the user-defined agents.
+type AgentsDialog interface {
+	dialogs.DialogModel
+}
+
+type agentsDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	agents   []config.Agent
+	selected int
+	keyMap   KeyMap
+	help     help.Model
+}
+
+// NewAgentsDialog creates a dialog listing agents.
+func NewAgentsDialog(agents []config.Agent) AgentsDialog {
+	t := styles.CurrentTheme()
+	h := help.New()
+	h.Styles = t.S().Help
+	return &agentsDialogCmp{
+		agents: agents,
+		keyMap: keymap.Apply(keymap.ScopeAgents, DefaultKeyMap()),
+		help:   h,
+	}
+}
+
+func (a *agentsDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (a *agentsDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		a.wWidth = msg.Width
+		a.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, a.keyMap.Next):
+			if a.selected < len(a.agents)-1 {
+				a.selected++
+			}
+		case key.Matches(msg, a.keyMap.Previous):
+			if a.selected > 0 {
+				a.selected--
+			}
+		case key.Matches(msg, a.keyMap.Select):
+			if len(a.agents) == 0 {
+				return a, util.CmdHandler(dialogs.CloseDialogMsg{})
+			}
+			return a, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(MentionAgentMsg{ID: a.agents[a.selected].ID}),
+			)
+		case key.Matches(msg, a.keyMap.Close):
+			return a, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	return a, nil
+}
+
+func (a *agentsDialogCmp) width() int {
+	return min(defaultWidth, max(a.wWidth-4, 40))
+}
+
+func (a *agentsDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	width := a.width()
+	contentWidth := width - 4
+
+	parts := []string{core.Title("Agents", contentWidth), ""}
+	if len(a.agents) == 0 {
+		parts = append(parts,
+			t.S().Subtle.Width(contentWidth).Render("No agents found. Add markdown agent files to .nexora/agents/ in the project or agents/ in the config directory."),
+		)
+	} else {
+		start := max(0, a.selected-maxListHeight+1)
+		end := min(len(a.agents), start+maxListHeight)
+		for i := start; i < end; i++ {
+			parts = append(parts, a.renderAgent(i, contentWidth))
+		}
+		parts = append(parts, "", a.renderDetails(a.agents[a.selected], contentWidth))
+	}
+	parts = append(parts, "", a.help.View(a.keyMap))
+
+	return t.S().Base.
+		Padding(0, 1).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Width(width).
+		Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
+
+func (a *agentsDialogCmp) renderAgent(i, width int) string {
+	t := styles.CurrentTheme()
+	agent := a.agents[i]
+
+	icon := t.S().Base.Foreground(agentColor(agent.Color)).Render("● ")
+	titleColor := t.FgMuted
+	if i == a.selected {
+		icon = t.S().Base.Foreground(t.Primary).Render("▸ ")
+		titleColor = t.FgBase
+	}
+	return core.Status(core.StatusOpts{
+		Icon:        icon,
+		Title:       "@" + agent.ID,
+		TitleColor:  titleColor,
+		Description: agent.Description,
+	}, width)
+}
+
+func (a *agentsDialogCmp) renderDetails(agent config.Agent, width int) string {
+	t := styles.CurrentTheme()
+	tools := "none"
+	if len(agent.AllowedTools) > 0 {
+		tools = strings.Join(agent.AllowedTools, ", ")
+	}
+	details := []string{
+		fmt.Sprintf("Tools: %s", tools),
+		fmt.Sprintf("File:  %s", fsext.PrettyPath(agent.Path)),
+	}
+	return t.S().Subtle.Width(width).MaxHeight(detailsHeight).Render(strings.Join(details, "\n"))
+}
+
+// agentColor returns the theme color named by an agent file, or the muted
+// foreground for names the theme doesn't have.
+func agentColor(name string) color.Color {
+	t := styles.CurrentTheme()
+	switch strings.ToLower(name) {
+	case "red":
+		return t.Red
+	case "green":
+		return t.Green
+	case "blue":
+		return t.Blue
+	case "yellow":
+		return t.Yellow
+	}
+	if strings.HasPrefix(name, "#") {
+		return lipgloss.Color(name)
+	}
+	return t.FgMuted
+}
+
+func (a *agentsDialogCmp) Position() (int, int) {
+	row := max(0, a.wHeight/2-(maxListHeight+detailsHeight+6)/2)
+	col := max(0, a.wWidth/2-a.width()/2)
+	return row, col
+}
+
+func (a *agentsDialogCmp) ID() dialogs.DialogID {
+	return AgentsDialogID
+}
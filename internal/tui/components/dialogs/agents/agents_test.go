@@ -0,0 +1,101 @@
+package agents
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/tui/components/dialogs"
+)
+
+func testAgents() []config.Agent {
+	return []config.Agent{
+		{ID: "docs", Description: "Writes documentation", AllowedTools: []string{"view", "edit"}, Color: "green", Path: "/p/.nexora/agents/docs.md"},
+		{ID: "reviewer", Description: "Reviews changes", AllowedTools: []string{"view"}, Color: "#ff0000", Path: "/p/.nexora/agents/reviewer.md"},
+	}
+}
+
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	// tea.Sequence wraps its commands in an unexported slice type.
+	v := reflect.ValueOf(msg)
+	if v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeFor[tea.Cmd]() {
+		var msgs []tea.Msg
+		for i := range v.Len() {
+			msgs = append(msgs, runCmd(v.Index(i).Interface().(tea.Cmd))...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+func TestAgentsDialog_View(t *testing.T) {
+	dialog := NewAgentsDialog(testAgents())
+	dialog.Update(tea.WindowSizeMsg{Width: 120, Height: 50})
+
+	view := dialog.View()
+	for _, want := range []string{"Agents", "@docs", "Writes documentation", "@reviewer", "view, edit"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected view to contain %q", want)
+		}
+	}
+	if dialog.ID() != AgentsDialogID {
+		t.Errorf("expected dialog ID %q, got %q", AgentsDialogID, dialog.ID())
+	}
+}
+
+func TestAgentsDialog_Empty(t *testing.T) {
+	dialog := NewAgentsDialog(nil)
+	dialog.Update(tea.WindowSizeMsg{Width: 120, Height: 50})
+
+	if !strings.Contains(dialog.View(), "No agents found") {
+		t.Error("expected view to explain there are no agents")
+	}
+	_, cmd := dialog.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	msgs := runCmd(cmd)
+	if len(msgs) != 1 {
+		t.Fatalf("expected only the dialog to close, got %v", msgs)
+	}
+	if _, ok := msgs[0].(dialogs.CloseDialogMsg); !ok {
+		t.Errorf("expected CloseDialogMsg, got %T", msgs[0])
+	}
+}
+
+func TestAgentsDialog_Select(t *testing.T) {
+	dialog := NewAgentsDialog(testAgents())
+	dialog.Update(tea.WindowSizeMsg{Width: 120, Height: 50})
+	dialog.Update(tea.KeyPressMsg{Code: tea.KeyDown})
+
+	_, cmd := dialog.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	msgs := runCmd(cmd)
+	if len(msgs) != 2 {
+		t.Fatalf("expected close and mention messages, got %v", msgs)
+	}
+	if _, ok := msgs[0].(dialogs.CloseDialogMsg); !ok {
+		t.Errorf("expected CloseDialogMsg first, got %T", msgs[0])
+	}
+	mention, ok := msgs[1].(MentionAgentMsg)
+	if !ok {
+		t.Fatalf("expected MentionAgentMsg, got %T", msgs[1])
+	}
+	if mention.ID != "reviewer" {
+		t.Errorf("expected reviewer to be mentioned, got %q", mention.ID)
+	}
+}
+
+func TestAgentsDialog_Close(t *testing.T) {
+	dialog := NewAgentsDialog(testAgents())
+	_, cmd := dialog.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	msgs := runCmd(cmd)
+	if len(msgs) != 1 {
+		t.Fatalf("expected one message, got %v", msgs)
+	}
+	if _, ok := msgs[0].(dialogs.CloseDialogMsg); !ok {
+		t.Errorf("expected CloseDialogMsg, got %T", msgs[0])
+	}
+}
@@ -9,6 +9,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/nexora/nexora/internal/tui/components/dialogs"
+	"github.com/nexora/nexora/internal/tui/keymap"
 	"github.com/nexora/nexora/internal/tui/styles"
 	"github.com/nexora/nexora/internal/tui/util"
 )
@@ -89,7 +90,7 @@ func NewCommandArgumentsDialog(
 
 	return &commandArgumentsDialogCmp{
 		inputs:      inputs,
-		keys:        DefaultArgumentsDialogKeyMap(),
+		keys:        keymap.Apply(keymap.ScopeArguments, DefaultArgumentsDialogKeyMap()),
 		id:          id,
 		name:        name,
 		title:       title,
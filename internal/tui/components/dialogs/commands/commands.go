@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"slices"
+	"strconv"
 	"strings"
 
 	"charm.land/bubbles/v2/help"
@@ -21,6 +22,7 @@ import (
 	"github.com/nexora/nexora/internal/tui/components/core"
 	"github.com/nexora/nexora/internal/tui/components/dialogs"
 	"github.com/nexora/nexora/internal/tui/exp/list"
+	"github.com/nexora/nexora/internal/tui/keymap"
 	"github.com/nexora/nexora/internal/tui/styles"
 	"github.com/nexora/nexora/internal/tui/util"
 )
@@ -75,6 +77,8 @@ type (
 	SwitchSessionsMsg      struct{}
 	NewSessionsMsg         struct{}
 	SwitchModelMsg         struct{}
+	SwitchThemeMsg         struct{}
+	ShowAgentsMsg          struct{}
 	QuitMsg                struct{}
 	OpenFilePickerMsg      struct{}
 	ToggleHelpMsg          struct{}
@@ -83,14 +87,30 @@ type (
 	OpenReasoningDialogMsg struct{}
 	OpenExternalEditorMsg  struct{}
 	ToggleYoloModeMsg      struct{}
+	TogglePlanModeMsg      struct{}
 	AboutNexoraMsg         struct{}
 	CompactMsg             struct {
 		SessionID string
 	}
+	// RewindMsg requests restoring the files the agent changed in the last
+	// Turns turns of a session.
+	RewindMsg struct {
+		SessionID string
+		Turns     int
+	}
+	// ForkSessionMsg requests a copy of a session in a new window.
+	ForkSessionMsg struct {
+		SessionID string
+	}
+	// ResumeSessionMsg requests resuming a session paused for its resource
+	// usage or a loop.
+	ResumeSessionMsg struct {
+		SessionID string
+	}
 )
 
 func NewCommandDialog(sessionID string) CommandsDialog {
-	keyMap := DefaultCommandsDialogKeyMap()
+	keyMap := keymap.Apply(keymap.ScopeCommands, DefaultCommandsDialogKeyMap())
 	listKeyMap := list.DefaultKeyMap()
 	listKeyMap.Down.SetEnabled(false)
 	listKeyMap.Up.SetEnabled(false)
@@ -113,7 +133,7 @@ func NewCommandDialog(sessionID string) CommandsDialog {
 	return &commandDialogCmp{
 		commandList: commandList,
 		width:       defaultWidth,
-		keyMap:      DefaultCommandsDialogKeyMap(),
+		keyMap:      keyMap,
 		help:        help,
 		selected:    SystemCommands,
 		sessionID:   sessionID,
@@ -334,7 +354,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			ID:          "new_session",
 			Title:       "New Session",
 			Description: "start a new session",
-			Shortcut:    "ctrl+n",
+			Shortcut:    keymap.Shortcut(keymap.ScopeChat+".new_session", "ctrl+n"),
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(NewSessionsMsg{})
 			},
@@ -343,7 +363,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			ID:          "switch_session",
 			Title:       "Switch Session",
 			Description: "Switch to a different session",
-			Shortcut:    "ctrl+s",
+			Shortcut:    keymap.Shortcut(keymap.ScopeApp+".sessions", "ctrl+s"),
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(SwitchSessionsMsg{})
 			},
@@ -352,11 +372,27 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			ID:          "switch_model",
 			Title:       "Switch Model",
 			Description: "Switch to a different model",
-			Shortcut:    "ctrl+l",
+			Shortcut:    keymap.Shortcut(keymap.ScopeApp+".models", "ctrl+l"),
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(SwitchModelMsg{})
 			},
 		},
+		{
+			ID:          "switch_theme",
+			Title:       "Switch Theme",
+			Description: "Preview and pick a TUI theme",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(SwitchThemeMsg{})
+			},
+		},
+		{
+			ID:          "agents",
+			Title:       "Agents",
+			Description: "List user-defined agents and mention one in the prompt",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(ShowAgentsMsg{})
+			},
+		},
 	}
 
 	// Only show compact command if there's an active session
@@ -371,6 +407,62 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 				})
 			},
 		})
+		commands = append(commands,
+			Command{
+				ID:          "fork_session",
+				Title:       "Fork Session",
+				Description: "Copy the current session into a new window",
+				Handler: func(cmd Command) tea.Cmd {
+					return util.CmdHandler(ForkSessionMsg{
+						SessionID: c.sessionID,
+					})
+				},
+			},
+			Command{
+				ID:          "resume_session",
+				Title:       "Resume Paused Session",
+				Description: "Resume the current session after it was paused for resources or a loop",
+				Handler: func(cmd Command) tea.Cmd {
+					return util.CmdHandler(ResumeSessionMsg{
+						SessionID: c.sessionID,
+					})
+				},
+			},
+			Command{
+				ID:          "undo",
+				Title:       "Undo Last Turn",
+				Description: "Restore files changed by the agent in the last turn",
+				Handler: func(cmd Command) tea.Cmd {
+					return util.CmdHandler(RewindMsg{
+						SessionID: c.sessionID,
+						Turns:     1,
+					})
+				},
+			},
+			Command{
+				ID:          "rewind",
+				Title:       "Rewind Turns",
+				Description: "Restore files changed by the agent in the last N turns",
+				Handler: func(cmd Command) tea.Cmd {
+					sessionID := c.sessionID
+					return util.CmdHandler(ShowArgumentsDialogMsg{
+						CommandID:   cmd.ID,
+						Description: cmd.Description,
+						ArgNames:    []string{"turns"},
+						OnSubmit: func(args map[string]string) tea.Cmd {
+							turns, err := strconv.Atoi(strings.TrimSpace(args["turns"]))
+							if err != nil || turns < 1 {
+								return util.ReportWarn("Number of turns must be a positive integer")
+							}
+							return util.CmdHandler(RewindMsg{
+								SessionID: sessionID,
+								Turns:     turns,
+							})
+						},
+					})
+				},
+			},
+		)
 	}
 
 	// Add reasoning toggle for models that support it
@@ -428,7 +520,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			commands = append(commands, Command{
 				ID:          "file_picker",
 				Title:       "Open File Picker",
-				Shortcut:    "ctrl+f",
+				Shortcut:    keymap.Shortcut(keymap.ScopeChat+".add_attachment", "ctrl+f"),
 				Description: "Open file picker",
 				Handler: func(cmd Command) tea.Cmd {
 					return util.CmdHandler(OpenFilePickerMsg{})
@@ -442,7 +534,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 		commands = append(commands, Command{
 			ID:          "open_external_editor",
 			Title:       "Open External Editor",
-			Shortcut:    "ctrl+o",
+			Shortcut:    keymap.Shortcut(keymap.ScopeEditor+".open_editor", "ctrl+o"),
 			Description: "Open external editor to compose message",
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(OpenExternalEditorMsg{})
@@ -459,10 +551,19 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 				return util.CmdHandler(ToggleYoloModeMsg{})
 			},
 		},
+		{
+			ID:          "toggle_plan",
+			Title:       "Toggle Plan Mode",
+			Shortcut:    keymap.Shortcut(keymap.ScopeEditor+".plan_mode", "shift+tab"),
+			Description: "Plan with read-only tools and approve the plan before anything changes",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(TogglePlanModeMsg{})
+			},
+		},
 		{
 			ID:          "toggle_help",
 			Title:       "Toggle Help",
-			Shortcut:    "ctrl+g",
+			Shortcut:    keymap.Shortcut(keymap.ScopeApp+".help", "ctrl+g"),
 			Description: "Toggle help",
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(ToggleHelpMsg{})
@@ -494,7 +595,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			ID:          "quit",
 			Title:       "Quit",
 			Description: "Quit",
-			Shortcut:    "ctrl+c",
+			Shortcut:    keymap.Shortcut(keymap.ScopeApp+".quit", "ctrl+c"),
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(QuitMsg{})
 			},
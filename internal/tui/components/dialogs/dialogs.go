@@ -5,6 +5,7 @@ import (
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/nexora/nexora/internal/tui/keymap"
 	"github.com/nexora/nexora/internal/tui/util"
 )
 
@@ -52,7 +53,7 @@ type dialogCmp struct {
 func NewDialogCmp() DialogCmp {
 	return dialogCmp{
 		dialogs: []DialogModel{},
-		keyMap:  DefaultKeyMap(),
+		keyMap:  keymap.Apply(keymap.ScopeDialogs, DefaultKeyMap()),
 		idMap:   make(map[DialogID]int),
 	}
 }
@@ -2,9 +2,7 @@ package filepicker
 
 import (
 	"fmt"
-	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"charm.land/bubbles/v2/filepicker"
@@ -17,6 +15,7 @@ import (
 	"github.com/nexora/nexora/internal/tui/components/core"
 	"github.com/nexora/nexora/internal/tui/components/dialogs"
 	"github.com/nexora/nexora/internal/tui/components/image"
+	"github.com/nexora/nexora/internal/tui/keymap"
 	"github.com/nexora/nexora/internal/tui/styles"
 	"github.com/nexora/nexora/internal/tui/util"
 )
@@ -79,7 +78,7 @@ func NewFilePickerCmp(workingDir string) FilePicker {
 	return &model{
 		filePicker: fp,
 		image:      image,
-		keyMap:     DefaultKeyMap(),
+		keyMap:     keymap.Apply(keymap.ScopeFilePicker, DefaultKeyMap()),
 		help:       help,
 	}
 }
@@ -138,15 +137,13 @@ func (m *model) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 					return util.ReportError(fmt.Errorf("file too large, max 5MB"))
 				}
 
-				content, err := os.ReadFile(path)
+				attachment, err := message.ReadAttachment(path)
 				if err != nil {
 					return util.ReportError(fmt.Errorf("unable to read the image: %w", err))
 				}
-
-				mimeBufferSize := min(512, len(content))
-				mimeType := http.DetectContentType(content[:mimeBufferSize])
-				fileName := filepath.Base(path)
-				attachment := message.Attachment{FilePath: path, FileName: fileName, MimeType: mimeType, Content: content}
+				if !attachment.IsImage() {
+					return util.ReportError(fmt.Errorf("%s is not a supported image", attachment.FileName))
+				}
 				return FilePickedMsg{
 					Attachment: attachment,
 				}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/modelstats"
 	"github.com/nexora/nexora/internal/tui/exp/list"
 	"github.com/nexora/nexora/internal/tui/styles"
 	"github.com/nexora/nexora/internal/tui/util"
@@ -20,6 +22,8 @@ type ModelListComponent struct {
 	list      listModel
 	modelType int
 	providers []catwalk.Provider
+	// speeds are the measured model speeds keyed by modelstats.Key.
+	speeds map[string]modelstats.Stats
 }
 
 func modelKey(providerID, modelID string) string {
@@ -54,6 +58,27 @@ func NewModelListComponent(keyMap list.KeyMap, inputPlaceholder string, shouldRe
 	}
 }
 
+// SetSpeeds sets the measured model speeds shown next to each model and
+// rebuilds the list with them.
+func (m *ModelListComponent) SetSpeeds(speeds map[string]modelstats.Stats) tea.Cmd {
+	m.speeds = speeds
+	return m.SetModelType(m.modelType)
+}
+
+// speedInfo returns the measured speed of a model, or an empty string when
+// it has not been measured.
+func (m *ModelListComponent) speedInfo(providerID, modelID string) string {
+	stats, ok := m.speeds[modelstats.Key(providerID, modelID)]
+	if !ok || stats.TokensPerSecond <= 0 {
+		return ""
+	}
+	return formatSpeed(stats)
+}
+
+func formatSpeed(stats modelstats.Stats) string {
+	return fmt.Sprintf("%.0f tok/s · %s", stats.TokensPerSecond, stats.TTFT.Round(10*time.Millisecond))
+}
+
 func (m *ModelListComponent) Init() tea.Cmd {
 	var cmds []tea.Cmd
 	if len(m.providers) == 0 {
@@ -185,6 +210,7 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 					model.Name,
 					modelOption,
 					list.WithCompletionID(key),
+					list.WithCompletionInfo(m.speedInfo(string(configProvider.ID), model.ID)),
 				)
 				itemsByKey[key] = item
 
@@ -326,6 +352,7 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 				model.Name,
 				modelOption,
 				list.WithCompletionID(key),
+				list.WithCompletionInfo(m.speedInfo(string(displayProvider.ID), model.ID)),
 			)
 			itemsByKey[key] = item
 			group.Items = append(group.Items, item)
@@ -360,6 +387,7 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 				option.Value(),
 				list.WithCompletionID(recentID),
 				list.WithCompletionShortcut(providerName),
+				list.WithCompletionInfo(m.speedInfo(recent.Provider, recent.Model)),
 			)
 			recentGroup.Items = append(recentGroup.Items, item)
 			if recent.Model == currentModel.Model && recent.Provider == currentModel.Provider {
@@ -13,10 +13,12 @@ import (
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/nexora/nexora/internal/config"
 	"github.com/nexora/nexora/internal/config/providers"
+	"github.com/nexora/nexora/internal/modelstats"
 	"github.com/nexora/nexora/internal/tui/components/core"
 	"github.com/nexora/nexora/internal/tui/components/dialogs"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/claude"
 	"github.com/nexora/nexora/internal/tui/exp/list"
+	"github.com/nexora/nexora/internal/tui/keymap"
 	"github.com/nexora/nexora/internal/tui/styles"
 	"github.com/nexora/nexora/internal/tui/util"
 )
@@ -41,6 +43,12 @@ type ModelSelectedMsg struct {
 	ModelType config.SelectedModelType
 }
 
+// SpeedsLoadedMsg carries the measured model speeds, keyed by
+// modelstats.Key, shown next to each model.
+type SpeedsLoadedMsg struct {
+	Speeds map[string]modelstats.Stats
+}
+
 // CloseModelDialogMsg is sent when a model is selected
 type CloseModelDialogMsg struct{}
 
@@ -82,8 +90,10 @@ type modelDialogCmp struct {
 	showLocalModels   bool
 }
 
+// NewModelDialogCmp creates the model picker. Measured model speeds are
+// shown once a SpeedsLoadedMsg arrives.
 func NewModelDialogCmp() ModelDialog {
-	keyMap := DefaultKeyMap()
+	keyMap := keymap.Apply(keymap.ScopeModels, DefaultKeyMap())
 
 	listKeyMap := list.DefaultKeyMap()
 	listKeyMap.Down.SetEnabled(false)
@@ -102,7 +112,7 @@ func NewModelDialogCmp() ModelDialog {
 		modelList:   modelList,
 		apiKeyInput: apiKeyInput,
 		width:       defaultWidth,
-		keyMap:      DefaultKeyMap(),
+		keyMap:      keyMap,
 		help:        help,
 
 		claudeAuthMethodChooser: claude.NewAuthMethodChooser(),
@@ -132,6 +142,8 @@ func (m *modelDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	}
 
 	switch msg := msg.(type) {
+	case SpeedsLoadedMsg:
+		return m, m.modelList.SetSpeeds(msg.Speeds)
 	case LocalModelsDetectComplete:
 		// Handle successful detection from local dialog
 		m.showLocalModels = false
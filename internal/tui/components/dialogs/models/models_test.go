@@ -10,6 +10,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/nexora/nexora/internal/config"
+	"github.com/nexora/nexora/internal/modelstats"
 	"github.com/nexora/nexora/internal/tui/exp/list"
 )
 
@@ -1098,3 +1099,22 @@ func extractCategories(models []Model) []string {
 func timeNow() time.Time {
 	return time.Now()
 }
+
+func TestModelListSpeedInfo(t *testing.T) {
+	setupTestConfig(t)
+	m := NewModelListComponent(list.DefaultKeyMap(), "", false)
+	if got := m.speedInfo("openai", "gpt-4o"); got != "" {
+		t.Errorf("expected no speed without measurements, got %q", got)
+	}
+
+	m.SetSpeeds(map[string]modelstats.Stats{
+		modelstats.Key("openai", "gpt-4o"): {Samples: 3, TTFT: 423 * time.Millisecond, TokensPerSecond: 61.6},
+		modelstats.Key("local", "qwen"):    {Samples: 1, TTFT: 100 * time.Millisecond},
+	})
+	if got, want := m.speedInfo("openai", "gpt-4o"), "62 tok/s · 420ms"; got != want {
+		t.Errorf("speedInfo() = %q, want %q", got, want)
+	}
+	if got := m.speedInfo("local", "qwen"); got != "" {
+		t.Errorf("expected no speed without output measurements, got %q", got)
+	}
+}
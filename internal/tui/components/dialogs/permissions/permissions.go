@@ -16,6 +16,7 @@ import (
 	"github.com/nexora/nexora/internal/permission"
 	"github.com/nexora/nexora/internal/tui/components/core"
 	"github.com/nexora/nexora/internal/tui/components/dialogs"
+	"github.com/nexora/nexora/internal/tui/keymap"
 	"github.com/nexora/nexora/internal/tui/styles"
 	"github.com/nexora/nexora/internal/tui/util"
 )
@@ -51,6 +52,7 @@ type permissionDialogCmp struct {
 	permission      permission.PermissionRequest
 	contentViewPort viewport.Model
 	selectedOption  int // 0: Allow, 1: Allow for session, 2: Deny
+	window          string
 
 	// Diff view state
 	defaultDiffSplitMode bool  // true for split, false for unified
@@ -82,7 +84,8 @@ func NewPermissionDialogCmp(permission permission.PermissionRequest, opts *Optio
 		selectedOption:  0, // Default to "Allow"
 		permission:      permission,
 		diffSplitMode:   opts.isSplitMode(),
-		keyMap:          DefaultKeyMap(),
+		window:          opts.Window,
+		keyMap:          keymap.Apply(keymap.ScopePermissions, DefaultKeyMap()),
 		contentDirty:    true, // Mark as dirty initially
 	}
 }
@@ -92,7 +95,8 @@ func (p *permissionDialogCmp) Init() tea.Cmd {
 }
 
 func (p *permissionDialogCmp) supportsDiffView() bool {
-	return p.permission.ToolName == tools.EditToolName || p.permission.ToolName == tools.WriteToolName || p.permission.ToolName == tools.MultiEditToolName
+	return p.permission.ToolName == tools.EditToolName || p.permission.ToolName == tools.WriteToolName || p.permission.ToolName == tools.MultiEditToolName ||
+		p.permission.ToolName == tools.NotebookEditToolName
 }
 
 func (p *permissionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
@@ -367,6 +371,29 @@ func (p *permissionDialogCmp) renderHeader() string {
 			),
 			baseStyle.Render(strings.Repeat(" ", p.width)),
 		)
+	case tools.NotebookEditToolName:
+		params := p.permission.Params.(tools.NotebookEditPermissionsParams)
+		fileKey := t.S().Muted.Render("Notebook")
+		filePath := t.S().Text.
+			Width(p.width - lipgloss.Width(fileKey)).
+			Render(fmt.Sprintf(" %s", fsext.PrettyPath(params.FilePath)))
+		cellKey := t.S().Muted.Render("Cell")
+		cell := t.S().Text.
+			Width(p.width - lipgloss.Width(cellKey)).
+			Render(fmt.Sprintf(" %d (%s)", params.CellIndex, params.EditMode))
+		headerParts = append(headerParts,
+			lipgloss.JoinHorizontal(
+				lipgloss.Left,
+				fileKey,
+				filePath,
+			),
+			lipgloss.JoinHorizontal(
+				lipgloss.Left,
+				cellKey,
+				cell,
+			),
+			baseStyle.Render(strings.Repeat(" ", p.width)),
+		)
 	case tools.MultiEditToolName:
 		params := p.permission.Params.(tools.MultiEditPermissionsParams)
 		fileKey := t.S().Muted.Render("File")
@@ -441,6 +468,8 @@ func (p *permissionDialogCmp) getOrGenerateContent() string {
 		content = p.generateEditContent()
 	case tools.WriteToolName:
 		content = p.generateWriteContent()
+	case tools.NotebookEditToolName:
+		content = p.generateNotebookEditContent()
 	case tools.MultiEditToolName:
 		content = p.generateMultiEditContent()
 	case tools.FetchToolName:
@@ -548,6 +577,26 @@ func (p *permissionDialogCmp) generateWriteContent() string {
 	return ""
 }
 
+func (p *permissionDialogCmp) generateNotebookEditContent() string {
+	if pr, ok := p.permission.Params.(tools.NotebookEditPermissionsParams); ok {
+		cell := fmt.Sprintf("%s [cell %d]", fsext.PrettyPath(pr.FilePath), pr.CellIndex)
+		formatter := core.DiffFormatter().
+			Before(cell, pr.OldSource).
+			After(cell, pr.NewSource).
+			Height(p.contentViewPort.Height()).
+			Width(p.contentViewPort.Width()).
+			XOffset(p.diffXOffset).
+			YOffset(p.diffYOffset)
+		if p.useDiffSplitMode() {
+			formatter = formatter.Split()
+		} else {
+			formatter = formatter.Unified()
+		}
+		return formatter.String()
+	}
+	return ""
+}
+
 func (p *permissionDialogCmp) generateDownloadContent() string {
 	t := styles.CurrentTheme()
 	baseStyle := t.S().Base.Background(t.BgSubtle)
@@ -740,7 +789,11 @@ func (p *permissionDialogCmp) styleViewport() string {
 func (p *permissionDialogCmp) render() string {
 	t := styles.CurrentTheme()
 	baseStyle := t.S().Base
-	title := core.Title("Permission Required", p.width-4)
+	titleText := "Permission Required"
+	if p.window != "" {
+		titleText += " · " + p.window
+	}
+	title := core.Title(titleText, p.width-4)
 	// Render header
 	headerContent := p.renderHeader()
 	// Render buttons
@@ -817,7 +870,7 @@ func (p *permissionDialogCmp) SetSize() tea.Cmd {
 	case tools.EditToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.8)
-	case tools.WriteToolName:
+	case tools.WriteToolName, tools.NotebookEditToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.8)
 	case tools.MultiEditToolName:
@@ -880,6 +933,7 @@ func (p *permissionDialogCmp) Position() (int, int) {
 // Options for create a new permission dialog
 type Options struct {
 	DiffMode string // split or unified, empty means use defaultDiffSplitMode
+	Window   string // window the request comes from, empty for the window on screen
 }
 
 // isSplitMode returns internal representation of diff mode switch
@@ -602,6 +602,27 @@ func TestPermissionDialogCmp_View(t *testing.T) {
 	}
 }
 
+func TestPermissionDialogCmp_WindowBadge(t *testing.T) {
+	permission := permission.PermissionRequest{
+		ID:        "test-id",
+		SessionID: "session-2",
+		ToolName:  tools.BashToolName,
+		Params:    tools.BashPermissionsParams{Command: "ls"},
+	}
+
+	dialog := NewPermissionDialogCmp(permission, &Options{Window: "Window 2: refactor"})
+	dialog.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	if view := dialog.View(); !strings.Contains(view, "Window 2: refactor") {
+		t.Error("expected view to name the window of the request")
+	}
+
+	dialog = NewPermissionDialogCmp(permission, nil)
+	dialog.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	if view := dialog.View(); strings.Contains(view, "Window") {
+		t.Error("expected no window badge for the window on screen")
+	}
+}
+
 func TestPermissionDialogCmp_Position(t *testing.T) {
 	permission := permission.PermissionRequest{
 		ID:         "test-id",
@@ -0,0 +1,58 @@
+package plan
+
+import (
+	"charm.land/bubbles/v2/key"
+)
+
+// KeyMap defines the keyboard bindings for the plan dialog.
+type KeyMap struct {
+	Down,
+	Up,
+	Approve,
+	Reject key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Down: key.NewBinding(
+			key.WithKeys("down", "j", "ctrl+n"),
+			key.WithHelp("↓/j", "scroll down"),
+		),
+		Up: key.NewBinding(
+			key.WithKeys("up", "k", "ctrl+p"),
+			key.WithHelp("↑/k", "scroll up"),
+		),
+		Approve: key.NewBinding(
+			key.WithKeys("enter", "y", "Y"),
+			key.WithHelp("enter/y", "execute plan"),
+		),
+		Reject: key.NewBinding(
+			key.WithKeys("esc", "n", "N"),
+			key.WithHelp("esc/n", "keep planning"),
+		),
+	}
+}
+
+// KeyBindings implements layout.KeyMapProvider
+func (k KeyMap) KeyBindings() []key.Binding {
+	return []key.Binding{
+		k.Down,
+		k.Up,
+		k.Approve,
+		k.Reject,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.KeyBindings()}
+}
+
+// ShortHelp implements help.KeyMap.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		k.Down,
+		k.Approve,
+		k.Reject,
+	}
+}
@@ -0,0 +1,160 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/nexora/nexora/internal/task"
+	"github.com/nexora/nexora/internal/tui/components/core"
+	"github.com/nexora/nexora/internal/tui/components/dialogs"
+	"github.com/nexora/nexora/internal/tui/keymap"
+	"github.com/nexora/nexora/internal/tui/styles"
+	"github.com/nexora/nexora/internal/tui/util"
+)
+
+const (
+	PlanDialogID dialogs.DialogID = "plan"
+
+	defaultWidth = 90
+	maxHeight    = 24
+)
+
+// PlanResponseMsg is sent when the user approves or rejects a plan.
+type PlanResponseMsg struct {
+	SessionID string
+	Approved  bool
+}
+
+// PlanDialog shows a submitted plan for the user to approve before it is
+// executed.
+type PlanDialog interface {
+	dialogs.DialogModel
+}
+
+type planDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	plan   task.Plan
+	offset int
+	keyMap KeyMap
+	help   help.Model
+}
+
+// NewPlanDialog creates a dialog asking to approve plan.
+func NewPlanDialog(plan task.Plan) PlanDialog {
+	t := styles.CurrentTheme()
+	h := help.New()
+	h.Styles = t.S().Help
+	return &planDialogCmp{
+		plan:   plan,
+		keyMap: keymap.Apply(keymap.ScopePlan, DefaultKeyMap()),
+		help:   h,
+	}
+}
+
+func (p *planDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (p *planDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.wWidth = msg.Width
+		p.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, p.keyMap.Down):
+			p.offset = min(p.offset+1, p.maxOffset())
+		case key.Matches(msg, p.keyMap.Up):
+			p.offset = max(p.offset-1, 0)
+		case key.Matches(msg, p.keyMap.Approve):
+			return p, p.respond(true)
+		case key.Matches(msg, p.keyMap.Reject):
+			return p, p.respond(false)
+		}
+	}
+	return p, nil
+}
+
+func (p *planDialogCmp) respond(approved bool) tea.Cmd {
+	return tea.Sequence(
+		util.CmdHandler(dialogs.CloseDialogMsg{}),
+		util.CmdHandler(PlanResponseMsg{SessionID: p.plan.SessionID, Approved: approved}),
+	)
+}
+
+func (p *planDialogCmp) width() int {
+	return min(defaultWidth, max(p.wWidth-4, 40))
+}
+
+func (p *planDialogCmp) height() int {
+	return min(maxHeight, max(p.wHeight-12, 5))
+}
+
+func (p *planDialogCmp) maxOffset() int {
+	return max(0, len(p.lines(p.width()-4))-p.height())
+}
+
+// lines renders the plan as a checklist wrapped to width.
+func (p *planDialogCmp) lines(width int) []string {
+	t := styles.CurrentTheme()
+	var lines []string
+	if p.plan.Summary != "" {
+		summary := t.S().Base.Width(width).Render(p.plan.Summary)
+		lines = append(lines, strings.Split(summary, "\n")...)
+		lines = append(lines, "")
+	}
+	indent := t.S().Base.PaddingLeft(4).Width(width)
+	for i, step := range p.plan.Steps {
+		title := fmt.Sprintf("☐ %d. %s", i+1, step.Title)
+		lines = append(lines, strings.Split(t.S().Base.Width(width).Render(title), "\n")...)
+		if step.Details != "" {
+			details := indent.Foreground(t.FgMuted).Render(step.Details)
+			lines = append(lines, strings.Split(details, "\n")...)
+		}
+		if len(step.Files) > 0 {
+			files := indent.Foreground(t.FgSubtle).Render(strings.Join(step.Files, ", "))
+			lines = append(lines, strings.Split(files, "\n")...)
+		}
+	}
+	return lines
+}
+
+func (p *planDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	width := p.width()
+	contentWidth := width - 4
+
+	lines := p.lines(contentWidth)
+	p.offset = min(p.offset, max(0, len(lines)-p.height()))
+	end := min(len(lines), p.offset+p.height())
+
+	parts := []string{core.Title("Review Plan", contentWidth), ""}
+	parts = append(parts, lines[p.offset:end]...)
+	if end < len(lines) {
+		parts = append(parts, t.S().Subtle.Render(fmt.Sprintf("… %d more line(s)", len(lines)-end)))
+	}
+	parts = append(parts, "", p.help.View(p.keyMap))
+
+	return t.S().Base.
+		Padding(0, 1).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Width(width).
+		Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
+
+func (p *planDialogCmp) Position() (int, int) {
+	row := max(0, p.wHeight/2-(p.height()+6)/2)
+	col := max(0, p.wWidth/2-p.width()/2)
+	return row, col
+}
+
+func (p *planDialogCmp) ID() dialogs.DialogID {
+	return PlanDialogID
+}
@@ -0,0 +1,122 @@
+package plan
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/nexora/nexora/internal/task"
+	"github.com/nexora/nexora/internal/tui/components/dialogs"
+)
+
+func testPlan() task.Plan {
+	return task.Plan{
+		SessionID: "s1",
+		Summary:   "Rename the config loader",
+		Steps: []task.PlanStep{
+			{Title: "Rename Load to Read", Details: "Update every caller", Files: []string{"config/load.go"}},
+			{Title: "Run the tests"},
+		},
+		Status: task.PlanPending,
+	}
+}
+
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	// tea.Sequence wraps its commands in an unexported slice type.
+	v := reflect.ValueOf(msg)
+	if v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeFor[tea.Cmd]() {
+		var msgs []tea.Msg
+		for i := range v.Len() {
+			msgs = append(msgs, runCmd(v.Index(i).Interface().(tea.Cmd))...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+func TestPlanDialog_View(t *testing.T) {
+	dialog := NewPlanDialog(testPlan())
+	dialog.Update(tea.WindowSizeMsg{Width: 120, Height: 50})
+
+	view := dialog.View()
+	for _, want := range []string{"Review Plan", "Rename the config loader", "1. Rename Load to Read", "Update every caller", "config/load.go", "2. Run the tests"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected view to contain %q", want)
+		}
+	}
+	if dialog.ID() != PlanDialogID {
+		t.Errorf("expected dialog ID %q, got %q", PlanDialogID, dialog.ID())
+	}
+}
+
+func TestPlanDialog_Respond(t *testing.T) {
+	tests := []struct {
+		name         string
+		key          tea.Key
+		wantApproved bool
+	}{
+		{name: "enter approves", key: tea.Key{Code: tea.KeyEnter}, wantApproved: true},
+		{name: "y approves", key: tea.Key{Code: 'y', Text: "y"}, wantApproved: true},
+		{name: "esc rejects", key: tea.Key{Code: tea.KeyEscape}},
+		{name: "n rejects", key: tea.Key{Code: 'n', Text: "n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialog := NewPlanDialog(testPlan())
+			_, cmd := dialog.Update(tea.KeyPressMsg(tt.key))
+
+			var closed bool
+			var response *PlanResponseMsg
+			for _, msg := range runCmd(cmd) {
+				switch msg := msg.(type) {
+				case dialogs.CloseDialogMsg:
+					closed = true
+				case PlanResponseMsg:
+					response = &msg
+				}
+			}
+			if !closed {
+				t.Error("expected dialog to close")
+			}
+			if response == nil {
+				t.Fatal("expected a plan response")
+			}
+			if response.SessionID != "s1" || response.Approved != tt.wantApproved {
+				t.Errorf("unexpected response %+v", *response)
+			}
+		})
+	}
+}
+
+func TestPlanDialog_Scroll(t *testing.T) {
+	plan := testPlan()
+	for i := range 40 {
+		plan.Steps = append(plan.Steps, task.PlanStep{Title: fmt.Sprintf("Step %d", i)})
+	}
+	dialog := NewPlanDialog(plan).(*planDialogCmp)
+	dialog.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+
+	if !strings.Contains(dialog.View(), "more line(s)") {
+		t.Error("expected long plans to be truncated")
+	}
+	dialog.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyUp}))
+	if dialog.offset != 0 {
+		t.Errorf("expected offset to stay at 0, got %d", dialog.offset)
+	}
+	for range 100 {
+		dialog.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyDown}))
+	}
+	if dialog.offset != dialog.maxOffset() || dialog.offset == 0 {
+		t.Errorf("expected offset to stop at %d, got %d", dialog.maxOffset(), dialog.offset)
+	}
+	if !strings.Contains(dialog.View(), "Step 39") {
+		t.Error("expected the last step after scrolling down")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/nexora/nexora/internal/tui/components/dialogs"
+	"github.com/nexora/nexora/internal/tui/keymap"
 	"github.com/nexora/nexora/internal/tui/styles"
 	"github.com/nexora/nexora/internal/tui/util"
 )
@@ -31,7 +32,7 @@ type quitDialogCmp struct {
 func NewQuitDialog() QuitDialog {
 	return &quitDialogCmp{
 		selectedNo: true, // Default to "No" for safety
-		keymap:     DefaultKeymap(),
+		keymap:     keymap.Apply(keymap.ScopeQuit, DefaultKeymap()),
 	}
 }
 
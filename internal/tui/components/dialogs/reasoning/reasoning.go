@@ -12,6 +12,7 @@ import (
 	"github.com/nexora/nexora/internal/tui/components/core"
 	"github.com/nexora/nexora/internal/tui/components/dialogs"
 	"github.com/nexora/nexora/internal/tui/exp/list"
+	"github.com/nexora/nexora/internal/tui/keymap"
 	"github.com/nexora/nexora/internal/tui/styles"
 	"github.com/nexora/nexora/internal/tui/util"
 )
@@ -87,7 +88,7 @@ func (k ReasoningDialogKeyMap) FullHelp() [][]key.Binding {
 }
 
 func NewReasoningDialog() ReasoningDialog {
-	keyMap := DefaultReasoningDialogKeyMap()
+	keyMap := keymap.Apply(keymap.ScopeReasoning, DefaultReasoningDialogKeyMap())
 	listKeyMap := list.DefaultKeyMap()
 	listKeyMap.Down.SetEnabled(false)
 	listKeyMap.Up.SetEnabled(false)
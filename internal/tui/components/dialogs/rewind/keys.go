@@ -0,0 +1,65 @@
+package rewind
+
+import (
+	"charm.land/bubbles/v2/key"
+)
+
+// KeyMap defines the keyboard bindings for the rewind dialog.
+type KeyMap struct {
+	Next,
+	Previous,
+	Confirm,
+	Force,
+	Close key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Next: key.NewBinding(
+			key.WithKeys("down", "j", "ctrl+n"),
+			key.WithHelp("↓/j", "next file"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "k", "ctrl+p"),
+			key.WithHelp("↑/k", "previous file"),
+		),
+		Confirm: key.NewBinding(
+			key.WithKeys("enter", "y", "Y"),
+			key.WithHelp("enter/y", "restore"),
+		),
+		Force: key.NewBinding(
+			key.WithKeys("f", "F"),
+			key.WithHelp("f", "restore, overwriting conflicts"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "n", "N"),
+			key.WithHelp("esc/n", "cancel"),
+		),
+	}
+}
+
+// KeyBindings implements layout.KeyMapProvider
+func (k KeyMap) KeyBindings() []key.Binding {
+	return []key.Binding{
+		k.Next,
+		k.Previous,
+		k.Confirm,
+		k.Force,
+		k.Close,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.KeyBindings()}
+}
+
+// ShortHelp implements help.KeyMap.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		k.Next,
+		k.Confirm,
+		k.Force,
+		k.Close,
+	}
+}
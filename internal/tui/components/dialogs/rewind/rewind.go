@@ -0,0 +1,187 @@
+package rewind
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/nexora/nexora/internal/fsext"
+	"github.com/nexora/nexora/internal/history"
+	"github.com/nexora/nexora/internal/tui/components/core"
+	"github.com/nexora/nexora/internal/tui/components/dialogs"
+	"github.com/nexora/nexora/internal/tui/keymap"
+	"github.com/nexora/nexora/internal/tui/styles"
+	"github.com/nexora/nexora/internal/tui/util"
+)
+
+const (
+	RewindDialogID dialogs.DialogID = "rewind"
+
+	defaultWidth      = 90
+	maxFileListHeight = 8
+	diffHeight        = 16
+)
+
+// ApplyRewindMsg is sent when the user confirms a rewind.
+type ApplyRewindMsg struct {
+	Plan  history.RewindPlan
+	Force bool
+}
+
+// RewindDialog previews the files a rewind restores before applying it.
+type RewindDialog interface {
+	dialogs.DialogModel
+}
+
+type rewindDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	title    string
+	plan     history.RewindPlan
+	selected int
+	keyMap   KeyMap
+	help     help.Model
+}
+
+// NewRewindDialog creates a dialog previewing plan.
+func NewRewindDialog(title string, plan history.RewindPlan) RewindDialog {
+	t := styles.CurrentTheme()
+	h := help.New()
+	h.Styles = t.S().Help
+	return &rewindDialogCmp{
+		title:  title,
+		plan:   plan,
+		keyMap: keymap.Apply(keymap.ScopeRewind, DefaultKeyMap()),
+		help:   h,
+	}
+}
+
+func (r *rewindDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (r *rewindDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		r.wWidth = msg.Width
+		r.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, r.keyMap.Next):
+			if r.selected < len(r.plan.Changes)-1 {
+				r.selected++
+			}
+		case key.Matches(msg, r.keyMap.Previous):
+			if r.selected > 0 {
+				r.selected--
+			}
+		case key.Matches(msg, r.keyMap.Confirm):
+			return r, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(ApplyRewindMsg{Plan: r.plan}),
+			)
+		case key.Matches(msg, r.keyMap.Force):
+			return r, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(ApplyRewindMsg{Plan: r.plan, Force: true}),
+			)
+		case key.Matches(msg, r.keyMap.Close):
+			return r, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	return r, nil
+}
+
+func (r *rewindDialogCmp) width() int {
+	return min(defaultWidth, max(r.wWidth-4, 40))
+}
+
+func (r *rewindDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	width := r.width()
+	contentWidth := width - 4
+
+	parts := []string{core.Title(r.title, contentWidth), ""}
+
+	if conflicts := len(r.plan.Conflicts()); conflicts > 0 {
+		parts = append(parts,
+			t.S().Warning.Render(fmt.Sprintf("%d file(s) were edited after the agent changed them. Press f to overwrite.", conflicts)),
+			"",
+		)
+	}
+
+	start := max(0, r.selected-maxFileListHeight+1)
+	end := min(len(r.plan.Changes), start+maxFileListHeight)
+	for i := start; i < end; i++ {
+		parts = append(parts, r.renderChange(i, contentWidth))
+	}
+
+	if len(r.plan.Changes) > 0 {
+		c := r.plan.Changes[r.selected]
+		path := fsext.PrettyPath(c.Path)
+		diff := core.DiffFormatter().
+			Before(path, c.Current).
+			After(path, c.Restored).
+			Height(diffHeight).
+			Width(contentWidth).
+			Unified().
+			String()
+		parts = append(parts, "", diff)
+	}
+
+	parts = append(parts, "", r.help.View(r.keyMap))
+
+	return t.S().Base.
+		Padding(0, 1).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Width(width).
+		Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
+
+func (r *rewindDialogCmp) renderChange(i, width int) string {
+	t := styles.CurrentTheme()
+	c := r.plan.Changes[i]
+
+	_, additions, removals := c.Diff()
+	var info []string
+	if c.Remove {
+		info = append(info, t.S().Subtle.Render("remove"))
+	}
+	if additions > 0 {
+		info = append(info, t.S().Base.Foreground(t.Success).Render(fmt.Sprintf("+%d", additions)))
+	}
+	if removals > 0 {
+		info = append(info, t.S().Base.Foreground(t.Error).Render(fmt.Sprintf("-%d", removals)))
+	}
+	if c.Conflict {
+		info = append(info, t.S().Warning.Render("conflict"))
+	}
+
+	icon := "  "
+	titleColor := t.FgMuted
+	if i == r.selected {
+		icon = t.S().Base.Foreground(t.Primary).Render("▸ ")
+		titleColor = t.FgBase
+	}
+	return core.Status(core.StatusOpts{
+		Icon:         icon,
+		Title:        fsext.PrettyPath(c.Path),
+		TitleColor:   titleColor,
+		ExtraContent: strings.Join(info, " "),
+	}, width)
+}
+
+func (r *rewindDialogCmp) Position() (int, int) {
+	row := max(0, r.wHeight/2-(diffHeight+maxFileListHeight+8)/2)
+	col := max(0, r.wWidth/2-r.width()/2)
+	return row, col
+}
+
+func (r *rewindDialogCmp) ID() dialogs.DialogID {
+	return RewindDialogID
+}
@@ -0,0 +1,110 @@
+package rewind
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/nexora/nexora/internal/history"
+	"github.com/nexora/nexora/internal/tui/components/dialogs"
+)
+
+func testPlan() history.RewindPlan {
+	return history.RewindPlan{
+		SessionID: "s1",
+		Changes: []history.RewindChange{
+			{Path: "a.go", Current: "new\n", Restored: "old\n"},
+			{Path: "b.go", Current: "user\n", Restored: "old\n", Conflict: true},
+		},
+	}
+}
+
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	// tea.Sequence wraps its commands in an unexported slice type.
+	v := reflect.ValueOf(msg)
+	if v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeFor[tea.Cmd]() {
+		var msgs []tea.Msg
+		for i := range v.Len() {
+			msgs = append(msgs, runCmd(v.Index(i).Interface().(tea.Cmd))...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+func TestRewindDialog_View(t *testing.T) {
+	dialog := NewRewindDialog("Undo Last Turn", testPlan())
+	dialog.Update(tea.WindowSizeMsg{Width: 120, Height: 50})
+
+	view := dialog.View()
+	for _, want := range []string{"Undo Last Turn", "a.go", "b.go", "conflict"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected view to contain %q", want)
+		}
+	}
+	if dialog.ID() != RewindDialogID {
+		t.Errorf("expected dialog ID %q, got %q", RewindDialogID, dialog.ID())
+	}
+}
+
+func TestRewindDialog_Confirm(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       tea.Key
+		wantApply bool
+		wantForce bool
+	}{
+		{name: "enter restores", key: tea.Key{Code: tea.KeyEnter}, wantApply: true},
+		{name: "f forces", key: tea.Key{Code: 'f', Text: "f"}, wantApply: true, wantForce: true},
+		{name: "esc cancels", key: tea.Key{Code: tea.KeyEscape}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialog := NewRewindDialog("Undo", testPlan())
+			_, cmd := dialog.Update(tea.KeyPressMsg(tt.key))
+
+			var closed bool
+			var apply *ApplyRewindMsg
+			for _, msg := range runCmd(cmd) {
+				switch msg := msg.(type) {
+				case dialogs.CloseDialogMsg:
+					closed = true
+				case ApplyRewindMsg:
+					apply = &msg
+				}
+			}
+			if !closed {
+				t.Error("expected dialog to close")
+			}
+			if (apply != nil) != tt.wantApply {
+				t.Fatalf("expected apply=%v, got %v", tt.wantApply, apply != nil)
+			}
+			if apply != nil && apply.Force != tt.wantForce {
+				t.Errorf("expected force=%v, got %v", tt.wantForce, apply.Force)
+			}
+		})
+	}
+}
+
+func TestRewindDialog_Navigation(t *testing.T) {
+	dialog := NewRewindDialog("Undo", testPlan()).(*rewindDialogCmp)
+
+	dialog.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyDown}))
+	if dialog.selected != 1 {
+		t.Errorf("expected selection 1, got %d", dialog.selected)
+	}
+	dialog.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyDown}))
+	if dialog.selected != 1 {
+		t.Errorf("expected selection to stay at 1, got %d", dialog.selected)
+	}
+	dialog.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyUp}))
+	if dialog.selected != 0 {
+		t.Errorf("expected selection 0, got %d", dialog.selected)
+	}
+}
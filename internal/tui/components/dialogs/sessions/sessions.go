@@ -10,6 +10,7 @@ import (
 	"github.com/nexora/nexora/internal/tui/components/core"
 	"github.com/nexora/nexora/internal/tui/components/dialogs"
 	"github.com/nexora/nexora/internal/tui/exp/list"
+	"github.com/nexora/nexora/internal/tui/keymap"
 	"github.com/nexora/nexora/internal/tui/styles"
 	"github.com/nexora/nexora/internal/tui/util"
 )
@@ -38,7 +39,7 @@ type sessionDialogCmp struct {
 func NewSessionDialogCmp(sessions []session.Session, selectedID string) SessionDialog {
 	t := styles.CurrentTheme()
 	listKeyMap := list.DefaultKeyMap()
-	keyMap := DefaultKeyMap()
+	keyMap := keymap.Apply(keymap.ScopeSessions, DefaultKeyMap())
 	listKeyMap.Down.SetEnabled(false)
 	listKeyMap.Up.SetEnabled(false)
 	listKeyMap.DownOneItem = keyMap.Next
@@ -65,7 +66,7 @@ func NewSessionDialogCmp(sessions []session.Session, selectedID string) SessionD
 	help.Styles = t.S().Help
 	s := &sessionDialogCmp{
 		selectedSessionID: selectedID,
-		keyMap:            DefaultKeyMap(),
+		keyMap:            keyMap,
 		sessionsList:      sessionsList,
 		help:              help,
 	}
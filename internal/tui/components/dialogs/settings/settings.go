@@ -5,6 +5,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/nexora/nexora/internal/tui/components/dialogs"
+	"github.com/nexora/nexora/internal/tui/keymap"
 	"github.com/nexora/nexora/internal/tui/styles"
 	"github.com/nexora/nexora/internal/tui/util"
 )
@@ -56,7 +57,7 @@ type settingsDialogCmp struct {
 // NewSettingsDialog creates a new settings dialog
 func NewSettingsDialog(settings SettingsManager) SettingsDialog {
 	s := &settingsDialogCmp{
-		keymap:   DefaultKeymap(),
+		keymap:   keymap.Apply(keymap.ScopeSettings, DefaultKeymap()),
 		settings: settings,
 		cursor:   0,
 	}
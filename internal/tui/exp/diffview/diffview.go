@@ -31,6 +31,15 @@ const (
 	layoutSplit
 )
 
+// Annotation is a note rendered below a line of the "after" file in the
+// unified layout, such as a review comment.
+type Annotation struct {
+	// Line is the 1-based line number in the "after" file.
+	Line  int
+	Text  string
+	Style lipgloss.Style
+}
+
 // DiffView represents a view for displaying differences between two files.
 type DiffView struct {
 	layout          layout
@@ -46,6 +55,7 @@ type DiffView struct {
 	style           Style
 	tabWidth        int
 	chromaStyle     *chroma.Style
+	annotations     map[int][]Annotation
 
 	isComputed bool
 	err        error
@@ -135,6 +145,16 @@ func (dv *DiffView) LineNumbers(lineNumbers bool) *DiffView {
 	return dv
 }
 
+// Annotations sets notes to render below lines of the "after" file. They are
+// only shown in the unified layout.
+func (dv *DiffView) Annotations(annotations ...Annotation) *DiffView {
+	dv.annotations = make(map[int][]Annotation, len(annotations))
+	for _, a := range annotations {
+		dv.annotations[a.Line] = append(dv.annotations[a.Line], a)
+	}
+	return dv
+}
+
 // Height sets the height of the DiffView.
 func (dv *DiffView) Height(height int) *DiffView {
 	dv.height = height
@@ -306,6 +326,13 @@ func (dv *DiffView) detectTotalLines() {
 	case layoutUnified:
 		for _, h := range dv.unified.Hunks {
 			dv.totalLines += 1 + len(h.Lines)
+			afterLine := h.ToLine
+			for _, l := range h.Lines {
+				if l.Kind != udiff.Delete {
+					dv.totalLines += len(dv.annotations[afterLine])
+					afterLine++
+				}
+			}
 		}
 	case layoutSplit:
 		for _, h := range dv.splitHunks {
@@ -429,6 +456,8 @@ outer:
 		afterLine := h.ToLine
 
 		for j, l := range h.Lines {
+			annotatedLine := 0
+
 			// print ellipis if we don't have enough space to print the rest of the diff
 			hasReachedHeight := dv.height > 0 && printedLines+1 == dv.height
 			isLastHunk := i+1 == len(dv.unified.Hunks)
@@ -462,6 +491,7 @@ outer:
 					))
 				}
 				beforeLine++
+				annotatedLine = afterLine
 				afterLine++
 			case udiff.Insert:
 				if shouldWrite() {
@@ -476,6 +506,7 @@ outer:
 							ls.Code.Width(dv.codeWidth).Render(content),
 					))
 				}
+				annotatedLine = afterLine
 				afterLine++
 			case udiff.Delete:
 				if shouldWrite() {
@@ -497,12 +528,35 @@ outer:
 			}
 
 			printedLines++
+			printedLines = dv.writeAnnotations(&b, annotatedLine, printedLines)
 		}
 	}
 
 	return b.String()
 }
 
+// writeAnnotations writes the annotations for afterLine, one per line, and
+// returns the updated printed line count.
+func (dv *DiffView) writeAnnotations(b *strings.Builder, afterLine, printedLines int) int {
+	if afterLine < 1 {
+		return printedLines
+	}
+	for _, a := range dv.annotations[afterLine] {
+		if printedLines >= 0 {
+			if dv.lineNumbers {
+				ls := dv.style.EqualLine
+				b.WriteString(ls.LineNumber.Render(pad(" ", dv.beforeNumDigits)))
+				b.WriteString(ls.LineNumber.Render(pad(" ", dv.afterNumDigits)))
+			}
+			text := ansi.Truncate("▲ "+a.Text, dv.fullCodeWidth, "…")
+			b.WriteString(a.Style.Width(dv.fullCodeWidth).Render(text))
+			b.WriteRune('\n')
+		}
+		printedLines++
+	}
+	return printedLines
+}
+
 // renderSplit renders the split (side-by-side) diff view as a string.
 func (dv *DiffView) renderSplit() string {
 	var b strings.Builder
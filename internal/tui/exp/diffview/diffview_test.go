@@ -330,6 +330,26 @@ func TestDiffViewYOffsetInfinite(t *testing.T) {
 	}
 }
 
+func TestDiffViewAnnotations(t *testing.T) {
+	t.Parallel()
+
+	dv := diffview.New().
+		Before("main.go", TestDefaultBefore).
+		After("main.go", TestDefaultAfter).
+		Annotations(diffview.Annotation{Line: 8, Text: "warning: unused variable"})
+
+	lines := strings.Split(ansi.Strip(dv.String()), "\n")
+	for i, line := range lines {
+		if strings.Contains(line, "content :=") {
+			if i+1 >= len(lines) || !strings.Contains(lines[i+1], "▲ warning: unused variable") {
+				t.Fatalf("expected annotation below line 8, got:\n%s", strings.Join(lines, "\n"))
+			}
+			return
+		}
+	}
+	t.Fatal("annotated line not rendered")
+}
+
 func assertLineWidth(t *testing.T, expected int, output string) {
 	var lineWidth int
 	for line := range strings.SplitSeq(output, "\n") {
@@ -35,6 +35,7 @@ type completionItemCmp[T any] struct {
 	matchIndexes []int
 	bgColor      color.Color
 	shortcut     string
+	info         string
 }
 
 type options struct {
@@ -43,6 +44,7 @@ type options struct {
 	bgColor      color.Color
 	matchIndexes []int
 	shortcut     string
+	info         string
 }
 
 type CompletionItemOption func(*options)
@@ -65,6 +67,14 @@ func WithCompletionShortcut(shortcut string) CompletionItemOption {
 	}
 }
 
+// WithCompletionInfo sets muted details shown after the item text, such as
+// facts about the value the item stands for.
+func WithCompletionInfo(info string) CompletionItemOption {
+	return func(cmp *options) {
+		cmp.info = info
+	}
+}
+
 func WithCompletionID(id string) CompletionItemOption {
 	return func(cmp *options) {
 		cmp.id = id
@@ -88,6 +98,7 @@ func NewCompletionItem[T any](text string, value T, opts ...CompletionItemOption
 	c.bgColor = o.bgColor
 	c.matchIndexes = o.matchIndexes
 	c.shortcut = o.shortcut
+	c.info = o.info
 	return c
 }
 
@@ -111,6 +122,14 @@ func (c *completionItemCmp[T]) View() string {
 	if c.shortcut != "" {
 		innerWidth -= lipgloss.Width(c.shortcut)
 	}
+	info := c.info
+	if info != "" {
+		info = " " + info + " "
+		if lipgloss.Width(info) > innerWidth/2 {
+			info = ""
+		}
+		innerWidth -= lipgloss.Width(info)
+	}
 
 	titleStyle := t.S().Text.Width(innerWidth)
 	titleMatchStyle := t.S().Text.Underline(true)
@@ -149,6 +168,13 @@ func (c *completionItemCmp[T]) View() string {
 		text = lipgloss.StyleRanges(text, ranges...)
 	}
 	parts := []string{text}
+	if info != "" {
+		infoStyle := t.S().Subtle
+		if c.focus {
+			infoStyle = t.S().TextSelected
+		}
+		parts = append(parts, infoStyle.Render(info))
+	}
 	if c.shortcut != "" {
 		// Add the shortcut at the end
 		shortcutStyle := t.S().Muted
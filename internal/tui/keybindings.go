@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/nexora/nexora/internal/tui/components/chat/editor"
+	"github.com/nexora/nexora/internal/tui/components/chat/splash"
+	"github.com/nexora/nexora/internal/tui/components/completions"
+	"github.com/nexora/nexora/internal/tui/components/dialogs"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/about"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/agents"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/commands"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/filepicker"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/models"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/permissions"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/plan"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/quit"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/reasoning"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/rewind"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/sessions"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/settings"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/themes"
+	"github.com/nexora/nexora/internal/tui/keymap"
+	"github.com/nexora/nexora/internal/tui/page/chat"
+)
+
+// defaultKeyMaps returns the default key map of every scope that can be
+// overridden from the keybindings config.
+func defaultKeyMaps() map[string]any {
+	return map[string]any{
+		keymap.ScopeApp:         DefaultKeyMap(),
+		keymap.ScopeChat:        chat.DefaultKeyMap(),
+		keymap.ScopeEditor:      editor.DefaultEditorKeyMap(),
+		keymap.ScopeCompletions: completions.DefaultKeyMap(),
+		keymap.ScopeSplash:      splash.DefaultKeyMap(),
+		keymap.ScopeDialogs:     dialogs.DefaultKeyMap(),
+		keymap.ScopeAbout:       about.DefaultKeymap(),
+		keymap.ScopeAgents:      agents.DefaultKeyMap(),
+		keymap.ScopeArguments:   commands.DefaultArgumentsDialogKeyMap(),
+		keymap.ScopeCommands:    commands.DefaultCommandsDialogKeyMap(),
+		keymap.ScopeFilePicker:  filepicker.DefaultKeyMap(),
+		keymap.ScopeModels:      models.DefaultKeyMap(),
+		keymap.ScopePermissions: permissions.DefaultKeyMap(),
+		keymap.ScopePlan:        plan.DefaultKeyMap(),
+		keymap.ScopeQuit:        quit.DefaultKeymap(),
+		keymap.ScopeReasoning:   reasoning.DefaultReasoningDialogKeyMap(),
+		keymap.ScopeRewind:      rewind.DefaultKeyMap(),
+		keymap.ScopeSessions:    sessions.DefaultKeyMap(),
+		keymap.ScopeSettings:    settings.DefaultKeymap(),
+		keymap.ScopeThemes:      themes.DefaultThemesDialogKeyMap(),
+	}
+}
+
+// keyMapGroups lists the scopes whose bindings are active at the same time.
+// Global keys are matched before the chat page, completions before the
+// editor, and an open dialog receives every key but quit.
+func keyMapGroups() [][]string {
+	groups := [][]string{
+		{keymap.ScopeApp, keymap.ScopeChat, keymap.ScopeEditor, keymap.ScopeSplash},
+		{keymap.ScopeApp + ".quit", keymap.ScopeCompletions, keymap.ScopeChat, keymap.ScopeEditor},
+	}
+	for _, scope := range []string{
+		keymap.ScopeAbout, keymap.ScopeAgents, keymap.ScopeArguments, keymap.ScopeCommands,
+		keymap.ScopeFilePicker, keymap.ScopeModels, keymap.ScopePermissions,
+		keymap.ScopePlan, keymap.ScopeQuit, keymap.ScopeReasoning, keymap.ScopeRewind,
+		keymap.ScopeSessions, keymap.ScopeSettings, keymap.ScopeThemes,
+	} {
+		groups = append(groups, []string{keymap.ScopeApp + ".quit", keymap.ScopeDialogs, scope})
+	}
+	return groups
+}
+
+// loadKeybindings makes the configured key binding overrides effective.
+// Invalid or conflicting overrides are ignored and reported.
+func loadKeybindings(bindings map[string][]string) error {
+	if err := keymap.Load(bindings, defaultKeyMaps(), keyMapGroups()); err != nil {
+		return fmt.Errorf("invalid keybindings:\n%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+// Package keymap applies user key binding overrides to the TUI key maps.
+//
+// Every key.Binding field of a key map is an action named
+// "<scope>.<field>", with the field name in snake case, e.g. the NewSession
+// field of the chat page key map is "chat.new_session".
+package keymap
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"charm.land/bubbles/v2/key"
+)
+
+// Scopes of the TUI key maps.
+const (
+	ScopeApp         = "app"
+	ScopeChat        = "chat"
+	ScopeEditor      = "editor"
+	ScopeCompletions = "completions"
+	ScopeSplash      = "splash"
+	ScopeDialogs     = "dialogs"
+	ScopeAbout       = "dialogs.about"
+	ScopeAgents      = "dialogs.agents"
+	ScopeArguments   = "dialogs.arguments"
+	ScopeCommands    = "dialogs.commands"
+	ScopeFilePicker  = "dialogs.filepicker"
+	ScopeModels      = "dialogs.models"
+	ScopePermissions = "dialogs.permissions"
+	ScopePlan        = "dialogs.plan"
+	ScopeQuit        = "dialogs.quit"
+	ScopeReasoning   = "dialogs.reasoning"
+	ScopeRewind      = "dialogs.rewind"
+	ScopeSessions    = "dialogs.sessions"
+	ScopeSettings    = "dialogs.settings"
+	ScopeThemes      = "dialogs.themes"
+)
+
+var (
+	mu        sync.RWMutex
+	overrides = map[string][]string{}
+)
+
+// Load validates overrides against the default key maps of each scope and
+// makes the valid ones effective. Overrides naming unknown actions or
+// conflicting with another binding are dropped and reported in the error.
+//
+// groups lists the scopes, or single actions, whose bindings are active at
+// the same time; a key may only be bound once within a group. Each scope is
+// implicitly a group of its own.
+func Load(bindings map[string][]string, defaults map[string]any, groups [][]string) error {
+	var errs []error
+	valid := map[string][]string{}
+	for _, action := range slices.Sorted(maps.Keys(bindings)) {
+		keys := bindings[action]
+		scope, field, ok := split(action)
+		km, known := defaults[scope]
+		if !ok || !known || !slices.Contains(fieldNames(km), field) {
+			errs = append(errs, fmt.Errorf("unknown action %q", action))
+			continue
+		}
+		if i := slices.IndexFunc(keys, func(k string) bool { return strings.TrimSpace(k) == "" }); i >= 0 {
+			errs = append(errs, fmt.Errorf("%s: empty key", action))
+			continue
+		}
+		valid[action] = keys
+	}
+
+	for {
+		conflicts := findConflicts(valid, defaults, groups)
+		if len(conflicts) == 0 {
+			break
+		}
+		// Only overridden actions are in valid, defaults are kept.
+		for _, c := range conflicts {
+			errs = append(errs, c)
+			delete(valid, c.a)
+			delete(valid, c.b)
+		}
+	}
+
+	mu.Lock()
+	overrides = valid
+	mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// Apply returns km with the effective overrides of scope applied to its
+// key.Binding fields.
+func Apply[T any](scope string, km T) T {
+	v := reflect.ValueOf(&km).Elem()
+	if v.Kind() != reflect.Struct {
+		return km
+	}
+	for i := range v.NumField() {
+		f := v.Type().Field(i)
+		if !f.IsExported() || f.Type != bindingType {
+			continue
+		}
+		b := v.Field(i).Addr().Interface().(*key.Binding)
+		*b = Binding(scope+"."+snakeCase(f.Name), *b)
+	}
+	return km
+}
+
+// Binding returns b with the override of action applied. The help text
+// shows the new keys and keeps the description of b.
+func Binding(action string, b key.Binding) key.Binding {
+	keys, ok := override(action)
+	if !ok {
+		return b
+	}
+	if len(keys) == 0 {
+		b.SetEnabled(false)
+		return b
+	}
+	b.SetKeys(keys...)
+	b.SetHelp(strings.Join(keys, "/"), b.Help().Desc)
+	b.SetEnabled(true)
+	return b
+}
+
+// Shortcut returns the keys shown for action, or fallback if the action is
+// not overridden.
+func Shortcut(action, fallback string) string {
+	keys, ok := override(action)
+	if !ok {
+		return fallback
+	}
+	return strings.Join(keys, "/")
+}
+
+// Overridden reports whether action has an effective override.
+func Overridden(action string) bool {
+	_, ok := override(action)
+	return ok
+}
+
+func override(action string) ([]string, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	keys, ok := overrides[action]
+	return keys, ok
+}
+
+var bindingType = reflect.TypeFor[key.Binding]()
+
+// bindings returns the bindings of km by field name.
+func bindings(km any) map[string]key.Binding {
+	v := reflect.ValueOf(km)
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	result := map[string]key.Binding{}
+	for i := range v.NumField() {
+		f := v.Type().Field(i)
+		if f.IsExported() && f.Type == bindingType {
+			result[snakeCase(f.Name)] = v.Field(i).Interface().(key.Binding)
+		}
+	}
+	return result
+}
+
+func fieldNames(km any) []string {
+	return slices.Collect(maps.Keys(bindings(km)))
+}
+
+type conflict struct {
+	a, b string
+	key  string
+}
+
+func (c conflict) Error() string {
+	return fmt.Sprintf("%s conflicts with %s on %q", c.a, c.b, c.key)
+}
+
+// findConflicts reports keys bound to two actions of a group where at least
+// one of them is overridden.
+func findConflicts(valid map[string][]string, defaults map[string]any, groups [][]string) []conflict {
+	type entry struct {
+		action string
+		keys   []string
+	}
+	effective := func(scope string) []entry {
+		var entries []entry
+		for field, b := range bindings(defaults[scope]) {
+			action := scope + "." + field
+			keys := b.Keys()
+			if !b.Enabled() {
+				keys = nil
+			}
+			if o, ok := valid[action]; ok {
+				keys = o
+			}
+			entries = append(entries, entry{action, keys})
+		}
+		return entries
+	}
+
+	for scope := range defaults {
+		groups = append(groups, []string{scope})
+	}
+	seen := map[[2]string]bool{}
+	var conflicts []conflict
+	for _, group := range groups {
+		var entries []entry
+		for _, member := range group {
+			scope, _, isAction := split(member)
+			if _, ok := defaults[member]; ok {
+				isAction = false
+				scope = member
+			}
+			for _, e := range effective(scope) {
+				if !isAction || e.action == member {
+					entries = append(entries, e)
+				}
+			}
+		}
+		slices.SortFunc(entries, func(x, y entry) int { return strings.Compare(x.action, y.action) })
+		for i, x := range entries {
+			for _, y := range entries[i+1:] {
+				_, xo := valid[x.action]
+				_, yo := valid[y.action]
+				if !xo && !yo {
+					continue
+				}
+				k := slices.IndexFunc(x.keys, func(k string) bool { return slices.Contains(y.keys, k) })
+				if k < 0 || seen[[2]string{x.action, y.action}] {
+					continue
+				}
+				seen[[2]string{x.action, y.action}] = true
+				conflicts = append(conflicts, conflict{a: x.action, b: y.action, key: x.keys[k]})
+			}
+		}
+	}
+	return conflicts
+}
+
+// split splits "dialogs.models.select" into "dialogs.models" and "select".
+func split(action string) (scope, field string, ok bool) {
+	i := strings.LastIndex(action, ".")
+	if i <= 0 || i == len(action)-1 {
+		return "", "", false
+	}
+	return action[:i], action[i+1:], true
+}
+
+// snakeCase turns a field name such as "AllowSession" into "allow_session".
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && unicode.IsLower(runes[i-1])
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])
+			if prevLower || nextLower {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
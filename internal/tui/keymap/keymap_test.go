@@ -0,0 +1,122 @@
+package keymap
+
+import (
+	"testing"
+
+	"charm.land/bubbles/v2/key"
+	"github.com/stretchr/testify/require"
+)
+
+type pageKeyMap struct {
+	NewSession key.Binding
+	Cancel     key.Binding
+	Tab        key.Binding
+
+	hidden key.Binding
+}
+
+type globalKeyMap struct {
+	Quit     key.Binding
+	Sessions key.Binding
+}
+
+type dialogKeyMap struct {
+	Select key.Binding
+	Close  key.Binding
+}
+
+func testDefaults() map[string]any {
+	return map[string]any{
+		"app": globalKeyMap{
+			Quit:     key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "quit")),
+			Sessions: key.NewBinding(key.WithKeys("ctrl+s"), key.WithHelp("ctrl+s", "sessions")),
+		},
+		"chat": pageKeyMap{
+			NewSession: key.NewBinding(key.WithKeys("ctrl+n"), key.WithHelp("ctrl+n", "new session")),
+			Cancel:     key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+			Tab:        key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "change focus")),
+		},
+		"dialogs.models": dialogKeyMap{
+			Select: key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "select")),
+			Close:  key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "close")),
+		},
+	}
+}
+
+var testGroups = [][]string{
+	{"app", "chat"},
+	{"app.quit", "dialogs.models"},
+}
+
+func TestApply(t *testing.T) {
+	err := Load(map[string][]string{
+		"chat.new_session": {"ctrl+shift+n", "alt+n"},
+		"chat.tab":         {},
+	}, testDefaults(), testGroups)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = Load(nil, nil, nil) })
+
+	km := Apply("chat", testDefaults()["chat"].(pageKeyMap))
+	require.Equal(t, []string{"ctrl+shift+n", "alt+n"}, km.NewSession.Keys())
+	require.Equal(t, key.Help{Key: "ctrl+shift+n/alt+n", Desc: "new session"}, km.NewSession.Help())
+	require.False(t, km.Tab.Enabled())
+	require.Equal(t, []string{"esc"}, km.Cancel.Keys())
+
+	require.True(t, Overridden("chat.new_session"))
+	require.Equal(t, "ctrl+shift+n/alt+n", Shortcut("chat.new_session", "ctrl+n"))
+	require.Equal(t, "ctrl+s", Shortcut("app.sessions", "ctrl+s"))
+}
+
+func TestLoadValidation(t *testing.T) {
+	t.Cleanup(func() { _ = Load(nil, nil, nil) })
+
+	err := Load(map[string][]string{
+		"chat.nope":             {"x"},
+		"nope.new_session":      {"x"},
+		"chat.cancel":           {""},
+		"chat.new_session":      {"ctrl+s"},
+		"dialogs.models.select": {"ctrl+c"},
+		"dialogs.models.close":  {"q"},
+		"app.sessions":          {"ctrl+o"},
+	}, testDefaults(), testGroups)
+	require.Error(t, err)
+	require.ErrorContains(t, err, `unknown action "chat.nope"`)
+	require.ErrorContains(t, err, `unknown action "nope.new_session"`)
+	require.ErrorContains(t, err, "chat.cancel: empty key")
+	require.ErrorContains(t, err, `app.quit conflicts with dialogs.models.select on "ctrl+c"`)
+
+	require.False(t, Overridden("dialogs.models.select"))
+	require.True(t, Overridden("dialogs.models.close"))
+	// ctrl+s is free once sessions moved to ctrl+o.
+	require.True(t, Overridden("chat.new_session"))
+	require.True(t, Overridden("app.sessions"))
+}
+
+func TestLoadRevertedConflict(t *testing.T) {
+	t.Cleanup(func() { _ = Load(nil, nil, nil) })
+
+	// Dropping the conflicting sessions override restores ctrl+s, which then
+	// conflicts with the new session override.
+	err := Load(map[string][]string{
+		"app.sessions":     {"ctrl+c"},
+		"chat.new_session": {"ctrl+s"},
+	}, testDefaults(), testGroups)
+	require.ErrorContains(t, err, `app.quit conflicts with app.sessions on "ctrl+c"`)
+	require.ErrorContains(t, err, `app.sessions conflicts with chat.new_session on "ctrl+s"`)
+	require.False(t, Overridden("app.sessions"))
+	require.False(t, Overridden("chat.new_session"))
+}
+
+func TestSnakeCase(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]string{
+		"Close":                "close",
+		"NewSession":           "new_session",
+		"AllowSession":         "allow_session",
+		"DeleteAllAttachments": "delete_all_attachments",
+		"HTMLView":             "html_view",
+	} {
+		require.Equal(t, want, snakeCase(name))
+	}
+}
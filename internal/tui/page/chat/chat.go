@@ -10,7 +10,10 @@ import (
 	"charm.land/bubbles/v2/key"
 	"charm.land/bubbles/v2/spinner"
 	tea "charm.land/bubbletea/v2"
+	"charm.land/fantasy"
 	"charm.land/lipgloss/v2"
+	"github.com/nexora/nexora/internal/agent"
+	"github.com/nexora/nexora/internal/agent/delegation"
 	"github.com/nexora/nexora/internal/app"
 	"github.com/nexora/nexora/internal/config"
 	"github.com/nexora/nexora/internal/history"
@@ -25,16 +28,20 @@ import (
 	"github.com/nexora/nexora/internal/tui/components/chat/messages"
 	"github.com/nexora/nexora/internal/tui/components/chat/sidebar"
 	"github.com/nexora/nexora/internal/tui/components/chat/splash"
+	"github.com/nexora/nexora/internal/tui/components/chat/tabs"
 	"github.com/nexora/nexora/internal/tui/components/completions"
 	"github.com/nexora/nexora/internal/tui/components/core"
 	"github.com/nexora/nexora/internal/tui/components/core/layout"
 	"github.com/nexora/nexora/internal/tui/components/dialogs"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/agents"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/claude"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/commands"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/filepicker"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/models"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/plan"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/reasoning"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/settings"
+	"github.com/nexora/nexora/internal/tui/keymap"
 	"github.com/nexora/nexora/internal/tui/page"
 	"github.com/nexora/nexora/internal/tui/styles"
 	"github.com/nexora/nexora/internal/tui/util"
@@ -48,6 +55,9 @@ type (
 		Focused bool
 	}
 	CancelTimerExpiredMsg struct{}
+	// WindowsChangedMsg tells the chat page that the windows changed outside
+	// of it, such as when one waits for a permission.
+	WindowsChangedMsg struct{}
 )
 
 type PanelType string
@@ -103,6 +113,7 @@ type chatPage struct {
 	// Session
 	session session.Session
 	keyMap  KeyMap
+	windows []agent.SessionWindow // Shown in the tabs, see refreshWindows
 
 	// Components
 	header  header.Header
@@ -122,7 +133,7 @@ type chatPage struct {
 func New(app *app.App) ChatPage {
 	return &chatPage{
 		app:         app,
-		keyMap:      DefaultKeyMap(),
+		keyMap:      keymap.Apply(keymap.ScopeChat, DefaultKeyMap()),
 		header:      header.New(app.LSPClients),
 		sidebar:     sidebar.New(app.History, app.LSPClients, false),
 		chat:        chat.New(app),
@@ -169,11 +180,17 @@ func (p *chatPage) Init() tea.Cmd {
 func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	ctx := context.Background()
 	var cmds []tea.Cmd
+	if changesWindows(msg) {
+		defer p.refreshWindows()
+	}
 	switch msg := msg.(type) {
+	case WindowsChangedMsg:
+		return p, nil
 	case tea.KeyboardEnhancementsMsg:
 		p.keyboardEnhancements = msg
 		return p, nil
 	case tea.MouseWheelMsg:
+		msg.Y -= p.tabsHeight()
 		if p.compact {
 			msg.Y -= 1
 		}
@@ -187,6 +204,7 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		if p.isOnboarding || p.isProjectInit {
 			return p, nil
 		}
+		msg.Y -= p.tabsHeight()
 		if p.compact {
 			msg.Y -= 1
 		}
@@ -203,6 +221,7 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		p.chat = u.(chat.MessageListCmp)
 		return p, cmd
 	case tea.MouseMotionMsg:
+		msg.Y -= p.tabsHeight()
 		if p.compact {
 			msg.Y -= 1
 		}
@@ -216,6 +235,7 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		if p.isOnboarding || p.isProjectInit {
 			return p, nil
 		}
+		msg.Y -= p.tabsHeight()
 		if p.compact {
 			msg.Y -= 1
 		}
@@ -236,6 +256,14 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	case CancelTimerExpiredMsg:
 		p.isCanceling = false
 		return p, nil
+	case styles.ThemeChangedMsg:
+		u, cmd := p.editor.Update(msg)
+		p.editor = u.(editor.Editor)
+		cmds = append(cmds, cmd)
+		u, cmd = p.chat.Update(msg)
+		p.chat = u.(chat.MessageListCmp)
+		cmds = append(cmds, cmd)
+		return p, tea.Batch(cmds...)
 	case editor.OpenEditorMsg:
 		u, cmd := p.editor.Update(msg)
 		p.editor = u.(editor.Editor)
@@ -247,7 +275,14 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		}
 		return p, nil
 	case chat.SendMsg:
+		if msg.Plan {
+			return p, p.sendPlanMessage(msg.Text)
+		}
 		return p, p.sendMessage(msg.Text, msg.Attachments)
+	case plan.PlanResponseMsg:
+		u, cmd := p.editor.Update(msg)
+		p.editor = u.(editor.Editor)
+		return p, tea.Batch(cmd, p.respondToPlan(msg))
 	case chat.SessionSelectedMsg:
 		return p, p.setSession(msg)
 	case splash.SubmitAPIKeyMsg:
@@ -283,6 +318,9 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		if msg.Type == pubsub.UpdatedEvent && p.session.ID == msg.Payload.ID {
 			p.session = msg.Payload
 		}
+		if msg.Type == pubsub.UpdatedEvent {
+			p.app.Windows.UpdateSession(msg.Payload)
+		}
 		u, cmd := p.header.Update(msg)
 		p.header = u.(header.Header)
 		cmds = append(cmds, cmd)
@@ -356,12 +394,20 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		}
 
 		return p, tea.Batch(cmds...)
-	case commands.ToggleYoloModeMsg:
+	case agents.MentionAgentMsg:
+		p.focusedPane = PanelTypeEditor
+		p.chat.Blur()
+		u, cmd := p.editor.Update(msg)
+		p.editor = u.(editor.Editor)
+		return p, cmd
+	case commands.ToggleYoloModeMsg, commands.TogglePlanModeMsg:
 		// update the editor style
 		u, cmd := p.editor.Update(msg)
 		p.editor = u.(editor.Editor)
 		return p, cmd
-	case pubsub.Event[history.File], sidebar.SessionFilesMsg:
+	case pubsub.Event[history.File], sidebar.SessionFilesMsg,
+		pubsub.Event[session.AgentState], sidebar.AgentStateMsg,
+		pubsub.Event[delegation.Status]:
 		u, cmd := p.sidebar.Update(msg)
 		p.sidebar = u.(sidebar.Sidebar)
 		cmds = append(cmds, cmd)
@@ -373,7 +419,7 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		return p, tea.Batch(cmds...)
 
 	case commands.CommandRunCustomMsg:
-		if p.app.AgentCoordinator.IsBusy() {
+		if p.isBusy() {
 			return p, util.ReportWarn("Agent is busy, please wait before executing a command...")
 		}
 
@@ -397,10 +443,11 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		p.focusedPane = PanelTypeEditor
 		return p, p.SetSize(p.width, p.height)
 	case commands.NewSessionsMsg:
-		if p.app.AgentCoordinator.IsBusy() {
-			return p, util.ReportWarn("Agent is busy, please wait before starting a new session...")
-		}
 		return p, p.newSession()
+	case commands.ForkSessionMsg:
+		return p, p.forkWindow(msg.SessionID)
+	case commands.ResumeSessionMsg:
+		return p, p.resumeSession(msg.SessionID)
 	case tea.KeyPressMsg:
 		switch {
 		case key.Matches(msg, p.keyMap.OpenSettings):
@@ -410,10 +457,16 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			if p.app.AgentCoordinator == nil {
 				return p, nil
 			}
-			if p.app.AgentCoordinator.IsBusy() {
-				return p, util.ReportWarn("Agent is busy, please wait before starting a new session...")
-			}
 			return p, p.newSession()
+		case key.Matches(msg, p.keyMap.NextWindow):
+			return p, p.showWindow(p.app.Windows.Neighbor(1))
+		case key.Matches(msg, p.keyMap.PrevWindow):
+			return p, p.showWindow(p.app.Windows.Neighbor(-1))
+		case key.Matches(msg, p.keyMap.GoToWindow):
+			k := msg.String()
+			return p, p.showWindow(p.app.Windows.WindowAt(int(k[len(k)-1] - '1')))
+		case key.Matches(msg, p.keyMap.CloseWindow):
+			return p, p.closeWindow()
 		case key.Matches(msg, p.keyMap.AddAttachment):
 			// Skip attachment handling during onboarding/splash screen
 			if p.focusedPane == PanelTypeSplash || p.isOnboarding {
@@ -440,7 +493,7 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			p.changeFocus()
 			return p, nil
 		case key.Matches(msg, p.keyMap.Cancel):
-			if p.session.ID != "" && p.app.AgentCoordinator.IsBusy() {
+			if p.session.ID != "" && p.isBusy() {
 				return p, p.cancel()
 			}
 		case key.Matches(msg, p.keyMap.Details):
@@ -542,6 +595,10 @@ func (p *chatPage) View() string {
 		}
 	}
 
+	if p.showTabs() {
+		chatView = lipgloss.JoinVertical(lipgloss.Left, tabs.View(p.tabs(), p.width), chatView)
+	}
+
 	layers := []*lipgloss.Layer{
 		lipgloss.NewLayer(chatView).X(0).Y(0),
 	}
@@ -559,7 +616,7 @@ func (p *chatPage) View() string {
 				version,
 			),
 		)
-		layers = append(layers, lipgloss.NewLayer(details).X(1).Y(1))
+		layers = append(layers, lipgloss.NewLayer(details).X(1).Y(1+p.tabsHeight()))
 	}
 
 	canvas := lipgloss.NewCanvas(
@@ -694,6 +751,9 @@ func (p *chatPage) SetSize(width, height int) tea.Cmd {
 	p.width = width
 	p.height = height
 	var cmds []tea.Cmd
+	// The tab bar takes rows from the top, the editor stays at the bottom.
+	bottom := height
+	height -= p.tabsHeight()
 
 	if p.session.ID == "" {
 		if p.splashFullScreen {
@@ -701,7 +761,7 @@ func (p *chatPage) SetSize(width, height int) tea.Cmd {
 		} else {
 			cmds = append(cmds, p.splash.SetSize(width, height-EditorHeight))
 			cmds = append(cmds, p.editor.SetSize(width, EditorHeight))
-			cmds = append(cmds, p.editor.SetPosition(0, height-EditorHeight))
+			cmds = append(cmds, p.editor.SetPosition(0, bottom-EditorHeight))
 		}
 	} else {
 		if p.compact {
@@ -715,7 +775,7 @@ func (p *chatPage) SetSize(width, height int) tea.Cmd {
 			cmds = append(cmds, p.editor.SetSize(width, EditorHeight))
 			cmds = append(cmds, p.sidebar.SetSize(SideBarWidth, height-EditorHeight))
 		}
-		cmds = append(cmds, p.editor.SetPosition(0, height-EditorHeight))
+		cmds = append(cmds, p.editor.SetPosition(0, bottom-EditorHeight))
 	}
 	return tea.Batch(cmds...)
 }
@@ -726,6 +786,8 @@ func (p *chatPage) newSession() tea.Cmd {
 	}
 
 	p.session = session.Session{}
+	p.app.Windows.ClearActive()
+	p.refreshWindows()
 	p.focusedPane = PanelTypeEditor
 	p.editor.Focus()
 	p.chat.Blur()
@@ -743,16 +805,144 @@ func (p *chatPage) setSession(session session.Session) tea.Cmd {
 
 	var cmds []tea.Cmd
 	p.session = session
+	p.app.Windows.OpenWindow(session)
+	p.refreshWindows()
+	p.isCanceling = false
 
 	cmds = append(cmds, p.SetSize(p.width, p.height))
 	cmds = append(cmds, p.chat.SetSession(session))
 	cmds = append(cmds, p.sidebar.SetSession(session))
+	cmds = append(cmds, p.loadAgentState(session.ID))
 	cmds = append(cmds, p.header.SetSession(session))
 	cmds = append(cmds, p.editor.SetSession(session))
 
 	return tea.Sequence(cmds...)
 }
 
+// showWindow switches to the window of session id.
+func (p *chatPage) showWindow(id string, ok bool) tea.Cmd {
+	if !ok || id == p.session.ID {
+		return nil
+	}
+	s, err := p.app.Sessions.Get(context.Background(), id)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	return util.CmdHandler(chat.SessionSelectedMsg(s))
+}
+
+// closeWindow closes the active window and shows its neighbor, or a new
+// chat when it was the last window.
+func (p *chatPage) closeWindow() tea.Cmd {
+	if p.session.ID == "" {
+		return nil
+	}
+	next, err := p.app.Windows.CloseWindow(p.session.ID)
+	if errors.Is(err, agent.ErrWindowBusy) {
+		return util.ReportWarn("Agent is busy in this window, cancel it before closing the window...")
+	}
+	if err != nil {
+		return util.ReportError(err)
+	}
+	p.refreshWindows()
+	if next == "" {
+		return p.newSession()
+	}
+	return p.showWindow(next, true)
+}
+
+// forkWindow copies session id into a new window and switches to it.
+func (p *chatPage) forkWindow(id string) tea.Cmd {
+	if id == "" {
+		return nil
+	}
+	if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsSessionBusy(id) {
+		return util.ReportWarn("Agent is busy, please wait before forking the session...")
+	}
+	forkID, err := p.app.Windows.ForkWindow(context.Background(), id)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	p.refreshWindows()
+	return tea.Batch(p.showWindow(forkID, true), util.ReportInfo("Session forked into a new window"))
+}
+
+// loadAgentState loads the saved agent state of the session, for the sidebar
+// to show whether it is paused.
+func (p *chatPage) loadAgentState(id string) tea.Cmd {
+	if id == "" || p.app.AgentStates == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		saved, err := p.app.AgentStates.Get(context.Background(), id)
+		if err != nil {
+			return nil // Not saved yet
+		}
+		return sidebar.AgentStateMsg{State: saved}
+	}
+}
+
+// resumeSession resumes the session when it is paused.
+func (p *chatPage) resumeSession(id string) tea.Cmd {
+	if id == "" || p.app.AgentCoordinator == nil {
+		return nil
+	}
+	if err := p.app.AgentCoordinator.ResumeSession(context.Background(), id); err != nil {
+		return util.ReportError(err)
+	}
+	return util.ReportInfo("Session resumed")
+}
+
+// isBusy reports whether the agent is working in the current window.
+func (p *chatPage) isBusy() bool {
+	return p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsSessionBusy(p.session.ID)
+}
+
+// refreshWindows caches the windows for the tabs, so that they are not
+// listed, and their sessions checked for being busy, on every render.
+func (p *chatPage) refreshWindows() {
+	p.windows = p.app.Windows.ListWindows()
+}
+
+// changesWindows reports whether msg changes the titles or states of the
+// windows shown in the tabs.
+func changesWindows(msg tea.Msg) bool {
+	switch msg := msg.(type) {
+	case WindowsChangedMsg, pubsub.Event[session.Session], pubsub.Event[permission.PermissionNotification]:
+		return true
+	case pubsub.Event[message.Message]:
+		// A prompt was sent or answered; streamed updates change nothing
+		return msg.Type == pubsub.CreatedEvent
+	}
+	return false
+}
+
+// showTabs reports whether the tab bar is shown, which is when there is a
+// window besides the one on screen.
+func (p *chatPage) showTabs() bool {
+	return len(p.windows) > 1 || (len(p.windows) == 1 && p.windows[0].ID != p.session.ID)
+}
+
+func (p *chatPage) tabsHeight() int {
+	if p.showTabs() {
+		return tabs.Height
+	}
+	return 0
+}
+
+func (p *chatPage) tabs() []tabs.Tab {
+	result := make([]tabs.Tab, len(p.windows))
+	for i, w := range p.windows {
+		result[i] = tabs.Tab{
+			Title:   w.Title,
+			Active:  w.ID == p.session.ID,
+			Busy:    w.Busy,
+			Pending: w.PendingPermission,
+		}
+	}
+	return result
+}
+
 func (p *chatPage) changeFocus() {
 	if p.session.ID == "" {
 		return
@@ -802,6 +992,40 @@ func (p *chatPage) toggleDetails() {
 }
 
 func (p *chatPage) sendMessage(text string, attachments []message.Attachment) tea.Cmd {
+	return p.runAgent(func(ctx context.Context, sessionID string) (*fantasy.AgentResult, error) {
+		return p.app.AgentCoordinator.Run(ctx, sessionID, text, attachments...)
+	})
+}
+
+// sendPlanMessage sends text to the read-only planner, which submits a plan
+// for the user to approve.
+func (p *chatPage) sendPlanMessage(text string) tea.Cmd {
+	return p.runAgent(func(ctx context.Context, sessionID string) (*fantasy.AgentResult, error) {
+		return p.app.Plan(ctx, sessionID, text)
+	})
+}
+
+// respondToPlan executes an approved plan with the coder, or rejects it so
+// the planner can revise it on the next message.
+func (p *chatPage) respondToPlan(msg plan.PlanResponseMsg) tea.Cmd {
+	if !msg.Approved {
+		if _, err := p.app.Plans.Reject(context.Background(), msg.SessionID); err != nil {
+			return util.ReportError(err)
+		}
+		return util.ReportInfo("Plan rejected, describe what to change")
+	}
+	return tea.Batch(p.chat.GoToBottom(), tea.Sequence(
+		func() tea.Msg {
+			_, err := p.app.ExecutePlan(context.Background(), msg.SessionID)
+			return agentErrorMsg(err)
+		},
+		util.CmdHandler(WindowsChangedMsg{}),
+	))
+}
+
+// runAgent runs an agent turn in the current session, creating the session
+// first when there is none.
+func (p *chatPage) runAgent(run func(ctx context.Context, sessionID string) (*fantasy.AgentResult, error)) tea.Cmd {
 	session := p.session
 	var cmds []tea.Cmd
 	if p.session.ID == "" {
@@ -816,36 +1040,54 @@ func (p *chatPage) sendMessage(text string, attachments []message.Attachment) te
 		return util.ReportError(fmt.Errorf("coder agent is not initialized"))
 	}
 	cmds = append(cmds, p.chat.GoToBottom())
-	cmds = append(cmds, func() tea.Msg {
-		_, err := p.app.AgentCoordinator.Run(context.Background(), session.ID, text, attachments...)
-		if err != nil {
-			isCancelErr := errors.Is(err, context.Canceled)
-			isPermissionErr := errors.Is(err, permission.ErrorPermissionDenied)
-			if isCancelErr || isPermissionErr {
-				return nil
-			}
-			return util.InfoMsg{
-				Type: util.InfoTypeError,
-				Msg:  err.Error(),
-			}
-		}
-		return nil
-	})
+	cmds = append(cmds, tea.Sequence(
+		func() tea.Msg {
+			_, err := run(context.Background(), session.ID)
+			return agentErrorMsg(err)
+		},
+		// The window is no longer busy
+		util.CmdHandler(WindowsChangedMsg{}),
+	))
 	return tea.Batch(cmds...)
 }
 
+// agentErrorMsg reports err from an agent run, ignoring cancellations and
+// denied permissions.
+func agentErrorMsg(err error) tea.Msg {
+	if err == nil {
+		return nil
+	}
+	isCancelErr := errors.Is(err, context.Canceled)
+	isPermissionErr := errors.Is(err, permission.ErrorPermissionDenied)
+	if isCancelErr || isPermissionErr {
+		return nil
+	}
+	return util.InfoMsg{
+		Type: util.InfoTypeError,
+		Msg:  err.Error(),
+	}
+}
+
 func (p *chatPage) Bindings() []key.Binding {
 	bindings := []key.Binding{
 		p.keyMap.NewSession,
 		p.keyMap.AddAttachment,
 	}
-	if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsBusy() {
+	if p.showTabs() {
+		bindings = append(bindings,
+			p.keyMap.NextWindow,
+			p.keyMap.PrevWindow,
+			p.keyMap.GoToWindow,
+			p.keyMap.CloseWindow,
+		)
+	}
+	if p.isBusy() {
 		cancelBinding := p.keyMap.Cancel
 		if p.isCanceling {
-			cancelBinding = key.NewBinding(
+			cancelBinding = keymap.Binding(keymap.ScopeChat+".cancel", key.NewBinding(
 				key.WithKeys("esc", "alt+esc"),
 				key.WithHelp("esc", "press again to cancel"),
-			)
+			))
 		}
 		bindings = append([]key.Binding{cancelBinding}, bindings...)
 	}
@@ -853,18 +1095,18 @@ func (p *chatPage) Bindings() []key.Binding {
 	switch p.focusedPane {
 	case PanelTypeChat:
 		bindings = append([]key.Binding{
-			key.NewBinding(
+			keymap.Binding(keymap.ScopeChat+".tab", key.NewBinding(
 				key.WithKeys("tab"),
 				key.WithHelp("tab", "focus editor"),
-			),
+			)),
 		}, bindings...)
 		bindings = append(bindings, p.chat.Bindings()...)
 	case PanelTypeEditor:
 		bindings = append([]key.Binding{
-			key.NewBinding(
+			keymap.Binding(keymap.ScopeChat+".tab", key.NewBinding(
 				key.WithKeys("tab"),
 				key.WithHelp("tab", "focus chat"),
-			),
+			)),
 		}, bindings...)
 		bindings = append(bindings, p.editor.Bindings()...)
 	case PanelTypeSplash:
@@ -896,10 +1138,10 @@ func (p *chatPage) Help() help.KeyMap {
 				key.WithHelp("esc", "back"),
 			),
 			// Quit
-			key.NewBinding(
+			keymap.Binding(keymap.ScopeApp+".quit", key.NewBinding(
 				key.WithKeys("ctrl+c"),
 				key.WithHelp("ctrl+c", "quit"),
-			),
+			)),
 		)
 		// keep them the same
 		for _, v := range shortList {
@@ -934,10 +1176,10 @@ func (p *chatPage) Help() help.KeyMap {
 		}
 		shortList = append(shortList,
 			// Quit
-			key.NewBinding(
+			keymap.Binding(keymap.ScopeApp+".quit", key.NewBinding(
 				key.WithKeys("ctrl+c"),
 				key.WithHelp("ctrl+c", "quit"),
-			),
+			)),
 		)
 		// keep them the same
 		for _, v := range shortList {
@@ -956,10 +1198,10 @@ func (p *chatPage) Help() help.KeyMap {
 				key.WithHelp("enter", "accept"),
 			),
 			// Quit
-			key.NewBinding(
+			keymap.Binding(keymap.ScopeApp+".quit", key.NewBinding(
 				key.WithKeys("ctrl+c"),
 				key.WithHelp("ctrl+c", "quit"),
-			),
+			)),
 		)
 		// keep them the same
 		for _, v := range shortList {
@@ -984,10 +1226,10 @@ func (p *chatPage) Help() help.KeyMap {
 		}
 		shortList = append(shortList,
 			// Quit
-			key.NewBinding(
+			keymap.Binding(keymap.ScopeApp+".quit", key.NewBinding(
 				key.WithKeys("ctrl+c"),
 				key.WithHelp("ctrl+c", "quit"),
-			),
+			)),
 		)
 		// keep them the same
 		for _, v := range shortList {
@@ -995,10 +1237,10 @@ func (p *chatPage) Help() help.KeyMap {
 		}
 	case p.isProjectInit:
 		shortList = append(shortList,
-			key.NewBinding(
+			keymap.Binding(keymap.ScopeApp+".quit", key.NewBinding(
 				key.WithKeys("ctrl+c"),
 				key.WithHelp("ctrl+c", "quit"),
-			),
+			)),
 		)
 		// keep them the same
 		for _, v := range shortList {
@@ -1007,14 +1249,14 @@ func (p *chatPage) Help() help.KeyMap {
 	default:
 		if p.editor.IsCompletionsOpen() {
 			shortList = append(shortList,
-				key.NewBinding(
+				keymap.Binding(keymap.ScopeCompletions+".select", key.NewBinding(
 					key.WithKeys("tab", "enter"),
 					key.WithHelp("tab/enter", "complete"),
-				),
-				key.NewBinding(
+				)),
+				keymap.Binding(keymap.ScopeCompletions+".cancel", key.NewBinding(
 					key.WithKeys("esc", "alt+esc"),
 					key.WithHelp("esc", "cancel"),
-				),
+				)),
 				key.NewBinding(
 					key.WithKeys("up", "down"),
 					key.WithHelp("↑/↓", "choose"),
@@ -1025,22 +1267,22 @@ func (p *chatPage) Help() help.KeyMap {
 			}
 			return core.NewSimpleHelp(shortList, fullList)
 		}
-		if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsBusy() {
-			cancelBinding := key.NewBinding(
+		if p.isBusy() {
+			cancelBinding := keymap.Binding(keymap.ScopeChat+".cancel", key.NewBinding(
 				key.WithKeys("esc", "alt+esc"),
 				key.WithHelp("esc", "cancel"),
-			)
+			))
 			if p.isCanceling {
-				cancelBinding = key.NewBinding(
+				cancelBinding = keymap.Binding(keymap.ScopeChat+".cancel", key.NewBinding(
 					key.WithKeys("esc", "alt+esc"),
 					key.WithHelp("esc", "press again to cancel"),
-				)
+				))
 			}
 			if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.QueuedPrompts(p.session.ID) > 0 {
-				cancelBinding = key.NewBinding(
+				cancelBinding = keymap.Binding(keymap.ScopeChat+".cancel", key.NewBinding(
 					key.WithKeys("esc", "alt+esc"),
 					key.WithHelp("esc", "clear queue"),
-				)
+				))
 			}
 			shortList = append(shortList, cancelBinding)
 			fullList = append(fullList,
@@ -1052,48 +1294,48 @@ func (p *chatPage) Help() help.KeyMap {
 		globalBindings := []key.Binding{}
 		// we are in a session
 		if p.session.ID != "" {
-			tabKey := key.NewBinding(
+			tabKey := keymap.Binding(keymap.ScopeChat+".tab", key.NewBinding(
 				key.WithKeys("tab"),
 				key.WithHelp("tab", "focus chat"),
-			)
+			))
 			if p.focusedPane == PanelTypeChat {
-				tabKey = key.NewBinding(
+				tabKey = keymap.Binding(keymap.ScopeChat+".tab", key.NewBinding(
 					key.WithKeys("tab"),
 					key.WithHelp("tab", "focus editor"),
-				)
+				))
 			}
 			shortList = append(shortList, tabKey)
 			globalBindings = append(globalBindings, tabKey)
 		}
-		commandsBinding := key.NewBinding(
+		commandsBinding := keymap.Binding(keymap.ScopeApp+".commands", key.NewBinding(
 			key.WithKeys("ctrl+p"),
 			key.WithHelp("ctrl+p", "commands"),
-		)
-		modelsBinding := key.NewBinding(
+		))
+		modelsBinding := keymap.Binding(keymap.ScopeApp+".models", key.NewBinding(
 			key.WithKeys("ctrl+m", "ctrl+l"),
 			key.WithHelp("ctrl+l", "models"),
-		)
-		if p.keyboardEnhancements.Flags > 0 {
+		))
+		if p.keyboardEnhancements.Flags > 0 && !keymap.Overridden(keymap.ScopeApp+".models") {
 			// non-zero flags mean we have at least key disambiguation
 			modelsBinding.SetHelp("ctrl+m", "models")
 		}
-		helpBinding := key.NewBinding(
+		helpBinding := keymap.Binding(keymap.ScopeApp+".help", key.NewBinding(
 			key.WithKeys("ctrl+g"),
 			key.WithHelp("ctrl+g", "more"),
-		)
+		))
 		globalBindings = append(globalBindings, commandsBinding, modelsBinding)
 		globalBindings = append(globalBindings,
-			key.NewBinding(
+			keymap.Binding(keymap.ScopeApp+".sessions", key.NewBinding(
 				key.WithKeys("ctrl+s"),
 				key.WithHelp("ctrl+s", "sessions"),
-			),
+			)),
 		)
 		if p.session.ID != "" {
 			globalBindings = append(globalBindings,
-				key.NewBinding(
+				keymap.Binding(keymap.ScopeChat+".new_session", key.NewBinding(
 					key.WithKeys("ctrl+n"),
 					key.WithHelp("ctrl+n", "new sessions"),
-				))
+				)))
 		}
 		shortList = append(shortList,
 			// Commands
@@ -1101,6 +1343,14 @@ func (p *chatPage) Help() help.KeyMap {
 			modelsBinding,
 		)
 		fullList = append(fullList, globalBindings)
+		if p.showTabs() {
+			fullList = append(fullList, []key.Binding{
+				p.keyMap.NextWindow,
+				p.keyMap.PrevWindow,
+				p.keyMap.GoToWindow,
+				p.keyMap.CloseWindow,
+			})
+		}
 
 		switch p.focusedPane {
 		case PanelTypeChat:
@@ -1154,14 +1404,14 @@ func (p *chatPage) Help() help.KeyMap {
 				},
 			)
 		case PanelTypeEditor:
-			newLineBinding := key.NewBinding(
+			newLineBinding := keymap.Binding(keymap.ScopeEditor+".newline", key.NewBinding(
 				key.WithKeys("shift+enter", "ctrl+j"),
 				// "ctrl+j" is a common keybinding for newline in many editors. If
 				// the terminal supports "shift+enter", we substitute the help text
 				// to reflect that.
 				key.WithHelp("ctrl+j", "newline"),
-			)
-			if p.keyboardEnhancements.Flags > 0 {
+			))
+			if p.keyboardEnhancements.Flags > 0 && !keymap.Overridden(keymap.ScopeEditor+".newline") {
 				// Non-zero flags mean we have at least key disambiguation.
 				newLineBinding.SetHelp("shift+enter", newLineBinding.Help().Desc)
 			}
@@ -1169,18 +1419,26 @@ func (p *chatPage) Help() help.KeyMap {
 			fullList = append(fullList,
 				[]key.Binding{
 					newLineBinding,
-					key.NewBinding(
+					keymap.Binding(keymap.ScopeChat+".add_attachment", key.NewBinding(
 						key.WithKeys("ctrl+f"),
 						key.WithHelp("ctrl+f", "add image"),
-					),
+					)),
+					keymap.Binding(keymap.ScopeEditor+".paste_image", key.NewBinding(
+						key.WithKeys("ctrl+v"),
+						key.WithHelp("ctrl+v", "paste image"),
+					)),
 					key.NewBinding(
 						key.WithKeys("@"),
 						key.WithHelp("@", "mention file"),
 					),
-					key.NewBinding(
+					keymap.Binding(keymap.ScopeEditor+".open_editor", key.NewBinding(
 						key.WithKeys("ctrl+o"),
 						key.WithHelp("ctrl+o", "open editor"),
-					),
+					)),
+					keymap.Binding(keymap.ScopeEditor+".plan_mode", key.NewBinding(
+						key.WithKeys("shift+tab"),
+						key.WithHelp("shift+tab", "plan mode"),
+					)),
 				})
 
 			if p.editor.HasAttachments() {
@@ -1202,18 +1460,18 @@ func (p *chatPage) Help() help.KeyMap {
 		}
 		shortList = append(shortList,
 			// Quit
-			key.NewBinding(
+			keymap.Binding(keymap.ScopeApp+".quit", key.NewBinding(
 				key.WithKeys("ctrl+c"),
 				key.WithHelp("ctrl+c", "quit"),
-			),
+			)),
 			// Help
 			helpBinding,
 		)
 		fullList = append(fullList, []key.Binding{
-			key.NewBinding(
+			keymap.Binding(keymap.ScopeApp+".help", key.NewBinding(
 				key.WithKeys("ctrl+g"),
 				key.WithHelp("ctrl+g", "less"),
-			),
+			)),
 		})
 	}
 
@@ -11,6 +11,10 @@ type KeyMap struct {
 	Tab           key.Binding
 	Details       key.Binding
 	OpenSettings  key.Binding
+	NextWindow    key.Binding
+	PrevWindow    key.Binding
+	GoToWindow    key.Binding
+	CloseWindow   key.Binding
 }
 
 func DefaultKeyMap() KeyMap {
@@ -39,5 +43,21 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("ctrl+,"),
 			key.WithHelp("ctrl+,", "settings"),
 		),
+		NextWindow: key.NewBinding(
+			key.WithKeys("alt+.", "ctrl+pgdown"),
+			key.WithHelp("alt+.", "next window"),
+		),
+		PrevWindow: key.NewBinding(
+			key.WithKeys("alt+,", "ctrl+pgup"),
+			key.WithHelp("alt+,", "previous window"),
+		),
+		GoToWindow: key.NewBinding(
+			key.WithKeys("alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6", "alt+7", "alt+8", "alt+9"),
+			key.WithHelp("alt+1-9", "go to window"),
+		),
+		CloseWindow: key.NewBinding(
+			key.WithKeys("alt+w"),
+			key.WithHelp("alt+w", "close window"),
+		),
 	}
 }
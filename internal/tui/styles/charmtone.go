@@ -81,3 +81,107 @@ func NewCharmtoneTheme() *Theme {
 
 	return t
 }
+
+// NewCharmtoneLightTheme returns the charmtone theme for light terminal
+// backgrounds.
+func NewCharmtoneLightTheme() *Theme {
+	t := &Theme{
+		Name:   "charmtone-light",
+		IsDark: false,
+
+		Primary:   charmtone.Charple,
+		Secondary: charmtone.Urchin,
+		Tertiary:  charmtone.Pickle,
+		Accent:    charmtone.Prince,
+
+		BgBase:        charmtone.Butter,
+		BgBaseLighter: charmtone.Salt,
+		BgSubtle:      charmtone.Ash,
+		BgOverlay:     charmtone.Smoke,
+
+		FgBase:      charmtone.Pepper,
+		FgMuted:     charmtone.Oyster,
+		FgHalfMuted: charmtone.Iron,
+		FgSubtle:    charmtone.Squid,
+		FgSelected:  charmtone.Salt,
+
+		Border:      charmtone.Smoke,
+		BorderFocus: charmtone.Charple,
+
+		Success: charmtone.Pickle,
+		Error:   charmtone.Sriracha,
+		Warning: charmtone.Tang,
+		Info:    charmtone.Damson,
+
+		White: charmtone.Butter,
+
+		BlueLight: charmtone.Malibu,
+		BlueDark:  charmtone.Oceania,
+		Blue:      charmtone.Damson,
+
+		Yellow: charmtone.Tang,
+		Citron: charmtone.Cumin,
+
+		Green:      charmtone.Pickle,
+		GreenDark:  charmtone.Gator,
+		GreenLight: charmtone.Guac,
+
+		Red:      charmtone.Sriracha,
+		RedDark:  charmtone.Pom,
+		RedLight: charmtone.Paprika,
+		Cherry:   charmtone.Chili,
+	}
+
+	t.TextSelection = lipgloss.NewStyle().Foreground(charmtone.Salt).Background(charmtone.Charple)
+
+	t.ItemOfflineIcon = lipgloss.NewStyle().Foreground(charmtone.Squid).SetString("●")
+	t.ItemBusyIcon = t.ItemOfflineIcon.Foreground(charmtone.Tang)
+	t.ItemErrorIcon = t.ItemOfflineIcon.Foreground(charmtone.Sriracha)
+	t.ItemOnlineIcon = t.ItemOfflineIcon.Foreground(charmtone.Pickle)
+
+	t.YoloIconFocused = lipgloss.NewStyle().Foreground(charmtone.Pepper).Background(charmtone.Citron).Bold(true).SetString(" ! ")
+	t.YoloIconBlurred = t.YoloIconFocused.Foreground(charmtone.Salt).Background(charmtone.Squid)
+	t.YoloDotsFocused = lipgloss.NewStyle().Foreground(charmtone.Tang).SetString(":::")
+	t.YoloDotsBlurred = t.YoloDotsFocused.Foreground(charmtone.Squid)
+
+	t.AuthBorderSelected = lipgloss.NewStyle().BorderForeground(charmtone.Pickle)
+	t.AuthTextSelected = lipgloss.NewStyle().Foreground(charmtone.Pickle)
+	t.AuthBorderUnselected = lipgloss.NewStyle().BorderForeground(charmtone.Smoke)
+	t.AuthTextUnselected = lipgloss.NewStyle().Foreground(charmtone.Squid)
+
+	// The generated diff and markdown colors are meant for dark backgrounds.
+	t.overrides = &themeOverrides{
+		markdown: []byte(`{
+			"document": {"color": "#201F26"},
+			"heading": {"color": "#007AB8"},
+			"h1": {"color": "#FFFAF1", "background_color": "#6B50FF"},
+			"h6": {"color": "#00A475"},
+			"hr": {"color": "#BFBCC8"},
+			"link": {"color": "#007AB8"},
+			"link_text": {"color": "#7134DD"},
+			"code": {"color": "#C337E0", "background_color": "#F1EFEF"},
+			"code_block": {"chroma": {
+				"text": {"color": "#201F26"},
+				"comment": {"color": "#858392"},
+				"keyword": {"color": "#007AB8"},
+				"name_function": {"color": "#00A475"},
+				"literal_string": {"color": "#D36C64"},
+				"literal_number": {"color": "#7134DD"}
+			}}
+		}`),
+		diff: map[string]DiffLineSpec{
+			"insert_line": {
+				LineNumber: &StyleSpec{Foreground: "#3d8a34", Background: "#d6f0d2"},
+				Symbol:     &StyleSpec{Foreground: "#3d8a34", Background: "#e3f7df"},
+				Code:       &StyleSpec{Background: "#e3f7df"},
+			},
+			"delete_line": {
+				LineNumber: &StyleSpec{Foreground: "#b3403a", Background: "#f6d5d3"},
+				Symbol:     &StyleSpec{Foreground: "#b3403a", Background: "#fbe3e1"},
+				Code:       &StyleSpec{Background: "#fbe3e1"},
+			},
+		},
+	}
+
+	return t
+}
@@ -3,6 +3,7 @@ package styles
 import (
 	"fmt"
 	"image/color"
+	"slices"
 	"strings"
 	"sync"
 
@@ -98,6 +99,9 @@ type Theme struct {
 	AuthBorderUnselected lipgloss.Style
 	AuthTextUnselected   lipgloss.Style
 
+	// overrides are set for themes loaded from files.
+	overrides *themeOverrides
+
 	stylesOnce sync.Once
 	styles     *Styles
 }
@@ -137,7 +141,12 @@ type Styles struct {
 
 func (t *Theme) S() *Styles {
 	t.stylesOnce.Do(func() {
-		t.styles = t.buildStyles()
+		styles, err := t.buildOverriddenStyles()
+		if err != nil {
+			// Theme files are validated when loaded, so this is unexpected.
+			styles = t.buildStyles()
+		}
+		t.styles = styles
 	})
 	return t.styles
 }
@@ -497,6 +506,10 @@ func (t *Theme) buildStyles() *Styles {
 	}
 }
 
+// ThemeChangedMsg is sent after the current theme changed, so components
+// can refresh styles they keep.
+type ThemeChangedMsg struct{}
+
 type Manager struct {
 	themes  map[string]*Theme
 	current *Theme
@@ -530,6 +543,7 @@ func NewManager() *Manager {
 
 	t := NewCharmtoneTheme() // default theme
 	m.Register(t)
+	m.Register(NewCharmtoneLightTheme())
 	m.current = m.themes[t.Name]
 
 	return m
@@ -539,6 +553,12 @@ func (m *Manager) Register(theme *Theme) {
 	m.themes[theme.Name] = theme
 }
 
+// Theme returns the registered theme called name.
+func (m *Manager) Theme(name string) (*Theme, bool) {
+	theme, ok := m.themes[name]
+	return theme, ok
+}
+
 func (m *Manager) Current() *Theme {
 	return m.current
 }
@@ -556,6 +576,7 @@ func (m *Manager) List() []string {
 	for name := range m.themes {
 		names = append(names, name)
 	}
+	slices.Sort(names)
 	return names
 }
 
@@ -0,0 +1,377 @@
+package styles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/glamour/v2/ansi"
+	"github.com/nexora/nexora/internal/tui/exp/diffview"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// ThemeFile is the format of user theme files. Every field is optional;
+// anything not set is taken from the theme it extends.
+type ThemeFile struct {
+	Name   string `json:"name" toml:"name"`
+	IsDark *bool  `json:"is_dark,omitempty" toml:"is_dark"`
+	// Extends names the built-in theme to start from. It defaults to the
+	// dark or light charmtone theme depending on IsDark.
+	Extends string `json:"extends,omitempty" toml:"extends"`
+
+	// Colors maps the Theme color fields in snake_case, e.g. "bg_base", to
+	// hex colors.
+	Colors map[string]string `json:"colors,omitempty" toml:"colors"`
+	// Styles maps the Theme style fields in snake_case, e.g.
+	// "text_selection", to styles.
+	Styles map[string]StyleSpec `json:"styles,omitempty" toml:"styles"`
+	// Diff maps the diffview line kinds ("divider_line", "missing_line",
+	// "equal_line", "insert_line", "delete_line") to their styles.
+	Diff map[string]DiffLineSpec `json:"diff,omitempty" toml:"diff"`
+	// Markdown is a glamour style config merged over the generated one.
+	Markdown json.RawMessage `json:"markdown,omitempty" toml:"-"`
+	// Chroma holds syntax highlighting styles merged over the generated ones,
+	// in the glamour chroma format.
+	Chroma json.RawMessage `json:"chroma,omitempty" toml:"-"`
+}
+
+// StyleSpec describes a lipgloss style in a theme file.
+type StyleSpec struct {
+	Foreground  string `json:"foreground,omitempty" toml:"foreground"`
+	Background  string `json:"background,omitempty" toml:"background"`
+	BorderColor string `json:"border_color,omitempty" toml:"border_color"`
+	Bold        *bool  `json:"bold,omitempty" toml:"bold"`
+	Italic      *bool  `json:"italic,omitempty" toml:"italic"`
+	Underline   *bool  `json:"underline,omitempty" toml:"underline"`
+}
+
+// DiffLineSpec describes the styles of one kind of diff line.
+type DiffLineSpec struct {
+	LineNumber *StyleSpec `json:"line_number,omitempty" toml:"line_number"`
+	Symbol     *StyleSpec `json:"symbol,omitempty" toml:"symbol"`
+	Code       *StyleSpec `json:"code,omitempty" toml:"code"`
+}
+
+// themeOverrides holds the parts of a theme file applied on top of the
+// generated styles.
+type themeOverrides struct {
+	diff     map[string]DiffLineSpec
+	markdown json.RawMessage
+	chroma   json.RawMessage
+}
+
+var themeColorFields = map[string]func(*Theme) *color.Color{
+	"primary":         func(t *Theme) *color.Color { return &t.Primary },
+	"secondary":       func(t *Theme) *color.Color { return &t.Secondary },
+	"tertiary":        func(t *Theme) *color.Color { return &t.Tertiary },
+	"accent":          func(t *Theme) *color.Color { return &t.Accent },
+	"bg_base":         func(t *Theme) *color.Color { return &t.BgBase },
+	"bg_base_lighter": func(t *Theme) *color.Color { return &t.BgBaseLighter },
+	"bg_subtle":       func(t *Theme) *color.Color { return &t.BgSubtle },
+	"bg_overlay":      func(t *Theme) *color.Color { return &t.BgOverlay },
+	"fg_base":         func(t *Theme) *color.Color { return &t.FgBase },
+	"fg_muted":        func(t *Theme) *color.Color { return &t.FgMuted },
+	"fg_half_muted":   func(t *Theme) *color.Color { return &t.FgHalfMuted },
+	"fg_subtle":       func(t *Theme) *color.Color { return &t.FgSubtle },
+	"fg_selected":     func(t *Theme) *color.Color { return &t.FgSelected },
+	"border":          func(t *Theme) *color.Color { return &t.Border },
+	"border_focus":    func(t *Theme) *color.Color { return &t.BorderFocus },
+	"success":         func(t *Theme) *color.Color { return &t.Success },
+	"error":           func(t *Theme) *color.Color { return &t.Error },
+	"warning":         func(t *Theme) *color.Color { return &t.Warning },
+	"info":            func(t *Theme) *color.Color { return &t.Info },
+	"white":           func(t *Theme) *color.Color { return &t.White },
+	"blue_light":      func(t *Theme) *color.Color { return &t.BlueLight },
+	"blue_dark":       func(t *Theme) *color.Color { return &t.BlueDark },
+	"blue":            func(t *Theme) *color.Color { return &t.Blue },
+	"yellow":          func(t *Theme) *color.Color { return &t.Yellow },
+	"citron":          func(t *Theme) *color.Color { return &t.Citron },
+	"green":           func(t *Theme) *color.Color { return &t.Green },
+	"green_dark":      func(t *Theme) *color.Color { return &t.GreenDark },
+	"green_light":     func(t *Theme) *color.Color { return &t.GreenLight },
+	"red":             func(t *Theme) *color.Color { return &t.Red },
+	"red_dark":        func(t *Theme) *color.Color { return &t.RedDark },
+	"red_light":       func(t *Theme) *color.Color { return &t.RedLight },
+	"cherry":          func(t *Theme) *color.Color { return &t.Cherry },
+}
+
+var themeStyleFields = map[string]func(*Theme) *lipgloss.Style{
+	"text_selection":         func(t *Theme) *lipgloss.Style { return &t.TextSelection },
+	"item_offline_icon":      func(t *Theme) *lipgloss.Style { return &t.ItemOfflineIcon },
+	"item_busy_icon":         func(t *Theme) *lipgloss.Style { return &t.ItemBusyIcon },
+	"item_error_icon":        func(t *Theme) *lipgloss.Style { return &t.ItemErrorIcon },
+	"item_online_icon":       func(t *Theme) *lipgloss.Style { return &t.ItemOnlineIcon },
+	"yolo_icon_focused":      func(t *Theme) *lipgloss.Style { return &t.YoloIconFocused },
+	"yolo_icon_blurred":      func(t *Theme) *lipgloss.Style { return &t.YoloIconBlurred },
+	"yolo_dots_focused":      func(t *Theme) *lipgloss.Style { return &t.YoloDotsFocused },
+	"yolo_dots_blurred":      func(t *Theme) *lipgloss.Style { return &t.YoloDotsBlurred },
+	"auth_border_selected":   func(t *Theme) *lipgloss.Style { return &t.AuthBorderSelected },
+	"auth_text_selected":     func(t *Theme) *lipgloss.Style { return &t.AuthTextSelected },
+	"auth_border_unselected": func(t *Theme) *lipgloss.Style { return &t.AuthBorderUnselected },
+	"auth_text_unselected":   func(t *Theme) *lipgloss.Style { return &t.AuthTextUnselected },
+}
+
+var diffLineFields = map[string]func(*diffview.Style) *diffview.LineStyle{
+	"divider_line": func(s *diffview.Style) *diffview.LineStyle { return &s.DividerLine },
+	"missing_line": func(s *diffview.Style) *diffview.LineStyle { return &s.MissingLine },
+	"equal_line":   func(s *diffview.Style) *diffview.LineStyle { return &s.EqualLine },
+	"insert_line":  func(s *diffview.Style) *diffview.LineStyle { return &s.InsertLine },
+	"delete_line":  func(s *diffview.Style) *diffview.LineStyle { return &s.DeleteLine },
+}
+
+// builtinThemes are the themes user themes can extend.
+var builtinThemes = map[string]func() *Theme{
+	"charmtone":       NewCharmtoneTheme,
+	"charmtone-light": NewCharmtoneLightTheme,
+}
+
+// LoadThemeFile reads a theme from a .json or .toml file.
+func LoadThemeFile(path string) (*Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file ThemeFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &file)
+	case ".toml":
+		file, err = decodeTOMLThemeFile(data)
+	default:
+		return nil, fmt.Errorf("unsupported theme file %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse theme %s: %w", path, err)
+	}
+	if file.Name == "" {
+		file.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	theme, err := file.Theme()
+	if err != nil {
+		return nil, fmt.Errorf("theme %s: %w", path, err)
+	}
+	return theme, nil
+}
+
+// decodeTOMLThemeFile decodes a theme file in TOML. The glamour configs of
+// Markdown and Chroma only have a JSON schema, so they are converted to JSON.
+func decodeTOMLThemeFile(data []byte) (ThemeFile, error) {
+	var file ThemeFile
+	if err := toml.Unmarshal(data, &file); err != nil {
+		return ThemeFile{}, err
+	}
+	var glamour struct {
+		Markdown map[string]any `toml:"markdown"`
+		Chroma   map[string]any `toml:"chroma"`
+	}
+	if err := toml.Unmarshal(data, &glamour); err != nil {
+		return ThemeFile{}, err
+	}
+	var err error
+	if glamour.Markdown != nil {
+		if file.Markdown, err = json.Marshal(glamour.Markdown); err != nil {
+			return ThemeFile{}, err
+		}
+	}
+	if glamour.Chroma != nil {
+		if file.Chroma, err = json.Marshal(glamour.Chroma); err != nil {
+			return ThemeFile{}, err
+		}
+	}
+	return file, nil
+}
+
+// Theme builds the theme described by the file.
+func (f ThemeFile) Theme() (*Theme, error) {
+	extends := f.Extends
+	if extends == "" {
+		extends = "charmtone"
+		if f.IsDark != nil && !*f.IsDark {
+			extends = "charmtone-light"
+		}
+	}
+	newBase, ok := builtinThemes[extends]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme to extend %q", extends)
+	}
+	t := newBase()
+	t.Name = f.Name
+	if f.IsDark != nil {
+		t.IsDark = *f.IsDark
+	}
+
+	for name, hex := range f.Colors {
+		field, ok := themeColorFields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown color %q", name)
+		}
+		c, err := parseThemeColor(hex)
+		if err != nil {
+			return nil, fmt.Errorf("color %s: %w", name, err)
+		}
+		*field(t) = c
+	}
+	for name, spec := range f.Styles {
+		field, ok := themeStyleFields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown style %q", name)
+		}
+		s, err := spec.apply(*field(t))
+		if err != nil {
+			return nil, fmt.Errorf("style %s: %w", name, err)
+		}
+		*field(t) = s
+	}
+
+	overrides := &themeOverrides{markdown: f.Markdown, chroma: f.Chroma}
+	if t.overrides != nil {
+		overrides.diff = t.overrides.diff
+	}
+	for name, spec := range f.Diff {
+		if _, ok := diffLineFields[name]; !ok {
+			return nil, fmt.Errorf("unknown diff line %q", name)
+		}
+		if overrides.diff == nil {
+			overrides.diff = make(map[string]DiffLineSpec)
+		}
+		overrides.diff[name] = spec
+	}
+	t.overrides = overrides
+	// Build once to report invalid styles now rather than when rendering.
+	if _, err := t.buildOverriddenStyles(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// LoadThemes loads the theme files in dirs. Later directories take
+// precedence for themes with the same name. Files that fail to load are
+// reported and skipped.
+func LoadThemes(dirs ...string) ([]*Theme, error) {
+	byName := map[string]*Theme{}
+	var names []string
+	var errs []error
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		for _, entry := range entries {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if entry.IsDir() || (ext != ".json" && ext != ".toml") {
+				continue
+			}
+			theme, err := LoadThemeFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if _, ok := byName[theme.Name]; !ok {
+				names = append(names, theme.Name)
+			}
+			byName[theme.Name] = theme
+		}
+	}
+	themes := make([]*Theme, 0, len(names))
+	for _, name := range names {
+		themes = append(themes, byName[name])
+	}
+	return themes, errors.Join(errs...)
+}
+
+func (s StyleSpec) apply(style lipgloss.Style) (lipgloss.Style, error) {
+	if s.Foreground != "" {
+		c, err := parseThemeColor(s.Foreground)
+		if err != nil {
+			return style, err
+		}
+		style = style.Foreground(c)
+	}
+	if s.Background != "" {
+		c, err := parseThemeColor(s.Background)
+		if err != nil {
+			return style, err
+		}
+		style = style.Background(c)
+	}
+	if s.BorderColor != "" {
+		c, err := parseThemeColor(s.BorderColor)
+		if err != nil {
+			return style, err
+		}
+		style = style.BorderForeground(c)
+	}
+	if s.Bold != nil {
+		style = style.Bold(*s.Bold)
+	}
+	if s.Italic != nil {
+		style = style.Italic(*s.Italic)
+	}
+	if s.Underline != nil {
+		style = style.Underline(*s.Underline)
+	}
+	return style, nil
+}
+
+// buildOverriddenStyles builds the styles and applies the theme file
+// overrides to them.
+func (t *Theme) buildOverriddenStyles() (*Styles, error) {
+	s := t.buildStyles()
+	if t.overrides == nil {
+		return s, nil
+	}
+	for name, spec := range t.overrides.diff {
+		line := diffLineFields[name](&s.Diff)
+		for _, part := range []struct {
+			spec  *StyleSpec
+			style *lipgloss.Style
+		}{
+			{spec.LineNumber, &line.LineNumber},
+			{spec.Symbol, &line.Symbol},
+			{spec.Code, &line.Code},
+		} {
+			if part.spec == nil {
+				continue
+			}
+			style, err := part.spec.apply(*part.style)
+			if err != nil {
+				return nil, fmt.Errorf("diff %s: %w", name, err)
+			}
+			*part.style = style
+		}
+	}
+	// buildStyles returns a fresh config, so merging into it is safe.
+	if len(t.overrides.markdown) > 0 {
+		if err := json.Unmarshal(t.overrides.markdown, &s.Markdown); err != nil {
+			return nil, fmt.Errorf("markdown: %w", err)
+		}
+	}
+	if len(t.overrides.chroma) > 0 {
+		if s.Markdown.CodeBlock.Chroma == nil {
+			s.Markdown.CodeBlock.Chroma = &ansi.Chroma{}
+		}
+		if err := json.Unmarshal(t.overrides.chroma, s.Markdown.CodeBlock.Chroma); err != nil {
+			return nil, fmt.Errorf("chroma: %w", err)
+		}
+	}
+	return s, nil
+}
+
+func parseThemeColor(hex string) (color.Color, error) {
+	h := strings.TrimPrefix(hex, "#")
+	if len(h) == 3 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	if len(h) != 6 || strings.Trim(strings.ToLower(h), "0123456789abcdef") != "" {
+		return nil, fmt.Errorf("invalid color %q, expected #rrggbb", hex)
+	}
+	return ParseHex("#" + h), nil
+}
@@ -0,0 +1,128 @@
+package styles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeTOMLThemeFile(t *testing.T) {
+	t.Parallel()
+
+	file, err := decodeTOMLThemeFile([]byte(`
+name = "nord \"arctic\"" # comment
+is_dark = true
+
+[colors]
+primary = "#88c0d0"
+"bg_base" = '#2e3440'
+
+[styles]
+text_selection = { foreground = "#eceff4", bold = true }
+
+[diff.insert_line]
+code = { background = "#3b4252" }
+
+[markdown.h1]
+prefix = "# "
+margin = 2
+`))
+	require.NoError(t, err)
+	require.Equal(t, `nord "arctic"`, file.Name)
+	require.True(t, *file.IsDark)
+	require.Equal(t, map[string]string{"primary": "#88c0d0", "bg_base": "#2e3440"}, file.Colors)
+	require.Equal(t, "#eceff4", file.Styles["text_selection"].Foreground)
+	require.True(t, *file.Styles["text_selection"].Bold)
+	require.Equal(t, "#3b4252", file.Diff["insert_line"].Code.Background)
+	require.JSONEq(t, `{"h1": {"prefix": "# ", "margin": 2}}`, string(file.Markdown))
+	require.Nil(t, file.Chroma)
+
+	_, err = decodeTOMLThemeFile([]byte("name = \"a\"\nname = \"b\""))
+	require.Error(t, err)
+	_, err = decodeTOMLThemeFile([]byte("[colors]\nprimary = [1, 2"))
+	require.Error(t, err)
+}
+
+func TestLoadThemes(t *testing.T) {
+	t.Parallel()
+
+	configDir, dataDir := t.TempDir(), t.TempDir()
+	writeFile := func(dir, name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	writeFile(configDir, "nord.toml", `
+is_dark = true
+[colors]
+primary = "#88c0d0"
+[diff.insert_line]
+code = { background = "#3b4252" }
+[chroma.keyword]
+color = "#81a1c1"
+`)
+	writeFile(configDir, "paper.json", `{
+  "name": "paper",
+  "is_dark": false,
+  "colors": {"fg_base": "#111"},
+  "styles": {"item_online_icon": {"foreground": "#00aa00"}},
+  "markdown": {"h1": {"prefix": "> "}}
+}`)
+	writeFile(dataDir, "paper.json", `{"name": "paper", "extends": "charmtone-light", "colors": {"fg_base": "#222222"}}`)
+	writeFile(dataDir, "broken.json", `{"colors": {"not_a_color": "#fff"}}`)
+	writeFile(dataDir, "notes.txt", "ignored")
+
+	themes, err := LoadThemes(configDir, dataDir, filepath.Join(dataDir, "missing"))
+	require.ErrorContains(t, err, `unknown color "not_a_color"`)
+	require.Len(t, themes, 2)
+
+	nord := themes[0]
+	require.Equal(t, "nord", nord.Name)
+	require.True(t, nord.IsDark)
+	require.Equal(t, "#88c0d0", ColorToHex(nord.Primary))
+	s := nord.S()
+	require.Equal(t, "#3b4252", ColorToHex(s.Diff.InsertLine.Code.GetBackground()))
+	require.Equal(t, "#81a1c1", *s.Markdown.CodeBlock.Chroma.Keyword.Color)
+	// Other chroma styles keep their generated values.
+	require.Equal(t, *NewCharmtoneTheme().S().Markdown.CodeBlock.Chroma.Comment.Color, *s.Markdown.CodeBlock.Chroma.Comment.Color)
+
+	// The data directory takes precedence.
+	paper := themes[1]
+	require.Equal(t, "paper", paper.Name)
+	require.False(t, paper.IsDark)
+	require.Equal(t, "#222222", ColorToHex(paper.FgBase))
+	require.Equal(t, "●", paper.ItemOnlineIcon.Value())
+}
+
+func TestThemeFileErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := ThemeFile{Name: "x", Extends: "nope"}.Theme()
+	require.ErrorContains(t, err, `unknown theme to extend "nope"`)
+	_, err = ThemeFile{Name: "x", Colors: map[string]string{"primary": "blue"}}.Theme()
+	require.ErrorContains(t, err, "invalid color")
+	_, err = ThemeFile{Name: "x", Markdown: []byte(`{"h1": 1}`)}.Theme()
+	require.ErrorContains(t, err, "markdown")
+
+	theme, err := ThemeFile{Name: "x", Markdown: []byte(`{"h1": {"color": "#123456"}}`)}.Theme()
+	require.NoError(t, err)
+	md := theme.S().Markdown
+	require.Equal(t, "#123456", *md.H1.Color)
+	require.Equal(t, " ", md.H1.Prefix, "unset fields keep their generated values")
+	// Overrides must not leak into the built-in theme.
+	require.NotEqual(t, "#123456", *NewCharmtoneTheme().S().Markdown.H1.Color)
+}
+
+func TestCharmtoneLightTheme(t *testing.T) {
+	t.Parallel()
+
+	theme := NewCharmtoneLightTheme()
+	require.False(t, theme.IsDark)
+	s, err := theme.buildOverriddenStyles()
+	require.NoError(t, err)
+	require.Equal(t, "#201F26", *s.Markdown.Document.Color)
+	require.Equal(t, "#e3f7df", ColorToHex(s.Diff.InsertLine.Code.GetBackground()))
+
+	m := NewManager()
+	require.Equal(t, []string{"charmtone", "charmtone-light"}, m.List())
+}
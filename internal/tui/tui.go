@@ -1,8 +1,10 @@
 package tui
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"regexp"
 	"slices"
@@ -18,6 +20,7 @@ import (
 	"github.com/nexora/nexora/internal/permission"
 	"github.com/nexora/nexora/internal/pubsub"
 	"github.com/nexora/nexora/internal/stringext"
+	"github.com/nexora/nexora/internal/task"
 	cmpChat "github.com/nexora/nexora/internal/tui/components/chat"
 	"github.com/nexora/nexora/internal/tui/components/chat/splash"
 	"github.com/nexora/nexora/internal/tui/components/completions"
@@ -25,13 +28,18 @@ import (
 	"github.com/nexora/nexora/internal/tui/components/core/status"
 	"github.com/nexora/nexora/internal/tui/components/dialogs"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/about"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/agents"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/commands"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/filepicker"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/models"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/permissions"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/plan"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/quit"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/rewind"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/sessions"
 	"github.com/nexora/nexora/internal/tui/components/dialogs/settings"
+	"github.com/nexora/nexora/internal/tui/components/dialogs/themes"
+	"github.com/nexora/nexora/internal/tui/keymap"
 	"github.com/nexora/nexora/internal/tui/page"
 	"github.com/nexora/nexora/internal/tui/page/chat"
 	"github.com/nexora/nexora/internal/tui/styles"
@@ -100,6 +108,14 @@ type appModel struct {
 	// terminal.
 	sendProgressBar bool
 
+	// darkBackground reports whether the terminal background is dark. It
+	// picks the theme when the configured theme is "auto".
+	darkBackground bool
+	// themeErr holds errors from loading theme files, reported on start.
+	themeErr error
+	// keybindingsErr holds invalid keybindings overrides, reported on start.
+	keybindingsErr error
+
 	// QueryVersion instructs the TUI to query for the terminal version when it
 	// starts.
 	QueryVersion bool
@@ -111,11 +127,7 @@ func (a appModel) Init() tea.Cmd {
 
 	// Check if models need setup and show model dialog if necessary
 	if a.app.Config().ModelsNeedSetup() {
-		cmds = append(cmds, func() tea.Msg {
-			return dialogs.OpenDialogMsg{
-				Model: models.NewModelDialogCmp(),
-			}
-		})
+		cmds = append(cmds, a.openModelDialog())
 	}
 
 	item, ok := a.pages[a.currentPage]
@@ -132,6 +144,15 @@ func (a appModel) Init() tea.Cmd {
 	if a.QueryVersion {
 		cmds = append(cmds, tea.RequestTerminalVersion)
 	}
+	if a.configuredTheme() == themes.AutoTheme {
+		cmds = append(cmds, tea.RequestBackgroundColor)
+	}
+	if a.themeErr != nil {
+		cmds = append(cmds, util.ReportWarn("Some themes failed to load: "+a.themeErr.Error()))
+	}
+	if a.keybindingsErr != nil {
+		cmds = append(cmds, util.ReportError(a.keybindingsErr))
+	}
 
 	return tea.Batch(cmds...)
 }
@@ -168,7 +189,7 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a, nil
 	case tea.KeyboardEnhancementsMsg:
 		// A non-zero value means we have key disambiguation support.
-		if msg.Flags > 0 {
+		if msg.Flags > 0 && !keymap.Overridden(keymap.ScopeApp+".models") {
 			a.keyMap.Models.SetHelp("ctrl+m", "models")
 		}
 		for id, page := range a.pages {
@@ -276,12 +297,34 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
-	case commands.SwitchModelMsg:
+	// Themes
+	case tea.BackgroundColorMsg:
+		a.darkBackground = msg.IsDark()
+		if a.configuredTheme() == themes.AutoTheme {
+			return a, a.applyTheme(themes.AutoTheme)
+		}
+		return a, nil
+	case commands.SwitchThemeMsg:
 		return a, util.CmdHandler(
 			dialogs.OpenDialogMsg{
-				Model: models.NewModelDialogCmp(),
+				Model: themes.NewThemesDialog(a.configuredTheme(), styles.DefaultManager().List()),
 			},
 		)
+	case themes.ThemePreviewMsg:
+		return a, a.applyTheme(msg.Name)
+	case themes.ThemeSelectedMsg:
+		if err := a.app.Config().SetTheme(msg.Name); err != nil {
+			return a, util.ReportError(err)
+		}
+		return a, tea.Batch(a.applyTheme(msg.Name), util.ReportInfo("Theme set to "+msg.Name))
+	case commands.ShowAgentsMsg:
+		return a, util.CmdHandler(
+			dialogs.OpenDialogMsg{
+				Model: agents.NewAgentsDialog(a.app.Config().CustomAgents()),
+			},
+		)
+	case commands.SwitchModelMsg:
+		return a, a.openModelDialog()
 	// Compact
 	case commands.CompactMsg:
 		return a, func() tea.Msg {
@@ -291,6 +334,34 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return nil
 		}
+	// Rewind
+	case commands.RewindMsg:
+		if a.app.AgentCoordinator != nil && a.app.AgentCoordinator.IsSessionBusy(msg.SessionID) {
+			return a, util.ReportWarn("Agent is busy, please wait...")
+		}
+		return a, func() tea.Msg {
+			plan, err := a.app.PlanRewind(context.Background(), msg.SessionID, msg.Turns)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			if len(plan.Changes) == 0 {
+				return util.ReportInfo("No files to restore")()
+			}
+			title := "Undo Last Turn"
+			if msg.Turns > 1 {
+				title = fmt.Sprintf("Rewind %d Turns", msg.Turns)
+			}
+			return dialogs.OpenDialogMsg{
+				Model: rewind.NewRewindDialog(title, plan),
+			}
+		}
+	case rewind.ApplyRewindMsg:
+		return a, func() tea.Msg {
+			if err := a.app.ApplyRewind(context.Background(), msg.Plan, msg.Force); err != nil {
+				return util.ReportError(err)()
+			}
+			return util.ReportInfo(fmt.Sprintf("Restored %d file(s)", len(msg.Plan.Changes)))()
+		}
 	case commands.QuitMsg:
 		return a, util.CmdHandler(dialogs.OpenDialogMsg{
 			Model: quit.NewQuitDialog(),
@@ -307,7 +378,7 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a, a.handleWindowResize(a.wWidth, a.wHeight)
 	// Model Switch
 	case models.ModelSelectedMsg:
-		if a.app.AgentCoordinator.IsBusy() {
+		if a.app.AgentCoordinator.IsSessionBusy(a.selectedSessionID) {
 			return a, util.ReportWarn("Agent is busy, please wait...")
 		}
 
@@ -342,13 +413,36 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.pages[a.currentPage] = updated
 
 		return a, itemCmd
-	case pubsub.Event[permission.PermissionRequest]:
+	case pubsub.Event[task.Plan]:
+		if msg.Type != pubsub.CreatedEvent || msg.Payload.SessionID != a.selectedSessionID {
+			return a, nil
+		}
 		return a, util.CmdHandler(dialogs.OpenDialogMsg{
-			Model: permissions.NewPermissionDialogCmp(msg.Payload, &permissions.Options{
-				DiffMode: config.Get().Options.TUI.DiffMode,
-			}),
+			Model: plan.NewPlanDialog(msg.Payload),
 		})
+	case pubsub.Event[permission.PermissionRequest]:
+		// Requests from a window in the background name it, so that the
+		// user knows what they are answering.
+		var badge string
+		if i, window, ok := a.app.Windows.WindowFor(context.Background(), msg.Payload.SessionID); ok {
+			a.app.Windows.SetPermissionPending(window.ID, true)
+			if window.ID != a.selectedSessionID {
+				badge = fmt.Sprintf("Window %d: %s", i+1, cmp.Or(window.Title, "New Session"))
+			}
+		}
+		return a, tea.Batch(
+			util.CmdHandler(chat.WindowsChangedMsg{}),
+			util.CmdHandler(dialogs.OpenDialogMsg{
+				Model: permissions.NewPermissionDialogCmp(msg.Payload, &permissions.Options{
+					DiffMode: config.Get().Options.TUI.DiffMode,
+					Window:   badge,
+				}),
+			}),
+		)
 	case permissions.PermissionResponseMsg:
+		if _, window, ok := a.app.Windows.WindowFor(context.Background(), msg.Permission.SessionID); ok {
+			a.app.Windows.SetPermissionPending(window.ID, false)
+		}
 		switch msg.Action {
 		case permissions.PermissionAllow:
 			a.app.Permissions.Grant(msg.Permission)
@@ -357,7 +451,7 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case permissions.PermissionDeny:
 			a.app.Permissions.Deny(msg.Permission)
 		}
-		return a, nil
+		return a, util.CmdHandler(chat.WindowsChangedMsg{})
 	case splash.OnboardingCompleteMsg:
 		item, ok := a.pages[a.currentPage]
 		if !ok {
@@ -487,6 +581,95 @@ func (a *appModel) handleWindowResize(width, height int) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+// loadThemes registers the user's theme files and applies the configured
+// theme. "auto" is applied once the terminal background is known.
+func (a *appModel) loadThemes() {
+	loaded, err := styles.LoadThemes(config.ThemeDirs()...)
+	if err != nil {
+		slog.Warn("Failed to load themes", "error", err)
+		a.themeErr = err
+	}
+	manager := styles.DefaultManager()
+	for _, theme := range loaded {
+		manager.Register(theme)
+	}
+	if name := a.configuredTheme(); name != themes.AutoTheme {
+		a.applyTheme(name)
+	}
+}
+
+// configuredTheme returns the theme set in the config, "auto" if unset.
+func (a *appModel) configuredTheme() string {
+	opts := a.app.Config().Options
+	if opts == nil || opts.TUI == nil || opts.TUI.Theme == "" {
+		return themes.AutoTheme
+	}
+	return opts.TUI.Theme
+}
+
+// autoTheme returns the theme matching the terminal background.
+func (a *appModel) autoTheme() string {
+	dark, light := "charmtone", "charmtone-light"
+	if opts := a.app.Config().Options; opts != nil && opts.TUI != nil {
+		dark = cmp.Or(opts.TUI.DarkTheme, dark)
+		light = cmp.Or(opts.TUI.LightTheme, light)
+	}
+	if a.darkBackground {
+		return dark
+	}
+	return light
+}
+
+// applyTheme switches to the named theme and lets components refresh
+// their styles.
+func (a *appModel) applyTheme(name string) tea.Cmd {
+	if name == themes.AutoTheme {
+		name = a.autoTheme()
+	}
+	manager := styles.DefaultManager()
+	if manager.Current().Name == name {
+		return nil
+	}
+	if err := manager.SetTheme(name); err != nil {
+		return util.ReportError(err)
+	}
+
+	var cmds []tea.Cmd
+	s, cmd := a.status.Update(styles.ThemeChangedMsg{})
+	a.status = s.(status.StatusCmp)
+	cmds = append(cmds, cmd)
+	for id, p := range a.pages {
+		updated, cmd := p.Update(styles.ThemeChangedMsg{})
+		a.pages[id] = updated
+		cmds = append(cmds, cmd)
+	}
+	d, cmd := a.dialog.Update(styles.ThemeChangedMsg{})
+	a.dialog = d.(dialogs.DialogCmp)
+	cmds = append(cmds, cmd)
+	if a.wWidth > 0 {
+		cmds = append(cmds, a.handleWindowResize(a.wWidth, a.wHeight))
+	}
+	return tea.Batch(cmds...)
+}
+
+// openModelDialog opens the models dialog and then loads the measured model
+// speeds it shows, so the dialog doesn't wait on the database.
+func (a *appModel) openModelDialog() tea.Cmd {
+	open := util.CmdHandler(dialogs.OpenDialogMsg{Model: models.NewModelDialogCmp()})
+	if a.app.ModelSpeeds == nil {
+		return open
+	}
+	speeds := a.app.ModelSpeeds
+	return tea.Sequence(open, func() tea.Msg {
+		all, err := speeds.All(context.Background())
+		if err != nil {
+			slog.Warn("Failed to load model speeds", "error", err)
+			return nil
+		}
+		return models.SpeedsLoadedMsg{Speeds: all}
+	})
+}
+
 // handleKeyPressMsg processes keyboard input and routes to appropriate handlers.
 func (a *appModel) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 	// Check this first as the user should be able to quit no matter what.
@@ -548,9 +731,7 @@ func (a *appModel) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 		if a.dialog.HasDialogs() {
 			return nil
 		}
-		return util.CmdHandler(dialogs.OpenDialogMsg{
-			Model: models.NewModelDialogCmp(),
-		})
+		return a.openModelDialog()
 	case key.Matches(msg, a.keyMap.Sessions):
 		// if the app is not configured show no sessions
 		if !a.isConfigured {
@@ -597,7 +778,7 @@ func (a *appModel) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 			Model: about.NewAboutDialog(),
 		})
 	case key.Matches(msg, a.keyMap.Suspend):
-		if a.app.AgentCoordinator != nil && a.app.AgentCoordinator.IsBusy() {
+		if a.app.AgentCoordinator != nil && a.app.AgentCoordinator.IsSessionBusy(a.selectedSessionID) {
 			return util.ReportWarn("Agent is busy, please wait...")
 		}
 		return tea.Suspend
@@ -615,7 +796,7 @@ func (a *appModel) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 
 // moveToPage handles navigation between different pages in the application.
 func (a *appModel) moveToPage(pageID page.PageID) tea.Cmd {
-	if a.app.AgentCoordinator.IsBusy() {
+	if a.app.AgentCoordinator.IsSessionBusy(a.selectedSessionID) {
 		// Agent busy navigation - temporarily blocking page transitions during agent operations
 		// Future enhancement: Consider queue or allow certain safe page transitions during agent work
 		return util.ReportWarn("Agent is busy, please wait...")
@@ -718,7 +899,7 @@ func (a *appModel) View() tea.View {
 	view.Content = canvas.Render()
 	view.Cursor = cursor
 
-	if a.sendProgressBar && a.app != nil && a.app.AgentCoordinator != nil && a.app.AgentCoordinator.IsBusy() {
+	if a.sendProgressBar && a.app != nil && a.app.AgentCoordinator != nil && a.app.AgentCoordinator.IsSessionBusy(a.selectedSessionID) {
 		// HACK: use a random percentage to prevent ghostty from hiding it
 		// after a timeout.
 		view.ProgressBar = tea.NewProgressBar(tea.ProgressBarIndeterminate, rand.Intn(100))
@@ -749,8 +930,14 @@ func handleMCPToolsEvent(ctx context.Context, name string) tea.Cmd {
 
 // New creates and initializes a new TUI application model.
 func New(app *app.App) *appModel {
+	// Key maps are built by the constructors below, so overrides must be
+	// loaded first.
+	keybindingsErr := loadKeybindings(app.Config().Keybindings)
+	if keybindingsErr != nil {
+		slog.Warn("Ignoring keybindings", "error", keybindingsErr)
+	}
 	chatPage := chat.New(app)
-	keyMap := DefaultKeyMap()
+	keyMap := keymap.Apply(keymap.ScopeApp, DefaultKeyMap())
 	keyMap.pageBindings = chatPage.Bindings()
 
 	model := &appModel{
@@ -766,7 +953,11 @@ func New(app *app.App) *appModel {
 
 		dialog:      dialogs.NewDialogCmp(),
 		completions: completions.New(),
+
+		darkBackground: true,
+		keybindingsErr: keybindingsErr,
 	}
+	model.loadThemes()
 
 	return model
 }